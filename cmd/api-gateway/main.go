@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/service"
+	"github.com/VincentArjuna/RexiErp/internal/gateway"
+	"github.com/VincentArjuna/RexiErp/internal/shared/cache"
+	"github.com/VincentArjuna/RexiErp/internal/shared/middleware"
+	bootstrap "github.com/VincentArjuna/RexiErp/internal/shared/service"
+)
+
+func main() {
+	// The JWT settings of the shared configuration must match the auth
+	// service's
+	svc, err := bootstrap.New(bootstrap.Options{
+		Name:          "api-gateway",
+		Port:          8080,
+		PreMiddleware: []gin.HandlerFunc{gateway.StripIdentityHeaders},
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize API gateway")
+	}
+	cfg := svc.Config
+
+	routesPath := os.Getenv("GATEWAY_CONFIG_PATH")
+	if routesPath == "" {
+		routesPath = "configs/gateway/routes.yaml"
+	}
+	routes, err := gateway.LoadConfig(routesPath)
+	if err != nil {
+		svc.Logger.WithError(err).Fatal("Failed to load gateway routes")
+	}
+
+	// Revoked sessions and rate limits are shared with the auth service and
+	// the other gateway replicas through Redis
+	redisCache, err := cache.NewRedisCache(&cfg.Redis, svc.Logger)
+	if err != nil {
+		svc.Logger.WithError(err).Fatal("Failed to connect to Redis")
+	}
+	svc.OnShutdown("redis", func(context.Context) error { return redisCache.Close() })
+	svc.Health.AddCheck("redis", func(context.Context) error { return redisCache.HealthCheck() })
+
+	var limiter middleware.RateLimiter
+	if cfg.RateLimit.Enabled {
+		limiter = middleware.NewRedisRateLimiter(redisCache)
+	}
+
+	jwtService := service.NewJWTService(
+		cfg.JWT.Secret,
+		cfg.JWT.Issuer,
+		cfg.JWT.AccessTokenTTL,
+		time.Duration(cfg.JWT.RefreshTokenDays)*24*time.Hour,
+	)
+
+	gw, err := gateway.NewGateway(routes, jwtService, redisCache, limiter, svc.Logger)
+	if err != nil {
+		svc.Logger.WithError(err).Fatal("Failed to create gateway")
+	}
+	svc.Go("upstream-health-checks", gw.RunHealthChecks)
+
+	// Everything the service does not serve itself is proxied
+	svc.Router.GET("/health/upstreams", gw.Health)
+	svc.Router.NoRoute(gin.WrapH(gw))
+
+	svc.Logger.WithField("routes", len(routes.Routes)).Info("Gateway routes loaded")
+
+	if err := svc.Run(); err != nil {
+		svc.Logger.WithError(err).Fatal("API gateway stopped with errors")
+	}
+}
@@ -4,12 +4,10 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 
 	"github.com/VincentArjuna/RexiErp/internal/authentication/config"
@@ -17,72 +15,176 @@ import (
 	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
 	"github.com/VincentArjuna/RexiErp/internal/authentication/repository"
 	"github.com/VincentArjuna/RexiErp/internal/authentication/service"
+	"github.com/VincentArjuna/RexiErp/internal/shared/auditchain"
 	"github.com/VincentArjuna/RexiErp/internal/shared/cache"
 	"github.com/VincentArjuna/RexiErp/internal/shared/database"
 	"github.com/VincentArjuna/RexiErp/internal/shared/middleware"
+	"github.com/VincentArjuna/RexiErp/internal/shared/outbox"
+	"github.com/VincentArjuna/RexiErp/internal/shared/region"
+	bootstrap "github.com/VincentArjuna/RexiErp/internal/shared/service"
+	"github.com/VincentArjuna/RexiErp/internal/shared/settings"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
 )
 
 func main() {
-	// Initialize logger
-	logger := logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{})
-
-	// Set log level from environment
-	logLevel := os.Getenv("LOG_LEVEL")
-	if logLevel == "" {
-		logLevel = "info"
-	}
-	level, err := logrus.ParseLevel(logLevel)
-	if err != nil {
-		level = logrus.InfoLevel
-	}
-	logger.SetLevel(level)
-
 	// Load configuration
 	cfg, err := config.LoadAuthServiceConfig()
 	if err != nil {
-		logger.WithError(err).Fatal("Failed to load configuration")
+		logrus.WithError(err).Fatal("Failed to load configuration")
 	}
 
-	logger.WithFields(logrus.Fields{
-		"app_name":    cfg.App.Name,
-		"version":     cfg.App.Version,
-		"environment": cfg.App.Environment,
-		"debug":       cfg.App.Debug,
-	}).Info("Starting authentication service")
-
-	// Set Gin mode based on environment
-	if cfg.App.Environment == "production" {
-		gin.SetMode(gin.ReleaseMode)
-	} else {
-		gin.SetMode(gin.DebugMode)
+	svc, err := bootstrap.New(bootstrap.Options{
+		Name:   "authentication-service",
+		Port:   8001,
+		Config: &cfg.Config,
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize authentication service")
 	}
+	logger := svc.Logger
 
 	// Initialize database connection
-	db, err := database.NewDatabase(&cfg.Database, logger)
+	db, err := database.NewDatabase(&cfg.Databases.Master, logger)
 	if err != nil {
 		logger.WithError(err).Fatal("Failed to connect to database")
 	}
-	defer db.Close()
+	svc.OnShutdown("database", func(context.Context) error { return db.Close() })
+	for i := range cfg.Databases.Replicas {
+		if err := db.AddReadReplica(&cfg.Databases.Replicas[i]); err != nil {
+			logger.WithError(err).WithField("replica", cfg.Databases.Replicas[i].Host).Warn("Failed to add read replica")
+		}
+	}
+	svc.Health.SetDatabase(db)
 
 	// Initialize Redis cache
 	redisCache, err := cache.NewRedisCache(&cfg.Redis, logger)
 	if err != nil {
 		logger.WithError(err).Fatal("Failed to connect to Redis")
 	}
-	defer redisCache.Close()
+	svc.OnShutdown("redis", func(context.Context) error { return redisCache.Close() })
+	svc.Health.AddCheck("redis", func(context.Context) error { return redisCache.HealthCheck() })
 
-	// Run database migrations
-	if err := model.AutoMigrate(db); err != nil {
+	// Share the read-your-writes windows with the other instances, whose
+	// replica reads would otherwise miss the writes made here
+	if resolver := db.Resolver(); resolver != nil {
+		resolver.ShareSticky(redisCache)
+	}
+
+	// Apply the SQL migrations in MIGRATIONS_DIR. Replicas starting together
+	// wait on the migration lock, and edited migrations stop the start.
+	// Dedicated tenant schemas and databases are migrated by migrate up.
+	migrations := database.NewMigrationManager(db.DB, logger, cfg.Migrations.Dir)
+	lockCtx, cancel := context.WithTimeout(context.Background(), cfg.Migrations.LockTimeout)
+	unlock, err := migrations.Lock(lockCtx)
+	cancel()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to lock database migrations")
+	}
+	err = migrations.Initialize(context.Background())
+	if err == nil {
+		var drift []database.MigrationDrift
+		drift, err = migrations.Verify(context.Background())
+		if err == nil && len(drift) > 0 {
+			err = fmt.Errorf("%w: %d applied migrations no longer match their files, see migrate verify", database.ErrMigrationDrift, len(drift))
+		}
+	}
+	if err == nil {
+		err = migrations.MigrateUp(context.Background())
+	}
+	unlock()
+	if err != nil {
 		logger.WithError(err).Fatal("Failed to run database migrations")
 	}
 	logger.Info("Database migrations completed successfully")
 
+	// Record every change in audit_logs. Sessions, activity, usage,
+	// numbering counters and outbox events change on almost every request
+	// and have their own trail, so they are left out.
+	auditor := database.NewAuditor(logger, "user_sessions", "activity_logs", "tenant_usage_daily", "numbering_sequences", "outbox_events")
+	if err := auditor.RegisterCallbacks(db.DB); err != nil {
+		logger.WithError(err).Fatal("Failed to register audit trail")
+	}
+
+	// Hash chain the audit and activity trails per tenant, verify them and
+	// export signed anchors of the chain heads to MinIO
+	hashChain := database.NewHashChain(logger, &database.AuditLog{}, &model.ActivityLog{})
+	if err := hashChain.RegisterCallbacks(db.DB); err != nil {
+		logger.WithError(err).Fatal("Failed to register audit hash chain")
+	}
+	chainVerifier := auditchain.NewVerifier(hashChain, db.DB)
+	if cfg.AuditChain.AnchorInterval > 0 && cfg.AuditChain.SigningKey != "" && cfg.MinIO.Endpoint != "" {
+		signer, err := auditchain.NewSigner(cfg.AuditChain.SigningKey)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to load audit anchor signing key")
+		}
+		anchorStore, err := auditchain.NewMinIOStore(context.Background(), cfg.MinIO)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to connect to audit anchor storage")
+		}
+		anchorer := auditchain.NewAnchorer(chainVerifier, signer, anchorStore, cfg.AuditChain.AnchorPrefix, logger)
+		svc.Go("audit-anchor", func(ctx context.Context) { anchorer.Run(ctx, cfg.AuditChain.AnchorInterval) })
+	} else {
+		logger.Warn("Audit chain anchoring disabled, set AUDIT_ANCHOR_SIGNING_KEY and MINIO_ENDPOINT to enable it")
+	}
+
+	// Validate region references of tenant, customer and supplier addresses on write
+	regionService := region.NewService(db, redisCache, logger)
+	if err := region.NewAddressValidator(regionService).RegisterCallbacks(db.DB); err != nil {
+		logger.WithError(err).Fatal("Failed to register address validation")
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db, logger)
 	sessionRepo := repository.NewSessionRepository(db, logger)
-	activityRepo := repository.NewActivityRepository(db, logger)
+	activityRepo := repository.NewActivityRepository(db, database.NewCursors(cfg.Pagination.CursorSecret), logger)
 	passwordResetRepo := repository.NewPasswordResetRepository(db, logger)
+	tenantRepo := repository.NewTenantRepository(db, logger)
+
+	// Allow the custom domains of active tenants as CORS origins
+	svc.CORS.UseOriginLookup(middleware.OriginLookupFunc(tenantRepo.ExistsActiveByDomain))
+
+	// Initialize subscription plan enforcement and usage metering
+	location, err := time.LoadLocation(cfg.App.Timezone)
+	if err != nil {
+		logger.WithError(err).Warn("Invalid timezone, usage periods will use UTC")
+		location = time.UTC
+	}
+	usageStore := subscription.NewRedisUsageStore(redisCache, logger)
+	planResolver := subscription.NewCachedPlanResolver(service.NewTenantPlanResolver(tenantRepo), 5*time.Minute)
+	planEnforcer := subscription.NewEnforcer(
+		subscription.DefaultCatalogue(),
+		planResolver,
+		usageStore,
+		location,
+		logger,
+	)
+	usageRollup := subscription.NewUsageRollup(db, usageStore, location, logger)
+
+	svc.Go("usage-rollup", func(ctx context.Context) { usageRollup.Run(ctx, time.Hour) })
+
+	// Settings and feature flags, overridable per plan, tenant and user
+	settingsService := settings.NewService(db, redisCache, planResolver, logger)
+	if err := settingsService.Define(service.Settings...); err != nil {
+		logger.WithError(err).Fatal("Failed to define settings")
+	}
+	flags := settings.NewClient(settingsService, logger)
+	svc.Go("settings-invalidation", settingsService.Listen)
+
+	// Publish domain events enqueued in the outbox to Redis streams
+	outboxRelay := outbox.NewRelay(
+		db.DB,
+		outbox.NewRedisStreamPublisher(redisCache.Client, cfg.Outbox.StreamPrefix, cfg.Outbox.StreamMaxLen),
+		cfg.Outbox,
+		logger,
+	)
+	if svc.Metrics != nil {
+		outboxMetrics := outbox.NewMetrics("authentication-service")
+		if err := outboxMetrics.Register(prometheus.DefaultRegisterer); err != nil {
+			logger.WithError(err).Fatal("Failed to register outbox metrics")
+		}
+		outboxRelay.UseMetrics(outboxMetrics)
+	}
+	svc.Go("outbox-relay", outboxRelay.Run)
 
 	// Initialize services
 	authConfig := service.NewAuthConfig(cfg)
@@ -97,122 +199,97 @@ func main() {
 		sessionRepo,
 		activityRepo,
 		passwordResetRepo,
+		tenantRepo,
+		planEnforcer,
+		flags,
 		redisCache,
 		jwtService,
 		logger,
 		authConfig,
 	)
+	tenantService := service.NewTenantService(
+		tenantRepo,
+		userRepo,
+		activityRepo,
+		planEnforcer,
+		redisCache,
+		logger,
+		authConfig,
+	)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService, logger)
+	tenantHandler := handler.NewTenantHandler(tenantService, logger)
+	regionHandler := region.NewHandler(regionService, logger)
+	auditHandler := auditchain.NewHandler(chainVerifier, logger)
+	settingsHandler := settings.NewHandler(settingsService, logger)
 
 	// Initialize middleware
 	jwtMiddleware := middleware.NewJWTMiddleware(authService, logger)
-	rbacMiddleware := middleware.NewRBACMiddleware(jwtMiddleware, logger)
-
-	// Create Gin router
-	router := gin.New()
-
-	// Add middleware
-	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		logger.WithFields(logrus.Fields{
-			"method":     param.Method,
-			"path":       param.Path,
-			"status":     param.StatusCode,
-			"latency":    param.Latency,
-			"client_ip":  param.ClientIP,
-			"user_agent": param.Request.UserAgent(),
-		}).Info("HTTP Request")
-		return ""
-	}))
-	router.Use(gin.Recovery())
-
-	// CORS middleware
-	router.Use(func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
-		if cfg.IsDevelopment() || origin == "https://rexi-erp.com" || origin == "https://www.rexi-erp.com" {
-			c.Header("Access-Control-Allow-Origin", origin)
-		}
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		c.Header("Access-Control-Expose-Headers", "Content-Length")
-		c.Header("Access-Control-Allow-Credentials", "true")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-
-		c.Next()
-	})
-
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		// Check database health
-		dbHealth := "healthy"
-		if err := db.HealthCheck(); err != nil {
-			dbHealth = "unhealthy"
-		}
-
-		// Check Redis health
-		redisHealth := "healthy"
-		if err := redisCache.HealthCheck(); err != nil {
-			redisHealth = "unhealthy"
-		}
-
-		status := "healthy"
-		if dbHealth != "healthy" || redisHealth != "healthy" {
-			status = "unhealthy"
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"status":    status,
-				"service":   "authentication-service",
-				"timestamp": time.Now().UTC(),
-				"version":   cfg.App.Version,
-				"checks": gin.H{
-					"database": dbHealth,
-					"redis":    redisHealth,
-				},
-			})
-			return
-		}
+	rbacMiddleware := middleware.NewRBACMiddleware(logger)
+	planLimitMiddleware := middleware.NewPlanLimitMiddleware(planEnforcer, logger)
+
+	// Requests of a tenant run in transactions PostgreSQL restricts to its rows
+	tenantRouter := database.NewTenantRouter(db, database.NewStaticTenancy(cfg.Tenancy), cfg.Tenancy, cfg.Databases.Master.AppRole, logger)
+	db.UseTenancy(tenantRouter)
+	svc.Go("tenant-pool-eviction", func(ctx context.Context) { tenantRouter.EvictIdle(ctx, time.Minute) })
+	svc.OnShutdown("tenant-pools", func(context.Context) error { return tenantRouter.Close() })
+	tenantTransactionMiddleware := middleware.NewTenantTransactionMiddleware(tenantRouter, logger)
+
+	// Idempotency-Key replay for retried mutating requests
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(
+		middleware.NewRedisIdempotencyStore(redisCache),
+		cfg.Idempotency.TTL,
+		cfg.Idempotency.LockTTL,
+		logger,
+	)
 
-		c.JSON(http.StatusOK, gin.H{
-			"status":    status,
-			"service":   "authentication-service",
-			"timestamp": time.Now().UTC(),
-			"version":   cfg.App.Version,
-			"checks": gin.H{
-				"database": dbHealth,
-				"redis":    redisHealth,
-			},
+	// Rate limits are shared by all replicas through Redis
+	publicRateLimit := func(c *gin.Context) { c.Next() }
+	protectedRateLimit := publicRateLimit
+	if cfg.RateLimit.Enabled {
+		rateLimitMiddleware := middleware.NewRateLimitMiddleware(middleware.NewRedisRateLimiter(redisCache), planResolver, logger)
+		publicRateLimit = rateLimitMiddleware.Limit(middleware.RateLimitRule{
+			Name:   "auth-public",
+			Limit:  cfg.RateLimit.PerIP,
+			Window: cfg.RateLimit.Window,
+			KeyBy:  middleware.RateLimitByIP,
 		})
-	})
+		protectedRateLimit = rateLimitMiddleware.Limit(
+			middleware.RateLimitRule{
+				Name:   "auth-user",
+				Limit:  cfg.RateLimit.PerUser,
+				Window: cfg.RateLimit.Window,
+				KeyBy:  middleware.RateLimitByUser,
+			},
+			middleware.RateLimitRule{
+				Name:       "auth-tenant",
+				Limit:      cfg.RateLimit.PerTenant,
+				Window:     cfg.RateLimit.Window,
+				KeyBy:      middleware.RateLimitByTenant,
+				PlanLimits: cfg.RateLimit.PlanQuotas,
+			},
+		)
+	}
 
 	// API routes
-	api := router.Group("/api/v1")
+	api := svc.API
 	{
-		// Public routes (no authentication required)
-		auth := api.Group("/auth")
-		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
-			auth.POST("/refresh", authHandler.RefreshToken)
-			auth.POST("/password-reset", authHandler.RequestPasswordReset)
-			auth.GET("/validate-reset-token", authHandler.ValidateResetToken)
-			auth.POST("/reset-password", authHandler.ResetPassword)
-		}
-
-		// Protected routes (authentication required)
-		protected := api.Group("/auth")
-		protected.Use(jwtMiddleware.RequireAuth())
-		{
-			protected.POST("/logout", authHandler.Logout)
-			protected.POST("/logout-all", authHandler.LogoutAll)
-			protected.GET("/profile", authHandler.GetProfile)
-			protected.PUT("/profile", authHandler.UpdateProfile)
-			protected.POST("/change-password", authHandler.ChangePassword)
-			protected.GET("/sessions", authHandler.GetSessions)
+		routes := &handler.Routes{
+			Auth:               authHandler,
+			Tenant:             tenantHandler,
+			Region:             regionHandler,
+			Audit:              auditHandler,
+			Settings:           settingsHandler,
+			JWT:                jwtMiddleware,
+			RBAC:               rbacMiddleware,
+			PlanLimit:          planLimitMiddleware,
+			Idempotency:        idempotencyMiddleware.Handle(),
+			PublicRateLimit:    publicRateLimit,
+			ProtectedRateLimit: protectedRateLimit,
+			TenantTransaction:  tenantTransactionMiddleware.Handle(),
 		}
+		routes.Register(api)
 
 		// RBAC protected routes (for API gateway integration example)
 		admin := api.Group("/admin")
@@ -291,43 +368,7 @@ func main() {
 		})
 	}
 
-	// Create HTTP server
-	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.App.Port),
-		Handler:      router,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
+	if err := svc.Run(); err != nil {
+		logger.WithError(err).Fatal("Authentication service stopped with errors")
 	}
-
-	// Start server in a goroutine
-	go func() {
-		logger.WithFields(logrus.Fields{
-			"port":     cfg.App.Port,
-			"service":  "authentication-service",
-			"env":      cfg.App.Environment,
-			"host":     cfg.App.Host,
-		}).Info("Starting authentication service")
-
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.WithError(err).Fatal("Failed to start server")
-		}
-	}()
-
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down authentication service...")
-
-	// Give outstanding requests 30 seconds to complete
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.WithError(err).Fatal("Server forced to shutdown")
-	}
-
-	logger.Info("Authentication service stopped")
-}
\ No newline at end of file
+}
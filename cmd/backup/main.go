@@ -0,0 +1,181 @@
+// Command backup takes, verifies and restores backups of the master
+// database in the MinIO bucket, following the BACKUP_* settings.
+//
+//	backup run                                      # take backups on schedule
+//	backup create -kind logical|base
+//	backup list -kind logical|base
+//	backup verify
+//	backup restore -id 20260301T020000Z -database rexi_erp_restored
+//	backup restore -at 2026-03-01T14:30:00Z -database rexi_erp_restored
+//	backup recover -at 2026-03-01T14:30:00Z -data-dir /var/lib/postgresql/recovered
+//
+// Point in time recovery replays the WAL archived by the server:
+//
+//	archive_mode = on
+//	archive_command = '/usr/local/bin/backup wal-push %p %f'
+//
+// and recover configures the restored data directory to fetch it with
+// backup wal-fetch %f %p.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+const usage = "usage: backup run|create|list|verify|restore|recover|wal-push|wal-fetch [flags]"
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load configuration")
+	}
+	logger.SetLevel(cfg.GetLogLevel())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mdb := database.NewMultiDBManager(cfg, logger)
+	if err := mdb.InitializeStorage(ctx); err != nil {
+		logger.WithError(err).Fatal("Failed to connect to MinIO")
+	}
+	defer mdb.Close()
+
+	manager, err := database.NewBackupManager(mdb, cfg.Backup, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to initialize backups")
+	}
+
+	if err := execute(ctx, manager, command, args); err != nil {
+		if errors.Is(err, database.ErrBackupNotFound) && command == "wal-fetch" {
+			// PostgreSQL asks for segments past the end of the archive
+			os.Exit(1)
+		}
+		logger.WithError(err).WithField("command", command).Fatal("Backup command failed")
+	}
+}
+
+func execute(ctx context.Context, manager *database.BackupManager, command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	kind := flags.String("kind", string(database.BackupKindLogical), "Backup kind: logical or base")
+	id := flags.String("id", "", "Backup ID")
+	at := flags.String("at", "", "Point in time in RFC 3339, e.g. 2026-03-01T14:30:00Z")
+	target := flags.String("database", "", "New database to restore into")
+	dataDir := flags.String("data-dir", "", "Empty directory to restore the cluster into")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	switch command {
+	case "run":
+		manager.Run(ctx)
+		return nil
+
+	case "create":
+		manifest, err := manager.CreateBackup(ctx, database.BackupKind(*kind))
+		if err != nil {
+			return err
+		}
+		return printJSON(manifest)
+
+	case "list":
+		manifests, err := manager.ListBackups(ctx, database.BackupKind(*kind))
+		if err != nil {
+			return err
+		}
+		return printJSON(manifests)
+
+	case "verify":
+		manifest, err := manager.Verify(ctx)
+		if err != nil {
+			return err
+		}
+		return printJSON(manifest)
+
+	case "restore":
+		if *target == "" {
+			return fmt.Errorf("-database is required")
+		}
+		if *id == "" && *at == "" {
+			return fmt.Errorf("-id or -at is required")
+		}
+		if *id != "" {
+			return manager.RestoreBackup(ctx, *id, *target)
+		}
+		pointInTime, err := parseTime(*at)
+		if err != nil {
+			return err
+		}
+		manifest, err := manager.RestoreAsOf(ctx, pointInTime, *target)
+		if err != nil {
+			return err
+		}
+		return printJSON(manifest)
+
+	case "recover":
+		if *dataDir == "" {
+			return fmt.Errorf("-data-dir is required")
+		}
+		pointInTime, err := parseTime(*at)
+		if err != nil {
+			return err
+		}
+		manifest, err := manager.PrepareRecovery(ctx, pointInTime, *dataDir)
+		if err != nil {
+			return err
+		}
+		return printJSON(manifest)
+
+	case "wal-push":
+		if flags.NArg() != 2 {
+			return fmt.Errorf("usage: backup wal-push <path> <file name>")
+		}
+		return manager.ArchiveWAL(ctx, flags.Arg(0), flags.Arg(1))
+
+	case "wal-fetch":
+		if flags.NArg() != 2 {
+			return fmt.Errorf("usage: backup wal-fetch <file name> <path>")
+		}
+		return manager.FetchWAL(ctx, flags.Arg(0), flags.Arg(1))
+	}
+
+	return fmt.Errorf("unknown command %q; %s", command, usage)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("-at is required")
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -at: %w", err)
+	}
+	return at, nil
+}
+
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
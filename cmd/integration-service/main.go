@@ -1,88 +1,28 @@
 package main
 
 import (
-	"context"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/service"
 )
 
 func main() {
-	logger := logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{})
-	logger.SetLevel(logrus.InfoLevel)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8007"
+	svc, err := service.New(service.Options{Name: "integration-service", Port: 8007})
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize integration service")
 	}
 
-	appEnv := os.Getenv("APP_ENV")
-	if appEnv == "" {
-		appEnv = "development"
-	}
-
-	if appEnv == "production" {
-		gin.SetMode(gin.ReleaseMode)
-	}
-
-	router := gin.New()
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-
-	router.GET("/health", func(c *gin.Context) {
+	svc.API.GET("/integration/status", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
-			"status":    "healthy",
-			"service":   "integration-service",
-			"timestamp": time.Now().UTC(),
-			"version":   "1.0.0",
+			"message": "Integration service is running",
+			"service": "integration-service",
 		})
 	})
 
-	api := router.Group("/api/v1")
-	{
-		api.GET("/integration/status", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"message": "Integration service is running",
-				"service": "integration-service",
-			})
-		})
-	}
-
-	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
+	if err := svc.Run(); err != nil {
+		svc.Logger.WithError(err).Fatal("Integration service stopped with errors")
 	}
-
-	go func() {
-		logger.WithFields(logrus.Fields{
-			"port":     port,
-			"service":  "integration-service",
-			"env":      appEnv,
-		}).Info("Starting integration service")
-
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.WithError(err).Fatal("Failed to start server")
-		}
-	}()
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down integration service...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.WithError(err).Fatal("Server forced to shutdown")
-	}
-
-	logger.Info("Integration service stopped")
-}
\ No newline at end of file
+}
@@ -1,98 +1,28 @@
 package main
 
 import (
-	"context"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/service"
 )
 
 func main() {
-	// Initialize logger
-	logger := logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{})
-	logger.SetLevel(logrus.InfoLevel)
-
-	// Get configuration from environment variables
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8002"
+	svc, err := service.New(service.Options{Name: "inventory-service", Port: 8002})
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize inventory service")
 	}
 
-	appEnv := os.Getenv("APP_ENV")
-	if appEnv == "" {
-		appEnv = "development"
-	}
-
-	// Set Gin mode based on environment
-	if appEnv == "production" {
-		gin.SetMode(gin.ReleaseMode)
-	}
-
-	// Create Gin router
-	router := gin.New()
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
+	svc.API.GET("/inventory/status", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
-			"status":    "healthy",
-			"service":   "inventory-service",
-			"timestamp": time.Now().UTC(),
-			"version":   "1.0.0",
+			"message": "Inventory service is running",
+			"service": "inventory-service",
 		})
 	})
 
-	// API routes
-	api := router.Group("/api/v1")
-	{
-		api.GET("/inventory/status", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"message": "Inventory service is running",
-				"service": "inventory-service",
-			})
-		})
-	}
-
-	// Create HTTP server
-	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
+	if err := svc.Run(); err != nil {
+		svc.Logger.WithError(err).Fatal("Inventory service stopped with errors")
 	}
-
-	// Start server in a goroutine
-	go func() {
-		logger.WithFields(logrus.Fields{
-			"port":     port,
-			"service":  "inventory-service",
-			"env":      appEnv,
-		}).Info("Starting inventory service")
-
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.WithError(err).Fatal("Failed to start server")
-		}
-	}()
-
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down inventory service...")
-
-	// Give outstanding requests 30 seconds to complete
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.WithError(err).Fatal("Server forced to shutdown")
-	}
-
-	logger.Info("Inventory service stopped")
-}
\ No newline at end of file
+}
@@ -0,0 +1,272 @@
+// Command migrate applies the SQL migrations in MIGRATIONS_DIR to the master
+// database. Commands that change the schema hold a PostgreSQL advisory
+// lock, so replicas that migrate as they start wait for each other instead
+// of racing.
+//
+//	migrate up [-dry-run]                 # apply pending migrations
+//	migrate down [-to 011] [-dry-run]     # roll back the last, or all after -to
+//	migrate redo [-dry-run]               # roll back and reapply the last
+//	migrate status
+//	migrate verify                        # fail if applied files were edited
+//	migrate create add customer segments  # write 012_add_customer_segments.{up,down}.sql
+//	migrate baseline -to 010_settings     # mark migrations applied without running them
+//
+// Databases created by docker-entrypoint-initdb.d already contain the
+// migrations in the directory; baseline records them as applied once.
+//
+// When TENANCY_MODE or TENANCY_OVERRIDES give tenants a schema or database
+// of their own, up then applies TENANCY_MIGRATIONS_DIR to each of them.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+const usage = "usage: migrate up|down|redo|status|verify|create|baseline [flags]"
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	to := flags.String("to", "", "Version to roll back to, or to baseline up to")
+	dryRun := flags.Bool("dry-run", false, "Print the SQL instead of running it")
+	if err := flags.Parse(args); err != nil {
+		os.Exit(2)
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load configuration")
+	}
+	logger.SetLevel(cfg.GetLogLevel())
+
+	if command == "create" {
+		if err := create(logger, cfg.Migrations.Dir, strings.Join(flags.Args(), " ")); err != nil {
+			logger.WithError(err).Fatal("Failed to create migration")
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.NewDatabase(&cfg.Databases.Master, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to connect to database")
+	}
+	defer db.Close()
+
+	manager := database.NewMigrationManager(db.DB, logger, cfg.Migrations.Dir)
+
+	lockCtx, cancel := context.WithTimeout(ctx, cfg.Migrations.LockTimeout)
+	unlock, err := manager.Lock(lockCtx)
+	cancel()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to lock migrations")
+	}
+
+	// Applied migrations are read under the lock, after any other process
+	// finished migrating
+	err = manager.Initialize(ctx)
+	if err == nil {
+		err = execute(ctx, manager, command, *to, *dryRun)
+	}
+	if err == nil && command == "up" && !*dryRun && cfg.Tenancy.Dedicated() {
+		err = migrateTenants(ctx, db, cfg, logger)
+	}
+	unlock()
+
+	if errors.Is(err, database.ErrMigrationDrift) {
+		os.Exit(1)
+	}
+	if err != nil {
+		logger.WithError(err).WithField("command", command).Fatal("Migration command failed")
+	}
+}
+
+func execute(ctx context.Context, manager *database.MigrationManager, command, to string, dryRun bool) error {
+	switch command {
+	case "up":
+		if err := verify(ctx, manager); err != nil {
+			return err
+		}
+		if dryRun {
+			pending, err := manager.GetPendingMigrations()
+			if err != nil {
+				return err
+			}
+			for _, migration := range pending {
+				printSQL(migration, migration.UpSQL)
+			}
+			return nil
+		}
+		return manager.MigrateUp(ctx)
+
+	case "down":
+		if dryRun {
+			plan, err := manager.RollbackPlan(to)
+			if err != nil {
+				return err
+			}
+			for _, migration := range plan {
+				printSQL(migration, migration.DownSQL)
+			}
+			return nil
+		}
+		return manager.MigrateDownTo(ctx, to)
+
+	case "redo":
+		if dryRun {
+			plan, err := manager.RollbackPlan("")
+			if err != nil {
+				return err
+			}
+			for _, migration := range plan {
+				printSQL(migration, migration.DownSQL)
+				printSQL(migration, migration.UpSQL)
+			}
+			return nil
+		}
+		return manager.Redo(ctx)
+
+	case "status":
+		return status(ctx, manager)
+
+	case "verify":
+		if err := verify(ctx, manager); err != nil {
+			return err
+		}
+		fmt.Println("All applied migrations match their files")
+		return nil
+
+	case "baseline":
+		if to == "" {
+			return fmt.Errorf("-to is required")
+		}
+		return manager.Baseline(ctx, to)
+	}
+
+	return fmt.Errorf("unknown command %q; %s", command, usage)
+}
+
+// migrateTenants applies the tenant migrations to the tenants that are not
+// closed
+func migrateTenants(ctx context.Context, db *database.Database, cfg *config.Config, logger *logrus.Logger) error {
+	var tenantIDs []uuid.UUID
+	if err := db.DB.WithContext(ctx).Model(&model.Tenant{}).Where("status <> ?", model.TenantStatusClosed).Pluck("id", &tenantIDs).Error; err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	router := database.NewTenantRouter(db, database.NewStaticTenancy(cfg.Tenancy), cfg.Tenancy, cfg.Databases.Master.AppRole, logger)
+	defer router.Close()
+	return router.MigrateTenants(ctx, cfg.Tenancy.MigrationsDir, tenantIDs)
+}
+
+// verify prints the applied migrations that no longer match their files
+// and fails if there are any
+func verify(ctx context.Context, manager *database.MigrationManager) error {
+	drift, err := manager.Verify(ctx)
+	if err != nil {
+		return err
+	}
+	for _, d := range drift {
+		fmt.Fprintf(os.Stderr, "%s: %s (applied %s, now %s)\n", d.Version, d.Reason, shortChecksum(d.AppliedChecksum), shortChecksum(d.CurrentChecksum))
+	}
+	if len(drift) > 0 {
+		return database.ErrMigrationDrift
+	}
+	return nil
+}
+
+func status(ctx context.Context, manager *database.MigrationManager) error {
+	migrationStatus, err := manager.GetStatus(ctx)
+	if err != nil {
+		return err
+	}
+	drift, err := manager.Verify(ctx)
+	if err != nil {
+		return err
+	}
+	drifted := make(map[string]string, len(drift))
+	for _, d := range drift {
+		drifted[d.Version] = d.Reason
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tDESCRIPTION")
+	for _, migration := range migrationStatus.Applied {
+		state := "applied"
+		if reason, ok := drifted[migration.Version]; ok {
+			state = "drifted: " + reason
+		}
+		appliedAt := ""
+		if migration.AppliedAt != nil {
+			appliedAt = migration.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", migration.Version, state, appliedAt, migration.Description)
+	}
+	for _, migration := range migrationStatus.Pending {
+		fmt.Fprintf(w, "%s\tpending\t\t%s\n", migration.Version, migration.Description)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d applied, %d pending\n", migrationStatus.AppliedMigrations, migrationStatus.PendingMigrations)
+	return nil
+}
+
+func create(logger *logrus.Logger, dir, description string) error {
+	if description == "" {
+		return fmt.Errorf("usage: migrate create <description>")
+	}
+
+	manager := database.NewMigrationManager(nil, logger, dir)
+	if err := manager.LoadFiles(); err != nil {
+		return err
+	}
+
+	upFile, downFile, err := database.NewMigrationHelper(manager, logger).CreateMigrationTemplate(manager.NextVersion(), description)
+	if err != nil {
+		return err
+	}
+	fmt.Println(upFile)
+	fmt.Println(downFile)
+	return nil
+}
+
+func printSQL(migration *database.MigrationFile, sql string) {
+	fmt.Printf("-- %s: %s\n%s\n", migration.Version, migration.Description, strings.TrimRight(sql, "\n"))
+}
+
+func shortChecksum(checksum string) string {
+	if checksum == "" {
+		return "missing"
+	}
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	return checksum
+}
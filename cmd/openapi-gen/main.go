@@ -0,0 +1,55 @@
+// Command openapi-gen generates the OpenAPI document of the authentication
+// service from its gin route table and handler annotations. Run it from the
+// repository root; -check fails instead of writing when the committed
+// document is out of date.
+//
+//	go run ./cmd/openapi-gen
+//	go run ./cmd/openapi-gen -check
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/handler"
+)
+
+func main() {
+	root := flag.String("root", ".", "Repository root")
+	out := flag.String("out", handler.OpenAPIPath, "Output file, relative to the repository root")
+	check := flag.Bool("check", false, "Fail if the output file is not up to date instead of writing it")
+	flag.Parse()
+
+	logger := logrus.New()
+
+	spec, err := handler.GenerateOpenAPI(*root)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to generate OpenAPI document")
+	}
+
+	path := *out
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(*root, path)
+	}
+
+	if *check {
+		current, err := os.ReadFile(path)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to read OpenAPI document")
+		}
+		if !bytes.Equal(current, spec) {
+			logger.Fatalf("%s is out of date, run go run ./cmd/openapi-gen", path)
+		}
+		logger.Infof("%s is up to date", path)
+		return
+	}
+
+	if err := os.WriteFile(path, spec, 0644); err != nil {
+		logger.WithError(err).Fatal("Failed to write OpenAPI document")
+	}
+	logger.Infof("Wrote %s", path)
+}
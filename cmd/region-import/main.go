@@ -0,0 +1,88 @@
+// Command region-import loads the Kemendagri region code dataset into the
+// master database. Without -file the dataset bundled with the binary is used.
+//
+//	go run ./cmd/region-import
+//	go run ./cmd/region-import -file wilayah_2025.csv -version kemendagri-2025
+package main
+
+import (
+	"context"
+	"flag"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/cache"
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/region"
+)
+
+func main() {
+	file := flag.String("file", "", "Path to a region dataset in CSV or JSON format (default: bundled dataset)")
+	version := flag.String("version", "", "Dataset version label (required with -file)")
+	force := flag.Bool("force", false, "Re-import a version that was already imported")
+	flag.Parse()
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load configuration")
+	}
+	logger.SetLevel(cfg.GetLogLevel())
+
+	var dataset *region.Dataset
+	opts := region.ImportOptions{Version: *version, Force: *force}
+	if *file == "" {
+		dataset, err = region.LoadBundledDataset()
+		opts.Source = "bundled"
+		if opts.Version == "" {
+			opts.Version = region.BundledDatasetVersion
+		}
+	} else {
+		if opts.Version == "" {
+			logger.Fatal("-version is required when importing from a file")
+		}
+		dataset, err = region.LoadDatasetFile(*file)
+		opts.Source = filepath.Base(*file)
+	}
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load region dataset")
+	}
+
+	db, err := database.NewDatabase(&cfg.Databases.Master, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to connect to database")
+	}
+	defer db.Close()
+
+	// The cache is only used for invalidation, so the import can run without it
+	redisCache, err := cache.NewRedisCache(&cfg.Redis, logger)
+	if err != nil {
+		logger.WithError(err).Warn("Redis unavailable, cached region lookups will expire on their own")
+		redisCache = nil
+	} else {
+		defer redisCache.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	result, err := region.NewImporter(db, redisCache, logger).Import(ctx, dataset, opts)
+	if err != nil {
+		logger.WithError(err).Fatal("Region import failed")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"version":   result.Version,
+		"skipped":   result.Skipped,
+		"provinces": result.Provinces,
+		"cities":    result.Cities,
+		"districts": result.Districts,
+		"villages":  result.Villages,
+		"duration":  result.Duration,
+	}).Info("Region import finished")
+}
@@ -0,0 +1,168 @@
+// Command schema-diff compares the GORM models with the tables of the master
+// database and reports missing and extra columns, type, nullability and
+// index mismatches.
+//
+//	schema-diff                      # print the differences and a candidate migration
+//	schema-diff -check               # exit 1 on drift, for CI
+//	schema-diff -write               # write the candidate as the next migration
+//	schema-diff -json
+//
+// The candidate migration is a starting point for review, not something to
+// apply blindly: drops of extra columns and indexes are commented out.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/outbox"
+	"github.com/VincentArjuna/RexiErp/internal/shared/region"
+	"github.com/VincentArjuna/RexiErp/internal/shared/schemadiff"
+	"github.com/VincentArjuna/RexiErp/internal/shared/settings"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
+)
+
+// models are the GORM models whose tables live in the master database
+var models = []interface{}{
+	&model.Tenant{},
+	&model.User{},
+	&model.UserSession{},
+	&model.ActivityLog{},
+	&model.PasswordResetToken{},
+	&model.ChartOfAccount{},
+	&model.Warehouse{},
+	&model.NumberingSequence{},
+	&database.AuditLog{},
+	&outbox.Event{},
+	&settings.Setting{},
+	&subscription.DailyUsage{},
+	&region.Country{},
+	&region.Province{},
+	&region.City{},
+	&region.District{},
+	&region.Village{},
+	&region.DatasetVersion{},
+}
+
+func main() {
+	check := flag.Bool("check", false, "Exit with status 1 if the database drifted from the models")
+	allow := flag.String("allow", string(schemadiff.KindExtraIndex), "Comma-separated difference kinds -check tolerates")
+	write := flag.Bool("write", false, "Write the candidate migration to the migrations directory")
+	asJSON := flag.Bool("json", false, "Print the report as JSON")
+	flag.Parse()
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load configuration")
+	}
+	logger.SetLevel(cfg.GetLogLevel())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.NewDatabase(&cfg.Databases.Master, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to connect to database")
+	}
+	defer db.Close()
+
+	report, err := schemadiff.Compare(ctx, db.DB, models...)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to compare schema")
+	}
+	up, down := report.Migration()
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			logger.WithError(err).Fatal("Failed to encode report")
+		}
+	} else {
+		printReport(report, up)
+	}
+
+	if *write && up != "" {
+		upFile, downFile, err := writeMigration(logger, cfg.Migrations.Dir, up, down)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to write migration")
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s and %s\n", upFile, downFile)
+	}
+
+	if *check && report.HasDrift(allowedKinds(*allow)...) {
+		os.Exit(1)
+	}
+}
+
+func printReport(report *schemadiff.Report, up string) {
+	if len(report.Differences) == 0 {
+		fmt.Printf("%d tables match their models\n", report.Tables)
+		return
+	}
+
+	for _, d := range report.Differences {
+		fmt.Println(d.String())
+	}
+	fmt.Printf("\n%d differences in %d tables\n\n", len(report.Differences), report.Tables)
+	fmt.Print(up)
+}
+
+func allowedKinds(allow string) []schemadiff.Kind {
+	var kinds []schemadiff.Kind
+	for _, kind := range strings.Split(allow, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds = append(kinds, schemadiff.Kind(kind))
+		}
+	}
+	return kinds
+}
+
+// writeMigration writes the candidate as the next numbered migration
+func writeMigration(logger *logrus.Logger, dir, up, down string) (string, string, error) {
+	manager := database.NewMigrationManager(nil, logger, dir)
+	if err := manager.LoadFiles(); err != nil {
+		return "", "", err
+	}
+
+	name := manager.NextVersion() + "_reconcile_models"
+	header := "-- Generated by schema-diff; review before applying\n\n"
+	upFile := filepath.Join(dir, name+".up.sql")
+	downFile := filepath.Join(dir, name+".down.sql")
+
+	if err := writeNewFile(upFile, header+up); err != nil {
+		return "", "", err
+	}
+	if err := writeNewFile(downFile, header+down); err != nil {
+		os.Remove(upFile)
+		return "", "", err
+	}
+	return upFile, downFile, nil
+}
+
+func writeNewFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
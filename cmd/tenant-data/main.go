@@ -0,0 +1,126 @@
+// Command tenant-data exports all business data of a tenant to an archive
+// and imports such an archive under another tenant, e.g. to move a tenant
+// between environments or to seed a sandbox from production.
+//
+//	tenant-data export -tenant 0b7c...e1 -out toko-maju.tar.gz
+//	tenant-data export -tenant 0b7c...e1 -out toko-maju.tar.gz -redact
+//	tenant-data import -tenant 5f2a...9c -file toko-maju.tar.gz
+//
+// The target tenant of an import must exist and receives new IDs for all
+// imported rows. Redacted archives, which omit credentials, are meant for
+// handing to the tenant and cannot be imported.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/tenantdata"
+)
+
+const usage = "usage: tenant-data export|import [flags]"
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	tenant := flags.String("tenant", "", "Tenant ID to export, or to import into")
+	out := flags.String("out", "", "Archive to write")
+	file := flags.String("file", "", "Archive to import")
+	redact := flags.Bool("redact", false, "Clear credential columns such as password hashes")
+	if err := flags.Parse(args); err != nil {
+		os.Exit(2)
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load configuration")
+	}
+	logger.SetLevel(cfg.GetLogLevel())
+
+	tenantID, err := uuid.Parse(*tenant)
+	if err != nil {
+		logger.WithError(err).Fatal("-tenant must be a tenant ID")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.NewDatabase(&cfg.Databases.Master, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to connect to database")
+	}
+	defer db.Close()
+
+	switch command {
+	case "export":
+		err = export(ctx, db, logger, tenantID, *out, tenantdata.ExportOptions{Redact: *redact})
+	case "import":
+		err = importArchive(ctx, db, logger, tenantID, *file)
+	default:
+		err = fmt.Errorf("unknown command %q; %s", command, usage)
+	}
+	if err != nil {
+		logger.WithError(err).WithField("command", command).Fatal("Tenant data command failed")
+	}
+}
+
+func export(ctx context.Context, db *database.Database, logger *logrus.Logger, tenantID uuid.UUID, path string, opts tenantdata.ExportOptions) error {
+	if path == "" {
+		return fmt.Errorf("-out is required")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := tenantdata.NewExporter(db, logger).Export(ctx, tenantID, f, opts)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	return printJSON(manifest)
+}
+
+func importArchive(ctx context.Context, db *database.Database, logger *logrus.Logger, tenantID uuid.UUID, path string) error {
+	if path == "" {
+		return fmt.Errorf("-file is required")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	result, err := tenantdata.NewImporter(db, logger).Import(ctx, f, tenantID)
+	if err != nil {
+		return err
+	}
+	return printJSON(result)
+}
+
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
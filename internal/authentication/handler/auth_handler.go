@@ -8,6 +8,8 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/VincentArjuna/RexiErp/internal/authentication/service"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/middleware"
 )
 
 // AuthHandler handles authentication HTTP requests
@@ -31,29 +33,30 @@ func NewAuthHandler(authService service.AuthService, logger *logrus.Logger) *Aut
 // @Accept json
 // @Produce json
 // @Param request body RegisterRequest true "Registration request"
-// @Success 201 {object} AuthResponse
+// @Success 201 {object} SuccessResponse{data=AuthResponse}
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.respondWithError(c, http.StatusBadRequest, "Validation failed", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
 	// Extract tenant ID from context (should be set by middleware)
 	tenantID, exists := c.Get("tenant_id")
 	if !exists {
-		h.respondWithError(c, http.StatusBadRequest, "Tenant ID required", "Tenant context not found")
+		apperror.Respond(c, apperror.New(apperror.CodeBadRequest, "tenant context not found"))
 		return
 	}
 
 	// Convert tenant ID to UUID
 	tenantUUID, ok := tenantID.(uuid.UUID)
 	if !ok {
-		h.respondWithError(c, http.StatusBadRequest, "Invalid tenant ID", "Tenant ID format is invalid")
+		apperror.Respond(c, apperror.New(apperror.CodeBadRequest, "tenant ID format is invalid"))
 		return
 	}
 
@@ -76,12 +79,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 			"error":     err,
 		}).Error("User registration failed")
 
-		if contains(err.Error(), "already exists") {
-			h.respondWithError(c, http.StatusConflict, "User already exists", err.Error())
-			return
-		}
-
-		h.respondWithError(c, http.StatusInternalServerError, "Registration failed", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -105,7 +103,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "Login request"
-// @Success 200 {object} AuthResponse
+// @Success 200 {object} SuccessResponse{data=AuthResponse}
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
@@ -113,7 +111,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.respondWithError(c, http.StatusBadRequest, "Validation failed", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -134,17 +132,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			"error":      err,
 		}).Warn("User login failed")
 
-		if contains(err.Error(), "invalid credentials") || contains(err.Error(), "not found") {
-			h.respondWithError(c, http.StatusUnauthorized, "Invalid credentials", "Email or password is incorrect")
-			return
-		}
-
-		if contains(err.Error(), "inactive") {
-			h.respondWithError(c, http.StatusForbidden, "Account inactive", "Your account is not active")
-			return
-		}
-
-		h.respondWithError(c, http.StatusInternalServerError, "Login failed", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -168,7 +156,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 // @Description Logs out a user by deactivating their session
 // @Tags authentication
 // @Produce json
-// @Param Authorization header string true "Bearer token"
+// @Security BearerAuth
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
@@ -178,13 +166,13 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	// Get session ID from token (should be set by auth middleware)
 	sessionID, exists := c.Get("session_id")
 	if !exists {
-		h.respondWithError(c, http.StatusBadRequest, "Session ID required", "No active session found")
+		apperror.Respond(c, apperror.New(apperror.CodeSessionNotFound, "session ID not found in context"))
 		return
 	}
 
 	sessionIDStr, ok := sessionID.(string)
 	if !ok {
-		h.respondWithError(c, http.StatusBadRequest, "Invalid session", "Session ID format is invalid")
+		apperror.Respond(c, apperror.New(apperror.CodeSessionNotFound, "session ID format is invalid"))
 		return
 	}
 
@@ -195,12 +183,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 			"error":      err,
 		}).Error("User logout failed")
 
-		if contains(err.Error(), "not found") {
-			h.respondWithError(c, http.StatusNotFound, "Session not found", "No active session found")
-			return
-		}
-
-		h.respondWithError(c, http.StatusInternalServerError, "Logout failed", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -219,7 +202,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body RefreshTokenRequest true "Refresh token request"
-// @Success 200 {object} AuthResponse
+// @Success 200 {object} SuccessResponse{data=AuthResponse}
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
@@ -227,7 +210,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	var req RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.respondWithError(c, http.StatusBadRequest, "Validation failed", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -236,17 +219,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	if err != nil {
 		h.logger.WithField("error", err).Warn("Token refresh failed")
 
-		if contains(err.Error(), "invalid") || contains(err.Error(), "expired") {
-			h.respondWithError(c, http.StatusUnauthorized, "Invalid token", "Refresh token is invalid or expired")
-			return
-		}
-
-		if contains(err.Error(), "not found") {
-			h.respondWithError(c, http.StatusUnauthorized, "Session not found", "No active session found")
-			return
-		}
-
-		h.respondWithError(c, http.StatusInternalServerError, "Token refresh failed", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -267,8 +240,8 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 // @Description Retrieves the current user's profile information
 // @Tags authentication
 // @Produce json
-// @Param Authorization header string true "Bearer token"
-// @Success 200 {object} UserDTO
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse{data=UserDTO}
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
@@ -277,13 +250,13 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	// Get user ID from token (should be set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		h.respondWithError(c, http.StatusUnauthorized, "Unauthorized", "User context not found")
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "user context not found"))
 		return
 	}
 
 	userUUID, ok := userID.(uuid.UUID)
 	if !ok {
-		h.respondWithError(c, http.StatusUnauthorized, "Invalid user", "User ID format is invalid")
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "user ID format is invalid"))
 		return
 	}
 
@@ -295,15 +268,11 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 			"error":   err,
 		}).Error("Failed to get user profile")
 
-		if contains(err.Error(), "not found") {
-			h.respondWithError(c, http.StatusNotFound, "User not found", "User profile not found")
-			return
-		}
-
-		h.respondWithError(c, http.StatusInternalServerError, "Failed to get profile", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
+	middleware.SetETag(c, user)
 	c.JSON(http.StatusOK, SuccessResponse{
 		Success: true,
 		Message: "Profile retrieved successfully",
@@ -313,35 +282,45 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 
 // UpdateProfile handles user profile update
 // @Summary Update user profile
-// @Description Updates the current user's profile information
+// @Description Updates the current user's profile information. The If-Match header must carry the ETag of GET /auth/profile; a profile changed since fails with 412.
 // @Tags authentication
 // @Accept json
 // @Produce json
-// @Param Authorization header string true "Bearer token"
+// @Security BearerAuth
+// @Param If-Match header string true "ETag of the profile the change is based on"
 // @Param request body UpdateProfileRequest true "Update profile request"
-// @Success 200 {object} UserDTO
+// @Success 200 {object} SuccessResponse{data=UserDTO}
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /auth/profile [put]
 func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	var req UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.respondWithError(c, http.StatusBadRequest, "Validation failed", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
 	// Get user ID from token
 	userID, exists := c.Get("user_id")
 	if !exists {
-		h.respondWithError(c, http.StatusUnauthorized, "Unauthorized", "User context not found")
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "user context not found"))
 		return
 	}
 
 	userUUID, ok := userID.(uuid.UUID)
 	if !ok {
-		h.respondWithError(c, http.StatusUnauthorized, "Invalid user", "User ID format is invalid")
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "user ID format is invalid"))
+		return
+	}
+
+	// The change applies to the version the client read
+	version, _, err := middleware.IfMatchVersion(c)
+	if err != nil {
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -349,6 +328,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	serviceReq := &service.UpdateProfileRequest{
 		FullName:    req.FullName,
 		PhoneNumber: req.PhoneNumber,
+		Version:     version,
 	}
 
 	// Call auth service
@@ -359,17 +339,13 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 			"error":   err,
 		}).Error("Failed to update user profile")
 
-		if contains(err.Error(), "not found") {
-			h.respondWithError(c, http.StatusNotFound, "User not found", "User profile not found")
-			return
-		}
-
-		h.respondWithError(c, http.StatusInternalServerError, "Failed to update profile", err.Error())
+		apperror.Respond(c, middleware.IfMatchError(c, err))
 		return
 	}
 
 	h.logger.WithField("user_id", userUUID).Info("User profile updated successfully")
 
+	middleware.SetETag(c, user)
 	c.JSON(http.StatusOK, SuccessResponse{
 		Success: true,
 		Message: "Profile updated successfully",
@@ -383,7 +359,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 // @Tags authentication
 // @Accept json
 // @Produce json
-// @Param Authorization header string true "Bearer token"
+// @Security BearerAuth
 // @Param request body ChangePasswordRequest true "Change password request"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
@@ -393,20 +369,20 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	var req ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.respondWithError(c, http.StatusBadRequest, "Validation failed", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
 	// Get user ID from token
 	userID, exists := c.Get("user_id")
 	if !exists {
-		h.respondWithError(c, http.StatusUnauthorized, "Unauthorized", "User context not found")
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "user context not found"))
 		return
 	}
 
 	userUUID, ok := userID.(uuid.UUID)
 	if !ok {
-		h.respondWithError(c, http.StatusUnauthorized, "Invalid user", "User ID format is invalid")
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "user ID format is invalid"))
 		return
 	}
 
@@ -423,12 +399,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 			"error":   err,
 		}).Error("Failed to change password")
 
-		if contains(err.Error(), "incorrect") {
-			h.respondWithError(c, http.StatusUnauthorized, "Invalid current password", "Current password is incorrect")
-			return
-		}
-
-		h.respondWithError(c, http.StatusInternalServerError, "Failed to change password", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -445,8 +416,8 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 // @Description Retrieves all active sessions for the current user
 // @Tags authentication
 // @Produce json
-// @Param Authorization header string true "Bearer token"
-// @Success 200 {array} SessionDTO
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse{data=[]SessionDTO}
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /auth/sessions [get]
@@ -454,13 +425,13 @@ func (h *AuthHandler) GetSessions(c *gin.Context) {
 	// Get user ID from token
 	userID, exists := c.Get("user_id")
 	if !exists {
-		h.respondWithError(c, http.StatusUnauthorized, "Unauthorized", "User context not found")
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "user context not found"))
 		return
 	}
 
 	userUUID, ok := userID.(uuid.UUID)
 	if !ok {
-		h.respondWithError(c, http.StatusUnauthorized, "Invalid user", "User ID format is invalid")
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "user ID format is invalid"))
 		return
 	}
 
@@ -472,7 +443,7 @@ func (h *AuthHandler) GetSessions(c *gin.Context) {
 			"error":   err,
 		}).Error("Failed to get user sessions")
 
-		h.respondWithError(c, http.StatusInternalServerError, "Failed to get sessions", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -494,7 +465,7 @@ func (h *AuthHandler) GetSessions(c *gin.Context) {
 // @Description Logs out the user from all active sessions
 // @Tags authentication
 // @Produce json
-// @Param Authorization header string true "Bearer token"
+// @Security BearerAuth
 // @Success 200 {object} SuccessResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
@@ -503,13 +474,13 @@ func (h *AuthHandler) LogoutAll(c *gin.Context) {
 	// Get user ID from token
 	userID, exists := c.Get("user_id")
 	if !exists {
-		h.respondWithError(c, http.StatusUnauthorized, "Unauthorized", "User context not found")
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "user context not found"))
 		return
 	}
 
 	userUUID, ok := userID.(uuid.UUID)
 	if !ok {
-		h.respondWithError(c, http.StatusUnauthorized, "Invalid user", "User ID format is invalid")
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "user ID format is invalid"))
 		return
 	}
 
@@ -520,7 +491,7 @@ func (h *AuthHandler) LogoutAll(c *gin.Context) {
 			"error":   err,
 		}).Error("Failed to logout from all sessions")
 
-		h.respondWithError(c, http.StatusInternalServerError, "Failed to logout from all sessions", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -547,7 +518,7 @@ func (h *AuthHandler) LogoutAll(c *gin.Context) {
 func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
 	var req PasswordResetRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.respondWithError(c, http.StatusBadRequest, "Validation failed", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -564,7 +535,7 @@ func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
 			"error": err,
 		}).Error("Failed to process password reset request")
 
-		h.respondWithError(c, http.StatusInternalServerError, "Failed to process password reset request", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -594,7 +565,7 @@ func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
 func (h *AuthHandler) ValidateResetToken(c *gin.Context) {
 	token := c.Query("token")
 	if token == "" {
-		h.respondWithError(c, http.StatusBadRequest, "Token required", "Reset token is required")
+		apperror.Respond(c, apperror.Validation(apperror.Field("token", "required", "reset token is required")))
 		return
 	}
 
@@ -602,7 +573,7 @@ func (h *AuthHandler) ValidateResetToken(c *gin.Context) {
 	result, err := h.authService.ValidateResetToken(c.Request.Context(), token)
 	if err != nil {
 		h.logger.WithField("token", "****").Error("Failed to validate reset token")
-		h.respondWithError(c, http.StatusInternalServerError, "Failed to validate reset token", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -629,7 +600,7 @@ func (h *AuthHandler) ValidateResetToken(c *gin.Context) {
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	var req ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.respondWithError(c, http.StatusBadRequest, "Validation failed", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -643,12 +614,7 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	if err := h.authService.ResetPassword(c.Request.Context(), serviceReq); err != nil {
 		h.logger.WithField("token", "****").Error("Failed to reset password")
 
-		if contains(err.Error(), "invalid reset token") {
-			h.respondWithError(c, http.StatusNotFound, "Invalid or expired token", err.Error())
-			return
-		}
-
-		h.respondWithError(c, http.StatusInternalServerError, "Failed to reset password", err.Error())
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -659,51 +625,3 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		Message: "Password reset successfully. You can now login with your new password.",
 	})
 }
-
-// Helper functions
-
-func (h *AuthHandler) respondWithError(c *gin.Context, statusCode int, errorType, message string) {
-	response := ErrorResponse{
-		Error:   errorType,
-		Message: message,
-		Code:    getErrorCode(statusCode),
-	}
-
-	c.JSON(statusCode, response)
-}
-
-func getErrorCode(statusCode int) string {
-	switch statusCode {
-	case http.StatusBadRequest:
-		return "BAD_REQUEST"
-	case http.StatusUnauthorized:
-		return "UNAUTHORIZED"
-	case http.StatusForbidden:
-		return "FORBIDDEN"
-	case http.StatusNotFound:
-		return "NOT_FOUND"
-	case http.StatusConflict:
-		return "CONFLICT"
-	case http.StatusInternalServerError:
-		return "INTERNAL_SERVER_ERROR"
-	default:
-		return "UNKNOWN_ERROR"
-	}
-}
-
-func contains(str, substr string) bool {
-	return len(str) >= len(substr) && (str == substr ||
-		(len(str) > len(substr) &&
-			(str[:len(substr)] == substr ||
-			 str[len(str)-len(substr):] == substr ||
-			 findSubstring(str, substr))))
-}
-
-func findSubstring(str, substr string) bool {
-	for i := 0; i <= len(str)-len(substr); i++ {
-		if str[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
\ No newline at end of file
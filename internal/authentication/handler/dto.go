@@ -6,6 +6,9 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
 )
 
 // RegisterRequest represents the request payload for user registration
@@ -51,6 +54,50 @@ type ResetPasswordRequest struct {
 	NewPassword string `json:"new_password" binding:"required,min=8" example:"NewSecurePass123!"`
 }
 
+// CreateTenantRequest represents the request payload for tenant provisioning
+type CreateTenantRequest struct {
+	Name             string             `json:"name" binding:"required,min=2,max=200" example:"PT Maju Jaya"`
+	Domain           string             `json:"domain,omitempty" binding:"omitempty,fqdn" example:"erp.majujaya.co.id"`
+	Subdomain        string             `json:"subdomain,omitempty" binding:"omitempty,max=63" example:"majujaya"`
+	CompanyType      string             `json:"company_type" binding:"required,oneof=pt cv firm ud koperasi yayasan lainnya" example:"pt"`
+	BusinessCategory string             `json:"business_category" binding:"required,oneof=dagang jasa manufaktur pertanian konstruksi transportasi lainnya" example:"dagang"`
+	TaxNumber        string             `json:"tax_number,omitempty" binding:"omitempty,max=50" example:"01.234.567.8-901.000"`
+	TaxStatus        string             `json:"tax_status,omitempty" binding:"omitempty,oneof=pkp non_pkp" example:"pkp"`
+	Email            string             `json:"email" binding:"required,email" example:"admin@majujaya.co.id"`
+	Phone            string             `json:"phone,omitempty" binding:"omitempty,max=50" example:"+62215551234"`
+	Address          string             `json:"address,omitempty" example:"Jl. Sudirman No. 1"`
+	CountryID        *uuid.UUID         `json:"country_id,omitempty" example:"00000000-0000-0000-0000-000000000001"`
+	ProvinceID       *uuid.UUID         `json:"province_id,omitempty" example:"10000000-0000-0000-0000-000000000001"`
+	CityID           *uuid.UUID         `json:"city_id,omitempty" example:"20000000-0000-0000-0000-000000000001"`
+	DistrictID       *uuid.UUID         `json:"district_id,omitempty"`
+	VillageID        *uuid.UUID         `json:"village_id,omitempty"`
+	PostalCode       string             `json:"postal_code,omitempty" binding:"omitempty,numeric,len=5" example:"10220"`
+	SubscriptionPlan string             `json:"subscription_plan,omitempty" example:"basic"`
+	MaxUsers         int                `json:"max_users,omitempty" binding:"omitempty,min=1" example:"10"`
+	Admin            TenantAdminRequest `json:"admin" binding:"required"`
+}
+
+// TenantAdminRequest represents the first tenant_admin account in a provisioning request
+type TenantAdminRequest struct {
+	Email       string `json:"email" binding:"required,email" example:"owner@majujaya.co.id"`
+	Password    string `json:"password" binding:"required,min=8" example:"SecurePass123!"`
+	FullName    string `json:"full_name" binding:"required,min=2,max=255" example:"Budi Santoso"`
+	PhoneNumber string `json:"phone_number" binding:"omitempty,e164" example:"+6281234567890"`
+}
+
+// TenantStatusRequest represents the request payload for suspending or closing a tenant
+type TenantStatusRequest struct {
+	Reason string `json:"reason" binding:"omitempty,max=500" example:"Subscription payment overdue"`
+}
+
+// ListActivityLogsRequest represents the query of a page of the activity log
+type ListActivityLogsRequest struct {
+	database.CursorPagination
+	Action       string `form:"action" binding:"omitempty,max=100" example:"login"`
+	ResourceType string `form:"resource_type" binding:"omitempty,max=100" example:"user"`
+	UserID       string `form:"user_id" binding:"omitempty,uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
 // AuthResponse represents the response payload for authentication
 type AuthResponse struct {
 	AccessToken  string    `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
@@ -82,6 +129,7 @@ type UserDTO struct {
 	LastLogin   *time.Time `json:"last_login,omitempty" example:"2024-01-15T10:30:00Z"`
 	CreatedAt   time.Time  `json:"created_at" example:"2024-01-01T00:00:00Z"`
 	UpdatedAt   time.Time  `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+	Version     int64      `json:"version" example:"3"`
 }
 
 // SessionDTO represents session data transferred in responses
@@ -114,14 +162,68 @@ type ActivityLogDTO struct {
 	CreatedAt    time.Time              `json:"created_at" example:"2024-01-15T10:30:00Z"`
 }
 
-// ErrorResponse represents the standard error response
-type ErrorResponse struct {
-	Error   string            `json:"error" example:"Validation failed"`
-	Message string            `json:"message" example:"Email is required"`
-	Code    string            `json:"code,omitempty" example:"VALIDATION_ERROR"`
-	Details map[string]string `json:"details,omitempty"`
+// TenantDTO represents tenant data transferred in responses
+type TenantDTO struct {
+	ID               uuid.UUID  `json:"id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Name             string     `json:"name" example:"PT Maju Jaya"`
+	Domain           *string    `json:"domain,omitempty" example:"erp.majujaya.co.id"`
+	Subdomain        *string    `json:"subdomain,omitempty" example:"majujaya"`
+	CompanyType      string     `json:"company_type" example:"pt"`
+	BusinessCategory string     `json:"business_category" example:"dagang"`
+	TaxNumber        string     `json:"tax_number,omitempty" example:"012345678901000"`
+	TaxStatus        string     `json:"tax_status" example:"pkp"`
+	Email            string     `json:"email" example:"admin@majujaya.co.id"`
+	Phone            string     `json:"phone,omitempty" example:"+62215551234"`
+	Address          string     `json:"address,omitempty" example:"Jl. Sudirman No. 1"`
+	CountryID        *uuid.UUID `json:"country_id,omitempty"`
+	ProvinceID       *uuid.UUID `json:"province_id,omitempty"`
+	CityID           *uuid.UUID `json:"city_id,omitempty"`
+	DistrictID       *uuid.UUID `json:"district_id,omitempty"`
+	VillageID        *uuid.UUID `json:"village_id,omitempty"`
+	PostalCode       string     `json:"postal_code,omitempty" example:"10220"`
+	IsActive         bool       `json:"is_active" example:"true"`
+	Status           string     `json:"status" example:"active"`
+	StatusReason     string     `json:"status_reason,omitempty" example:"Subscription payment overdue"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty" example:"2024-01-15T10:30:00Z"`
+	ClosedAt         *time.Time `json:"closed_at,omitempty" example:"2024-01-15T10:30:00Z"`
+	SubscriptionPlan string     `json:"subscription_plan" example:"basic"`
+	MaxUsers         int        `json:"max_users" example:"10"`
+	CreatedAt        time.Time  `json:"created_at" example:"2024-01-01T00:00:00Z"`
+	UpdatedAt        time.Time  `json:"updated_at" example:"2024-01-15T10:30:00Z"`
 }
 
+// TenantProvisionResponse represents the response payload for tenant provisioning
+type TenantProvisionResponse struct {
+	Tenant           *TenantDTO `json:"tenant"`
+	Admin            *UserDTO   `json:"admin"`
+	SeededAccounts   int        `json:"seeded_accounts" example:"21"`
+	SeededWarehouses int        `json:"seeded_warehouses" example:"1"`
+	SeededSequences  int        `json:"seeded_sequences" example:"6"`
+}
+
+// UsageItemDTO represents the usage of a single resource against its plan limit.
+// A limit of -1 means the resource is unlimited on the plan.
+type UsageItemDTO struct {
+	Resource    string  `json:"resource" example:"monthly_invoices"`
+	Period      string  `json:"period" example:"monthly"`
+	Used        int64   `json:"used" example:"420"`
+	Limit       int64   `json:"limit" example:"500"`
+	Remaining   int64   `json:"remaining" example:"80"`
+	PercentUsed float64 `json:"percent_used" example:"84"`
+}
+
+// TenantUsageDTO represents a tenant's usage against its subscription plan
+type TenantUsageDTO struct {
+	TenantID    uuid.UUID      `json:"tenant_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Plan        string         `json:"plan" example:"basic"`
+	PlanName    string         `json:"plan_name" example:"Basic"`
+	GeneratedAt time.Time      `json:"generated_at" example:"2024-01-15T10:30:00Z"`
+	Items       []UsageItemDTO `json:"items"`
+}
+
+// ErrorResponse represents the standard error response
+type ErrorResponse = apperror.Response
+
 // SuccessResponse represents the standard success response
 type SuccessResponse struct {
 	Success bool        `json:"success" example:"true"`
@@ -142,6 +244,14 @@ type PaginatedResponse struct {
 	HasPrevious bool        `json:"has_previous" example:"false"`
 }
 
+// CursorPaginatedResponse represents a page of a keyset paginated list
+type CursorPaginatedResponse struct {
+	Success    bool              `json:"success" example:"true"`
+	Message    string            `json:"message" example:"Data retrieved successfully"`
+	Data       interface{}       `json:"data"`
+	Pagination database.PageInfo `json:"pagination"`
+}
+
 // Validation helper functions
 
 // IsEmpty checks if a string pointer is nil or empty
@@ -171,6 +281,7 @@ func UserToDTO(user *model.User) *UserDTO {
 		LastLogin:   user.LastLogin,
 		CreatedAt:   user.CreatedAt,
 		UpdatedAt:   user.UpdatedAt,
+		Version:     user.Version,
 	}
 }
 
@@ -232,4 +343,67 @@ func ActivityLogToDTO(log *model.ActivityLog) *ActivityLogDTO {
 		Context:      context,
 		CreatedAt:    log.CreatedAt,
 	}
-}
\ No newline at end of file
+}
+
+// TenantToDTO converts a Tenant model to TenantDTO
+func TenantToDTO(tenant *model.Tenant) *TenantDTO {
+	if tenant == nil {
+		return nil
+	}
+
+	return &TenantDTO{
+		ID:               tenant.ID,
+		Name:             tenant.Name,
+		Domain:           tenant.Domain,
+		Subdomain:        tenant.Subdomain,
+		CompanyType:      string(tenant.CompanyType),
+		BusinessCategory: string(tenant.BusinessCategory),
+		TaxNumber:        tenant.TaxNumber,
+		TaxStatus:        string(tenant.TaxStatus),
+		Email:            tenant.Email,
+		Phone:            tenant.Phone,
+		Address:          tenant.Address,
+		CountryID:        tenant.CountryID,
+		ProvinceID:       tenant.ProvinceID,
+		CityID:           tenant.CityID,
+		DistrictID:       tenant.DistrictID,
+		VillageID:        tenant.VillageID,
+		PostalCode:       tenant.PostalCode,
+		IsActive:         tenant.IsActive,
+		Status:           string(tenant.Status),
+		StatusReason:     tenant.StatusReason,
+		SuspendedAt:      tenant.SuspendedAt,
+		ClosedAt:         tenant.ClosedAt,
+		SubscriptionPlan: tenant.SubscriptionPlan,
+		MaxUsers:         tenant.MaxUsers,
+		CreatedAt:        tenant.CreatedAt,
+		UpdatedAt:        tenant.UpdatedAt,
+	}
+}
+
+// UsageReportToDTO converts a subscription.UsageReport to TenantUsageDTO
+func UsageReportToDTO(report *subscription.UsageReport) *TenantUsageDTO {
+	if report == nil {
+		return nil
+	}
+
+	items := make([]UsageItemDTO, 0, len(report.Items))
+	for _, item := range report.Items {
+		items = append(items, UsageItemDTO{
+			Resource:    string(item.Resource),
+			Period:      string(item.Period),
+			Used:        item.Used,
+			Limit:       item.Limit,
+			Remaining:   item.Remaining,
+			PercentUsed: item.PercentUsed,
+		})
+	}
+
+	return &TenantUsageDTO{
+		TenantID:    report.TenantID,
+		Plan:        report.Plan,
+		PlanName:    report.PlanName,
+		GeneratedAt: report.GeneratedAt,
+		Items:       items,
+	}
+}
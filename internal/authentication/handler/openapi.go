@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/service"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/auditchain"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/middleware"
+	"github.com/VincentArjuna/RexiErp/internal/shared/openapi"
+	"github.com/VincentArjuna/RexiErp/internal/shared/region"
+	"github.com/VincentArjuna/RexiErp/internal/shared/settings"
+)
+
+// OpenAPIPath is where the generated document is published, relative to the
+// repository root
+const OpenAPIPath = "deployments/docker-compose/nginx/docs/authentication-service.yaml"
+
+// GenerateOpenAPI generates the OpenAPI document of the authentication
+// service from its route table. root is the repository root, used to read
+// the handler annotations.
+func GenerateOpenAPI(root string) ([]byte, error) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	// The route table only needs the handler and middleware values, so no
+	// services are wired in
+	noop := func(c *gin.Context) { c.Next() }
+	jwt := middleware.NewJWTMiddleware(nil, logger)
+	routes := &Routes{
+		Auth:               NewAuthHandler(nil, logger),
+		Tenant:             NewTenantHandler(nil, logger),
+		Region:             region.NewHandler(nil, logger),
+		Audit:              auditchain.NewHandler(nil, logger),
+		Settings:           settings.NewHandler(nil, logger),
+		JWT:                jwt,
+		RBAC:               middleware.NewRBACMiddleware(logger),
+		PlanLimit:          middleware.NewPlanLimitMiddleware(nil, logger),
+		Idempotency:        noop,
+		PublicRateLimit:    noop,
+		ProtectedRateLimit: noop,
+		TenantTransaction:  noop,
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	routes.Register(router.Group("/api/v1"))
+
+	return openapi.Generate(router.Routes(), openapi.GeneratorConfig{
+		Title:       "RexiERP Authentication Service API",
+		Description: "Authentication, tenant provisioning and region master data",
+		Version:     "1.0.0",
+		Servers:     []string{"http://localhost:8080/api/v1"},
+		SourceDirs: []string{
+			filepath.Join(root, "internal/authentication/handler"),
+			filepath.Join(root, "internal/authentication/service"),
+			filepath.Join(root, "internal/shared/apperror"),
+			filepath.Join(root, "internal/shared/region"),
+			filepath.Join(root, "internal/shared/auditchain"),
+			filepath.Join(root, "internal/shared/settings"),
+		},
+		Models: []interface{}{
+			RegisterRequest{},
+			LoginRequest{},
+			RefreshTokenRequest{},
+			UpdateProfileRequest{},
+			ChangePasswordRequest{},
+			PasswordResetRequest{},
+			ResetPasswordRequest{},
+			CreateTenantRequest{},
+			TenantStatusRequest{},
+			AuthResponse{},
+			PasswordResetResponse{},
+			UserDTO{},
+			SessionDTO{},
+			TenantDTO{},
+			TenantUsageDTO{},
+			TenantProvisionResponse{},
+			SuccessResponse{},
+			PaginatedResponse{},
+			CursorPaginatedResponse{},
+			ActivityLogDTO{},
+			database.PageInfo{},
+			apperror.Response{},
+			service.ResetTokenValidationResult{},
+			region.Province{},
+			region.City{},
+			region.District{},
+			region.Village{},
+			region.Hierarchy{},
+			database.ChainReport{},
+			database.ChainBreak{},
+			settings.SetSettingRequest{},
+			settings.Setting{},
+			settings.Value{},
+			settings.Definition{},
+		},
+		SecuritySchemes: map[string]openapi.SecurityScheme{
+			"BearerAuth": {
+				Type:         "http",
+				Description:  "Access token returned by /auth/login",
+				Scheme:       "bearer",
+				BearerFormat: "JWT",
+			},
+			"ApiKeyAuth": {
+				Type:        "apiKey",
+				Description: "Service API key for internal integrations",
+				Name:        "X-API-Key",
+				In:          "header",
+			},
+		},
+	})
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/openapi"
+)
+
+var update = flag.Bool("update", false, "update the generated OpenAPI document")
+
+const repoRoot = "../../.."
+
+// TestOpenAPIGolden fails when routes, annotations or DTOs change without
+// regenerating the published document with go run ./cmd/openapi-gen
+func TestOpenAPIGolden(t *testing.T) {
+	spec, err := GenerateOpenAPI(repoRoot)
+	require.NoError(t, err)
+
+	golden := filepath.Join(repoRoot, OpenAPIPath)
+	if *update {
+		require.NoError(t, os.WriteFile(golden, spec, 0644))
+	}
+
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(spec),
+		"%s is out of date, run go run ./cmd/openapi-gen", OpenAPIPath)
+}
+
+func TestOpenAPIDocumentIsValid(t *testing.T) {
+	spec, err := GenerateOpenAPI(repoRoot)
+	require.NoError(t, err)
+
+	doc, err := openapi.Parse(spec)
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+
+	// Routes the hand-written document was missing
+	for _, path := range []string{"/auth/validate-reset-token", "/auth/sessions", "/tenants/{id}/usage"} {
+		assert.Contains(t, doc.Paths, path)
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/authentication/repository"
+	"github.com/VincentArjuna/RexiErp/internal/authentication/service"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/middleware"
+)
+
+// discardActivityRepository drops the activity log of the profile changes
+type discardActivityRepository struct {
+	repository.ActivityRepository
+}
+
+func (discardActivityRepository) Create(context.Context, *model.ActivityLog) error {
+	return nil
+}
+
+// profileAuthService authenticates every token as user
+type profileAuthService struct {
+	service.AuthService
+	user *model.User
+}
+
+func (s *profileAuthService) ValidateToken(context.Context, string) (*service.TokenValidationResult, error) {
+	return &service.TokenValidationResult{
+		IsValid:   true,
+		UserID:    s.user.ID,
+		TenantID:  s.user.TenantID,
+		Role:      string(s.user.Role),
+		SessionID: uuid.NewString(),
+	}, nil
+}
+
+func newProfileRouter(t *testing.T) *gin.Engine {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	// The model defaults to gen_random_uuid(), which SQLite lacks
+	require.NoError(t, db.Exec(`CREATE TABLE users (
+		id TEXT PRIMARY KEY, tenant_id TEXT NOT NULL, email TEXT NOT NULL, password_hash TEXT NOT NULL,
+		full_name TEXT NOT NULL, phone_number TEXT, role TEXT NOT NULL, is_active BOOLEAN NOT NULL,
+		last_login DATETIME, created_at DATETIME NOT NULL, updated_at DATETIME NOT NULL, deleted_at DATETIME,
+		version INTEGER NOT NULL DEFAULT 1)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE user_sessions (
+		id TEXT PRIMARY KEY, user_id TEXT NOT NULL, tenant_id TEXT NOT NULL, session_id TEXT NOT NULL,
+		token_hash TEXT, refresh_token_hash TEXT, device_info TEXT, ip_address TEXT, user_agent TEXT,
+		expires_at DATETIME, last_activity DATETIME, is_active BOOLEAN, created_at DATETIME, updated_at DATETIME)`).Error)
+
+	user := &model.User{
+		TenantID:     uuid.New(),
+		Email:        "budi@majujaya.co.id",
+		PasswordHash: "bcrypt-hash",
+		FullName:     "Budi Santoso",
+		Role:         model.RoleStaff,
+		IsActive:     true,
+	}
+	require.NoError(t, db.Create(user).Error)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	auth := service.NewAuthService(
+		repository.NewUserRepository(&database.Database{DB: db}, logger),
+		nil, discardActivityRepository{}, nil, nil, nil, nil, nil, nil, logger, nil,
+	)
+	return newRoutesRouterWith(&profileAuthService{AuthService: auth, user: user}, &recordingTenantService{})
+}
+
+func updateProfile(router *gin.Engine, ifMatch, fullName string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/auth/profile", strings.NewReader(`{"full_name":"`+fullName+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer token")
+	if ifMatch != "" {
+		req.Header.Set(middleware.IfMatchHeader, ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestProfileOptimisticLocking(t *testing.T) {
+	router := newProfileRouter(t)
+
+	rec := serveAs(router, http.MethodGet, "/api/v1/auth/profile", "token")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	read := rec.Header().Get(middleware.ETagHeader)
+	assert.Equal(t, `"1"`, read)
+
+	// Changes must name the version they are based on
+	rec = updateProfile(router, "", "Budi")
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+
+	rec = updateProfile(router, read, "Budi Santoso Putra")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, `"2"`, rec.Header().Get(middleware.ETagHeader))
+
+	// A second client still holding the first version loses its update
+	rec = updateProfile(router, read, "Budi S.")
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code, rec.Body.String())
+
+	rec = serveAs(router, http.MethodGet, "/api/v1/auth/profile", "token")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"full_name":"Budi Santoso Putra"`)
+	assert.Equal(t, `"2"`, rec.Header().Get(middleware.ETagHeader))
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/auditchain"
+	"github.com/VincentArjuna/RexiErp/internal/shared/middleware"
+	"github.com/VincentArjuna/RexiErp/internal/shared/region"
+	"github.com/VincentArjuna/RexiErp/internal/shared/settings"
+)
+
+// Routes holds the handlers and middleware of the authentication service API
+type Routes struct {
+	Auth        *AuthHandler
+	Tenant      *TenantHandler
+	Region      *region.Handler
+	Audit       *auditchain.Handler
+	Settings    *settings.Handler
+	JWT         *middleware.JWTMiddleware
+	RBAC        *middleware.RBACMiddleware
+	PlanLimit   *middleware.PlanLimitMiddleware
+	Idempotency gin.HandlerFunc
+	// PublicRateLimit limits unauthenticated routes by client IP
+	PublicRateLimit gin.HandlerFunc
+	// ProtectedRateLimit limits authenticated routes by user and tenant
+	ProtectedRateLimit gin.HandlerFunc
+	// TenantTransaction runs authenticated routes of a tenant's own data
+	// under row-level security
+	TenantTransaction gin.HandlerFunc
+}
+
+// Register registers the API routes on the /api/v1 group
+func (r *Routes) Register(api *gin.RouterGroup) {
+	// Public routes (no authentication required). Idempotency is left out:
+	// without a tenant the replay key is scoped by client IP only, and these
+	// responses carry tokens.
+	auth := api.Group("/auth")
+	auth.Use(r.PublicRateLimit)
+	{
+		auth.POST("/register", r.Auth.Register)
+		auth.POST("/login", r.Auth.Login)
+		auth.POST("/refresh", r.Auth.RefreshToken)
+		auth.POST("/password-reset", r.Auth.RequestPasswordReset)
+		auth.GET("/validate-reset-token", r.Auth.ValidateResetToken)
+		auth.POST("/reset-password", r.Auth.ResetPassword)
+	}
+
+	// Protected routes (authentication required)
+	protected := api.Group("/auth")
+	protected.Use(r.JWT.RequireAuth())
+	protected.Use(r.ProtectedRateLimit)
+	protected.Use(r.PlanLimit.MeterAPICalls())
+	protected.Use(r.Idempotency)
+	protected.Use(r.TenantTransaction)
+	{
+		protected.POST("/logout", r.Auth.Logout)
+		protected.POST("/logout-all", r.Auth.LogoutAll)
+		protected.GET("/profile", r.Auth.GetProfile)
+		protected.PUT("/profile", middleware.RequireIfMatch(), r.Auth.UpdateProfile)
+		protected.POST("/change-password", r.Auth.ChangePassword)
+		protected.GET("/sessions", r.Auth.GetSessions)
+	}
+
+	// Tenant provisioning and lifecycle (platform administration)
+	tenants := api.Group("/tenants")
+	tenants.Use(r.JWT.RequireAuth())
+	tenants.Use(r.RBAC.RequireRole("super_admin"))
+	tenants.Use(r.Idempotency)
+	{
+		tenants.POST("", r.Tenant.CreateTenant)
+		tenants.GET("", r.Tenant.ListTenants)
+		tenants.GET("/:id", r.Tenant.GetTenant)
+		tenants.GET("/:id/usage", r.Tenant.GetTenantUsage)
+		tenants.POST("/:id/suspend", r.Tenant.SuspendTenant)
+		tenants.POST("/:id/reactivate", r.Tenant.ReactivateTenant)
+		tenants.POST("/:id/close", r.Tenant.CloseTenant)
+	}
+
+	// Region master data for address dropdowns
+	r.Region.RegisterRoutes(api)
+
+	// Audit trail integrity for SAK and tax audits
+	r.Audit.RegisterRoutes(api, r.JWT.RequireAuth(), r.RBAC.RequireRole("super_admin", "tenant_admin"))
+
+	// Settings and feature flags
+	r.Settings.RegisterRoutes(api, r.JWT.RequireAuth(), r.RBAC.RequireRole("super_admin", "tenant_admin"))
+
+	// Current user's tenant
+	api.GET("/tenant", r.JWT.RequireAuth(), r.TenantTransaction, r.Tenant.GetCurrentTenant)
+	api.GET("/tenant/usage", r.JWT.RequireAuth(), r.TenantTransaction, r.Tenant.GetCurrentTenantUsage)
+	api.GET("/tenant/activity-logs", r.JWT.RequireAuth(), r.RBAC.RequireRole("super_admin", "tenant_admin"), r.TenantTransaction, r.Tenant.ListActivityLogs)
+}
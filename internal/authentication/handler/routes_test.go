@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/authentication/repository"
+	"github.com/VincentArjuna/RexiErp/internal/authentication/service"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/auditchain"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/middleware"
+	"github.com/VincentArjuna/RexiErp/internal/shared/region"
+	"github.com/VincentArjuna/RexiErp/internal/shared/settings"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
+)
+
+// tokenAuthService accepts tokens named after the role they carry
+type tokenAuthService struct {
+	service.AuthService
+}
+
+func (s *tokenAuthService) ValidateToken(_ context.Context, token string) (*service.TokenValidationResult, error) {
+	switch token {
+	case "super_admin", "tenant_admin", "staff", "viewer":
+		return &service.TokenValidationResult{
+			IsValid:   true,
+			UserID:    uuid.New(),
+			TenantID:  uuid.New(),
+			Role:      token,
+			SessionID: uuid.NewString(),
+		}, nil
+	}
+	return nil, errors.New("invalid token")
+}
+
+// recordingTenantService counts the lifecycle calls that reach the service
+type recordingTenantService struct {
+	service.TenantService
+	calls int
+}
+
+func (s *recordingTenantService) CreateTenant(context.Context, *service.CreateTenantRequest) (*service.TenantProvisionResult, error) {
+	s.calls++
+	return nil, apperror.New(apperror.CodeTenantAlreadyExists, "Tenant already exists")
+}
+
+func (s *recordingTenantService) SuspendTenant(context.Context, uuid.UUID, string) (*model.Tenant, error) {
+	s.calls++
+	return nil, apperror.New(apperror.CodeTenantNotFound, "Tenant not found")
+}
+
+func (s *recordingTenantService) CloseTenant(context.Context, uuid.UUID, string) (*model.Tenant, error) {
+	s.calls++
+	return nil, apperror.New(apperror.CodeTenantNotFound, "Tenant not found")
+}
+
+func (s *recordingTenantService) ListActivityLogs(_ context.Context, tenantID uuid.UUID, _ repository.ActivityFilters, page database.CursorPagination) ([]*model.ActivityLog, database.PageInfo, error) {
+	s.calls++
+	logs := []*model.ActivityLog{{ID: uuid.New(), TenantID: tenantID, Action: "login", ResourceType: "user", Success: true}}
+	return logs, database.PageInfo{NextCursor: "next", HasMore: true, Limit: page.Limit}, nil
+}
+
+func newRoutesRouter(tenants service.TenantService) *gin.Engine {
+	return newRoutesRouterWith(&tokenAuthService{}, tenants)
+}
+
+// newRoutesRouterWith registers the routes over auth, which also validates
+// the bearer tokens
+func newRoutesRouterWith(auth service.AuthService, tenants service.TenantService) *gin.Engine {
+	return serveRoutes(newTestRoutes(auth, tenants))
+}
+
+func newTestRoutes(auth service.AuthService, tenants service.TenantService) *Routes {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	noop := func(c *gin.Context) { c.Next() }
+	// Metering failures let requests through, so no plan needs resolving
+	plans := subscription.PlanResolverFunc(func(context.Context, uuid.UUID) (*subscription.TenantPlan, error) {
+		return nil, errors.New("no plan")
+	})
+	enforcer := subscription.NewEnforcer(subscription.DefaultCatalogue(), plans, nil, time.UTC, logger)
+
+	return &Routes{
+		Auth:               NewAuthHandler(auth, logger),
+		Tenant:             NewTenantHandler(tenants, logger),
+		Region:             region.NewHandler(nil, logger),
+		Audit:              auditchain.NewHandler(nil, logger),
+		Settings:           settings.NewHandler(nil, logger),
+		JWT:                middleware.NewJWTMiddleware(auth, logger),
+		RBAC:               middleware.NewRBACMiddleware(logger),
+		PlanLimit:          middleware.NewPlanLimitMiddleware(enforcer, logger),
+		Idempotency:        noop,
+		PublicRateLimit:    noop,
+		ProtectedRateLimit: noop,
+		TenantTransaction:  noop,
+	}
+}
+
+func serveRoutes(routes *Routes) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	router := gin.New()
+	router.Use(apperror.Middleware(logger))
+	routes.Register(router.Group("/api/v1"))
+	return router
+}
+
+func serveAs(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(`{"name":"PT Maju Jaya"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTenantRoutesRejectNonSuperAdmin(t *testing.T) {
+	tenants := &recordingTenantService{}
+	router := newRoutesRouter(tenants)
+	id := uuid.NewString()
+
+	for _, role := range []string{"tenant_admin", "staff", "viewer"} {
+		for _, path := range []string{"/api/v1/tenants", "/api/v1/tenants/" + id + "/suspend", "/api/v1/tenants/" + id + "/close"} {
+			rec := serveAs(router, http.MethodPost, path, role)
+			assert.Equal(t, http.StatusForbidden, rec.Code, "%s %s", role, path)
+			assert.Contains(t, rec.Body.String(), string(apperror.CodeInsufficientPermissions))
+		}
+	}
+
+	assert.Zero(t, tenants.calls, "tenant handlers must not run for other roles")
+}
+
+func TestTenantRoutesRequireToken(t *testing.T) {
+	tenants := &recordingTenantService{}
+	router := newRoutesRouter(tenants)
+
+	rec := serveAs(router, http.MethodPost, "/api/v1/tenants", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serveAs(router, http.MethodPost, "/api/v1/tenants", "forged")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Zero(t, tenants.calls)
+}
+
+func TestTenantRoutesAllowSuperAdmin(t *testing.T) {
+	tenants := &recordingTenantService{}
+	router := newRoutesRouter(tenants)
+
+	rec := serveAs(router, http.MethodPost, "/api/v1/tenants/"+uuid.NewString()+"/suspend", "super_admin")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, 1, tenants.calls)
+}
+
+func TestAuditRoutesRejectNonAdmin(t *testing.T) {
+	router := newRoutesRouter(&recordingTenantService{})
+
+	for _, role := range []string{"staff", "viewer"} {
+		rec := serveAs(router, http.MethodGet, "/api/v1/audit/chain/verify", role)
+		assert.Equal(t, http.StatusForbidden, rec.Code, role)
+		assert.Contains(t, rec.Body.String(), string(apperror.CodeInsufficientPermissions))
+	}
+
+	rec := serveAs(router, http.MethodGet, "/api/v1/audit/chain/verify", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestSettingsRoutesRejectNonAdmin(t *testing.T) {
+	router := newRoutesRouter(&recordingTenantService{})
+
+	for _, role := range []string{"staff", "viewer"} {
+		rec := serveAs(router, http.MethodPut, "/api/v1/settings/ppn_12_percent", role)
+		assert.Equal(t, http.StatusForbidden, rec.Code, role)
+
+		rec = serveAs(router, http.MethodDelete, "/api/v1/settings/ppn_12_percent", role)
+		assert.Equal(t, http.StatusForbidden, rec.Code, role)
+	}
+
+	rec := serveAs(router, http.MethodPut, "/api/v1/settings/ppn_12_percent", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestActivityLogRoutesReturnCursorPage(t *testing.T) {
+	tenants := &recordingTenantService{}
+	router := newRoutesRouter(tenants)
+
+	rec := serveAs(router, http.MethodGet, "/api/v1/tenant/activity-logs?limit=1", "tenant_admin")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var page struct {
+		Data       []ActivityLogDTO  `json:"data"`
+		Pagination database.PageInfo `json:"pagination"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Len(t, page.Data, 1)
+	assert.Equal(t, database.PageInfo{NextCursor: "next", HasMore: true, Limit: 1}, page.Pagination)
+
+	rec = serveAs(router, http.MethodGet, "/api/v1/tenant/activity-logs", "staff")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, 1, tenants.calls)
+}
+
+func TestPublicAuthRoutesSkipIdempotency(t *testing.T) {
+	routes := newTestRoutes(&tokenAuthService{}, &recordingTenantService{})
+	var guarded []string
+	routes.Idempotency = func(c *gin.Context) {
+		guarded = append(guarded, c.FullPath())
+		c.Next()
+	}
+	router := serveRoutes(routes)
+
+	for _, path := range []string{"/api/v1/auth/register", "/api/v1/auth/login", "/api/v1/auth/refresh", "/api/v1/auth/reset-password"} {
+		serveAs(router, http.MethodPost, path, "")
+	}
+	assert.Empty(t, guarded, "token responses must not be replayable by IP")
+
+	serveAs(router, http.MethodPost, "/api/v1/auth/change-password", "staff")
+	assert.Equal(t, []string{"/api/v1/auth/change-password"}, guarded)
+}
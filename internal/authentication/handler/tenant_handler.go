@@ -0,0 +1,465 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/authentication/repository"
+	"github.com/VincentArjuna/RexiErp/internal/authentication/service"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/region"
+)
+
+// TenantHandler handles tenant provisioning and lifecycle HTTP requests
+type TenantHandler struct {
+	tenantService service.TenantService
+	logger        *logrus.Logger
+}
+
+// NewTenantHandler creates a new instance of TenantHandler
+func NewTenantHandler(tenantService service.TenantService, logger *logrus.Logger) *TenantHandler {
+	return &TenantHandler{
+		tenantService: tenantService,
+		logger:        logger,
+	}
+}
+
+// CreateTenant handles tenant provisioning
+// @Summary Provision a new tenant
+// @Description Creates a tenant with its first tenant_admin and seeds the default chart of accounts, warehouse and numbering sequences
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateTenantRequest true "Create tenant request"
+// @Success 201 {object} SuccessResponse{data=TenantProvisionResponse}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenants [post]
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+	var req CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	result, err := h.tenantService.CreateTenant(c.Request.Context(), &service.CreateTenantRequest{
+		Name:             req.Name,
+		Domain:           req.Domain,
+		Subdomain:        req.Subdomain,
+		CompanyType:      req.CompanyType,
+		BusinessCategory: req.BusinessCategory,
+		TaxNumber:        req.TaxNumber,
+		TaxStatus:        req.TaxStatus,
+		Email:            req.Email,
+		Phone:            req.Phone,
+		Address:          req.Address,
+		CountryID:        req.CountryID,
+		ProvinceID:       req.ProvinceID,
+		CityID:           req.CityID,
+		DistrictID:       req.DistrictID,
+		VillageID:        req.VillageID,
+		PostalCode:       req.PostalCode,
+		SubscriptionPlan: req.SubscriptionPlan,
+		MaxUsers:         req.MaxUsers,
+		Admin: service.TenantAdminRequest{
+			Email:       req.Admin.Email,
+			Password:    req.Admin.Password,
+			FullName:    req.Admin.FullName,
+			PhoneNumber: req.Admin.PhoneNumber,
+		},
+	})
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"name":  req.Name,
+			"email": req.Email,
+			"error": err,
+		}).Error("Tenant provisioning failed")
+
+		if errors.Is(err, region.ErrInvalidAddress) {
+			apperror.Respond(c, apperror.Wrap(err, apperror.CodeInvalidAddress, "invalid address"))
+			return
+		}
+
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Success: true,
+		Message: "Tenant provisioned successfully",
+		Data: TenantProvisionResponse{
+			Tenant:           TenantToDTO(result.Tenant),
+			Admin:            UserToDTO(result.Admin),
+			SeededAccounts:   result.SeededAccounts,
+			SeededWarehouses: result.SeededWarehouses,
+			SeededSequences:  result.SeededSequences,
+		},
+	})
+}
+
+// ListTenants handles tenant listing
+// @Summary List tenants
+// @Description Lists tenants with optional status filter and pagination
+// @Tags tenants
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status (active, suspended, closed)"
+// @Param page query int false "Page number"
+// @Param per_page query int false "Items per page"
+// @Success 200 {object} PaginatedResponse{data=[]TenantDTO}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenants [get]
+func (h *TenantHandler) ListTenants(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(database.DefaultPageSize)))
+	if perPage < 1 {
+		perPage = database.DefaultPageSize
+	}
+	if perPage > database.MaxPageSize {
+		perPage = database.MaxPageSize
+	}
+
+	tenants, total, err := h.tenantService.ListTenants(c.Request.Context(), c.Query("status"), perPage, (page-1)*perPage)
+	if err != nil {
+		h.logger.WithField("error", err).Error("Failed to list tenants")
+
+		apperror.Respond(c, err)
+		return
+	}
+
+	tenantDTOs := make([]*TenantDTO, len(tenants))
+	for i, tenant := range tenants {
+		tenantDTOs[i] = TenantToDTO(tenant)
+	}
+
+	totalPages := int((total + int64(perPage) - 1) / int64(perPage))
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Success:     true,
+		Message:     "Tenants retrieved successfully",
+		Data:        tenantDTOs,
+		Total:       total,
+		Page:        page,
+		PerPage:     perPage,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	})
+}
+
+// GetTenant handles retrieval of a single tenant
+// @Summary Get tenant
+// @Description Retrieves a tenant by ID
+// @Tags tenants
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} SuccessResponse{data=TenantDTO}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenants/{id} [get]
+func (h *TenantHandler) GetTenant(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperror.Respond(c, apperror.Validation(apperror.Field("id", "uuid", "tenant ID format is invalid")))
+		return
+	}
+
+	h.respondWithTenant(c, tenantID)
+}
+
+// GetCurrentTenant handles retrieval of the authenticated user's tenant
+// @Summary Get current tenant
+// @Description Retrieves the tenant of the authenticated user
+// @Tags tenants
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse{data=TenantDTO}
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenant [get]
+func (h *TenantHandler) GetCurrentTenant(c *gin.Context) {
+	tenantUUID, ok := currentTenantID(c)
+	if !ok {
+		return
+	}
+
+	h.respondWithTenant(c, tenantUUID)
+}
+
+// GetTenantUsage handles retrieval of a tenant's plan usage
+// @Summary Get tenant usage
+// @Description Reports a tenant's resource usage against its subscription plan limits
+// @Tags tenants
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} SuccessResponse{data=TenantUsageDTO}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenants/{id}/usage [get]
+func (h *TenantHandler) GetTenantUsage(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperror.Respond(c, apperror.Validation(apperror.Field("id", "uuid", "tenant ID format is invalid")))
+		return
+	}
+
+	h.respondWithUsage(c, tenantID)
+}
+
+// GetCurrentTenantUsage handles retrieval of the authenticated user's tenant usage
+// @Summary Get current tenant usage
+// @Description Reports how close the authenticated user's tenant is to its subscription plan limits
+// @Tags tenants
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse{data=TenantUsageDTO}
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenant/usage [get]
+func (h *TenantHandler) GetCurrentTenantUsage(c *gin.Context) {
+	tenantUUID, ok := currentTenantID(c)
+	if !ok {
+		return
+	}
+
+	h.respondWithUsage(c, tenantUUID)
+}
+
+// SuspendTenant handles tenant suspension
+// @Summary Suspend tenant
+// @Description Suspends an active tenant and revokes all of its sessions
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tenant ID"
+// @Param request body TenantStatusRequest false "Suspension reason"
+// @Success 200 {object} SuccessResponse{data=TenantDTO}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenants/{id}/suspend [post]
+func (h *TenantHandler) SuspendTenant(c *gin.Context) {
+	h.changeStatus(c, model.TenantStatusSuspended)
+}
+
+// ReactivateTenant handles tenant reactivation
+// @Summary Reactivate tenant
+// @Description Reactivates a suspended tenant
+// @Tags tenants
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} SuccessResponse{data=TenantDTO}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenants/{id}/reactivate [post]
+func (h *TenantHandler) ReactivateTenant(c *gin.Context) {
+	h.changeStatus(c, model.TenantStatusActive)
+}
+
+// CloseTenant handles tenant closure
+// @Summary Close tenant
+// @Description Permanently closes a tenant and revokes all of its sessions
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tenant ID"
+// @Param request body TenantStatusRequest false "Closure reason"
+// @Success 200 {object} SuccessResponse{data=TenantDTO}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenants/{id}/close [post]
+func (h *TenantHandler) CloseTenant(c *gin.Context) {
+	h.changeStatus(c, model.TenantStatusClosed)
+}
+
+// Helper functions
+
+func (h *TenantHandler) changeStatus(c *gin.Context, target model.TenantStatus) {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperror.Respond(c, apperror.Validation(apperror.Field("id", "uuid", "tenant ID format is invalid")))
+		return
+	}
+
+	var req TenantStatusRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apperror.Respond(c, err)
+			return
+		}
+	}
+
+	var tenant *model.Tenant
+	switch target {
+	case model.TenantStatusSuspended:
+		tenant, err = h.tenantService.SuspendTenant(c.Request.Context(), tenantID, req.Reason)
+	case model.TenantStatusClosed:
+		tenant, err = h.tenantService.CloseTenant(c.Request.Context(), tenantID, req.Reason)
+	default:
+		tenant, err = h.tenantService.ReactivateTenant(c.Request.Context(), tenantID)
+	}
+
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"target":    target,
+			"error":     err,
+		}).Error("Failed to change tenant status")
+
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "Tenant status updated successfully",
+		Data:    TenantToDTO(tenant),
+	})
+}
+
+func (h *TenantHandler) respondWithTenant(c *gin.Context, tenantID uuid.UUID) {
+	tenant, err := h.tenantService.GetTenant(c.Request.Context(), tenantID)
+	if err != nil {
+
+		h.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"error":     err,
+		}).Error("Failed to get tenant")
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "Tenant retrieved successfully",
+		Data:    TenantToDTO(tenant),
+	})
+}
+
+func (h *TenantHandler) respondWithUsage(c *gin.Context, tenantID uuid.UUID) {
+	report, err := h.tenantService.GetTenantUsage(c.Request.Context(), tenantID)
+	if err != nil {
+
+		h.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"error":     err,
+		}).Error("Failed to get tenant usage")
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "Tenant usage retrieved successfully",
+		Data:    UsageReportToDTO(report),
+	})
+}
+
+// ListActivityLogs handles listing of the authenticated user's tenant activity log
+// @Summary List current tenant activity log
+// @Description Lists the activity log of the authenticated user's tenant one keyset page at a time, newest first by default. Pass next_cursor as cursor, with the same sort_by and sort_dir, for the following page.
+// @Tags tenants
+// @Produce json
+// @Security BearerAuth
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Items per page"
+// @Param sort_by query string false "Sort field (created_at, action)"
+// @Param sort_dir query string false "Sort direction (asc, desc)"
+// @Param action query string false "Filter by action"
+// @Param resource_type query string false "Filter by resource type"
+// @Param user_id query string false "Filter by user ID"
+// @Success 200 {object} CursorPaginatedResponse{data=[]ActivityLogDTO}
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenant/activity-logs [get]
+func (h *TenantHandler) ListActivityLogs(c *gin.Context) {
+	tenantID, ok := currentTenantID(c)
+	if !ok {
+		return
+	}
+
+	var req ListActivityLogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	filters := repository.ActivityFilters{
+		Action:       req.Action,
+		ResourceType: req.ResourceType,
+	}
+	if req.UserID != "" {
+		userID := uuid.MustParse(req.UserID)
+		filters.UserID = &userID
+	}
+
+	logs, pageInfo, err := h.tenantService.ListActivityLogs(c.Request.Context(), tenantID, filters, req.CursorPagination)
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	logDTOs := make([]*ActivityLogDTO, len(logs))
+	for i, log := range logs {
+		logDTOs[i] = ActivityLogToDTO(log)
+	}
+
+	c.JSON(http.StatusOK, CursorPaginatedResponse{
+		Success:    true,
+		Message:    "Activity logs retrieved successfully",
+		Data:       logDTOs,
+		Pagination: pageInfo,
+	})
+}
+
+// currentTenantID returns the tenant of the authenticated user, responding
+// with an error when the request has no tenant context
+func currentTenantID(c *gin.Context) (uuid.UUID, bool) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "tenant context not found"))
+		return uuid.Nil, false
+	}
+
+	tenantUUID, ok := tenantID.(uuid.UUID)
+	if !ok {
+		apperror.Respond(c, apperror.New(apperror.CodeUnauthorized, "tenant ID format is invalid"))
+		return uuid.Nil, false
+	}
+
+	return tenantUUID, true
+}
@@ -6,10 +6,14 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
 )
 
-// ActivityLog represents an activity log entry
+// ActivityLog represents an activity log entry. Entries are hash chained
+// per tenant so edits to the history can be detected.
 type ActivityLog struct {
+	database.ChainLink
 	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	UserID       *uuid.UUID `gorm:"type:uuid;index:idx_activity_user" json:"user_id"`
 	TenantID     uuid.UUID  `gorm:"type:uuid;not null;index:idx_activity_tenant" json:"tenant_id"`
@@ -46,6 +50,40 @@ func (ActivityLog) TableName() string {
 	return "activity_logs"
 }
 
+// SortFields declares the fields activity log lists may be sorted by
+func (ActivityLog) SortFields() []database.SortField {
+	return []database.SortField{
+		{Name: "created_at", Column: "created_at", Desc: true},
+		{Name: "action", Column: "action"},
+	}
+}
+
+// ChainTenantID implements database.Chained
+func (al *ActivityLog) ChainTenantID() *uuid.UUID {
+	return &al.TenantID
+}
+
+// ChainPayload implements database.Chained
+func (al *ActivityLog) ChainPayload() interface{} {
+	return map[string]interface{}{
+		"id":            al.ID,
+		"user_id":       al.UserID,
+		"tenant_id":     al.TenantID,
+		"action":        al.Action,
+		"resource_type": al.ResourceType,
+		"resource_id":   al.ResourceID,
+		"old_values":    al.OldValues,
+		"new_values":    al.NewValues,
+		"ip_address":    al.IPAddress,
+		"user_agent":    al.UserAgent,
+		"session_id":    al.SessionID,
+		"success":       al.Success,
+		"error_message": al.ErrorMessage,
+		"context":       al.Context,
+		"created_at":    al.CreatedAt.UTC(),
+	}
+}
+
 // BeforeCreate is a GORM hook that runs before creating an activity log
 func (al *ActivityLog) BeforeCreate(tx *gorm.DB) error {
 	if al.ID == uuid.Nil {
@@ -1,19 +1,5 @@
 package model
 
-import (
-	"github.com/VincentArjuna/RexiErp/internal/shared/database"
-)
-
-// AutoMigrate runs auto migration for all authentication models
-func AutoMigrate(db *database.Database) error {
-	return db.DB.AutoMigrate(
-		&User{},
-		&UserSession{},
-		&ActivityLog{},
-		&PasswordResetToken{},
-	)
-}
-
 // ModelValidationErrors represents collection of validation errors
 type ModelValidationErrors struct {
 	Errors map[string]string `json:"errors"`
@@ -0,0 +1,214 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/region"
+)
+
+// CompanyType represents the legal entity type of a tenant
+type CompanyType string
+
+const (
+	CompanyTypePT       CompanyType = "pt"
+	CompanyTypeCV       CompanyType = "cv"
+	CompanyTypeFirm     CompanyType = "firm"
+	CompanyTypeUD       CompanyType = "ud"
+	CompanyTypeKoperasi CompanyType = "koperasi"
+	CompanyTypeYayasan  CompanyType = "yayasan"
+	CompanyTypeLainnya  CompanyType = "lainnya"
+)
+
+// BusinessCategory represents the main business line of a tenant
+type BusinessCategory string
+
+const (
+	BusinessCategoryDagang       BusinessCategory = "dagang"
+	BusinessCategoryJasa         BusinessCategory = "jasa"
+	BusinessCategoryManufaktur   BusinessCategory = "manufaktur"
+	BusinessCategoryPertanian    BusinessCategory = "pertanian"
+	BusinessCategoryKonstruksi   BusinessCategory = "konstruksi"
+	BusinessCategoryTransportasi BusinessCategory = "transportasi"
+	BusinessCategoryLainnya      BusinessCategory = "lainnya"
+)
+
+// TaxStatus represents whether a tenant is a PKP (Pengusaha Kena Pajak)
+type TaxStatus string
+
+const (
+	TaxStatusPKP    TaxStatus = "pkp"
+	TaxStatusNonPKP TaxStatus = "non_pkp"
+)
+
+// TenantStatus represents the lifecycle status of a tenant
+type TenantStatus string
+
+const (
+	TenantStatusActive    TenantStatus = "active"
+	TenantStatusSuspended TenantStatus = "suspended"
+	TenantStatusClosed    TenantStatus = "closed"
+)
+
+// Tenant represents a company subscribed to RexiERP
+type Tenant struct {
+	ID               uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name             string           `gorm:"type:varchar(200);not null" json:"name"`
+	Domain           *string          `gorm:"type:varchar(100);uniqueIndex" json:"domain,omitempty"`
+	Subdomain        *string          `gorm:"type:varchar(100);uniqueIndex" json:"subdomain,omitempty"`
+	CompanyType      CompanyType      `gorm:"type:company_type;not null" json:"company_type"`
+	BusinessCategory BusinessCategory `gorm:"type:business_category;not null" json:"business_category"`
+	TaxNumber        string           `gorm:"type:varchar(50)" json:"tax_number,omitempty"`
+	TaxStatus        TaxStatus        `gorm:"type:tax_status;default:'non_pkp'" json:"tax_status"`
+	Email            string           `gorm:"type:varchar(255);not null;uniqueIndex" json:"email"`
+	Phone            string           `gorm:"type:varchar(50)" json:"phone,omitempty"`
+	Address          string           `gorm:"type:text" json:"address,omitempty"`
+	CountryID        *uuid.UUID       `gorm:"type:uuid" json:"country_id,omitempty"`
+	ProvinceID       *uuid.UUID       `gorm:"type:uuid" json:"province_id,omitempty"`
+	CityID           *uuid.UUID       `gorm:"type:uuid" json:"city_id,omitempty"`
+	DistrictID       *uuid.UUID       `gorm:"type:uuid" json:"district_id,omitempty"`
+	VillageID        *uuid.UUID       `gorm:"type:uuid" json:"village_id,omitempty"`
+	PostalCode       string           `gorm:"type:varchar(10)" json:"postal_code,omitempty"`
+	IsActive         bool             `gorm:"not null;default:true" json:"is_active"`
+	Status           TenantStatus     `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	StatusReason     string           `gorm:"type:text" json:"status_reason,omitempty"`
+	SuspendedAt      *time.Time       `json:"suspended_at,omitempty"`
+	ClosedAt         *time.Time       `json:"closed_at,omitempty"`
+	SubscriptionPlan string           `gorm:"type:varchar(50);default:'basic'" json:"subscription_plan"`
+	MaxUsers         int              `gorm:"default:10" json:"max_users"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+}
+
+// TableName returns the table name for the Tenant model
+func (Tenant) TableName() string {
+	return "tenants"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a tenant
+func (t *Tenant) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	if t.Status == "" {
+		t.Status = TenantStatusActive
+	}
+	t.IsActive = t.Status == TenantStatusActive
+	return nil
+}
+
+// IsActiveTenant checks if the tenant is allowed to use the system
+func (t *Tenant) IsActiveTenant() bool {
+	return t.IsActive && t.Status == TenantStatusActive
+}
+
+// IsClosed checks if the tenant has been permanently closed
+func (t *Tenant) IsClosed() bool {
+	return t.Status == TenantStatusClosed
+}
+
+// IsPKP checks if the tenant is registered as a PKP and must charge PPN
+func (t *Tenant) IsPKP() bool {
+	return t.TaxStatus == TaxStatusPKP
+}
+
+// CanTransitionTo checks if the tenant may move to the given lifecycle status
+func (t *Tenant) CanTransitionTo(status TenantStatus) bool {
+	switch t.Status {
+	case TenantStatusActive:
+		return status == TenantStatusSuspended || status == TenantStatusClosed
+	case TenantStatusSuspended:
+		return status == TenantStatusActive || status == TenantStatusClosed
+	default:
+		// Closed is terminal
+		return false
+	}
+}
+
+// Suspend marks the tenant as suspended
+func (t *Tenant) Suspend(reason string) {
+	now := time.Now()
+	t.Status = TenantStatusSuspended
+	t.StatusReason = reason
+	t.SuspendedAt = &now
+	t.IsActive = false
+}
+
+// Reactivate marks a suspended tenant as active again
+func (t *Tenant) Reactivate() {
+	t.Status = TenantStatusActive
+	t.StatusReason = ""
+	t.SuspendedAt = nil
+	t.IsActive = true
+}
+
+// Close permanently closes the tenant
+func (t *Tenant) Close(reason string) {
+	now := time.Now()
+	t.Status = TenantStatusClosed
+	t.StatusReason = reason
+	t.ClosedAt = &now
+	t.IsActive = false
+}
+
+// RegionAddress returns the region references of the tenant address.
+// It implements region.Addressable so the address is validated on write.
+func (t *Tenant) RegionAddress() region.Address {
+	return region.Address{
+		CountryID:  t.CountryID,
+		ProvinceID: t.ProvinceID,
+		CityID:     t.CityID,
+		DistrictID: t.DistrictID,
+		VillageID:  t.VillageID,
+	}
+}
+
+// IsValidCompanyType checks if the company type is supported
+func IsValidCompanyType(companyType string) bool {
+	switch CompanyType(companyType) {
+	case CompanyTypePT, CompanyTypeCV, CompanyTypeFirm, CompanyTypeUD,
+		CompanyTypeKoperasi, CompanyTypeYayasan, CompanyTypeLainnya:
+		return true
+	}
+	return false
+}
+
+// IsValidBusinessCategory checks if the business category is supported
+func IsValidBusinessCategory(category string) bool {
+	switch BusinessCategory(category) {
+	case BusinessCategoryDagang, BusinessCategoryJasa, BusinessCategoryManufaktur,
+		BusinessCategoryPertanian, BusinessCategoryKonstruksi, BusinessCategoryTransportasi,
+		BusinessCategoryLainnya:
+		return true
+	}
+	return false
+}
+
+var npwpSeparators = regexp.MustCompile(`[.\-\s]`)
+
+// NormalizeNPWP strips formatting from an NPWP and validates its length.
+// Both the legacy 15 digit format (99.999.999.9-999.999) and the 16 digit
+// NIK-based format introduced in 2024 are accepted.
+func NormalizeNPWP(npwp string) (string, error) {
+	digits := npwpSeparators.ReplaceAllString(strings.TrimSpace(npwp), "")
+	if digits == "" {
+		return "", fmt.Errorf("NPWP is required")
+	}
+
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("NPWP must contain digits only")
+		}
+	}
+
+	if len(digits) != 15 && len(digits) != 16 {
+		return "", fmt.Errorf("NPWP must be 15 or 16 digits")
+	}
+
+	return digits, nil
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChartOfAccount represents an account seeded into a tenant's chart of accounts
+type ChartOfAccount struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_coa_tenant_code" json:"tenant_id"`
+	Code        string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_coa_tenant_code" json:"code"`
+	Name        string     `gorm:"type:varchar(200);not null" json:"name"`
+	Description string     `gorm:"type:text" json:"description,omitempty"`
+	AccountType string     `gorm:"type:varchar(50);not null" json:"account_type"`
+	ParentID    *uuid.UUID `gorm:"type:uuid" json:"parent_id,omitempty"`
+	IsActive    bool       `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the ChartOfAccount model
+func (ChartOfAccount) TableName() string {
+	return "chart_of_accounts"
+}
+
+// Warehouse represents a tenant warehouse
+type Warehouse struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_warehouse_tenant_code" json:"tenant_id"`
+	Code      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_warehouse_tenant_code" json:"code"`
+	Name      string    `gorm:"type:varchar(200);not null" json:"name"`
+	Address   string    `gorm:"type:text" json:"address,omitempty"`
+	IsActive  bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for the Warehouse model
+func (Warehouse) TableName() string {
+	return "warehouses"
+}
+
+// NumberingSequence represents a per-tenant document numbering sequence
+type NumberingSequence struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_numbering_tenant_document" json:"tenant_id"`
+	DocumentType string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_numbering_tenant_document" json:"document_type"`
+	Prefix       string    `gorm:"type:varchar(20);not null" json:"prefix"`
+	NextNumber   int64     `gorm:"not null;default:1" json:"next_number"`
+	Padding      int       `gorm:"not null;default:6" json:"padding"`
+	ResetPeriod  string    `gorm:"type:varchar(20);not null;default:'yearly'" json:"reset_period"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for the NumberingSequence model
+func (NumberingSequence) TableName() string {
+	return "numbering_sequences"
+}
+
+// TenantDefaults holds the master data seeded when a tenant is provisioned
+type TenantDefaults struct {
+	Accounts   []*ChartOfAccount
+	Warehouses []*Warehouse
+	Sequences  []*NumberingSequence
+}
+
+// defaultAccount describes one entry of the default chart of accounts
+type defaultAccount struct {
+	code        string
+	name        string
+	accountType string
+	parentCode  string
+	pkpOnly     bool
+}
+
+// defaultAccounts is a simplified SAK EMKM chart of accounts
+var defaultAccounts = []defaultAccount{
+	{code: "1000", name: "Aset", accountType: "asset"},
+	{code: "1100", name: "Kas dan Setara Kas", accountType: "asset", parentCode: "1000"},
+	{code: "1110", name: "Kas", accountType: "asset", parentCode: "1100"},
+	{code: "1120", name: "Bank", accountType: "asset", parentCode: "1100"},
+	{code: "1200", name: "Piutang Usaha", accountType: "asset", parentCode: "1000"},
+	{code: "1300", name: "Persediaan", accountType: "asset", parentCode: "1000"},
+	{code: "1400", name: "PPN Masukan", accountType: "asset", parentCode: "1000", pkpOnly: true},
+	{code: "1500", name: "Aset Tetap", accountType: "asset", parentCode: "1000"},
+	{code: "2000", name: "Liabilitas", accountType: "liability"},
+	{code: "2100", name: "Utang Usaha", accountType: "liability", parentCode: "2000"},
+	{code: "2200", name: "PPN Keluaran", accountType: "liability", parentCode: "2000", pkpOnly: true},
+	{code: "2300", name: "Utang Pajak", accountType: "liability", parentCode: "2000"},
+	{code: "3000", name: "Ekuitas", accountType: "equity"},
+	{code: "3100", name: "Modal Disetor", accountType: "equity", parentCode: "3000"},
+	{code: "3200", name: "Laba Ditahan", accountType: "equity", parentCode: "3000"},
+	{code: "4000", name: "Pendapatan", accountType: "revenue"},
+	{code: "4100", name: "Penjualan", accountType: "revenue", parentCode: "4000"},
+	{code: "4200", name: "Retur Penjualan", accountType: "revenue", parentCode: "4000"},
+	{code: "5000", name: "Beban Pokok Penjualan", accountType: "expense"},
+	{code: "6000", name: "Beban Operasional", accountType: "expense"},
+	{code: "6100", name: "Beban Gaji", accountType: "expense", parentCode: "6000"},
+	{code: "6200", name: "Beban Sewa", accountType: "expense", parentCode: "6000"},
+	{code: "6300", name: "Beban Listrik, Air dan Telepon", accountType: "expense", parentCode: "6000"},
+}
+
+// defaultSequences maps document types to their numbering prefix
+var defaultSequences = []struct {
+	documentType string
+	prefix       string
+}{
+	{documentType: "sales_order", prefix: "SO"},
+	{documentType: "purchase_order", prefix: "PO"},
+	{documentType: "invoice", prefix: "INV"},
+	{documentType: "payment", prefix: "PAY"},
+	{documentType: "journal_entry", prefix: "JV"},
+	{documentType: "inventory_movement", prefix: "IM"},
+}
+
+// NewTenantDefaults builds the default master data for a newly provisioned tenant.
+// PPN accounts are only seeded for PKP tenants.
+func NewTenantDefaults(tenant *Tenant) *TenantDefaults {
+	defaults := &TenantDefaults{}
+
+	accountIDs := make(map[string]uuid.UUID, len(defaultAccounts))
+	for _, def := range defaultAccounts {
+		if def.pkpOnly && !tenant.IsPKP() {
+			continue
+		}
+
+		account := &ChartOfAccount{
+			ID:          uuid.New(),
+			TenantID:    tenant.ID,
+			Code:        def.code,
+			Name:        def.name,
+			AccountType: def.accountType,
+			IsActive:    true,
+		}
+		if parentID, ok := accountIDs[def.parentCode]; ok {
+			parent := parentID
+			account.ParentID = &parent
+		}
+
+		accountIDs[def.code] = account.ID
+		defaults.Accounts = append(defaults.Accounts, account)
+	}
+
+	defaults.Warehouses = append(defaults.Warehouses, &Warehouse{
+		ID:       uuid.New(),
+		TenantID: tenant.ID,
+		Code:     "WH-01",
+		Name:     "Gudang Utama",
+		Address:  tenant.Address,
+		IsActive: true,
+	})
+
+	for _, seq := range defaultSequences {
+		defaults.Sequences = append(defaults.Sequences, &NumberingSequence{
+			ID:           uuid.New(),
+			TenantID:     tenant.ID,
+			DocumentType: seq.documentType,
+			Prefix:       seq.prefix,
+			NextNumber:   1,
+			Padding:      6,
+			ResetPeriod:  "yearly",
+		})
+	}
+
+	return defaults
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeNPWP(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Legacy formatted NPWP",
+			input:    "01.234.567.8-901.000",
+			expected: "012345678901000",
+		},
+		{
+			name:     "16 digit NIK-based NPWP",
+			input:    "3171234567890001",
+			expected: "3171234567890001",
+		},
+		{
+			name:     "NPWP with spaces",
+			input:    " 01 234 567 8 901 000 ",
+			expected: "012345678901000",
+		},
+		{
+			name:    "Empty NPWP",
+			input:   "",
+			wantErr: true,
+		},
+		{
+			name:    "Too short",
+			input:   "01.234.567.8-901",
+			wantErr: true,
+		},
+		{
+			name:    "Contains letters",
+			input:   "01.234.567.8-901.ABC",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NormalizeNPWP(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestTenant_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     TenantStatus
+		to       TenantStatus
+		expected bool
+	}{
+		{name: "Active to suspended", from: TenantStatusActive, to: TenantStatusSuspended, expected: true},
+		{name: "Active to closed", from: TenantStatusActive, to: TenantStatusClosed, expected: true},
+		{name: "Active to active", from: TenantStatusActive, to: TenantStatusActive, expected: false},
+		{name: "Suspended to active", from: TenantStatusSuspended, to: TenantStatusActive, expected: true},
+		{name: "Suspended to closed", from: TenantStatusSuspended, to: TenantStatusClosed, expected: true},
+		{name: "Closed is terminal", from: TenantStatusClosed, to: TenantStatusActive, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant := &Tenant{Status: tt.from}
+			assert.Equal(t, tt.expected, tenant.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestTenant_Lifecycle(t *testing.T) {
+	tenant := &Tenant{Status: TenantStatusActive, IsActive: true}
+	assert.True(t, tenant.IsActiveTenant())
+
+	tenant.Suspend("Payment overdue")
+	assert.False(t, tenant.IsActiveTenant())
+	assert.Equal(t, TenantStatusSuspended, tenant.Status)
+	assert.Equal(t, "Payment overdue", tenant.StatusReason)
+	assert.NotNil(t, tenant.SuspendedAt)
+
+	tenant.Reactivate()
+	assert.True(t, tenant.IsActiveTenant())
+	assert.Nil(t, tenant.SuspendedAt)
+	assert.Empty(t, tenant.StatusReason)
+
+	tenant.Close("Customer request")
+	assert.False(t, tenant.IsActiveTenant())
+	assert.True(t, tenant.IsClosed())
+	assert.NotNil(t, tenant.ClosedAt)
+}
+
+func TestNewTenantDefaults(t *testing.T) {
+	t.Run("Non PKP tenant has no PPN accounts", func(t *testing.T) {
+		tenant := &Tenant{ID: uuid.New(), TaxStatus: TaxStatusNonPKP, Address: "Jl. Sudirman No. 1"}
+		defaults := NewTenantDefaults(tenant)
+
+		codes := make(map[string]*ChartOfAccount)
+		for _, account := range defaults.Accounts {
+			assert.Equal(t, tenant.ID, account.TenantID)
+			codes[account.Code] = account
+		}
+		assert.NotContains(t, codes, "1400")
+		assert.NotContains(t, codes, "2200")
+
+		// Child accounts reference their parents
+		require.Contains(t, codes, "1110")
+		require.NotNil(t, codes["1110"].ParentID)
+		assert.Equal(t, codes["1100"].ID, *codes["1110"].ParentID)
+		assert.Nil(t, codes["1000"].ParentID)
+
+		require.Len(t, defaults.Warehouses, 1)
+		assert.Equal(t, tenant.Address, defaults.Warehouses[0].Address)
+
+		documentTypes := make(map[string]bool)
+		for _, seq := range defaults.Sequences {
+			assert.Equal(t, tenant.ID, seq.TenantID)
+			assert.Equal(t, int64(1), seq.NextNumber)
+			documentTypes[seq.DocumentType] = true
+		}
+		assert.True(t, documentTypes["invoice"])
+		assert.True(t, documentTypes["sales_order"])
+	})
+
+	t.Run("PKP tenant has PPN accounts", func(t *testing.T) {
+		tenant := &Tenant{ID: uuid.New(), TaxStatus: TaxStatusPKP}
+		defaults := NewTenantDefaults(tenant)
+
+		codes := make(map[string]bool)
+		for _, account := range defaults.Accounts {
+			codes[account.Code] = true
+		}
+		assert.True(t, codes["1400"])
+		assert.True(t, codes["2200"])
+	})
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
 )
 
 // UserRole represents the user role enum
@@ -32,6 +34,10 @@ type User struct {
 	UpdatedAt   time.Time  `gorm:"not null" json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Version detects concurrent edits of the profile; it is served as the
+	// ETag of the profile and incremented by every update
+	database.Versioning
+
 	// Relationships
 	UserSessions []UserSession `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
 	ActivityLogs []ActivityLog `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
@@ -47,6 +53,9 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
 		u.ID = uuid.New()
 	}
+	if u.Version == 0 {
+		u.Version = 1
+	}
 	return nil
 }
 
@@ -98,6 +107,7 @@ func (u *User) SanitizeForResponse() *User {
 		LastLogin:   u.LastLogin,
 		CreatedAt:   u.CreatedAt,
 		UpdatedAt:   u.UpdatedAt,
+		Versioning:  u.Versioning,
 	}
 }
 
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
 	"github.com/VincentArjuna/RexiErp/internal/shared/database"
 )
 
@@ -21,6 +23,7 @@ type ActivityRepository interface {
 	GetByTenantID(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*model.ActivityLog, error)
 	GetByResource(ctx context.Context, resourceType string, resourceID uuid.UUID, limit, offset int) ([]*model.ActivityLog, error)
 	SearchActivities(ctx context.Context, tenantID uuid.UUID, filters ActivityFilters, limit, offset int) ([]*model.ActivityLog, error)
+	ListPage(ctx context.Context, tenantID uuid.UUID, filters ActivityFilters, page database.CursorPagination) ([]*model.ActivityLog, database.PageInfo, error)
 	CountByTenant(ctx context.Context, tenantID uuid.UUID) (int64, error)
 	CountByAction(ctx context.Context, tenantID uuid.UUID, action string) (int64, error)
 	DeleteOldActivities(ctx context.Context, olderThan time.Time) (int64, error)
@@ -40,15 +43,18 @@ type ActivityFilters struct {
 
 // activityRepository implements ActivityRepository interface
 type activityRepository struct {
-	db     *database.Database
-	logger *logrus.Logger
+	db      *database.Database
+	cursors *database.Cursors
+	logger  *logrus.Logger
 }
 
-// NewActivityRepository creates a new instance of ActivityRepository
-func NewActivityRepository(db *database.Database, logger *logrus.Logger) ActivityRepository {
+// NewActivityRepository creates a new instance of ActivityRepository. cursors
+// signs the cursor tokens of ListPage.
+func NewActivityRepository(db *database.Database, cursors *database.Cursors, logger *logrus.Logger) ActivityRepository {
 	return &activityRepository{
-		db:     db,
-		logger: logger,
+		db:      db,
+		cursors: cursors,
+		logger:  logger,
 	}
 }
 
@@ -61,7 +67,7 @@ func (r *activityRepository) Create(ctx context.Context, activity *model.Activit
 		"tenant_id":    activity.TenantID,
 	}).Debug("Creating activity log")
 
-	if err := r.db.DB.WithContext(ctx).Create(activity).Error; err != nil {
+	if err := r.db.Conn(ctx).Create(activity).Error; err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"action":       activity.Action,
 			"resource_type": activity.ResourceType,
@@ -87,13 +93,13 @@ func (r *activityRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.
 	r.logger.WithField("activity_id", id).Debug("Getting activity log by ID")
 
 	var activity model.ActivityLog
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Preload("User").
 		Where("id = ?", id).
 		First(&activity).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			r.logger.WithField("activity_id", id).Debug("Activity log not found")
-			return nil, fmt.Errorf("activity log not found")
+			return nil, apperror.New(apperror.CodeNotFound, "activity log not found")
 		}
 		r.logger.WithFields(logrus.Fields{
 			"activity_id": id,
@@ -120,7 +126,7 @@ func (r *activityRepository) GetByUserID(ctx context.Context, userID uuid.UUID,
 	}).Debug("Getting activity logs by user ID")
 
 	var activities []*model.ActivityLog
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Where("user_id = ?", userID).
 		Order("created_at DESC").
 		Limit(limit).
@@ -150,7 +156,7 @@ func (r *activityRepository) GetByTenantID(ctx context.Context, tenantID uuid.UU
 	}).Debug("Getting activity logs by tenant ID")
 
 	var activities []*model.ActivityLog
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Preload("User").
 		Where("tenant_id = ?", tenantID).
 		Order("created_at DESC").
@@ -182,7 +188,7 @@ func (r *activityRepository) GetByResource(ctx context.Context, resourceType str
 	}).Debug("Getting activity logs by resource")
 
 	var activities []*model.ActivityLog
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Preload("User").
 		Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).
 		Order("created_at DESC").
@@ -215,9 +221,70 @@ func (r *activityRepository) SearchActivities(ctx context.Context, tenantID uuid
 		"offset":    offset,
 	}).Debug("Searching activity logs")
 
-	query := r.db.DB.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	query := applyActivityFilters(r.db.Conn(ctx).Where("tenant_id = ?", tenantID), filters)
 
-	// Apply filters
+	var activities []*model.ActivityLog
+	if err := query.
+		Preload("User").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&activities).Error; err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"filters":   filters,
+			"error":     err,
+		}).Error("Failed to search activity logs")
+		return nil, fmt.Errorf("failed to search activity logs: %w", err)
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"tenant_id": tenantID,
+		"filters":   filters,
+		"count":     len(activities),
+	}).Debug("Activity logs searched successfully")
+
+	return activities, nil
+}
+
+// ListPage retrieves one keyset page of a tenant's activity log entries.
+// Unlike SearchActivities, the cost of a page does not grow with its depth.
+func (r *activityRepository) ListPage(ctx context.Context, tenantID uuid.UUID, filters ActivityFilters, page database.CursorPagination) ([]*model.ActivityLog, database.PageInfo, error) {
+	r.logger.WithFields(logrus.Fields{
+		"tenant_id": tenantID,
+		"filters":   filters,
+		"sort_by":   page.SortBy,
+		"limit":     page.Limit,
+	}).Debug("Listing activity logs")
+
+	query := applyActivityFilters(r.db.Conn(ctx).Where("tenant_id = ?", tenantID), filters)
+
+	activities, info, err := database.FindPage[model.ActivityLog](query.Preload("User"), r.cursors, page)
+	if err != nil {
+		var validationErrs database.ValidationErrors
+		var validationErr database.ValidationError
+		if errors.As(err, &validationErrs) || errors.As(err, &validationErr) {
+			return nil, database.PageInfo{}, err
+		}
+		r.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"filters":   filters,
+			"error":     err,
+		}).Error("Failed to list activity logs")
+		return nil, database.PageInfo{}, fmt.Errorf("failed to list activity logs: %w", err)
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"tenant_id": tenantID,
+		"count":     len(activities),
+		"has_more":  info.HasMore,
+	}).Debug("Activity logs listed successfully")
+
+	return activities, info, nil
+}
+
+// applyActivityFilters narrows query to the activities matching filters
+func applyActivityFilters(query *gorm.DB, filters ActivityFilters) *gorm.DB {
 	if filters.Action != "" {
 		query = query.Where("action = ?", filters.Action)
 	}
@@ -242,29 +309,7 @@ func (r *activityRepository) SearchActivities(ctx context.Context, tenantID uuid
 	if filters.SessionID != "" {
 		query = query.Where("session_id = ?", filters.SessionID)
 	}
-
-	var activities []*model.ActivityLog
-	if err := query.
-		Preload("User").
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&activities).Error; err != nil {
-		r.logger.WithFields(logrus.Fields{
-			"tenant_id": tenantID,
-			"filters":   filters,
-			"error":     err,
-		}).Error("Failed to search activity logs")
-		return nil, fmt.Errorf("failed to search activity logs: %w", err)
-	}
-
-	r.logger.WithFields(logrus.Fields{
-		"tenant_id": tenantID,
-		"filters":   filters,
-		"count":     len(activities),
-	}).Debug("Activity logs searched successfully")
-
-	return activities, nil
+	return query
 }
 
 // CountByTenant counts activity log entries by tenant ID
@@ -272,7 +317,7 @@ func (r *activityRepository) CountByTenant(ctx context.Context, tenantID uuid.UU
 	r.logger.WithField("tenant_id", tenantID).Debug("Counting activity logs by tenant ID")
 
 	var count int64
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Model(&model.ActivityLog{}).
 		Where("tenant_id = ?", tenantID).
 		Count(&count).Error; err != nil {
@@ -299,7 +344,7 @@ func (r *activityRepository) CountByAction(ctx context.Context, tenantID uuid.UU
 	}).Debug("Counting activity logs by tenant ID and action")
 
 	var count int64
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Model(&model.ActivityLog{}).
 		Where("tenant_id = ? AND action = ?", tenantID, action).
 		Count(&count).Error; err != nil {
@@ -324,7 +369,7 @@ func (r *activityRepository) CountByAction(ctx context.Context, tenantID uuid.UU
 func (r *activityRepository) DeleteOldActivities(ctx context.Context, olderThan time.Time) (int64, error) {
 	r.logger.WithField("older_than", olderThan).Debug("Deleting old activity logs")
 
-	result := r.db.DB.WithContext(ctx).
+	result := r.db.Conn(ctx).
 		Where("created_at < ?", olderThan).
 		Delete(&model.ActivityLog{})
 
@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
 	"github.com/VincentArjuna/RexiErp/internal/shared/database"
 )
 
@@ -49,7 +50,7 @@ func (r *passwordResetRepository) Create(ctx context.Context, token *model.Passw
 		"email":     token.Email,
 	}).Debug("Creating password reset token")
 
-	if err := r.db.DB.WithContext(ctx).Create(token).Error; err != nil {
+	if err := r.db.Conn(ctx).Create(token).Error; err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"user_id":   token.UserID,
 			"tenant_id": token.TenantID,
@@ -74,13 +75,13 @@ func (r *passwordResetRepository) GetByToken(ctx context.Context, token string)
 	r.logger.WithField("token_id", "hash").Debug("Getting password reset token by token")
 
 	var resetToken model.PasswordResetToken
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Preload("User").
 		Where("token = ? AND deleted_at IS NULL", token).
 		First(&resetToken).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			r.logger.Debug("Password reset token not found")
-			return nil, fmt.Errorf("token not found")
+			return nil, apperror.New(apperror.CodeInvalidResetToken, "token not found")
 		}
 		r.logger.WithField("error", err).Error("Failed to get password reset token")
 		return nil, fmt.Errorf("failed to get token: %w", err)
@@ -100,13 +101,13 @@ func (r *passwordResetRepository) GetByTokenHash(ctx context.Context, tokenHash
 	r.logger.WithField("token_hash", tokenHash).Debug("Getting password reset token by hash")
 
 	var resetToken model.PasswordResetToken
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Preload("User").
 		Where("token_hash = ? AND deleted_at IS NULL", tokenHash).
 		First(&resetToken).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			r.logger.Debug("Password reset token not found by hash")
-			return nil, fmt.Errorf("token not found")
+			return nil, apperror.New(apperror.CodeInvalidResetToken, "token not found")
 		}
 		r.logger.WithFields(logrus.Fields{
 			"token_hash": tokenHash,
@@ -129,7 +130,7 @@ func (r *passwordResetRepository) GetByUserID(ctx context.Context, userID uuid.U
 	r.logger.WithField("user_id", userID).Debug("Getting password reset tokens by user ID")
 
 	var tokens []*model.PasswordResetToken
-	query := r.db.DB.WithContext(ctx).Where("user_id = ? AND deleted_at IS NULL", userID)
+	query := r.db.Conn(ctx).Where("user_id = ? AND deleted_at IS NULL", userID)
 
 	if activeOnly {
 		query = query.Where("is_active = ? AND expires_at > ?", true, time.Now())
@@ -158,7 +159,7 @@ func (r *passwordResetRepository) Update(ctx context.Context, token *model.Passw
 		"user_id":  token.UserID,
 	}).Debug("Updating password reset token")
 
-	if err := r.db.DB.WithContext(ctx).Save(token).Error; err != nil {
+	if err := r.db.Conn(ctx).Save(token).Error; err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"token_id": token.ID,
 			"user_id":  token.UserID,
@@ -179,7 +180,7 @@ func (r *passwordResetRepository) Update(ctx context.Context, token *model.Passw
 func (r *passwordResetRepository) DeactivateByUserID(ctx context.Context, userID uuid.UUID) error {
 	r.logger.WithField("user_id", userID).Debug("Deactivating all password reset tokens for user")
 
-	result := r.db.DB.WithContext(ctx).
+	result := r.db.Conn(ctx).
 		Model(&model.PasswordResetToken{}).
 		Where("user_id = ? AND is_active = ?", userID, true).
 		Update("is_active", false)
@@ -204,7 +205,7 @@ func (r *passwordResetRepository) DeactivateByUserID(ctx context.Context, userID
 func (r *passwordResetRepository) DeactivateExpiredTokens(ctx context.Context) error {
 	r.logger.Debug("Deactivating expired password reset tokens")
 
-	result := r.db.DB.WithContext(ctx).
+	result := r.db.Conn(ctx).
 		Model(&model.PasswordResetToken{}).
 		Where("is_active = ? AND expires_at < ?", true, time.Now()).
 		Update("is_active", false)
@@ -223,7 +224,7 @@ func (r *passwordResetRepository) DeactivateExpiredTokens(ctx context.Context) e
 func (r *passwordResetRepository) Delete(ctx context.Context, tokenID uuid.UUID) error {
 	r.logger.WithField("token_id", tokenID).Warn("Hard deleting password reset token")
 
-	if err := r.db.DB.WithContext(ctx).Unscoped().Delete(&model.PasswordResetToken{}, tokenID).Error; err != nil {
+	if err := r.db.Conn(ctx).Unscoped().Delete(&model.PasswordResetToken{}, tokenID).Error; err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"token_id": tokenID,
 			"error":    err,
@@ -239,7 +240,7 @@ func (r *passwordResetRepository) Delete(ctx context.Context, tokenID uuid.UUID)
 func (r *passwordResetRepository) SoftDelete(ctx context.Context, tokenID uuid.UUID) error {
 	r.logger.WithField("token_id", tokenID).Debug("Soft deleting password reset token")
 
-	if err := r.db.DB.WithContext(ctx).Delete(&model.PasswordResetToken{}, tokenID).Error; err != nil {
+	if err := r.db.Conn(ctx).Delete(&model.PasswordResetToken{}, tokenID).Error; err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"token_id": tokenID,
 			"error":    err,
@@ -259,7 +260,7 @@ func (r *passwordResetRepository) CountActiveByUserID(ctx context.Context, userI
 	}).Debug("Counting active password reset tokens for user")
 
 	var count int64
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Model(&model.PasswordResetToken{}).
 		Where("user_id = ? AND is_active = ? AND created_at >= ?", userID, true, since).
 		Count(&count).Error; err != nil {
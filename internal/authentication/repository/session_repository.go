@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
 	"github.com/VincentArjuna/RexiErp/internal/shared/database"
 )
 
@@ -51,7 +52,7 @@ func (r *sessionRepository) Create(ctx context.Context, session *model.UserSessi
 		"tenant_id":  session.TenantID,
 	}).Debug("Creating new session")
 
-	if err := r.db.DB.WithContext(ctx).Create(session).Error; err != nil {
+	if err := r.db.Conn(ctx).Create(session).Error; err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"session_id": session.SessionID,
 			"user_id":    session.UserID,
@@ -75,13 +76,13 @@ func (r *sessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.U
 	r.logger.WithField("session_id", id).Debug("Getting session by ID")
 
 	var session model.UserSession
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Preload("User").
 		Where("id = ?", id).
 		First(&session).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			r.logger.WithField("session_id", id).Debug("Session not found")
-			return nil, fmt.Errorf("session not found")
+			return nil, apperror.New(apperror.CodeSessionNotFound, "session not found")
 		}
 		r.logger.WithFields(logrus.Fields{
 			"session_id": id,
@@ -104,13 +105,13 @@ func (r *sessionRepository) GetBySessionID(ctx context.Context, sessionID string
 	r.logger.WithField("session_id", sessionID).Debug("Getting session by session ID")
 
 	var session model.UserSession
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Preload("User").
 		Where("session_id = ?", sessionID).
 		First(&session).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			r.logger.WithField("session_id", sessionID).Debug("Session not found by session ID")
-			return nil, fmt.Errorf("session not found")
+			return nil, apperror.New(apperror.CodeSessionNotFound, "session not found")
 		}
 		r.logger.WithFields(logrus.Fields{
 			"session_id": sessionID,
@@ -136,7 +137,7 @@ func (r *sessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID, a
 	}).Debug("Getting sessions by user ID")
 
 	var sessions []*model.UserSession
-	query := r.db.DB.WithContext(ctx).Where("user_id = ?", userID)
+	query := r.db.Conn(ctx).Where("user_id = ?", userID)
 
 	if activeOnly {
 		query = query.Where("is_active = ? AND expires_at > ?", true, time.Now())
@@ -166,13 +167,13 @@ func (r *sessionRepository) GetByTokenHash(ctx context.Context, tokenHash string
 	r.logger.WithField("token_hash", tokenHash[:8]+"...").Debug("Getting session by token hash")
 
 	var session model.UserSession
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Preload("User").
 		Where("token_hash = ? AND is_active = ? AND expires_at > ?", tokenHash, true, time.Now()).
 		First(&session).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			r.logger.WithField("token_hash", tokenHash[:8]+"...").Debug("Session not found by token hash")
-			return nil, fmt.Errorf("session not found")
+			return nil, apperror.New(apperror.CodeSessionNotFound, "session not found")
 		}
 		r.logger.WithFields(logrus.Fields{
 			"token_hash": tokenHash[:8] + "...",
@@ -197,7 +198,7 @@ func (r *sessionRepository) Update(ctx context.Context, session *model.UserSessi
 		"user_id":    session.UserID,
 	}).Debug("Updating session")
 
-	if err := r.db.DB.WithContext(ctx).Save(session).Error; err != nil {
+	if err := r.db.Conn(ctx).Save(session).Error; err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"session_id": session.SessionID,
 			"user_id":    session.UserID,
@@ -214,7 +215,7 @@ func (r *sessionRepository) Update(ctx context.Context, session *model.UserSessi
 func (r *sessionRepository) UpdateActivity(ctx context.Context, sessionID string) error {
 	r.logger.WithField("session_id", sessionID).Debug("Updating session activity")
 
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Model(&model.UserSession{}).
 		Where("session_id = ?", sessionID).
 		Update("last_activity", time.Now()).Error; err != nil {
@@ -233,7 +234,7 @@ func (r *sessionRepository) UpdateActivity(ctx context.Context, sessionID string
 func (r *sessionRepository) Deactivate(ctx context.Context, sessionID string) error {
 	r.logger.WithField("session_id", sessionID).Debug("Deactivating session")
 
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Model(&model.UserSession{}).
 		Where("session_id = ?", sessionID).
 		Update("is_active", false).Error; err != nil {
@@ -252,7 +253,7 @@ func (r *sessionRepository) Deactivate(ctx context.Context, sessionID string) er
 func (r *sessionRepository) DeactivateByUserID(ctx context.Context, userID uuid.UUID) error {
 	r.logger.WithField("user_id", userID).Debug("Deactivating all sessions for user")
 
-	result := r.db.DB.WithContext(ctx).
+	result := r.db.Conn(ctx).
 		Model(&model.UserSession{}).
 		Where("user_id = ? AND is_active = ?", userID, true).
 		Update("is_active", false)
@@ -277,7 +278,7 @@ func (r *sessionRepository) DeactivateByUserID(ctx context.Context, userID uuid.
 func (r *sessionRepository) Delete(ctx context.Context, sessionID string) error {
 	r.logger.WithField("session_id", sessionID).Debug("Deleting session")
 
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Where("session_id = ?", sessionID).
 		Delete(&model.UserSession{}).Error; err != nil {
 		r.logger.WithFields(logrus.Fields{
@@ -295,7 +296,7 @@ func (r *sessionRepository) Delete(ctx context.Context, sessionID string) error
 func (r *sessionRepository) CleanupExpiredSessions(ctx context.Context) (int64, error) {
 	r.logger.Debug("Cleaning up expired sessions")
 
-	result := r.db.DB.WithContext(ctx).
+	result := r.db.Conn(ctx).
 		Where("expires_at < ? OR (is_active = ? AND last_activity < ?)",
 			time.Now(), false, time.Now().Add(-7*24*time.Hour)).
 		Delete(&model.UserSession{})
@@ -314,7 +315,7 @@ func (r *sessionRepository) GetActiveSessionsCount(ctx context.Context, userID u
 	r.logger.WithField("user_id", userID).Debug("Counting active sessions for user")
 
 	var count int64
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Model(&model.UserSession{}).
 		Where("user_id = ? AND is_active = ? AND expires_at > ?", userID, true, time.Now()).
 		Count(&count).Error; err != nil {
@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+// TenantRepository interface defines the contract for tenant data operations
+type TenantRepository interface {
+	Provision(ctx context.Context, tenant *model.Tenant, admin *model.User, defaults *model.TenantDefaults) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Tenant, error)
+	List(ctx context.Context, status string, limit, offset int) ([]*model.Tenant, error)
+	Count(ctx context.Context, status string) (int64, error)
+	Update(ctx context.Context, tenant *model.Tenant) error
+	UpdateStatus(ctx context.Context, tenant *model.Tenant) ([]*model.UserSession, error)
+	CountWarehouses(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	ExistsBySubdomain(ctx context.Context, subdomain string) (bool, error)
+	ExistsActiveByDomain(ctx context.Context, domain string) (bool, error)
+}
+
+// tenantRepository implements TenantRepository interface
+type tenantRepository struct {
+	db     *database.Database
+	logger *logrus.Logger
+}
+
+// NewTenantRepository creates a new instance of TenantRepository
+func NewTenantRepository(db *database.Database, logger *logrus.Logger) TenantRepository {
+	return &tenantRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Provision creates a tenant together with its first admin user and default
+// master data in a single transaction
+func (r *tenantRepository) Provision(ctx context.Context, tenant *model.Tenant, admin *model.User, defaults *model.TenantDefaults) error {
+	r.logger.WithFields(logrus.Fields{
+		"tenant_id":   tenant.ID,
+		"name":        tenant.Name,
+		"admin_email": admin.Email,
+	}).Debug("Provisioning new tenant")
+
+	err := r.db.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(tenant).Error; err != nil {
+			return fmt.Errorf("failed to create tenant: %w", err)
+		}
+
+		admin.TenantID = tenant.ID
+		if err := tx.Create(admin).Error; err != nil {
+			return fmt.Errorf("failed to create tenant admin: %w", err)
+		}
+
+		if defaults == nil {
+			return nil
+		}
+
+		if len(defaults.Accounts) > 0 {
+			if err := tx.Create(defaults.Accounts).Error; err != nil {
+				return fmt.Errorf("failed to seed chart of accounts: %w", err)
+			}
+		}
+		if len(defaults.Warehouses) > 0 {
+			if err := tx.Create(defaults.Warehouses).Error; err != nil {
+				return fmt.Errorf("failed to seed warehouses: %w", err)
+			}
+		}
+		if len(defaults.Sequences) > 0 {
+			if err := tx.Create(defaults.Sequences).Error; err != nil {
+				return fmt.Errorf("failed to seed numbering sequences: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"tenant_id": tenant.ID,
+			"name":      tenant.Name,
+			"error":     err,
+		}).Error("Failed to provision tenant")
+		return err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"tenant_id": tenant.ID,
+		"name":      tenant.Name,
+		"admin_id":  admin.ID,
+	}).Info("Tenant provisioned successfully")
+
+	return nil
+}
+
+// GetByID retrieves a tenant by ID
+func (r *tenantRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Tenant, error) {
+	r.logger.WithField("tenant_id", id).Debug("Getting tenant by ID")
+
+	var tenant model.Tenant
+	if err := r.db.Conn(ctx).
+		Where("id = ?", id).
+		First(&tenant).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.logger.WithField("tenant_id", id).Debug("Tenant not found")
+			return nil, apperror.New(apperror.CodeTenantNotFound, "tenant not found")
+		}
+		r.logger.WithFields(logrus.Fields{
+			"tenant_id": id,
+			"error":     err,
+		}).Error("Failed to get tenant by ID")
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return &tenant, nil
+}
+
+// List retrieves tenants with optional status filter and pagination
+func (r *tenantRepository) List(ctx context.Context, status string, limit, offset int) ([]*model.Tenant, error) {
+	r.logger.WithFields(logrus.Fields{
+		"status": status,
+		"limit":  limit,
+		"offset": offset,
+	}).Debug("Listing tenants")
+
+	query := r.db.Conn(ctx).Model(&model.Tenant{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var tenants []*model.Tenant
+	if err := query.
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&tenants).Error; err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"status": status,
+			"error":  err,
+		}).Error("Failed to list tenants")
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	return tenants, nil
+}
+
+// Count counts tenants with optional status filter
+func (r *tenantRepository) Count(ctx context.Context, status string) (int64, error) {
+	query := r.db.Conn(ctx).Model(&model.Tenant{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"status": status,
+			"error":  err,
+		}).Error("Failed to count tenants")
+		return 0, fmt.Errorf("failed to count tenants: %w", err)
+	}
+
+	return count, nil
+}
+
+// Update updates a tenant's profile
+func (r *tenantRepository) Update(ctx context.Context, tenant *model.Tenant) error {
+	r.logger.WithField("tenant_id", tenant.ID).Debug("Updating tenant")
+
+	if err := r.db.Conn(ctx).Save(tenant).Error; err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"tenant_id": tenant.ID,
+			"error":     err,
+		}).Error("Failed to update tenant")
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	r.logger.WithField("tenant_id", tenant.ID).Info("Tenant updated successfully")
+	return nil
+}
+
+// UpdateStatus persists the tenant's lifecycle status. When the tenant is no
+// longer active, all of its user sessions are deactivated in the same
+// transaction. It returns the sessions deactivated, which the caller must
+// also mark revoked for the API gateway.
+func (r *tenantRepository) UpdateStatus(ctx context.Context, tenant *model.Tenant) ([]*model.UserSession, error) {
+	r.logger.WithFields(logrus.Fields{
+		"tenant_id": tenant.ID,
+		"status":    tenant.Status,
+	}).Debug("Updating tenant status")
+
+	var deactivated []*model.UserSession
+	err := r.db.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Tenant{}).
+			Where("id = ?", tenant.ID).
+			Updates(map[string]interface{}{
+				"status":        tenant.Status,
+				"status_reason": tenant.StatusReason,
+				"is_active":     tenant.IsActive,
+				"suspended_at":  tenant.SuspendedAt,
+				"closed_at":     tenant.ClosedAt,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update tenant status: %w", err)
+		}
+
+		if tenant.IsActiveTenant() {
+			return nil
+		}
+
+		if err := tx.Where("tenant_id = ? AND is_active = ?", tenant.ID, true).
+			Find(&deactivated).Error; err != nil {
+			return fmt.Errorf("failed to find tenant sessions: %w", err)
+		}
+		if len(deactivated) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(deactivated))
+		for i, session := range deactivated {
+			ids[i] = session.ID
+		}
+		if err := tx.Model(&model.UserSession{}).
+			Where("id IN ?", ids).
+			Update("is_active", false).Error; err != nil {
+			return fmt.Errorf("failed to deactivate tenant sessions: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"tenant_id": tenant.ID,
+			"status":    tenant.Status,
+			"error":     err,
+		}).Error("Failed to update tenant status")
+		return nil, err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"tenant_id":            tenant.ID,
+		"status":               tenant.Status,
+		"sessions_deactivated": len(deactivated),
+	}).Info("Tenant status updated successfully")
+
+	return deactivated, nil
+}
+
+// CountWarehouses counts the active warehouses of a tenant
+func (r *tenantRepository) CountWarehouses(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	var count int64
+	if err := r.db.Conn(ctx).
+		Model(&model.Warehouse{}).
+		Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Count(&count).Error; err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"error":     err,
+		}).Error("Failed to count warehouses")
+		return 0, fmt.Errorf("failed to count warehouses: %w", err)
+	}
+
+	return count, nil
+}
+
+// ExistsByEmail checks if a tenant is already registered with the email
+func (r *tenantRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	var count int64
+	if err := r.db.Conn(ctx).
+		Model(&model.Tenant{}).
+		Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(email))).
+		Count(&count).Error; err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"email": email,
+			"error": err,
+		}).Error("Failed to check if tenant exists by email")
+		return false, fmt.Errorf("failed to check tenant existence: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// ExistsBySubdomain checks if a subdomain is already taken
+func (r *tenantRepository) ExistsBySubdomain(ctx context.Context, subdomain string) (bool, error) {
+	var count int64
+	if err := r.db.Conn(ctx).
+		Model(&model.Tenant{}).
+		Where("subdomain = ?", strings.ToLower(strings.TrimSpace(subdomain))).
+		Count(&count).Error; err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"subdomain": subdomain,
+			"error":     err,
+		}).Error("Failed to check if tenant exists by subdomain")
+		return false, fmt.Errorf("failed to check subdomain existence: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// ExistsActiveByDomain checks if an active tenant uses the custom domain
+func (r *tenantRepository) ExistsActiveByDomain(ctx context.Context, domain string) (bool, error) {
+	var count int64
+	if err := r.db.Conn(ctx).
+		Model(&model.Tenant{}).
+		Where("domain = ? AND status = ?", strings.ToLower(strings.TrimSpace(domain)), model.TenantStatusActive).
+		Count(&count).Error; err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"domain": domain,
+			"error":  err,
+		}).Error("Failed to check if active tenant exists by domain")
+		return false, fmt.Errorf("failed to check domain existence: %w", err)
+	}
+
+	return count > 0, nil
+}
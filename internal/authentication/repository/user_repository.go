@@ -11,6 +11,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
 	"github.com/VincentArjuna/RexiErp/internal/shared/database"
 )
 
@@ -27,6 +28,7 @@ type UserRepository interface {
 	SoftDelete(ctx context.Context, userID uuid.UUID) error
 	CountByTenant(ctx context.Context, tenantID uuid.UUID) (int64, error)
 	ExistsByEmail(ctx context.Context, email string, tenantID uuid.UUID) (bool, error)
+	ExistsByEmailAcrossTenants(ctx context.Context, email string) (bool, error)
 	SearchUsers(ctx context.Context, tenantID uuid.UUID, query string, limit, offset int) ([]*model.User, error)
 }
 
@@ -52,7 +54,7 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 		"role":      user.Role,
 	}).Debug("Creating new user")
 
-	if err := r.db.DB.WithContext(ctx).Create(user).Error; err != nil {
+	if err := r.db.Conn(ctx).Create(user).Error; err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"email":     user.Email,
 			"tenant_id": user.TenantID,
@@ -75,13 +77,13 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User
 	r.logger.WithField("user_id", id).Debug("Getting user by ID")
 
 	var user model.User
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Preload("UserSessions").
 		Where("id = ? AND deleted_at IS NULL", id).
 		First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			r.logger.WithField("user_id", id).Debug("User not found")
-			return nil, fmt.Errorf("user not found")
+			return nil, apperror.New(apperror.CodeUserNotFound, "user not found")
 		}
 		r.logger.WithFields(logrus.Fields{
 			"user_id": id,
@@ -107,7 +109,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string, tenantID
 	}).Debug("Getting user by email")
 
 	var user model.User
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Where("email = ? AND tenant_id = ? AND deleted_at IS NULL", email, tenantID).
 		First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -115,7 +117,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string, tenantID
 				"email":     email,
 				"tenant_id": tenantID,
 			}).Debug("User not found by email")
-			return nil, fmt.Errorf("user not found")
+			return nil, apperror.New(apperror.CodeUserNotFound, "user not found")
 		}
 		r.logger.WithFields(logrus.Fields{
 			"email":     email,
@@ -143,7 +145,7 @@ func (r *userRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID,
 	}).Debug("Getting users by tenant ID")
 
 	var users []*model.User
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Where("tenant_id = ? AND deleted_at IS NULL", tenantID).
 		Order("created_at DESC").
 		Limit(limit).
@@ -164,22 +166,41 @@ func (r *userRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID,
 	return users, nil
 }
 
-// Update updates a user
+// Update updates a user and increments its version. The update only applies
+// to the version the user was read at, so a concurrent change makes it fail
+// with database.ErrStaleObject instead of being overwritten.
 func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 	r.logger.WithFields(logrus.Fields{
 		"user_id":   user.ID,
 		"email":     user.Email,
 		"tenant_id": user.TenantID,
+		"version":   user.Version,
 	}).Debug("Updating user")
 
-	if err := r.db.DB.WithContext(ctx).Save(user).Error; err != nil {
+	expected := user.Version
+	user.Version = expected + 1
+	result := r.db.Conn(ctx).
+		Model(user).
+		Where(database.VersionColumn+" = ?", expected).
+		Select("*").
+		Updates(user)
+	if result.Error != nil {
+		user.Version = expected
 		r.logger.WithFields(logrus.Fields{
 			"user_id":   user.ID,
 			"email":     user.Email,
 			"tenant_id": user.TenantID,
-			"error":     err,
+			"error":     result.Error,
 		}).Error("Failed to update user")
-		return fmt.Errorf("failed to update user: %w", err)
+		return fmt.Errorf("failed to update user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		user.Version = expected
+		r.logger.WithFields(logrus.Fields{
+			"user_id": user.ID,
+			"version": expected,
+		}).Debug("User was modified since it was read")
+		return fmt.Errorf("user %s at version %d: %w", user.ID, expected, database.ErrStaleObject)
 	}
 
 	r.logger.WithFields(logrus.Fields{
@@ -196,7 +217,7 @@ func (r *userRepository) UpdateLastLogin(ctx context.Context, userID uuid.UUID)
 	r.logger.WithField("user_id", userID).Debug("Updating user last login")
 
 	now := time.Now()
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Model(&model.User{}).
 		Where("id = ?", userID).
 		Update("last_login", now).Error; err != nil {
@@ -215,7 +236,7 @@ func (r *userRepository) UpdateLastLogin(ctx context.Context, userID uuid.UUID)
 func (r *userRepository) Delete(ctx context.Context, userID uuid.UUID) error {
 	r.logger.WithField("user_id", userID).Warn("Hard deleting user")
 
-	if err := r.db.DB.WithContext(ctx).Unscoped().Delete(&model.User{}, userID).Error; err != nil {
+	if err := r.db.Conn(ctx).Unscoped().Delete(&model.User{}, userID).Error; err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"user_id": userID,
 			"error":   err,
@@ -231,7 +252,7 @@ func (r *userRepository) Delete(ctx context.Context, userID uuid.UUID) error {
 func (r *userRepository) SoftDelete(ctx context.Context, userID uuid.UUID) error {
 	r.logger.WithField("user_id", userID).Debug("Soft deleting user")
 
-	if err := r.db.DB.WithContext(ctx).Delete(&model.User{}, userID).Error; err != nil {
+	if err := r.db.Conn(ctx).Delete(&model.User{}, userID).Error; err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"user_id": userID,
 			"error":   err,
@@ -248,7 +269,7 @@ func (r *userRepository) CountByTenant(ctx context.Context, tenantID uuid.UUID)
 	r.logger.WithField("tenant_id", tenantID).Debug("Counting users by tenant ID")
 
 	var count int64
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Model(&model.User{}).
 		Where("tenant_id = ? AND deleted_at IS NULL", tenantID).
 		Count(&count).Error; err != nil {
@@ -275,7 +296,7 @@ func (r *userRepository) ExistsByEmail(ctx context.Context, email string, tenant
 	}).Debug("Checking if user exists by email")
 
 	var count int64
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Model(&model.User{}).
 		Where("email = ? AND tenant_id = ? AND deleted_at IS NULL", email, tenantID).
 		Count(&count).Error; err != nil {
@@ -297,6 +318,25 @@ func (r *userRepository) ExistsByEmail(ctx context.Context, email string, tenant
 	return exists, nil
 }
 
+// ExistsByEmailAcrossTenants checks if a user exists by email in any tenant
+func (r *userRepository) ExistsByEmailAcrossTenants(ctx context.Context, email string) (bool, error) {
+	r.logger.WithField("email", email).Debug("Checking if user exists by email across all tenants")
+
+	var count int64
+	if err := r.db.Conn(ctx).
+		Model(&model.User{}).
+		Where("email = ? AND deleted_at IS NULL", strings.ToLower(strings.TrimSpace(email))).
+		Count(&count).Error; err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"email": email,
+			"error": err,
+		}).Error("Failed to check if user exists by email across all tenants")
+		return false, fmt.Errorf("failed to check user existence: %w", err)
+	}
+
+	return count > 0, nil
+}
+
 // SearchUsers searches users by query string within a tenant
 func (r *userRepository) SearchUsers(ctx context.Context, tenantID uuid.UUID, query string, limit, offset int) ([]*model.User, error) {
 	r.logger.WithFields(logrus.Fields{
@@ -309,7 +349,7 @@ func (r *userRepository) SearchUsers(ctx context.Context, tenantID uuid.UUID, qu
 	var users []*model.User
 	searchPattern := "%" + query + "%"
 
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Where("tenant_id = ? AND deleted_at IS NULL AND (email ILIKE ? OR full_name ILIKE ?)", tenantID, searchPattern, searchPattern).
 		Order("created_at DESC").
 		Limit(limit).
@@ -337,12 +377,12 @@ func (r *userRepository) FindByEmailAcrossTenants(ctx context.Context, email str
 	r.logger.WithField("email", email).Debug("Finding user by email across all tenants")
 
 	var user model.User
-	if err := r.db.DB.WithContext(ctx).
+	if err := r.db.Conn(ctx).
 		Where("email = ? AND deleted_at IS NULL", strings.ToLower(strings.TrimSpace(email))).
 		First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			r.logger.WithField("email", email).Debug("User not found by email across all tenants")
-			return nil, fmt.Errorf("user not found")
+			return nil, apperror.New(apperror.CodeUserNotFound, "user not found")
 		}
 		r.logger.WithFields(logrus.Fields{
 			"email": email,
@@ -14,7 +14,12 @@ import (
 
 	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
 	"github.com/VincentArjuna/RexiErp/internal/authentication/repository"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
 	"github.com/VincentArjuna/RexiErp/internal/shared/cache"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+	"github.com/VincentArjuna/RexiErp/internal/shared/settings"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
 )
 
 // authService implements AuthService interface
@@ -23,6 +28,9 @@ type authService struct {
 	sessionRepo     repository.SessionRepository
 	activityRepo    repository.ActivityRepository
 	passwordResetRepo repository.PasswordResetRepository
+	tenantRepo      repository.TenantRepository
+	enforcer        *subscription.Enforcer
+	settings        *settings.Client
 	cache           *cache.RedisCache
 	jwtService      JWTService
 	logger          *logrus.Logger
@@ -35,6 +43,9 @@ func NewAuthService(
 	sessionRepo repository.SessionRepository,
 	activityRepo repository.ActivityRepository,
 	passwordResetRepo repository.PasswordResetRepository,
+	tenantRepo repository.TenantRepository,
+	enforcer *subscription.Enforcer,
+	settings *settings.Client,
 	cache *cache.RedisCache,
 	jwtService JWTService,
 	logger *logrus.Logger,
@@ -45,6 +56,9 @@ func NewAuthService(
 		sessionRepo:     sessionRepo,
 		activityRepo:    activityRepo,
 		passwordResetRepo: passwordResetRepo,
+		tenantRepo:      tenantRepo,
+		enforcer:        enforcer,
+		settings:        settings,
 		cache:           cache,
 		jwtService:      jwtService,
 		logger:          logger,
@@ -66,6 +80,13 @@ func (s *authService) Register(ctx context.Context, req *RegisterRequest) (*Auth
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
+	// Only active tenants accept new users
+	if err := s.ensureTenantActive(ctx, req.TenantID); err != nil {
+		s.logActivity(ctx, nil, req.TenantID, "register", "user", nil, nil, nil,
+			false, err.Error(), "")
+		return nil, err
+	}
+
 	// Check if user already exists
 	exists, err := s.userRepo.ExistsByEmail(ctx, req.Email, req.TenantID)
 	if err != nil {
@@ -80,7 +101,18 @@ func (s *authService) Register(ctx context.Context, req *RegisterRequest) (*Auth
 	if exists {
 		s.logActivity(ctx, nil, req.TenantID, "register", "user", nil, nil, nil,
 			false, "User already exists", "")
-		return nil, fmt.Errorf("user with email %s already exists", req.Email)
+		return nil, apperror.Newf(apperror.CodeUserAlreadyExists, "user with email %s already exists", req.Email)
+	}
+
+	// Enforce the user limit of the tenant's subscription plan
+	userCount, err := s.userRepo.CountByTenant(ctx, req.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tenant users: %w", err)
+	}
+	if err := s.enforcer.CheckCount(ctx, req.TenantID, subscription.ResourceUsers, userCount, 1); err != nil {
+		s.logActivity(ctx, nil, req.TenantID, "register", "user", nil, nil, nil,
+			false, err.Error(), "")
+		return nil, err
 	}
 
 	// Hash password
@@ -154,21 +186,28 @@ func (s *authService) Login(ctx context.Context, req *LoginRequest, ipAddress, u
 	if err != nil {
 		s.logActivity(ctx, nil, uuid.Nil, "login", "user", nil, nil, nil,
 			false, "User not found", "")
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, apperror.New(apperror.CodeInvalidCredentials, "invalid credentials")
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
 		s.logActivity(ctx, &user.ID, user.TenantID, "login", "user", &user.ID, nil, nil,
 			false, "Invalid password", "")
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, apperror.New(apperror.CodeInvalidCredentials, "invalid credentials")
 	}
 
 	// Check if user is active
 	if !user.IsActiveUser() {
 		s.logActivity(ctx, &user.ID, user.TenantID, "login", "user", &user.ID, nil, nil,
 			false, "User account is inactive", "")
-		return nil, fmt.Errorf("account is inactive")
+		return nil, apperror.New(apperror.CodeAccountInactive, "account is inactive")
+	}
+
+	// Check if the user's tenant is active
+	if err := s.ensureTenantActive(ctx, user.TenantID); err != nil {
+		s.logActivity(ctx, &user.ID, user.TenantID, "login", "user", &user.ID, nil, nil,
+			false, err.Error(), "")
+		return nil, err
 	}
 
 	// Update last login
@@ -207,7 +246,7 @@ func (s *authService) Logout(ctx context.Context, sessionID string) error {
 	// Get session
 	session, err := s.sessionRepo.GetBySessionID(ctx, sessionID)
 	if err != nil {
-		return fmt.Errorf("session not found")
+		return apperror.Wrap(err, apperror.CodeSessionNotFound, "session not found")
 	}
 
 	// Deactivate session
@@ -219,13 +258,7 @@ func (s *authService) Logout(ctx context.Context, sessionID string) error {
 		return fmt.Errorf("failed to logout: %w", err)
 	}
 
-	// Add token to blacklist (if using Redis for token blacklisting)
-	if err := s.cache.Set(ctx, fmt.Sprintf("blacklist:%s", sessionID), true, time.Until(session.ExpiresAt)); err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"session_id": sessionID,
-			"error":      err,
-		}).Warn("Failed to add token to blacklist")
-	}
+	s.markRevoked(ctx, session)
 
 	s.logActivity(ctx, &session.UserID, session.TenantID, "logout", "session", &session.ID,
 		nil, nil, true, "", sessionID)
@@ -241,33 +274,38 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*A
 	// Validate refresh token
 	claims, err := s.jwtService.ValidateToken(refreshToken)
 	if err != nil {
-		return nil, fmt.Errorf("invalid refresh token: %w", err)
+		return nil, apperror.Wrap(err, apperror.CodeInvalidToken, "invalid refresh token")
 	}
 
 	if claims.TokenType != "refresh" {
-		return nil, fmt.Errorf("invalid token type")
+		return nil, apperror.New(apperror.CodeInvalidToken, "invalid token type")
 	}
 
 	// Get session
 	session, err := s.sessionRepo.GetByTokenHash(ctx, claims.TokenHash)
 	if err != nil {
-		return nil, fmt.Errorf("session not found")
+		return nil, apperror.Wrap(err, apperror.CodeSessionNotFound, "session not found")
 	}
 
 	// Check if session is valid
 	if !session.IsValid() {
-		return nil, fmt.Errorf("session expired or inactive")
+		return nil, apperror.New(apperror.CodeInvalidToken, "session expired or inactive")
 	}
 
 	// Get user
 	user, err := s.userRepo.GetByID(ctx, session.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, apperror.Wrap(err, apperror.CodeInvalidToken, "user not found")
 	}
 
 	// Check if user is active
 	if !user.IsActiveUser() {
-		return nil, fmt.Errorf("account is inactive")
+		return nil, apperror.New(apperror.CodeAccountInactive, "account is inactive")
+	}
+
+	// Check if the user's tenant is still active
+	if err := s.ensureTenantActive(ctx, user.TenantID); err != nil {
+		return nil, err
 	}
 
 	// Generate new tokens
@@ -327,6 +365,10 @@ func (s *authService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
+	// The update only applies to the version the client edited
+	if req.Version != 0 {
+		user.Version = req.Version
+	}
 
 	// Store old values for activity log
 	oldValues := map[string]interface{}{
@@ -374,7 +416,7 @@ func (s *authService) ChangePassword(ctx context.Context, userID uuid.UUID, req
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
 		s.logActivity(ctx, &user.ID, user.TenantID, "change_password", "user", &user.ID,
 			nil, nil, false, "Invalid current password", "")
-		return fmt.Errorf("current password is incorrect")
+		return apperror.New(apperror.CodeInvalidCredentials, "current password is incorrect")
 	}
 
 	// Hash new password
@@ -390,7 +432,7 @@ func (s *authService) ChangePassword(ctx context.Context, userID uuid.UUID, req
 	}
 
 	// Deactivate all other sessions for security
-	if err := s.sessionRepo.DeactivateByUserID(ctx, userID); err != nil {
+	if err := s.revokeUserSessions(ctx, userID); err != nil {
 		s.logger.WithField("error", err).Warn("Failed to deactivate other sessions")
 	}
 
@@ -405,28 +447,28 @@ func (s *authService) ChangePassword(ctx context.Context, userID uuid.UUID, req
 func (s *authService) ValidateToken(ctx context.Context, tokenString string) (*TokenValidationResult, error) {
 	s.logger.Debug("Validating token")
 
-	// Check if token is blacklisted
-	tokenHash := s.hashToken(tokenString)
-	isBlacklisted, err := s.cache.Exists(ctx, fmt.Sprintf("blacklist:%s", tokenHash))
+	// Validate JWT token
+	claims, err := s.jwtService.ValidateToken(tokenString)
 	if err != nil {
-		s.logger.WithField("error", err).Warn("Failed to check token blacklist")
+		return &TokenValidationResult{IsValid: false}, nil
 	}
 
-	if isBlacklisted {
+	if claims.TokenType != "access" {
 		return &TokenValidationResult{IsValid: false}, nil
 	}
 
-	// Validate JWT token
-	claims, err := s.jwtService.ValidateToken(tokenString)
+	// Check if the session was revoked
+	isRevoked, err := s.cache.Exists(ctx, RevokedSessionKey(claims.SessionID))
 	if err != nil {
-		return &TokenValidationResult{IsValid: false}, nil
+		s.logger.WithField("error", err).Warn("Failed to check revoked sessions")
 	}
 
-	if claims.TokenType != "access" {
+	if isRevoked {
 		return &TokenValidationResult{IsValid: false}, nil
 	}
 
 	// Get session to validate
+	tokenHash := s.hashToken(tokenString)
 	session, err := s.sessionRepo.GetByTokenHash(ctx, tokenHash)
 	if err != nil || !session.IsValid() {
 		return &TokenValidationResult{IsValid: false}, nil
@@ -446,7 +488,7 @@ func (s *authService) ValidateToken(ctx context.Context, tokenString string) (*T
 func (s *authService) DeactivateAllSessions(ctx context.Context, userID uuid.UUID) error {
 	s.logger.WithField("user_id", userID).Debug("Deactivating all user sessions")
 
-	return s.sessionRepo.DeactivateByUserID(ctx, userID)
+	return s.revokeUserSessions(ctx, userID)
 }
 
 // GetUserSessions retrieves all active sessions for a user
@@ -458,46 +500,65 @@ func (s *authService) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]
 
 // Helper functions
 
-func (s *authService) validateRegistrationRequest(req *RegisterRequest) error {
-	// Validate email
-	if req.Email == "" {
-		return fmt.Errorf("email is required")
-	}
+// RevokedSessionKey returns the cache key marking a session as revoked. The
+// API gateway verifies access tokens without a database and checks this key
+// to reject tokens of sessions that were logged out.
+func RevokedSessionKey(sessionID string) string {
+	return fmt.Sprintf("blacklist:%s", sessionID)
+}
 
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	if !emailRegex.MatchString(req.Email) {
-		return fmt.Errorf("invalid email format")
-	}
+// RevocationStore records the revoked sessions checked by the API gateway.
+// It is satisfied by *cache.RedisCache.
+type RevocationStore interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+}
 
-	// Validate password
-	if len(req.Password) < s.config.MinPasswordLength {
-		return fmt.Errorf("password must be at least %d characters long", s.config.MinPasswordLength)
-	}
+// markRevoked marks a deactivated session as revoked until it would have expired
+func (s *authService) markRevoked(ctx context.Context, session *model.UserSession) {
+	markRevoked(ctx, s.cache, s.logger, session)
+}
 
-	if s.config.RequireUppercase {
-		hasUpper := regexp.MustCompile(`[A-Z]`).MatchString(req.Password)
-		if !hasUpper {
-			return fmt.Errorf("password must contain at least one uppercase letter")
-		}
+func markRevoked(ctx context.Context, store RevocationStore, logger *logrus.Logger, session *model.UserSession) {
+	if err := store.Set(ctx, RevokedSessionKey(session.SessionID), true, time.Until(session.ExpiresAt)); err != nil {
+		logger.WithFields(logrus.Fields{
+			"session_id": session.SessionID,
+			"error":      err,
+		}).Warn("Failed to mark session as revoked")
 	}
+}
 
-	if s.config.RequireNumbers {
-		hasNumber := regexp.MustCompile(`[0-9]`).MatchString(req.Password)
-		if !hasNumber {
-			return fmt.Errorf("password must contain at least one number")
-		}
+// revokeUserSessions deactivates the active sessions of a user and marks
+// them as revoked
+func (s *authService) revokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	sessions, err := s.sessionRepo.GetByUserID(ctx, userID, true)
+	if err != nil {
+		return err
+	}
+	if err := s.sessionRepo.DeactivateByUserID(ctx, userID); err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		s.markRevoked(ctx, session)
 	}
+	return nil
+}
 
-	if s.config.RequireSpecialChars {
-		hasSpecial := regexp.MustCompile(`[!@#$%^&*(),.?":{}|<>]`).MatchString(req.Password)
-		if !hasSpecial {
-			return fmt.Errorf("password must contain at least one special character")
-		}
+func (s *authService) validateRegistrationRequest(req *RegisterRequest) error {
+	var errs database.ValidationErrors
+
+	// Validate email
+	if req.Email == "" {
+		errs.AddRule("email", "required", "email is required", nil, nil)
+	} else if !emailRegex.MatchString(req.Email) {
+		errs.AddRule("email", "email", "invalid email format", nil, nil)
 	}
 
+	// Validate password
+	validatePasswordPolicy(&errs, "password", req.Password, s.config)
+
 	// Validate full name
 	if strings.TrimSpace(req.FullName) == "" {
-		return fmt.Errorf("full name is required")
+		errs.AddRule("full_name", "required", "full name is required", nil, nil)
 	}
 
 	// Validate role
@@ -509,13 +570,45 @@ func (s *authService) validateRegistrationRequest(req *RegisterRequest) error {
 			"viewer":       true,
 		}
 		if !validRoles[req.Role] {
-			return fmt.Errorf("invalid role: %s", req.Role)
+			errs.AddRule("role", "one_of", fmt.Sprintf("invalid role: %s", req.Role), req.Role,
+				map[string]interface{}{"allowed": "super_admin, tenant_admin, staff, viewer"})
 		}
 	}
 
+	if errs.HasErrors() {
+		return errs
+	}
 	return nil
 }
 
+// emailRegex matches syntactically valid email addresses
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// validatePasswordPolicy checks a password against the configured password
+// policy and records violations on the given field
+func validatePasswordPolicy(errs *database.ValidationErrors, field, password string, config *AuthConfig) {
+	if len(password) < config.MinPasswordLength {
+		errs.AddRule(field, "min_length",
+			fmt.Sprintf("password must be at least %d characters long", config.MinPasswordLength),
+			nil, map[string]interface{}{"min": config.MinPasswordLength})
+		return
+	}
+
+	if config.RequireUppercase && !regexp.MustCompile(`[A-Z]`).MatchString(password) {
+		errs.AddRule(field, "password_uppercase", "password must contain at least one uppercase letter", nil, nil)
+		return
+	}
+
+	if config.RequireNumbers && !regexp.MustCompile(`[0-9]`).MatchString(password) {
+		errs.AddRule(field, "password_number", "password must contain at least one number", nil, nil)
+		return
+	}
+
+	if config.RequireSpecialChars && !regexp.MustCompile(`[!@#$%^&*(),.?":{}|<>]`).MatchString(password) {
+		errs.AddRule(field, "password_special", "password must contain at least one special character", nil, nil)
+	}
+}
+
 func (s *authService) createSessionAndTokens(ctx context.Context, user *model.User, ipAddress, userAgent string) (*AuthResponse, error) {
 	// Generate JWT tokens
 	serviceUser := &User{
@@ -571,7 +664,7 @@ func (s *authService) findUserByEmail(ctx context.Context, email string) (*model
 			"email": email,
 			"error": err,
 		}).Debug("User not found during login attempt")
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, apperror.New(apperror.CodeInvalidCredentials, "invalid credentials")
 	}
 
 	// Additional validation can be added here if needed
@@ -580,6 +673,29 @@ func (s *authService) findUserByEmail(ctx context.Context, email string) (*model
 	return user, nil
 }
 
+// ensureTenantActive returns an error when the tenant does not exist or has
+// been suspended or closed
+func (s *authService) ensureTenantActive(ctx context.Context, tenantID uuid.UUID) error {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"error":     err,
+		}).Debug("Tenant lookup failed")
+		return apperror.Wrap(err, apperror.CodeTenantNotFound, "tenant not found")
+	}
+
+	if !tenant.IsActiveTenant() {
+		s.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"status":    tenant.Status,
+		}).Debug("Tenant is not active")
+		return apperror.New(apperror.CodeTenantInactive, "tenant is inactive")
+	}
+
+	return nil
+}
+
 func (s *authService) hashToken(token string) string {
 	// Implement proper SHA-256 hashing for token security
 	hash := sha256.Sum256([]byte(token))
@@ -593,12 +709,11 @@ func (s *authService) RequestPasswordReset(ctx context.Context, req *PasswordRes
 	// Validate email format
 	email := strings.ToLower(strings.TrimSpace(req.Email))
 	if email == "" {
-		return nil, fmt.Errorf("email is required")
+		return nil, apperror.Validation(apperror.Field("email", "required", "email is required"))
 	}
 
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	if !emailRegex.MatchString(email) {
-		return nil, fmt.Errorf("invalid email format")
+		return nil, apperror.Validation(apperror.Field("email", "email", "invalid email format"))
 	}
 
 	// Find user by email across all tenants
@@ -614,8 +729,8 @@ func (s *authService) RequestPasswordReset(ctx context.Context, req *PasswordRes
 		}, nil
 	}
 
-	// Check rate limiting - prevent too many reset requests
-	since := time.Now().Add(-1 * time.Hour) // Allow max 3 requests per hour
+	// Check rate limiting - prevent too many reset requests per hour
+	since := time.Now().Add(-1 * time.Hour)
 	count, err := s.passwordResetRepo.CountActiveByUserID(ctx, user.ID, since)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
@@ -624,7 +739,9 @@ func (s *authService) RequestPasswordReset(ctx context.Context, req *PasswordRes
 		}).Error("Failed to check password reset rate limit")
 	}
 
-	if count >= 3 {
+	// The request is anonymous, so the limit is resolved for the user
+	userCtx := logger.WithUserID(logger.WithTenantID(ctx, user.TenantID.String()), user.ID.String())
+	if count >= s.settings.Int(userCtx, SettingPasswordResetLimit) {
 		s.logger.WithFields(logrus.Fields{
 			"user_id": user.ID,
 			"email":   email,
@@ -763,16 +880,18 @@ func (s *authService) ValidateResetToken(ctx context.Context, token string) (*Re
 func (s *authService) ResetPassword(ctx context.Context, req *ResetPasswordRequest) error {
 	s.logger.Debug("Processing password reset")
 
+	var errs database.ValidationErrors
 	if req.Token == "" {
-		return fmt.Errorf("reset token is required")
+		errs.AddRule("token", "required", "reset token is required", nil, nil)
 	}
 	if req.NewPassword == "" {
-		return fmt.Errorf("new password is required")
+		errs.AddRule("new_password", "required", "new password is required", nil, nil)
+	} else {
+		// Validate password strength
+		validatePasswordPolicy(&errs, "new_password", req.NewPassword, s.config)
 	}
-
-	// Validate password strength
-	if len(req.NewPassword) < s.config.MinPasswordLength {
-		return fmt.Errorf("password must be at least %d characters long", s.config.MinPasswordLength)
+	if errs.HasErrors() {
+		return errs
 	}
 
 	// Validate the reset token first
@@ -782,7 +901,7 @@ func (s *authService) ResetPassword(ctx context.Context, req *ResetPasswordReque
 	}
 
 	if !validation.IsValid {
-		return fmt.Errorf("invalid reset token: %s", validation.ErrorMessage)
+		return apperror.Newf(apperror.CodeInvalidResetToken, "invalid reset token: %s", validation.ErrorMessage)
 	}
 
 	// Get the user
@@ -824,7 +943,7 @@ func (s *authService) ResetPassword(ctx context.Context, req *ResetPasswordReque
 	}
 
 	// Deactivate all other sessions for security
-	if err := s.sessionRepo.DeactivateByUserID(ctx, user.ID); err != nil {
+	if err := s.revokeUserSessions(ctx, user.ID); err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"user_id": user.ID,
 			"error":   err,
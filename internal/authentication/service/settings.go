@@ -0,0 +1,15 @@
+package service
+
+import (
+	"github.com/VincentArjuna/RexiErp/internal/shared/settings"
+)
+
+// Setting keys of the authentication service
+const (
+	SettingPasswordResetLimit = "auth.password_reset_limit"
+)
+
+// Settings declares the tunable settings of the authentication service
+var Settings = []settings.Definition{
+	settings.Int(SettingPasswordResetLimit, 3, "Password reset requests a user may make per hour").Between(1, 10),
+}
@@ -0,0 +1,463 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/authentication/repository"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
+)
+
+// subdomainRegex matches a single DNS label
+var subdomainRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// tenantService implements TenantService interface
+type tenantService struct {
+	tenantRepo   repository.TenantRepository
+	userRepo     repository.UserRepository
+	activityRepo repository.ActivityRepository
+	enforcer     *subscription.Enforcer
+	revocations  RevocationStore
+	logger       *logrus.Logger
+	config       *AuthConfig
+}
+
+// NewTenantService creates a new instance of TenantService
+func NewTenantService(
+	tenantRepo repository.TenantRepository,
+	userRepo repository.UserRepository,
+	activityRepo repository.ActivityRepository,
+	enforcer *subscription.Enforcer,
+	revocations RevocationStore,
+	logger *logrus.Logger,
+	config *AuthConfig,
+) TenantService {
+	return &tenantService{
+		tenantRepo:   tenantRepo,
+		userRepo:     userRepo,
+		activityRepo: activityRepo,
+		enforcer:     enforcer,
+		revocations:  revocations,
+		logger:       logger,
+		config:       config,
+	}
+}
+
+// CreateTenant provisions a new tenant with its first tenant_admin and default master data
+func (s *tenantService) CreateTenant(ctx context.Context, req *CreateTenantRequest) (*TenantProvisionResult, error) {
+	s.logger.WithFields(logrus.Fields{
+		"name":  req.Name,
+		"email": req.Email,
+	}).Debug("Creating new tenant")
+
+	// Validate input
+	if err := s.validateCreateTenantRequest(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// Check uniqueness
+	exists, err := s.tenantRepo.ExistsByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check tenant existence: %w", err)
+	}
+	if exists {
+		return nil, apperror.Newf(apperror.CodeTenantAlreadyExists, "tenant with email %s already exists", req.Email)
+	}
+
+	if req.Subdomain != "" {
+		exists, err := s.tenantRepo.ExistsBySubdomain(ctx, req.Subdomain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check subdomain existence: %w", err)
+		}
+		if exists {
+			return nil, apperror.Newf(apperror.CodeTenantAlreadyExists, "subdomain %s already exists", req.Subdomain)
+		}
+	}
+
+	// Login resolves users across tenants, so admin emails must be globally unique
+	exists, err = s.userRepo.ExistsByEmailAcrossTenants(ctx, req.Admin.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check admin email existence: %w", err)
+	}
+	if exists {
+		return nil, apperror.Newf(apperror.CodeUserAlreadyExists, "user with email %s already exists", req.Admin.Email)
+	}
+
+	// Hash admin password
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Admin.Password), bcrypt.DefaultCost)
+	if err != nil {
+		s.logger.WithField("error", err).Error("Failed to hash password")
+		return nil, fmt.Errorf("failed to process password: %w", err)
+	}
+
+	tenant := s.buildTenant(req)
+	admin := &model.User{
+		ID:           uuid.New(),
+		TenantID:     tenant.ID,
+		Email:        strings.ToLower(strings.TrimSpace(req.Admin.Email)),
+		PasswordHash: string(hashedPassword),
+		FullName:     strings.TrimSpace(req.Admin.FullName),
+		PhoneNumber:  strings.TrimSpace(req.Admin.PhoneNumber),
+		Role:         model.RoleTenantAdmin,
+		IsActive:     true,
+	}
+	defaults := model.NewTenantDefaults(tenant)
+
+	// The seeded warehouses count against the warehouse limit of the plan
+	if err := s.enforcer.CheckPlanCount(tenant.ID, tenant.SubscriptionPlan, subscription.ResourceWarehouses, 0, int64(len(defaults.Warehouses))); err != nil {
+		return nil, err
+	}
+
+	if err := s.tenantRepo.Provision(ctx, tenant, admin, defaults); err != nil {
+		return nil, fmt.Errorf("failed to provision tenant: %w", err)
+	}
+
+	s.logActivity(ctx, tenant.ID, "tenant_create", &tenant.ID, nil,
+		map[string]interface{}{
+			"name":         tenant.Name,
+			"company_type": tenant.CompanyType,
+			"tax_status":   tenant.TaxStatus,
+			"admin_id":     admin.ID,
+		}, true, "")
+
+	s.logger.WithFields(logrus.Fields{
+		"tenant_id": tenant.ID,
+		"name":      tenant.Name,
+		"admin_id":  admin.ID,
+	}).Info("Tenant created successfully")
+
+	return &TenantProvisionResult{
+		Tenant:           tenant,
+		Admin:            admin.SanitizeForResponse(),
+		SeededAccounts:   len(defaults.Accounts),
+		SeededWarehouses: len(defaults.Warehouses),
+		SeededSequences:  len(defaults.Sequences),
+	}, nil
+}
+
+// GetTenant retrieves a tenant by ID
+func (s *tenantService) GetTenant(ctx context.Context, tenantID uuid.UUID) (*model.Tenant, error) {
+	s.logger.WithField("tenant_id", tenantID).Debug("Getting tenant")
+
+	return s.tenantRepo.GetByID(ctx, tenantID)
+}
+
+// ListTenants retrieves tenants with an optional status filter
+func (s *tenantService) ListTenants(ctx context.Context, status string, limit, offset int) ([]*model.Tenant, int64, error) {
+	if status != "" && !isValidTenantStatus(status) {
+		return nil, 0, apperror.Validation(apperror.FieldError{
+			Field:   "status",
+			Rule:    "one_of",
+			Message: fmt.Sprintf("invalid status: %s", status),
+			Params:  map[string]interface{}{"allowed": "active, suspended, closed"},
+		})
+	}
+
+	tenants, err := s.tenantRepo.List(ctx, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.tenantRepo.Count(ctx, status)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return tenants, total, nil
+}
+
+// SuspendTenant suspends an active tenant and revokes all of its sessions
+func (s *tenantService) SuspendTenant(ctx context.Context, tenantID uuid.UUID, reason string) (*model.Tenant, error) {
+	return s.transition(ctx, tenantID, model.TenantStatusSuspended, reason, "tenant_suspend")
+}
+
+// ReactivateTenant reactivates a suspended tenant
+func (s *tenantService) ReactivateTenant(ctx context.Context, tenantID uuid.UUID) (*model.Tenant, error) {
+	return s.transition(ctx, tenantID, model.TenantStatusActive, "", "tenant_reactivate")
+}
+
+// CloseTenant permanently closes a tenant and revokes all of its sessions
+func (s *tenantService) CloseTenant(ctx context.Context, tenantID uuid.UUID, reason string) (*model.Tenant, error) {
+	return s.transition(ctx, tenantID, model.TenantStatusClosed, reason, "tenant_close")
+}
+
+// GetTenantUsage reports the tenant's resource usage against its plan limits
+func (s *tenantService) GetTenantUsage(ctx context.Context, tenantID uuid.UUID) (*subscription.UsageReport, error) {
+	s.logger.WithField("tenant_id", tenantID).Debug("Getting tenant usage")
+
+	if _, err := s.tenantRepo.GetByID(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	users, err := s.userRepo.CountByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	warehouses, err := s.tenantRepo.CountWarehouses(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.enforcer.Report(ctx, tenantID, map[subscription.Resource]int64{
+		subscription.ResourceUsers:      users,
+		subscription.ResourceWarehouses: warehouses,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build usage report: %w", err)
+	}
+
+	return report, nil
+}
+
+// ListActivityLogs returns one keyset page of the tenant's activity log
+func (s *tenantService) ListActivityLogs(ctx context.Context, tenantID uuid.UUID, filters repository.ActivityFilters, page database.CursorPagination) ([]*model.ActivityLog, database.PageInfo, error) {
+	s.logger.WithField("tenant_id", tenantID).Debug("Listing tenant activity logs")
+
+	return s.activityRepo.ListPage(ctx, tenantID, filters, page)
+}
+
+// transition moves a tenant to the target lifecycle status
+func (s *tenantService) transition(ctx context.Context, tenantID uuid.UUID, target model.TenantStatus, reason, action string) (*model.Tenant, error) {
+	s.logger.WithFields(logrus.Fields{
+		"tenant_id": tenantID,
+		"target":    target,
+	}).Debug("Changing tenant status")
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := tenant.Status
+	if !tenant.CanTransitionTo(target) {
+		s.logActivity(ctx, tenant.ID, action, &tenant.ID, nil, nil, false,
+			fmt.Sprintf("Invalid transition from %s to %s", previous, target))
+		return nil, apperror.Newf(apperror.CodeInvalidStatusTransition, "invalid status transition from %s to %s", previous, target).
+			WithDetail("from", previous).
+			WithDetail("to", target)
+	}
+
+	switch target {
+	case model.TenantStatusSuspended:
+		tenant.Suspend(strings.TrimSpace(reason))
+	case model.TenantStatusClosed:
+		tenant.Close(strings.TrimSpace(reason))
+	case model.TenantStatusActive:
+		tenant.Reactivate()
+	}
+
+	deactivated, err := s.tenantRepo.UpdateStatus(ctx, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tenant status: %w", err)
+	}
+
+	// The gateway verifies tokens without a database, so the access tokens of
+	// the deactivated sessions stay usable until they are marked revoked
+	for _, session := range deactivated {
+		markRevoked(ctx, s.revocations, s.logger, session)
+	}
+
+	s.logActivity(ctx, tenant.ID, action, &tenant.ID,
+		map[string]interface{}{"status": previous},
+		map[string]interface{}{
+			"status":               tenant.Status,
+			"reason":               tenant.StatusReason,
+			"sessions_deactivated": len(deactivated),
+		}, true, "")
+
+	s.logger.WithFields(logrus.Fields{
+		"tenant_id":            tenant.ID,
+		"from":                 previous,
+		"to":                   tenant.Status,
+		"sessions_deactivated": len(deactivated),
+	}).Info("Tenant status changed successfully")
+
+	return tenant, nil
+}
+
+// Helper functions
+
+func (s *tenantService) validateCreateTenantRequest(req *CreateTenantRequest) error {
+	var errs database.ValidationErrors
+
+	if strings.TrimSpace(req.Name) == "" {
+		errs.AddRule("name", "required", "name is required", nil, nil)
+	}
+
+	if !emailRegex.MatchString(req.Email) {
+		errs.AddRule("email", "email", "invalid email format", req.Email, nil)
+	}
+
+	if !model.IsValidCompanyType(req.CompanyType) {
+		errs.AddRule("company_type", "invalid", fmt.Sprintf("invalid company type: %s", req.CompanyType), req.CompanyType, nil)
+	}
+
+	if !model.IsValidBusinessCategory(req.BusinessCategory) {
+		errs.AddRule("business_category", "invalid", fmt.Sprintf("invalid business category: %s", req.BusinessCategory), req.BusinessCategory, nil)
+	}
+
+	if req.TaxStatus != "" && req.TaxStatus != string(model.TaxStatusPKP) && req.TaxStatus != string(model.TaxStatusNonPKP) {
+		errs.AddRule("tax_status", "one_of", fmt.Sprintf("invalid tax status: %s", req.TaxStatus), req.TaxStatus,
+			map[string]interface{}{"allowed": fmt.Sprintf("%s, %s", model.TaxStatusPKP, model.TaxStatusNonPKP)})
+	}
+
+	// PKP tenants issue Faktur Pajak and must have an NPWP
+	if req.TaxStatus == string(model.TaxStatusPKP) && strings.TrimSpace(req.TaxNumber) == "" {
+		errs.AddRule("tax_number", "npwp_required", "NPWP is required for PKP tenants", nil, nil)
+	}
+
+	if strings.TrimSpace(req.TaxNumber) != "" {
+		if _, err := model.NormalizeNPWP(req.TaxNumber); err != nil {
+			errs.AddRule("tax_number", "npwp", err.Error(), nil, nil)
+		}
+	}
+
+	if req.Subdomain != "" && !subdomainRegex.MatchString(strings.ToLower(req.Subdomain)) {
+		errs.AddRule("subdomain", "invalid", fmt.Sprintf("invalid subdomain: %s", req.Subdomain), req.Subdomain, nil)
+	}
+
+	// A region can only be set together with its parent regions
+	if req.VillageID != nil && req.DistrictID == nil {
+		errs.AddRule("district_id", "required", "district is required when village is set", nil, nil)
+	}
+	if req.DistrictID != nil && req.CityID == nil {
+		errs.AddRule("city_id", "required", "city is required when district is set", nil, nil)
+	}
+	if req.CityID != nil && req.ProvinceID == nil {
+		errs.AddRule("province_id", "required", "province is required when city is set", nil, nil)
+	}
+
+	if req.SubscriptionPlan != "" && !s.enforcer.Catalogue().IsValid(req.SubscriptionPlan) {
+		errs.AddRule("subscription_plan", "invalid", fmt.Sprintf("invalid subscription plan: %s", req.SubscriptionPlan), req.SubscriptionPlan, nil)
+	}
+
+	if req.MaxUsers < 0 {
+		errs.AddRule("max_users", "non_negative", "max users cannot be negative", req.MaxUsers, nil)
+	}
+
+	// Validate the first tenant admin
+	if !emailRegex.MatchString(req.Admin.Email) {
+		errs.AddRule("admin.email", "email", "invalid admin email format", req.Admin.Email, nil)
+	}
+	if strings.TrimSpace(req.Admin.FullName) == "" {
+		errs.AddRule("admin.full_name", "required", "admin full name is required", nil, nil)
+	}
+	validatePasswordPolicy(&errs, "admin.password", req.Admin.Password, s.config)
+
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+func (s *tenantService) buildTenant(req *CreateTenantRequest) *model.Tenant {
+	tenant := &model.Tenant{
+		ID:               uuid.New(),
+		Name:             strings.TrimSpace(req.Name),
+		CompanyType:      model.CompanyType(req.CompanyType),
+		BusinessCategory: model.BusinessCategory(req.BusinessCategory),
+		TaxStatus:        model.TaxStatusNonPKP,
+		Email:            strings.ToLower(strings.TrimSpace(req.Email)),
+		Phone:            strings.TrimSpace(req.Phone),
+		Address:          strings.TrimSpace(req.Address),
+		CountryID:        req.CountryID,
+		ProvinceID:       req.ProvinceID,
+		CityID:           req.CityID,
+		DistrictID:       req.DistrictID,
+		VillageID:        req.VillageID,
+		PostalCode:       strings.TrimSpace(req.PostalCode),
+		Status:           model.TenantStatusActive,
+		IsActive:         true,
+		SubscriptionPlan: subscription.PlanBasic,
+	}
+
+	if req.TaxStatus != "" {
+		tenant.TaxStatus = model.TaxStatus(req.TaxStatus)
+	}
+	if req.TaxNumber != "" {
+		tenant.TaxNumber, _ = model.NormalizeNPWP(req.TaxNumber)
+	}
+	if domain := strings.ToLower(strings.TrimSpace(req.Domain)); domain != "" {
+		tenant.Domain = &domain
+	}
+	if subdomain := strings.ToLower(strings.TrimSpace(req.Subdomain)); subdomain != "" {
+		tenant.Subdomain = &subdomain
+	}
+	if req.SubscriptionPlan != "" {
+		tenant.SubscriptionPlan = req.SubscriptionPlan
+	}
+
+	// max_users defaults to the plan limit and may only raise it
+	plan := s.enforcer.Catalogue().Resolve(tenant.SubscriptionPlan)
+	if plan.Limits.MaxUsers > 0 {
+		tenant.MaxUsers = int(plan.Limits.MaxUsers)
+	}
+	if req.MaxUsers > tenant.MaxUsers {
+		tenant.MaxUsers = req.MaxUsers
+	}
+
+	return tenant
+}
+
+// NewTenantPlanResolver creates a subscription.PlanResolver reading the plan
+// from the tenants table
+func NewTenantPlanResolver(tenantRepo repository.TenantRepository) subscription.PlanResolver {
+	return subscription.PlanResolverFunc(func(ctx context.Context, tenantID uuid.UUID) (*subscription.TenantPlan, error) {
+		tenant, err := tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		return &subscription.TenantPlan{
+			PlanCode: tenant.SubscriptionPlan,
+			MaxUsers: tenant.MaxUsers,
+		}, nil
+	})
+}
+
+func isValidTenantStatus(status string) bool {
+	switch model.TenantStatus(status) {
+	case model.TenantStatusActive, model.TenantStatusSuspended, model.TenantStatusClosed:
+		return true
+	}
+	return false
+}
+
+func (s *tenantService) logActivity(ctx context.Context, tenantID uuid.UUID, action string,
+	resourceID *uuid.UUID, oldValues, newValues map[string]interface{}, success bool, errorMessage string) {
+
+	activity := &model.ActivityLog{
+		TenantID:     tenantID,
+		Action:       action,
+		ResourceType: "tenant",
+		ResourceID:   resourceID,
+		Success:      success,
+		ErrorMessage: errorMessage,
+	}
+
+	if oldValues != nil {
+		_ = activity.SetOldValues(oldValues)
+	}
+	if newValues != nil {
+		_ = activity.SetNewValues(newValues)
+	}
+
+	// Create activity log asynchronously
+	go func() {
+		if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"action": action,
+				"error":  err,
+			}).Error("Failed to create activity log")
+		}
+	}()
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/authentication/repository"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+// memoryRevocations records the keys the gateway checks for revoked sessions
+type memoryRevocations map[string]time.Duration
+
+func (m memoryRevocations) Set(_ context.Context, key string, _ interface{}, expiration time.Duration) error {
+	m[key] = expiration
+	return nil
+}
+
+// discardActivityRepository drops the activity log of the transitions
+type discardActivityRepository struct {
+	repository.ActivityRepository
+}
+
+func (discardActivityRepository) Create(context.Context, *model.ActivityLog) error {
+	return nil
+}
+
+func newLifecycleTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	// The models default to gen_random_uuid(), which SQLite lacks
+	require.NoError(t, db.Exec(`CREATE TABLE tenants (
+		id TEXT PRIMARY KEY, name TEXT NOT NULL, domain TEXT, subdomain TEXT, company_type TEXT, business_category TEXT,
+		tax_number TEXT, tax_status TEXT, email TEXT NOT NULL, phone TEXT, address TEXT, country_id TEXT, province_id TEXT,
+		city_id TEXT, district_id TEXT, village_id TEXT, postal_code TEXT, is_active BOOLEAN NOT NULL,
+		status TEXT NOT NULL, status_reason TEXT, suspended_at DATETIME, closed_at DATETIME,
+		subscription_plan TEXT, max_users INTEGER, created_at DATETIME, updated_at DATETIME)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE user_sessions (
+		id TEXT PRIMARY KEY, user_id TEXT NOT NULL, tenant_id TEXT NOT NULL, session_id TEXT NOT NULL,
+		token_hash TEXT, refresh_token_hash TEXT, device_info TEXT, ip_address TEXT, user_agent TEXT,
+		expires_at DATETIME, last_activity DATETIME, is_active BOOLEAN, created_at DATETIME, updated_at DATETIME)`).Error)
+	return db
+}
+
+func createTestSession(t *testing.T, db *gorm.DB, tenantID uuid.UUID, active bool) *model.UserSession {
+	session := &model.UserSession{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		TenantID:     tenantID,
+		SessionID:    uuid.NewString(),
+		ExpiresAt:    time.Now().Add(time.Hour),
+		LastActivity: time.Now(),
+		IsActive:     true,
+	}
+	require.NoError(t, db.Omit("User").Create(session).Error)
+	if !active {
+		require.NoError(t, db.Model(session).Update("is_active", false).Error)
+	}
+	return session
+}
+
+func TestTenantService_TransitionRevokesSessions(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		transition func(TenantService, context.Context, uuid.UUID) (*model.Tenant, error)
+	}{
+		{"suspend", func(s TenantService, ctx context.Context, id uuid.UUID) (*model.Tenant, error) {
+			return s.SuspendTenant(ctx, id, "Unpaid invoice")
+		}},
+		{"close", func(s TenantService, ctx context.Context, id uuid.UUID) (*model.Tenant, error) {
+			return s.CloseTenant(ctx, id, "Contract ended")
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newLifecycleTestDB(t)
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+
+			tenant := &model.Tenant{
+				ID:       uuid.New(),
+				Name:     "PT Maju Jaya",
+				Email:    "admin@majujaya.co.id",
+				IsActive: true,
+				Status:   model.TenantStatusActive,
+			}
+			require.NoError(t, db.Create(tenant).Error)
+			active := []*model.UserSession{
+				createTestSession(t, db, tenant.ID, true),
+				createTestSession(t, db, tenant.ID, true),
+			}
+			loggedOut := createTestSession(t, db, tenant.ID, false)
+			otherTenant := createTestSession(t, db, uuid.New(), true)
+
+			revocations := memoryRevocations{}
+			tenants := NewTenantService(
+				repository.NewTenantRepository(&database.Database{DB: db}, logger),
+				nil, discardActivityRepository{}, nil, revocations, logger, nil,
+			)
+
+			_, err := tc.transition(tenants, ctx, tenant.ID)
+			require.NoError(t, err)
+
+			// The gateway rejects the access tokens of every deactivated session
+			assert.Len(t, revocations, len(active))
+			for _, session := range active {
+				ttl, revoked := revocations[RevokedSessionKey(session.SessionID)]
+				assert.True(t, revoked)
+				assert.InDelta(t, time.Hour.Seconds(), ttl.Seconds(), 60)
+			}
+			assert.NotContains(t, revocations, RevokedSessionKey(loggedOut.SessionID))
+			assert.NotContains(t, revocations, RevokedSessionKey(otherTenant.SessionID))
+
+			var stillActive int64
+			require.NoError(t, db.Model(&model.UserSession{}).Where("tenant_id = ? AND is_active = ?", tenant.ID, true).Count(&stillActive).Error)
+			assert.Zero(t, stillActive)
+		})
+	}
+}
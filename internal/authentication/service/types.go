@@ -18,6 +18,9 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/VincentArjuna/RexiErp/internal/authentication/model"
+	"github.com/VincentArjuna/RexiErp/internal/authentication/repository"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
 )
 
 // ============================================================================
@@ -52,6 +55,28 @@ type AuthService interface {
 	GetUserSessions(ctx context.Context, userID uuid.UUID) ([]*model.UserSession, error)
 }
 
+// TenantService defines the contract for tenant provisioning and lifecycle operations.
+// Provisioning creates the tenant, its first tenant_admin and the default master
+// data in one step. Suspending or closing a tenant revokes all of its sessions.
+// Usage is reported against the limits of the tenant's subscription plan.
+type TenantService interface {
+	// Provisioning
+	CreateTenant(ctx context.Context, req *CreateTenantRequest) (*TenantProvisionResult, error)
+	GetTenant(ctx context.Context, tenantID uuid.UUID) (*model.Tenant, error)
+	ListTenants(ctx context.Context, status string, limit, offset int) ([]*model.Tenant, int64, error)
+
+	// Lifecycle
+	SuspendTenant(ctx context.Context, tenantID uuid.UUID, reason string) (*model.Tenant, error)
+	ReactivateTenant(ctx context.Context, tenantID uuid.UUID) (*model.Tenant, error)
+	CloseTenant(ctx context.Context, tenantID uuid.UUID, reason string) (*model.Tenant, error)
+
+	// Subscription
+	GetTenantUsage(ctx context.Context, tenantID uuid.UUID) (*subscription.UsageReport, error)
+
+	// Activity
+	ListActivityLogs(ctx context.Context, tenantID uuid.UUID, filters repository.ActivityFilters, page database.CursorPagination) ([]*model.ActivityLog, database.PageInfo, error)
+}
+
 // JWTService defines the contract for JWT token operations.
 // This interface handles the creation, validation, and parsing of JWT tokens
 // for authentication and authorization purposes.
@@ -174,6 +199,11 @@ type LoginRequest struct {
 type UpdateProfileRequest struct {
 	FullName    *string `json:"full_name,omitempty"`
 	PhoneNumber *string `json:"phone_number,omitempty"`
+
+	// Version is the version of the profile the change is based on; the
+	// update fails with database.ErrStaleObject if the profile changed
+	// since. Zero updates whatever version is current.
+	Version int64 `json:"-"`
 }
 
 // ChangePasswordRequest represents password change data with security validation.
@@ -222,5 +252,48 @@ type AuthResponse struct {
 	SessionID    string      `json:"session_id"`
 }
 
+// CreateTenantRequest represents tenant provisioning input.
+// Region IDs reference the countries/provinces/cities/districts/villages master data.
+type CreateTenantRequest struct {
+	Name             string     `json:"name"`
+	Domain           string     `json:"domain,omitempty"`
+	Subdomain        string     `json:"subdomain,omitempty"`
+	CompanyType      string     `json:"company_type"`
+	BusinessCategory string     `json:"business_category"`
+	TaxNumber        string     `json:"tax_number,omitempty"` // NPWP
+	TaxStatus        string     `json:"tax_status,omitempty"`
+	Email            string     `json:"email"`
+	Phone            string     `json:"phone,omitempty"`
+	Address          string     `json:"address,omitempty"`
+	CountryID        *uuid.UUID `json:"country_id,omitempty"`
+	ProvinceID       *uuid.UUID `json:"province_id,omitempty"`
+	CityID           *uuid.UUID `json:"city_id,omitempty"`
+	DistrictID       *uuid.UUID `json:"district_id,omitempty"`
+	VillageID        *uuid.UUID `json:"village_id,omitempty"`
+	PostalCode       string     `json:"postal_code,omitempty"`
+	SubscriptionPlan string     `json:"subscription_plan,omitempty"`
+	MaxUsers         int        `json:"max_users,omitempty"`
+
+	// Admin is the first tenant_admin user created for the tenant
+	Admin TenantAdminRequest `json:"admin"`
+}
+
+// TenantAdminRequest represents the first tenant_admin account of a new tenant.
+type TenantAdminRequest struct {
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	FullName    string `json:"full_name"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+// TenantProvisionResult represents the outcome of tenant provisioning.
+type TenantProvisionResult struct {
+	Tenant           *model.Tenant `json:"tenant"`
+	Admin            *model.User   `json:"admin"`
+	SeededAccounts   int           `json:"seeded_accounts"`
+	SeededWarehouses int           `json:"seeded_warehouses"`
+	SeededSequences  int           `json:"seeded_sequences"`
+}
+
 // NOTE: model.User and other model types are imported from the model package
 // to avoid circular dependencies and maintain clean separation of concerns.
\ No newline at end of file
@@ -0,0 +1,190 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errNoHealthyUpstream is returned when every instance of a route is down
+var errNoHealthyUpstream = errors.New("no healthy upstream")
+
+// upstream is a single service instance
+type upstream struct {
+	url *url.URL
+
+	mu        sync.Mutex
+	healthy   bool
+	failures  int
+	successes int
+}
+
+func (u *upstream) isHealthy() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.healthy
+}
+
+// recordFailure counts a failed check or request and reports whether the
+// instance was taken out of rotation by it
+func (u *upstream) recordFailure(threshold int) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.successes = 0
+	u.failures++
+	if u.healthy && u.failures >= threshold {
+		u.healthy = false
+		return true
+	}
+	return false
+}
+
+// recordSuccess counts a successful check or request and reports whether the
+// instance was put back into rotation by it
+func (u *upstream) recordSuccess(threshold int) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.failures = 0
+	u.successes++
+	if !u.healthy && u.successes >= threshold {
+		u.healthy = true
+		return true
+	}
+	return false
+}
+
+// pool balances requests round robin over the healthy instances of a route
+type pool struct {
+	route     string
+	upstreams []*upstream
+	counter   uint64
+	config    HealthCheckConfig
+	logger    *logrus.Logger
+}
+
+func newPool(route string, urls []string, config HealthCheckConfig, logger *logrus.Logger) (*pool, error) {
+	p := &pool{route: route, config: config, logger: logger}
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		// Instances start healthy so the gateway serves traffic before the
+		// first round of checks
+		p.upstreams = append(p.upstreams, &upstream{url: u, healthy: true})
+	}
+	return p, nil
+}
+
+// pick returns the next healthy instance, skipping those in exclude
+func (p *pool) pick(exclude map[*upstream]bool) (*upstream, error) {
+	n := uint64(len(p.upstreams))
+	start := atomic.AddUint64(&p.counter, 1)
+	for i := uint64(0); i < n; i++ {
+		u := p.upstreams[(start+i)%n]
+		if u.isHealthy() && !exclude[u] {
+			return u, nil
+		}
+	}
+	return nil, errNoHealthyUpstream
+}
+
+// healthyCount returns the number of instances in rotation
+func (p *pool) healthyCount() int {
+	count := 0
+	for _, u := range p.upstreams {
+		if u.isHealthy() {
+			count++
+		}
+	}
+	return count
+}
+
+// markFailure records a failed proxied request
+func (p *pool) markFailure(u *upstream, err error) {
+	if u.recordFailure(p.config.UnhealthyThreshold) {
+		p.logger.WithFields(logrus.Fields{
+			"route":    p.route,
+			"upstream": u.url.String(),
+			"error":    err,
+		}).Warn("Upstream taken out of rotation")
+	}
+}
+
+// markSuccess records a successful proxied request. Only health checks put
+// an instance back into rotation, since it no longer receives traffic.
+func (p *pool) markSuccess(u *upstream) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.failures = 0
+}
+
+// check probes every instance once
+func (p *pool) check(ctx context.Context, client *http.Client) {
+	var wg sync.WaitGroup
+	for _, u := range p.upstreams {
+		wg.Add(1)
+		go func(u *upstream) {
+			defer wg.Done()
+			p.checkOne(ctx, client, u)
+		}(u)
+	}
+	wg.Wait()
+}
+
+func (p *pool) checkOne(ctx context.Context, client *http.Client, u *upstream) {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	target := strings.TrimSuffix(u.url.String(), "/") + p.config.Path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		p.markFailure(u, err)
+		return
+	}
+
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			err = errors.New(resp.Status)
+		}
+	}
+	if err != nil {
+		p.markFailure(u, err)
+		return
+	}
+
+	if u.recordSuccess(p.config.HealthyThreshold) {
+		p.logger.WithFields(logrus.Fields{
+			"route":    p.route,
+			"upstream": u.url.String(),
+		}).Info("Upstream back in rotation")
+	}
+}
+
+// runHealthChecks probes the pools every interval until ctx is done
+func runHealthChecks(ctx context.Context, pools []*pool, config HealthCheckConfig, client *http.Client) {
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	for {
+		for _, p := range pools {
+			p.check(ctx, client)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/middleware"
+)
+
+// AuthMode controls JWT verification on a route
+type AuthMode string
+
+const (
+	// AuthRequired rejects requests without a valid access token
+	AuthRequired AuthMode = "required"
+	// AuthOptional verifies a token when one is sent, e.g. for the auth
+	// service whose login routes are public
+	AuthOptional AuthMode = "optional"
+	// AuthNone forwards requests without looking at the token
+	AuthNone AuthMode = "none"
+)
+
+// Config is the gateway route table
+type Config struct {
+	HealthCheck HealthCheckConfig `yaml:"health_check"`
+	Routes      []RouteConfig     `yaml:"routes"`
+}
+
+// HealthCheckConfig configures upstream health checking. Upstreams are taken
+// out of rotation after UnhealthyThreshold consecutive failed checks or
+// proxied requests and put back after HealthyThreshold successful checks.
+type HealthCheckConfig struct {
+	Path               string        `yaml:"path"`
+	Interval           time.Duration `yaml:"interval"`
+	Timeout            time.Duration `yaml:"timeout"`
+	UnhealthyThreshold int           `yaml:"unhealthy_threshold"`
+	HealthyThreshold   int           `yaml:"healthy_threshold"`
+}
+
+// RouteConfig maps a path prefix to a set of upstream instances
+type RouteConfig struct {
+	Name       string `yaml:"name"`
+	PathPrefix string `yaml:"path_prefix"`
+	// Methods restricts the route to these HTTP methods; empty allows all
+	Methods []string `yaml:"methods"`
+	// Upstreams are the base URLs of the service instances
+	Upstreams []string `yaml:"upstreams"`
+	// StripPrefix is removed from the path before forwarding
+	StripPrefix string           `yaml:"strip_prefix"`
+	Auth        AuthMode         `yaml:"auth"`
+	Timeout     time.Duration    `yaml:"timeout"`
+	RateLimit   *RateLimitConfig `yaml:"rate_limit"`
+}
+
+// RateLimitConfig is the rate limit of a route
+type RateLimitConfig struct {
+	Limit  int64         `yaml:"limit"`
+	Window time.Duration `yaml:"window"`
+	// KeyBy is ip, user or tenant; anonymous requests fall back to the IP
+	KeyBy middleware.RateLimitKey `yaml:"key_by"`
+}
+
+// LoadConfig reads the route table from a YAML file. Environment variables
+// in the file are expanded, so upstreams can be overridden per deployment,
+// e.g. ${INVENTORY_SERVICE_URL}.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path comes from the deployment configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gateway config: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse gateway config: %w", err)
+	}
+
+	cfg.setDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) setDefaults() {
+	if c.HealthCheck.Path == "" {
+		c.HealthCheck.Path = "/health"
+	}
+	if c.HealthCheck.Interval == 0 {
+		c.HealthCheck.Interval = 10 * time.Second
+	}
+	if c.HealthCheck.Timeout == 0 {
+		c.HealthCheck.Timeout = 2 * time.Second
+	}
+	if c.HealthCheck.UnhealthyThreshold == 0 {
+		c.HealthCheck.UnhealthyThreshold = 3
+	}
+	if c.HealthCheck.HealthyThreshold == 0 {
+		c.HealthCheck.HealthyThreshold = 2
+	}
+
+	for i := range c.Routes {
+		route := &c.Routes[i]
+		if route.Auth == "" {
+			route.Auth = AuthRequired
+		}
+		if route.Timeout == 0 {
+			route.Timeout = 30 * time.Second
+		}
+		if route.RateLimit != nil {
+			if route.RateLimit.Window == 0 {
+				route.RateLimit.Window = time.Minute
+			}
+			if route.RateLimit.KeyBy == "" {
+				route.RateLimit.KeyBy = middleware.RateLimitByIP
+			}
+		}
+		for j, method := range route.Methods {
+			route.Methods[j] = strings.ToUpper(method)
+		}
+	}
+}
+
+// Validate checks the route table for mistakes that would otherwise only
+// show up as misrouted requests
+func (c *Config) Validate() error {
+	if len(c.Routes) == 0 {
+		return fmt.Errorf("gateway config has no routes")
+	}
+
+	names := make(map[string]bool)
+	prefixes := make(map[string]string)
+	for _, route := range c.Routes {
+		if route.Name == "" {
+			return fmt.Errorf("route with prefix %q has no name", route.PathPrefix)
+		}
+		if names[route.Name] {
+			return fmt.Errorf("duplicate route name %q", route.Name)
+		}
+		names[route.Name] = true
+
+		if !strings.HasPrefix(route.PathPrefix, "/") {
+			return fmt.Errorf("route %s: path_prefix must start with /", route.Name)
+		}
+		if other, exists := prefixes[route.PathPrefix]; exists {
+			return fmt.Errorf("route %s: path_prefix %s is already used by route %s", route.Name, route.PathPrefix, other)
+		}
+		prefixes[route.PathPrefix] = route.Name
+
+		if route.StripPrefix != "" && !strings.HasPrefix(route.PathPrefix, route.StripPrefix) {
+			return fmt.Errorf("route %s: strip_prefix must be a prefix of path_prefix", route.Name)
+		}
+		if len(route.Upstreams) == 0 {
+			return fmt.Errorf("route %s: no upstreams", route.Name)
+		}
+		for _, upstream := range route.Upstreams {
+			u, err := url.Parse(upstream)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("route %s: invalid upstream URL %q", route.Name, upstream)
+			}
+		}
+		for _, method := range route.Methods {
+			if !validMethod(method) {
+				return fmt.Errorf("route %s: invalid method %q", route.Name, method)
+			}
+		}
+
+		switch route.Auth {
+		case AuthRequired, AuthOptional, AuthNone:
+		default:
+			return fmt.Errorf("route %s: invalid auth mode %q", route.Name, route.Auth)
+		}
+
+		if rl := route.RateLimit; rl != nil {
+			if rl.Limit <= 0 {
+				return fmt.Errorf("route %s: rate_limit.limit must be positive", route.Name)
+			}
+			switch rl.KeyBy {
+			case middleware.RateLimitByIP, middleware.RateLimitByUser, middleware.RateLimitByTenant:
+			default:
+				return fmt.Errorf("route %s: invalid rate_limit.key_by %q", route.Name, rl.KeyBy)
+			}
+		}
+	}
+	return nil
+}
+
+func validMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
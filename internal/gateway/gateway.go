@@ -0,0 +1,409 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/service"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+	"github.com/VincentArjuna/RexiErp/internal/shared/middleware"
+	"github.com/VincentArjuna/RexiErp/internal/shared/tracing"
+)
+
+// Headers the gateway sets from the verified token. Values sent by clients
+// are always dropped so services can trust them.
+const (
+	HeaderUserID   = "X-User-ID"
+	HeaderTenantID = "X-Tenant-ID"
+	HeaderUserRole = "X-User-Role"
+)
+
+var identityHeaders = []string{HeaderUserID, HeaderTenantID, HeaderUserRole}
+
+// SessionStore holds the sessions revoked by the authentication service.
+// *cache.RedisCache implements it.
+type SessionStore interface {
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// Gateway routes API requests to the backend services
+type Gateway struct {
+	config    *Config
+	jwt       service.JWTService
+	sessions  SessionStore
+	rateLimit *middleware.RateLimitMiddleware
+	transport http.RoundTripper
+	logger    *logrus.Logger
+
+	routes []*route
+	pools  []*pool
+	engine *gin.Engine
+}
+
+// route is a configured route with its handler chain
+type route struct {
+	config RouteConfig
+	pool   *pool
+	engine *gin.Engine
+}
+
+// NewGateway creates a gateway for the route table. Tokens are verified with
+// the JWT settings of the authentication service and rejected once their
+// session is revoked in sessions. The limiter is optional; without it route
+// rate limits are not enforced.
+func NewGateway(cfg *Config, jwtService service.JWTService, sessions SessionStore, limiter middleware.RateLimiter, logger *logrus.Logger) (*Gateway, error) {
+	if sessions == nil {
+		return nil, errors.New("session store is required to reject revoked tokens")
+	}
+
+	g := &Gateway{
+		config:    cfg,
+		jwt:       jwtService,
+		sessions:  sessions,
+		transport: http.DefaultTransport,
+		logger:    logger,
+	}
+	if limiter != nil {
+		g.rateLimit = middleware.NewRateLimitMiddleware(limiter, nil, logger)
+	}
+
+	for _, rc := range cfg.Routes {
+		p, err := newPool(rc.Name, rc.Upstreams, cfg.HealthCheck, logger)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", rc.Name, err)
+		}
+		rt := &route{config: rc, pool: p}
+		rt.engine = g.newEngine()
+		rt.engine.NoRoute(g.routeHandlers(rt)...)
+
+		g.routes = append(g.routes, rt)
+		g.pools = append(g.pools, p)
+	}
+
+	// The longest prefix wins
+	sort.SliceStable(g.routes, func(i, j int) bool {
+		return len(g.routes[i].config.PathPrefix) > len(g.routes[j].config.PathPrefix)
+	})
+
+	g.engine = g.newEngine()
+	g.engine.GET("/health", g.Health)
+	g.engine.NoRoute(func(c *gin.Context) {
+		apperror.Respond(c, apperror.New(apperror.CodeNotFound, "no route for path"))
+	})
+
+	return g, nil
+}
+
+// RunHealthChecks checks the upstreams until ctx is done. It blocks, so it
+// runs as a background worker of the service.
+func (g *Gateway) RunHealthChecks(ctx context.Context) {
+	client := &http.Client{Transport: g.transport}
+	runHealthChecks(ctx, g.pools, g.config.HealthCheck, client)
+}
+
+// ServeHTTP dispatches a request to the route with the longest matching prefix
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rt := g.match(r.URL.Path); rt != nil {
+		rt.engine.ServeHTTP(w, r)
+		return
+	}
+	g.engine.ServeHTTP(w, r)
+}
+
+func (g *Gateway) match(path string) *route {
+	for _, rt := range g.routes {
+		prefix := rt.config.PathPrefix
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return rt
+		}
+	}
+	return nil
+}
+
+// newEngine creates an engine with the middleware shared by every route
+func (g *Gateway) newEngine() *gin.Engine {
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	engine.Use(StripIdentityHeaders)
+	engine.Use(logger.RequestContext())
+	engine.Use(apperror.Middleware(g.logger))
+	return engine
+}
+
+// routeHandlers builds the handler chain of a route
+func (g *Gateway) routeHandlers(rt *route) []gin.HandlerFunc {
+	handlers := []gin.HandlerFunc{}
+	if len(rt.config.Methods) > 0 {
+		handlers = append(handlers, allowMethods(rt.config.Methods))
+	}
+	if rt.config.Auth != AuthNone {
+		handlers = append(handlers, g.authenticate(rt.config.Auth))
+	}
+	if rl := rt.config.RateLimit; rl != nil && g.rateLimit != nil {
+		handlers = append(handlers, g.rateLimit.Limit(middleware.RateLimitRule{
+			Name:   "gateway-" + rt.config.Name,
+			Limit:  rl.Limit,
+			Window: rl.Window,
+			KeyBy:  rl.KeyBy,
+		}))
+	}
+	return append(handlers, g.proxy(rt))
+}
+
+// StripIdentityHeaders drops identity headers sent by clients. The service
+// bootstrap runs it before its own middleware, which logs the identity
+// headers it finds.
+func StripIdentityHeaders(c *gin.Context) {
+	for _, header := range identityHeaders {
+		c.Request.Header.Del(header)
+	}
+	c.Next()
+}
+
+func allowMethods(methods []string) gin.HandlerFunc {
+	allowed := strings.Join(methods, ", ")
+	return func(c *gin.Context) {
+		for _, method := range methods {
+			if c.Request.Method == method {
+				c.Next()
+				return
+			}
+		}
+		c.Header("Allow", allowed)
+		apperror.Abort(c, apperror.New(apperror.CodeMethodNotAllowed, "method not allowed").
+			WithDetail("allowed", methods))
+	}
+}
+
+// authenticate verifies the bearer token and forwards the identity it
+// carries to the upstream service
+func (g *Gateway) authenticate(mode AuthMode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			if mode == AuthOptional {
+				c.Next()
+				return
+			}
+			apperror.Abort(c, apperror.New(apperror.CodeMissingAuthHeader, "Authorization header required"))
+			return
+		}
+
+		tokenParts := strings.SplitN(authHeader, " ", 2)
+		if len(tokenParts) != 2 || strings.ToLower(tokenParts[0]) != "bearer" || tokenParts[1] == "" {
+			apperror.Abort(c, apperror.New(apperror.CodeInvalidAuthFormat, "Invalid authorization header format. Expected 'Bearer <token>'"))
+			return
+		}
+
+		claims, err := g.jwt.ValidateToken(tokenParts[1])
+		if err != nil {
+			g.logger.WithFields(logrus.Fields{
+				"error": err,
+				"path":  c.Request.URL.Path,
+			}).Debug("Token validation failed")
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				apperror.Abort(c, apperror.New(apperror.CodeTokenExpired, "token expired"))
+				return
+			}
+			apperror.Abort(c, apperror.New(apperror.CodeInvalidToken, "invalid token"))
+			return
+		}
+		if claims.TokenType != "access" {
+			apperror.Abort(c, apperror.New(apperror.CodeInvalidToken, "not an access token"))
+			return
+		}
+
+		// A logged out token keeps a valid signature until it expires, so
+		// the session is checked too. Without the store nothing can be
+		// verified and the request is refused.
+		revoked, err := g.sessions.Exists(c.Request.Context(), service.RevokedSessionKey(claims.SessionID))
+		if err != nil {
+			g.logger.WithError(err).Error("Failed to check revoked sessions")
+			apperror.Abort(c, apperror.New(apperror.CodeServiceUnavailable, "unable to verify session"))
+			return
+		}
+		if revoked {
+			apperror.Abort(c, apperror.New(apperror.CodeInvalidToken, "session has been revoked"))
+			return
+		}
+
+		// Rate limits key on these
+		c.Set("user_id", claims.UserID)
+		c.Set("tenant_id", claims.TenantID)
+		c.Set("user_role", claims.Role)
+
+		c.Request.Header.Set(HeaderUserID, claims.UserID.String())
+		c.Request.Header.Set(HeaderTenantID, claims.TenantID.String())
+		c.Request.Header.Set(HeaderUserRole, claims.Role)
+
+		ctx := logger.WithUserID(c.Request.Context(), claims.UserID.String())
+		ctx = logger.WithTenantID(ctx, claims.TenantID.String())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// proxy forwards the request to a healthy instance of the route. Requests
+// without a body and an idempotent method are retried on another instance
+// when the connection to the first one fails.
+func (g *Gateway) proxy(rt *route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientCtx := c.Request.Context()
+		ctx, cancel := context.WithTimeout(clientCtx, rt.config.Timeout)
+		defer cancel()
+		req := c.Request.WithContext(ctx)
+
+		retryable := req.Body == http.NoBody && isIdempotent(req.Method)
+		tried := make(map[*upstream]bool)
+		start := time.Now()
+
+		for {
+			target, err := rt.pool.pick(tried)
+			if err != nil {
+				code := apperror.CodeServiceUnavailable
+				if len(tried) > 0 {
+					code = apperror.CodeBadGateway
+				}
+				apperror.Abort(c, apperror.New(code, "no healthy upstream").WithDetail("route", rt.config.Name))
+				return
+			}
+			tried[target] = true
+
+			var proxyErr error
+			reverseProxy := &httputil.ReverseProxy{
+				Transport: g.transport,
+				Rewrite: func(pr *httputil.ProxyRequest) {
+					g.rewrite(pr, rt, target)
+				},
+				ModifyResponse: func(resp *http.Response) error {
+					// Headers set in front of the proxy, such as CORS and
+					// security headers, replace the upstream's copies
+					for key := range c.Writer.Header() {
+						resp.Header.Del(key)
+					}
+					switch resp.StatusCode {
+					case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+						rt.pool.markFailure(target, errors.New(resp.Status))
+					default:
+						rt.pool.markSuccess(target)
+					}
+					return nil
+				},
+				ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+					proxyErr = err
+				},
+			}
+			reverseProxy.ServeHTTP(c.Writer, req)
+			if proxyErr == nil {
+				return
+			}
+
+			fields := logrus.Fields{
+				"route":          rt.config.Name,
+				"upstream":       target.url.String(),
+				"path":           req.URL.Path,
+				"correlation_id": logger.GetCorrelationID(ctx),
+				"duration":       time.Since(start),
+				"error":          proxyErr,
+			}
+			switch {
+			case clientCtx.Err() != nil:
+				// The client went away, there is nobody to answer
+				c.Abort()
+				return
+			case errors.Is(ctx.Err(), context.DeadlineExceeded):
+				g.logger.WithFields(fields).Warn("Upstream request timed out")
+				if !c.Writer.Written() {
+					apperror.Abort(c, apperror.New(apperror.CodeTimeout, "upstream timed out").WithDetail("route", rt.config.Name))
+				}
+				c.Abort()
+				return
+			}
+
+			rt.pool.markFailure(target, proxyErr)
+			if c.Writer.Written() {
+				g.logger.WithFields(fields).Warn("Upstream response interrupted")
+				c.Abort()
+				return
+			}
+			if retryable {
+				g.logger.WithFields(fields).Info("Upstream request failed, retrying on another instance")
+				continue
+			}
+			g.logger.WithFields(fields).Warn("Upstream request failed")
+			apperror.Abort(c, apperror.New(apperror.CodeBadGateway, "upstream request failed").WithDetail("route", rt.config.Name))
+			return
+		}
+	}
+}
+
+// rewrite points the outgoing request at the upstream instance and adds the
+// forwarding, correlation and tracing headers
+func (g *Gateway) rewrite(pr *httputil.ProxyRequest, rt *route, target *upstream) {
+	if strip := rt.config.StripPrefix; strip != "" {
+		path := strings.TrimPrefix(pr.In.URL.Path, strip)
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		pr.Out.URL.Path = path
+		pr.Out.URL.RawPath = ""
+	}
+	pr.SetURL(target.url)
+	pr.SetXForwarded()
+
+	ctx := pr.In.Context()
+	if correlationID := logger.GetCorrelationID(ctx); correlationID != "" {
+		pr.Out.Header.Set(tracing.CorrelationIDHeader, correlationID)
+	}
+	if err := tracing.InjectHTTPHeaders(ctx, pr.Out.Header); err != nil {
+		g.logger.WithError(err).Debug("Failed to inject trace headers")
+	}
+}
+
+// Health reports the upstreams of every route and the gateway as unhealthy
+// when a route has no instance left
+func (g *Gateway) Health(c *gin.Context) {
+	status := http.StatusOK
+	routes := make(map[string]gin.H, len(g.routes))
+	for _, rt := range g.routes {
+		healthy := rt.pool.healthyCount()
+		if healthy == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		routes[rt.config.Name] = gin.H{
+			"healthy": healthy,
+			"total":   len(rt.pool.upstreams),
+		}
+	}
+
+	state := "healthy"
+	if status != http.StatusOK {
+		state = "degraded"
+	}
+	c.JSON(status, gin.H{
+		"status":    state,
+		"service":   "api-gateway",
+		"timestamp": time.Now().UTC(),
+		"routes":    routes,
+	})
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
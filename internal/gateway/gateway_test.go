@@ -0,0 +1,436 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VincentArjuna/RexiErp/internal/authentication/service"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/middleware"
+)
+
+// echo is an upstream that reports what it received
+func echo(t *testing.T, name string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"instance":       name,
+			"path":           r.URL.Path,
+			"user_id":        r.Header.Get(HeaderUserID),
+			"tenant_id":      r.Header.Get(HeaderTenantID),
+			"role":           r.Header.Get(HeaderUserRole),
+			"correlation_id": r.Header.Get("X-Correlation-ID"),
+			"forwarded_for":  r.Header.Get("X-Forwarded-For"),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// revokedSessions is an in-memory SessionStore
+type revokedSessions map[string]bool
+
+func (s revokedSessions) Exists(_ context.Context, key string) (bool, error) {
+	return s[key], nil
+}
+
+// failingSessions is a SessionStore whose backend is down
+type failingSessions struct{}
+
+func (failingSessions) Exists(context.Context, string) (bool, error) {
+	return false, errors.New("redis: connection refused")
+}
+
+func newTestGateway(t *testing.T, cfg *Config, limiter middleware.RateLimiter) (*Gateway, service.JWTService) {
+	return newTestGatewayWithSessions(t, cfg, revokedSessions{}, limiter)
+}
+
+func newTestGatewayWithSessions(t *testing.T, cfg *Config, sessions SessionStore, limiter middleware.RateLimiter) (*Gateway, service.JWTService) {
+	gin.SetMode(gin.TestMode)
+	cfg.setDefaults()
+	require.NoError(t, cfg.Validate())
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	jwtService := service.NewJWTService("test-secret", "RexiERP", time.Hour, 24*time.Hour)
+
+	g, err := NewGateway(cfg, jwtService, sessions, limiter, logger)
+	require.NoError(t, err)
+	return g, jwtService
+}
+
+func serve(g *Gateway, method, path string, headers map[string]string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req := httptest.NewRequest(method, path, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+
+	var body map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return w, body
+}
+
+func accessToken(t *testing.T, jwtService service.JWTService, user *service.User) string {
+	token, err := jwtService.GenerateAccessToken(user, uuid.NewString())
+	require.NoError(t, err)
+	return "Bearer " + token
+}
+
+func TestGateway_Routing(t *testing.T) {
+	auth := echo(t, "auth")
+	inventory := echo(t, "inventory")
+	g, _ := newTestGateway(t, &Config{Routes: []RouteConfig{
+		{Name: "auth", PathPrefix: "/api/v1/auth", Upstreams: []string{auth.URL}, Auth: AuthOptional},
+		{Name: "inventory", PathPrefix: "/api/v1/inventory", Upstreams: []string{inventory.URL}, Auth: AuthNone, StripPrefix: "/api/v1"},
+		{Name: "inventory-reports", PathPrefix: "/api/v1/inventory/reports", Upstreams: []string{auth.URL}, Auth: AuthNone, Methods: []string{"get"}},
+	}}, nil)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		status   int
+		instance string
+		upstream string
+	}{
+		{"prefix match", http.MethodPost, "/api/v1/auth/login", http.StatusOK, "auth", "/api/v1/auth/login"},
+		{"strip prefix", http.MethodGet, "/api/v1/inventory/products", http.StatusOK, "inventory", "/inventory/products"},
+		{"longest prefix wins", http.MethodGet, "/api/v1/inventory/reports/stock", http.StatusOK, "auth", "/api/v1/inventory/reports/stock"},
+		{"segment boundary", http.MethodGet, "/api/v1/authors", http.StatusNotFound, "", ""},
+		{"method filter", http.MethodDelete, "/api/v1/inventory/reports/stock", http.StatusMethodNotAllowed, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, body := serve(g, tt.method, tt.path, nil)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.instance != "" {
+				assert.Equal(t, tt.instance, body["instance"])
+				assert.Equal(t, tt.upstream, body["path"])
+			}
+		})
+	}
+}
+
+func TestGateway_Authentication(t *testing.T) {
+	upstream := echo(t, "inventory")
+	g, jwtService := newTestGateway(t, &Config{Routes: []RouteConfig{
+		{Name: "inventory", PathPrefix: "/api/v1/inventory", Upstreams: []string{upstream.URL}},
+	}}, nil)
+
+	user := &service.User{ID: uuid.New(), TenantID: uuid.New(), Email: "staff@majujaya.co.id", Role: "staff"}
+
+	t.Run("verified identity is forwarded", func(t *testing.T) {
+		w, body := serve(g, http.MethodGet, "/api/v1/inventory/products", map[string]string{
+			"Authorization":    accessToken(t, jwtService, user),
+			"X-Correlation-ID": "req-123",
+			// Spoofed identity is replaced by the token's
+			HeaderTenantID: uuid.NewString(),
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, user.ID.String(), body["user_id"])
+		assert.Equal(t, user.TenantID.String(), body["tenant_id"])
+		assert.Equal(t, "staff", body["role"])
+		assert.Equal(t, "req-123", body["correlation_id"])
+		assert.NotEmpty(t, body["forwarded_for"])
+		assert.Equal(t, "req-123", w.Header().Get("X-Correlation-ID"))
+	})
+
+	t.Run("correlation ID is generated", func(t *testing.T) {
+		w, body := serve(g, http.MethodGet, "/api/v1/inventory/products", map[string]string{
+			"Authorization": accessToken(t, jwtService, user),
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, body["correlation_id"])
+		assert.Equal(t, body["correlation_id"], w.Header().Get("X-Correlation-ID"))
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		w, body := serve(g, http.MethodGet, "/api/v1/inventory/products", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, string(apperror.CodeMissingAuthHeader), body["code"])
+	})
+
+	t.Run("token signed with another key", func(t *testing.T) {
+		other := service.NewJWTService("other-secret", "RexiERP", time.Hour, time.Hour)
+		w, body := serve(g, http.MethodGet, "/api/v1/inventory/products", map[string]string{
+			"Authorization": accessToken(t, other, user),
+		})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, string(apperror.CodeInvalidToken), body["code"])
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		expired := service.NewJWTService("test-secret", "RexiERP", -time.Minute, time.Hour)
+		w, body := serve(g, http.MethodGet, "/api/v1/inventory/products", map[string]string{
+			"Authorization": accessToken(t, expired, user),
+		})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, string(apperror.CodeTokenExpired), body["code"])
+	})
+
+	t.Run("refresh token is rejected", func(t *testing.T) {
+		refresh, err := jwtService.GenerateRefreshToken(user, uuid.NewString())
+		require.NoError(t, err)
+		w, _ := serve(g, http.MethodGet, "/api/v1/inventory/products", map[string]string{
+			"Authorization": "Bearer " + refresh,
+		})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestGateway_RevokedSession(t *testing.T) {
+	upstream := echo(t, "inventory")
+	routes := []RouteConfig{{Name: "inventory", PathPrefix: "/api/v1/inventory", Upstreams: []string{upstream.URL}}}
+	user := &service.User{ID: uuid.New(), TenantID: uuid.New(), Email: "staff@majujaya.co.id", Role: "staff"}
+
+	sessions := revokedSessions{}
+	g, jwtService := newTestGatewayWithSessions(t, &Config{Routes: routes}, sessions, nil)
+
+	sessionID := uuid.NewString()
+	token, err := jwtService.GenerateAccessToken(user, sessionID)
+	require.NoError(t, err)
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	w, _ := serve(g, http.MethodGet, "/api/v1/inventory/products", headers)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Logout marks the session revoked while the token is still unexpired
+	sessions[service.RevokedSessionKey(sessionID)] = true
+	w, body := serve(g, http.MethodGet, "/api/v1/inventory/products", headers)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, string(apperror.CodeInvalidToken), body["code"])
+	assert.Nil(t, body["instance"], "the upstream must not be reached")
+
+	t.Run("store unavailable", func(t *testing.T) {
+		g, jwtService := newTestGatewayWithSessions(t, &Config{Routes: routes}, failingSessions{}, nil)
+		w, body := serve(g, http.MethodGet, "/api/v1/inventory/products", map[string]string{
+			"Authorization": accessToken(t, jwtService, user),
+		})
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, string(apperror.CodeServiceUnavailable), body["code"])
+	})
+}
+
+func TestGateway_HeadersSetInFront(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "https://app.rexi.id")
+		w.Header().Set("X-Inventory-Version", "2")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(upstream.Close)
+	g, _ := newTestGateway(t, &Config{Routes: []RouteConfig{
+		{Name: "inventory", PathPrefix: "/api/v1/inventory", Upstreams: []string{upstream.URL}, Auth: AuthNone},
+	}}, nil)
+
+	// The service bootstrap sets CORS headers before the gateway runs
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", "https://app.rexi.id")
+		c.Next()
+	})
+	router.NoRoute(gin.WrapH(g))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/products", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"https://app.rexi.id"}, w.Header().Values("Access-Control-Allow-Origin"))
+	assert.Equal(t, "2", w.Header().Get("X-Inventory-Version"))
+}
+
+func TestGateway_RateLimit(t *testing.T) {
+	upstream := echo(t, "auth")
+	g, _ := newTestGateway(t, &Config{Routes: []RouteConfig{
+		{
+			Name: "auth", PathPrefix: "/api/v1/auth", Upstreams: []string{upstream.URL}, Auth: AuthNone,
+			RateLimit: &RateLimitConfig{Limit: 2, Window: time.Minute},
+		},
+	}}, middleware.NewMemoryRateLimiter())
+
+	for i := 0; i < 2; i++ {
+		w, _ := serve(g, http.MethodPost, "/api/v1/auth/login", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w, body := serve(g, http.MethodPost, "/api/v1/auth/login", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, string(apperror.CodeRateLimitExceeded), body["code"])
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestGateway_Timeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	g, _ := newTestGateway(t, &Config{Routes: []RouteConfig{
+		{Name: "reports", PathPrefix: "/api/v1/reports", Upstreams: []string{slow.URL}, Auth: AuthNone, Timeout: 50 * time.Millisecond},
+	}}, nil)
+
+	w, body := serve(g, http.MethodGet, "/api/v1/reports/balance-sheet", nil)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, string(apperror.CodeTimeout), body["code"])
+}
+
+func TestGateway_LoadBalancing(t *testing.T) {
+	first := echo(t, "first")
+	second := echo(t, "second")
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	g, _ := newTestGateway(t, &Config{
+		HealthCheck: HealthCheckConfig{UnhealthyThreshold: 1},
+		Routes: []RouteConfig{
+			{Name: "inventory", PathPrefix: "/api/v1/inventory", Upstreams: []string{first.URL, downURL, second.URL}, Auth: AuthNone},
+		},
+	}, nil)
+
+	t.Run("idempotent requests fail over", func(t *testing.T) {
+		seen := make(map[interface{}]int)
+		for i := 0; i < 6; i++ {
+			w, body := serve(g, http.MethodGet, "/api/v1/inventory/products", nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			seen[body["instance"]]++
+		}
+		assert.Equal(t, 6, seen["first"]+seen["second"])
+		assert.Positive(t, seen["first"])
+		assert.Positive(t, seen["second"])
+	})
+
+	t.Run("failed instance is out of rotation", func(t *testing.T) {
+		w, body := serve(g, http.MethodGet, "/health", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		routes := body["routes"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"healthy": 2.0, "total": 3.0}, routes["inventory"])
+	})
+}
+
+func TestGateway_HealthChecks(t *testing.T) {
+	var failing atomic.Bool
+	instance := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer instance.Close()
+
+	g, _ := newTestGateway(t, &Config{
+		HealthCheck: HealthCheckConfig{UnhealthyThreshold: 2, HealthyThreshold: 1, Timeout: time.Second},
+		Routes: []RouteConfig{
+			{Name: "hr", PathPrefix: "/api/v1/hr", Upstreams: []string{instance.URL}, Auth: AuthNone},
+		},
+	}, nil)
+	p := g.pools[0]
+	client := instance.Client()
+
+	failing.Store(true)
+	p.check(t.Context(), client)
+	assert.Equal(t, 1, p.healthyCount(), "one failed check is below the threshold")
+	p.check(t.Context(), client)
+	assert.Equal(t, 0, p.healthyCount())
+
+	w, body := serve(g, http.MethodGet, "/api/v1/hr/employees", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, string(apperror.CodeServiceUnavailable), body["code"])
+
+	w, _ = serve(g, http.MethodGet, "/health", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	failing.Store(false)
+	p.check(t.Context(), client)
+	assert.Equal(t, 1, p.healthyCount())
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("INVENTORY_SERVICE_URL", "http://inventory.internal:8002")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gateway.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+routes:
+  - name: inventory
+    path_prefix: /api/v1/inventory
+    upstreams: [${INVENTORY_SERVICE_URL}]
+    timeout: 5s
+    rate_limit:
+      limit: 50
+      key_by: tenant
+`), 0644))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Routes, 1)
+
+	route := cfg.Routes[0]
+	assert.Equal(t, []string{"http://inventory.internal:8002"}, route.Upstreams)
+	assert.Equal(t, AuthRequired, route.Auth)
+	assert.Equal(t, 5*time.Second, route.Timeout)
+	assert.Equal(t, time.Minute, route.RateLimit.Window)
+	assert.Equal(t, middleware.RateLimitByTenant, route.RateLimit.KeyBy)
+	assert.Equal(t, "/health", cfg.HealthCheck.Path)
+}
+
+func TestLoadConfig_Bundled(t *testing.T) {
+	_, err := LoadConfig("../../configs/gateway/routes.yaml")
+	require.NoError(t, err)
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := func() RouteConfig {
+		return RouteConfig{Name: "crm", PathPrefix: "/api/v1/crm", Upstreams: []string{"http://crm-service:8005"}}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		err    string
+	}{
+		{"no routes", func(cfg *Config) { cfg.Routes = nil }, "no routes"},
+		{"duplicate prefix", func(cfg *Config) {
+			other := valid()
+			other.Name = "crm-v2"
+			cfg.Routes = append(cfg.Routes, other)
+		}, "already used by route crm"},
+		{"relative prefix", func(cfg *Config) { cfg.Routes[0].PathPrefix = "api/v1/crm" }, "must start with /"},
+		{"bad upstream", func(cfg *Config) { cfg.Routes[0].Upstreams = []string{"crm-service:8005"} }, "invalid upstream URL"},
+		{"bad strip prefix", func(cfg *Config) { cfg.Routes[0].StripPrefix = "/api/v2" }, "strip_prefix"},
+		{"bad auth mode", func(cfg *Config) { cfg.Routes[0].Auth = "sometimes" }, "invalid auth mode"},
+		{"bad rate limit key", func(cfg *Config) {
+			cfg.Routes[0].RateLimit = &RateLimitConfig{Limit: 10, KeyBy: "session"}
+		}, "invalid rate_limit.key_by"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Routes: []RouteConfig{valid()}}
+			tt.modify(cfg)
+			cfg.setDefaults()
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// Package apperror provides the typed application errors shared by all
+// services. Every error carries a stable code from the catalogue, which
+// determines its HTTP status and its localised client message, so handlers
+// no longer need to map errors by string matching.
+package apperror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+// Error is a typed application error
+type Error struct {
+	// Code is the stable client error code
+	Code Code
+	// Message describes the error for logs. Clients receive the localised
+	// catalogue message instead, so it may contain internal details.
+	Message string
+	// Status overrides the catalogue HTTP status when non-zero
+	Status int
+	// Fields holds field level validation failures
+	Fields []FieldError
+	// Details holds additional structured data exposed to clients
+	Details map[string]interface{}
+	// Err is the underlying cause
+	Err error
+}
+
+// FieldError describes a validation failure of a single field
+type FieldError struct {
+	Field string
+	// Rule identifies the failed check and selects the localised message
+	Rule string
+	// Message is used when the rule has no localised message
+	Message string
+	Params  map[string]interface{}
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = string(e.Code)
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", message, e.Err)
+	}
+	return message
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an application error with the same code, so
+// the sentinels below can be matched with errors.Is
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// HTTPStatus returns the HTTP status of the error
+func (e *Error) HTTPStatus() int {
+	if e.Status != 0 {
+		return e.Status
+	}
+	return StatusOf(e.Code)
+}
+
+// WithDetail returns a copy of the error with an additional detail
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	clone := *e
+	clone.Details = make(map[string]interface{}, len(e.Details)+1)
+	for k, v := range e.Details {
+		clone.Details[k] = v
+	}
+	clone.Details[key] = value
+	return &clone
+}
+
+// Sentinels for errors.Is checks
+var (
+	ErrNotFound           = &Error{Code: CodeNotFound}
+	ErrAlreadyExists      = &Error{Code: CodeAlreadyExists}
+	ErrInvalidCredentials = &Error{Code: CodeInvalidCredentials}
+	ErrValidation         = &Error{Code: CodeValidationFailed}
+)
+
+// New creates an application error
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf creates an application error with a formatted message
+func Newf(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap creates an application error caused by err
+func Wrap(err error, code Code, message string) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// Validation creates a validation error from field errors
+func Validation(fields ...FieldError) *Error {
+	return &Error{
+		Code:    CodeValidationFailed,
+		Message: "validation failed",
+		Fields:  fields,
+	}
+}
+
+// Field creates a field error
+func Field(field, rule, message string) FieldError {
+	return FieldError{Field: field, Rule: rule, Message: message}
+}
+
+// FromValidationErrors converts model validation errors
+func FromValidationErrors(errs database.ValidationErrors) *Error {
+	fields := make([]FieldError, 0, len(errs))
+	for _, ve := range errs {
+		fields = append(fields, FieldError{
+			Field:   ve.Field,
+			Rule:    ve.Rule,
+			Message: ve.Message,
+			Params:  ve.Params,
+		})
+	}
+	appErr := Validation(fields...)
+	appErr.Err = errs
+	return appErr
+}
+
+// statusCoder is implemented by domain errors that carry their own code and
+// status, such as subscription.LimitExceededError
+type statusCoder interface {
+	Code() string
+	StatusCode() int
+}
+
+type detailer interface {
+	Details() map[string]interface{}
+}
+
+// From converts any error into an application error. Errors that are not
+// recognised become internal errors wrapping the original.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var validationErrs database.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return FromValidationErrors(validationErrs)
+	}
+	var validationErr database.ValidationError
+	if errors.As(err, &validationErr) {
+		return FromValidationErrors(database.ValidationErrors{validationErr})
+	}
+
+	if bindingErr := fromBinding(err); bindingErr != nil {
+		return bindingErr
+	}
+
+	var coder statusCoder
+	if errors.As(err, &coder) {
+		converted := &Error{
+			Code:    Code(coder.Code()),
+			Message: err.Error(),
+			Status:  coder.StatusCode(),
+			Err:     err,
+		}
+		if d, ok := coder.(detailer); ok {
+			converted.Details = d.Details()
+		}
+		return converted
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return Wrap(err, CodeNotFound, "record not found")
+	case errors.Is(err, database.ErrStaleObject):
+		return Wrap(err, CodeStaleObject, "stale object")
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(err, CodeTimeout, "request timed out")
+	case errors.Is(err, io.EOF):
+		return Wrap(err, CodeBadRequest, "request body is empty")
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return Wrap(err, CodeBadRequest, "malformed JSON body")
+	}
+
+	return Wrap(err, CodeInternal, "internal error")
+}
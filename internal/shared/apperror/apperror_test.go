@@ -0,0 +1,205 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+// quotaError mimics a domain error carrying its own code and status
+type quotaError struct{}
+
+func (quotaError) Error() string                   { return "quota exhausted" }
+func (quotaError) Code() string                    { return "PLAN_LIMIT_EXCEEDED" }
+func (quotaError) StatusCode() int                 { return http.StatusTooManyRequests }
+func (quotaError) Details() map[string]interface{} { return map[string]interface{}{"limit": 10} }
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected Language
+	}{
+		{"", English},
+		{"id", Indonesian},
+		{"id-ID,id;q=0.9,en;q=0.8", Indonesian},
+		{"en-US,en;q=0.9,id;q=0.8", English},
+		{"en;q=0.5,id;q=0.9", Indonesian},
+		{"fr-FR,de;q=0.9", English},
+		{"fr-FR,in;q=0.5", Indonesian},
+		{"id;q=0,en", English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseAcceptLanguage(tt.header))
+		})
+	}
+}
+
+func TestFrom(t *testing.T) {
+	t.Run("application error is kept", func(t *testing.T) {
+		original := New(CodeUserNotFound, "user 42 not found")
+		wrapped := fmt.Errorf("failed to load profile: %w", original)
+
+		appErr := From(wrapped)
+		assert.Equal(t, CodeUserNotFound, appErr.Code)
+		assert.Equal(t, http.StatusNotFound, appErr.HTTPStatus())
+	})
+
+	t.Run("model validation errors become field errors", func(t *testing.T) {
+		validator := database.NewValidator()
+		validator.Required("email", "")
+		validator.MinLength("name", "a", 2)
+
+		appErr := From(validator.ToError())
+		require.Len(t, appErr.Fields, 2)
+		assert.Equal(t, CodeValidationFailed, appErr.Code)
+		assert.Equal(t, "required", appErr.Fields[0].Rule)
+		assert.Equal(t, "min_length", appErr.Fields[1].Rule)
+		assert.True(t, errors.Is(appErr, ErrValidation))
+	})
+
+	t.Run("domain errors keep their code and status", func(t *testing.T) {
+		appErr := From(fmt.Errorf("register: %w", quotaError{}))
+		assert.Equal(t, CodePlanLimitExceeded, appErr.Code)
+		assert.Equal(t, http.StatusTooManyRequests, appErr.HTTPStatus())
+		assert.Equal(t, 10, appErr.Details["limit"])
+	})
+
+	t.Run("record not found", func(t *testing.T) {
+		appErr := From(fmt.Errorf("query: %w", gorm.ErrRecordNotFound))
+		assert.Equal(t, CodeNotFound, appErr.Code)
+		assert.True(t, errors.Is(appErr, ErrNotFound))
+	})
+
+	t.Run("stale objects conflict", func(t *testing.T) {
+		appErr := From(fmt.Errorf("update: %w", database.ErrStaleObject))
+		assert.Equal(t, CodeStaleObject, appErr.Code)
+		assert.Equal(t, http.StatusConflict, appErr.HTTPStatus())
+	})
+
+	t.Run("unknown errors are internal", func(t *testing.T) {
+		appErr := From(errors.New("connection reset"))
+		assert.Equal(t, CodeInternal, appErr.Code)
+		assert.Equal(t, http.StatusInternalServerError, appErr.HTTPStatus())
+	})
+}
+
+func TestFieldMessage(t *testing.T) {
+	field := FieldError{Field: "name", Rule: "min_length", Params: map[string]interface{}{"min": 2}}
+	assert.Equal(t, "must be at least 2 characters", FieldMessage(field, English))
+	assert.Equal(t, "minimal 2 karakter", FieldMessage(field, Indonesian))
+
+	npwp := Field("tax_number", "npwp_required", "NPWP is required for PKP tenants")
+	assert.Equal(t, "wajib diisi untuk perusahaan PKP", FieldMessage(npwp, Indonesian))
+
+	// Rules without a catalogue entry keep the message given by the caller
+	custom := Field("sku", "sku_format", "SKU must start with the warehouse code")
+	assert.Equal(t, "SKU must start with the warehouse code", FieldMessage(custom, Indonesian))
+}
+
+func TestCatalogueIsComplete(t *testing.T) {
+	for code, entry := range catalogue {
+		assert.NotZero(t, entry.status, code)
+		assert.NotEmpty(t, entry.messages[English], code)
+		assert.NotEmpty(t, entry.messages[Indonesian], code)
+	}
+	for rule, messages := range ruleMessages {
+		assert.NotEmpty(t, messages[English], rule)
+		assert.NotEmpty(t, messages[Indonesian], rule)
+	}
+}
+
+func newTestRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	router := gin.New()
+	router.Use(Middleware(logger))
+	router.POST("/test", handler)
+	return router
+}
+
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder) Response {
+	var response Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func TestMiddleware_RendersLocalisedBindingErrors(t *testing.T) {
+	type request struct {
+		Email    string `json:"email" binding:"required,email"`
+		FullName string `json:"full_name" binding:"required,min=2"`
+	}
+	router := newTestRouter(func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"email":"not-an-email","full_name":"A"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "id-ID,id;q=0.9")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	response := decodeResponse(t, w)
+	assert.Equal(t, CodeValidationFailed, response.Code)
+	assert.Equal(t, "Beberapa isian tidak valid", response.Error)
+	require.Len(t, response.Fields, 2)
+	assert.Equal(t, FieldResponse{Field: "email", Rule: "email", Message: "harus berupa alamat email yang valid"}, response.Fields[0])
+	assert.Equal(t, FieldResponse{Field: "full_name", Rule: "min_length", Message: "minimal 2 karakter"}, response.Fields[1])
+}
+
+func TestMiddleware_HidesInternalDetails(t *testing.T) {
+	router := newTestRouter(func(c *gin.Context) {
+		_ = c.Error(errors.New("pq: password authentication failed for user rexi"))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "pq:")
+	assert.Equal(t, "An internal error occurred", decodeResponse(t, w).Error)
+}
+
+func TestAbort(t *testing.T) {
+	reached := false
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/test", func(c *gin.Context) {
+		Abort(c, New(CodeTenantInactive, "tenant suspended"))
+	}, func(c *gin.Context) {
+		reached = true
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.False(t, reached)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	response := decodeResponse(t, w)
+	assert.Equal(t, CodeTenantInactive, response.Code)
+	assert.Equal(t, "Your company account is suspended or closed", response.Error)
+}
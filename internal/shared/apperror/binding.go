@@ -0,0 +1,85 @@
+package apperror
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// fromBinding converts a gin binding error (ShouldBindJSON and friends)
+// into a validation error with one field error per failed tag
+func fromBinding(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, bindingFieldError(fe))
+	}
+	appErr := Validation(fields...)
+	appErr.Err = err
+	return appErr
+}
+
+// bindingFieldError maps a validator tag to the catalogue rules
+func bindingFieldError(fe validator.FieldError) FieldError {
+	field := FieldError{
+		Field:   snakeCase(fe.Field()),
+		Message: fe.Error(),
+	}
+
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required":
+		field.Rule = "required"
+	case "email":
+		field.Rule = "email"
+	case "uuid", "uuid4":
+		field.Rule = "uuid"
+	case "e164":
+		field.Rule = "phone"
+	case "oneof":
+		field.Rule = "one_of"
+		field.Params = map[string]interface{}{"allowed": strings.ReplaceAll(fe.Param(), " ", ", ")}
+	case "min", "gte":
+		if isString {
+			field.Rule = "min_length"
+			field.Params = map[string]interface{}{"min": fe.Param()}
+		} else {
+			field.Rule = "invalid"
+		}
+	case "max", "lte":
+		if isString {
+			field.Rule = "max_length"
+			field.Params = map[string]interface{}{"max": fe.Param()}
+		} else {
+			field.Rule = "invalid"
+		}
+	default:
+		field.Rule = "invalid"
+	}
+	return field
+}
+
+// snakeCase converts a Go field name to the snake_case JSON name used by
+// the request DTOs, e.g. FullName to full_name
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Start a new word unless inside an acronym such as "ID"
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
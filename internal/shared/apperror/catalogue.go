@@ -0,0 +1,431 @@
+package apperror
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Code is a stable client error code. Codes are part of the public API and
+// must not be renamed once released.
+type Code string
+
+const (
+	// Request errors
+	CodeBadRequest           Code = "BAD_REQUEST"
+	CodeValidationFailed     Code = "VALIDATION_FAILED"
+	CodeInvalidAddress       Code = "INVALID_ADDRESS"
+	CodeUnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE"
+
+	// Authentication errors
+	CodeUnauthorized       Code = "UNAUTHORIZED"
+	CodeInvalidCredentials Code = "INVALID_CREDENTIALS"
+	CodeMissingAuthHeader  Code = "MISSING_AUTH_HEADER"
+	CodeInvalidAuthFormat  Code = "INVALID_AUTH_FORMAT"
+	CodeInvalidToken       Code = "INVALID_TOKEN"
+	CodeTokenExpired       Code = "TOKEN_EXPIRED"
+	CodeSessionNotFound    Code = "SESSION_NOT_FOUND"
+	CodeInvalidAPIKey      Code = "INVALID_API_KEY"
+
+	// Authorization errors
+	CodeForbidden               Code = "FORBIDDEN"
+	CodeInsufficientPermissions Code = "INSUFFICIENT_PERMISSIONS"
+	CodeCrossTenantAccess       Code = "CROSS_TENANT_ACCESS_DENIED"
+	CodeAccountInactive         Code = "ACCOUNT_INACTIVE"
+	CodeTenantInactive          Code = "TENANT_INACTIVE"
+	CodePlanLimitExceeded       Code = "PLAN_LIMIT_EXCEEDED"
+
+	// Resource errors
+	CodeNotFound                Code = "NOT_FOUND"
+	CodeMethodNotAllowed        Code = "METHOD_NOT_ALLOWED"
+	CodeUserNotFound            Code = "USER_NOT_FOUND"
+	CodeTenantNotFound          Code = "TENANT_NOT_FOUND"
+	CodeRegionNotFound          Code = "REGION_NOT_FOUND"
+	CodeSettingNotFound         Code = "SETTING_NOT_FOUND"
+	CodeConflict                Code = "CONFLICT"
+	CodeAlreadyExists           Code = "ALREADY_EXISTS"
+	CodeUserAlreadyExists       Code = "USER_ALREADY_EXISTS"
+	CodeTenantAlreadyExists     Code = "TENANT_ALREADY_EXISTS"
+	CodeInvalidStatusTransition Code = "INVALID_STATUS_TRANSITION"
+	CodeInvalidResetToken       Code = "INVALID_RESET_TOKEN"
+	CodeStaleObject             Code = "STALE_OBJECT"
+	CodePreconditionFailed      Code = "PRECONDITION_FAILED"
+	CodePreconditionRequired    Code = "PRECONDITION_REQUIRED"
+
+	// Request handling errors
+	CodeRateLimitExceeded      Code = "RATE_LIMIT_EXCEEDED"
+	CodeIdempotencyKeyRequired Code = "IDEMPOTENCY_KEY_REQUIRED"
+	CodeInvalidIdempotencyKey  Code = "INVALID_IDEMPOTENCY_KEY"
+	CodeIdempotencyMismatch    Code = "IDEMPOTENCY_KEY_MISMATCH"
+	CodeIdempotencyPending     Code = "IDEMPOTENCY_REQUEST_IN_PROGRESS"
+	CodeIdempotencyUnavailable Code = "IDEMPOTENCY_UNAVAILABLE"
+
+	// Server errors
+	CodeInternal           Code = "INTERNAL_ERROR"
+	CodeContractViolation  Code = "RESPONSE_CONTRACT_VIOLATION"
+	CodeBadGateway         Code = "BAD_GATEWAY"
+	CodeServiceUnavailable Code = "SERVICE_UNAVAILABLE"
+	CodeTimeout            Code = "TIMEOUT"
+)
+
+type catalogueEntry struct {
+	status   int
+	messages map[Language]string
+}
+
+// catalogue maps every code to its HTTP status and client messages
+var catalogue = map[Code]catalogueEntry{
+	CodeBadRequest: {http.StatusBadRequest, map[Language]string{
+		English:    "The request is invalid",
+		Indonesian: "Permintaan tidak valid",
+	}},
+	CodeValidationFailed: {http.StatusBadRequest, map[Language]string{
+		English:    "Some fields are invalid",
+		Indonesian: "Beberapa isian tidak valid",
+	}},
+	CodeInvalidAddress: {http.StatusBadRequest, map[Language]string{
+		English:    "The address regions do not match each other",
+		Indonesian: "Wilayah pada alamat tidak sesuai satu sama lain",
+	}},
+	CodeUnsupportedMediaType: {http.StatusUnsupportedMediaType, map[Language]string{
+		English:    "The request content type is not supported",
+		Indonesian: "Tipe konten permintaan tidak didukung",
+	}},
+	CodeUnauthorized: {http.StatusUnauthorized, map[Language]string{
+		English:    "Authentication is required",
+		Indonesian: "Autentikasi diperlukan",
+	}},
+	CodeInvalidCredentials: {http.StatusUnauthorized, map[Language]string{
+		English:    "Email or password is incorrect",
+		Indonesian: "Email atau kata sandi salah",
+	}},
+	CodeMissingAuthHeader: {http.StatusUnauthorized, map[Language]string{
+		English:    "Authorization header is required",
+		Indonesian: "Header Authorization wajib diisi",
+	}},
+	CodeInvalidAuthFormat: {http.StatusUnauthorized, map[Language]string{
+		English:    "Invalid authorization header format, expected 'Bearer <token>'",
+		Indonesian: "Format header Authorization tidak valid, gunakan 'Bearer <token>'",
+	}},
+	CodeInvalidToken: {http.StatusUnauthorized, map[Language]string{
+		English:    "The token is invalid",
+		Indonesian: "Token tidak valid",
+	}},
+	CodeTokenExpired: {http.StatusUnauthorized, map[Language]string{
+		English:    "The token has expired",
+		Indonesian: "Token sudah kedaluwarsa",
+	}},
+	CodeSessionNotFound: {http.StatusUnauthorized, map[Language]string{
+		English:    "No active session found",
+		Indonesian: "Sesi aktif tidak ditemukan",
+	}},
+	CodeInvalidAPIKey: {http.StatusUnauthorized, map[Language]string{
+		English:    "The API key is missing or invalid",
+		Indonesian: "API key tidak ada atau tidak valid",
+	}},
+	CodeForbidden: {http.StatusForbidden, map[Language]string{
+		English:    "You are not allowed to perform this action",
+		Indonesian: "Anda tidak diizinkan melakukan tindakan ini",
+	}},
+	CodeInsufficientPermissions: {http.StatusForbidden, map[Language]string{
+		English:    "You do not have permission to perform this action",
+		Indonesian: "Anda tidak memiliki izin untuk melakukan tindakan ini",
+	}},
+	CodeCrossTenantAccess: {http.StatusForbidden, map[Language]string{
+		English:    "Access to another company's data is not allowed",
+		Indonesian: "Akses ke data perusahaan lain tidak diizinkan",
+	}},
+	CodeAccountInactive: {http.StatusForbidden, map[Language]string{
+		English:    "Your account is not active",
+		Indonesian: "Akun Anda tidak aktif",
+	}},
+	CodeTenantInactive: {http.StatusForbidden, map[Language]string{
+		English:    "Your company account is suspended or closed",
+		Indonesian: "Akun perusahaan Anda ditangguhkan atau ditutup",
+	}},
+	CodePlanLimitExceeded: {http.StatusForbidden, map[Language]string{
+		English:    "Your subscription plan limit has been reached",
+		Indonesian: "Batas paket langganan Anda telah tercapai",
+	}},
+	CodeNotFound: {http.StatusNotFound, map[Language]string{
+		English:    "The requested resource was not found",
+		Indonesian: "Data yang diminta tidak ditemukan",
+	}},
+	CodeMethodNotAllowed: {http.StatusMethodNotAllowed, map[Language]string{
+		English:    "The method is not allowed for this resource",
+		Indonesian: "Metode tidak diizinkan untuk sumber daya ini",
+	}},
+	CodeUserNotFound: {http.StatusNotFound, map[Language]string{
+		English:    "User not found",
+		Indonesian: "Pengguna tidak ditemukan",
+	}},
+	CodeTenantNotFound: {http.StatusNotFound, map[Language]string{
+		English:    "Company not found",
+		Indonesian: "Perusahaan tidak ditemukan",
+	}},
+	CodeRegionNotFound: {http.StatusNotFound, map[Language]string{
+		English:    "Region not found",
+		Indonesian: "Wilayah tidak ditemukan",
+	}},
+	CodeSettingNotFound: {http.StatusNotFound, map[Language]string{
+		English:    "Setting not found",
+		Indonesian: "Pengaturan tidak ditemukan",
+	}},
+	CodeConflict: {http.StatusConflict, map[Language]string{
+		English:    "The request conflicts with the current state",
+		Indonesian: "Permintaan bertentangan dengan kondisi saat ini",
+	}},
+	CodeAlreadyExists: {http.StatusConflict, map[Language]string{
+		English:    "The resource already exists",
+		Indonesian: "Data sudah ada",
+	}},
+	CodeUserAlreadyExists: {http.StatusConflict, map[Language]string{
+		English:    "A user with this email already exists",
+		Indonesian: "Pengguna dengan email ini sudah terdaftar",
+	}},
+	CodeTenantAlreadyExists: {http.StatusConflict, map[Language]string{
+		English:    "A company with this email or subdomain already exists",
+		Indonesian: "Perusahaan dengan email atau subdomain ini sudah terdaftar",
+	}},
+	CodeInvalidStatusTransition: {http.StatusConflict, map[Language]string{
+		English:    "The status cannot be changed from its current value",
+		Indonesian: "Status tidak dapat diubah dari nilai saat ini",
+	}},
+	CodeInvalidResetToken: {http.StatusNotFound, map[Language]string{
+		English:    "The password reset token is invalid or expired",
+		Indonesian: "Token atur ulang kata sandi tidak valid atau sudah kedaluwarsa",
+	}},
+	CodeStaleObject: {http.StatusConflict, map[Language]string{
+		English:    "The data was changed by someone else, please reload and try again",
+		Indonesian: "Data telah diubah oleh pengguna lain, silakan muat ulang dan coba lagi",
+	}},
+	CodePreconditionFailed: {http.StatusPreconditionFailed, map[Language]string{
+		English:    "The If-Match header does not match the current version",
+		Indonesian: "Header If-Match tidak sesuai dengan versi saat ini",
+	}},
+	CodePreconditionRequired: {http.StatusPreconditionRequired, map[Language]string{
+		English:    "The If-Match header is required",
+		Indonesian: "Header If-Match wajib diisi",
+	}},
+	CodeRateLimitExceeded: {http.StatusTooManyRequests, map[Language]string{
+		English:    "Too many requests, please try again later",
+		Indonesian: "Terlalu banyak permintaan, silakan coba lagi nanti",
+	}},
+	CodeIdempotencyKeyRequired: {http.StatusBadRequest, map[Language]string{
+		English:    "The Idempotency-Key header is required",
+		Indonesian: "Header Idempotency-Key wajib diisi",
+	}},
+	CodeInvalidIdempotencyKey: {http.StatusBadRequest, map[Language]string{
+		English:    "The Idempotency-Key header is invalid",
+		Indonesian: "Header Idempotency-Key tidak valid",
+	}},
+	CodeIdempotencyMismatch: {http.StatusConflict, map[Language]string{
+		English:    "The Idempotency-Key was already used for a different request",
+		Indonesian: "Idempotency-Key sudah digunakan untuk permintaan lain",
+	}},
+	CodeIdempotencyPending: {http.StatusTooEarly, map[Language]string{
+		English:    "A request with this Idempotency-Key is still being processed",
+		Indonesian: "Permintaan dengan Idempotency-Key ini masih diproses",
+	}},
+	CodeIdempotencyUnavailable: {http.StatusServiceUnavailable, map[Language]string{
+		English:    "The idempotency check is unavailable, please retry",
+		Indonesian: "Pemeriksaan idempotensi sedang tidak tersedia, silakan coba lagi",
+	}},
+	CodeInternal: {http.StatusInternalServerError, map[Language]string{
+		English:    "An internal error occurred",
+		Indonesian: "Terjadi kesalahan internal",
+	}},
+	CodeContractViolation: {http.StatusInternalServerError, map[Language]string{
+		English:    "The response does not match the API contract",
+		Indonesian: "Respons tidak sesuai dengan kontrak API",
+	}},
+	CodeBadGateway: {http.StatusBadGateway, map[Language]string{
+		English:    "The upstream service could not be reached",
+		Indonesian: "Layanan tujuan tidak dapat dihubungi",
+	}},
+	CodeServiceUnavailable: {http.StatusServiceUnavailable, map[Language]string{
+		English:    "The service is temporarily unavailable",
+		Indonesian: "Layanan sedang tidak tersedia",
+	}},
+	CodeTimeout: {http.StatusGatewayTimeout, map[Language]string{
+		English:    "The request timed out",
+		Indonesian: "Permintaan melebihi batas waktu",
+	}},
+}
+
+// ruleMessages holds the localised field messages by validation rule.
+// Placeholders in braces are replaced by the rule parameters.
+var ruleMessages = map[string]map[Language]string{
+	"required": {
+		English:    "is required",
+		Indonesian: "wajib diisi",
+	},
+	"email": {
+		English:    "must be a valid email address",
+		Indonesian: "harus berupa alamat email yang valid",
+	},
+	"uuid": {
+		English:    "must be a valid UUID",
+		Indonesian: "harus berupa UUID yang valid",
+	},
+	"min_length": {
+		English:    "must be at least {min} characters",
+		Indonesian: "minimal {min} karakter",
+	},
+	"max_length": {
+		English:    "must be at most {max} characters",
+		Indonesian: "maksimal {max} karakter",
+	},
+	"one_of": {
+		English:    "must be one of: {allowed}",
+		Indonesian: "harus salah satu dari: {allowed}",
+	},
+	"range": {
+		English:    "must be between {min} and {max}",
+		Indonesian: "harus di antara {min} dan {max}",
+	},
+	"minimum": {
+		English:    "must be at least {min}",
+		Indonesian: "minimal {min}",
+	},
+	"maximum": {
+		English:    "must be at most {max}",
+		Indonesian: "maksimal {max}",
+	},
+	"min_items": {
+		English:    "must contain at least {min} items",
+		Indonesian: "minimal berisi {min} item",
+	},
+	"max_items": {
+		English:    "must contain at most {max} items",
+		Indonesian: "maksimal berisi {max} item",
+	},
+	"positive": {
+		English:    "must be positive",
+		Indonesian: "harus bernilai positif",
+	},
+	"non_negative": {
+		English:    "must be non-negative",
+		Indonesian: "tidak boleh negatif",
+	},
+	"future_date": {
+		English:    "must be in the future",
+		Indonesian: "harus tanggal yang akan datang",
+	},
+	"past_date": {
+		English:    "must be in the past",
+		Indonesian: "harus tanggal yang sudah lewat",
+	},
+	"phone": {
+		English:    "must be a valid phone number in international format",
+		Indonesian: "harus berupa nomor telepon dalam format internasional",
+	},
+	"password_uppercase": {
+		English:    "must contain at least one uppercase letter",
+		Indonesian: "harus mengandung minimal satu huruf kapital",
+	},
+	"password_number": {
+		English:    "must contain at least one number",
+		Indonesian: "harus mengandung minimal satu angka",
+	},
+	"password_special": {
+		English:    "must contain at least one special character",
+		Indonesian: "harus mengandung minimal satu karakter khusus",
+	},
+	"npwp": {
+		English:    "must be a valid 15 or 16 digit NPWP",
+		Indonesian: "harus berupa NPWP 15 atau 16 digit yang valid",
+	},
+	"npwp_required": {
+		English:    "is required for PKP companies",
+		Indonesian: "wajib diisi untuk perusahaan PKP",
+	},
+	"type": {
+		English:    "must be of type {type}",
+		Indonesian: "harus bertipe {type}",
+	},
+	"pattern": {
+		English:    "has an invalid format",
+		Indonesian: "formatnya tidak valid",
+	},
+	"date": {
+		English:    "must be a date in YYYY-MM-DD format",
+		Indonesian: "harus berupa tanggal dengan format YYYY-MM-DD",
+	},
+	"date_time": {
+		English:    "must be an RFC 3339 date-time",
+		Indonesian: "harus berupa tanggal dan waktu RFC 3339",
+	},
+	"unknown_field": {
+		English:    "is not allowed",
+		Indonesian: "tidak diizinkan",
+	},
+	"invalid": {
+		English:    "is invalid",
+		Indonesian: "tidak valid",
+	},
+}
+
+// StatusOf returns the HTTP status of an error code
+func StatusOf(code Code) int {
+	if entry, ok := catalogue[code]; ok {
+		return entry.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Message returns the client message of an error code in the given
+// language, falling back to English. Codes outside the catalogue return
+// an empty string.
+func Message(code Code, lang Language) string {
+	entry, ok := catalogue[code]
+	if !ok {
+		return ""
+	}
+	if message, ok := entry.messages[lang]; ok {
+		return message
+	}
+	return entry.messages[English]
+}
+
+// FieldMessage returns the localised message of a field error. Rules
+// without a localised message keep the message they were created with.
+func FieldMessage(field FieldError, lang Language) string {
+	messages, ok := ruleMessages[field.Rule]
+	if !ok {
+		if field.Message != "" {
+			return field.Message
+		}
+		messages = ruleMessages["invalid"]
+	}
+
+	message, ok := messages[lang]
+	if !ok {
+		message = messages[English]
+	}
+	for key, value := range field.Params {
+		message = strings.ReplaceAll(message, "{"+key+"}", fmt.Sprint(value))
+	}
+	return message
+}
+
+// statusMessage derives a message for codes outside the catalogue, e.g.
+// codes reported by domain errors, from their HTTP status
+func statusMessage(status int, lang Language) string {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return Message(CodeInternal, lang)
+	case status == http.StatusUnauthorized:
+		return Message(CodeUnauthorized, lang)
+	case status == http.StatusForbidden:
+		return Message(CodeForbidden, lang)
+	case status == http.StatusNotFound:
+		return Message(CodeNotFound, lang)
+	case status == http.StatusConflict:
+		return Message(CodeConflict, lang)
+	case status == http.StatusTooManyRequests:
+		return Message(CodeRateLimitExceeded, lang)
+	default:
+		return Message(CodeBadRequest, lang)
+	}
+}
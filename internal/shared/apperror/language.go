@@ -0,0 +1,65 @@
+package apperror
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Language is a supported client message language
+type Language string
+
+const (
+	English    Language = "en"
+	Indonesian Language = "id"
+)
+
+// DefaultLanguage is used when the client does not state a supported language
+const DefaultLanguage = English
+
+// ParseAcceptLanguage selects the supported language the client prefers
+// from an Accept-Language header, e.g. "id-ID,id;q=0.9,en;q=0.8"
+func ParseAcceptLanguage(header string) Language {
+	type candidate struct {
+		lang    Language
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		// Only the primary subtag matters: id-ID and in (legacy) are Indonesian
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		switch primary {
+		case "id", "in":
+			candidates = append(candidates, candidate{Indonesian, quality})
+		case "en":
+			candidates = append(candidates, candidate{English, quality})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].lang
+}
@@ -0,0 +1,104 @@
+package apperror
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+)
+
+// languageKey is the gin context key of the negotiated language
+const languageKey = "language"
+
+// Response is the error envelope returned by every service
+type Response struct {
+	Error         string                 `json:"error" example:"Beberapa isian tidak valid"`
+	Code          Code                   `json:"code" example:"VALIDATION_FAILED"`
+	Details       map[string]interface{} `json:"details,omitempty"`
+	Fields        []FieldResponse        `json:"fields,omitempty"`
+	CorrelationID string                 `json:"correlation_id,omitempty"`
+}
+
+// FieldResponse is a field level validation failure in the error envelope
+type FieldResponse struct {
+	Field   string `json:"field" example:"email"`
+	Rule    string `json:"rule,omitempty" example:"required"`
+	Message string `json:"message" example:"wajib diisi"`
+}
+
+// Middleware negotiates the response language and renders errors that
+// handlers attached with c.Error but did not write themselves
+func Middleware(log *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(languageKey, ParseAcceptLanguage(c.GetHeader("Accept-Language")))
+
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		render(c, log, c.Errors.Last().Err)
+	}
+}
+
+// Respond writes err as the error envelope
+func Respond(c *gin.Context, err error) {
+	render(c, nil, err)
+}
+
+// Abort writes err as the error envelope and stops the handler chain
+func Abort(c *gin.Context, err error) {
+	render(c, nil, err)
+	c.Abort()
+}
+
+// LanguageOf returns the language negotiated for the request
+func LanguageOf(c *gin.Context) Language {
+	if lang, ok := c.Get(languageKey); ok {
+		if l, ok := lang.(Language); ok {
+			return l
+		}
+	}
+	return ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+}
+
+// NewResponse builds the error envelope of err in the given language
+func NewResponse(err *Error, lang Language) Response {
+	message := Message(err.Code, lang)
+	if message == "" {
+		message = statusMessage(err.HTTPStatus(), lang)
+	}
+
+	response := Response{
+		Error:   message,
+		Code:    err.Code,
+		Details: err.Details,
+	}
+	for _, field := range err.Fields {
+		response.Fields = append(response.Fields, FieldResponse{
+			Field:   field.Field,
+			Rule:    field.Rule,
+			Message: FieldMessage(field, lang),
+		})
+	}
+	return response
+}
+
+func render(c *gin.Context, log *logrus.Logger, err error) {
+	appErr := From(err)
+	status := appErr.HTTPStatus()
+
+	if status >= http.StatusInternalServerError && log != nil {
+		log.WithFields(logrus.Fields{
+			"path":  c.FullPath(),
+			"code":  appErr.Code,
+			"error": appErr.Error(),
+		}).Error("Request failed")
+	}
+
+	response := NewResponse(appErr, LanguageOf(c))
+	response.CorrelationID = logger.GetCorrelationID(c.Request.Context())
+	c.JSON(status, response)
+}
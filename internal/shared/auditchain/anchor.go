@@ -0,0 +1,90 @@
+// Package auditchain verifies the hash chained audit trail and exports
+// signed anchors of the chain heads, so that the history can be checked
+// against a record kept outside the database.
+package auditchain
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidSignature is returned for an anchor whose signature does not
+// match its content
+var ErrInvalidSignature = errors.New("invalid anchor signature")
+
+// Anchor is a signed digest of the head of a chain at a point in time. Any
+// later chain must still contain a row with this sequence and hash.
+type Anchor struct {
+	Table     string     `json:"table"`
+	TenantID  *uuid.UUID `json:"tenant_id,omitempty"`
+	Seq       int64      `json:"seq"`
+	Hash      string     `json:"hash"`
+	CreatedAt time.Time  `json:"created_at"`
+	// PublicKey is the base64 Ed25519 key that verifies Signature
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// Digest returns the signed content of the anchor
+func (a *Anchor) Digest() []byte {
+	tenant := "platform"
+	if a.TenantID != nil {
+		tenant = a.TenantID.String()
+	}
+	return []byte(strings.Join([]string{
+		"rexierp-audit-anchor/v1",
+		a.Table,
+		tenant,
+		strconv.FormatInt(a.Seq, 10),
+		a.Hash,
+		a.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}, "\n"))
+}
+
+// Verify checks the anchor's signature against publicKey, the key the
+// anchors are published with
+func (a *Anchor) Verify(publicKey ed25519.PublicKey) error {
+	signature, err := base64.StdEncoding.DecodeString(a.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if !ed25519.Verify(publicKey, a.Digest(), signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Signer signs anchors with an Ed25519 key
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewSigner creates a signer from a base64 Ed25519 seed
+func NewSigner(seed string) (*Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid anchor signing key: %w", err)
+	}
+	if len(raw) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid anchor signing key: expected a %d byte seed, got %d bytes", ed25519.SeedSize, len(raw))
+	}
+	return &Signer{key: ed25519.NewKeyFromSeed(raw)}, nil
+}
+
+// PublicKey returns the key that verifies the signer's anchors
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// Sign sets the public key and signature of the anchor
+func (s *Signer) Sign(anchor *Anchor) {
+	anchor.PublicKey = base64.StdEncoding.EncodeToString(s.PublicKey())
+	anchor.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, anchor.Digest()))
+}
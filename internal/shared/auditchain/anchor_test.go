@@ -0,0 +1,40 @@
+package auditchain
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSigner(t *testing.T) *Signer {
+	signer, err := NewSigner(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", ed25519.SeedSize))))
+	require.NoError(t, err)
+	return signer
+}
+
+func TestSigner_SignsAnchors(t *testing.T) {
+	signer := testSigner(t)
+	tenantID := uuid.New()
+	anchor := &Anchor{Table: "audit_logs", TenantID: &tenantID, Seq: 42, Hash: strings.Repeat("a", 64), CreatedAt: time.Now()}
+
+	signer.Sign(anchor)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(signer.PublicKey()), anchor.PublicKey)
+	require.NoError(t, anchor.Verify(signer.PublicKey()))
+
+	anchor.Seq = 43
+	assert.ErrorIs(t, anchor.Verify(signer.PublicKey()), ErrInvalidSignature)
+}
+
+func TestNewSigner_RejectsInvalidKeys(t *testing.T) {
+	_, err := NewSigner("not base64!")
+	assert.Error(t, err)
+
+	_, err = NewSigner(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.Error(t, err)
+}
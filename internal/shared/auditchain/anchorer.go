@@ -0,0 +1,172 @@
+package auditchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+// Verifier walks the hash chains stored in a database
+type Verifier struct {
+	chain *database.HashChain
+	db    *gorm.DB
+}
+
+// NewVerifier creates a verifier for the tables of chain
+func NewVerifier(chain *database.HashChain, db *gorm.DB) *Verifier {
+	return &Verifier{chain: chain, db: db}
+}
+
+// Tables returns the chained tables
+func (v *Verifier) Tables() []string {
+	return v.chain.Tables()
+}
+
+// Verify walks the chain of a tenant in table; a nil tenant selects the
+// platform chain
+func (v *Verifier) Verify(ctx context.Context, table string, tenantID *uuid.UUID) (*database.ChainReport, error) {
+	return v.chain.Verify(ctx, v.db, table, tenantID)
+}
+
+// VerifyAll walks every chain of every table
+func (v *Verifier) VerifyAll(ctx context.Context) ([]*database.ChainReport, error) {
+	var reports []*database.ChainReport
+	for _, table := range v.chain.Tables() {
+		tenantIDs, err := v.chain.Chains(ctx, v.db, table)
+		if err != nil {
+			return nil, err
+		}
+		for _, tenantID := range tenantIDs {
+			report, err := v.Verify(ctx, table, tenantID)
+			if err != nil {
+				return nil, err
+			}
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+// Anchorer periodically verifies every chain and exports a signed anchor
+// of each intact chain whose head moved since the last export
+type Anchorer struct {
+	verifier *Verifier
+	signer   *Signer
+	store    Store
+	prefix   string
+	logger   *logrus.Logger
+	now      func() time.Time
+
+	mu       sync.Mutex
+	anchored map[string]int64
+}
+
+// NewAnchorer creates an anchorer writing to store under prefix
+func NewAnchorer(verifier *Verifier, signer *Signer, store Store, prefix string, logger *logrus.Logger) *Anchorer {
+	return &Anchorer{
+		verifier: verifier,
+		signer:   signer,
+		store:    store,
+		prefix:   prefix,
+		logger:   logger,
+		now:      time.Now,
+		anchored: make(map[string]int64),
+	}
+}
+
+// Run verifies and anchors the chains on every tick until ctx is done
+func (a *Anchorer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.AnchorAll(ctx); err != nil {
+			a.logger.WithError(err).Error("Audit chain anchoring failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// AnchorAll verifies every chain and exports the anchors. Broken chains
+// are reported and not anchored.
+func (a *Anchorer) AnchorAll(ctx context.Context) ([]*database.ChainReport, error) {
+	reports, err := a.verifier.VerifyAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, report := range reports {
+		if !report.Valid {
+			a.logger.WithFields(logrus.Fields{
+				"table":     report.Table,
+				"tenant_id": report.TenantID,
+				"seq":       report.Break.Seq,
+				"reason":    report.Break.Reason,
+			}).Error("Audit chain is broken")
+			continue
+		}
+		if err := a.anchor(ctx, report); err != nil {
+			return reports, err
+		}
+	}
+	return reports, nil
+}
+
+func (a *Anchorer) anchor(ctx context.Context, report *database.ChainReport) error {
+	tenant := "platform"
+	if report.TenantID != nil {
+		tenant = report.TenantID.String()
+	}
+	chainKey := report.Table + "/" + tenant
+
+	a.mu.Lock()
+	last, ok := a.anchored[chainKey]
+	a.mu.Unlock()
+	if report.Rows == 0 || (ok && last == report.HeadSeq) {
+		return nil
+	}
+
+	anchor := &Anchor{
+		Table:     report.Table,
+		TenantID:  report.TenantID,
+		Seq:       report.HeadSeq,
+		Hash:      report.HeadHash,
+		CreatedAt: a.now().UTC(),
+	}
+	a.signer.Sign(anchor)
+
+	data, err := json.MarshalIndent(anchor, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode anchor: %w", err)
+	}
+	key := path.Join(a.prefix, chainKey, fmt.Sprintf("%020d.json", anchor.Seq))
+	if err := a.store.Put(ctx, key, data); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.anchored[chainKey] = report.HeadSeq
+	a.mu.Unlock()
+
+	a.logger.WithFields(logrus.Fields{
+		"table":     report.Table,
+		"tenant_id": report.TenantID,
+		"seq":       anchor.Seq,
+		"key":       key,
+	}).Info("Audit chain anchored")
+	return nil
+}
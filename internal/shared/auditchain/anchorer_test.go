@@ -0,0 +1,128 @@
+package auditchain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+type memoryStore struct {
+	objects map[string][]byte
+}
+
+func (s *memoryStore) Put(_ context.Context, key string, data []byte) error {
+	s.objects[key] = data
+	return nil
+}
+
+func newTestVerifier(t *testing.T) (*Verifier, *gorm.DB, *logrus.Logger) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, db.AutoMigrate(&database.AuditLog{}))
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	chain := database.NewHashChain(log, &database.AuditLog{})
+	require.NoError(t, chain.RegisterCallbacks(db))
+	return NewVerifier(chain, db), db, log
+}
+
+func createEntry(t *testing.T, db *gorm.DB, tenantID uuid.UUID) *database.AuditLog {
+	entry := &database.AuditLog{TenantID: &tenantID, TableName: "widgets", Action: database.AuditActionInsert}
+	require.NoError(t, db.Create(entry).Error)
+	return entry
+}
+
+func TestAnchorer_AnchorsIntactChains(t *testing.T) {
+	verifier, db, log := newTestVerifier(t)
+	ctx := context.Background()
+	store := &memoryStore{objects: make(map[string][]byte)}
+	signer := testSigner(t)
+	anchorer := NewAnchorer(verifier, signer, store, "audit-anchors", log)
+	anchorer.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	intact := uuid.New()
+	createEntry(t, db, intact)
+	head := createEntry(t, db, intact)
+	broken := uuid.New()
+	tampered := createEntry(t, db, broken)
+	require.NoError(t, db.Exec("UPDATE audit_logs SET action = 'DELETE' WHERE id = ?", tampered.ID).Error)
+
+	reports, err := anchorer.AnchorAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, reports, 2)
+
+	key := "audit-anchors/audit_logs/" + intact.String() + "/00000000000000000002.json"
+	require.Len(t, store.objects, 1)
+	require.Contains(t, store.objects, key)
+
+	var anchor Anchor
+	require.NoError(t, json.Unmarshal(store.objects[key], &anchor))
+	assert.Equal(t, int64(2), anchor.Seq)
+	assert.Equal(t, head.RowHash, anchor.Hash)
+	require.NoError(t, anchor.Verify(signer.PublicKey()))
+
+	// An unchanged head is not anchored again
+	delete(store.objects, key)
+	_, err = anchorer.AnchorAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, store.objects)
+
+	createEntry(t, db, intact)
+	_, err = anchorer.AnchorAll(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, store.objects, "audit-anchors/audit_logs/"+intact.String()+"/00000000000000000003.json")
+}
+
+func TestHandler_VerifyChain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier, db, log := newTestVerifier(t)
+	tenantID := uuid.New()
+	createEntry(t, db, tenantID)
+
+	request := func(role, query string) *httptest.ResponseRecorder {
+		router := gin.New()
+		group := router.Group("/api/v1")
+		NewHandler(verifier, log).RegisterRoutes(group, func(c *gin.Context) {
+			c.Set("tenant_id", tenantID)
+			c.Set("user_role", role)
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit/chain/verify"+query, nil))
+		return w
+	}
+
+	w := request("tenant_admin", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Success bool                    `json:"success"`
+		Data    []*database.ChainReport `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data, 1)
+	assert.True(t, response.Data[0].Valid)
+	assert.Equal(t, int64(1), response.Data[0].HeadSeq)
+
+	assert.Equal(t, http.StatusBadRequest, request("tenant_admin", "?table=users").Code)
+	assert.Equal(t, http.StatusForbidden, request("tenant_admin", "?tenant_id="+uuid.NewString()).Code)
+	assert.Equal(t, http.StatusOK, request("super_admin", "?tenant_id="+uuid.NewString()).Code)
+}
@@ -0,0 +1,62 @@
+package auditchain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+)
+
+// Store keeps exported anchors
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// MinIOStore writes anchors to a MinIO bucket
+type MinIOStore struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewMinIOStore connects to MinIO and creates the bucket if it does not
+// exist yet
+func NewMinIOStore(ctx context.Context, cfg config.MinIOConfig) (*MinIOStore, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinIO client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check MinIO bucket: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, fmt.Errorf("failed to create MinIO bucket: %w", err)
+		}
+	}
+
+	return &MinIOStore{client: client, bucket: cfg.Bucket}, nil
+}
+
+// Put writes an anchor object
+func (s *MinIOStore) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: "application/json",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s to MinIO: %w", key, err)
+	}
+	return nil
+}
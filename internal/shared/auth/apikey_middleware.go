@@ -1,11 +1,12 @@
 package auth
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
 )
 
 // APIKeyMiddleware provides API key authentication middleware
@@ -50,13 +51,7 @@ func (m *APIKeyMiddleware) RequireAPIKey() gin.HandlerFunc {
 				"method": c.Request.Method,
 			}).Warn("API key authentication failed: missing key")
 
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "UNAUTHORIZED",
-					"message": "API key required",
-				},
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeInvalidAPIKey, "API key required"))
 			return
 		}
 
@@ -68,13 +63,7 @@ func (m *APIKeyMiddleware) RequireAPIKey() gin.HandlerFunc {
 				"key_hash": hashAPIKey(apiKey),
 			}).Warn("API key authentication failed: invalid key")
 
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "UNAUTHORIZED",
-					"message": "Invalid API key",
-				},
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeInvalidAPIKey, "Invalid API key"))
 			return
 		}
 
@@ -116,13 +105,7 @@ func (m *APIKeyMiddleware) OptionalAPIKey() gin.HandlerFunc {
 					"key_hash": hashAPIKey(apiKey),
 				}).Warn("API key authentication failed: invalid key")
 
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": gin.H{
-						"code":    "UNAUTHORIZED",
-						"message": "Invalid API key",
-					},
-				})
-				c.Abort()
+				apperror.Abort(c, apperror.New(apperror.CodeInvalidAPIKey, "Invalid API key"))
 				return
 			}
 		}
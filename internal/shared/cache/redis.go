@@ -191,6 +191,19 @@ func (r *RedisCache) IncrementWithExpiration(ctx context.Context, key string, ex
 	return result, nil
 }
 
+// Publish sends a message to every subscriber of a channel
+func (r *RedisCache) Publish(ctx context.Context, channel string, message string) error {
+	if err := r.Client.Publish(ctx, channel, message).Err(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Subscribe subscribes to a channel. The caller must close the subscription.
+func (r *RedisCache) Subscribe(ctx context.Context, channel string) *redis.PubSub {
+	return r.Client.Subscribe(ctx, channel)
+}
+
 // Clear removes all keys from the current database
 func (r *RedisCache) Clear(ctx context.Context) error {
 	if err := r.Client.FlushDB(ctx).Err(); err != nil {
@@ -12,15 +12,28 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	App        AppConfig        `yaml:"app"`
-	Databases  DatabaseConfigs  `yaml:"databases"`
-	Redis      RedisConfig      `yaml:"redis"`
-	MinIO      MinIOConfig      `yaml:"minio"`
-	RabbitMQ   RabbitMQConfig   `yaml:"rabbitmq"`
-	JWT        JWTConfig        `yaml:"jwt"`
-	APIKey     APIKeyConfig     `yaml:"api_key"`
-	Log        LogConfig        `yaml:"log"`
-	Monitoring MonitoringConfig `yaml:"monitoring"`
+	App         AppConfig         `yaml:"app"`
+	Databases   DatabaseConfigs   `yaml:"databases"`
+	Redis       RedisConfig       `yaml:"redis"`
+	MinIO       MinIOConfig       `yaml:"minio"`
+	RabbitMQ    RabbitMQConfig    `yaml:"rabbitmq"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	APIKey      APIKeyConfig      `yaml:"api_key"`
+	Log         LogConfig         `yaml:"log"`
+	Monitoring  MonitoringConfig  `yaml:"monitoring"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	OpenAPI     OpenAPIConfig     `yaml:"openapi"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Shutdown    ShutdownConfig    `yaml:"shutdown"`
+	AuditChain  AuditChainConfig  `yaml:"audit_chain"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Security    SecurityConfig    `yaml:"security"`
+	Backup      BackupConfig      `yaml:"backup"`
+	Migrations  MigrationsConfig  `yaml:"migrations"`
+	Tenancy     TenancyConfig     `yaml:"tenancy"`
+	Outbox      OutboxConfig      `yaml:"outbox"`
+	Pagination  PaginationConfig  `yaml:"pagination"`
 }
 
 // AppConfig represents application-specific configuration
@@ -59,6 +72,19 @@ type DatabaseConfig struct {
 	IsMaster        bool         `yaml:"is_master"`
 	Weight          int          `yaml:"weight"`
 	Enabled         bool         `yaml:"enabled"`
+	// AppRole is the role tenant transactions switch to; row-level security
+	// applies to it. Empty runs them as the login user.
+	AppRole string `yaml:"app_role"`
+	// SearchPath is the schema search path of the connections; empty keeps
+	// the server default
+	SearchPath string `yaml:"search_path"`
+	// MaxReplicaLag takes replicas of a master out of read rotation while
+	// they are further behind
+	MaxReplicaLag time.Duration `yaml:"max_replica_lag"`
+	// ReadYourWritesWindow is how long a user reads from the master after
+	// writing, on every instance sharing the window through Redis; it
+	// should exceed MaxReplicaLag
+	ReadYourWritesWindow time.Duration `yaml:"read_your_writes_window"`
 }
 
 // DatabaseConfigs represents multiple database configurations
@@ -223,6 +249,30 @@ func (dc *DatabaseConfigs) Validate() error {
 	return nil
 }
 
+// replicaConfigs returns the settings of the replicas at hosts ("host" or
+// "host:port"), which share the credentials and database of the master
+func replicaConfigs(master DatabaseConfig, hosts []string) []DatabaseConfig {
+	var replicas []DatabaseConfig
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		replica := master
+		replica.Host = host
+		if name, port, ok := strings.Cut(host, ":"); ok {
+			replica.Host = name
+			if value, err := strconv.Atoi(port); err == nil {
+				replica.Port = value
+			}
+		}
+		replica.IsMaster = false
+		replica.Enabled = true
+		replicas = append(replicas, replica)
+	}
+	return replicas
+}
+
 // Validate validates the Redis configuration
 func (c *RedisConfig) Validate() error {
 	// Validate basic configuration
@@ -318,6 +368,225 @@ type MonitoringConfig struct {
 	MetricsURL string `yaml:"metrics_url"`
 }
 
+// RateLimitConfig represents API rate limiting configuration
+type RateLimitConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Window  time.Duration `yaml:"window"`
+	// PerIP limits unauthenticated requests per client IP
+	PerIP int64 `yaml:"per_ip"`
+	// PerUser limits authenticated requests per user
+	PerUser int64 `yaml:"per_user"`
+	// PerTenant limits requests per tenant when the plan has no quota
+	PerTenant int64 `yaml:"per_tenant"`
+	// PlanQuotas limits requests per tenant by subscription plan code
+	PlanQuotas map[string]int64 `yaml:"plan_quotas"`
+}
+
+// IdempotencyConfig represents Idempotency-Key handling configuration
+type IdempotencyConfig struct {
+	// TTL is how long completed responses are kept for replay
+	TTL time.Duration `yaml:"ttl"`
+	// LockTTL bounds how long an in-flight request holds its key, so a
+	// crashed request does not block retries forever
+	LockTTL time.Duration `yaml:"lock_ttl"`
+}
+
+// OpenAPIConfig represents request validation against the published spec
+type OpenAPIConfig struct {
+	// SpecPath is the OpenAPI document to validate against; empty disables validation
+	SpecPath string `yaml:"spec_path"`
+	// ValidateResponses also checks responses, for tests and CI only
+	ValidateResponses bool `yaml:"validate_responses"`
+}
+
+// TracingConfig represents Jaeger tracing configuration
+type TracingConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	AgentHost string `yaml:"agent_host"`
+	AgentPort string `yaml:"agent_port"`
+	// SampleRate is the fraction of traces recorded, from 0 to 1
+	SampleRate float64 `yaml:"sample_rate"`
+}
+
+// ShutdownConfig represents graceful shutdown configuration
+type ShutdownConfig struct {
+	// DrainDelay is how long readiness fails before the server stops
+	// accepting connections, so load balancers stop routing to it first
+	DrainDelay time.Duration `yaml:"drain_delay"`
+	// Timeout bounds draining in-flight requests and closing resources
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// AuditChainConfig represents the verification and anchoring of the hash
+// chained audit trail
+type AuditChainConfig struct {
+	// AnchorInterval is how often the chains are verified and their heads
+	// exported; zero disables the job
+	AnchorInterval time.Duration `yaml:"anchor_interval"`
+	// AnchorPrefix is the object key prefix of the anchors in the MinIO bucket
+	AnchorPrefix string `yaml:"anchor_prefix"`
+	// SigningKey is the base64 Ed25519 seed the anchors are signed with
+	SigningKey string `yaml:"signing_key"`
+}
+
+// CORSConfig represents the cross-origin policy of the browser facing APIs
+type CORSConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// AllowedOrigins are exact origins such as https://rexi-erp.com or
+	// wildcard subdomains such as https://*.rexi-erp.com; "*" allows any
+	// origin without credentials
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	// TenantDomains also allows the custom domains of active tenants
+	TenantDomains    bool          `yaml:"tenant_domains"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// SecurityConfig represents the security headers set on every response
+type SecurityConfig struct {
+	// HSTSMaxAge is sent on HTTPS responses; zero disables HSTS
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `yaml:"hsts_include_subdomains"`
+	HSTSPreload           bool          `yaml:"hsts_preload"`
+	// ContentSecurityPolicy is empty to omit the header
+	ContentSecurityPolicy string `yaml:"content_security_policy"`
+	ReferrerPolicy        string `yaml:"referrer_policy"`
+	FrameOptions          string `yaml:"frame_options"`
+}
+
+// BackupConfig represents the scheduled backups of the master database to
+// the MinIO bucket
+type BackupConfig struct {
+	// Prefix is the object key prefix of backups and archived WAL
+	Prefix string `yaml:"prefix"`
+	// Interval is how often a logical backup is taken; zero disables them
+	Interval time.Duration `yaml:"interval"`
+	// BaseInterval is how often a physical base backup is taken for point
+	// in time recovery; zero disables them
+	BaseInterval time.Duration `yaml:"base_interval"`
+	// Retention is how long backups are kept; MinKeep of each kind are kept
+	// regardless of age
+	Retention time.Duration `yaml:"retention"`
+	MinKeep   int           `yaml:"min_keep"`
+	// VerifyInterval is how often the latest backup is test restored; zero
+	// disables verification
+	VerifyInterval time.Duration `yaml:"verify_interval"`
+	// ScratchDatabase is the database verification restores into. It is
+	// dropped and recreated on every run.
+	ScratchDatabase string `yaml:"scratch_database"`
+	// WALFetchCommand is the restore_command written into recovered data
+	// directories; %f and %p are expanded by PostgreSQL
+	WALFetchCommand string `yaml:"wal_fetch_command"`
+}
+
+// MigrationsConfig represents the SQL migrations applied by cmd/migrate
+// and by the authentication service as it starts
+type MigrationsConfig struct {
+	// Dir holds the migration files
+	Dir string `yaml:"dir"`
+	// LockTimeout is how long to wait for another process that is migrating
+	LockTimeout time.Duration `yaml:"lock_timeout"`
+}
+
+// PaginationConfig represents keyset pagination configuration
+type PaginationConfig struct {
+	// CursorSecret signs cursor tokens and must be shared by all replicas.
+	// It must differ from the JWT secret, so a leaked cursor key cannot be
+	// used to sign tokens.
+	CursorSecret string `yaml:"cursor_secret"`
+}
+
+// OutboxConfig represents the relay of the transactional outbox
+type OutboxConfig struct {
+	// PollInterval is how often the relay looks for due events
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// BatchSize is how many events one poll claims
+	BatchSize int `yaml:"batch_size"`
+	// MaxAttempts parks an event after that many failed deliveries
+	MaxAttempts int `yaml:"max_attempts"`
+	// RetryDelay is the first retry delay; it doubles up to MaxRetryDelay
+	RetryDelay    time.Duration `yaml:"retry_delay"`
+	MaxRetryDelay time.Duration `yaml:"max_retry_delay"`
+	// StreamPrefix precedes the topic in the names of the Redis streams
+	StreamPrefix string `yaml:"stream_prefix"`
+	// StreamMaxLen caps each stream, approximately
+	StreamMaxLen int64 `yaml:"stream_max_len"`
+}
+
+// Tenancy modes
+const (
+	// TenancyShared keeps tenants in the master database under row-level
+	// security
+	TenancyShared = "shared"
+	// TenancySchema gives each tenant a schema in the master database
+	TenancySchema = "schema"
+	// TenancyDatabase gives each tenant a database on the master server
+	TenancyDatabase = "database"
+)
+
+// TenancyConfig represents how tenant data is isolated
+type TenancyConfig struct {
+	// Mode is the isolation of tenants without an override
+	Mode string `yaml:"mode"`
+	// Overrides maps tenant IDs to their own mode, such as enterprise
+	// tenants on a dedicated database
+	Overrides map[string]string `yaml:"overrides"`
+	// SchemaPrefix and DatabasePrefix precede the tenant ID in the names of
+	// dedicated schemas and databases
+	SchemaPrefix   string `yaml:"schema_prefix"`
+	DatabasePrefix string `yaml:"database_prefix"`
+	// MaxPools caps the open connection pools of dedicated tenants; the
+	// least recently used idle pool is closed beyond it
+	MaxPools int `yaml:"max_pools"`
+	// PoolIdleTimeout closes pools unused for that long
+	PoolIdleTimeout time.Duration `yaml:"pool_idle_timeout"`
+	// PoolMaxOpenConns caps the connections of each tenant pool
+	PoolMaxOpenConns int `yaml:"pool_max_open_conns"`
+	// MigrationsDir holds the migrations applied to each dedicated tenant
+	MigrationsDir string `yaml:"migrations_dir"`
+}
+
+// Validate validates the tenancy configuration
+func (c *TenancyConfig) Validate() error {
+	for tenant, mode := range c.Overrides {
+		if !validTenancyMode(mode) {
+			return fmt.Errorf("invalid tenancy mode %q for tenant %s", mode, tenant)
+		}
+	}
+	if !validTenancyMode(c.Mode) {
+		return fmt.Errorf("invalid tenancy mode: %q", c.Mode)
+	}
+	if c.MaxPools < 1 {
+		return fmt.Errorf("tenancy max pools must be positive: %d", c.MaxPools)
+	}
+	return nil
+}
+
+// Dedicated reports whether any tenant has a schema or database of its own
+func (c *TenancyConfig) Dedicated() bool {
+	if c.Mode != TenancyShared {
+		return true
+	}
+	for _, mode := range c.Overrides {
+		if mode != TenancyShared {
+			return true
+		}
+	}
+	return false
+}
+
+func validTenancyMode(mode string) bool {
+	switch mode {
+	case TenancyShared, TenancySchema, TenancyDatabase:
+		return true
+	}
+	return false
+}
+
 // LoadConfig loads configuration from environment variables with defaults
 func LoadConfig() (*Config, error) {
 	config := &Config{
@@ -344,6 +613,10 @@ func LoadConfig() (*Config, error) {
 				ConnMaxLifetime: getEnvDuration("DB_CONNECTION_MAX_LIFETIME", 1*time.Hour),
 				ConnMaxIdleTime: getEnvDuration("DB_CONNECTION_MAX_IDLE_TIME", 30*time.Minute),
 				IsMaster:        true,
+				AppRole:         getEnv("DB_APP_ROLE", "rexi_app"),
+
+				MaxReplicaLag:        getEnvDuration("DB_REPLICA_MAX_LAG", 5*time.Second),
+				ReadYourWritesWindow: getEnvDuration("DB_READ_YOUR_WRITES_WINDOW", 10*time.Second),
 			},
 		},
 		Redis: RedisConfig{
@@ -353,6 +626,16 @@ func LoadConfig() (*Config, error) {
 			DB:       getEnvInt("REDIS_DB", 0),
 			PoolSize: getEnvInt("REDIS_POOL_SIZE", 10),
 		},
+		MinIO: MinIOConfig{
+			Endpoint:        getEnv("MINIO_ENDPOINT", ""),
+			AccessKeyID:     getEnv("MINIO_ACCESS_KEY", ""),
+			SecretAccessKey: getEnv("MINIO_SECRET_KEY", ""),
+			UseSSL:          getEnvBool("MINIO_USE_SSL", false),
+			Region:          getEnv("MINIO_REGION", "us-east-1"),
+			Bucket:          getEnv("MINIO_BUCKET", "rexi-erp"),
+			Timeout:         getEnvDuration("MINIO_TIMEOUT", 30*time.Second),
+			RetryAttempts:   getEnvInt("MINIO_RETRY_ATTEMPTS", 3),
+		},
 		RabbitMQ: RabbitMQConfig{
 			Host:               getEnv("RABBITMQ_HOST", "localhost"),
 			Port:               getEnvInt("RABBITMQ_PORT", 5672),
@@ -391,8 +674,102 @@ func LoadConfig() (*Config, error) {
 			Path:       getEnv("METRICS_PATH", "/metrics"),
 			MetricsURL: getEnv("METRICS_URL", ""),
 		},
+		RateLimit: RateLimitConfig{
+			Enabled:   getEnvBool("RATE_LIMIT_ENABLED", true),
+			Window:    getEnvDuration("RATE_LIMIT_WINDOW", time.Minute),
+			PerIP:     int64(getEnvInt("RATE_LIMIT_PER_IP", 60)),
+			PerUser:   int64(getEnvInt("RATE_LIMIT_PER_USER", 120)),
+			PerTenant: int64(getEnvInt("RATE_LIMIT_PER_TENANT", 300)),
+			PlanQuotas: getEnvInt64Map("RATE_LIMIT_PLAN_QUOTAS", map[string]int64{
+				"basic":        300,
+				"professional": 1200,
+				"enterprise":   6000,
+			}),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:     getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+			LockTTL: getEnvDuration("IDEMPOTENCY_LOCK_TTL", time.Minute),
+		},
+		OpenAPI: OpenAPIConfig{
+			SpecPath:          getEnv("OPENAPI_SPEC_PATH", ""),
+			ValidateResponses: getEnvBool("OPENAPI_VALIDATE_RESPONSES", false),
+		},
+		Tracing: TracingConfig{
+			Enabled:    getEnvBool("TRACING_ENABLED", true),
+			AgentHost:  getEnv("JAEGER_AGENT_HOST", "jaeger-agent"),
+			AgentPort:  getEnv("JAEGER_AGENT_PORT", "6831"),
+			SampleRate: getEnvFloat("TRACING_SAMPLE_RATE", 1.0),
+		},
+		Shutdown: ShutdownConfig{
+			DrainDelay: getEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+			Timeout:    getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		},
+		AuditChain: AuditChainConfig{
+			AnchorInterval: getEnvDuration("AUDIT_ANCHOR_INTERVAL", time.Hour),
+			AnchorPrefix:   getEnv("AUDIT_ANCHOR_PREFIX", "audit-anchors"),
+			SigningKey:     getEnv("AUDIT_ANCHOR_SIGNING_KEY", ""),
+		},
+		CORS: CORSConfig{
+			Enabled:        getEnvBool("ENABLE_CORS", true),
+			AllowedOrigins: getEnvSlice("CORS_ALLOWED_ORIGINS", []string{"https://rexi-erp.com", "https://*.rexi-erp.com"}),
+			TenantDomains:  getEnvBool("CORS_TENANT_DOMAINS", true),
+			AllowedMethods: getEnvSlice("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+			AllowedHeaders: getEnvSlice("CORS_ALLOWED_HEADERS", []string{
+				"Origin", "Content-Type", "Accept", "Accept-Language", "Authorization",
+				"X-CSRF-Token", "X-Correlation-ID", "Idempotency-Key", "If-Match",
+			}),
+			ExposedHeaders:   getEnvSlice("CORS_EXPOSED_HEADERS", []string{"Content-Length", "X-Correlation-ID", "Retry-After", "ETag"}),
+			AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", true),
+			MaxAge:           getEnvDuration("CORS_MAX_AGE", 10*time.Minute),
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            getEnvDuration("SECURITY_HSTS_MAX_AGE", 365*24*time.Hour),
+			HSTSIncludeSubdomains: getEnvBool("SECURITY_HSTS_INCLUDE_SUBDOMAINS", true),
+			HSTSPreload:           getEnvBool("SECURITY_HSTS_PRELOAD", false),
+			ContentSecurityPolicy: getEnv("SECURITY_CSP", "default-src 'none'; frame-ancestors 'none'"),
+			ReferrerPolicy:        getEnv("SECURITY_REFERRER_POLICY", "strict-origin-when-cross-origin"),
+			FrameOptions:          getEnv("SECURITY_FRAME_OPTIONS", "DENY"),
+		},
+		Backup: BackupConfig{
+			Prefix:          getEnv("BACKUP_PREFIX", "backups"),
+			Interval:        getEnvDuration("BACKUP_INTERVAL", 24*time.Hour),
+			BaseInterval:    getEnvDuration("BACKUP_BASE_INTERVAL", 7*24*time.Hour),
+			Retention:       getEnvDuration("BACKUP_RETENTION", 30*24*time.Hour),
+			MinKeep:         getEnvInt("BACKUP_MIN_KEEP", 3),
+			VerifyInterval:  getEnvDuration("BACKUP_VERIFY_INTERVAL", 24*time.Hour),
+			ScratchDatabase: getEnv("BACKUP_SCRATCH_DATABASE", "rexi_erp_restore_check"),
+			WALFetchCommand: getEnv("BACKUP_WAL_FETCH_COMMAND", "/usr/local/bin/backup wal-fetch %f %p"),
+		},
+		Migrations: MigrationsConfig{
+			Dir:         getEnv("MIGRATIONS_DIR", "migrations/master"),
+			LockTimeout: getEnvDuration("MIGRATIONS_LOCK_TIMEOUT", 10*time.Minute),
+		},
+		Outbox: OutboxConfig{
+			PollInterval:  getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			BatchSize:     getEnvInt("OUTBOX_BATCH_SIZE", 100),
+			MaxAttempts:   getEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+			RetryDelay:    getEnvDuration("OUTBOX_RETRY_DELAY", time.Second),
+			MaxRetryDelay: getEnvDuration("OUTBOX_MAX_RETRY_DELAY", 10*time.Minute),
+			StreamPrefix:  getEnv("OUTBOX_STREAM_PREFIX", "events:"),
+			StreamMaxLen:  int64(getEnvInt("OUTBOX_STREAM_MAX_LEN", 100000)),
+		},
+		Pagination: PaginationConfig{
+			CursorSecret: getEnv("PAGINATION_CURSOR_SECRET", "your-super-secret-cursor-key-for-development-only"),
+		},
+		Tenancy: TenancyConfig{
+			Mode:             getEnv("TENANCY_MODE", TenancyShared),
+			Overrides:        getEnvStringMap("TENANCY_OVERRIDES", nil),
+			SchemaPrefix:     getEnv("TENANCY_SCHEMA_PREFIX", "tenant_"),
+			DatabasePrefix:   getEnv("TENANCY_DATABASE_PREFIX", "rexi_tenant_"),
+			MaxPools:         getEnvInt("TENANCY_MAX_POOLS", 50),
+			PoolIdleTimeout:  getEnvDuration("TENANCY_POOL_IDLE_TIMEOUT", 10*time.Minute),
+			PoolMaxOpenConns: getEnvInt("TENANCY_POOL_MAX_OPEN_CONNECTIONS", 5),
+			MigrationsDir:    getEnv("TENANCY_MIGRATIONS_DIR", "migrations/tenants"),
+		},
 	}
 
+	config.Databases.Replicas = replicaConfigs(config.Databases.Master, getEnvSlice("DB_REPLICA_HOSTS", nil))
+
 	// Validate configuration
 	if err := config.validate(); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
@@ -419,6 +796,15 @@ func (c *Config) validate() error {
 	if c.JWT.Secret == "your-super-secret-jwt-key-for-development-only" && c.App.Environment == "production" {
 		return fmt.Errorf("JWT secret must be changed in production")
 	}
+	if c.Pagination.CursorSecret == "" {
+		return fmt.Errorf("pagination cursor secret is required")
+	}
+	if c.Pagination.CursorSecret == c.JWT.Secret {
+		return fmt.Errorf("pagination cursor secret must differ from the JWT secret")
+	}
+	if c.Pagination.CursorSecret == "your-super-secret-cursor-key-for-development-only" && c.App.Environment == "production" {
+		return fmt.Errorf("pagination cursor secret must be changed in production")
+	}
 
 	// Validate port ranges
 	if c.App.Port < 1 || c.App.Port > 65535 {
@@ -434,10 +820,17 @@ func (c *Config) validate() error {
 		return fmt.Errorf("invalid rabbitmq port: %d", c.RabbitMQ.Port)
 	}
 
+	if c.Tracing.SampleRate < 0 || c.Tracing.SampleRate > 1 {
+		return fmt.Errorf("tracing sample rate must be between 0 and 1: %v", c.Tracing.SampleRate)
+	}
+
 	// Validate database connection settings
 	if err := c.Databases.Validate(); err != nil {
 		return fmt.Errorf("database configuration validation failed: %w", err)
 	}
+	if err := c.Tenancy.Validate(); err != nil {
+		return fmt.Errorf("tenancy configuration validation failed: %w", err)
+	}
 
 	return nil
 }
@@ -515,6 +908,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -538,4 +940,45 @@ func getEnvSlice(key string, defaultValue []string) []string {
 		return strings.Split(value, ",")
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getEnvInt64Map parses "key=value" pairs separated by commas,
+// e.g. "basic=300,professional=1200"
+func getEnvInt64Map(key string, defaultValue map[string]int64) map[string]int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	result := make(map[string]int64)
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 {
+			return defaultValue
+		}
+		intValue, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			return defaultValue
+		}
+		result[strings.TrimSpace(parts[0])] = intValue
+	}
+	return result
+}
+
+// getEnvStringMap gets a "key=value,key=value" environment variable as a map
+func getEnvStringMap(key string, defaultValue map[string]string) map[string]string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	result := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 {
+			return defaultValue
+		}
+		result[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return result
+}
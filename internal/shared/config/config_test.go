@@ -35,23 +35,24 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "custom environment variables",
 			envVars: map[string]string{
-				"APP_NAME":        "TestApp",
-				"APP_VERSION":     "2.0.0",
-				"APP_ENV":         "production",
-				"APP_DEBUG":       "false",
-				"APP_HOST":        "127.0.0.1",
-				"APP_PORT":        "9000",
-				"TZ":              "UTC",
-				"DB_HOST":         "test-host",
-				"DB_PORT":         "5433",
-				"DB_NAME":         "test_db",
-				"DB_USER":         "test_user",
-				"DB_PASSWORD":     "test_password",
-				"JWT_SECRET":      "test-jwt-secret",
-				"REDIS_HOST":      "test-redis",
-				"REDIS_PORT":      "6380",
-				"RABBITMQ_HOST":   "test-rabbitmq",
-				"RABBITMQ_PORT":   "5673",
+				"APP_NAME":                 "TestApp",
+				"APP_VERSION":              "2.0.0",
+				"APP_ENV":                  "production",
+				"APP_DEBUG":                "false",
+				"APP_HOST":                 "127.0.0.1",
+				"APP_PORT":                 "9000",
+				"TZ":                       "UTC",
+				"DB_HOST":                  "test-host",
+				"DB_PORT":                  "5433",
+				"DB_NAME":                  "test_db",
+				"DB_USER":                  "test_user",
+				"DB_PASSWORD":              "test_password",
+				"JWT_SECRET":               "test-jwt-secret",
+				"PAGINATION_CURSOR_SECRET": "test-cursor-secret",
+				"REDIS_HOST":               "test-redis",
+				"REDIS_PORT":               "6380",
+				"RABBITMQ_HOST":            "test-rabbitmq",
+				"RABBITMQ_PORT":            "5673",
 			},
 			wantFunc: func(t *testing.T, cfg *Config) {
 				assert.Equal(t, "TestApp", cfg.App.Name)
@@ -61,11 +62,11 @@ func TestLoadConfig(t *testing.T) {
 				assert.Equal(t, "127.0.0.1", cfg.App.Host)
 				assert.Equal(t, 9000, cfg.App.Port)
 				assert.Equal(t, "UTC", cfg.App.Timezone)
-				assert.Equal(t, "test-host", cfg.Database.Host)
-				assert.Equal(t, 5433, cfg.Database.Port)
-				assert.Equal(t, "test_db", cfg.Database.Name)
-				assert.Equal(t, "test_user", cfg.Database.User)
-				assert.Equal(t, "test_password", cfg.Database.Password)
+				assert.Equal(t, "test-host", cfg.Databases.Master.Host)
+				assert.Equal(t, 5433, cfg.Databases.Master.Port)
+				assert.Equal(t, "test_db", cfg.Databases.Master.Name)
+				assert.Equal(t, "test_user", cfg.Databases.Master.User)
+				assert.Equal(t, "test_password", cfg.Databases.Master.Password)
 				assert.Equal(t, "test-jwt-secret", cfg.JWT.Secret)
 				assert.Equal(t, "test-redis", cfg.Redis.Host)
 				assert.Equal(t, 6380, cfg.Redis.Port)
@@ -81,18 +82,34 @@ func TestLoadConfig(t *testing.T) {
 			},
 			wantFunc: func(t *testing.T, cfg *Config) {
 				// Should use default value "localhost"
-				assert.Equal(t, "localhost", cfg.Database.Host)
+				assert.Equal(t, "localhost", cfg.Databases.Master.Host)
 			},
 			wantErr: false,
 		},
 		{
 			name: "invalid configuration - production with default JWT secret",
 			envVars: map[string]string{
-				"APP_ENV":   "production",
+				"APP_ENV":    "production",
 				"JWT_SECRET": "your-super-secret-jwt-key-for-development-only",
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid configuration - cursor secret reuses the JWT secret",
+			envVars: map[string]string{
+				"JWT_SECRET":               "shared-secret",
+				"PAGINATION_CURSOR_SECRET": "shared-secret",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid configuration - production with default cursor secret",
+			envVars: map[string]string{
+				"APP_ENV":    "production",
+				"JWT_SECRET": "test-jwt-secret",
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid configuration - invalid port range",
 			envVars: map[string]string{
@@ -133,13 +150,15 @@ func TestLoadConfig(t *testing.T) {
 
 func TestConfigGetDSN(t *testing.T) {
 	cfg := &Config{
-		Database: DatabaseConfig{
-			Host:     "localhost",
-			Port:     5432,
-			User:     "test_user",
-			Password: "test_password",
-			Name:     "test_db",
-			SSLMode:  "disable",
+		Databases: DatabaseConfigs{
+			Master: DatabaseConfig{
+				Host:     "localhost",
+				Port:     5432,
+				User:     "test_user",
+				Password: "test_password",
+				Name:     "test_db",
+				SSLMode:  "disable",
+			},
 		},
 	}
 
@@ -202,26 +221,26 @@ func TestConfigGetRabbitMQURL(t *testing.T) {
 
 func TestConfigEnvironmentChecks(t *testing.T) {
 	tests := []struct {
-		name       string
-		environment string
+		name          string
+		environment   string
 		isDevelopment bool
 		isProduction  bool
 	}{
 		{
-			name:         "development environment",
-			environment:  "development",
+			name:          "development environment",
+			environment:   "development",
 			isDevelopment: true,
 			isProduction:  false,
 		},
 		{
-			name:         "production environment",
-			environment:  "production",
+			name:          "production environment",
+			environment:   "production",
 			isDevelopment: false,
 			isProduction:  true,
 		},
 		{
-			name:         "staging environment",
-			environment:  "staging",
+			name:          "staging environment",
+			environment:   "staging",
 			isDevelopment: false,
 			isProduction:  false,
 		},
@@ -426,6 +445,13 @@ func TestDatabaseConfigValidate(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
+			// The cases only vary the pool settings
+			tc.config.Type = DatabaseTypePostgreSQL
+			tc.config.Host = "localhost"
+			tc.config.Port = 5432
+			tc.config.Name = "rexi_erp"
+			tc.config.User = "rexi"
+
 			err := tc.config.Validate()
 			if tc.expectError {
 				assert.Error(t, err)
@@ -446,7 +472,7 @@ func TestLoadConfigWithAPIKeySettings(t *testing.T) {
 		wantConfig APIKeyConfig
 	}{
 		{
-			name: "default API key settings",
+			name:    "default API key settings",
 			envVars: map[string]string{},
 			wantConfig: APIKeyConfig{
 				Enabled:    true,
@@ -458,7 +484,7 @@ func TestLoadConfigWithAPIKeySettings(t *testing.T) {
 			name: "custom API key settings",
 			envVars: map[string]string{
 				"API_KEY_AUTH_ENABLED": "false",
-				"API_KEYS":            "key1,key2,key3",
+				"API_KEYS":             "key1,key2,key3",
 				"API_KEY_HEADER":       "Custom-API-Key",
 			},
 			wantConfig: APIKeyConfig{
@@ -489,4 +515,4 @@ func TestLoadConfigWithAPIKeySettings(t *testing.T) {
 			assert.Equal(t, tc.wantConfig.HeaderName, cfg.APIKey.HeaderName)
 		})
 	}
-}
\ No newline at end of file
+}
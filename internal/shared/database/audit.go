@@ -0,0 +1,400 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+	"github.com/VincentArjuna/RexiErp/internal/shared/tracing"
+)
+
+// Audit actions, matching the values written by the database trigger
+const (
+	AuditActionInsert = "INSERT"
+	AuditActionUpdate = "UPDATE"
+	AuditActionDelete = "DELETE"
+)
+
+// auditLogTable is append-only and never audited itself
+const auditLogTable = "audit_logs"
+
+const (
+	auditBeforeKey = "audit:before_values"
+	redactedValue  = "[REDACTED]"
+)
+
+// sensitiveColumnMarkers identify columns whose values are never copied
+// into the audit trail
+var sensitiveColumnMarkers = []string{"password", "token", "secret"}
+
+// AuditContext describes who made a change and from where. Fields left
+// empty are taken from the logger and tracing context when a change is
+// recorded.
+type AuditContext struct {
+	ActorID       uuid.UUID
+	TenantID      uuid.UUID
+	IPAddress     string
+	UserAgent     string
+	CorrelationID string
+	TraceID       string
+}
+
+type auditContextKey struct{}
+
+// WithAuditContext attaches the audit context to ctx
+func WithAuditContext(ctx context.Context, auditCtx AuditContext) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, auditCtx)
+}
+
+// AuditContextFromContext returns the audit context of ctx, filling the
+// actor, tenant, correlation ID and trace ID from the request context
+func AuditContextFromContext(ctx context.Context) AuditContext {
+	if ctx == nil {
+		return AuditContext{}
+	}
+
+	auditCtx, _ := ctx.Value(auditContextKey{}).(AuditContext)
+	if auditCtx.ActorID == uuid.Nil {
+		auditCtx.ActorID, _ = uuid.Parse(logger.GetUserID(ctx))
+	}
+	if auditCtx.TenantID == uuid.Nil {
+		auditCtx.TenantID, _ = uuid.Parse(logger.GetTenantID(ctx))
+	}
+	if auditCtx.CorrelationID == "" {
+		auditCtx.CorrelationID = logger.GetCorrelationID(ctx)
+	}
+	if auditCtx.TraceID == "" {
+		auditCtx.TraceID = tracing.TraceIDFromContext(ctx)
+	}
+	return auditCtx
+}
+
+// Auditor records every create, update and delete made through GORM in
+// audit_logs. Entries are written on the statement's own connection, so
+// they commit or roll back together with the change.
+type Auditor struct {
+	logger   *logrus.Logger
+	excluded map[string]bool
+}
+
+// NewAuditor creates an auditor. Changes to excludedTables, e.g. sessions
+// that are rewritten on every request, are not recorded.
+func NewAuditor(logger *logrus.Logger, excludedTables ...string) *Auditor {
+	excluded := make(map[string]bool, len(excludedTables))
+	for _, table := range excludedTables {
+		excluded[table] = true
+	}
+	return &Auditor{logger: logger, excluded: excluded}
+}
+
+// RegisterCallbacks captures the current values before updates and deletes
+// and records the change after every create, update and delete, before
+// GORM commits its default transaction
+func (a *Auditor) RegisterCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Update().Before("gorm:update").Register("audit:capture_update", a.captureBefore); err != nil {
+		return fmt.Errorf("failed to register audit callback: %w", err)
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("audit:capture_delete", a.captureBefore); err != nil {
+		return fmt.Errorf("failed to register audit callback: %w", err)
+	}
+	if err := db.Callback().Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").Register("audit:record_create", a.recordCreate); err != nil {
+		return fmt.Errorf("failed to register audit callback: %w", err)
+	}
+	if err := db.Callback().Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").Register("audit:record_update", a.recordUpdate); err != nil {
+		return fmt.Errorf("failed to register audit callback: %w", err)
+	}
+	if err := db.Callback().Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").Register("audit:record_delete", a.recordDelete); err != nil {
+		return fmt.Errorf("failed to register audit callback: %w", err)
+	}
+	return nil
+}
+
+func (a *Auditor) audited(db *gorm.DB) bool {
+	stmt := db.Statement
+	return db.Error == nil &&
+		stmt.Schema != nil &&
+		stmt.Table != auditLogTable &&
+		!a.excluded[stmt.Table]
+}
+
+func (a *Auditor) captureBefore(db *gorm.DB) {
+	if !a.audited(db) {
+		return
+	}
+
+	conditions := auditConditions(db.Statement)
+	if len(conditions) == 0 {
+		return
+	}
+
+	rows, err := a.loadRows(db, conditions)
+	if err != nil {
+		db.AddError(fmt.Errorf("failed to capture audit values: %w", err))
+		return
+	}
+	db.InstanceSet(auditBeforeKey, rows)
+}
+
+func (a *Auditor) recordCreate(db *gorm.DB) {
+	if !a.audited(db) || db.Statement.RowsAffected == 0 {
+		return
+	}
+
+	stmt := db.Statement
+	var entries []*AuditLog
+	eachRecord(stmt.ReflectValue, func(record reflect.Value) {
+		values := structValues(stmt, record)
+		entry := newAuditLog(stmt.Context, AuditActionInsert, stmt.Table, values)
+		entry.NewValues = values
+		entries = append(entries, entry)
+	})
+	a.write(db, entries)
+}
+
+func (a *Auditor) recordUpdate(db *gorm.DB) {
+	before := a.capturedRows(db)
+	if len(before) == 0 {
+		return
+	}
+
+	stmt := db.Statement
+	primaryKey := stmt.Schema.PrioritizedPrimaryField
+	if primaryKey == nil {
+		return
+	}
+
+	ids := make([]interface{}, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row[primaryKey.DBName])
+	}
+	after, err := a.loadRows(db, []clause.Expression{
+		clause.IN{Column: clause.Column{Name: primaryKey.DBName}, Values: ids},
+	})
+	if err != nil {
+		db.AddError(fmt.Errorf("failed to load audit values: %w", err))
+		return
+	}
+
+	afterByID := make(map[string]JSONB, len(after))
+	for _, row := range after {
+		afterByID[fmt.Sprint(row[primaryKey.DBName])] = row
+	}
+
+	var entries []*AuditLog
+	for _, oldValues := range before {
+		newValues := afterByID[fmt.Sprint(oldValues[primaryKey.DBName])]
+		changes := diffValues(oldValues, newValues)
+		if len(changes) == 0 {
+			continue
+		}
+
+		entry := newAuditLog(stmt.Context, AuditActionUpdate, stmt.Table, oldValues)
+		entry.OldValues = oldValues
+		entry.NewValues = newValues
+		entry.Changes = changes
+		entries = append(entries, entry)
+	}
+	a.write(db, entries)
+}
+
+func (a *Auditor) recordDelete(db *gorm.DB) {
+	before := a.capturedRows(db)
+	if len(before) == 0 {
+		return
+	}
+
+	stmt := db.Statement
+	entries := make([]*AuditLog, 0, len(before))
+	for _, oldValues := range before {
+		entry := newAuditLog(stmt.Context, AuditActionDelete, stmt.Table, oldValues)
+		entry.OldValues = oldValues
+		entries = append(entries, entry)
+	}
+	a.write(db, entries)
+}
+
+func (a *Auditor) capturedRows(db *gorm.DB) []JSONB {
+	if !a.audited(db) || db.Statement.RowsAffected == 0 {
+		return nil
+	}
+	rows, _ := db.InstanceGet(auditBeforeKey)
+	captured, _ := rows.([]JSONB)
+	return captured
+}
+
+// loadRows reads the matching rows on the statement's connection, inside
+// its transaction
+func (a *Auditor) loadRows(db *gorm.DB, conditions []clause.Expression) ([]JSONB, error) {
+	var rows []map[string]interface{}
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Table(db.Statement.Table).
+		Clauses(clause.Where{Exprs: conditions}).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]JSONB, 0, len(rows))
+	for _, row := range rows {
+		for column, value := range row {
+			row[column] = auditValue(column, value)
+		}
+		values = append(values, row)
+	}
+	return values, nil
+}
+
+// write appends the entries on the statement's connection. A failed write
+// fails the statement so the change is rolled back with it.
+func (a *Auditor) write(db *gorm.DB, entries []*AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(&entries).Error
+	if err != nil {
+		a.logger.WithError(err).WithField("table", db.Statement.Table).Error("Failed to write audit log")
+		db.AddError(fmt.Errorf("failed to write audit log: %w", err))
+	}
+}
+
+// auditConditions returns the conditions selecting the rows a statement
+// is about to change: its WHERE clause plus the primary keys of its model
+func auditConditions(stmt *gorm.Statement) []clause.Expression {
+	var conditions []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			conditions = append(conditions, where.Exprs...)
+		}
+	}
+
+	if primaryKey := stmt.Schema.PrioritizedPrimaryField; primaryKey != nil {
+		var ids []interface{}
+		eachRecord(stmt.ReflectValue, func(record reflect.Value) {
+			if id, zero := primaryKey.ValueOf(stmt.Context, record); !zero {
+				ids = append(ids, id)
+			}
+		})
+		if len(ids) > 0 {
+			conditions = append(conditions, clause.IN{Column: clause.Column{Name: primaryKey.DBName}, Values: ids})
+		}
+	}
+	return conditions
+}
+
+func eachRecord(value reflect.Value, fn func(record reflect.Value)) {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if record := reflect.Indirect(value.Index(i)); record.Kind() == reflect.Struct {
+				fn(record)
+			}
+		}
+	case reflect.Struct:
+		fn(value)
+	}
+}
+
+func structValues(stmt *gorm.Statement, record reflect.Value) JSONB {
+	values := JSONB{}
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" || !field.Readable {
+			continue
+		}
+		value, _ := field.ValueOf(stmt.Context, record)
+		values[field.DBName] = auditValue(field.DBName, value)
+	}
+	return values
+}
+
+// IsSensitiveColumn reports whether a column holds credentials such as
+// password hashes or tokens
+func IsSensitiveColumn(column string) bool {
+	for _, marker := range sensitiveColumnMarkers {
+		if strings.Contains(column, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func auditValue(column string, value interface{}) interface{} {
+	if IsSensitiveColumn(column) {
+		return redactedValue
+	}
+	// Scanning into a map yields *interface{} for types the driver does not
+	// map, such as uuid
+	if p, ok := value.(*interface{}); ok && p != nil {
+		value = *p
+	}
+	if b, ok := value.([]byte); ok {
+		return string(b)
+	}
+	return value
+}
+
+// diffValues returns the changed columns as {"old": ..., "new": ...}
+func diffValues(oldValues, newValues JSONB) JSONB {
+	changes := JSONB{}
+	for column, newValue := range newValues {
+		oldValue := oldValues[column]
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes[column] = map[string]interface{}{"old": oldValue, "new": newValue}
+		}
+	}
+	return changes
+}
+
+func newAuditLog(ctx context.Context, action, table string, values JSONB) *AuditLog {
+	auditCtx := AuditContextFromContext(ctx)
+	entry := &AuditLog{
+		ID:            uuid.New(),
+		Action:        action,
+		TableName:     table,
+		UserAgent:     auditCtx.UserAgent,
+		CorrelationID: auditCtx.CorrelationID,
+		TraceID:       auditCtx.TraceID,
+		CreatedAt:     time.Now(),
+	}
+
+	if auditCtx.ActorID != uuid.Nil {
+		entry.UserID = &auditCtx.ActorID
+	}
+	if auditCtx.IPAddress != "" {
+		entry.IPAddress = &auditCtx.IPAddress
+	}
+
+	entry.TenantID = uuidPtr(values["tenant_id"])
+	if auditCtx.TenantID != uuid.Nil {
+		entry.TenantID = &auditCtx.TenantID
+	}
+	entry.RecordID = uuidPtr(values["id"])
+	return entry
+}
+
+func uuidPtr(value interface{}) *uuid.UUID {
+	var id uuid.UUID
+	switch v := value.(type) {
+	case uuid.UUID:
+		id = v
+	case *uuid.UUID:
+		if v != nil {
+			id = *v
+		}
+	case string:
+		id, _ = uuid.Parse(v)
+	}
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
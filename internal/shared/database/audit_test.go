@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+)
+
+type auditWidget struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TenantID    uuid.UUID `gorm:"type:uuid"`
+	Name        string
+	Quantity    int
+	AccessToken string
+}
+
+func newAuditTestDB(t *testing.T, excludedTables ...string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&auditWidget{}, &AuditLog{}))
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	require.NoError(t, NewAuditor(log, excludedTables...).RegisterCallbacks(db))
+	return db
+}
+
+func auditEntries(t *testing.T, db *gorm.DB) []AuditLog {
+	var entries []AuditLog
+	require.NoError(t, db.Order("created_at").Find(&entries).Error)
+	return entries
+}
+
+func TestAuditor_RecordsChanges(t *testing.T) {
+	db := newAuditTestDB(t)
+
+	actorID := uuid.New()
+	tenantID := uuid.New()
+	ctx := logger.WithCorrelationID(context.Background(), "corr-123")
+	ctx = logger.WithUserID(ctx, actorID.String())
+	ctx = WithAuditContext(ctx, AuditContext{IPAddress: "10.0.0.1", UserAgent: "rexi-test"})
+
+	widget := &auditWidget{ID: uuid.New(), TenantID: tenantID, Name: "Bolt", Quantity: 10, AccessToken: "s3cr3t"}
+	require.NoError(t, db.WithContext(ctx).Create(widget).Error)
+	require.NoError(t, db.WithContext(ctx).Model(widget).Updates(map[string]interface{}{"quantity": 7}).Error)
+	require.NoError(t, db.WithContext(ctx).Delete(&auditWidget{}, "id = ?", widget.ID).Error)
+
+	entries := auditEntries(t, db)
+	require.Len(t, entries, 3)
+
+	created, updated, deleted := entries[0], entries[1], entries[2]
+	for _, entry := range entries {
+		assert.Equal(t, "audit_widgets", entry.TableName)
+		require.NotNil(t, entry.RecordID)
+		assert.Equal(t, widget.ID, *entry.RecordID)
+		require.NotNil(t, entry.TenantID, "tenant falls back to the record's tenant_id")
+		assert.Equal(t, tenantID, *entry.TenantID)
+		require.NotNil(t, entry.UserID)
+		assert.Equal(t, actorID, *entry.UserID)
+		require.NotNil(t, entry.IPAddress)
+		assert.Equal(t, "10.0.0.1", *entry.IPAddress)
+		assert.Equal(t, "rexi-test", entry.UserAgent)
+		assert.Equal(t, "corr-123", entry.CorrelationID)
+	}
+
+	assert.Equal(t, AuditActionInsert, created.Action)
+	assert.Nil(t, created.OldValues)
+	assert.Equal(t, "Bolt", created.NewValues["name"])
+	assert.Equal(t, redactedValue, created.NewValues["access_token"])
+
+	assert.Equal(t, AuditActionUpdate, updated.Action)
+	assert.EqualValues(t, 10, updated.OldValues["quantity"])
+	assert.EqualValues(t, 7, updated.NewValues["quantity"])
+	changes, err := json.Marshal(updated.Changes)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"quantity": {"old": 10, "new": 7}}`, string(changes))
+
+	assert.Equal(t, AuditActionDelete, deleted.Action)
+	assert.Equal(t, "Bolt", deleted.OldValues["name"])
+	assert.Nil(t, deleted.NewValues)
+}
+
+func TestAuditor_SkipsUnchangedAndExcludedTables(t *testing.T) {
+	t.Run("update without changes", func(t *testing.T) {
+		db := newAuditTestDB(t)
+		widget := &auditWidget{ID: uuid.New(), Name: "Nut", Quantity: 1}
+		require.NoError(t, db.Create(widget).Error)
+		require.NoError(t, db.Model(widget).Update("quantity", 1).Error)
+
+		entries := auditEntries(t, db)
+		require.Len(t, entries, 1)
+		assert.Equal(t, AuditActionInsert, entries[0].Action)
+	})
+
+	t.Run("excluded table", func(t *testing.T) {
+		db := newAuditTestDB(t, "audit_widgets")
+		require.NoError(t, db.Create(&auditWidget{ID: uuid.New(), Name: "Nut"}).Error)
+		assert.Empty(t, auditEntries(t, db))
+	})
+}
+
+func TestAuditor_SharesTransaction(t *testing.T) {
+	t.Run("rolled back changes leave no audit trail", func(t *testing.T) {
+		db := newAuditTestDB(t)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			require.NoError(t, tx.Create(&auditWidget{ID: uuid.New(), Name: "Washer"}).Error)
+			return assert.AnError
+		})
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Empty(t, auditEntries(t, db))
+	})
+
+	t.Run("a failed audit write rolls back the change", func(t *testing.T) {
+		db := newAuditTestDB(t)
+		require.NoError(t, db.Migrator().DropTable(&AuditLog{}))
+
+		err := db.Create(&auditWidget{ID: uuid.New(), Name: "Washer"}).Error
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to write audit log")
+
+		var count int64
+		require.NoError(t, db.Model(&auditWidget{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
+
+func TestAuditManager_LogChange(t *testing.T) {
+	db := newAuditTestDB(t)
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	recordID := uuid.New()
+	tenantID := uuid.New()
+	ctx := WithAuditContext(context.Background(), AuditContext{TenantID: tenantID, TraceID: "trace-1"})
+	require.NoError(t, NewAuditManager(db, log).LogChange(ctx, AuditActionUpdate, "stock_levels", recordID, map[string]interface{}{
+		"quantity": map[string]interface{}{"old": 5, "new": 3},
+	}))
+
+	entries := auditEntries(t, db)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "stock_levels", entries[0].TableName)
+	assert.Equal(t, recordID, *entries[0].RecordID)
+	assert.Equal(t, tenantID, *entries[0].TenantID)
+	assert.Equal(t, "trace-1", entries[0].TraceID)
+	assert.Contains(t, entries[0].Changes, "quantity")
+}
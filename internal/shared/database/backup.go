@@ -0,0 +1,769 @@
+package database
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+)
+
+// BackupKind is how a backup was taken
+type BackupKind string
+
+const (
+	// BackupKindLogical is a pg_dump of the database, restored into a new
+	// database
+	BackupKindLogical BackupKind = "logical"
+	// BackupKindBase is a pg_basebackup of the cluster, the starting point
+	// for replaying the archived WAL up to a point in time
+	BackupKindBase BackupKind = "base"
+)
+
+const (
+	backupManifestName = "manifest.json"
+	backupIDLayout     = "20060102T150405Z"
+	// backupCheckInterval is how often Run checks for due backups
+	backupCheckInterval = time.Minute
+	// walSegmentLength is the length of a WAL segment file name
+	walSegmentLength = 24
+)
+
+var (
+	// ErrBackupNotFound is returned for missing backups and WAL segments
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrBackupChecksum is returned when a backup does not match its manifest
+	ErrBackupChecksum = errors.New("backup checksum mismatch")
+)
+
+// BackupManifest describes a backup. It is stored next to the backup and
+// updated with the outcome of verification.
+type BackupManifest struct {
+	ID         string     `json:"id"`
+	Kind       BackupKind `json:"kind"`
+	Database   string     `json:"database"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt time.Time  `json:"finished_at"`
+	// StartWAL is the first WAL segment a base backup needs
+	StartWAL string `json:"start_wal,omitempty"`
+	// Tables is the number of tables in a logical backup
+	Tables      int    `json:"tables,omitempty"`
+	Object      string `json:"object"`
+	Compression string `json:"compression"`
+	Size        int64  `json:"size"`
+	// SHA256 is the checksum of the compressed object
+	SHA256 string `json:"sha256"`
+	// VerifiedAt is the last verification; VerifyError is empty if it passed
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+	VerifyError string     `json:"verify_error,omitempty"`
+}
+
+// BackupManager takes compressed backups of the master database into
+// MinIO, archives its WAL, prunes both by the retention policy and restores
+// them. Only one instance should run the schedule.
+type BackupManager struct {
+	store  BackupStore
+	tool   BackupTool
+	db     *config.DatabaseConfig
+	cfg    config.BackupConfig
+	logger *logrus.Logger
+	now    func() time.Time
+}
+
+// NewBackupManager creates a backup manager for the master database of an
+// initialized MultiDBManager, storing backups in its MinIO bucket. The
+// database itself is reached through the PostgreSQL client tools, so
+// InitializeStorage is enough.
+func NewBackupManager(mdb *MultiDBManager, cfg config.BackupConfig, logger *logrus.Logger) (*BackupManager, error) {
+	master := &mdb.config.Databases.Master
+	if master.Type != "" && master.Type != config.DatabaseTypePostgreSQL {
+		return nil, fmt.Errorf("backups are only supported for PostgreSQL, not %s", master.Type)
+	}
+	client := mdb.GetMinIO()
+	if client == nil {
+		return nil, fmt.Errorf("backups require a MinIO connection")
+	}
+
+	return &BackupManager{
+		store:  NewMinIOBackupStore(client, mdb.config.MinIO.Bucket),
+		tool:   NewPostgresBackupTool(),
+		db:     master,
+		cfg:    cfg,
+		logger: logger,
+		now:    time.Now,
+	}, nil
+}
+
+// Run takes the backups and verifications that are due until ctx is done
+func (bm *BackupManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(backupCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		bm.RunDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue takes each kind of backup whose interval has passed since the
+// latest one, prunes expired backups after a new one and verifies the
+// latest logical backup when verification is due
+func (bm *BackupManager) RunDue(ctx context.Context) {
+	schedule := []struct {
+		kind     BackupKind
+		interval time.Duration
+	}{
+		{BackupKindLogical, bm.cfg.Interval},
+		{BackupKindBase, bm.cfg.BaseInterval},
+	}
+
+	created := false
+	for _, entry := range schedule {
+		if entry.interval <= 0 {
+			continue
+		}
+		manifests, err := bm.ListBackups(ctx, entry.kind)
+		if err != nil {
+			bm.logger.WithError(err).WithField("kind", entry.kind).Error("Failed to list backups")
+			continue
+		}
+		if len(manifests) > 0 && bm.now().Sub(manifests[0].StartedAt) < entry.interval {
+			continue
+		}
+		if _, err := bm.CreateBackup(ctx, entry.kind); err != nil {
+			bm.logger.WithError(err).WithField("kind", entry.kind).Error("Scheduled backup failed")
+			continue
+		}
+		created = true
+	}
+
+	if created {
+		if err := bm.Prune(ctx); err != nil {
+			bm.logger.WithError(err).Error("Failed to prune backups")
+		}
+	}
+
+	if bm.cfg.VerifyInterval > 0 && bm.verificationDue(ctx) {
+		if _, err := bm.Verify(ctx); err != nil {
+			bm.logger.WithError(err).Error("Backup verification failed")
+		}
+	}
+}
+
+// CreateBackup takes a backup and streams it compressed into the store
+func (bm *BackupManager) CreateBackup(ctx context.Context, kind BackupKind) (*BackupManifest, error) {
+	started := bm.now().UTC()
+	manifest := &BackupManifest{
+		ID:          started.Format(backupIDLayout),
+		Kind:        kind,
+		Database:    bm.db.Name,
+		StartedAt:   started,
+		Compression: "gzip",
+	}
+	dir := bm.backupDir(kind, manifest.ID)
+
+	var write func(io.Writer) error
+	switch kind {
+	case BackupKindLogical:
+		tables, err := bm.tool.CountTables(ctx, bm.db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count tables: %w", err)
+		}
+		manifest.Tables = tables
+		manifest.Object = path.Join(dir, "data.sql.gz")
+		write = func(w io.Writer) error { return bm.tool.Dump(ctx, bm.db, w) }
+	case BackupKindBase:
+		// The segment current before the backup's checkpoint is at or before
+		// the backup's start
+		segment, err := bm.tool.CurrentWALSegment(ctx, bm.db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the current WAL segment: %w", err)
+		}
+		manifest.StartWAL = segment
+		manifest.Object = path.Join(dir, "base.tar.gz")
+		write = func(w io.Writer) error { return bm.tool.BaseBackup(ctx, bm.db, w) }
+	default:
+		return nil, fmt.Errorf("unknown backup kind %q", kind)
+	}
+
+	size, checksum, err := bm.upload(ctx, manifest.Object, write)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s backup: %w", kind, err)
+	}
+	manifest.Size = size
+	manifest.SHA256 = checksum
+	manifest.FinishedAt = bm.now().UTC()
+
+	if err := bm.putManifest(ctx, manifest); err != nil {
+		return nil, err
+	}
+
+	bm.logger.WithFields(logrus.Fields{
+		"backup_id": manifest.ID,
+		"kind":      kind,
+		"size":      size,
+		"duration":  manifest.FinishedAt.Sub(started).String(),
+	}).Info("Backup created")
+	return manifest, nil
+}
+
+// ListBackups returns the backups of a kind, newest first
+func (bm *BackupManager) ListBackups(ctx context.Context, kind BackupKind) ([]BackupManifest, error) {
+	keys, err := bm.store.List(ctx, path.Join(bm.prefix(), string(kind))+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []BackupManifest
+	for _, key := range keys {
+		if path.Base(key) != backupManifestName {
+			continue
+		}
+		manifest, err := bm.readManifest(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, *manifest)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].StartedAt.After(manifests[j].StartedAt)
+	})
+	return manifests, nil
+}
+
+// RestoreBackup restores a logical backup into a new database. The
+// database must not exist and is dropped again if the restore fails.
+func (bm *BackupManager) RestoreBackup(ctx context.Context, id, database string) error {
+	manifest, err := bm.readManifest(ctx, path.Join(bm.backupDir(BackupKindLogical, id), backupManifestName))
+	if err != nil {
+		return err
+	}
+	return bm.restoreLogical(ctx, manifest, database)
+}
+
+// RestoreAsOf restores the latest logical backup taken at or before at into
+// a new database. For recovery to an exact time use PrepareRecovery.
+func (bm *BackupManager) RestoreAsOf(ctx context.Context, at time.Time, database string) (*BackupManifest, error) {
+	manifest, err := bm.backupAsOf(ctx, BackupKindLogical, at, func(m BackupManifest) time.Time { return m.StartedAt })
+	if err != nil {
+		return nil, err
+	}
+	if err := bm.restoreLogical(ctx, manifest, database); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// PrepareRecovery restores the latest base backup finished before at into
+// an empty data directory and configures PostgreSQL to replay the archived
+// WAL up to at and promote. Starting a server on the directory completes
+// the recovery as a new database cluster.
+func (bm *BackupManager) PrepareRecovery(ctx context.Context, at time.Time, dataDir string) (*BackupManifest, error) {
+	if bm.cfg.WALFetchCommand == "" {
+		return nil, fmt.Errorf("point in time recovery requires a WAL fetch command")
+	}
+	// Recovery can only stop once the backup is consistent
+	manifest, err := bm.backupAsOf(ctx, BackupKindBase, at, func(m BackupManifest) time.Time { return m.FinishedAt })
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data directory: %w", err)
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("data directory %s is not empty", dataDir)
+	}
+
+	if err := bm.download(ctx, manifest, func(r io.Reader) error { return extractTar(r, dataDir) }); err != nil {
+		bm.clearDir(dataDir)
+		return nil, err
+	}
+
+	settings := fmt.Sprintf("\n# Point in time recovery from base backup %s\nrestore_command = '%s'\nrecovery_target_time = '%s'\nrecovery_target_action = 'promote'\n",
+		manifest.ID,
+		strings.ReplaceAll(bm.cfg.WALFetchCommand, "'", "''"),
+		at.UTC().Format("2006-01-02 15:04:05.999999Z07:00"),
+	)
+	if err := appendFile(filepath.Join(dataDir, "postgresql.auto.conf"), settings); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "recovery.signal"), nil, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write recovery.signal: %w", err)
+	}
+
+	bm.logger.WithFields(logrus.Fields{
+		"backup_id": manifest.ID,
+		"target":    at.UTC().Format(time.RFC3339),
+		"data_dir":  dataDir,
+	}).Info("Point in time recovery prepared")
+	return manifest, nil
+}
+
+// ArchiveWAL stores a completed WAL segment; it is meant to be called from
+// the server's archive_command
+func (bm *BackupManager) ArchiveWAL(ctx context.Context, walPath, name string) error {
+	file, err := os.Open(walPath)
+	if err != nil {
+		return fmt.Errorf("failed to open WAL segment: %w", err)
+	}
+	defer file.Close()
+
+	_, _, err = bm.upload(ctx, bm.walKey(name), func(w io.Writer) error {
+		_, err := io.Copy(w, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive WAL segment %s: %w", name, err)
+	}
+	return nil
+}
+
+// FetchWAL restores an archived WAL segment to walPath; it is meant to be
+// called from the server's restore_command. ErrBackupNotFound marks the
+// end of the archive.
+func (bm *BackupManager) FetchWAL(ctx context.Context, name, walPath string) error {
+	object, err := bm.store.Get(ctx, bm.walKey(name))
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	// gzip checks the segment's CRC once it is read to the end
+	gz, err := gzip.NewReader(object)
+	if err != nil {
+		return fmt.Errorf("failed to read WAL segment %s: %w", name, err)
+	}
+	temp := walPath + ".partial"
+	file, err := os.OpenFile(temp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write WAL segment: %w", err)
+	}
+	if _, err := io.Copy(file, gz); err != nil {
+		file.Close()
+		os.Remove(temp)
+		return fmt.Errorf("failed to read WAL segment %s: %w", name, err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("failed to write WAL segment: %w", err)
+	}
+	if err := os.Rename(temp, walPath); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("failed to write WAL segment: %w", err)
+	}
+	return nil
+}
+
+// Prune removes the backups that fall out of the retention period. The
+// newest MinKeep backups of each kind are kept, as is the newest backup
+// taken before the period so that every point within it stays restorable.
+// Archived WAL older than the oldest kept base backup is removed.
+func (bm *BackupManager) Prune(ctx context.Context) error {
+	if bm.cfg.Retention <= 0 {
+		return nil
+	}
+	cutoff := bm.now().Add(-bm.cfg.Retention)
+
+	oldestWAL := ""
+	for _, kind := range []BackupKind{BackupKindLogical, BackupKindBase} {
+		manifests, err := bm.ListBackups(ctx, kind)
+		if err != nil {
+			return err
+		}
+
+		keptBeforeCutoff := false
+		for i, manifest := range manifests {
+			inPeriod := manifest.StartedAt.After(cutoff)
+			keep := inPeriod || i < bm.cfg.MinKeep || !keptBeforeCutoff
+			if !inPeriod {
+				keptBeforeCutoff = true
+			}
+			if keep {
+				if kind == BackupKindBase && manifest.StartWAL != "" {
+					oldestWAL = manifest.StartWAL
+				}
+				continue
+			}
+			if err := bm.deleteBackup(ctx, manifest); err != nil {
+				return err
+			}
+		}
+	}
+
+	if oldestWAL == "" {
+		return nil
+	}
+	return bm.pruneWAL(ctx, oldestWAL)
+}
+
+// Verify test restores the latest logical backup into the scratch
+// database and checks the checksum of the latest base backup, recording
+// the outcome in their manifests
+func (bm *BackupManager) Verify(ctx context.Context) (*BackupManifest, error) {
+	scratch := bm.cfg.ScratchDatabase
+	if scratch == "" || scratch == bm.db.Name {
+		return nil, fmt.Errorf("verification requires a scratch database other than %q", bm.db.Name)
+	}
+
+	manifests, err := bm.ListBackups(ctx, BackupKindLogical)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("no logical backup to verify: %w", ErrBackupNotFound)
+	}
+	latest := manifests[0]
+
+	if err := bm.tool.DropDatabase(ctx, bm.db, scratch); err != nil {
+		return nil, fmt.Errorf("failed to drop scratch database: %w", err)
+	}
+	verifyErr := bm.restoreLogical(ctx, &latest, scratch)
+	if verifyErr == nil {
+		target := *bm.db
+		target.Name = scratch
+		tables, err := bm.tool.CountTables(ctx, &target)
+		switch {
+		case err != nil:
+			verifyErr = fmt.Errorf("failed to count restored tables: %w", err)
+		case tables != latest.Tables:
+			verifyErr = fmt.Errorf("restored %d tables, backup has %d", tables, latest.Tables)
+		}
+		if err := bm.tool.DropDatabase(ctx, bm.db, scratch); err != nil {
+			bm.logger.WithError(err).WithField("database", scratch).Warn("Failed to drop scratch database")
+		}
+	}
+	if err := bm.recordVerification(ctx, &latest, verifyErr); err != nil {
+		return nil, err
+	}
+
+	bases, err := bm.ListBackups(ctx, BackupKindBase)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) > 0 {
+		base := bases[0]
+		baseErr := bm.download(ctx, &base, func(r io.Reader) error {
+			_, err := io.Copy(io.Discard, r)
+			return err
+		})
+		if err := bm.recordVerification(ctx, &base, baseErr); err != nil {
+			return nil, err
+		}
+		verifyErr = errors.Join(verifyErr, baseErr)
+	}
+
+	if verifyErr != nil {
+		return &latest, fmt.Errorf("backup %s failed verification: %w", latest.ID, verifyErr)
+	}
+	bm.logger.WithField("backup_id", latest.ID).Info("Backup verified")
+	return &latest, nil
+}
+
+func (bm *BackupManager) verificationDue(ctx context.Context) bool {
+	manifests, err := bm.ListBackups(ctx, BackupKindLogical)
+	if err != nil {
+		bm.logger.WithError(err).Error("Failed to list backups")
+		return false
+	}
+	if len(manifests) == 0 {
+		return false
+	}
+	for _, manifest := range manifests {
+		if manifest.VerifiedAt != nil && bm.now().Sub(*manifest.VerifiedAt) < bm.cfg.VerifyInterval {
+			return false
+		}
+	}
+	return true
+}
+
+func (bm *BackupManager) recordVerification(ctx context.Context, manifest *BackupManifest, verifyErr error) error {
+	verifiedAt := bm.now().UTC()
+	manifest.VerifiedAt = &verifiedAt
+	manifest.VerifyError = ""
+	if verifyErr != nil {
+		manifest.VerifyError = verifyErr.Error()
+	}
+	return bm.putManifest(ctx, manifest)
+}
+
+func (bm *BackupManager) restoreLogical(ctx context.Context, manifest *BackupManifest, database string) error {
+	if database == "" || database == bm.db.Name {
+		return fmt.Errorf("restore must target a new database, not %q", database)
+	}
+	if err := bm.tool.CreateDatabase(ctx, bm.db, database); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", database, err)
+	}
+
+	target := *bm.db
+	target.Name = database
+	err := bm.download(ctx, manifest, func(r io.Reader) error { return bm.tool.Restore(ctx, &target, r) })
+	if err != nil {
+		if dropErr := bm.tool.DropDatabase(ctx, bm.db, database); dropErr != nil {
+			bm.logger.WithError(dropErr).WithField("database", database).Warn("Failed to drop partially restored database")
+		}
+		return fmt.Errorf("failed to restore backup %s: %w", manifest.ID, err)
+	}
+
+	bm.logger.WithFields(logrus.Fields{
+		"backup_id": manifest.ID,
+		"database":  database,
+	}).Info("Backup restored")
+	return nil
+}
+
+func (bm *BackupManager) backupAsOf(ctx context.Context, kind BackupKind, at time.Time, consistentAt func(BackupManifest) time.Time) (*BackupManifest, error) {
+	manifests, err := bm.ListBackups(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	for _, manifest := range manifests {
+		if !consistentAt(manifest).After(at) {
+			return &manifest, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s backup as of %s: %w", kind, at.UTC().Format(time.RFC3339), ErrBackupNotFound)
+}
+
+// upload streams what write produces, gzip compressed, into the store and
+// returns the size and checksum of the stored object
+func (bm *BackupManager) upload(ctx context.Context, key string, write func(io.Writer) error) (int64, string, error) {
+	// Cancelling stops the writer if the store gives up reading
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reader, writer := io.Pipe()
+	hash := sha256.New()
+	counter := &countingWriter{}
+	go func() {
+		gz := gzip.NewWriter(io.MultiWriter(writer, hash, counter))
+		err := write(gz)
+		if err == nil {
+			err = gz.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	if err := bm.store.Put(ctx, key, reader); err != nil {
+		reader.CloseWithError(err)
+		return 0, "", err
+	}
+	return counter.n, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// download decompresses a backup into read and checks the checksum of the
+// whole object afterwards
+func (bm *BackupManager) download(ctx context.Context, manifest *BackupManifest, read func(io.Reader) error) error {
+	object, err := bm.store.Get(ctx, manifest.Object)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	hash := sha256.New()
+	source := io.TeeReader(object, hash)
+	gz, err := gzip.NewReader(source)
+	if err != nil {
+		return fmt.Errorf("failed to read backup %s: %w", manifest.ID, err)
+	}
+	if err := read(gz); err != nil {
+		return err
+	}
+	if _, err := io.Copy(io.Discard, source); err != nil {
+		return fmt.Errorf("failed to read backup %s: %w", manifest.ID, err)
+	}
+
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != manifest.SHA256 {
+		return fmt.Errorf("backup %s has checksum %s, manifest has %s: %w", manifest.ID, checksum, manifest.SHA256, ErrBackupChecksum)
+	}
+	return nil
+}
+
+func (bm *BackupManager) deleteBackup(ctx context.Context, manifest BackupManifest) error {
+	// The manifest goes last so a failed removal is retried on the next run
+	if err := bm.store.Remove(ctx, manifest.Object); err != nil {
+		return err
+	}
+	if err := bm.store.Remove(ctx, path.Join(bm.backupDir(manifest.Kind, manifest.ID), backupManifestName)); err != nil {
+		return err
+	}
+	bm.logger.WithFields(logrus.Fields{
+		"backup_id": manifest.ID,
+		"kind":      manifest.Kind,
+	}).Info("Expired backup removed")
+	return nil
+}
+
+// pruneWAL removes archived segments before oldest. Timeline history files
+// are kept.
+func (bm *BackupManager) pruneWAL(ctx context.Context, oldest string) error {
+	keys, err := bm.store.List(ctx, bm.walKey(""))
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	for _, key := range keys {
+		name := strings.TrimSuffix(path.Base(key), ".gz")
+		if len(name) < walSegmentLength || strings.HasSuffix(name, ".history") {
+			continue
+		}
+		if name[:walSegmentLength] >= oldest {
+			continue
+		}
+		if err := bm.store.Remove(ctx, key); err != nil {
+			return err
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		bm.logger.WithFields(logrus.Fields{
+			"segments": removed,
+			"oldest":   oldest,
+		}).Info("Expired WAL segments removed")
+	}
+	return nil
+}
+
+func (bm *BackupManager) readManifest(ctx context.Context, key string) (*BackupManifest, error) {
+	object, err := bm.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	var manifest BackupManifest
+	if err := json.NewDecoder(object).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to read backup manifest %s: %w", key, err)
+	}
+	return &manifest, nil
+}
+
+func (bm *BackupManager) putManifest(ctx context.Context, manifest *BackupManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup manifest: %w", err)
+	}
+	key := path.Join(bm.backupDir(manifest.Kind, manifest.ID), backupManifestName)
+	return bm.store.Put(ctx, key, bytes.NewReader(data))
+}
+
+func (bm *BackupManager) clearDir(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			bm.logger.WithError(err).WithField("path", dir).Warn("Failed to clean up data directory")
+		}
+	}
+}
+
+func (bm *BackupManager) prefix() string {
+	return path.Join(bm.cfg.Prefix, bm.db.Name)
+}
+
+func (bm *BackupManager) backupDir(kind BackupKind, id string) string {
+	return path.Join(bm.prefix(), string(kind), id)
+}
+
+func (bm *BackupManager) walKey(name string) string {
+	if name == "" {
+		return path.Join(bm.prefix(), "wal") + "/"
+	}
+	return path.Join(bm.prefix(), "wal", name+".gz")
+}
+
+// extractTar unpacks a tar stream into dir, refusing entries that would
+// land outside it
+func extractTar(r io.Reader, dir string) error {
+	root := filepath.Clean(dir)
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read backup archive: %w", err)
+		}
+
+		target := filepath.Join(root, header.Name)
+		if target != root && !strings.HasPrefix(target, root+string(os.PathSeparator)) {
+			return fmt.Errorf("backup archive entry %q escapes the data directory", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return fmt.Errorf("failed to create %s: %w", target, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, header.FileInfo().Mode().Perm())
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", target, err)
+			}
+			if _, err := io.Copy(file, archive); err != nil {
+				file.Close()
+				return fmt.Errorf("failed to write %s: %w", target, err)
+			}
+			if err := file.Close(); err != nil {
+				return fmt.Errorf("failed to write %s: %w", target, err)
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return fmt.Errorf("failed to create %s: %w", target, err)
+			}
+		}
+	}
+}
+
+func appendFile(name, content string) error {
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return file.Close()
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+)
+
+// BackupTool runs the database client tools for the BackupManager
+type BackupTool interface {
+	// Dump writes a plain SQL dump of the database
+	Dump(ctx context.Context, cfg *config.DatabaseConfig, w io.Writer) error
+	// BaseBackup writes a tar of the cluster's data directory without WAL,
+	// which is restored from the archive instead
+	BaseBackup(ctx context.Context, cfg *config.DatabaseConfig, w io.Writer) error
+	// Restore applies a plain SQL dump to the database in one transaction
+	Restore(ctx context.Context, cfg *config.DatabaseConfig, r io.Reader) error
+	// CreateDatabase fails if the database already exists
+	CreateDatabase(ctx context.Context, cfg *config.DatabaseConfig, name string) error
+	DropDatabase(ctx context.Context, cfg *config.DatabaseConfig, name string) error
+	CountTables(ctx context.Context, cfg *config.DatabaseConfig) (int, error)
+	// CurrentWALSegment returns the name of the WAL segment being written
+	CurrentWALSegment(ctx context.Context, cfg *config.DatabaseConfig) (string, error)
+}
+
+// PostgresBackupTool runs pg_dump, pg_basebackup and psql from the PATH.
+// Base backups need a user with the REPLICATION attribute.
+type PostgresBackupTool struct{}
+
+// NewPostgresBackupTool creates a PostgreSQL backup tool
+func NewPostgresBackupTool() *PostgresBackupTool {
+	return &PostgresBackupTool{}
+}
+
+// Dump runs pg_dump
+func (t *PostgresBackupTool) Dump(ctx context.Context, cfg *config.DatabaseConfig, w io.Writer) error {
+	cmd := t.command(ctx, cfg, "pg_dump", "--dbname", cfg.Name, "--format=plain", "--no-owner", "--no-privileges")
+	cmd.Stdout = w
+	return runBackupCommand(cmd)
+}
+
+// BaseBackup runs pg_basebackup
+func (t *PostgresBackupTool) BaseBackup(ctx context.Context, cfg *config.DatabaseConfig, w io.Writer) error {
+	cmd := t.command(ctx, cfg, "pg_basebackup", "--pgdata=-", "--format=tar", "--wal-method=none", "--checkpoint=fast")
+	cmd.Stdout = w
+	return runBackupCommand(cmd)
+}
+
+// Restore runs psql, stopping at the first error
+func (t *PostgresBackupTool) Restore(ctx context.Context, cfg *config.DatabaseConfig, r io.Reader) error {
+	cmd := t.command(ctx, cfg, "psql", "--dbname", cfg.Name, "--no-psqlrc", "--quiet",
+		"--set", "ON_ERROR_STOP=1", "--single-transaction", "--file=-")
+	cmd.Stdin = r
+	return runBackupCommand(cmd)
+}
+
+// CreateDatabase creates a database
+func (t *PostgresBackupTool) CreateDatabase(ctx context.Context, cfg *config.DatabaseConfig, name string) error {
+	_, err := t.query(ctx, cfg, "CREATE DATABASE "+quoteIdentifier(name))
+	return err
+}
+
+// DropDatabase drops a database if it exists, closing its connections
+func (t *PostgresBackupTool) DropDatabase(ctx context.Context, cfg *config.DatabaseConfig, name string) error {
+	_, err := t.query(ctx, cfg, "DROP DATABASE IF EXISTS "+quoteIdentifier(name)+" WITH (FORCE)")
+	return err
+}
+
+// CountTables counts the tables outside the system schemas
+func (t *PostgresBackupTool) CountTables(ctx context.Context, cfg *config.DatabaseConfig) (int, error) {
+	output, err := t.query(ctx, cfg, `SELECT count(*) FROM information_schema.tables
+		WHERE table_type = 'BASE TABLE' AND table_schema NOT IN ('pg_catalog', 'information_schema')`)
+	if err != nil {
+		return 0, err
+	}
+	count, err := strconv.Atoi(output)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected table count %q: %w", output, err)
+	}
+	return count, nil
+}
+
+// CurrentWALSegment returns the current WAL file name
+func (t *PostgresBackupTool) CurrentWALSegment(ctx context.Context, cfg *config.DatabaseConfig) (string, error) {
+	return t.query(ctx, cfg, "SELECT pg_walfile_name(pg_current_wal_lsn())")
+}
+
+func (t *PostgresBackupTool) query(ctx context.Context, cfg *config.DatabaseConfig, sql string) (string, error) {
+	var stdout bytes.Buffer
+	cmd := t.command(ctx, cfg, "psql", "--dbname", cfg.Name, "--no-psqlrc", "--tuples-only", "--no-align", "--command", sql)
+	cmd.Stdout = &stdout
+	if err := runBackupCommand(cmd); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (t *PostgresBackupTool) command(ctx context.Context, cfg *config.DatabaseConfig, name string, args ...string) *exec.Cmd {
+	args = append([]string{"--host", cfg.Host, "--port", strconv.Itoa(cfg.Port), "--username", cfg.User}, args...)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+cfg.Password)
+	if cfg.SSLMode != "" {
+		cmd.Env = append(cmd.Env, "PGSSLMODE="+cfg.SSLMode)
+	}
+	return cmd
+}
+
+func runBackupCommand(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", cmd.Args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
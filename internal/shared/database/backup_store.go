@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// backupPartSize bounds the memory used to stream an upload of unknown
+// size; MinIO allows 10000 parts, so objects up to ~160GB
+const backupPartSize = 16 << 20
+
+// BackupStore keeps backup objects
+type BackupStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get returns ErrBackupNotFound when the object does not exist
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	List(ctx context.Context, prefix string) ([]string, error)
+	Remove(ctx context.Context, key string) error
+}
+
+// MinIOBackupStore keeps backups in a MinIO bucket
+type MinIOBackupStore struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewMinIOBackupStore creates a backup store on an existing bucket
+func NewMinIOBackupStore(client *minio.Client, bucket string) *MinIOBackupStore {
+	return &MinIOBackupStore{client: client, bucket: bucket}
+}
+
+// Put streams an object of unknown size
+func (s *MinIOBackupStore) Put(ctx context.Context, key string, r io.Reader) error {
+	contentType := "application/gzip"
+	if path.Ext(key) == ".json" {
+		contentType = "application/json"
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, -1, minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    backupPartSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s to MinIO: %w", key, err)
+	}
+	return nil
+}
+
+// Get opens an object
+func (s *MinIOBackupStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from MinIO: %w", key, err)
+	}
+	// GetObject is lazy; Stat surfaces a missing key
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf("%s: %w", key, ErrBackupNotFound)
+		}
+		return nil, fmt.Errorf("failed to read %s from MinIO: %w", key, err)
+	}
+	return object, nil
+}
+
+// List returns the keys under prefix
+func (s *MinIOBackupStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list %s in MinIO: %w", prefix, object.Err)
+		}
+		keys = append(keys, object.Key)
+	}
+	return keys, nil
+}
+
+// Remove deletes an object
+func (s *MinIOBackupStore) Remove(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to remove %s from MinIO: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package database
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+)
+
+type memoryBackupStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *memoryBackupStore) Put(_ context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memoryBackupStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", key, ErrBackupNotFound)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryBackupStore) List(_ context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *memoryBackupStore) Remove(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+// fakeBackupTool keeps databases as SQL text
+type fakeBackupTool struct {
+	databases map[string]string
+	wal       string
+}
+
+func (t *fakeBackupTool) Dump(_ context.Context, cfg *config.DatabaseConfig, w io.Writer) error {
+	_, err := io.WriteString(w, t.databases[cfg.Name])
+	return err
+}
+
+func (t *fakeBackupTool) BaseBackup(_ context.Context, _ *config.DatabaseConfig, w io.Writer) error {
+	archive := tar.NewWriter(w)
+	files := map[string]string{"PG_VERSION": "16\n", "base/1/1259": "catalog", "backup_label": "START WAL LOCATION: 0/3000028"}
+	for _, name := range []string{"PG_VERSION", "backup_label", "base/1/1259"} {
+		if err := archive.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(files[name])), Typeflag: tar.TypeReg}); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(archive, files[name]); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func (t *fakeBackupTool) Restore(_ context.Context, cfg *config.DatabaseConfig, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	t.databases[cfg.Name] = string(data)
+	return nil
+}
+
+func (t *fakeBackupTool) CreateDatabase(_ context.Context, _ *config.DatabaseConfig, name string) error {
+	if _, ok := t.databases[name]; ok {
+		return fmt.Errorf("database %q already exists", name)
+	}
+	t.databases[name] = ""
+	return nil
+}
+
+func (t *fakeBackupTool) DropDatabase(_ context.Context, _ *config.DatabaseConfig, name string) error {
+	delete(t.databases, name)
+	return nil
+}
+
+func (t *fakeBackupTool) CountTables(_ context.Context, cfg *config.DatabaseConfig) (int, error) {
+	return strings.Count(t.databases[cfg.Name], "CREATE TABLE"), nil
+}
+
+func (t *fakeBackupTool) CurrentWALSegment(context.Context, *config.DatabaseConfig) (string, error) {
+	return t.wal, nil
+}
+
+func newTestBackupManager(t *testing.T) (*BackupManager, *memoryBackupStore, *fakeBackupTool, *time.Time) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	store := &memoryBackupStore{objects: make(map[string][]byte)}
+	tool := &fakeBackupTool{
+		databases: map[string]string{"rexi_erp": "CREATE TABLE users (id uuid);\nCREATE TABLE tenants (id uuid);\n"},
+		wal:       "000000010000000000000003",
+	}
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	bm := &BackupManager{
+		store: store,
+		tool:  tool,
+		db:    &config.DatabaseConfig{Name: "rexi_erp"},
+		cfg: config.BackupConfig{
+			Prefix:          "backups",
+			Interval:        24 * time.Hour,
+			BaseInterval:    7 * 24 * time.Hour,
+			Retention:       7 * 24 * time.Hour,
+			MinKeep:         2,
+			VerifyInterval:  24 * time.Hour,
+			ScratchDatabase: "restore_check",
+			WALFetchCommand: "/usr/local/bin/backup wal-fetch %f %p",
+		},
+		logger: logger,
+		now:    func() time.Time { return now },
+	}
+	return bm, store, tool, &now
+}
+
+func TestBackupManager_CreateAndRestore(t *testing.T) {
+	bm, store, tool, _ := newTestBackupManager(t)
+	ctx := context.Background()
+
+	manifest, err := bm.CreateBackup(ctx, BackupKindLogical)
+	require.NoError(t, err)
+	assert.Equal(t, "20260301T020000Z", manifest.ID)
+	assert.Equal(t, "backups/rexi_erp/logical/20260301T020000Z/data.sql.gz", manifest.Object)
+	assert.Equal(t, 2, manifest.Tables)
+	assert.Equal(t, int64(len(store.objects[manifest.Object])), manifest.Size)
+	assert.Len(t, manifest.SHA256, 64)
+
+	manifests, err := bm.ListBackups(ctx, BackupKindLogical)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	assert.Equal(t, manifest.SHA256, manifests[0].SHA256)
+
+	require.NoError(t, bm.RestoreBackup(ctx, manifest.ID, "rexi_erp_restored"))
+	assert.Equal(t, tool.databases["rexi_erp"], tool.databases["rexi_erp_restored"])
+
+	// Restores never touch the live or an existing database
+	assert.Error(t, bm.RestoreBackup(ctx, manifest.ID, "rexi_erp"))
+	assert.Error(t, bm.RestoreBackup(ctx, manifest.ID, "rexi_erp_restored"))
+	assert.ErrorIs(t, bm.RestoreBackup(ctx, "20250101T000000Z", "other"), ErrBackupNotFound)
+
+	// A corrupted object is detected and the partial restore dropped
+	object := store.objects[manifest.Object]
+	object[len(object)-1] ^= 0xff
+	assert.Error(t, bm.RestoreBackup(ctx, manifest.ID, "corrupted"))
+	assert.NotContains(t, tool.databases, "corrupted")
+
+	manifest.SHA256 = strings.Repeat("0", 64)
+	object[len(object)-1] ^= 0xff
+	assert.ErrorIs(t, bm.restoreLogical(ctx, manifest, "mismatch"), ErrBackupChecksum)
+	assert.NotContains(t, tool.databases, "mismatch")
+}
+
+func TestBackupManager_ScheduleAndRetention(t *testing.T) {
+	bm, store, tool, now := newTestBackupManager(t)
+	ctx := context.Background()
+
+	bm.RunDue(ctx)
+	logical, err := bm.ListBackups(ctx, BackupKindLogical)
+	require.NoError(t, err)
+	base, err := bm.ListBackups(ctx, BackupKindBase)
+	require.NoError(t, err)
+	require.Len(t, logical, 1)
+	require.Len(t, base, 1)
+	require.NotNil(t, logical[0].VerifiedAt)
+	assert.Empty(t, logical[0].VerifyError)
+	assert.NotNil(t, base[0].VerifiedAt)
+
+	*now = now.Add(time.Hour)
+	bm.RunDue(ctx)
+	logical, _ = bm.ListBackups(ctx, BackupKindLogical)
+	assert.Len(t, logical, 1)
+
+	require.NoError(t, store.Put(ctx, bm.walKey("000000010000000000000001"), strings.NewReader("old")))
+	require.NoError(t, store.Put(ctx, bm.walKey("000000010000000000000002.00000028.backup"), strings.NewReader("old")))
+	require.NoError(t, store.Put(ctx, bm.walKey("00000002.history"), strings.NewReader("history")))
+	require.NoError(t, store.Put(ctx, bm.walKey("000000010000000000000010"), strings.NewReader("needed")))
+
+	// Twenty days of daily backups with a seven day retention
+	for day := 1; day <= 20; day++ {
+		*now = time.Date(2026, 3, 1+day, 2, 0, 0, 0, time.UTC)
+		tool.wal = fmt.Sprintf("0000000100000000000000%02X", 3+day)
+		bm.RunDue(ctx)
+	}
+
+	logical, err = bm.ListBackups(ctx, BackupKindLogical)
+	require.NoError(t, err)
+	// Seven days within the retention period plus the newest one before it
+	require.Len(t, logical, 8)
+	assert.Equal(t, "20260321T020000Z", logical[0].ID)
+	assert.Equal(t, "20260314T020000Z", logical[7].ID)
+
+	base, err = bm.ListBackups(ctx, BackupKindBase)
+	require.NoError(t, err)
+	require.Len(t, base, 2)
+	assert.Equal(t, "20260315T020000Z", base[0].ID)
+	assert.Equal(t, "20260308T020000Z", base[1].ID)
+
+	wal, err := store.List(ctx, bm.walKey(""))
+	require.NoError(t, err)
+	assert.Equal(t, []string{bm.walKey("000000010000000000000010"), bm.walKey("00000002.history")}, wal,
+		"segments before the oldest kept base backup are removed")
+}
+
+func TestBackupManager_VerifyRecordsFailures(t *testing.T) {
+	bm, _, tool, now := newTestBackupManager(t)
+	ctx := context.Background()
+
+	_, err := bm.Verify(ctx)
+	assert.ErrorIs(t, err, ErrBackupNotFound)
+
+	manifest, err := bm.CreateBackup(ctx, BackupKindLogical)
+	require.NoError(t, err)
+	verified, err := bm.Verify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.ID, verified.ID)
+	assert.NotContains(t, tool.databases, "restore_check", "the scratch database is dropped")
+	assert.False(t, bm.verificationDue(ctx))
+
+	// A dump that lost a table fails verification
+	tool.databases["rexi_erp"] = "CREATE TABLE users (id uuid);\n"
+	*now = now.Add(24 * time.Hour)
+	manifest, err = bm.CreateBackup(ctx, BackupKindLogical)
+	require.NoError(t, err)
+	manifest.Tables = 2
+	require.NoError(t, bm.putManifest(ctx, manifest))
+	assert.True(t, bm.verificationDue(ctx))
+
+	_, err = bm.Verify(ctx)
+	require.Error(t, err)
+	manifests, err := bm.ListBackups(ctx, BackupKindLogical)
+	require.NoError(t, err)
+	assert.Contains(t, manifests[0].VerifyError, "restored 1 tables, backup has 2")
+	assert.False(t, bm.verificationDue(ctx))
+
+	bm.cfg.ScratchDatabase = "rexi_erp"
+	_, err = bm.Verify(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, tool.databases, "rexi_erp")
+}
+
+func TestBackupManager_PointInTimeRecovery(t *testing.T) {
+	bm, _, _, now := newTestBackupManager(t)
+	ctx := context.Background()
+
+	first, err := bm.CreateBackup(ctx, BackupKindBase)
+	require.NoError(t, err)
+	*now = now.Add(24 * time.Hour)
+	_, err = bm.CreateBackup(ctx, BackupKindBase)
+	require.NoError(t, err)
+
+	segment := filepath.Join(t.TempDir(), "000000010000000000000003")
+	require.NoError(t, os.WriteFile(segment, []byte("wal records"), 0o600))
+	require.NoError(t, bm.ArchiveWAL(ctx, segment, "000000010000000000000003"))
+
+	fetched := filepath.Join(t.TempDir(), "RECOVERYXLOG")
+	require.NoError(t, bm.FetchWAL(ctx, "000000010000000000000003", fetched))
+	data, err := os.ReadFile(fetched)
+	require.NoError(t, err)
+	assert.Equal(t, "wal records", string(data))
+	assert.ErrorIs(t, bm.FetchWAL(ctx, "000000010000000000000004", fetched), ErrBackupNotFound)
+
+	target := time.Date(2026, 3, 1, 14, 30, 0, 0, time.UTC)
+	dataDir := filepath.Join(t.TempDir(), "pgdata")
+	manifest, err := bm.PrepareRecovery(ctx, target, dataDir)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, manifest.ID, "the latest base backup before the target is used")
+
+	version, err := os.ReadFile(filepath.Join(dataDir, "PG_VERSION"))
+	require.NoError(t, err)
+	assert.Equal(t, "16\n", string(version))
+	assert.FileExists(t, filepath.Join(dataDir, "base", "1", "1259"))
+	assert.FileExists(t, filepath.Join(dataDir, "recovery.signal"))
+	conf, err := os.ReadFile(filepath.Join(dataDir, "postgresql.auto.conf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(conf), "restore_command = '/usr/local/bin/backup wal-fetch %f %p'")
+	assert.Contains(t, string(conf), "recovery_target_time = '2026-03-01 14:30:00Z'")
+	assert.Contains(t, string(conf), "recovery_target_action = 'promote'")
+
+	_, err = bm.PrepareRecovery(ctx, target, dataDir)
+	assert.Error(t, err, "the data directory must be empty")
+	_, err = bm.PrepareRecovery(ctx, target.Add(-24*time.Hour), filepath.Join(t.TempDir(), "early"))
+	assert.ErrorIs(t, err, ErrBackupNotFound)
+}
+
+func TestExtractTar_RejectsEscapingEntries(t *testing.T) {
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	require.NoError(t, archive.WriteHeader(&tar.Header{Name: "../escape", Mode: 0o600, Size: 1, Typeflag: tar.TypeReg}))
+	_, err := archive.Write([]byte("x"))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	dir := t.TempDir()
+	err = extractTar(&buf, filepath.Join(dir, "data"))
+	require.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "escape"))
+}
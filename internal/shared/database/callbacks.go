@@ -11,6 +11,11 @@ import (
 
 // Audit trail callback functions
 func beforeCreateAudit(db *gorm.DB) {
+	// Audit entries are append-only and only carry created_at
+	if db.Statement.Table == auditLogTable {
+		return
+	}
+
 	// Set created_at and updated_at timestamps
 	db.Statement.SetColumn("created_at", time.Now())
 	db.Statement.SetColumn("updated_at", time.Now())
@@ -26,36 +31,6 @@ func beforeDeleteAudit(db *gorm.DB) {
 	db.Statement.SetColumn("deleted_at", time.Now())
 }
 
-func afterCreateAudit(db *gorm.DB) {
-	// Log creation
-	tableName := db.Statement.Table
-	id := db.Statement.Dest
-
-	if logger, ok := db.Logger.(interface{ Infof(string, ...interface{}) }); ok {
-		logger.Infof("Created record in %s: %+v", tableName, id)
-	}
-}
-
-func afterUpdateAudit(db *gorm.DB) {
-	// Log update
-	tableName := db.Statement.Table
-	affected := db.Statement.RowsAffected
-
-	if logger, ok := db.Logger.(interface{ Infof(string, ...interface{}) }); ok {
-		logger.Infof("Updated %d record(s) in %s", affected, tableName)
-	}
-}
-
-func afterDeleteAudit(db *gorm.DB) {
-	// Log deletion
-	tableName := db.Statement.Table
-	affected := db.Statement.RowsAffected
-
-	if logger, ok := db.Logger.(interface{ Infof(string, ...interface{}) }); ok {
-		logger.Infof("Deleted %d record(s) from %s", affected, tableName)
-	}
-}
-
 // Tenant isolation callback functions
 func beforeQueryTenant(db *gorm.DB) {
 	ctx := db.Statement.Context
@@ -347,16 +322,24 @@ func NewAuditManager(db *gorm.DB, logger *logrus.Logger) *AuditManager {
 	}
 }
 
-// LogChange logs an audit trail entry
+// LogChange appends an audit trail entry for a change made outside GORM's
+// create, update and delete callbacks, e.g. a raw SQL statement. Pass a
+// transaction as the manager's db to write it together with the change.
 func (am *AuditManager) LogChange(ctx context.Context, operation, tableName string, recordID interface{}, changes map[string]interface{}) error {
-	// This would log to an audit table
-	am.logger.WithFields(logrus.Fields{
-		"operation": operation,
-		"table":     tableName,
-		"record_id": recordID,
-		"changes":   changes,
-		"tenant_id": ctx.Value("tenant_id"),
-	}).Info("Database operation audit log")
+	entry := newAuditLog(ctx, operation, tableName, JSONB{"id": recordID})
+	if changes != nil {
+		entry.Changes = JSONB(changes)
+	}
+
+	err := am.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).Create(entry).Error
+	if err != nil {
+		am.logger.WithError(err).WithFields(logrus.Fields{
+			"operation": operation,
+			"table":     tableName,
+			"record_id": recordID,
+		}).Error("Failed to write audit log")
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
 
 	return nil
 }
@@ -396,64 +379,3 @@ func getLoggerFromDB(db *gorm.DB) *logrus.Logger {
 	// Return default logger if available
 	return logrus.StandardLogger()
 }
-
-// createRLSPolicies creates Row Level Security policies for tenant isolation
-// This should be called during database migration/setup
-func createRLSPolicies(db *gorm.DB, tables []string) error {
-
-	for _, table := range tables {
-		// Enable RLS on the table
-		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", table)).Error; err != nil {
-			return fmt.Errorf("failed to enable RLS on table %s: %w", table, err)
-		}
-
-		// Create tenant isolation policy
-		policyName := fmt.Sprintf("%s_tenant_isolation_policy", table)
-		policySQL := fmt.Sprintf(`
-			CREATE POLICY %s ON %s
-			FOR ALL
-			TO application_role
-			USING (tenant_id = current_setting('app.current_tenant_id')::UUID)
-			WITH CHECK (tenant_id = current_setting('app.current_tenant_id')::UUID)
-		`, policyName, table)
-
-		if err := db.Exec(policySQL).Error; err != nil {
-			// Policy might already exist, which is okay
-			if !contains(err.Error(), "already exists") {
-				return fmt.Errorf("failed to create RLS policy for table %s: %w", table, err)
-			}
-		}
-
-		if logger := getLoggerFromDB(db); logger != nil {
-			logger.WithFields(logrus.Fields{
-				"table": table,
-				"policy": policyName,
-			}).Info("RLS policy created for tenant isolation")
-		}
-	}
-
-	return nil
-}
-
-// setTenantContext sets the PostgreSQL session variable for tenant context
-func SetTenantContext(db *gorm.DB, tenantID string) error {
-	if !isValidUUID(tenantID) {
-		return fmt.Errorf("invalid tenant ID format")
-	}
-
-	// Set the session variable for RLS policies
-	sql := fmt.Sprintf("SET app.current_tenant_id = '%s'", tenantID)
-	if err := db.Exec(sql).Error; err != nil {
-		return fmt.Errorf("failed to set tenant context: %w", err)
-	}
-
-	return nil
-}
-
-// clearTenantContext clears the PostgreSQL session variable
-func ClearTenantContext(db *gorm.DB) error {
-	if err := db.Exec("RESET app.current_tenant_id").Error; err != nil {
-		return fmt.Errorf("failed to clear tenant context: %w", err)
-	}
-	return nil
-}
\ No newline at end of file
@@ -18,6 +18,7 @@ import (
 	gormlogger "gorm.io/gorm/logger"
 
 	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
 )
 
 // ConnectionMetrics holds connection pool metrics
@@ -46,6 +47,8 @@ type Database struct {
 	healthCheckTicker *time.Ticker
 	reconnecting      int32
 	closed            int32
+	tenancy           *TenantRouter
+	resolver          *ReadResolver
 }
 
 // NewDatabase creates a new database connection with optimized pooling
@@ -58,6 +61,9 @@ func NewDatabase(cfg *config.DatabaseConfig, logger *logrus.Logger) (*Database,
 		cfg.Name,
 		cfg.SSLMode,
 	)
+	if cfg.SearchPath != "" {
+		dsn += " search_path=" + cfg.SearchPath
+	}
 
 	// Configure GORM logger
 	var logLevel gormlogger.LogLevel
@@ -121,6 +127,10 @@ func NewDatabase(cfg *config.DatabaseConfig, logger *logrus.Logger) (*Database,
 		},
 	}
 
+	if err := database.enableReadResolver(); err != nil {
+		return nil, err
+	}
+
 	// Start health monitoring
 	if err := database.startHealthMonitoring(); err != nil {
 		logger.WithError(err).Warn("Failed to start health monitoring")
@@ -129,6 +139,20 @@ func NewDatabase(cfg *config.DatabaseConfig, logger *logrus.Logger) (*Database,
 	return database, nil
 }
 
+// enableReadResolver sends the reads of d.DB to its replicas
+func (d *Database) enableReadResolver() error {
+	d.resolver = NewReadResolver(d.Config.MaxReplicaLag, d.Config.ReadYourWritesWindow, d.Logger)
+	if err := d.DB.Use(d.resolver); err != nil {
+		return fmt.Errorf("failed to register read resolver: %w", err)
+	}
+	return nil
+}
+
+// Resolver returns the read resolver, which is nil for replicas
+func (d *Database) Resolver() *ReadResolver {
+	return d.resolver
+}
+
 // configureConnectionPool sets up the database connection pool with optimal settings
 func configureConnectionPool(sqlDB *sql.DB, cfg *config.DatabaseConfig, logger *logrus.Logger) error {
 	// Set maximum number of open connections
@@ -205,11 +229,20 @@ func (d *Database) BeginTx(opts *sql.TxOptions) (*gorm.DB, error) {
 	return d.DB.Begin(opts), nil
 }
 
-// GetTenantDB returns a database connection scoped to the specified tenant
-func (d *Database) GetTenantDB(tenantID string) (*gorm.DB, error) {
-	// Implement tenant isolation by setting search_path or schema
-	// This is a placeholder for tenant-specific database configuration
-	return d.DB.WithContext(context.WithValue(context.Background(), "tenant_id", tenantID)), nil
+// UseTenancy routes GetTenantDB through the tenant router
+func (d *Database) UseTenancy(router *TenantRouter) {
+	d.tenancy = router
+}
+
+// GetTenantDB returns a database connection scoped to the specified tenant.
+// With a tenant router it is the schema or database of the tenant. Release
+// must be called once the connection is no longer used.
+func (d *Database) GetTenantDB(tenantID string) (db *gorm.DB, release func(), err error) {
+	ctx := logger.WithTenantID(context.Background(), tenantID)
+	if d.tenancy == nil {
+		return d.DB.WithContext(context.WithValue(ctx, "tenant_id", tenantID)), func() {}, nil
+	}
+	return d.tenancy.DB(ctx)
 }
 
 // startHealthMonitoring begins periodic health checks
@@ -263,14 +296,9 @@ func (d *Database) performHealthCheck() error {
 		}).Warn("Connection pool experiencing delays")
 	}
 
-	// Check read replicas if configured
-	for i, replica := range d.ReadReplicas {
-		if err := replica.PingContext(ctx); err != nil {
-			d.Logger.WithFields(logrus.Fields{
-				"replica_id": i,
-				"error":      err,
-			}).Error("Read replica health check failed")
-		}
+	// Check read replicas and their lag; lagging ones leave the rotation
+	if d.resolver != nil {
+		d.resolver.Check(ctx)
 	}
 
 	atomic.StoreInt32(&d.metrics.HealthCheckFailures, 0)
@@ -355,7 +383,8 @@ func (d *Database) AddReadReplica(cfg *config.DatabaseConfig) error {
 		cfg.SSLMode,
 	)
 
-	db, err := sql.Open("postgres", dsn)
+	// pgx is the driver the GORM PostgreSQL dialector registers
+	db, err := sql.Open("pgx", dsn)
 	if err != nil {
 		return fmt.Errorf("failed to connect to read replica: %w", err)
 	}
@@ -369,6 +398,9 @@ func (d *Database) AddReadReplica(cfg *config.DatabaseConfig) error {
 	d.mu.Lock()
 	d.ReadReplicas = append(d.ReadReplicas, db)
 	d.mu.Unlock()
+	if d.resolver != nil {
+		d.resolver.AddReplica(fmt.Sprintf("%s:%d", cfg.Host, cfg.Port), db)
+	}
 
 	d.Logger.WithFields(logrus.Fields{
 		"host": cfg.Host,
@@ -378,18 +410,15 @@ func (d *Database) AddReadReplica(cfg *config.DatabaseConfig) error {
 	return nil
 }
 
-// GetReadReplica returns a read replica connection using round-robin
+// GetReadReplica returns a read replica in rotation using round-robin, or
+// the primary when none is
 func (d *Database) GetReadReplica() *sql.DB {
-	d.mu.RLock()
-	defer d.mu.RUnlock()
-
-	if len(d.ReadReplicas) == 0 {
-		return d.SQLDB
+	if d.resolver != nil {
+		if replica := d.resolver.Replica(); replica != nil {
+			return replica
+		}
 	}
-
-	// Simple round-robin selection
-	index := int(atomic.AddInt64(&d.metrics.WaitCount, 1)) % len(d.ReadReplicas)
-	return d.ReadReplicas[index]
+	return d.SQLDB
 }
 
 // Close gracefully closes all database connections
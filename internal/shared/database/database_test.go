@@ -242,9 +242,10 @@ func TestDatabase_GetTenantDB(t *testing.T) {
 		}
 		defer db.Close()
 
-		tenantDB, err := db.GetTenantDB("tenant-123")
+		tenantDB, release, err := db.GetTenantDB("tenant-123")
 		assert.NoError(t, err)
 		assert.NotNil(t, tenantDB)
+		release()
 	})
 }
 
@@ -54,7 +54,7 @@ func NewGORMManager(db *gorm.DB, cfg *GORMConfig) *GORMManager {
 // setupGORMCallbacks configures GORM callbacks for enhanced functionality
 func setupGORMCallbacks(db *gorm.DB, cfg *GORMConfig) {
 	// Register callbacks for audit trail
-	registerAuditCallbacks(db)
+	registerAuditCallbacks(db, cfg.Logger)
 
 	// Register callbacks for tenant isolation
 	registerTenantCallbacks(db)
@@ -64,7 +64,7 @@ func setupGORMCallbacks(db *gorm.DB, cfg *GORMConfig) {
 }
 
 // registerAuditCallbacks registers callbacks for audit trail functionality
-func registerAuditCallbacks(db *gorm.DB) {
+func registerAuditCallbacks(db *gorm.DB, logger *logrus.Logger) {
 	// Before create callback
 	db.Callback().Create().Before("gorm:create").Register("audit:before_create", beforeCreateAudit)
 
@@ -74,10 +74,13 @@ func registerAuditCallbacks(db *gorm.DB) {
 	// Before delete callback
 	db.Callback().Delete().Before("gorm:delete").Register("audit:before_delete", beforeDeleteAudit)
 
-	// After create/update/delete callbacks for logging
-	db.Callback().Create().After("gorm:create").Register("audit:after_create", afterCreateAudit)
-	db.Callback().Update().After("gorm:update").Register("audit:after_update", afterUpdateAudit)
-	db.Callback().Delete().After("gorm:delete").Register("audit:after_delete", afterDeleteAudit)
+	// Record before and after values in audit_logs
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	if err := NewAuditor(logger).RegisterCallbacks(db); err != nil {
+		logger.WithError(err).Error("Failed to register audit trail callbacks")
+	}
 }
 
 // registerTenantCallbacks registers callbacks for multi-tenant isolation
@@ -215,9 +218,38 @@ func (r *Repository[T]) FindAll(ctx context.Context, conditions map[string]inter
 	return entities, err
 }
 
-// Update updates a record
+// Update updates a record. Updates of Versioned models increment the
+// version; when updates name the version they were based on under
+// VersionColumn, ErrStaleObject is returned if the record changed since.
 func (r *Repository[T]) Update(ctx context.Context, id interface{}, updates map[string]interface{}) error {
-	return r.db.WithContext(ctx).Model(new(T)).Where("id = ?", id).Updates(updates).Error
+	if _, ok := any(new(T)).(Versioned); !ok {
+		return r.db.WithContext(ctx).Model(new(T)).Where("id = ?", id).Updates(updates).Error
+	}
+
+	query := r.db.WithContext(ctx).Model(new(T)).Where("id = ?", id)
+	versioned := make(map[string]interface{}, len(updates)+1)
+	for key, value := range updates {
+		versioned[key] = value
+	}
+	expected, checked := versioned[VersionColumn]
+	if checked {
+		query = query.Where(VersionColumn+" = ?", expected)
+	}
+	versioned[VersionColumn] = gorm.Expr(VersionColumn + " + 1")
+
+	result := query.Updates(versioned)
+	if result.Error != nil || !checked || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	exists, err := r.Exists(ctx, map[string]interface{}{"id = ?": id})
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+	return fmt.Errorf("%T %v at version %v: %w", *new(T), id, expected, ErrStaleObject)
 }
 
 // Delete soft deletes a record
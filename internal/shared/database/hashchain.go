@@ -0,0 +1,338 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const chainBatchSize = 1000
+
+// ChainLink is embedded by append-only models whose rows are hash chained
+// per tenant. RowHash covers the row's payload, its sequence number and
+// the previous row's hash, so editing, removing or reordering a row breaks
+// every later link.
+type ChainLink struct {
+	ChainSeq int64  `gorm:"not null;default:0" json:"chain_seq"`
+	PrevHash string `gorm:"size:64" json:"prev_hash,omitempty"`
+	RowHash  string `gorm:"size:64" json:"row_hash,omitempty"`
+}
+
+// Link returns the chain link of the row
+func (l *ChainLink) Link() *ChainLink {
+	return l
+}
+
+// Chained is implemented by models that embed ChainLink
+type Chained interface {
+	Link() *ChainLink
+	// ChainTenantID selects the chain of the row; nil for platform rows
+	ChainTenantID() *uuid.UUID
+	// ChainPayload returns the content covered by the row hash. It must
+	// read the same after a round trip through the database.
+	ChainPayload() interface{}
+}
+
+// ChainHash computes the hash of a row from its payload, sequence number
+// and the previous row's hash
+func ChainHash(prevHash string, seq int64, payload interface{}) (string, error) {
+	content, err := canonicalJSON(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode chain payload: %w", err)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write([]byte("\n" + strconv.FormatInt(seq, 10) + "\n"))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ChainBreak describes the first row of a chain that does not verify
+type ChainBreak struct {
+	Seq    int64  `json:"seq"`
+	Reason string `json:"reason"`
+}
+
+// ChainReport is the outcome of walking one chain
+type ChainReport struct {
+	Table    string      `json:"table"`
+	TenantID *uuid.UUID  `json:"tenant_id,omitempty"`
+	Rows     int64       `json:"rows"`
+	FirstSeq int64       `json:"first_seq"`
+	HeadSeq  int64       `json:"head_seq"`
+	HeadHash string      `json:"head_hash,omitempty"`
+	Valid    bool        `json:"valid"`
+	Break    *ChainBreak `json:"break,omitempty"`
+}
+
+// HashChain links the rows of the registered models as they are created
+// and verifies the chains afterwards
+type HashChain struct {
+	logger *logrus.Logger
+	models map[string]reflect.Type
+	tables []string
+}
+
+// NewHashChain creates a hash chain over the tables of the given models
+func NewHashChain(logger *logrus.Logger, models ...Chained) *HashChain {
+	hc := &HashChain{logger: logger, models: make(map[string]reflect.Type)}
+	for _, model := range models {
+		modelType := reflect.Indirect(reflect.ValueOf(model)).Type()
+		table := tableName(model)
+		if _, exists := hc.models[table]; !exists {
+			hc.tables = append(hc.tables, table)
+		}
+		hc.models[table] = modelType
+	}
+	return hc
+}
+
+// Tables returns the chained tables
+func (hc *HashChain) Tables() []string {
+	return hc.tables
+}
+
+// RegisterCallbacks links every created row of a chained model to the head
+// of its tenant's chain. The head is read and extended inside the creating
+// transaction; on PostgreSQL concurrent writers to the same chain are
+// serialised with an advisory transaction lock.
+func (hc *HashChain) RegisterCallbacks(db *gorm.DB) error {
+	// gorm:before_create runs the model hooks inside the default transaction
+	err := db.Callback().Create().
+		After("gorm:before_create").
+		Before("gorm:create").
+		Register("hashchain:link", hc.link)
+	if err != nil {
+		return fmt.Errorf("failed to register hash chain callback: %w", err)
+	}
+	return nil
+}
+
+func (hc *HashChain) link(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	if _, ok := hc.models[stmt.Table]; !ok {
+		return
+	}
+
+	heads := make(map[string]*ChainLink)
+	eachRecord(stmt.ReflectValue, func(record reflect.Value) {
+		if db.Error != nil || !record.CanAddr() {
+			return
+		}
+		row, ok := record.Addr().Interface().(Chained)
+		if !ok {
+			return
+		}
+		if err := prepareChainedRow(stmt, record); err != nil {
+			db.AddError(err)
+			return
+		}
+
+		tenantID := row.ChainTenantID()
+		key := chainKey(tenantID)
+		head, ok := heads[key]
+		if !ok {
+			var err error
+			if head, err = hc.head(db, stmt.Table, tenantID); err != nil {
+				db.AddError(fmt.Errorf("failed to read chain head of %s: %w", stmt.Table, err))
+				return
+			}
+			heads[key] = head
+		}
+
+		link := row.Link()
+		link.ChainSeq = head.ChainSeq + 1
+		link.PrevHash = head.RowHash
+		hash, err := ChainHash(link.PrevHash, link.ChainSeq, row.ChainPayload())
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		link.RowHash = hash
+		*head = *link
+	})
+}
+
+// head locks the chain for the rest of the transaction and returns its
+// last link
+func (hc *HashChain) head(db *gorm.DB, table string, tenantID *uuid.UUID) (*ChainLink, error) {
+	tx := db.Session(&gorm.Session{NewDB: true, SkipHooks: true})
+	if db.Dialector.Name() == "postgres" {
+		lockKey := table + ":" + chainKey(tenantID)
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", lockKey).Error; err != nil {
+			return nil, fmt.Errorf("failed to lock chain: %w", err)
+		}
+	}
+
+	var head ChainLink
+	err := chainScope(tx.Table(table), tenantID).
+		Select("chain_seq", "prev_hash", "row_hash").
+		Where("chain_seq > 0").
+		Order("chain_seq DESC").
+		Limit(1).
+		Take(&head).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &head, nil
+}
+
+// Chains returns the tenants with a chain in table; nil stands for the
+// platform chain
+func (hc *HashChain) Chains(ctx context.Context, db *gorm.DB, table string) ([]*uuid.UUID, error) {
+	var tenantIDs []*uuid.UUID
+	err := db.WithContext(ctx).Table(table).
+		Where("chain_seq > 0").
+		Distinct("tenant_id").
+		Pluck("tenant_id", &tenantIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chains of %s: %w", table, err)
+	}
+	return tenantIDs, nil
+}
+
+// Verify walks the chain of a tenant in table and reports the first row
+// whose sequence, previous hash or content does not match. Retention may
+// prune a chain from its start, so the walk begins at the first retained
+// row; published anchors prove what came before.
+func (hc *HashChain) Verify(ctx context.Context, db *gorm.DB, table string, tenantID *uuid.UUID) (*ChainReport, error) {
+	modelType, ok := hc.models[table]
+	if !ok {
+		return nil, fmt.Errorf("table %s is not hash chained", table)
+	}
+
+	report := &ChainReport{Table: table, TenantID: tenantID, Valid: true}
+	var prev *ChainLink
+	for {
+		batch := reflect.New(reflect.SliceOf(reflect.PointerTo(modelType)))
+		query := chainScope(db.WithContext(ctx).Table(table), tenantID).Where("chain_seq > 0")
+		if prev != nil {
+			query = query.Where("chain_seq > ?", prev.ChainSeq)
+		}
+		if err := query.Order("chain_seq").Limit(chainBatchSize).Find(batch.Interface()).Error; err != nil {
+			return nil, fmt.Errorf("failed to read chain of %s: %w", table, err)
+		}
+
+		rows := batch.Elem()
+		for i := 0; i < rows.Len(); i++ {
+			row := rows.Index(i).Interface().(Chained)
+			link := *row.Link()
+			if prev == nil {
+				report.FirstSeq = link.ChainSeq
+			}
+			if reason := verifyLink(prev, &link, row.ChainPayload()); reason != "" {
+				report.Valid = false
+				report.Break = &ChainBreak{Seq: link.ChainSeq, Reason: reason}
+				return report, nil
+			}
+
+			report.Rows++
+			report.HeadSeq = link.ChainSeq
+			report.HeadHash = link.RowHash
+			prev = &link
+		}
+
+		if rows.Len() < chainBatchSize {
+			return report, nil
+		}
+	}
+}
+
+func verifyLink(prev, link *ChainLink, payload interface{}) string {
+	if prev != nil {
+		if link.ChainSeq != prev.ChainSeq+1 {
+			return fmt.Sprintf("expected sequence %d, found %d", prev.ChainSeq+1, link.ChainSeq)
+		}
+		if link.PrevHash != prev.RowHash {
+			return "previous hash does not match the preceding row"
+		}
+	}
+
+	hash, err := ChainHash(link.PrevHash, link.ChainSeq, payload)
+	if err != nil {
+		return err.Error()
+	}
+	if hash != link.RowHash {
+		return "row hash does not match its content"
+	}
+	return ""
+}
+
+// prepareChainedRow assigns the ID and creation time before the row is
+// hashed, since both are part of its payload. Times are truncated to the
+// microsecond precision of PostgreSQL.
+func prepareChainedRow(stmt *gorm.Statement, record reflect.Value) error {
+	if field := stmt.Schema.PrioritizedPrimaryField; field != nil {
+		if value, zero := field.ValueOf(stmt.Context, record); zero {
+			if _, isUUID := value.(uuid.UUID); isUUID {
+				if err := field.Set(stmt.Context, record, uuid.New()); err != nil {
+					return fmt.Errorf("failed to assign chained row ID: %w", err)
+				}
+			}
+		}
+	}
+
+	if field := stmt.Schema.LookUpField("created_at"); field != nil {
+		value, _ := field.ValueOf(stmt.Context, record)
+		if createdAt, ok := value.(time.Time); ok {
+			if createdAt.IsZero() {
+				createdAt = time.Now()
+			}
+			if err := field.Set(stmt.Context, record, createdAt.UTC().Truncate(time.Microsecond)); err != nil {
+				return fmt.Errorf("failed to set chained row creation time: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func chainScope(db *gorm.DB, tenantID *uuid.UUID) *gorm.DB {
+	if tenantID == nil {
+		return db.Where("tenant_id IS NULL")
+	}
+	return db.Where("tenant_id = ?", *tenantID)
+}
+
+func chainKey(tenantID *uuid.UUID) string {
+	if tenantID == nil {
+		return "platform"
+	}
+	return tenantID.String()
+}
+
+func tableName(model interface{}) string {
+	if tabler, ok := model.(interface{ TableName() string }); ok {
+		return tabler.TableName()
+	}
+	return schema.NamingStrategy{}.TableName(reflect.Indirect(reflect.ValueOf(model)).Type().Name())
+}
+
+// canonicalJSON encodes v with sorted keys after a JSON round trip, so a
+// payload built before insert and one read back from the database encode
+// identically
+func canonicalJSON(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalized)
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func newHashChainTestDB(t *testing.T) (*gorm.DB, *HashChain) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&AuditLog{}))
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	chain := NewHashChain(log, &AuditLog{})
+	require.NoError(t, chain.RegisterCallbacks(db))
+	return db, chain
+}
+
+func createChainedEntries(t *testing.T, db *gorm.DB, tenantID *uuid.UUID, n int) []AuditLog {
+	entries := make([]AuditLog, n)
+	for i := range entries {
+		entries[i] = AuditLog{
+			TenantID:  tenantID,
+			TableName: "widgets",
+			Action:    AuditActionInsert,
+			NewValues: JSONB{"quantity": float64(i)},
+		}
+		require.NoError(t, db.Create(&entries[i]).Error)
+	}
+	return entries
+}
+
+func TestHashChain_LinksRowsPerTenant(t *testing.T) {
+	db, chain := newHashChainTestDB(t)
+	ctx := context.Background()
+
+	tenantA := uuid.New()
+	tenantB := uuid.New()
+	a := createChainedEntries(t, db, &tenantA, 3)
+	b := createChainedEntries(t, db, &tenantB, 2)
+	platform := createChainedEntries(t, db, nil, 1)
+
+	assert.Equal(t, []int64{1, 2, 3}, []int64{a[0].ChainSeq, a[1].ChainSeq, a[2].ChainSeq})
+	assert.Empty(t, a[0].PrevHash)
+	assert.Equal(t, a[0].RowHash, a[1].PrevHash)
+	assert.Equal(t, a[1].RowHash, a[2].PrevHash)
+	assert.Equal(t, int64(1), b[0].ChainSeq)
+	assert.Equal(t, int64(1), platform[0].ChainSeq)
+
+	report, err := chain.Verify(ctx, db, "audit_logs", &tenantA)
+	require.NoError(t, err)
+	assert.True(t, report.Valid)
+	assert.Equal(t, int64(3), report.Rows)
+	assert.Equal(t, int64(3), report.HeadSeq)
+	assert.Equal(t, a[2].RowHash, report.HeadHash)
+
+	report, err = chain.Verify(ctx, db, "audit_logs", nil)
+	require.NoError(t, err)
+	assert.True(t, report.Valid)
+	assert.Equal(t, int64(1), report.Rows)
+
+	chains, err := chain.Chains(ctx, db, "audit_logs")
+	require.NoError(t, err)
+	assert.Len(t, chains, 3)
+}
+
+func TestHashChain_DetectsTampering(t *testing.T) {
+	db, chain := newHashChainTestDB(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	entries := createChainedEntries(t, db, &tenantID, 4)
+
+	require.NoError(t, db.Exec("UPDATE audit_logs SET action = ? WHERE id = ?", AuditActionDelete, entries[1].ID).Error)
+
+	report, err := chain.Verify(ctx, db, "audit_logs", &tenantID)
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	require.NotNil(t, report.Break)
+	assert.Equal(t, int64(2), report.Break.Seq)
+	assert.Equal(t, "row hash does not match its content", report.Break.Reason)
+	assert.Equal(t, int64(1), report.HeadSeq)
+}
+
+func TestHashChain_DetectsRemovedRows(t *testing.T) {
+	db, chain := newHashChainTestDB(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	entries := createChainedEntries(t, db, &tenantID, 4)
+
+	require.NoError(t, db.Exec("DELETE FROM audit_logs WHERE id = ?", entries[2].ID).Error)
+
+	report, err := chain.Verify(ctx, db, "audit_logs", &tenantID)
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	require.NotNil(t, report.Break)
+	assert.Equal(t, int64(4), report.Break.Seq)
+	assert.Contains(t, report.Break.Reason, "expected sequence 3")
+
+	// Pruning the oldest rows, as retention does, keeps the chain valid
+	require.NoError(t, db.Exec("DELETE FROM audit_logs WHERE chain_seq > 2").Error)
+	require.NoError(t, db.Exec("DELETE FROM audit_logs WHERE id = ?", entries[0].ID).Error)
+	report, err = chain.Verify(ctx, db, "audit_logs", &tenantID)
+	require.NoError(t, err)
+	assert.True(t, report.Valid)
+	assert.Equal(t, int64(2), report.FirstSeq)
+}
@@ -194,19 +194,35 @@ func (hc *HealthChecker) checkDatabaseReplicas(ctx context.Context, multiDB *Mul
 		}
 	}
 
+	// Measure the lag of the replicas; this also updates the read rotation
+	statuses := make(map[string]ReplicaStatus)
+	if master, err := multiDB.GetMaster(); err == nil && master.Resolver() != nil {
+		for _, status := range master.Resolver().Check(ctx) {
+			statuses[status.Name] = status
+		}
+	}
+
 	var healthyReplicas int
 	var totalReplicas int
 	var messages []string
 	details := make(map[string]interface{})
+	lags := make(map[string]float64)
 
 	for name, db := range replicas {
 		totalReplicas++
+		status, measured := statuses[name]
+		if measured {
+			lags[name] = status.Lag.Seconds()
+		}
 		if err := db.HealthCheck(); err != nil {
 			messages = append(messages, fmt.Sprintf("%s: %v", name, err))
+		} else if measured && !status.InRotation {
+			messages = append(messages, fmt.Sprintf("%s: lag %s, out of read rotation", name, status.Lag.Round(time.Millisecond)))
 		} else {
 			healthyReplicas++
 		}
 	}
+	details["replica_lag_seconds"] = lags
 
 	details["total_replicas"] = totalReplicas
 	details["healthy_replicas"] = healthyReplicas
@@ -0,0 +1,273 @@
+package database
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// SortField is a field the lists of a model may be sorted by
+type SortField struct {
+	// Name is the value of the sort_by parameter
+	Name string
+	// Column must be NOT NULL and should be indexed together with id
+	Column string
+	// Desc sorts descending unless sort_dir says otherwise
+	Desc bool
+}
+
+// Sortable is implemented by models that declare the fields their lists may
+// be sorted by. The first field is the default.
+type Sortable interface {
+	SortFields() []SortField
+}
+
+// lookupSortField returns the declared sort field named name, or the
+// default for an empty name
+func lookupSortField(model Sortable, name string) (SortField, bool) {
+	fields := model.SortFields()
+	if len(fields) == 0 {
+		return SortField{}, false
+	}
+	if name == "" {
+		return fields[0], true
+	}
+	for _, field := range fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return SortField{}, false
+}
+
+func sortFieldNames(model Sortable) []string {
+	fields := model.SortFields()
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// CursorPagination represents keyset pagination parameters. Unlike OFFSET
+// pagination, the cost of a page does not grow with its depth.
+type CursorPagination struct {
+	// Cursor is the next_cursor of the previous page, empty for the first
+	Cursor  string `json:"cursor" form:"cursor"`
+	Limit   int    `json:"limit" form:"limit"`
+	SortBy  string `json:"sort_by" form:"sort_by"`
+	SortDir string `json:"sort_dir" form:"sort_dir"`
+}
+
+// PageInfo is the pagination metadata of list responses
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	Limit      int    `json:"limit"`
+}
+
+// Cursors signs the cursor tokens of keyset pagination. Tokens are opaque
+// to clients, and a token that was altered, or issued for another sort, is
+// rejected.
+type Cursors struct {
+	key []byte
+}
+
+// NewCursors creates cursors signed with secret
+func NewCursors(secret string) *Cursors {
+	return &Cursors{key: []byte(secret)}
+}
+
+// cursor is the position after the last row of a page
+type cursor struct {
+	SortBy string      `json:"s"`
+	Desc   bool        `json:"d"`
+	Value  interface{} `json:"v"`
+	Time   *time.Time  `json:"t,omitempty"`
+	ID     interface{} `json:"i"`
+}
+
+// value returns the sort key with its Go type restored
+func (c *cursor) value() interface{} {
+	if c.Time != nil {
+		return *c.Time
+	}
+	if number, ok := c.Value.(json.Number); ok {
+		if i, err := number.Int64(); err == nil {
+			return i
+		}
+		f, _ := number.Float64()
+		return f
+	}
+	return c.Value
+}
+
+func (cs *Cursors) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, cs.key)
+	mac.Write([]byte("cursor:"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func (cs *Cursors) encode(c *cursor) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(cs.sign(payload)), nil
+}
+
+func (cs *Cursors) decode(token string) (*cursor, error) {
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+	if !hmac.Equal(signature, cs.sign(payload)) {
+		return nil, fmt.Errorf("cursor signature mismatch")
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(payload)))
+	decoder.UseNumber()
+	var c cursor
+	if err := decoder.Decode(&c); err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+	return &c, nil
+}
+
+// Keyset is the sort and limit of a page that ApplyKeyset applied
+type Keyset struct {
+	cursors *Cursors
+	field   SortField
+	desc    bool
+	limit   int
+}
+
+// ApplyKeyset orders the query by a sort field model declares and the ID,
+// and continues after the cursor of page. It fetches one row more than the
+// limit, which tells FindPage whether more rows follow. Invalid parameters
+// are returned as ValidationErrors.
+func (qb *QueryBuilder) ApplyKeyset(cursors *Cursors, model Sortable, page CursorPagination) (*gorm.DB, *Keyset, error) {
+	validator := NewValidator()
+	validator.OneOf("sort_by", page.SortBy, sortFieldNames(model))
+	validator.OneOf("sort_dir", strings.ToLower(page.SortDir), []string{"asc", "desc"})
+	if err := validator.ToError(); err != nil {
+		return nil, nil, err
+	}
+	field, ok := lookupSortField(model, page.SortBy)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T declares no sort fields", model)
+	}
+
+	keyset := &Keyset{cursors: cursors, field: field, desc: field.Desc, limit: page.Limit}
+	if page.SortDir != "" {
+		keyset.desc = strings.EqualFold(page.SortDir, "desc")
+	}
+	if keyset.limit <= 0 {
+		keyset.limit = DefaultPageSize
+	}
+	if keyset.limit > MaxPageSize {
+		keyset.limit = MaxPageSize
+	}
+
+	column := clause.Column{Table: clause.CurrentTable, Name: field.Column}
+	id := clause.Column{Table: clause.CurrentTable, Name: "id"}
+	query := qb.db.Order(clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: column, Desc: keyset.desc},
+		{Column: id, Desc: keyset.desc},
+	}}).Limit(keyset.limit + 1)
+
+	if page.Cursor != "" {
+		after, err := cursors.decode(page.Cursor)
+		if err == nil && (after.SortBy != field.Name || after.Desc != keyset.desc) {
+			err = fmt.Errorf("cursor belongs to another sort")
+		}
+		if err != nil {
+			return nil, nil, ValidationError{Field: "cursor", Rule: "invalid", Message: err.Error()}
+		}
+
+		operator := ">"
+		if keyset.desc {
+			operator = "<"
+		}
+		query = query.Where(clause.Expr{
+			SQL:  fmt.Sprintf("(?, ?) %s (?, ?)", operator),
+			Vars: []interface{}{column, id, after.value(), after.ID},
+		})
+	}
+	return query, keyset, nil
+}
+
+// next returns the cursor after row
+func (k *Keyset) next(db *gorm.DB, row interface{}) (string, error) {
+	rowSchema, err := schema.Parse(row, &sync.Map{}, db.NamingStrategy)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %T: %w", row, err)
+	}
+	sortField := rowSchema.LookUpField(k.field.Column)
+	idField := rowSchema.LookUpField("id")
+	if sortField == nil || idField == nil {
+		return "", fmt.Errorf("%T has no %s or id column", row, k.field.Column)
+	}
+
+	value := reflect.ValueOf(row)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	ctx := db.Statement.Context
+	sortValue, _ := sortField.ValueOf(ctx, value)
+	idValue, _ := idField.ValueOf(ctx, value)
+
+	after := &cursor{SortBy: k.field.Name, Desc: k.desc, ID: idValue}
+	if t, ok := sortValue.(time.Time); ok {
+		after.Time = &t
+	} else {
+		after.Value = sortValue
+	}
+	return k.cursors.encode(after)
+}
+
+// FindPage finds one page of T in the order page asks for, e.g.
+//
+//	products, info, err := database.FindPage[model.Product](db.Conn(ctx).Where("warehouse_id = ?", id), cursors, page)
+func FindPage[T Sortable](query *gorm.DB, cursors *Cursors, page CursorPagination) ([]*T, PageInfo, error) {
+	var model T
+	keyed, keyset, err := NewQueryBuilder(query).ApplyKeyset(cursors, model, page)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	var rows []*T
+	if err := keyed.Find(&rows).Error; err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	info := PageInfo{Limit: keyset.limit}
+	if len(rows) > keyset.limit {
+		rows = rows[:keyset.limit]
+		info.HasMore = true
+		if info.NextCursor, err = keyset.next(query, rows[len(rows)-1]); err != nil {
+			return nil, PageInfo{}, err
+		}
+	}
+	return rows, info, nil
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type pagedOrder struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Number    string
+	Total     int64
+	CreatedAt time.Time
+}
+
+func (pagedOrder) SortFields() []SortField {
+	return []SortField{
+		{Name: "created_at", Column: "created_at", Desc: true},
+		{Name: "total", Column: "total"},
+	}
+}
+
+// newPagedOrders creates 25 orders, several of which share a creation time
+// and a total, so pages must break ties by ID
+func newPagedOrders(t *testing.T) (*gorm.DB, []pagedOrder) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, db.AutoMigrate(&pagedOrder{}))
+
+	start := time.Now().UTC().Truncate(time.Second)
+	orders := make([]pagedOrder, 25)
+	for i := range orders {
+		orders[i] = pagedOrder{
+			ID:        uuid.New(),
+			Number:    fmt.Sprintf("SO-%02d", i),
+			Total:     int64(i % 4 * 1000),
+			CreatedAt: start.Add(time.Duration(i/3) * time.Minute),
+		}
+	}
+	require.NoError(t, db.Create(&orders).Error)
+	return db, orders
+}
+
+func walkPages(t *testing.T, db *gorm.DB, cursors *Cursors, page CursorPagination) []pagedOrder {
+	var seen []pagedOrder
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10, "pagination does not terminate")
+		rows, info, err := FindPage[pagedOrder](db.Model(&pagedOrder{}), cursors, page)
+		require.NoError(t, err)
+		assert.Equal(t, page.Limit, info.Limit)
+		for _, row := range rows {
+			seen = append(seen, *row)
+		}
+		if !info.HasMore {
+			assert.Empty(t, info.NextCursor)
+			return seen
+		}
+		require.Len(t, rows, page.Limit)
+		page.Cursor = info.NextCursor
+	}
+}
+
+func TestFindPage(t *testing.T) {
+	db, orders := newPagedOrders(t)
+	cursors := NewCursors("secret")
+
+	t.Run("DefaultSort", func(t *testing.T) {
+		expected := append([]pagedOrder(nil), orders...)
+		sort.Slice(expected, func(i, j int) bool {
+			if !expected[i].CreatedAt.Equal(expected[j].CreatedAt) {
+				return expected[i].CreatedAt.After(expected[j].CreatedAt)
+			}
+			return expected[i].ID.String() > expected[j].ID.String()
+		})
+
+		seen := walkPages(t, db, cursors, CursorPagination{Limit: 10})
+		require.Len(t, seen, len(orders))
+		for i := range expected {
+			assert.Equal(t, expected[i].Number, seen[i].Number)
+		}
+	})
+
+	t.Run("NumericSort", func(t *testing.T) {
+		seen := walkPages(t, db, cursors, CursorPagination{Limit: 7, SortBy: "total", SortDir: "ASC"})
+		require.Len(t, seen, len(orders))
+		numbers := map[string]bool{}
+		for i, order := range seen {
+			numbers[order.Number] = true
+			if i > 0 {
+				assert.LessOrEqual(t, seen[i-1].Total, order.Total)
+			}
+		}
+		assert.Len(t, numbers, len(orders))
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		_, _, err := FindPage[pagedOrder](db, cursors, CursorPagination{SortBy: "number; DROP TABLE paged_orders"})
+		var validationErrs ValidationErrors
+		require.True(t, errors.As(err, &validationErrs))
+		assert.Equal(t, "sort_by", validationErrs[0].Field)
+
+		_, info, err := FindPage[pagedOrder](db, cursors, CursorPagination{Limit: 5})
+		require.NoError(t, err)
+		payload, signature, _ := strings.Cut(info.NextCursor, ".")
+
+		cursorErr := func(token string, page CursorPagination) ValidationError {
+			page.Cursor = token
+			_, _, err := FindPage[pagedOrder](db, cursors, page)
+			var validationErr ValidationError
+			require.True(t, errors.As(err, &validationErr), token)
+			return validationErr
+		}
+		assert.Equal(t, "cursor", cursorErr(payload+"x."+signature, CursorPagination{}).Field)
+		assert.Equal(t, "cursor", cursorErr("not-a-cursor", CursorPagination{}).Field)
+		assert.Equal(t, "cursor", cursorErr(info.NextCursor, CursorPagination{SortBy: "total"}).Field)
+		assert.Equal(t, "cursor", cursorErr(info.NextCursor, CursorPagination{SortDir: "asc"}).Field)
+
+		// Cursors signed with another secret are rejected
+		_, _, err = FindPage[pagedOrder](db, NewCursors("other"), CursorPagination{Cursor: info.NextCursor})
+		assert.Error(t, err)
+	})
+}
+
+func TestApplyPagination_SortFields(t *testing.T) {
+	db, _ := newPagedOrders(t)
+
+	var orders []pagedOrder
+	query := NewQueryBuilder(db.Model(&pagedOrder{})).ApplyPagination(Pagination{PageSize: 5, SortBy: "total", SortDir: "desc"})
+	require.NoError(t, query.Find(&orders).Error)
+	require.Len(t, orders, 5)
+	assert.Equal(t, int64(3000), orders[0].Total)
+
+	// Undeclared fields are ignored, even harmless ones
+	stmt := NewQueryBuilder(db.Model(&pagedOrder{})).ApplyPagination(Pagination{SortBy: "number"}).
+		Session(&gorm.Session{DryRun: true}).Find(&orders).Statement
+	assert.NotContains(t, stmt.SQL.String(), "ORDER BY")
+}
@@ -3,10 +3,13 @@ package database
 import (
 	"context"
 	"crypto/sha256"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -87,6 +90,12 @@ func (mm *MigrationManager) Initialize(ctx context.Context) error {
 	return nil
 }
 
+// LoadFiles scans the migrations directory without connecting to the
+// database, e.g. to pick the version of a new migration
+func (mm *MigrationManager) LoadFiles() error {
+	return mm.scanMigrationFiles()
+}
+
 // loadAppliedMigrations loads migrations that have already been applied
 func (mm *MigrationManager) loadAppliedMigrations(ctx context.Context) error {
 	var migrations []Migration
@@ -147,8 +156,9 @@ func (mm *MigrationManager) scanMigrationSubdirectory(subdir string) error {
 func (mm *MigrationManager) processMigrationFile(filePath string) error {
 	filename := filepath.Base(filePath)
 
-	// Check if it's an up migration file
-	if !strings.HasSuffix(filename, ".up.sql") {
+	// Up migrations have an optional .down.sql rollback; other .sql files
+	// are forward-only
+	if !strings.HasSuffix(filename, ".sql") || strings.HasSuffix(filename, ".down.sql") {
 		return nil
 	}
 
@@ -170,12 +180,14 @@ func (mm *MigrationManager) processMigrationFile(filePath string) error {
 	}
 
 	// Read down migration file
-	downFilePath := strings.Replace(filePath, ".up.sql", ".down.sql", 1)
 	var downSQL []byte
-	if _, err := os.Stat(downFilePath); err == nil {
-		downSQL, err = os.ReadFile(downFilePath)
-		if err != nil {
-			return fmt.Errorf("failed to read down migration file %s: %w", downFilePath, err)
+	if strings.HasSuffix(filename, ".up.sql") {
+		downFilePath := strings.TrimSuffix(filePath, ".up.sql") + ".down.sql"
+		if _, err := os.Stat(downFilePath); err == nil {
+			downSQL, err = os.ReadFile(downFilePath)
+			if err != nil {
+				return fmt.Errorf("failed to read down migration file %s: %w", downFilePath, err)
+			}
 		}
 	}
 
@@ -200,10 +212,15 @@ func (mm *MigrationManager) processMigrationFile(filePath string) error {
 	return nil
 }
 
-// parseMigrationFilename extracts version and description from filename
+// parseMigrationFilename extracts version and description from filename.
+// Forward-only migrations (version_description.sql, as run by
+// docker-entrypoint-initdb.d) are identified by their whole name because
+// several of them share a number.
 func (mm *MigrationManager) parseMigrationFilename(filename string) (string, string, error) {
+	forwardOnly := !strings.HasSuffix(filename, ".up.sql")
+
 	// Remove .up.sql extension
-	name := strings.TrimSuffix(filename, ".up.sql")
+	name := strings.TrimSuffix(strings.TrimSuffix(filename, ".up.sql"), ".sql")
 
 	// Split by underscore to extract version
 	parts := strings.SplitN(name, "_", 2)
@@ -212,6 +229,9 @@ func (mm *MigrationManager) parseMigrationFilename(filename string) (string, str
 	}
 
 	version := parts[0]
+	if forwardOnly {
+		version = name
+	}
 	description := strings.ReplaceAll(parts[1], "_", " ")
 
 	return version, description, nil
@@ -539,4 +559,225 @@ type MigrationStatus struct {
 	PendingMigrations int            `json:"pending_migrations"`
 	Applied          []*Migration   `json:"applied"`
 	Pending          []*MigrationFile `json:"pending"`
-}
\ No newline at end of file
+}
+
+// migrationLockKey names the advisory lock held while migrating
+const migrationLockKey = "rexi_erp.migrations"
+
+// ErrMigrationDrift is returned when applied migrations no longer match
+// their files
+var ErrMigrationDrift = errors.New("applied migrations do not match their files")
+
+// MigrationDrift describes an applied migration whose file was edited or
+// removed after it was applied
+type MigrationDrift struct {
+	Version         string `json:"version"`
+	Reason          string `json:"reason"`
+	AppliedChecksum string `json:"applied_checksum"`
+	CurrentChecksum string `json:"current_checksum,omitempty"`
+}
+
+// Lock takes a PostgreSQL advisory lock so that only one process migrates
+// at a time, e.g. replicas migrating as they start. The lock is held on a
+// dedicated connection until the returned function is called. Other
+// databases are not locked.
+func (mm *MigrationManager) Lock(ctx context.Context) (func(), error) {
+	if mm.db.Dialector.Name() != "postgres" {
+		return func() {}, nil
+	}
+
+	sqlDB, err := mm.db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtextextended($1, 0))", migrationLockKey).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if !locked {
+		mm.logger.Info("Waiting for another process to finish migrating")
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtextextended($1, 0))", migrationLockKey); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtextextended($1, 0))", migrationLockKey); err != nil {
+			mm.logger.WithError(err).Warn("Failed to release migration lock")
+			// Discard the connection so the lock is not returned to the pool
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
+
+// Verify compares the checksums recorded for applied migrations with their
+// files and reports the applied migrations that were edited or removed
+func (mm *MigrationManager) Verify(ctx context.Context) ([]MigrationDrift, error) {
+	var applied []Migration
+	if err := mm.db.WithContext(ctx).Where("applied = ?", true).Order("version").Find(&applied).Error; err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+
+	var drift []MigrationDrift
+	for _, migration := range applied {
+		file, exists := mm.migrations[migration.Version]
+		switch {
+		case !exists:
+			drift = append(drift, MigrationDrift{
+				Version:         migration.Version,
+				Reason:          "migration file is missing",
+				AppliedChecksum: migration.Checksum,
+			})
+		case file.Checksum != migration.Checksum:
+			drift = append(drift, MigrationDrift{
+				Version:         migration.Version,
+				Reason:          "migration file was modified after it was applied",
+				AppliedChecksum: migration.Checksum,
+				CurrentChecksum: file.Checksum,
+			})
+		}
+	}
+
+	return drift, nil
+}
+
+// RollbackPlan returns the applied migrations newer than targetVersion in
+// the order MigrateDownTo rolls them back, newest first. Without a target
+// only the last applied migration is rolled back.
+func (mm *MigrationManager) RollbackPlan(targetVersion string) ([]*MigrationFile, error) {
+	_, exists := mm.migrations[targetVersion]
+	if targetVersion != "" && !exists && !mm.appliedVersions[targetVersion] {
+		return nil, fmt.Errorf("migration %s not found", targetVersion)
+	}
+
+	var plan []*MigrationFile
+	for _, version := range mm.appliedVersionsNewestFirst() {
+		if version <= targetVersion || (targetVersion == "" && len(plan) == 1) {
+			break
+		}
+		migration, exists := mm.migrations[version]
+		if !exists {
+			return nil, fmt.Errorf("migration %s not found", version)
+		}
+		if migration.DownSQL == "" {
+			return nil, fmt.Errorf("migration %s does not have a rollback script", version)
+		}
+		plan = append(plan, migration)
+	}
+
+	return plan, nil
+}
+
+// MigrateDownTo rolls back every applied migration newer than targetVersion,
+// or the last applied migration without a target
+func (mm *MigrationManager) MigrateDownTo(ctx context.Context, targetVersion string) error {
+	plan, err := mm.RollbackPlan(targetVersion)
+	if err != nil {
+		return err
+	}
+
+	if len(plan) == 0 {
+		mm.logger.WithField("version", targetVersion).Info("No migrations to rollback")
+		return nil
+	}
+
+	for _, migration := range plan {
+		if err := mm.rollbackMigration(ctx, migration); err != nil {
+			return fmt.Errorf("failed to rollback migration %s: %w", migration.Version, err)
+		}
+	}
+
+	return nil
+}
+
+// Redo rolls back the last applied migration and applies it again
+func (mm *MigrationManager) Redo(ctx context.Context) error {
+	applied := mm.appliedVersionsNewestFirst()
+	if len(applied) == 0 {
+		return fmt.Errorf("no migrations have been applied")
+	}
+
+	migration, exists := mm.migrations[applied[0]]
+	if !exists {
+		return fmt.Errorf("migration %s not found", applied[0])
+	}
+
+	if err := mm.MigrateDown(ctx, migration.Version); err != nil {
+		return err
+	}
+
+	if err := mm.applyMigration(ctx, migration); err != nil {
+		return fmt.Errorf("failed to apply migration %s: %w", migration.Version, err)
+	}
+
+	return nil
+}
+
+// Baseline records the pending migrations up to and including version as
+// applied without running them, for databases created before migrations
+// were tracked, e.g. by docker-entrypoint-initdb.d
+func (mm *MigrationManager) Baseline(ctx context.Context, version string) error {
+	if _, exists := mm.migrations[version]; !exists {
+		return fmt.Errorf("migration %s not found", version)
+	}
+
+	pending, err := mm.GetPendingMigrations()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, migration := range pending {
+		if migration.Version > version {
+			break
+		}
+
+		record := Migration{
+			Version:      migration.Version,
+			Description:  migration.Description,
+			Applied:      true,
+			AppliedAt:    &now,
+			Checksum:     migration.Checksum,
+			Dependencies: migration.Dependencies,
+			Metadata:     map[string]interface{}{"baseline": true},
+		}
+		if err := mm.db.WithContext(ctx).Create(&record).Error; err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", migration.Version, err)
+		}
+		mm.appliedVersions[migration.Version] = true
+
+		mm.logger.WithField("version", migration.Version).Info("Migration marked as applied")
+	}
+
+	return nil
+}
+
+// NextVersion returns the number following the highest migration number,
+// padded to three digits
+func (mm *MigrationManager) NextVersion() string {
+	highest := 0
+	for version := range mm.migrations {
+		number, err := strconv.Atoi(strings.SplitN(version, "_", 2)[0])
+		if err == nil && number > highest {
+			highest = number
+		}
+	}
+	return fmt.Sprintf("%03d", highest+1)
+}
+
+func (mm *MigrationManager) appliedVersionsNewestFirst() []string {
+	versions := make([]string, 0, len(mm.appliedVersions))
+	for version := range mm.appliedVersions {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+	return versions
+}
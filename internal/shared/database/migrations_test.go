@@ -14,7 +14,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
 func TestMigrationManager_Initialize(t *testing.T) {
@@ -449,6 +451,205 @@ func TestMigrationPerformance(t *testing.T) {
 	assert.Equal(t, 20, status.AppliedMigrations)
 }
 
+func newSQLiteMigrationManager(t *testing.T, dir string) (*MigrationManager, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	mm := NewMigrationManager(db, logger, dir)
+	require.NoError(t, mm.Initialize(context.Background()))
+	return mm, db
+}
+
+// reopen reads the applied migrations and files again, as a new process would
+func reopen(t *testing.T, mm *MigrationManager) *MigrationManager {
+	reopened := NewMigrationManager(mm.db, mm.logger, mm.migrationsPath)
+	require.NoError(t, reopened.Initialize(context.Background()))
+	return reopened
+}
+
+func writeMigration(t *testing.T, dir, name, sql string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(sql), 0644))
+}
+
+// writeTableMigrations writes count up and down migrations that each
+// create a table, in SQL SQLite accepts
+func writeTableMigrations(t *testing.T, dir string, count int) {
+	for i := 1; i <= count; i++ {
+		name := fmt.Sprintf("%03d_create_test_table_%d", i, i)
+		writeMigration(t, dir, name+".up.sql", fmt.Sprintf("CREATE TABLE test_table_%d (id TEXT PRIMARY KEY, name TEXT);", i))
+		writeMigration(t, dir, name+".down.sql", fmt.Sprintf("DROP TABLE test_table_%d;", i))
+	}
+}
+
+func sqliteTables(t *testing.T, db *gorm.DB) []string {
+	var tables []string
+	require.NoError(t, db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name LIKE 'test_%' ORDER BY name").Scan(&tables).Error)
+	return tables
+}
+
+func TestMigrationManager_ForwardOnlyFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeMigration(t, dir, "001_create_test_users.sql", "CREATE TABLE test_users (id TEXT PRIMARY KEY);")
+	writeMigration(t, dir, "001_create_test_roles.sql", "CREATE TABLE test_roles (id TEXT PRIMARY KEY);")
+	writeMigration(t, dir, "002_create_test_products.up.sql", "CREATE TABLE test_products (id TEXT PRIMARY KEY);")
+	writeMigration(t, dir, "002_create_test_products.down.sql", "DROP TABLE test_products;")
+	writeMigration(t, dir, "README.md", "not a migration")
+
+	mm, db := newSQLiteMigrationManager(t, dir)
+
+	pending, err := mm.GetPendingMigrations()
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+	assert.Equal(t, "001_create_test_roles", pending[0].Version)
+	assert.Equal(t, "001_create_test_users", pending[1].Version)
+	assert.Equal(t, "002", pending[2].Version)
+	assert.Empty(t, pending[0].DownSQL)
+	assert.Equal(t, "DROP TABLE test_products;", pending[2].DownSQL)
+
+	require.NoError(t, mm.MigrateUp(context.Background()))
+	assert.Equal(t, []string{"test_products", "test_roles", "test_users"}, sqliteTables(t, db))
+	assert.Equal(t, "003", mm.NextVersion())
+
+	// Forward-only migrations cannot be rolled back
+	plan, err := mm.RollbackPlan("001_create_test_users")
+	require.NoError(t, err)
+	require.Len(t, plan, 1)
+	assert.Equal(t, "002", plan[0].Version)
+	_, err = mm.RollbackPlan("001_create_test_roles")
+	assert.Error(t, err)
+
+	require.NoError(t, mm.MigrateDownTo(context.Background(), ""))
+	assert.Error(t, mm.MigrateDownTo(context.Background(), ""))
+}
+
+func TestMigrationManager_Verify(t *testing.T) {
+	dir := t.TempDir()
+	writeTableMigrations(t, dir, 2)
+	mm, _ := newSQLiteMigrationManager(t, dir)
+	require.NoError(t, mm.MigrateUp(context.Background()))
+
+	drift, err := reopen(t, mm).Verify(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+
+	// Editing an applied migration is drift, even in the rollback script
+	writeMigration(t, dir, "001_create_test_table_1.down.sql", "DROP TABLE IF EXISTS test_table_1;")
+	require.NoError(t, os.Remove(filepath.Join(dir, "002_create_test_table_2.up.sql")))
+
+	drift, err = reopen(t, mm).Verify(context.Background())
+	require.NoError(t, err)
+	require.Len(t, drift, 2)
+	assert.Equal(t, "001", drift[0].Version)
+	assert.Contains(t, drift[0].Reason, "modified")
+	assert.NotEqual(t, drift[0].AppliedChecksum, drift[0].CurrentChecksum)
+	assert.Equal(t, "002", drift[1].Version)
+	assert.Contains(t, drift[1].Reason, "missing")
+}
+
+func TestMigrationManager_MigrateDownTo(t *testing.T) {
+	dir := t.TempDir()
+	writeTableMigrations(t, dir, 4)
+	mm, db := newSQLiteMigrationManager(t, dir)
+	ctx := context.Background()
+	require.NoError(t, mm.MigrateUp(ctx))
+
+	plan, err := mm.RollbackPlan("002")
+	require.NoError(t, err)
+	require.Len(t, plan, 2)
+	assert.Equal(t, "004", plan[0].Version)
+	assert.Equal(t, "003", plan[1].Version)
+
+	plan, err = mm.RollbackPlan("")
+	require.NoError(t, err)
+	require.Len(t, plan, 1)
+	assert.Equal(t, "004", plan[0].Version)
+
+	_, err = mm.RollbackPlan("099")
+	assert.Error(t, err)
+
+	require.NoError(t, mm.MigrateDownTo(ctx, "002"))
+	assert.Equal(t, []string{"test_table_1", "test_table_2"}, sqliteTables(t, db))
+
+	status, err := reopen(t, mm).GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.AppliedMigrations)
+	assert.Equal(t, 2, status.PendingMigrations)
+}
+
+func TestMigrationManager_Redo(t *testing.T) {
+	dir := t.TempDir()
+	writeTableMigrations(t, dir, 2)
+	mm, db := newSQLiteMigrationManager(t, dir)
+	ctx := context.Background()
+	require.NoError(t, mm.MigrateUp(ctx))
+	require.NoError(t, db.Exec("INSERT INTO test_table_2 (id, name) VALUES ('p1', 'Kopi')").Error)
+
+	require.NoError(t, mm.Redo(ctx))
+
+	// The table was dropped and created again
+	var count int64
+	require.NoError(t, db.Raw("SELECT count(*) FROM test_table_2").Scan(&count).Error)
+	assert.Equal(t, int64(0), count)
+	status, err := reopen(t, mm).GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.AppliedMigrations)
+}
+
+func TestMigrationManager_Baseline(t *testing.T) {
+	dir := t.TempDir()
+	writeTableMigrations(t, dir, 3)
+	mm, db := newSQLiteMigrationManager(t, dir)
+	ctx := context.Background()
+
+	// The first two were applied before migrations were tracked
+	require.NoError(t, db.Exec("CREATE TABLE test_table_1 (id TEXT); CREATE TABLE test_table_2 (id TEXT);").Error)
+	require.NoError(t, mm.Baseline(ctx, "002"))
+	require.NoError(t, mm.MigrateUp(ctx))
+	assert.Equal(t, []string{"test_table_1", "test_table_2", "test_table_3"}, sqliteTables(t, db))
+
+	drift, err := reopen(t, mm).Verify(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+
+	assert.Error(t, mm.Baseline(ctx, "099"))
+}
+
+func TestMigrationHelper_CreateMigrationTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTableMigrations(t, dir, 2)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	mm := NewMigrationManager(nil, logger, dir)
+	require.NoError(t, mm.LoadFiles())
+	helper := NewMigrationHelper(mm, logger)
+
+	upFile, downFile, err := helper.CreateMigrationTemplate(mm.NextVersion(), "Add customer segments!")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "003_add_customer_segments.up.sql"), upFile)
+	assert.Equal(t, filepath.Join(dir, "003_add_customer_segments.down.sql"), downFile)
+
+	content, err := os.ReadFile(upFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "-- Version: 003")
+	_, err = os.Stat(downFile)
+	assert.NoError(t, err)
+
+	// Existing migrations are never overwritten
+	_, _, err = helper.CreateMigrationTemplate("003", "add customer segments")
+	assert.Error(t, err)
+
+	_, _, err = helper.CreateMigrationTemplate("004", "  ")
+	assert.Error(t, err)
+}
+
 // Helper functions for testing
 
 func setupTestDatabase(t *testing.T) *gorm.DB {
@@ -109,18 +109,50 @@ const (
 	StatusArchived Status = "archived"
 )
 
-// AuditLog represents an audit trail entry
+// AuditLog represents an audit trail entry. The table is append-only, so
+// unlike BaseModel it has no updated_at or deleted_at, and its rows are
+// hash chained per tenant.
 type AuditLog struct {
-	BaseModel
-	Action      string                 `gorm:"not null;index" json:"action"`
-	TableName   string                 `gorm:"not null;index" json:"table_name"`
-	RecordID    uuid.UUID              `gorm:"type:uuid;not null;index" json:"record_id"`
-	OldValues   JSONB                  `gorm:"type:jsonb" json:"old_values,omitempty"`
-	NewValues   JSONB                  `gorm:"type:jsonb" json:"new_values,omitempty"`
-	UserID      uuid.UUID              `gorm:"type:uuid;index" json:"user_id,omitempty"`
-	UserAgent   string                 `gorm:"size:500" json:"user_agent,omitempty"`
-	IPAddress   string                 `gorm:"size:45" json:"ip_address,omitempty"`
-	Metadata    JSONB                  `gorm:"type:jsonb" json:"metadata,omitempty"`
+	ChainLink
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	TenantID      *uuid.UUID `gorm:"type:uuid;index" json:"tenant_id,omitempty"`
+	UserID        *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	TableName     string     `gorm:"size:100;not null;index" json:"table_name"`
+	RecordID      *uuid.UUID `gorm:"type:uuid;index" json:"record_id,omitempty"`
+	Action        string     `gorm:"size:20;not null" json:"action"`
+	OldValues     JSONB      `gorm:"type:jsonb" json:"old_values,omitempty"`
+	NewValues     JSONB      `gorm:"type:jsonb" json:"new_values,omitempty"`
+	Changes       JSONB      `gorm:"type:jsonb" json:"changes,omitempty"`
+	IPAddress     *string    `gorm:"type:inet" json:"ip_address,omitempty"`
+	UserAgent     string     `gorm:"type:text" json:"user_agent,omitempty"`
+	CorrelationID string     `gorm:"size:100;index" json:"correlation_id,omitempty"`
+	TraceID       string     `gorm:"size:64" json:"trace_id,omitempty"`
+	CreatedAt     time.Time  `gorm:"not null;index" json:"created_at"`
+}
+
+// ChainTenantID implements Chained
+func (a *AuditLog) ChainTenantID() *uuid.UUID {
+	return a.TenantID
+}
+
+// ChainPayload implements Chained
+func (a *AuditLog) ChainPayload() interface{} {
+	return map[string]interface{}{
+		"id":             a.ID,
+		"tenant_id":      a.TenantID,
+		"user_id":        a.UserID,
+		"table_name":     a.TableName,
+		"record_id":      a.RecordID,
+		"action":         a.Action,
+		"old_values":     a.OldValues,
+		"new_values":     a.NewValues,
+		"changes":        a.Changes,
+		"ip_address":     a.IPAddress,
+		"user_agent":     a.UserAgent,
+		"correlation_id": a.CorrelationID,
+		"trace_id":       a.TraceID,
+		"created_at":     a.CreatedAt.UTC(),
+	}
 }
 
 // Config represents system configuration
@@ -220,6 +252,10 @@ type ValidationError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
 	Value   interface{} `json:"value,omitempty"`
+	// Rule identifies the failed check (e.g. "required", "min_length") so
+	// the message can be localised; Params holds the rule arguments
+	Rule   string                 `json:"rule,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
 }
 
 // Error implements the error interface
@@ -250,6 +286,17 @@ func (ves *ValidationErrors) Add(field, message string, value interface{}) {
 	})
 }
 
+// AddRule adds a validation error for a named rule
+func (ves *ValidationErrors) AddRule(field, rule, message string, value interface{}, params map[string]interface{}) {
+	*ves = append(*ves, ValidationError{
+		Field:   field,
+		Message: message,
+		Value:   value,
+		Rule:    rule,
+		Params:  params,
+	})
+}
+
 // HasErrors returns true if there are validation errors
 func (ves ValidationErrors) HasErrors() bool {
 	return len(ves) > 0
@@ -270,21 +317,21 @@ func NewValidator() *Validator {
 // Required validates that a field is not empty
 func (v *Validator) Required(field string, value interface{}) {
 	if value == nil || value == "" {
-		v.errors.Add(field, "is required", value)
+		v.errors.AddRule(field, "required", "is required", value, nil)
 	}
 }
 
 // MinLength validates minimum length
 func (v *Validator) MinLength(field string, value string, min int) {
 	if len(value) < min {
-		v.errors.Add(field, fmt.Sprintf("must be at least %d characters", min), value)
+		v.errors.AddRule(field, "min_length", fmt.Sprintf("must be at least %d characters", min), value, map[string]interface{}{"min": min})
 	}
 }
 
 // MaxLength validates maximum length
 func (v *Validator) MaxLength(field string, value string, max int) {
 	if len(value) > max {
-		v.errors.Add(field, fmt.Sprintf("must be at most %d characters", max), value)
+		v.errors.AddRule(field, "max_length", fmt.Sprintf("must be at most %d characters", max), value, map[string]interface{}{"max": max})
 	}
 }
 
@@ -296,7 +343,7 @@ func (v *Validator) Email(field string, value string) {
 	}
 
 	if len(value) > EmailMaxLength || !strings.Contains(value, "@") || !strings.Contains(value, ".") {
-		v.errors.Add(field, "must be a valid email address", value)
+		v.errors.AddRule(field, "email", "must be a valid email address", value, nil)
 	}
 }
 
@@ -307,7 +354,7 @@ func (v *Validator) UUID(field string, value string) {
 	}
 
 	if _, err := uuid.Parse(value); err != nil {
-		v.errors.Add(field, "must be a valid UUID", value)
+		v.errors.AddRule(field, "uuid", "must be a valid UUID", value, nil)
 	}
 }
 
@@ -323,41 +370,41 @@ func (v *Validator) OneOf(field string, value string, allowed []string) {
 		}
 	}
 
-	v.errors.Add(field, fmt.Sprintf("must be one of: %v", allowed), value)
+	v.errors.AddRule(field, "one_of", fmt.Sprintf("must be one of: %v", allowed), value, map[string]interface{}{"allowed": strings.Join(allowed, ", ")})
 }
 
 // Range validates numeric range
 func (v *Validator) Range(field string, value int64, min, max int64) {
 	if value < min || value > max {
-		v.errors.Add(field, fmt.Sprintf("must be between %d and %d", min, max), value)
+		v.errors.AddRule(field, "range", fmt.Sprintf("must be between %d and %d", min, max), value, map[string]interface{}{"min": min, "max": max})
 	}
 }
 
 // Positive validates that a number is positive
 func (v *Validator) Positive(field string, value int64) {
 	if value <= 0 {
-		v.errors.Add(field, "must be positive", value)
+		v.errors.AddRule(field, "positive", "must be positive", value, nil)
 	}
 }
 
 // NonNegative validates that a number is non-negative
 func (v *Validator) NonNegative(field string, value int64) {
 	if value < 0 {
-		v.errors.Add(field, "must be non-negative", value)
+		v.errors.AddRule(field, "non_negative", "must be non-negative", value, nil)
 	}
 }
 
 // FutureDate validates that a date is in the future
 func (v *Validator) FutureDate(field string, value time.Time) {
 	if !value.IsZero() && value.Before(time.Now()) {
-		v.errors.Add(field, "must be in the future", value)
+		v.errors.AddRule(field, "future_date", "must be in the future", value, nil)
 	}
 }
 
 // PastDate validates that a date is in the past
 func (v *Validator) PastDate(field string, value time.Time) {
 	if !value.IsZero() && value.After(time.Now()) {
-		v.errors.Add(field, "must be in the past", value)
+		v.errors.AddRule(field, "past_date", "must be in the past", value, nil)
 	}
 }
 
@@ -35,6 +35,9 @@ type MultiDBManager struct {
 	logger    *logrus.Logger
 	config    *config.Config
 	mu        sync.RWMutex
+	// strategy places tenants; tenancy routes them after Initialize
+	strategy TenancyStrategy
+	tenancy  *TenantRouter
 }
 
 // NewMultiDBManager creates a new multi-database manager
@@ -53,6 +56,18 @@ func (mdb *MultiDBManager) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize master database: %w", err)
 	}
 
+	// Route tenants to where their data lives
+	master, err := mdb.GetMaster()
+	if err != nil {
+		return err
+	}
+	strategy := mdb.strategy
+	if strategy == nil {
+		strategy = NewStaticTenancy(mdb.config.Tenancy)
+	}
+	mdb.tenancy = NewTenantRouter(master, strategy, mdb.config.Tenancy, mdb.config.Databases.Master.AppRole, mdb.logger)
+	master.UseTenancy(mdb.tenancy)
+
 	// Initialize replica databases
 	for i, replica := range mdb.config.Databases.Replicas {
 		if !replica.Enabled {
@@ -61,6 +76,11 @@ func (mdb *MultiDBManager) Initialize(ctx context.Context) error {
 		name := fmt.Sprintf("replica_%d", i+1)
 		if err := mdb.initializeDatabase(ctx, name, &replica); err != nil {
 			mdb.logger.WithError(err).WithField("replica", name).Warn("Failed to initialize replica database")
+			continue
+		}
+		// GORM reads of the master go to the replica
+		if replicaDB, err := mdb.GetDatabase(name); err == nil {
+			master.Resolver().AddReplica(name, replicaDB.SQLDB)
 		}
 	}
 
@@ -79,6 +99,35 @@ func (mdb *MultiDBManager) Initialize(ctx context.Context) error {
 	return nil
 }
 
+// InitializeStorage only connects to MinIO, for tools such as backups that
+// must work while the databases are down
+func (mdb *MultiDBManager) InitializeStorage(ctx context.Context) error {
+	if err := mdb.initializeMinIO(ctx); err != nil {
+		return fmt.Errorf("failed to initialize MinIO: %w", err)
+	}
+	return nil
+}
+
+// SetTenancyStrategy replaces the configured placement of tenants; it must
+// be called before Initialize
+func (mdb *MultiDBManager) SetTenancyStrategy(strategy TenancyStrategy) {
+	mdb.strategy = strategy
+}
+
+// Tenancy returns the tenant router, which is nil before Initialize
+func (mdb *MultiDBManager) Tenancy() *TenantRouter {
+	return mdb.tenancy
+}
+
+// GetTenantDB returns the connection for the data of the tenant of ctx.
+// Release must be called once the connection is no longer used.
+func (mdb *MultiDBManager) GetTenantDB(ctx context.Context) (db *gorm.DB, release func(), err error) {
+	if mdb.tenancy == nil {
+		return nil, nil, fmt.Errorf("tenancy is not initialized")
+	}
+	return mdb.tenancy.DB(ctx)
+}
+
 // initializeDatabase initializes a single database connection
 func (mdb *MultiDBManager) initializeDatabase(ctx context.Context, name string, cfg *config.DatabaseConfig) error {
 	if cfg.Type == "" {
@@ -143,6 +192,12 @@ func (mdb *MultiDBManager) initializeDatabase(ctx context.Context, name string,
 		Config: cfg,
 	}
 
+	if name == "master" {
+		if err := database.enableReadResolver(); err != nil {
+			return err
+		}
+	}
+
 	// Start health monitoring
 	if err := database.startHealthMonitoring(); err != nil {
 		mdb.logger.WithError(err).Warn("Failed to start health monitoring")
@@ -302,15 +357,20 @@ func (mdb *MultiDBManager) GetMaster() (*Database, error) {
 	return mdb.GetDatabase("master")
 }
 
-// GetReadReplica returns a read replica connection (round-robin)
+// GetReadReplica returns a read replica connection (round-robin) among the
+// replicas in rotation
 func (mdb *MultiDBManager) GetReadReplica() (*Database, error) {
 	mdb.mu.RLock()
 	defer mdb.mu.RUnlock()
 
-	// Filter enabled replicas
+	// Filter replicas that are healthy and current enough
+	var resolver *ReadResolver
+	if master, ok := mdb.databases["master"]; ok {
+		resolver = master.Resolver()
+	}
 	var replicas []string
 	for name := range mdb.databases {
-		if strings.HasPrefix(name, "replica_") {
+		if strings.HasPrefix(name, "replica_") && (resolver == nil || resolver.InRotation(name)) {
 			replicas = append(replicas, name)
 		}
 	}
@@ -381,6 +441,13 @@ func (mdb *MultiDBManager) HealthCheck(ctx context.Context) error {
 func (mdb *MultiDBManager) Close() error {
 	var errors []string
 
+	// Close tenant pools
+	if mdb.tenancy != nil {
+		if err := mdb.tenancy.Close(); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
 	// Close databases
 	mdb.mu.Lock()
 	for name, db := range mdb.databases {
@@ -417,6 +484,9 @@ func (mdb *MultiDBManager) GetConnectionMetrics() map[string]interface{} {
 	}
 	mdb.mu.RUnlock()
 	metrics["databases"] = dbMetrics
+	if mdb.tenancy != nil {
+		metrics["tenant_pools"] = mdb.tenancy.OpenPools()
+	}
 
 	// Redis metrics (only available for single client)
 	if mdb.redis != nil {
@@ -0,0 +1,356 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+)
+
+// replicaLagQuery returns how many seconds a PostgreSQL standby is behind.
+// A standby that replayed everything it received is current even when the
+// primary has been idle since its last transaction.
+const replicaLagQuery = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+type usePrimaryKey struct{}
+
+// UsePrimary makes the queries of ctx read from the primary, e.g. to read a
+// row right before updating it
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, usePrimaryKey{}, true)
+}
+
+// ReplicaStatus is the state of a read replica at its last check
+type ReplicaStatus struct {
+	Name string `json:"name"`
+	// InRotation reports whether reads are sent to the replica
+	InRotation bool          `json:"in_rotation"`
+	Lag        time.Duration `json:"lag"`
+	Error      string        `json:"error,omitempty"`
+	CheckedAt  time.Time     `json:"checked_at"`
+}
+
+// StickyStore shares the read-your-writes windows between the instances
+// of a service, e.g. cache.RedisCache, so that a read served by another
+// instance than the write still goes to the primary
+type StickyStore interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// stickyStorePrefix prefixes the keys of the windows in the sticky store
+const stickyStorePrefix = "read_primary:"
+
+// replica is a read replica known to the resolver
+type replica struct {
+	db     *sql.DB
+	status ReplicaStatus
+}
+
+// ReadResolver is a GORM plugin that sends reads outside of transactions to
+// healthy replicas and everything else to the primary. After a write the
+// user, or else the request, reads from the primary for a window, so it
+// sees its own writes despite replication lag. The windows are kept in
+// memory, so they only span instances once shared through ShareSticky.
+// Replicas lagging more than the maximum are taken out of rotation until
+// they catch up.
+type ReadResolver struct {
+	maxLag       time.Duration
+	stickyWindow time.Duration
+	logger       *logrus.Logger
+	dialect      string
+
+	mu       sync.RWMutex
+	replicas []*replica
+	next     uint64
+	// sticky holds until when a user or request reads from the primary
+	sticky map[string]time.Time
+	store  StickyStore
+}
+
+// NewReadResolver creates a read resolver. stickyWindow should exceed
+// maxLag, or a read after the window may miss the write.
+func NewReadResolver(maxLag, stickyWindow time.Duration, log *logrus.Logger) *ReadResolver {
+	return &ReadResolver{
+		maxLag:       maxLag,
+		stickyWindow: stickyWindow,
+		logger:       log,
+		sticky:       make(map[string]time.Time),
+	}
+}
+
+// ShareSticky also keeps the read-your-writes windows in store, so that
+// every instance of the service honours them
+func (r *ReadResolver) ShareSticky(store StickyStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+}
+
+// Name implements gorm.Plugin
+func (r *ReadResolver) Name() string {
+	return "rexi:read_resolver"
+}
+
+// Initialize registers the routing callbacks on db
+func (r *ReadResolver) Initialize(db *gorm.DB) error {
+	r.dialect = db.Dialector.Name()
+
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("rexi:route_read", r.routeRead); err != nil {
+		return fmt.Errorf("failed to register query callback: %w", err)
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("rexi:route_read", r.routeRead); err != nil {
+		return fmt.Errorf("failed to register row callback: %w", err)
+	}
+	if err := callbacks.Create().After("gorm:create").Register("rexi:stick_to_primary", r.stick); err != nil {
+		return fmt.Errorf("failed to register create callback: %w", err)
+	}
+	if err := callbacks.Update().After("gorm:update").Register("rexi:stick_to_primary", r.stick); err != nil {
+		return fmt.Errorf("failed to register update callback: %w", err)
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register("rexi:stick_to_primary", r.stick); err != nil {
+		return fmt.Errorf("failed to register delete callback: %w", err)
+	}
+	if err := callbacks.Raw().After("gorm:raw").Register("rexi:stick_to_primary", r.stick); err != nil {
+		return fmt.Errorf("failed to register raw callback: %w", err)
+	}
+	return nil
+}
+
+// AddReplica puts a replica in rotation
+func (r *ReadResolver) AddReplica(name string, db *sql.DB) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replicas = append(r.replicas, &replica{
+		db:     db,
+		status: ReplicaStatus{Name: name, InRotation: true},
+	})
+}
+
+// Replicas returns the state of the replicas
+func (r *ReadResolver) Replicas() []ReplicaStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]ReplicaStatus, len(r.replicas))
+	for i, replica := range r.replicas {
+		statuses[i] = replica.status
+	}
+	return statuses
+}
+
+// InRotation reports whether reads are sent to the named replica
+func (r *ReadResolver) InRotation(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, replica := range r.replicas {
+		if replica.status.Name == name {
+			return replica.status.InRotation
+		}
+	}
+	return false
+}
+
+// Replica returns a replica in rotation, round-robin, or nil if none is
+func (r *ReadResolver) Replica() *sql.DB {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var healthy []*sql.DB
+	for _, replica := range r.replicas {
+		if replica.status.InRotation {
+			healthy = append(healthy, replica.db)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	return healthy[atomic.AddUint64(&r.next, 1)%uint64(len(healthy))]
+}
+
+// Check pings the replicas and measures their lag, taking those that fail
+// or lag too much out of rotation and returning the others to it
+func (r *ReadResolver) Check(ctx context.Context) []ReplicaStatus {
+	r.mu.RLock()
+	replicas := append([]*replica(nil), r.replicas...)
+	r.mu.RUnlock()
+
+	statuses := make([]ReplicaStatus, len(replicas))
+	for i, replica := range replicas {
+		status := ReplicaStatus{Name: replica.status.Name, CheckedAt: time.Now()}
+		lag, err := r.measureLag(ctx, replica.db)
+		status.Lag = lag
+		if err != nil {
+			status.Error = err.Error()
+		}
+		status.InRotation = err == nil && (r.maxLag <= 0 || lag <= r.maxLag)
+		statuses[i] = status
+
+		r.mu.Lock()
+		previous := replica.status
+		replica.status = status
+		r.mu.Unlock()
+
+		if previous.InRotation != status.InRotation {
+			entry := r.logger.WithFields(logrus.Fields{
+				"replica": status.Name,
+				"lag":     status.Lag,
+				"max_lag": r.maxLag,
+			})
+			if status.InRotation {
+				entry.Info("Read replica returned to rotation")
+			} else {
+				entry.WithField("error", status.Error).Warn("Read replica taken out of rotation")
+			}
+		}
+	}
+
+	r.pruneSticky(time.Now())
+	return statuses
+}
+
+// measureLag pings the replica and returns how far it is behind
+func (r *ReadResolver) measureLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	if err := db.PingContext(ctx); err != nil {
+		return 0, fmt.Errorf("ping failed: %w", err)
+	}
+	if r.dialect != "postgres" {
+		return 0, nil
+	}
+
+	var seconds float64
+	if err := db.QueryRowContext(ctx, replicaLagQuery).Scan(&seconds); err != nil {
+		return 0, fmt.Errorf("failed to measure lag: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// routeRead sends a read to a replica unless it must see the primary
+func (r *ReadResolver) routeRead(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	// Transactions and their reads stay on one connection
+	if _, inTransaction := db.Statement.ConnPool.(gorm.TxCommitter); inTransaction {
+		return
+	}
+	// SELECT ... FOR UPDATE locks rows on the primary
+	if _, locking := db.Statement.Clauses["FOR"]; locking {
+		return
+	}
+	if db.Statement.SQL.Len() > 0 && !isPlainSelect(db.Statement.SQL.String()) {
+		return
+	}
+
+	// Without a replica there is nothing to decide, nor a store to ask
+	replica := r.Replica()
+	if replica == nil {
+		return
+	}
+	ctx := db.Statement.Context
+	if primary, _ := ctx.Value(usePrimaryKey{}).(bool); primary || r.isSticky(ctx, time.Now()) {
+		return
+	}
+	db.Statement.ConnPool = replica
+}
+
+// stick makes the writer read from the primary for the sticky window
+func (r *ReadResolver) stick(db *gorm.DB) {
+	if db.Error != nil || r.stickyWindow <= 0 {
+		return
+	}
+	key := stickyKey(db.Statement.Context)
+	if key == "" {
+		return
+	}
+
+	r.mu.Lock()
+	r.sticky[key] = time.Now().Add(r.stickyWindow)
+	store := r.store
+	r.mu.Unlock()
+
+	if store != nil {
+		if err := store.Set(db.Statement.Context, stickyStorePrefix+key, true, r.stickyWindow); err != nil {
+			r.logger.WithError(err).WithField("key", key).Warn("Failed to share read-your-writes window")
+		}
+	}
+}
+
+// isSticky reports whether the user or request wrote within the window,
+// here or, with a sticky store, on another instance. Reads go to the
+// primary when the store cannot tell.
+func (r *ReadResolver) isSticky(ctx context.Context, now time.Time) bool {
+	key := stickyKey(ctx)
+	if key == "" {
+		return false
+	}
+
+	r.mu.RLock()
+	until, ok := r.sticky[key]
+	store := r.store
+	r.mu.RUnlock()
+	if ok && now.Before(until) {
+		return true
+	}
+	if store == nil {
+		return false
+	}
+
+	sticky, err := store.Exists(ctx, stickyStorePrefix+key)
+	if err != nil {
+		r.logger.WithError(err).WithField("key", key).Warn("Failed to check read-your-writes window, reading from the primary")
+		return true
+	}
+	return sticky
+}
+
+// pruneSticky forgets windows that ended before now
+func (r *ReadResolver) pruneSticky(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, until := range r.sticky {
+		if !now.Before(until) {
+			delete(r.sticky, key)
+		}
+	}
+}
+
+// stickyKey identifies who wrote: the user, else the request
+func stickyKey(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if userID := logger.GetUserID(ctx); userID != "" {
+		return "user:" + userID
+	}
+	if correlationID := logger.GetCorrelationID(ctx); correlationID != "" {
+		return "request:" + correlationID
+	}
+	return ""
+}
+
+// isPlainSelect reports whether raw SQL only reads
+func isPlainSelect(sql string) bool {
+	sql = strings.ToUpper(strings.TrimSpace(sql))
+	if !strings.HasPrefix(sql, "SELECT") && !strings.HasPrefix(sql, "WITH") {
+		return false
+	}
+	for _, keyword := range []string{"FOR UPDATE", "FOR SHARE", "FOR NO KEY UPDATE", "INSERT ", "UPDATE ", "DELETE "} {
+		if strings.Contains(sql, keyword) {
+			return false
+		}
+	}
+	return true
+}
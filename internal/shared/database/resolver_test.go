@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+)
+
+// newResolvedSQLite returns a primary whose reads the resolver sends to a
+// separate replica database, each holding one order naming it
+func newResolvedSQLite(t *testing.T) (*gorm.DB, *sql.DB, *ReadResolver) {
+	open := func(number string) *gorm.DB {
+		db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+		require.NoError(t, err)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		sqlDB.SetMaxOpenConns(1)
+		t.Cleanup(func() { sqlDB.Close() })
+		require.NoError(t, db.AutoMigrate(&scopedOrder{}))
+		require.NoError(t, db.Create(&scopedOrder{TenantID: uuid.New(), Number: number}).Error)
+		return db
+	}
+	primary, replica := open("PRIMARY"), open("REPLICA")
+
+	log := logrus.New()
+	log.SetLevel(logrus.PanicLevel)
+	resolver := NewReadResolver(5*time.Second, time.Minute, log)
+	require.NoError(t, primary.Use(resolver))
+	replicaDB, err := replica.DB()
+	require.NoError(t, err)
+	resolver.AddReplica("replica_1", replicaDB)
+	return primary, replicaDB, resolver
+}
+
+func readFrom(t *testing.T, db *gorm.DB) string {
+	var order scopedOrder
+	require.NoError(t, db.Order("id").First(&order).Error)
+	return order.Number
+}
+
+func TestReadResolver_Routing(t *testing.T) {
+	primary, _, _ := newResolvedSQLite(t)
+	ctx := context.Background()
+
+	assert.Equal(t, "REPLICA", readFrom(t, primary.WithContext(ctx)))
+
+	var number string
+	require.NoError(t, primary.Raw("SELECT number FROM rls_test_orders ORDER BY id LIMIT 1").Scan(&number).Error)
+	assert.Equal(t, "REPLICA", number)
+
+	t.Run("Primary", func(t *testing.T) {
+		assert.Equal(t, "PRIMARY", readFrom(t, primary.WithContext(UsePrimary(ctx))))
+		assert.Equal(t, "PRIMARY", readFrom(t, primary.Clauses(clause.Locking{Strength: "UPDATE"})))
+
+		require.NoError(t, primary.Transaction(func(tx *gorm.DB) error {
+			assert.Equal(t, "PRIMARY", readFrom(t, tx))
+			return nil
+		}))
+	})
+
+	t.Run("Writes", func(t *testing.T) {
+		require.NoError(t, primary.Create(&scopedOrder{TenantID: uuid.New(), Number: "WRITTEN"}).Error)
+
+		var count int64
+		require.NoError(t, primary.WithContext(UsePrimary(ctx)).Model(&scopedOrder{}).Where("number = ?", "WRITTEN").Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+}
+
+func TestReadResolver_ReadYourWrites(t *testing.T) {
+	primary, _, resolver := newResolvedSQLite(t)
+	writer := logger.WithUserID(context.Background(), uuid.NewString())
+	other := logger.WithUserID(context.Background(), uuid.NewString())
+
+	require.NoError(t, primary.WithContext(writer).Create(&scopedOrder{TenantID: uuid.New(), Number: "SO-1"}).Error)
+
+	// The writer reads from the primary for the window, others do not
+	assert.Equal(t, "PRIMARY", readFrom(t, primary.WithContext(writer)))
+	assert.Equal(t, "REPLICA", readFrom(t, primary.WithContext(other)))
+
+	// Requests without a user stick by correlation ID
+	request := logger.WithCorrelationID(context.Background(), "req-1")
+	require.NoError(t, primary.WithContext(request).Model(&scopedOrder{}).Where("number = ?", "SO-1").Update("number", "SO-2").Error)
+	assert.Equal(t, "PRIMARY", readFrom(t, primary.WithContext(request)))
+
+	resolver.pruneSticky(time.Now().Add(time.Minute))
+	assert.Equal(t, "REPLICA", readFrom(t, primary.WithContext(writer)))
+	assert.Empty(t, resolver.sticky)
+}
+
+// memoryStickyStore is a sticky store shared by resolvers in one test,
+// failing every call once broken
+type memoryStickyStore struct {
+	mu     sync.Mutex
+	keys   map[string]time.Time
+	broken bool
+}
+
+func (m *memoryStickyStore) Set(_ context.Context, key string, _ interface{}, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.broken {
+		return errors.New("store unavailable")
+	}
+	m.keys[key] = time.Now().Add(expiration)
+	return nil
+}
+
+func (m *memoryStickyStore) Exists(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.broken {
+		return false, errors.New("store unavailable")
+	}
+	until, ok := m.keys[key]
+	return ok && time.Now().Before(until), nil
+}
+
+func TestReadResolver_SharedReadYourWrites(t *testing.T) {
+	store := &memoryStickyStore{keys: make(map[string]time.Time)}
+	writing, _, writingResolver := newResolvedSQLite(t)
+	reading, _, readingResolver := newResolvedSQLite(t)
+	writingResolver.ShareSticky(store)
+	readingResolver.ShareSticky(store)
+
+	writer := logger.WithUserID(context.Background(), uuid.NewString())
+	other := logger.WithUserID(context.Background(), uuid.NewString())
+	require.NoError(t, writing.WithContext(writer).Create(&scopedOrder{TenantID: uuid.New(), Number: "SO-1"}).Error)
+
+	// Another instance sends the writer's reads to the primary as well
+	assert.Equal(t, "PRIMARY", readFrom(t, reading.WithContext(writer)))
+	assert.Equal(t, "REPLICA", readFrom(t, reading.WithContext(other)))
+
+	// Reads go to the primary when the store cannot tell
+	store.mu.Lock()
+	store.broken = true
+	store.mu.Unlock()
+	assert.Equal(t, "PRIMARY", readFrom(t, reading.WithContext(other)))
+}
+
+func TestReadResolver_Check(t *testing.T) {
+	primary, replica, resolver := newResolvedSQLite(t)
+
+	statuses := resolver.Check(context.Background())
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].InRotation)
+	assert.Empty(t, statuses[0].Error)
+
+	// A replica that fails leaves the rotation and reads fall back
+	require.NoError(t, replica.Close())
+	statuses = resolver.Check(context.Background())
+	assert.False(t, statuses[0].InRotation)
+	assert.NotEmpty(t, statuses[0].Error)
+	assert.False(t, resolver.InRotation("replica_1"))
+	assert.Nil(t, resolver.Replica())
+	assert.Equal(t, "PRIMARY", readFrom(t, primary))
+}
+
+func TestIsPlainSelect(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT * FROM orders":                                               true,
+		"  select count(*) from orders":                                      true,
+		"WITH totals AS (SELECT 1) SELECT * FROM totals":                     true,
+		"SELECT * FROM orders FOR UPDATE":                                    false,
+		"WITH moved AS (DELETE FROM orders RETURNING *) SELECT * FROM moved": false,
+		"UPDATE orders SET number = 'x' RETURNING id":                        false,
+		"INSERT INTO orders (number) VALUES ('x')":                           false,
+	}
+	for sql, expected := range tests {
+		assert.Equal(t, expected, isPlainSelect(sql), sql)
+	}
+}
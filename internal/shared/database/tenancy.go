@@ -0,0 +1,513 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+)
+
+// TenancyMode is how the data of a tenant is isolated
+type TenancyMode string
+
+// Tenancy modes
+const (
+	// TenancyShared keeps the tenant in the master database under
+	// row-level security
+	TenancyShared TenancyMode = config.TenancyShared
+	// TenancySchema gives the tenant a schema in the master database
+	TenancySchema TenancyMode = config.TenancySchema
+	// TenancyDatabase gives the tenant a database on the master server
+	TenancyDatabase TenancyMode = config.TenancyDatabase
+)
+
+// TenantLocation is where the data of a tenant lives
+type TenantLocation struct {
+	Mode TenancyMode
+	// Schema is the schema of a TenancySchema tenant
+	Schema string
+	// Database is the database of a TenancyDatabase tenant
+	Database string
+}
+
+// Dedicated reports whether the tenant has a schema or database of its own
+func (l TenantLocation) Dedicated() bool {
+	return l.Mode == TenancySchema || l.Mode == TenancyDatabase
+}
+
+// key identifies the connection pool of a dedicated tenant
+func (l TenantLocation) key() string {
+	return string(l.Mode) + ":" + l.Schema + l.Database
+}
+
+// TenancyStrategy decides where the data of a tenant lives
+type TenancyStrategy interface {
+	Locate(ctx context.Context, tenantID uuid.UUID) (TenantLocation, error)
+}
+
+// TenancyStrategyFunc adapts a function to TenancyStrategy
+type TenancyStrategyFunc func(ctx context.Context, tenantID uuid.UUID) (TenantLocation, error)
+
+// Locate calls f
+func (f TenancyStrategyFunc) Locate(ctx context.Context, tenantID uuid.UUID) (TenantLocation, error) {
+	return f(ctx, tenantID)
+}
+
+// StaticTenancy places tenants by configuration: the overridden mode of the
+// tenant, else the default mode
+type StaticTenancy struct {
+	mode           TenancyMode
+	overrides      map[uuid.UUID]TenancyMode
+	schemaPrefix   string
+	databasePrefix string
+}
+
+// NewStaticTenancy creates a static tenancy strategy. Overrides whose key is
+// not a tenant ID are ignored.
+func NewStaticTenancy(cfg config.TenancyConfig) *StaticTenancy {
+	s := &StaticTenancy{
+		mode:           TenancyMode(cfg.Mode),
+		overrides:      make(map[uuid.UUID]TenancyMode, len(cfg.Overrides)),
+		schemaPrefix:   cfg.SchemaPrefix,
+		databasePrefix: cfg.DatabasePrefix,
+	}
+	if s.mode == "" {
+		s.mode = TenancyShared
+	}
+	for tenant, mode := range cfg.Overrides {
+		if id, err := uuid.Parse(tenant); err == nil {
+			s.overrides[id] = TenancyMode(mode)
+		}
+	}
+	return s
+}
+
+// Locate returns the location of the tenant
+func (s *StaticTenancy) Locate(_ context.Context, tenantID uuid.UUID) (TenantLocation, error) {
+	mode, ok := s.overrides[tenantID]
+	if !ok {
+		mode = s.mode
+	}
+
+	// Identifiers cannot hold dashes unquoted
+	name := strings.ReplaceAll(tenantID.String(), "-", "")
+	switch mode {
+	case TenancyShared:
+		return TenantLocation{Mode: mode}, nil
+	case TenancySchema:
+		return TenantLocation{Mode: mode, Schema: s.schemaPrefix + name}, nil
+	case TenancyDatabase:
+		return TenantLocation{Mode: mode, Database: s.databasePrefix + name}, nil
+	default:
+		return TenantLocation{}, fmt.Errorf("unknown tenancy mode %q for tenant %s", mode, tenantID)
+	}
+}
+
+// tenantPool is the open connection pool of a dedicated tenant
+type tenantPool struct {
+	key      string
+	db       *Database
+	inUse    int
+	lastUsed time.Time
+	element  *list.Element
+}
+
+// TenantRouter routes the work of a tenant to where its data lives. Shared
+// tenants use the master under row-level security, schema tenants the
+// master with their schema first on the search path, and database tenants
+// a connection pool of their own that is opened on first use and closed
+// when idle.
+type TenantRouter struct {
+	master   *Database
+	strategy TenancyStrategy
+	scope    *TenantScope
+	config   config.TenancyConfig
+	logger   *logrus.Logger
+	// open connects to the schema or database of a tenant
+	open func(cfg *config.DatabaseConfig) (*Database, error)
+
+	mu    sync.Mutex
+	pools map[string]*tenantPool
+	// lru orders the pools from most to least recently used
+	lru *list.List
+}
+
+// NewTenantRouter creates a tenant router. role is the row-level security
+// role of master transactions.
+func NewTenantRouter(master *Database, strategy TenancyStrategy, cfg config.TenancyConfig, role string, log *logrus.Logger) *TenantRouter {
+	r := &TenantRouter{
+		master:   master,
+		strategy: strategy,
+		scope:    NewTenantScope(master.DB, role),
+		config:   cfg,
+		logger:   log,
+		pools:    make(map[string]*tenantPool),
+		lru:      list.New(),
+	}
+	r.open = func(cfg *config.DatabaseConfig) (*Database, error) {
+		return NewDatabase(cfg, log)
+	}
+	return r
+}
+
+// Locate returns where the data of the tenant lives
+func (r *TenantRouter) Locate(ctx context.Context, tenantID uuid.UUID) (TenantLocation, error) {
+	location, err := r.strategy.Locate(ctx, tenantID)
+	if err != nil {
+		return TenantLocation{}, fmt.Errorf("failed to locate tenant %s: %w", tenantID, err)
+	}
+	return location, nil
+}
+
+// Run calls fn in a transaction of the tenant where its data lives and
+// commits it unless fn returns an error. The master transaction under
+// row-level security is always part of it, so code using Conn on the master
+// keeps working; for a database tenant fn gets the transaction on the
+// tenant database, which commits first.
+func (r *TenantRouter) Run(ctx context.Context, tenantID uuid.UUID, fn func(tx *gorm.DB) error) error {
+	if tenantID == uuid.Nil {
+		return ErrTenantRequired
+	}
+	location, err := r.Locate(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	switch location.Mode {
+	case TenancySchema:
+		return r.scope.Run(ctx, tenantID, func(tx *gorm.DB) error {
+			if err := setSearchPath(tx, location.Schema); err != nil {
+				return err
+			}
+			return fn(tx)
+		})
+	case TenancyDatabase:
+		return r.scope.Run(ctx, tenantID, func(tx *gorm.DB) error {
+			return r.Dedicated(tx.Statement.Context, tenantID, func(db *gorm.DB) error {
+				return NewTenantScope(db, "").Run(tx.Statement.Context, tenantID, fn)
+			})
+		})
+	default:
+		return r.scope.Run(ctx, tenantID, fn)
+	}
+}
+
+// DB returns the connection for the data of the tenant of ctx: the tenant
+// transaction ctx carries, else the schema or database of the tenant. The
+// tenant comes from the transaction or the logging context. The pool of a
+// database tenant stays open until release is called, which must happen
+// once the connection is no longer used.
+func (r *TenantRouter) DB(ctx context.Context) (db *gorm.DB, release func(), err error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		id, err := uuid.Parse(logger.GetTenantID(ctx))
+		if err != nil || id == uuid.Nil {
+			return nil, nil, ErrTenantRequired
+		}
+		tenantID = id
+	}
+
+	location, err := r.Locate(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The master transaction of a schema tenant has its search path
+	if location.Mode == TenancyShared || (location.Mode == TenancySchema && ok) {
+		return Conn(ctx, r.master.DB), func() {}, nil
+	}
+
+	pool, err := r.acquire(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	var once sync.Once
+	return Conn(ctx, pool.db.DB), func() { once.Do(func() { r.release(pool) }) }, nil
+}
+
+// Dedicated calls fn with the connection pool of the schema or database of
+// the tenant, which stays open until fn returns. Shared tenants have none.
+func (r *TenantRouter) Dedicated(ctx context.Context, tenantID uuid.UUID, fn func(db *gorm.DB) error) error {
+	location, err := r.Locate(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if !location.Dedicated() {
+		return fmt.Errorf("tenant %s has no dedicated schema or database", tenantID)
+	}
+
+	pool, err := r.acquire(location)
+	if err != nil {
+		return err
+	}
+	defer r.release(pool)
+	return fn(pool.db.DB.WithContext(ctx))
+}
+
+// Provision creates the schema or database of the tenant if it is missing.
+// Other databases than PostgreSQL are not provisioned.
+func (r *TenantRouter) Provision(ctx context.Context, tenantID uuid.UUID) error {
+	location, err := r.Locate(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if !location.Dedicated() {
+		return nil
+	}
+
+	db := r.master.DB.WithContext(ctx)
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	if location.Mode == TenancySchema {
+		if err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + quoteIdentifier(location.Schema)).Error; err != nil {
+			return fmt.Errorf("failed to create schema %s: %w", location.Schema, err)
+		}
+		return nil
+	}
+
+	var exists bool
+	if err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = ?)", location.Database).Scan(&exists).Error; err != nil {
+		return fmt.Errorf("failed to check database %s: %w", location.Database, err)
+	}
+	if exists {
+		return nil
+	}
+	// CREATE DATABASE cannot run in a transaction
+	if err := db.Exec("CREATE DATABASE " + quoteIdentifier(location.Database)).Error; err != nil {
+		return fmt.Errorf("failed to create database %s: %w", location.Database, err)
+	}
+	r.logger.WithFields(logrus.Fields{
+		"tenant_id": tenantID,
+		"database":  location.Database,
+	}).Info("Tenant database created")
+	return nil
+}
+
+// MigrateTenants applies the migrations in migrationsPath to the schema or
+// database of each dedicated tenant, provisioning it first. Shared tenants
+// are skipped; their tables come from the master migrations. A failing
+// tenant does not stop the others.
+func (r *TenantRouter) MigrateTenants(ctx context.Context, migrationsPath string, tenantIDs []uuid.UUID) error {
+	var errs []error
+	migrated := 0
+	for _, tenantID := range tenantIDs {
+		location, err := r.Locate(ctx, tenantID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !location.Dedicated() {
+			continue
+		}
+
+		err = r.Provision(ctx, tenantID)
+		if err == nil {
+			err = r.Dedicated(ctx, tenantID, func(db *gorm.DB) error {
+				manager := NewMigrationManager(db, r.logger, migrationsPath)
+				if err := manager.Initialize(ctx); err != nil {
+					return err
+				}
+				return manager.MigrateUp(ctx)
+			})
+		}
+		if err != nil {
+			r.logger.WithError(err).WithField("tenant_id", tenantID).Error("Failed to migrate tenant")
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenantID, err))
+			continue
+		}
+		migrated++
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"migrated": migrated,
+		"failed":   len(errs),
+	}).Info("Tenant migrations finished")
+	return errors.Join(errs...)
+}
+
+// acquire returns the open pool of the location, opening it if needed, and
+// keeps it open until release
+func (r *TenantRouter) acquire(location TenantLocation) (*tenantPool, error) {
+	key := location.key()
+
+	r.mu.Lock()
+	if pool, ok := r.pools[key]; ok {
+		r.use(pool)
+		r.mu.Unlock()
+		return pool, nil
+	}
+	r.mu.Unlock()
+
+	// Connecting is slow; other tenants are not held up meanwhile
+	db, err := r.open(r.poolConfig(location))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pool of %s: %w", key, err)
+	}
+
+	r.mu.Lock()
+	pool, ok := r.pools[key]
+	if ok {
+		// Another request opened it first
+		r.use(pool)
+		r.mu.Unlock()
+		db.Close()
+		return pool, nil
+	}
+	pool = &tenantPool{key: key, db: db}
+	pool.element = r.lru.PushFront(pool)
+	r.pools[key] = pool
+	r.use(pool)
+	evicted := r.evictOverflow()
+	r.mu.Unlock()
+
+	r.closePools(evicted, "pool limit reached")
+	return pool, nil
+}
+
+// use marks the pool as in use; r.mu must be held
+func (r *TenantRouter) use(pool *tenantPool) {
+	pool.inUse++
+	pool.lastUsed = time.Now()
+	r.lru.MoveToFront(pool.element)
+}
+
+// release ends a use of the pool
+func (r *TenantRouter) release(pool *tenantPool) {
+	r.mu.Lock()
+	pool.inUse--
+	pool.lastUsed = time.Now()
+	r.mu.Unlock()
+}
+
+// evictOverflow removes the least recently used idle pools beyond the pool
+// limit; r.mu must be held. Pools in use are never evicted, so the limit
+// can be exceeded while they are.
+func (r *TenantRouter) evictOverflow() []*tenantPool {
+	var evicted []*tenantPool
+	for element := r.lru.Back(); element != nil && len(r.pools) > r.config.MaxPools; {
+		pool := element.Value.(*tenantPool)
+		element = element.Prev()
+		if pool.inUse == 0 {
+			r.remove(pool)
+			evicted = append(evicted, pool)
+		}
+	}
+	return evicted
+}
+
+// remove forgets the pool; r.mu must be held
+func (r *TenantRouter) remove(pool *tenantPool) {
+	r.lru.Remove(pool.element)
+	delete(r.pools, pool.key)
+}
+
+// evictIdle closes the pools unused since PoolIdleTimeout before now
+func (r *TenantRouter) evictIdle(now time.Time) {
+	var evicted []*tenantPool
+	r.mu.Lock()
+	for element := r.lru.Back(); element != nil; {
+		pool := element.Value.(*tenantPool)
+		element = element.Prev()
+		if pool.inUse == 0 && now.Sub(pool.lastUsed) >= r.config.PoolIdleTimeout {
+			r.remove(pool)
+			evicted = append(evicted, pool)
+		}
+	}
+	r.mu.Unlock()
+
+	r.closePools(evicted, "idle")
+}
+
+// EvictIdle closes idle tenant pools every interval until ctx is done
+func (r *TenantRouter) EvictIdle(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			r.evictIdle(now)
+		}
+	}
+}
+
+func (r *TenantRouter) closePools(pools []*tenantPool, reason string) {
+	for _, pool := range pools {
+		if err := pool.db.Close(); err != nil {
+			r.logger.WithError(err).WithField("pool", pool.key).Warn("Failed to close tenant pool")
+			continue
+		}
+		r.logger.WithFields(logrus.Fields{
+			"pool":   pool.key,
+			"reason": reason,
+		}).Debug("Tenant pool closed")
+	}
+}
+
+// OpenPools returns the number of open tenant pools
+func (r *TenantRouter) OpenPools() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pools)
+}
+
+// Close closes all tenant pools
+func (r *TenantRouter) Close() error {
+	r.mu.Lock()
+	pools := make([]*tenantPool, 0, len(r.pools))
+	for _, pool := range r.pools {
+		pools = append(pools, pool)
+	}
+	r.pools = make(map[string]*tenantPool)
+	r.lru.Init()
+	r.mu.Unlock()
+
+	var errs []error
+	for _, pool := range pools {
+		if err := pool.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close tenant pool %s: %w", pool.key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// poolConfig derives the connection settings of a location from the master
+func (r *TenantRouter) poolConfig(location TenantLocation) *config.DatabaseConfig {
+	cfg := *r.master.Config
+	cfg.MaxOpenConns = r.config.PoolMaxOpenConns
+	if cfg.MaxIdleConns > cfg.MaxOpenConns {
+		cfg.MaxIdleConns = cfg.MaxOpenConns
+	}
+	if location.Mode == TenancySchema {
+		// Only the tenant schema, so nothing reaches the shared tables
+		cfg.SearchPath = quoteIdentifier(location.Schema)
+	} else {
+		cfg.Name = location.Database
+	}
+	return &cfg
+}
+
+// setSearchPath puts the schema first on the search path of the
+// transaction; the shared tables stay reachable under row-level security
+func setSearchPath(tx *gorm.DB, schema string) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	if err := tx.Exec("SELECT set_config('search_path', ?, true)", quoteIdentifier(schema)+", public").Error; err != nil {
+		return fmt.Errorf("failed to set search path: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+)
+
+func openSQLiteDatabase(t *testing.T, cfg *config.DatabaseConfig, log *logrus.Logger) *Database {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&scopedOrder{}))
+	return &Database{DB: db, SQLDB: sqlDB, Logger: log, Config: cfg}
+}
+
+// newSQLiteTenantRouter returns a router whose tenant pools are in-memory
+// databases, and the names of the databases it opened
+func newSQLiteTenantRouter(t *testing.T, cfg config.TenancyConfig) (*TenantRouter, *[]string) {
+	log := logrus.New()
+	log.SetLevel(logrus.PanicLevel)
+
+	master := openSQLiteDatabase(t, &config.DatabaseConfig{Name: "rexi_erp"}, log)
+	t.Cleanup(func() { master.Close() })
+
+	router := NewTenantRouter(master, NewStaticTenancy(cfg), cfg, "rexi_app", log)
+	opened := &[]string{}
+	router.open = func(cfg *config.DatabaseConfig) (*Database, error) {
+		*opened = append(*opened, cfg.Name)
+		return openSQLiteDatabase(t, cfg, log), nil
+	}
+	t.Cleanup(func() { router.Close() })
+	return router, opened
+}
+
+func tenancyConfig(mode string) config.TenancyConfig {
+	return config.TenancyConfig{
+		Mode:             mode,
+		SchemaPrefix:     "tenant_",
+		DatabasePrefix:   "rexi_tenant_",
+		MaxPools:         2,
+		PoolIdleTimeout:  10 * time.Minute,
+		PoolMaxOpenConns: 5,
+	}
+}
+
+func orderNumbers(t *testing.T, db *gorm.DB) []string {
+	var numbers []string
+	require.NoError(t, db.Model(&scopedOrder{}).Order("number").Pluck("number", &numbers).Error)
+	return numbers
+}
+
+func TestStaticTenancy_Locate(t *testing.T) {
+	ctx := context.Background()
+	shared, schema, dedicated := uuid.New(), uuid.New(), uuid.New()
+
+	cfg := tenancyConfig(config.TenancyShared)
+	cfg.Overrides = map[string]string{
+		schema.String():    config.TenancySchema,
+		dedicated.String(): config.TenancyDatabase,
+		"not-a-tenant":     config.TenancyDatabase,
+	}
+	strategy := NewStaticTenancy(cfg)
+
+	location, err := strategy.Locate(ctx, shared)
+	require.NoError(t, err)
+	assert.Equal(t, TenantLocation{Mode: TenancyShared}, location)
+	assert.False(t, location.Dedicated())
+
+	location, err = strategy.Locate(ctx, schema)
+	require.NoError(t, err)
+	assert.Equal(t, TenancySchema, location.Mode)
+	assert.Regexp(t, `^tenant_[0-9a-f]{32}$`, location.Schema)
+
+	location, err = strategy.Locate(ctx, dedicated)
+	require.NoError(t, err)
+	assert.Equal(t, TenancyDatabase, location.Mode)
+	assert.Regexp(t, `^rexi_tenant_[0-9a-f]{32}$`, location.Database)
+
+	_, err = NewStaticTenancy(tenancyConfig("sharded")).Locate(ctx, shared)
+	assert.ErrorContains(t, err, "unknown tenancy mode")
+}
+
+func TestTenantRouter_Run(t *testing.T) {
+	router, _ := newSQLiteTenantRouter(t, tenancyConfig(config.TenancyDatabase))
+	master := router.master.DB
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	var tenantDB *gorm.DB
+	err := router.Run(ctx, tenantID, func(tx *gorm.DB) error {
+		txCtx := tx.Statement.Context
+		current, ok := TenantFromContext(txCtx)
+		assert.True(t, ok)
+		assert.Equal(t, tenantID, current)
+
+		// The master transaction is still reachable for platform tables
+		require.NoError(t, Conn(txCtx, master).Create(&scopedOrder{TenantID: tenantID, Number: "MASTER-1"}).Error)
+
+		routed, release, err := router.DB(txCtx)
+		require.NoError(t, err)
+		defer release()
+		assert.Equal(t, tx.Statement.ConnPool, routed.Statement.ConnPool)
+		tenantDB = tx
+		return tx.Create(&scopedOrder{TenantID: tenantID, Number: "TENANT-1"}).Error
+	})
+	require.NoError(t, err)
+
+	err = router.Run(ctx, tenantID, func(tx *gorm.DB) error {
+		require.NoError(t, Conn(tx.Statement.Context, master).Create(&scopedOrder{TenantID: tenantID, Number: "MASTER-2"}).Error)
+		require.NoError(t, tx.Create(&scopedOrder{TenantID: tenantID, Number: "TENANT-2"}).Error)
+		return errors.New("validation failed")
+	})
+	assert.EqualError(t, err, "validation failed")
+
+	// Both transactions roll back together
+	assert.Equal(t, []string{"MASTER-1"}, orderNumbers(t, master))
+	pool, release, err := router.DB(logger.WithTenantID(ctx, tenantID.String()))
+	require.NoError(t, err)
+	defer release()
+	assert.Equal(t, []string{"TENANT-1"}, orderNumbers(t, pool))
+	assert.NotEqual(t, master.Config.ConnPool, tenantDB.Config.ConnPool)
+	assert.Equal(t, 1, router.OpenPools())
+}
+
+func TestTenantRouter_RunShared(t *testing.T) {
+	router, opened := newSQLiteTenantRouter(t, tenancyConfig(config.TenancyShared))
+	tenantID := uuid.New()
+
+	err := router.Run(context.Background(), tenantID, func(tx *gorm.DB) error {
+		return tx.Create(&scopedOrder{TenantID: tenantID, Number: "SO-1"}).Error
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"SO-1"}, orderNumbers(t, router.master.DB))
+	assert.Empty(t, *opened)
+	assert.ErrorContains(t, router.Dedicated(context.Background(), tenantID, func(*gorm.DB) error { return nil }), "no dedicated")
+	assert.ErrorIs(t, router.Run(context.Background(), uuid.Nil, func(*gorm.DB) error { return nil }), ErrTenantRequired)
+}
+
+func TestTenantRouter_PoolEviction(t *testing.T) {
+	router, opened := newSQLiteTenantRouter(t, tenancyConfig(config.TenancyDatabase))
+	ctx := context.Background()
+	tenantA, tenantB, tenantC := uuid.New(), uuid.New(), uuid.New()
+	use := func(tenantID uuid.UUID) {
+		require.NoError(t, router.Dedicated(ctx, tenantID, func(*gorm.DB) error { return nil }))
+	}
+	pools := func() []string {
+		router.mu.Lock()
+		defer router.mu.Unlock()
+		var keys []string
+		for element := router.lru.Front(); element != nil; element = element.Next() {
+			keys = append(keys, element.Value.(*tenantPool).key)
+		}
+		return keys
+	}
+	key := func(tenantID uuid.UUID) string {
+		location, err := router.Locate(ctx, tenantID)
+		require.NoError(t, err)
+		return location.key()
+	}
+
+	use(tenantA)
+	use(tenantB)
+	use(tenantA)
+	assert.Len(t, *opened, 2, "pools are reused")
+
+	// The least recently used pool makes room
+	use(tenantC)
+	assert.Equal(t, []string{key(tenantC), key(tenantA)}, pools())
+
+	// Pools in use are kept beyond the limit
+	require.NoError(t, router.Dedicated(ctx, tenantA, func(*gorm.DB) error {
+		return router.Dedicated(ctx, tenantC, func(*gorm.DB) error {
+			use(tenantB)
+			assert.Len(t, pools(), 3)
+			return nil
+		})
+	}))
+	assert.Len(t, pools(), 3)
+
+	router.evictIdle(time.Now().Add(time.Minute))
+	assert.Len(t, pools(), 3)
+	router.evictIdle(time.Now().Add(router.config.PoolIdleTimeout))
+	assert.Empty(t, pools())
+	assert.Equal(t, 0, router.OpenPools())
+}
+
+func TestTenantRouter_DBHoldsPool(t *testing.T) {
+	cfg := tenancyConfig(config.TenancyDatabase)
+	cfg.MaxPools = 1
+	router, opened := newSQLiteTenantRouter(t, cfg)
+	ctx := context.Background()
+	tenantA, tenantB := uuid.New(), uuid.New()
+
+	db, release, err := router.DB(logger.WithTenantID(ctx, tenantA.String()))
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&scopedOrder{}))
+
+	// Neither the idle timeout nor the pool limit closes a held pool
+	router.evictIdle(time.Now().Add(router.config.PoolIdleTimeout))
+	require.NoError(t, router.Dedicated(ctx, tenantB, func(*gorm.DB) error { return nil }))
+	assert.Len(t, *opened, 2)
+	require.NoError(t, db.Create(&scopedOrder{TenantID: tenantA, Number: "SO-1"}).Error)
+
+	// Releasing twice ends a single use
+	release()
+	release()
+	router.evictIdle(time.Now().Add(router.config.PoolIdleTimeout))
+	assert.Equal(t, 0, router.OpenPools())
+	assert.Error(t, db.Create(&scopedOrder{TenantID: tenantA, Number: "SO-2"}).Error, "the pool is closed once released and idle")
+}
+
+func TestDatabase_GetTenantDBWithTenancy(t *testing.T) {
+	cfg := tenancyConfig(config.TenancyShared)
+	dedicated := uuid.New()
+	cfg.Overrides = map[string]string{dedicated.String(): config.TenancyDatabase}
+	router, opened := newSQLiteTenantRouter(t, cfg)
+	router.master.UseTenancy(router)
+
+	db, release, err := router.master.GetTenantDB(uuid.New().String())
+	require.NoError(t, err)
+	assert.Equal(t, router.master.DB.Config.ConnPool, db.Statement.ConnPool)
+	release()
+
+	db, release, err = router.master.GetTenantDB(dedicated.String())
+	require.NoError(t, err)
+	assert.NotEqual(t, router.master.DB.Config.ConnPool, db.Statement.ConnPool)
+	assert.Len(t, *opened, 1)
+	release()
+
+	_, _, err = router.master.GetTenantDB("tenant-123")
+	assert.ErrorIs(t, err, ErrTenantRequired)
+}
+
+func TestTenantRouter_MigrateTenants(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "001_create_ledgers.up.sql"),
+		[]byte("CREATE TABLE ledgers (id INTEGER PRIMARY KEY, name TEXT NOT NULL);"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "001_create_ledgers.down.sql"),
+		[]byte("DROP TABLE ledgers;"), 0o644))
+
+	cfg := tenancyConfig(config.TenancyShared)
+	dedicated := uuid.New()
+	cfg.Overrides = map[string]string{dedicated.String(): config.TenancyDatabase}
+	router, opened := newSQLiteTenantRouter(t, cfg)
+	ctx := context.Background()
+
+	require.NoError(t, router.MigrateTenants(ctx, dir, []uuid.UUID{uuid.New(), dedicated}))
+	assert.Len(t, *opened, 1, "shared tenants are skipped")
+	assert.False(t, router.master.DB.Migrator().HasTable("ledgers"))
+
+	require.NoError(t, router.Dedicated(ctx, dedicated, func(db *gorm.DB) error {
+		assert.True(t, db.Migrator().HasTable("ledgers"))
+		var applied int64
+		require.NoError(t, db.Model(&Migration{}).Where("applied = ?", true).Count(&applied).Error)
+		assert.Equal(t, int64(1), applied)
+		return nil
+	}))
+
+	// Reruns find nothing pending
+	require.NoError(t, router.MigrateTenants(ctx, dir, []uuid.UUID{dedicated}))
+}
+
+func TestTenantRouter_MigrateTenantsSchema(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	log := logrus.New()
+	log.SetLevel(logrus.PanicLevel)
+	master, err := NewDatabase(&config.DatabaseConfig{
+		Type:         config.DatabaseTypePostgreSQL,
+		Host:         getEnv("TEST_DB_HOST", "localhost"),
+		Port:         getEnvInt("TEST_DB_PORT", 5432),
+		Name:         getEnv("TEST_DB_NAME", "rexi_erp_test"),
+		User:         getEnv("TEST_DB_USER", "rexi"),
+		Password:     getEnv("TEST_DB_PASSWORD", "password"),
+		SSLMode:      "disable",
+		MaxOpenConns: 5,
+		MaxIdleConns: 2,
+	}, log)
+	if err != nil {
+		t.Skipf("Skipping test - database not available: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+
+	cfg := tenancyConfig(config.TenancySchema)
+	router := NewTenantRouter(master, NewStaticTenancy(cfg), cfg, "", log)
+	t.Cleanup(func() { router.Close() })
+	ctx := context.Background()
+
+	tenantID := uuid.New()
+	location, err := router.Locate(ctx, tenantID)
+	require.NoError(t, err)
+	t.Cleanup(func() { master.DB.Exec("DROP SCHEMA IF EXISTS " + quoteIdentifier(location.Schema) + " CASCADE") })
+
+	require.NoError(t, router.MigrateTenants(ctx, filepath.Join("..", "..", "..", "migrations", "tenants"), []uuid.UUID{tenantID}))
+
+	var tables []string
+	require.NoError(t, master.DB.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = ?", location.Schema).Scan(&tables).Error)
+	for _, table := range []string{
+		"chart_of_accounts", "product_categories", "products", "customers", "suppliers", "warehouses",
+		"inventory_movements", "inventory_stocks", "sales_orders", "sales_order_items", "purchase_orders",
+		"purchase_order_items", "invoices", "invoice_items", "payments", "journal_entries",
+		"journal_entry_lines", "numbering_sequences", "audit_logs", "migrations",
+	} {
+		assert.Contains(t, tables, table)
+	}
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TenantSetting is the PostgreSQL setting the row-level security policies
+// of migration 011 compare tenant_id with
+const TenantSetting = "app.tenant_id"
+
+var (
+	// ErrTenantRequired is returned when a tenant transaction has no tenant
+	ErrTenantRequired = errors.New("tenant ID is required")
+	// ErrTenantMismatch is returned when a tenant transaction is started
+	// inside the transaction of another tenant
+	ErrTenantMismatch = errors.New("already in a transaction of another tenant")
+)
+
+type tenantTxKey struct{}
+
+// tenantTx is the transaction of a tenant carried by a context. A request
+// of a tenant with a dedicated database holds one on the master and one on
+// its database; parent links them.
+type tenantTx struct {
+	tx       *gorm.DB
+	tenantID uuid.UUID
+	pool     gorm.ConnPool
+	parent   *tenantTx
+}
+
+// on returns the transaction on pool
+func (t *tenantTx) on(pool gorm.ConnPool) *tenantTx {
+	for ; t != nil; t = t.parent {
+		if t.pool == pool {
+			return t
+		}
+	}
+	return nil
+}
+
+// TenantScope runs work in transactions that PostgreSQL restricts to the
+// rows of one tenant. The role and tenant are set with SET LOCAL, so they
+// end with the transaction and never leak to the next user of the pooled
+// connection.
+type TenantScope struct {
+	db   *gorm.DB
+	role string
+}
+
+// NewTenantScope creates a tenant scope. Transactions switch to role, which
+// must not own the tables or bypass row-level security; an empty role keeps
+// the login user.
+func NewTenantScope(db *gorm.DB, role string) *TenantScope {
+	return &TenantScope{db: db, role: role}
+}
+
+// Run calls fn in a transaction of the tenant and commits it unless fn
+// returns an error. The context of the transaction carries it, so code
+// using Conn joins it. Run inside a transaction of the same tenant on the
+// same database joins that transaction.
+func (s *TenantScope) Run(ctx context.Context, tenantID uuid.UUID, fn func(tx *gorm.DB) error) error {
+	if tenantID == uuid.Nil {
+		return ErrTenantRequired
+	}
+	current, _ := ctx.Value(tenantTxKey{}).(*tenantTx)
+	if current != nil && current.tenantID != tenantID {
+		return fmt.Errorf("%w: %s", ErrTenantMismatch, current.tenantID)
+	}
+	if joined := current.on(s.db.Config.ConnPool); joined != nil {
+		return fn(joined.tx.WithContext(ctx))
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.begin(tx, tenantID); err != nil {
+			return err
+		}
+
+		scoped := &tenantTx{tenantID: tenantID, pool: s.db.Config.ConnPool, parent: current}
+		scoped.tx = tx.WithContext(context.WithValue(ctx, tenantTxKey{}, scoped))
+		return fn(scoped.tx)
+	})
+}
+
+// begin switches the transaction to the application role and tenant
+func (s *TenantScope) begin(tx *gorm.DB, tenantID uuid.UUID) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	if s.role != "" {
+		if err := tx.Exec("SET LOCAL ROLE " + quoteIdentifier(s.role)).Error; err != nil {
+			return fmt.Errorf("failed to switch to role %s: %w", s.role, err)
+		}
+	}
+	// SET LOCAL takes no parameters; set_config with is_local does the same
+	if err := tx.Exec("SELECT set_config(?, ?, true)", TenantSetting, tenantID.String()).Error; err != nil {
+		return fmt.Errorf("failed to set tenant: %w", err)
+	}
+	return nil
+}
+
+// TenantFromContext returns the tenant of the transaction ctx carries
+func TenantFromContext(ctx context.Context) (uuid.UUID, bool) {
+	current, ok := ctx.Value(tenantTxKey{}).(*tenantTx)
+	if !ok {
+		return uuid.Nil, false
+	}
+	return current.tenantID, true
+}
+
+// Conn returns the tenant transaction on db that ctx carries, or db outside
+// of one. Repositories use it so their queries run under row-level security
+// when the request has a tenant.
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	current, _ := ctx.Value(tenantTxKey{}).(*tenantTx)
+	if joined := current.on(db.Config.ConnPool); joined != nil {
+		return joined.tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// Conn returns the tenant transaction ctx carries, or the database outside
+// of one
+func (d *Database) Conn(ctx context.Context) *gorm.DB {
+	return Conn(ctx, d.DB)
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type scopedOrder struct {
+	ID       uint      `gorm:"primaryKey"`
+	TenantID uuid.UUID `gorm:"type:uuid;not null"`
+	Number   string    `gorm:"not null"`
+}
+
+func (scopedOrder) TableName() string { return "rls_test_orders" }
+
+func newSQLiteTenantScope(t *testing.T) (*TenantScope, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&scopedOrder{}))
+	return NewTenantScope(db, "rexi_app"), db
+}
+
+func TestTenantScope_Run(t *testing.T) {
+	scope, db := newSQLiteTenantScope(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	err := scope.Run(ctx, tenantID, func(tx *gorm.DB) error {
+		current, ok := TenantFromContext(tx.Statement.Context)
+		assert.True(t, ok)
+		assert.Equal(t, tenantID, current)
+
+		// Code handed the context joins the transaction
+		return Conn(tx.Statement.Context, db).Create(&scopedOrder{TenantID: tenantID, Number: "SO-1"}).Error
+	})
+	require.NoError(t, err)
+
+	err = scope.Run(ctx, tenantID, func(tx *gorm.DB) error {
+		require.NoError(t, Conn(tx.Statement.Context, db).Create(&scopedOrder{TenantID: tenantID, Number: "SO-2"}).Error)
+		return errors.New("validation failed")
+	})
+	assert.EqualError(t, err, "validation failed")
+
+	var numbers []string
+	require.NoError(t, db.Model(&scopedOrder{}).Order("number").Pluck("number", &numbers).Error)
+	assert.Equal(t, []string{"SO-1"}, numbers)
+
+	_, ok := TenantFromContext(ctx)
+	assert.False(t, ok)
+	assert.ErrorIs(t, scope.Run(ctx, uuid.Nil, func(*gorm.DB) error { return nil }), ErrTenantRequired)
+}
+
+func TestTenantScope_Nested(t *testing.T) {
+	scope, _ := newSQLiteTenantScope(t)
+	tenantID := uuid.New()
+
+	err := scope.Run(context.Background(), tenantID, func(outer *gorm.DB) error {
+		ctx := outer.Statement.Context
+
+		joined := false
+		require.NoError(t, scope.Run(ctx, tenantID, func(inner *gorm.DB) error {
+			joined = inner.Statement.ConnPool == outer.Statement.ConnPool
+			return nil
+		}))
+		assert.True(t, joined)
+
+		err := scope.Run(ctx, uuid.New(), func(*gorm.DB) error { return nil })
+		assert.ErrorIs(t, err, ErrTenantMismatch)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// TestTenantScope_RowLevelSecurity proves that PostgreSQL keeps tenants
+// apart even when queries forget their tenant_id condition
+func TestTenantScope_RowLevelSecurity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	db := setupTestDatabase(t)
+	defer cleanupTestDatabase(t, db)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// One connection proves the settings do not outlive the transaction
+	sqlDB.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	require.NoError(t, db.Exec("DROP TABLE IF EXISTS rls_test_orders").Error)
+	require.NoError(t, db.AutoMigrate(&scopedOrder{}))
+	defer db.Exec("DROP TABLE IF EXISTS rls_test_orders")
+
+	migration, err := os.ReadFile(filepath.Join("..", "..", "..", "migrations", "master", "011_row_level_security.up.sql"))
+	require.NoError(t, err)
+	if err := db.Exec(string(migration)).Error; err != nil {
+		t.Skipf("Skipping test - cannot apply row-level security migration: %v", err)
+	}
+
+	tenantA, tenantB := uuid.New(), uuid.New()
+	require.NoError(t, db.Create([]*scopedOrder{
+		{TenantID: tenantA, Number: "A-1"},
+		{TenantID: tenantA, Number: "A-2"},
+		{TenantID: tenantB, Number: "B-1"},
+	}).Error)
+
+	scope := NewTenantScope(db, "rexi_app")
+
+	t.Run("ReadsWithoutWhere", func(t *testing.T) {
+		var numbers []string
+		require.NoError(t, scope.Run(ctx, tenantA, func(tx *gorm.DB) error {
+			return tx.Model(&scopedOrder{}).Order("number").Pluck("number", &numbers).Error
+		}))
+		assert.Equal(t, []string{"A-1", "A-2"}, numbers)
+	})
+
+	t.Run("UpdatesWithoutWhere", func(t *testing.T) {
+		var affected int64
+		require.NoError(t, scope.Run(ctx, tenantB, func(tx *gorm.DB) error {
+			result := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Model(&scopedOrder{}).Update("number", "B-X")
+			affected = result.RowsAffected
+			return result.Error
+		}))
+		assert.Equal(t, int64(1), affected)
+
+		var count int64
+		require.NoError(t, db.Model(&scopedOrder{}).Where("number = ?", "B-X").Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("WritesToOtherTenant", func(t *testing.T) {
+		err := scope.Run(ctx, tenantA, func(tx *gorm.DB) error {
+			return tx.Create(&scopedOrder{TenantID: tenantB, Number: "B-2"}).Error
+		})
+		assert.ErrorContains(t, err, "row-level security")
+	})
+
+	t.Run("NoTenantSeesNothing", func(t *testing.T) {
+		var count int64
+		require.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+			require.NoError(t, tx.Exec("SET LOCAL ROLE rexi_app").Error)
+			return tx.Model(&scopedOrder{}).Count(&count).Error
+		}))
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("SettingsEndWithTransaction", func(t *testing.T) {
+		var setting, role string
+		require.NoError(t, db.Raw("SELECT COALESCE(current_setting(?, true), '')", TenantSetting).Scan(&setting).Error)
+		require.NoError(t, db.Raw("SELECT current_user").Scan(&role).Error)
+		assert.Empty(t, setting)
+		assert.NotEqual(t, "rexi_app", role)
+	})
+}
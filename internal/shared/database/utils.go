@@ -3,6 +3,8 @@ package database
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // QueryBuilder provides utilities for building database queries
@@ -113,8 +116,16 @@ func (qb *QueryBuilder) ApplyPagination(pagination Pagination) *gorm.DB {
 			sortDir = "ASC"
 		}
 
-		// Validate sort field to prevent SQL injection
-		if qb.isValidSortField(pagination.SortBy) {
+		// Models that declare their sort fields only sort by those; other
+		// sort fields are validated to prevent SQL injection
+		if sortable, ok := qb.db.Statement.Model.(Sortable); ok {
+			if field, found := lookupSortField(sortable, pagination.SortBy); found {
+				query = query.Order(clause.OrderByColumn{
+					Column: clause.Column{Table: clause.CurrentTable, Name: field.Column},
+					Desc:   sortDir == "DESC",
+				})
+			}
+		} else if qb.isValidSortField(pagination.SortBy) {
 			query = query.Order(fmt.Sprintf("%s %s", pagination.SortBy, sortDir))
 		}
 	}
@@ -382,48 +393,6 @@ func (cpm *ConnectionPoolManager) OptimizePool() error {
 	return nil
 }
 
-// BackupManager provides database backup utilities
-type BackupManager struct {
-	db     *Database
-	logger *logrus.Logger
-}
-
-// NewBackupManager creates a new backup manager
-func NewBackupManager(db *Database, logger *logrus.Logger) *BackupManager {
-	return &BackupManager{
-		db:     db,
-		logger: logger,
-	}
-}
-
-// CreateBackup creates a database backup
-func (bm *BackupManager) CreateBackup(ctx context.Context, backupPath string) error {
-	// This is a placeholder for backup functionality
-	// In production, you would implement database-specific backup logic
-
-	bm.logger.WithField("path", backupPath).Info("Creating database backup")
-
-	// Example for PostgreSQL:
-	// cmd := exec.CommandContext(ctx, "pg_dump", "--format=custom", "--no-owner", "--no-privileges", dbName)
-	// output, err := cmd.Output()
-	// if err != nil {
-	//     return fmt.Errorf("backup failed: %w", err)
-	// }
-	// return os.WriteFile(backupPath, output, 0644)
-
-	return fmt.Errorf("backup functionality not implemented")
-}
-
-// RestoreBackup restores a database from backup
-func (bm *BackupManager) RestoreBackup(ctx context.Context, backupPath string) error {
-	// This is a placeholder for restore functionality
-	// In production, you would implement database-specific restore logic
-
-	bm.logger.WithField("path", backupPath).Info("Restoring database from backup")
-
-	return fmt.Errorf("restore functionality not implemented")
-}
-
 // MigrationHelper provides migration utilities
 type MigrationHelper struct {
 	migrationManager *MigrationManager
@@ -438,10 +407,20 @@ func NewMigrationHelper(mm *MigrationManager, logger *logrus.Logger) *MigrationH
 	}
 }
 
-// CreateMigrationTemplate creates a new migration template
-func (mh *MigrationHelper) CreateMigrationTemplate(version, description string) error {
+// migrationNameSeparators are replaced by underscores in migration file names
+var migrationNameSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CreateMigrationTemplate writes an up and down migration template to the
+// migrations directory and returns their paths. Existing files are never
+// overwritten.
+func (mh *MigrationHelper) CreateMigrationTemplate(version, description string) (string, string, error) {
+	name := strings.Trim(migrationNameSeparators.ReplaceAllString(strings.ToLower(description), "_"), "_")
+	if version == "" || name == "" {
+		return "", "", fmt.Errorf("migration version and description are required")
+	}
+
 	// Create up migration file
-	upFile := fmt.Sprintf("migrations/master/%s_%s.up.sql", version, strings.ReplaceAll(description, " ", "_"))
+	upFile := filepath.Join(mh.migrationManager.migrationsPath, fmt.Sprintf("%s_%s.up.sql", version, name))
 	upContent := fmt.Sprintf(`-- Migration: %s
 -- Description: %s
 -- Version: %s
@@ -458,7 +437,7 @@ func (mh *MigrationHelper) CreateMigrationTemplate(version, description string)
 `, description, description, version, time.Now().Format(time.RFC3339))
 
 	// Create down migration file
-	downFile := fmt.Sprintf("migrations/master/%s_%s.down.sql", version, strings.ReplaceAll(description, " ", "_"))
+	downFile := filepath.Join(mh.migrationManager.migrationsPath, fmt.Sprintf("%s_%s.down.sql", version, name))
 	downContent := fmt.Sprintf(`-- Rollback: %s
 -- Description: %s
 -- Version: %s
@@ -469,15 +448,31 @@ func (mh *MigrationHelper) CreateMigrationTemplate(version, description string)
 -- DROP TABLE IF EXISTS example_table;
 `, description, description, version, time.Now().Format(time.RFC3339))
 
-	// Use the template contents (prevents unused variable warnings)
-	_ = upContent
-	_ = downContent
+	if err := writeNewFile(upFile, upContent); err != nil {
+		return "", "", err
+	}
+	if err := writeNewFile(downFile, downContent); err != nil {
+		os.Remove(upFile)
+		return "", "", err
+	}
 
-	// Write files (implementation depends on your file system)
 	mh.logger.WithFields(logrus.Fields{
 		"up_file":   upFile,
 		"down_file": downFile,
 	}).Info("Migration template created")
 
-	return nil
-}
\ No newline at end of file
+	return upFile, downFile, nil
+}
+
+// writeNewFile writes content to a file that must not exist yet
+func writeNewFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// VersionColumn holds the version of versioned models. Updates passed to
+// Repository.Update name the version they were based on under this key.
+const VersionColumn = "version"
+
+// ErrStaleObject is returned when a record changed since the version an
+// update was based on
+var ErrStaleObject = errors.New("record was modified by another request")
+
+// Versioned is implemented by models with optimistic locking
+type Versioned interface {
+	GetVersion() int64
+}
+
+// Versioning holds the version of optimistic locking. Models that declare
+// their own ID and timestamps, such as users, embed it directly and must
+// increment the version on every update.
+type Versioning struct {
+	Version int64 `gorm:"not null;default:1" json:"version"`
+}
+
+// GetVersion returns the version of the record
+func (v Versioning) GetVersion() int64 {
+	return v.Version
+}
+
+// VersionedModel is a BaseModel with a version that every update through
+// Repository.Update increments, so concurrent edits are detected instead of
+// overwriting each other. Models opt in by embedding it instead of BaseModel.
+// The version is not on BaseModel itself because GORM maps every field of an
+// embedded struct, so each table embedding BaseModel would then need a
+// version column; only the tables migrated to have one embed this.
+type VersionedModel struct {
+	BaseModel
+	Versioning
+}
+
+// BeforeCreate sets the timestamps, the ID and the first version
+func (v *VersionedModel) BeforeCreate(tx *gorm.DB) error {
+	if v.Version == 0 {
+		v.Version = 1
+	}
+	return v.BaseModel.BeforeCreate(tx)
+}
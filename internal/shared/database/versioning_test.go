@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type versionedProduct struct {
+	VersionedModel
+	Name string
+}
+
+func (versionedProduct) TableName() string {
+	return "versioned_products"
+}
+
+func newVersionedRepository(t *testing.T) *Repository[versionedProduct] {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	// BaseModel defaults to gen_random_uuid(), which SQLite lacks
+	require.NoError(t, db.Exec(`CREATE TABLE versioned_products (
+		id TEXT PRIMARY KEY, tenant_id TEXT NOT NULL, created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL, deleted_at DATETIME, version INTEGER NOT NULL DEFAULT 1, name TEXT)`).Error)
+	return NewRepository[versionedProduct](db)
+}
+
+func findProduct(t *testing.T, repo *Repository[versionedProduct], id uuid.UUID) *versionedProduct {
+	var product versionedProduct
+	require.NoError(t, repo.GetDB().First(&product, "id = ?", id).Error)
+	return &product
+}
+
+func TestRepository_UpdateVersioned(t *testing.T) {
+	repo := newVersionedRepository(t)
+	ctx := context.Background()
+
+	product := &versionedProduct{VersionedModel: VersionedModel{BaseModel: BaseModel{TenantID: uuid.New()}}, Name: "Kopi"}
+	require.NoError(t, repo.Create(ctx, product))
+	assert.Equal(t, int64(1), product.GetVersion())
+
+	// An update based on the current version increments it
+	require.NoError(t, repo.Update(ctx, product.ID, map[string]interface{}{"name": "Kopi Susu", VersionColumn: int64(1)}))
+	current := findProduct(t, repo, product.ID)
+	assert.Equal(t, "Kopi Susu", current.Name)
+	assert.Equal(t, int64(2), current.Version)
+
+	// A second editor still holding version 1 loses instead of overwriting
+	err := repo.Update(ctx, product.ID, map[string]interface{}{"name": "Teh", VersionColumn: int64(1)})
+	assert.ErrorIs(t, err, ErrStaleObject)
+	current = findProduct(t, repo, product.ID)
+	assert.Equal(t, "Kopi Susu", current.Name)
+
+	// Updates without a version still increment it
+	require.NoError(t, repo.Update(ctx, product.ID, map[string]interface{}{"name": "Teh"}))
+	current = findProduct(t, repo, product.ID)
+	assert.Equal(t, int64(3), current.Version)
+
+	err = repo.Update(ctx, uuid.New(), map[string]interface{}{"name": "Teh", VersionColumn: int64(1)})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -1,9 +1,13 @@
 package health
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -55,29 +59,75 @@ type MemoryInfo struct {
 	NumGC      uint32 `json:"num_gc"`
 }
 
+// Check reports whether a dependency is usable
+type Check func(ctx context.Context) error
+
+// checkTimeout bounds each registered check
+const checkTimeout = 3 * time.Second
+
 // HealthChecker provides health check functionality
 type HealthChecker struct {
-	db     *database.Database
-	config *config.Config
-	logger *logrus.Logger
+	db        *database.Database
+	checkDB   bool
+	config    *config.Config
+	logger    *logrus.Logger
 	startTime time.Time
+
+	mu       sync.RWMutex
+	checks   map[string]Check
+	draining atomic.Bool
 }
 
-// NewHealthChecker creates a new health checker
+// NewHealthChecker creates a new health checker that requires db
 func NewHealthChecker(db *database.Database, cfg *config.Config, logger *logrus.Logger) *HealthChecker {
+	h := NewChecker(cfg, logger)
+	h.SetDatabase(db)
+	return h
+}
+
+// NewChecker creates a health checker without a database, for services
+// that add their dependencies with SetDatabase and AddCheck
+func NewChecker(cfg *config.Config, logger *logrus.Logger) *HealthChecker {
 	return &HealthChecker{
-		db:     db,
-		config: cfg,
-		logger: logger,
+		config:    cfg,
+		logger:    logger,
 		startTime: time.Now(),
+		checks:    make(map[string]Check),
 	}
 }
 
+// SetDatabase makes readiness depend on db
+func (h *HealthChecker) SetDatabase(db *database.Database) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.db = db
+	h.checkDB = true
+}
+
+// AddCheck makes readiness depend on check, e.g. a Redis ping
+func (h *HealthChecker) AddCheck(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// SetDraining marks the service as shutting down. Readiness fails from then
+// on so load balancers stop sending traffic while requests drain; liveness
+// keeps passing so the instance is not killed early.
+func (h *HealthChecker) SetDraining() {
+	h.draining.Store(true)
+}
+
+// IsDraining reports whether the service is shutting down
+func (h *HealthChecker) IsDraining() bool {
+	return h.draining.Load()
+}
+
 // RegisterRoutes registers health check routes
-func (h *HealthChecker) RegisterRoutes(router *gin.Engine) {
+func (h *HealthChecker) RegisterRoutes(router gin.IRouter) {
 	health := router.Group("/health")
 	{
-		health.GET("/", h.BasicHealth)
+		health.GET("", h.BasicHealth)
 		health.GET("/live", h.Liveness)
 		health.GET("/ready", h.Readiness)
 		health.GET("/detailed", h.DetailedHealth)
@@ -89,7 +139,7 @@ func (h *HealthChecker) BasicHealth(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":    StatusHealthy,
 		"timestamp": time.Now(),
-		"message":   "API Gateway is healthy",
+		"message":   "Service is healthy",
 	})
 }
 
@@ -108,11 +158,19 @@ func (h *HealthChecker) Readiness(c *gin.Context) {
 	checks := make(map[string]CheckResult)
 	overallStatus := StatusHealthy
 
-	// Check database connection
-	dbStatus := h.checkDatabase()
-	checks["database"] = dbStatus
-	if dbStatus.Status != StatusHealthy {
+	if h.IsDraining() {
+		checks["shutdown"] = CheckResult{
+			Status:  StatusUnhealthy,
+			Message: "Service is shutting down",
+		}
 		overallStatus = StatusUnhealthy
+	} else {
+		for name, result := range h.runChecks(c.Request.Context()) {
+			checks[name] = result
+			if result.Status != StatusHealthy {
+				overallStatus = StatusUnhealthy
+			}
+		}
 	}
 
 	response := HealthResponse{
@@ -136,11 +194,12 @@ func (h *HealthChecker) DetailedHealth(c *gin.Context) {
 	checks := make(map[string]CheckResult)
 	overallStatus := StatusHealthy
 
-	// Database check
-	dbStatus := h.checkDatabase()
-	checks["database"] = dbStatus
-	if dbStatus.Status != StatusHealthy {
-		overallStatus = StatusDegraded
+	// Database and registered dependency checks
+	for name, result := range h.runChecks(c.Request.Context()) {
+		checks[name] = result
+		if result.Status != StatusHealthy {
+			overallStatus = StatusDegraded
+		}
 	}
 
 	// Configuration check
@@ -176,6 +235,45 @@ func (h *HealthChecker) DetailedHealth(c *gin.Context) {
 	}
 }
 
+// runChecks runs the database check, when required, and the registered checks
+func (h *HealthChecker) runChecks(ctx context.Context) map[string]CheckResult {
+	h.mu.RLock()
+	checkDB := h.checkDB
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	h.mu.RUnlock()
+
+	results := make(map[string]CheckResult, len(names)+1)
+	if checkDB {
+		results["database"] = h.checkDatabase()
+	}
+	for i, name := range names {
+		results[name] = h.runCheck(ctx, name, checks[i])
+	}
+	return results
+}
+
+func (h *HealthChecker) runCheck(ctx context.Context, name string, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	if err := check(ctx); err != nil {
+		h.logger.WithError(err).WithField("check", name).Error("Health check failed")
+		return CheckResult{
+			Status:  StatusUnhealthy,
+			Message: fmt.Sprintf("%s check failed: %v", name, err),
+		}
+	}
+	return CheckResult{Status: StatusHealthy}
+}
+
 // checkDatabase validates the database connection
 func (h *HealthChecker) checkDatabase() CheckResult {
 	if h.db == nil {
@@ -214,7 +312,7 @@ func (h *HealthChecker) checkDatabase() CheckResult {
 		}
 	}
 
-	if stats.OpenConnections >= h.config.Database.MaxOpenConns*9/10 {
+	if stats.OpenConnections >= h.config.Databases.Master.MaxOpenConns*9/10 {
 		return CheckResult{
 			Status:  StatusDegraded,
 			Message: "Database connection pool near capacity",
@@ -1,7 +1,9 @@
 package health
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -82,11 +84,13 @@ func TestHealthChecker_Readiness(t *testing.T) {
 			Version:     "1.0.0",
 			Environment: "test",
 		},
-		Database: config.DatabaseConfig{
-			MaxOpenConns:    10,
-			MaxIdleConns:    5,
-			ConnMaxLifetime: 1 * time.Hour,
-			ConnMaxIdleTime: 30 * time.Minute,
+		Databases: config.DatabaseConfigs{
+			Master: config.DatabaseConfig{
+				MaxOpenConns:    10,
+				MaxIdleConns:    5,
+				ConnMaxLifetime: 1 * time.Hour,
+				ConnMaxIdleTime: 30 * time.Minute,
+			},
 		},
 	}
 
@@ -124,11 +128,13 @@ func TestHealthChecker_DetailedHealth(t *testing.T) {
 		APIKey: config.APIKeyConfig{
 			Keys: []string{"test-key"},
 		},
-		Database: config.DatabaseConfig{
-			MaxOpenConns:    10,
-			MaxIdleConns:    5,
-			ConnMaxLifetime: 1 * time.Hour,
-			ConnMaxIdleTime: 30 * time.Minute,
+		Databases: config.DatabaseConfigs{
+			Master: config.DatabaseConfig{
+				MaxOpenConns:    10,
+				MaxIdleConns:    5,
+				ConnMaxLifetime: 1 * time.Hour,
+				ConnMaxIdleTime: 30 * time.Minute,
+			},
 		},
 	}
 
@@ -235,8 +241,10 @@ func TestHealthChecker_SystemResourcesCheck(t *testing.T) {
 	logger.SetLevel(logrus.ErrorLevel)
 
 	cfg := &config.Config{
-		Database: config.DatabaseConfig{
-			MaxOpenConns: 10,
+		Databases: config.DatabaseConfigs{
+			Master: config.DatabaseConfig{
+				MaxOpenConns: 10,
+			},
 		},
 	}
 
@@ -295,6 +303,70 @@ func TestSetupDefaultHealthRoutes(t *testing.T) {
 	assert.True(t, routePaths["/health/detailed"])
 }
 
+func TestHealthChecker_RegisteredChecks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	healthChecker := NewChecker(&config.Config{}, logger)
+	router := gin.New()
+	healthChecker.RegisterRoutes(router)
+
+	ready := func() (int, HealthResponse) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/health/ready", nil))
+		var response HealthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	// Without a database only the registered checks count
+	code, response := ready()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, response.Checks)
+
+	var redisErr error
+	healthChecker.AddCheck("redis", func(ctx context.Context) error {
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		return redisErr
+	})
+	code, response = ready()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusHealthy, response.Checks["redis"].Status)
+
+	redisErr = errors.New("connection refused")
+	code, response = ready()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusUnhealthy, response.Checks["redis"].Status)
+	assert.Contains(t, response.Checks["redis"].Message, "connection refused")
+}
+
+func TestHealthChecker_Draining(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	healthChecker := NewChecker(&config.Config{}, logger)
+	router := gin.New()
+	healthChecker.RegisterRoutes(router)
+
+	healthChecker.SetDraining()
+	assert.True(t, healthChecker.IsDraining())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/health/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var response HealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, StatusUnhealthy, response.Checks["shutdown"].Status)
+
+	// Liveness keeps passing so the instance is not restarted mid-drain
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/health/live", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 func TestBToMb(t *testing.T) {
 	testCases := []struct {
 		name     string
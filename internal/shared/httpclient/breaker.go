@@ -0,0 +1,138 @@
+package httpclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the host while its breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker
+type BreakerState string
+
+const (
+	// BreakerClosed lets every call through
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects calls until the cooldown has passed
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a limited number of probe calls through
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// breaker is a consecutive-failure circuit breaker for a single host
+type breaker struct {
+	threshold     int
+	cooldown      time.Duration
+	maxProbes     int
+	now           func() time.Time
+	onStateChange func(from, to BreakerState)
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+func newBreaker(config BreakerConfig, now func() time.Time, onStateChange func(from, to BreakerState)) *breaker {
+	return &breaker{
+		threshold:     config.FailureThreshold,
+		cooldown:      config.Cooldown,
+		maxProbes:     config.HalfOpenProbes,
+		now:           now,
+		onStateChange: onStateChange,
+		state:         BreakerClosed,
+	}
+}
+
+// State returns the current state, moving an open breaker whose cooldown
+// has passed to half open
+func (b *breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// allow reports whether a call may go through. Every allowed call must be
+// followed by exactly one call to record.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	switch b.state {
+	case BreakerOpen:
+		return ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probes >= b.maxProbes {
+			return ErrCircuitOpen
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// record reports the outcome of an allowed call
+func (b *breaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+
+	if success {
+		b.failures = 0
+		if b.state == BreakerHalfOpen {
+			b.transition(BreakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	switch b.state {
+	case BreakerHalfOpen:
+		b.trip()
+	case BreakerClosed:
+		if b.failures >= b.threshold {
+			b.trip()
+		}
+	}
+}
+
+// release gives back a probe slot for a call whose outcome says nothing
+// about the host, e.g. one cancelled by the caller
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *breaker) trip() {
+	b.openedAt = b.now()
+	b.transition(BreakerOpen)
+}
+
+func (b *breaker) refresh() {
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		b.transition(BreakerHalfOpen)
+	}
+}
+
+func (b *breaker) transition(to BreakerState) {
+	from := b.state
+	b.state = to
+	b.probes = 0
+	if to == BreakerClosed {
+		b.failures = 0
+	}
+	if b.onStateChange != nil && from != to {
+		b.onStateChange(from, to)
+	}
+}
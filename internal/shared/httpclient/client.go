@@ -0,0 +1,468 @@
+// Package httpclient provides the HTTP client services use to call each
+// other. On top of tracing it adds per-host circuit breakers, retries with
+// jittered exponential backoff for idempotent requests, bulkheads that cap
+// concurrent calls and forwarding of the caller's deadline, correlation ID
+// and auth token.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+	"github.com/VincentArjuna/RexiErp/internal/shared/tracing"
+)
+
+// TimeoutHeader carries the time the caller has left, in milliseconds, so
+// the called service can give up once nobody is waiting for the answer
+const TimeoutHeader = "X-Request-Timeout-Ms"
+
+// ErrBulkheadFull is returned when a host already has the maximum number of
+// calls in flight and no slot freed up within the queue timeout
+var ErrBulkheadFull = errors.New("too many concurrent calls")
+
+// CallRecorder records the outcome of calls. It is implemented by
+// metrics.ExternalServiceMiddleware.
+type CallRecorder interface {
+	RecordCall(ctx context.Context, serviceName, endpoint string, duration time.Duration, success bool)
+}
+
+// Config configures a Client
+type Config struct {
+	// ServiceName labels recorded calls, e.g. "inventory-service"
+	ServiceName string
+	// Timeout bounds each attempt; zero leaves attempts bound only by the
+	// request context
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// MaxConcurrent caps calls in flight per host; zero disables the bulkhead
+	MaxConcurrent int
+	// QueueTimeout is how long a call waits for a bulkhead slot
+	QueueTimeout time.Duration
+	Breaker      BreakerConfig
+	// Transport is the underlying transport, http.DefaultTransport if nil
+	Transport http.RoundTripper
+}
+
+// BreakerConfig configures the per-host circuit breakers. A breaker opens
+// after FailureThreshold consecutive failed calls, rejects calls for
+// Cooldown and then lets HalfOpenProbes calls through to test the host.
+type BreakerConfig struct {
+	// FailureThreshold of zero disables circuit breaking
+	FailureThreshold int
+	Cooldown         time.Duration
+	HalfOpenProbes   int
+}
+
+// DefaultConfig returns the default client configuration
+func DefaultConfig(serviceName string) Config {
+	return Config{
+		ServiceName:    serviceName,
+		Timeout:        10 * time.Second,
+		MaxRetries:     2,
+		RetryBaseDelay: 100 * time.Millisecond,
+		RetryMaxDelay:  2 * time.Second,
+		MaxConcurrent:  100,
+		QueueTimeout:   time.Second,
+		Breaker: BreakerConfig{
+			FailureThreshold: 5,
+			Cooldown:         30 * time.Second,
+			HalfOpenProbes:   1,
+		},
+	}
+}
+
+// Client is a resilient HTTP client for calls to other services
+type Client struct {
+	config   Config
+	http     *http.Client
+	recorder CallRecorder
+	logger   *logrus.Logger
+
+	// Replaced in tests
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func(n int64) int64
+
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+// host holds the breaker and bulkhead of a single host
+type host struct {
+	breaker  *breaker
+	bulkhead chan struct{}
+}
+
+// NewClient creates a client. recorder may be nil.
+func NewClient(config Config, recorder CallRecorder, logger *logrus.Logger) *Client {
+	if config.Breaker.HalfOpenProbes <= 0 {
+		config.Breaker.HalfOpenProbes = 1
+	}
+
+	client := tracing.TracedHTTPClient(config.Transport)
+	return &Client{
+		config:   config,
+		http:     client,
+		recorder: recorder,
+		logger:   logger,
+		now:      time.Now,
+		sleep:    sleepContext,
+		jitter:   rand.Int63n, // #nosec G404 -- backoff jitter needs no cryptographic randomness
+		hosts:    make(map[string]*host),
+	}
+}
+
+// Get issues a GET request
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return c.Do(req)
+}
+
+// Do sends a request. The caller must close the response body, which also
+// frees the request's bulkhead slot. Non-idempotent requests are never
+// retried, and requests with a body are only retried when it can be
+// replayed through req.GetBody.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	endpoint := endpointName(ctx, req)
+	h := c.host(req.URL.Host)
+
+	release, err := h.acquire(ctx, c.config.QueueTimeout)
+	if err != nil {
+		c.record(ctx, endpoint, 0, false)
+		return nil, fmt.Errorf("%s: %w", req.URL.Host, err)
+	}
+
+	start := time.Now()
+	resp, err := c.execute(ctx, req, h)
+	c.record(ctx, endpoint, time.Since(start), err == nil && resp.StatusCode < http.StatusInternalServerError)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	resp.Body = &closeHook{ReadCloser: resp.Body, onClose: release}
+	return resp, nil
+}
+
+// execute runs the attempts of a request
+func (c *Client) execute(ctx context.Context, req *http.Request, h *host) (*http.Response, error) {
+	retryable := c.config.MaxRetries > 0 && idempotent(req.Method) && replayable(req)
+
+	for attempt := 0; ; attempt++ {
+		if h.breaker != nil {
+			if err := h.breaker.allow(); err != nil {
+				return nil, fmt.Errorf("%s: %w", req.URL.Host, err)
+			}
+		}
+
+		resp, err := c.attempt(ctx, req, attempt)
+		callerDone := ctx.Err() != nil
+		if h.breaker != nil {
+			switch {
+			case callerDone:
+				// The caller gave up; that says nothing about the host
+				h.breaker.release()
+			default:
+				h.breaker.record(err == nil && resp.StatusCode < http.StatusInternalServerError)
+			}
+		}
+
+		if callerDone || !retryable || attempt >= c.config.MaxRetries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := c.backoff(attempt, resp)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			// Another attempt could not finish in time
+			return resp, err
+		}
+		if resp != nil {
+			discard(resp.Body)
+		}
+
+		c.logger.WithFields(logrus.Fields{
+			"service":        c.config.ServiceName,
+			"host":           req.URL.Host,
+			"method":         req.Method,
+			"attempt":        attempt + 1,
+			"delay_ms":       delay.Milliseconds(),
+			"correlation_id": logger.GetCorrelationID(ctx),
+			"error":          attemptError(resp, err),
+		}).Debug("Retrying service call")
+
+		if err := c.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends a single copy of req
+func (c *Client) attempt(ctx context.Context, req *http.Request, attempt int) (*http.Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if c.config.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+	}
+
+	out := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to replay request body: %w", err)
+		}
+		out.Body = body
+	}
+	forwardHeaders(ctx, out)
+
+	resp, err := c.http.Do(out)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The attempt context must outlive the call until the body is read
+	resp.Body = &closeHook{ReadCloser: resp.Body, onClose: cancel}
+	return resp, nil
+}
+
+// backoff returns the delay before the retry following attempt, using full
+// jitter over an exponentially growing window. A Retry-After header from
+// the host is honoured up to RetryMaxDelay.
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, c.config.RetryMaxDelay)
+		}
+	}
+
+	window := c.config.RetryMaxDelay
+	if attempt < 32 {
+		if d := c.config.RetryBaseDelay << attempt; d > 0 && d < window {
+			window = d
+		}
+	}
+	if window <= 0 {
+		return 0
+	}
+	return time.Duration(c.jitter(int64(window)) + 1)
+}
+
+// BreakerState returns the state of the breaker for a host, e.g.
+// "inventory-service:8003"
+func (c *Client) BreakerState(hostname string) BreakerState {
+	h := c.host(hostname)
+	if h.breaker == nil {
+		return BreakerClosed
+	}
+	return h.breaker.State()
+}
+
+func (c *Client) host(hostname string) *host {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if h, ok := c.hosts[hostname]; ok {
+		return h
+	}
+
+	h := &host{}
+	if c.config.Breaker.FailureThreshold > 0 {
+		h.breaker = newBreaker(c.config.Breaker, c.now, func(from, to BreakerState) {
+			c.logger.WithFields(logrus.Fields{
+				"service": c.config.ServiceName,
+				"host":    hostname,
+				"from":    from,
+				"to":      to,
+			}).Warn("Circuit breaker state changed")
+		})
+	}
+	if c.config.MaxConcurrent > 0 {
+		h.bulkhead = make(chan struct{}, c.config.MaxConcurrent)
+	}
+	c.hosts[hostname] = h
+	return h
+}
+
+func (c *Client) record(ctx context.Context, endpoint string, duration time.Duration, success bool) {
+	if c.recorder != nil {
+		c.recorder.RecordCall(ctx, c.config.ServiceName, endpoint, duration, success)
+	}
+}
+
+// acquire takes a bulkhead slot and returns the function that frees it
+func (h *host) acquire(ctx context.Context, wait time.Duration) (func(), error) {
+	if h.bulkhead == nil {
+		return func() {}, nil
+	}
+
+	release := func() { <-h.bulkhead }
+	select {
+	case h.bulkhead <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case h.bulkhead <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, ErrBulkheadFull
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type authTokenKey struct{}
+type endpointKey struct{}
+
+// WithAuthToken returns a context whose calls carry token as a bearer token
+func WithAuthToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, authTokenKey{}, token)
+}
+
+// GetAuthToken returns the bearer token stored in the context
+func GetAuthToken(ctx context.Context) string {
+	if token, ok := ctx.Value(authTokenKey{}).(string); ok {
+		return token
+	}
+	return ""
+}
+
+// ContextFromRequest returns the context of an incoming request together
+// with its bearer token, so calls made while handling it act on behalf of
+// the same user
+func ContextFromRequest(r *http.Request) context.Context {
+	ctx := r.Context()
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+		ctx = WithAuthToken(ctx, token)
+	}
+	return ctx
+}
+
+// WithEndpoint names the endpoint calls are recorded under, e.g.
+// "stock-valuation". By default the method and path are used with IDs
+// replaced by :id.
+func WithEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+func endpointName(ctx context.Context, req *http.Request) string {
+	if endpoint, ok := ctx.Value(endpointKey{}).(string); ok && endpoint != "" {
+		return endpoint
+	}
+
+	segments := strings.Split(req.URL.Path, "/")
+	for i, segment := range segments {
+		if _, err := strconv.ParseUint(segment, 10, 64); err == nil {
+			segments[i] = ":id"
+		} else if _, err := uuid.Parse(segment); err == nil && len(segment) == 36 {
+			segments[i] = ":id"
+		}
+	}
+	return req.Method + " " + strings.Join(segments, "/")
+}
+
+// forwardHeaders adds the caller's correlation ID, auth token and remaining
+// time unless the request already sets them
+func forwardHeaders(ctx context.Context, req *http.Request) {
+	if req.Header.Get(tracing.CorrelationIDHeader) == "" {
+		if correlationID := logger.GetCorrelationID(ctx); correlationID != "" {
+			req.Header.Set(tracing.CorrelationIDHeader, correlationID)
+		}
+	}
+	if req.Header.Get("Authorization") == "" {
+		if token := GetAuthToken(ctx); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := max(time.Until(deadline).Milliseconds(), 1)
+		req.Header.Set(TimeoutHeader, strconv.FormatInt(remaining, 10))
+	}
+}
+
+// idempotent reports whether a method may safely be sent more than once
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// shouldRetry reports whether an attempt failed in a way another attempt
+// might not
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented
+}
+
+func attemptError(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
+
+// discard drains a little of an unused body so the connection can be reused
+func discard(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 4096))
+	_ = body.Close()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeHook runs onClose once when the body is closed
+type closeHook struct {
+	io.ReadCloser
+	once    sync.Once
+	onClose func()
+}
+
+func (b *closeHook) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.onClose)
+	return err
+}
@@ -0,0 +1,445 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+	"github.com/VincentArjuna/RexiErp/internal/shared/tracing"
+)
+
+// flakyServer answers with failStatus for the next failures requests and
+// 200 afterwards. block holds requests until it is closed.
+type flakyServer struct {
+	*httptest.Server
+	failures   atomic.Int32
+	failStatus atomic.Int32
+	delay      atomic.Int64
+	hits       atomic.Int32
+
+	mu      sync.Mutex
+	headers []http.Header
+	bodies  []string
+	block   chan struct{}
+}
+
+func newFlakyServer(t *testing.T) *flakyServer {
+	s := &flakyServer{}
+	s.failStatus.Store(http.StatusServiceUnavailable)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.hits.Add(1)
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.headers = append(s.headers, r.Header.Clone())
+		s.bodies = append(s.bodies, string(body))
+		block := s.block
+		s.mu.Unlock()
+
+		if block != nil {
+			<-block
+		}
+		if delay := time.Duration(s.delay.Load()); delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if s.failures.Add(-1) >= 0 {
+			w.WriteHeader(int(s.failStatus.Load()))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// failNext makes the next n requests fail with status
+func (s *flakyServer) failNext(n int32, status int) {
+	s.failStatus.Store(int32(status))
+	s.failures.Store(n)
+}
+
+func (s *flakyServer) lastHeader() http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.headers[len(s.headers)-1]
+}
+
+func (s *flakyServer) host() string {
+	u, _ := url.Parse(s.URL)
+	return u.Host
+}
+
+type call struct {
+	service  string
+	endpoint string
+	success  bool
+}
+
+type fakeRecorder struct {
+	mu    sync.Mutex
+	calls []call
+}
+
+func (r *fakeRecorder) RecordCall(_ context.Context, serviceName, endpoint string, _ time.Duration, success bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call{serviceName, endpoint, success})
+}
+
+func (r *fakeRecorder) recorded() []call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]call(nil), r.calls...)
+}
+
+func testConfig() Config {
+	config := DefaultConfig("inventory-service")
+	config.RetryBaseDelay = time.Millisecond
+	config.RetryMaxDelay = 5 * time.Millisecond
+	config.Breaker.FailureThreshold = 0
+	return config
+}
+
+func newTestClient(config Config) (*Client, *fakeRecorder) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	recorder := &fakeRecorder{}
+	return NewClient(config, recorder, log), recorder
+}
+
+func get(t *testing.T, client *Client, ctx context.Context, target string) (*http.Response, error) {
+	t.Helper()
+	resp, err := client.Get(ctx, target)
+	if resp != nil {
+		t.Cleanup(func() { resp.Body.Close() })
+	}
+	return resp, err
+}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	server := newFlakyServer(t)
+	server.failNext(2, http.StatusServiceUnavailable)
+	client, recorder := newTestClient(testConfig())
+
+	resp, err := get(t, client, context.Background(), server.URL+"/api/v1/products/42")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), server.hits.Load())
+	assert.Equal(t, []call{{"inventory-service", "GET /api/v1/products/:id", true}}, recorder.recorded())
+}
+
+func TestClient_GivesUpAfterMaxRetries(t *testing.T) {
+	server := newFlakyServer(t)
+	server.failNext(10, http.StatusBadGateway)
+	client, recorder := newTestClient(testConfig())
+
+	resp, err := get(t, client, context.Background(), server.URL+"/stock")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(3), server.hits.Load())
+	assert.False(t, recorder.recorded()[0].success)
+}
+
+func TestClient_DoesNotRetryClientErrors(t *testing.T) {
+	server := newFlakyServer(t)
+	server.failNext(1, http.StatusNotFound)
+	client, recorder := newTestClient(testConfig())
+
+	resp, err := get(t, client, context.Background(), server.URL+"/stock")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, int32(1), server.hits.Load())
+	assert.True(t, recorder.recorded()[0].success)
+}
+
+func TestClient_DoesNotRetryNonIdempotentMethods(t *testing.T) {
+	server := newFlakyServer(t)
+	server.failNext(1, http.StatusServiceUnavailable)
+	client, _ := newTestClient(testConfig())
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/journal-entries", bytes.NewReader([]byte(`{}`)))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), server.hits.Load())
+}
+
+func TestClient_ReplaysBodyOnRetry(t *testing.T) {
+	server := newFlakyServer(t)
+	server.failNext(1, http.StatusServiceUnavailable)
+	client, _ := newTestClient(testConfig())
+
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/products/1", bytes.NewReader([]byte(`{"sku":"A-1"}`)))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{`{"sku":"A-1"}`, `{"sku":"A-1"}`}, server.bodies)
+}
+
+func TestClient_RetriesTransportErrors(t *testing.T) {
+	server := newFlakyServer(t)
+	target := server.URL
+	server.Close()
+	client, recorder := newTestClient(testConfig())
+
+	_, err := get(t, client, context.Background(), target)
+
+	require.Error(t, err)
+	require.Len(t, recorder.recorded(), 1)
+	assert.False(t, recorder.recorded()[0].success)
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	server := newFlakyServer(t)
+	config := testConfig()
+	config.MaxRetries = 0
+	config.Breaker = BreakerConfig{FailureThreshold: 2, Cooldown: time.Minute, HalfOpenProbes: 1}
+	client, _ := newTestClient(config)
+
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	server.failNext(3, http.StatusInternalServerError)
+	for i := 0; i < 2; i++ {
+		resp, err := get(t, client, context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+	assert.Equal(t, BreakerOpen, client.BreakerState(server.host()))
+
+	// Open: calls fail fast without reaching the host
+	_, err := get(t, client, context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), server.hits.Load())
+
+	// Half open: a failed probe opens the breaker again
+	now = now.Add(time.Minute)
+	assert.Equal(t, BreakerHalfOpen, client.BreakerState(server.host()))
+	_, err = get(t, client, context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, BreakerOpen, client.BreakerState(server.host()))
+
+	// A successful probe closes it
+	now = now.Add(time.Minute)
+	resp, err := get(t, client, context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, BreakerClosed, client.BreakerState(server.host()))
+}
+
+func TestClient_CircuitBreakerIsPerHost(t *testing.T) {
+	failing := newFlakyServer(t)
+	healthy := newFlakyServer(t)
+	config := testConfig()
+	config.MaxRetries = 0
+	config.Breaker = BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute}
+	client, _ := newTestClient(config)
+
+	failing.failNext(1, http.StatusServiceUnavailable)
+	_, err := get(t, client, context.Background(), failing.URL)
+	require.NoError(t, err)
+
+	_, err = get(t, client, context.Background(), failing.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	resp, err := get(t, client, context.Background(), healthy.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestClient_Bulkhead(t *testing.T) {
+	server := newFlakyServer(t)
+	server.block = make(chan struct{})
+	config := testConfig()
+	config.MaxConcurrent = 1
+	config.QueueTimeout = 20 * time.Millisecond
+	client, recorder := newTestClient(config)
+
+	first := make(chan *http.Response)
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		assert.NoError(t, err)
+		first <- resp
+	}()
+	require.Eventually(t, func() bool { return server.hits.Load() == 1 }, time.Second, time.Millisecond)
+
+	_, err := get(t, client, context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrBulkheadFull)
+	assert.Equal(t, int32(1), server.hits.Load())
+	assert.False(t, recorder.recorded()[0].success)
+
+	// The slot is held until the body of the first call is closed
+	server.mu.Lock()
+	close(server.block)
+	server.block = nil
+	server.mu.Unlock()
+	resp := <-first
+	_, err = get(t, client, context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrBulkheadFull)
+
+	resp.Body.Close()
+	resp, err = get(t, client, context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestClient_PropagatesDeadline(t *testing.T) {
+	server := newFlakyServer(t)
+	server.delay.Store(int64(time.Second))
+	client, _ := newTestClient(testConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := get(t, client, ctx, server.URL)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(1), server.hits.Load(), "no retries once the caller's deadline has passed")
+
+	remaining, err := strconv.Atoi(server.lastHeader().Get(TimeoutHeader))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, remaining, 50)
+	assert.Positive(t, remaining)
+}
+
+func TestClient_AttemptTimeoutIsRetried(t *testing.T) {
+	server := newFlakyServer(t)
+	server.delay.Store(int64(time.Second))
+	config := testConfig()
+	config.Timeout = 20 * time.Millisecond
+	client, _ := newTestClient(config)
+
+	_, err := get(t, client, context.Background(), server.URL)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(3), server.hits.Load())
+}
+
+func TestClient_SkipsRetryThatCannotFinishInTime(t *testing.T) {
+	server := newFlakyServer(t)
+	server.failNext(1, http.StatusServiceUnavailable)
+	config := testConfig()
+	config.RetryBaseDelay = time.Second
+	config.RetryMaxDelay = time.Second
+	client, _ := newTestClient(config)
+	client.jitter = func(n int64) int64 { return n - 1 }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	resp, err := get(t, client, ctx, server.URL)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), server.hits.Load())
+}
+
+func TestClient_ForwardsCorrelationIDAndAuthToken(t *testing.T) {
+	server := newFlakyServer(t)
+	client, _ := newTestClient(testConfig())
+
+	incoming := httptest.NewRequest(http.MethodGet, "/api/v1/valuations", nil)
+	incoming.Header.Set("Authorization", "Bearer user-token")
+	ctx := logger.WithCorrelationID(ContextFromRequest(incoming), "corr-123")
+
+	_, err := get(t, client, ctx, server.URL)
+	require.NoError(t, err)
+
+	header := server.lastHeader()
+	assert.Equal(t, "corr-123", header.Get(tracing.CorrelationIDHeader))
+	assert.Equal(t, "Bearer user-token", header.Get("Authorization"))
+	remaining, err := strconv.Atoi(header.Get(TimeoutHeader))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, remaining, 10000, "bounded by the attempt timeout")
+}
+
+func TestClient_KeepsExplicitHeaders(t *testing.T) {
+	server := newFlakyServer(t)
+	client, _ := newTestClient(testConfig())
+
+	ctx := WithAuthToken(logger.WithCorrelationID(context.Background(), "corr-123"), "user-token")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer service-token")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "Bearer service-token", server.lastHeader().Get("Authorization"))
+}
+
+func TestClient_Backoff(t *testing.T) {
+	config := testConfig()
+	config.RetryBaseDelay = 100 * time.Millisecond
+	config.RetryMaxDelay = time.Second
+	client, _ := newTestClient(config)
+	client.jitter = func(n int64) int64 { return n - 1 }
+
+	assert.Equal(t, 100*time.Millisecond, client.backoff(0, nil))
+	assert.Equal(t, 400*time.Millisecond, client.backoff(2, nil))
+	assert.Equal(t, time.Second, client.backoff(10, nil))
+	assert.Equal(t, time.Second, client.backoff(100, nil))
+
+	client.jitter = func(int64) int64 { return 0 }
+	assert.Equal(t, time.Duration(1), client.backoff(3, nil))
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"0"}}}
+	assert.Equal(t, time.Duration(0), client.backoff(3, resp))
+	resp.Header.Set("Retry-After", "120")
+	assert.Equal(t, time.Second, client.backoff(0, resp))
+}
+
+func TestClient_EndpointName(t *testing.T) {
+	server := newFlakyServer(t)
+	client, recorder := newTestClient(testConfig())
+
+	_, err := get(t, client, context.Background(), server.URL+"/api/v1/tenants/550e8400-e29b-41d4-a716-446655440000/items/7")
+	require.NoError(t, err)
+	_, err = get(t, client, WithEndpoint(context.Background(), "stock-valuation"), server.URL+"/api/v1/valuations")
+	require.NoError(t, err)
+
+	calls := recorder.recorded()
+	assert.Equal(t, "GET /api/v1/tenants/:id/items/:id", calls[0].endpoint)
+	assert.Equal(t, "stock-valuation", calls[1].endpoint)
+}
+
+func TestClient_CancelledCallerDoesNotTripBreaker(t *testing.T) {
+	server := newFlakyServer(t)
+	server.delay.Store(int64(time.Second))
+	config := testConfig()
+	config.Breaker = BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute}
+	client, _ := newTestClient(config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := get(t, client, ctx, server.URL)
+
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, BreakerClosed, client.BreakerState(server.host()))
+}
@@ -0,0 +1,275 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+)
+
+const (
+	// tenantOriginTTL is how long a tenant domain lookup is cached
+	tenantOriginTTL = 5 * time.Minute
+	// maxTenantOrigins bounds the lookup cache, since origins are chosen by
+	// the client
+	maxTenantOrigins = 10000
+)
+
+// OriginLookup reports whether a host is the custom domain of an active
+// tenant
+type OriginLookup interface {
+	IsTenantOrigin(ctx context.Context, host string) (bool, error)
+}
+
+// OriginLookupFunc adapts a function to OriginLookup
+type OriginLookupFunc func(ctx context.Context, host string) (bool, error)
+
+// IsTenantOrigin calls f
+func (f OriginLookupFunc) IsTenantOrigin(ctx context.Context, host string) (bool, error) {
+	return f(ctx, host)
+}
+
+// originPattern matches an origin, or with a wildcard any subdomain of host
+type originPattern struct {
+	scheme   string
+	host     string
+	port     string
+	wildcard bool
+}
+
+type cachedOrigin struct {
+	allowed   bool
+	expiresAt time.Time
+}
+
+// CORSMiddleware applies the configured cross-origin policy. Configured and
+// tenant origins are echoed back with credentials; origins only allowed by
+// "*" get a wildcard response without credentials.
+type CORSMiddleware struct {
+	cfg       config.CORSConfig
+	patterns  []originPattern
+	anyOrigin bool
+	methods   map[string]bool
+	headers   map[string]bool
+	logger    *logrus.Logger
+	now       func() time.Time
+
+	mu      sync.RWMutex
+	lookup  OriginLookup
+	tenants map[string]cachedOrigin
+}
+
+// NewCORSMiddleware creates a CORS middleware. Invalid origin patterns are
+// logged and ignored.
+func NewCORSMiddleware(cfg config.CORSConfig, logger *logrus.Logger) *CORSMiddleware {
+	m := &CORSMiddleware{
+		cfg:     cfg,
+		methods: make(map[string]bool),
+		headers: make(map[string]bool),
+		logger:  logger,
+		now:     time.Now,
+		tenants: make(map[string]cachedOrigin),
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.TrimSpace(origin)
+		if origin == "*" {
+			m.anyOrigin = true
+			continue
+		}
+		pattern, ok := parseOriginPattern(origin)
+		if !ok {
+			logger.WithField("origin", origin).Warn("Ignoring invalid CORS origin")
+			continue
+		}
+		m.patterns = append(m.patterns, pattern)
+	}
+	for _, method := range cfg.AllowedMethods {
+		m.methods[strings.ToUpper(strings.TrimSpace(method))] = true
+	}
+	for _, header := range cfg.AllowedHeaders {
+		m.headers[strings.ToLower(strings.TrimSpace(header))] = true
+	}
+	return m
+}
+
+// UseOriginLookup allows the custom domains of tenants when enabled in the
+// configuration. Services set it once their database is connected.
+func (m *CORSMiddleware) UseOriginLookup(lookup OriginLookup) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lookup = lookup
+	m.tenants = make(map[string]cachedOrigin)
+}
+
+// Handler returns the gin middleware. Preflight requests are answered
+// directly; a preflight that is not allowed is rejected with 403.
+func (m *CORSMiddleware) Handler() gin.HandlerFunc {
+	allowMethods := strings.Join(m.cfg.AllowedMethods, ", ")
+	allowHeaders := strings.Join(m.cfg.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(m.cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(m.cfg.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if !m.cfg.Enabled || origin == "" {
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		allowed, specific := m.allowOrigin(c.Request.Context(), origin)
+		if !allowed {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// The browser withholds the response without CORS headers
+			c.Next()
+			return
+		}
+
+		if specific {
+			header.Set("Access-Control-Allow-Origin", origin)
+			if m.cfg.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+		} else {
+			header.Set("Access-Control-Allow-Origin", "*")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		if !m.allowRequest(c.GetHeader("Access-Control-Request-Method"), c.GetHeader("Access-Control-Request-Headers")) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		if allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		}
+		if m.cfg.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// allowOrigin reports whether origin is allowed and whether it was matched
+// specifically rather than by "*"
+func (m *CORSMiddleware) allowOrigin(ctx context.Context, origin string) (bool, bool) {
+	parsed, ok := parseOrigin(origin)
+	if ok {
+		for _, pattern := range m.patterns {
+			if pattern.matches(parsed) {
+				return true, true
+			}
+		}
+		if parsed.scheme == "https" && m.isTenantOrigin(ctx, parsed.host) {
+			return true, true
+		}
+	}
+	return m.anyOrigin, false
+}
+
+func (m *CORSMiddleware) allowRequest(method, requestHeaders string) bool {
+	if !m.methods[strings.ToUpper(strings.TrimSpace(method))] {
+		return false
+	}
+	for _, header := range strings.Split(requestHeaders, ",") {
+		header = strings.ToLower(strings.TrimSpace(header))
+		if header != "" && !m.headers[header] {
+			return false
+		}
+	}
+	return true
+}
+
+// isTenantOrigin looks up and caches whether host is a tenant's custom
+// domain. Lookup failures deny the origin without caching.
+func (m *CORSMiddleware) isTenantOrigin(ctx context.Context, host string) bool {
+	if !m.cfg.TenantDomains {
+		return false
+	}
+
+	m.mu.RLock()
+	lookup := m.lookup
+	entry, cached := m.tenants[host]
+	m.mu.RUnlock()
+	if lookup == nil {
+		return false
+	}
+	if cached && m.now().Before(entry.expiresAt) {
+		return entry.allowed
+	}
+
+	allowed, err := lookup.IsTenantOrigin(ctx, host)
+	if err != nil {
+		m.logger.WithError(err).WithField("host", host).Warn("Failed to look up tenant origin")
+		return false
+	}
+
+	m.mu.Lock()
+	if len(m.tenants) >= maxTenantOrigins {
+		m.tenants = make(map[string]cachedOrigin)
+	}
+	m.tenants[host] = cachedOrigin{allowed: allowed, expiresAt: m.now().Add(tenantOriginTTL)}
+	m.mu.Unlock()
+	return allowed
+}
+
+// parseOrigin splits a serialized origin; anything with a path, query,
+// credentials or the opaque "null" origin is rejected
+func parseOrigin(origin string) (originPattern, bool) {
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Host == "" || u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return originPattern{}, false
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return originPattern{}, false
+	}
+	return originPattern{scheme: u.Scheme, host: u.Hostname(), port: u.Port()}, true
+}
+
+// parseOriginPattern parses an allowed origin, which may start its host
+// with "*." to allow every subdomain
+func parseOriginPattern(origin string) (originPattern, bool) {
+	wildcard := false
+	if i := strings.Index(origin, "://*."); i >= 0 {
+		wildcard = true
+		origin = origin[:i+3] + origin[i+5:]
+	}
+	pattern, ok := parseOrigin(origin)
+	pattern.wildcard = wildcard
+	return pattern, ok
+}
+
+func (p originPattern) matches(origin originPattern) bool {
+	if p.scheme != origin.scheme || p.port != origin.port {
+		return false
+	}
+	if p.wildcard {
+		return strings.HasSuffix(origin.host, "."+p.host)
+	}
+	return p.host == origin.host
+}
@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+)
+
+func testCORSConfig(origins ...string) config.CORSConfig {
+	return config.CORSConfig{
+		Enabled:          true,
+		AllowedOrigins:   origins,
+		TenantDomains:    true,
+		AllowedMethods:   []string{"GET", "POST", "PUT"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{"X-Correlation-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+}
+
+func newCORSRouter(m *CORSMiddleware) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(m.Handler())
+	router.Any("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func newTestCORS(cfg config.CORSConfig) *CORSMiddleware {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	return NewCORSMiddleware(cfg, logger)
+}
+
+func preflight(router *gin.Engine, origin, method, headers string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, "/test", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func corsRequest(router *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/test", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	router := newCORSRouter(newTestCORS(testCORSConfig("https://rexi-erp.com")))
+
+	w := preflight(router, "https://rexi-erp.com", "PUT", "content-type, Authorization")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://rexi-erp.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, POST, PUT", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(t, w.Header().Get("Access-Control-Expose-Headers"))
+	assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, w.Header().Values("Vary"))
+}
+
+func TestCORSMiddleware_RejectsPreflight(t *testing.T) {
+	router := newCORSRouter(newTestCORS(testCORSConfig("https://rexi-erp.com")))
+
+	tests := []struct {
+		name    string
+		origin  string
+		method  string
+		headers string
+	}{
+		{"unknown origin", "https://evil.example", "GET", ""},
+		{"method not allowed", "https://rexi-erp.com", "DELETE", ""},
+		{"header not allowed", "https://rexi-erp.com", "POST", "Content-Type, X-Debug"},
+		{"null origin", "null", "GET", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := preflight(router, tt.origin, tt.method, tt.headers)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+		})
+	}
+}
+
+func TestCORSMiddleware_MatchesOrigins(t *testing.T) {
+	router := newCORSRouter(newTestCORS(testCORSConfig("https://*.rexi-erp.com", "http://localhost:3000")))
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.rexi-erp.com", true},
+		{"https://a.b.rexi-erp.com", true},
+		{"https://APP.Rexi-Erp.com", true},
+		{"https://rexi-erp.com", false},
+		{"http://app.rexi-erp.com", false},
+		{"https://app.rexi-erp.com:8443", false},
+		{"https://evilrexi-erp.com", false},
+		{"https://rexi-erp.com.evil.example", false},
+		{"https://app.rexi-erp.com/path", false},
+		{"http://localhost:3000", true},
+		{"http://localhost:3001", false},
+		{"http://localhost", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			w := corsRequest(router, http.MethodGet, tt.origin)
+			assert.Equal(t, http.StatusOK, w.Code)
+			if tt.allowed {
+				assert.Equal(t, tt.origin, w.Header().Get("Access-Control-Allow-Origin"))
+				assert.Equal(t, "X-Correlation-ID", w.Header().Get("Access-Control-Expose-Headers"))
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware_AnyOriginWithoutCredentials(t *testing.T) {
+	router := newCORSRouter(newTestCORS(testCORSConfig("https://rexi-erp.com", "*")))
+
+	w := preflight(router, "https://anywhere.example", "GET", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+
+	w = corsRequest(router, http.MethodGet, "https://rexi-erp.com")
+	assert.Equal(t, "https://rexi-erp.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSMiddleware_PassesThrough(t *testing.T) {
+	router := newCORSRouter(newTestCORS(testCORSConfig("https://rexi-erp.com")))
+
+	// Without Access-Control-Request-Method an OPTIONS request is not a preflight
+	w := corsRequest(router, http.MethodOptions, "https://rexi-erp.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://rexi-erp.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = corsRequest(router, http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Vary"))
+
+	cfg := testCORSConfig("https://rexi-erp.com")
+	cfg.Enabled = false
+	w = preflight(newCORSRouter(newTestCORS(cfg)), "https://rexi-erp.com", "GET", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSMiddleware_TenantDomains(t *testing.T) {
+	lookups := 0
+	cors := newTestCORS(testCORSConfig("https://rexi-erp.com"))
+	cors.UseOriginLookup(OriginLookupFunc(func(ctx context.Context, host string) (bool, error) {
+		lookups++
+		switch host {
+		case "erp.tokobudi.co.id":
+			return true, nil
+		case "down.example":
+			return false, errors.New("connection refused")
+		}
+		return false, nil
+	}))
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	cors.now = func() time.Time { return now }
+	router := newCORSRouter(cors)
+
+	w := preflight(router, "https://erp.tokobudi.co.id", "POST", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://erp.tokobudi.co.id", w.Header().Get("Access-Control-Allow-Origin"))
+	corsRequest(router, http.MethodPost, "https://erp.tokobudi.co.id")
+	assert.Equal(t, 1, lookups)
+
+	// Custom domains are only trusted over HTTPS
+	assert.Empty(t, corsRequest(router, http.MethodGet, "http://erp.tokobudi.co.id").Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, 1, lookups)
+
+	assert.Equal(t, http.StatusForbidden, preflight(router, "https://unknown.example", "GET", "").Code)
+	assert.Equal(t, http.StatusForbidden, preflight(router, "https://unknown.example", "GET", "").Code)
+	assert.Equal(t, 2, lookups)
+
+	// Failed lookups deny the origin and are retried
+	assert.Equal(t, http.StatusForbidden, preflight(router, "https://down.example", "GET", "").Code)
+	assert.Equal(t, http.StatusForbidden, preflight(router, "https://down.example", "GET", "").Code)
+	assert.Equal(t, 4, lookups)
+
+	now = now.Add(tenantOriginTTL)
+	corsRequest(router, http.MethodGet, "https://erp.tokobudi.co.id")
+	assert.Equal(t, 5, lookups)
+
+	cfg := testCORSConfig("https://rexi-erp.com")
+	cfg.TenantDomains = false
+	disabled := newTestCORS(cfg)
+	disabled.UseOriginLookup(OriginLookupFunc(func(ctx context.Context, host string) (bool, error) {
+		return true, nil
+	}))
+	assert.Equal(t, http.StatusForbidden, preflight(newCORSRouter(disabled), "https://erp.tokobudi.co.id", "GET", "").Code)
+}
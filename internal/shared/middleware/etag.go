@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+const (
+	// ETagHeader carries the version of a versioned resource
+	ETagHeader = "ETag"
+	// IfMatchHeader names the version a change is based on
+	IfMatchHeader = "If-Match"
+)
+
+// ETag returns the strong entity tag of a version
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// SetETag sets the ETag of the response to the version of a versioned
+// resource, which clients send back in If-Match to update it safely
+func SetETag(c *gin.Context, resource database.Versioned) {
+	c.Header(ETagHeader, ETag(resource.GetVersion()))
+}
+
+// IfMatchVersion returns the version the If-Match header of the request
+// names. ok is false without the header or for "*", which matches any
+// version. Weak or several entity tags cannot name one version and fail the
+// precondition.
+func IfMatchVersion(c *gin.Context) (version int64, ok bool, err error) {
+	header := strings.TrimSpace(c.GetHeader(IfMatchHeader))
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+
+	unquoted := strings.TrimSuffix(strings.TrimPrefix(header, `"`), `"`)
+	if len(unquoted) != len(header)-2 {
+		return 0, false, apperror.Newf(apperror.CodePreconditionFailed, "unsupported If-Match %q", header)
+	}
+	version, err = strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version < 1 {
+		return 0, false, apperror.Newf(apperror.CodePreconditionFailed, "unsupported If-Match %q", header)
+	}
+	return version, true, nil
+}
+
+// ApplyIfMatch adds the version the If-Match header names to the updates of
+// Repository.Update, which then fail with ErrStaleObject if the record
+// changed since the client read it; IfMatchError turns that into a 412
+func ApplyIfMatch(c *gin.Context, updates map[string]interface{}) error {
+	version, ok, err := IfMatchVersion(c)
+	if err != nil || !ok {
+		return err
+	}
+	updates[database.VersionColumn] = version
+	return nil
+}
+
+// IfMatchError returns the error of an update as the response it calls for.
+// When the request named a version in If-Match, ErrStaleObject means that
+// precondition failed, a 412; without If-Match a stale update stays a 409
+// conflict between concurrent requests.
+func IfMatchError(c *gin.Context, err error) error {
+	if !errors.Is(err, database.ErrStaleObject) {
+		return err
+	}
+	if _, ok, _ := IfMatchVersion(c); !ok {
+		return err
+	}
+	return apperror.Wrap(err, apperror.CodePreconditionFailed, "resource was modified since it was read")
+}
+
+// RequireIfMatch rejects changes without an If-Match header, so clients of
+// resources where lost updates are costly, such as invoices, must read
+// before they write
+func RequireIfMatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if strings.TrimSpace(c.GetHeader(IfMatchHeader)) == "" {
+			apperror.Abort(c, apperror.New(apperror.CodePreconditionRequired, "If-Match header is required"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		header  string
+		version int64
+		ok      bool
+		fails   bool
+	}{
+		{header: ""},
+		{header: "*"},
+		{header: `"3"`, version: 3, ok: true},
+		{header: `W/"3"`, fails: true},
+		{header: `"3", "4"`, fails: true},
+		{header: "3", fails: true},
+		{header: `"0"`, fails: true},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPatch, "/products/1", nil)
+		c.Request.Header.Set(IfMatchHeader, tt.header)
+
+		version, ok, err := IfMatchVersion(c)
+		assert.Equal(t, tt.version, version, tt.header)
+		assert.Equal(t, tt.ok, ok, tt.header)
+		if tt.fails {
+			assert.Equal(t, apperror.CodePreconditionFailed, apperror.From(err).Code, tt.header)
+		} else {
+			assert.NoError(t, err, tt.header)
+		}
+	}
+}
+
+func TestETag(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	router := gin.New()
+	router.Use(apperror.Middleware(logger))
+	router.GET("/products/1", func(c *gin.Context) {
+		SetETag(c, database.Versioning{Version: 7})
+		c.Status(http.StatusOK)
+	})
+	router.PATCH("/products/1", RequireIfMatch(), func(c *gin.Context) {
+		updates := map[string]interface{}{"name": "Teh"}
+		if err := ApplyIfMatch(c, updates); err != nil {
+			apperror.Abort(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, updates)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products/1", nil))
+	etag := w.Header().Get(ETagHeader)
+	assert.Equal(t, `"7"`, etag)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/products/1", nil))
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+
+	req := httptest.NewRequest(http.MethodPatch, "/products/1", nil)
+	req.Header.Set(IfMatchHeader, etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name":"Teh","version":7}`, w.Body.String())
+}
@@ -0,0 +1,266 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client key
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses replayed from the store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// perRequestHeaders are not stored with the response because they describe
+// the original request rather than its result
+var perRequestHeaders = []string{
+	"Date",
+	"Retry-After",
+	"X-Correlation-Id",
+	"X-Request-Id",
+	"X-Trace-Id",
+}
+
+// IdempotencyMiddleware replays the stored response of mutating requests
+// that are retried with the same Idempotency-Key
+type IdempotencyMiddleware struct {
+	store   IdempotencyStore
+	ttl     time.Duration
+	lockTTL time.Duration
+	logger  *logrus.Logger
+}
+
+// NewIdempotencyMiddleware creates a new idempotency middleware instance.
+// Completed responses are kept for ttl; lockTTL bounds how long an in-flight
+// request holds its key.
+func NewIdempotencyMiddleware(store IdempotencyStore, ttl, lockTTL time.Duration, logger *logrus.Logger) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		store:   store,
+		ttl:     ttl,
+		lockTTL: lockTTL,
+		logger:  logger,
+	}
+}
+
+// Handle applies idempotency to requests that carry an Idempotency-Key and
+// lets requests without one through unchanged
+func (m *IdempotencyMiddleware) Handle() gin.HandlerFunc {
+	return m.handle(false)
+}
+
+// Require rejects mutating requests without an Idempotency-Key. Use it for
+// endpoints where a duplicate is costly, such as payments.
+func (m *IdempotencyMiddleware) Require() gin.HandlerFunc {
+	return m.handle(true)
+}
+
+func (m *IdempotencyMiddleware) handle(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		idempotencyKey := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if idempotencyKey == "" {
+			if required {
+				apperror.Abort(c, apperror.New(apperror.CodeIdempotencyKeyRequired, "Idempotency-Key header is required"))
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			apperror.Abort(c, apperror.Newf(apperror.CodeInvalidIdempotencyKey, "Idempotency-Key must not exceed %d characters", maxIdempotencyKeyLength))
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c)
+		if err != nil {
+			apperror.Abort(c, apperror.New(apperror.CodeBadRequest, "Failed to read request body"))
+			return
+		}
+
+		key := idempotencyStoreKey(c, idempotencyKey)
+		ctx := c.Request.Context()
+
+		existing, reserved, err := m.store.Reserve(ctx, key, &IdempotencyRecord{
+			Status:      IdempotencyInProgress,
+			Fingerprint: fingerprint,
+			CreatedAt:   time.Now(),
+		}, m.lockTTL)
+		if err != nil {
+			// Failing closed: processing without the key could create the
+			// very duplicate the client is protecting against
+			m.logger.WithFields(logrus.Fields{
+				"path":  c.FullPath(),
+				"error": err,
+			}).Error("Idempotency store unavailable")
+			apperror.Abort(c, apperror.New(apperror.CodeIdempotencyUnavailable, "Idempotency check unavailable, please retry"))
+			return
+		}
+
+		if !reserved {
+			m.respondExisting(c, existing, fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		completed := false
+		defer func() {
+			// Release the key when the handler panicked so the client can retry
+			if !completed {
+				if err := m.store.Release(ctx, key); err != nil {
+					m.logger.WithError(err).Warn("Failed to release idempotency key")
+				}
+			}
+		}()
+
+		c.Next()
+		completed = true
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			// Transient failures are not stored so that a retry is processed again
+			if err := m.store.Release(ctx, key); err != nil {
+				m.logger.WithError(err).Warn("Failed to release idempotency key")
+			}
+			return
+		}
+
+		record := &IdempotencyRecord{
+			Status:      IdempotencyCompleted,
+			Fingerprint: fingerprint,
+			StatusCode:  status,
+			Header:      storableHeader(recorder.Header()),
+			Body:        recorder.body.Bytes(),
+			CreatedAt:   time.Now(),
+		}
+		if err := m.store.Complete(ctx, key, record, m.ttl); err != nil {
+			m.logger.WithFields(logrus.Fields{
+				"path":  c.FullPath(),
+				"error": err,
+			}).Error("Failed to store idempotent response")
+		}
+	}
+}
+
+// respondExisting answers a request whose key is already taken
+func (m *IdempotencyMiddleware) respondExisting(c *gin.Context, existing *IdempotencyRecord, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		apperror.Abort(c, apperror.New(apperror.CodeIdempotencyMismatch, "Idempotency-Key was already used for a different request"))
+		return
+	}
+
+	if existing.Status != IdempotencyCompleted {
+		c.Header("Retry-After", "1")
+		apperror.Abort(c, apperror.New(apperror.CodeIdempotencyPending, "A request with this Idempotency-Key is still being processed"))
+		return
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"path":   c.FullPath(),
+		"status": existing.StatusCode,
+	}).Debug("Replaying idempotent response")
+
+	for name, values := range existing.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(existing.StatusCode)
+	if len(existing.Body) > 0 {
+		_, _ = c.Writer.Write(existing.Body)
+	}
+	c.Abort()
+}
+
+// idempotencyStoreKey scopes the client key by tenant and user, so users of
+// a tenant never replay each other's responses. Requests without a tenant,
+// such as partner calls, are scoped by API key or IP. Clients behind one
+// address share the IP scope, so routes whose responses carry credentials
+// must not be guarded without authentication.
+func idempotencyStoreKey(c *gin.Context, idempotencyKey string) string {
+	scope := ""
+	if tenantID, ok := c.Get("tenant_id"); ok {
+		scope = fmt.Sprintf("tenant:%v", tenantID)
+		if userID, ok := c.Get("user_id"); ok {
+			scope += fmt.Sprintf(":user:%v", userID)
+		}
+	} else if apiKeyHash := c.GetString("api_key_hash"); apiKeyHash != "" {
+		scope = "api_key:" + apiKeyHash
+	} else {
+		scope = "ip:" + c.ClientIP()
+	}
+	return fmt.Sprintf("idempotency:%s:%s", scope, idempotencyKey)
+}
+
+// requestFingerprint hashes the method, path and body of the request and
+// restores the body for the handler
+func requestFingerprint(c *gin.Context) (string, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method))
+	hash.Write([]byte{'\n'})
+	hash.Write([]byte(c.Request.URL.Path))
+	hash.Write([]byte{'\n'})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// storableHeader copies the response headers that should be replayed
+func storableHeader(header http.Header) http.Header {
+	stored := header.Clone()
+	for _, name := range perRequestHeaders {
+		stored.Del(name)
+	}
+	for name := range stored {
+		if strings.HasPrefix(name, "Ratelimit-") {
+			delete(stored, name)
+		}
+	}
+	return stored
+}
+
+// responseRecorder captures the response body while writing it through
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
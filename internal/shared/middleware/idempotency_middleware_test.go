@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingIdempotencyStore simulates an unavailable Redis
+type failingIdempotencyStore struct{}
+
+func (failingIdempotencyStore) Reserve(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	return nil, false, errors.New("connection refused")
+}
+
+func (failingIdempotencyStore) Complete(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func (failingIdempotencyStore) Release(ctx context.Context, key string) error {
+	return errors.New("connection refused")
+}
+
+type idempotencyTestServer struct {
+	router *gin.Engine
+	calls  int
+	status int
+}
+
+func newIdempotencyTestServer(store IdempotencyStore, tenantID uuid.UUID, required bool) *idempotencyTestServer {
+	return newUserIdempotencyTestServer(store, tenantID, uuid.Nil, required)
+}
+
+// newUserIdempotencyTestServer authenticates requests as the user of the
+// tenant; without a user only the tenant is set
+func newUserIdempotencyTestServer(store IdempotencyStore, tenantID, userID uuid.UUID, required bool) *idempotencyTestServer {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	server := &idempotencyTestServer{status: http.StatusCreated}
+	idempotency := NewIdempotencyMiddleware(store, time.Hour, time.Minute, logger)
+
+	server.router = gin.New()
+	server.router.Use(func(c *gin.Context) {
+		c.Set("tenant_id", tenantID)
+		if userID != uuid.Nil {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	})
+	if required {
+		server.router.Use(idempotency.Require())
+	} else {
+		server.router.Use(idempotency.Handle())
+	}
+	server.router.POST("/payments", func(c *gin.Context) {
+		server.calls++
+		c.Header("Location", "/payments/1")
+		c.JSON(server.status, gin.H{"call": server.calls})
+	})
+	return server
+}
+
+func (s *idempotencyTestServer) post(key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware_ReplaysResponse(t *testing.T) {
+	server := newIdempotencyTestServer(NewMemoryIdempotencyStore(), uuid.New(), false)
+
+	first := server.post("key-1", `{"amount":1000}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+
+	second := server.post("key-1", `{"amount":1000}`)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "/payments/1", second.Header().Get("Location"))
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, server.calls)
+}
+
+func TestIdempotencyMiddleware_DifferentBodyConflicts(t *testing.T) {
+	server := newIdempotencyTestServer(NewMemoryIdempotencyStore(), uuid.New(), false)
+
+	require.Equal(t, http.StatusCreated, server.post("key-1", `{"amount":1000}`).Code)
+
+	w := server.post("key-1", `{"amount":2000}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "IDEMPOTENCY_KEY_MISMATCH")
+	assert.Equal(t, 1, server.calls)
+}
+
+func TestIdempotencyMiddleware_InFlight(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	tenantID := uuid.New()
+	server := newIdempotencyTestServer(store, tenantID, false)
+
+	// Simulate a first request that is still being processed
+	body := `{"amount":1000}`
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	c.Set("tenant_id", tenantID)
+	fingerprint, err := requestFingerprint(c)
+	require.NoError(t, err)
+	_, reserved, err := store.Reserve(context.Background(), idempotencyStoreKey(c, "key-1"), &IdempotencyRecord{
+		Status:      IdempotencyInProgress,
+		Fingerprint: fingerprint,
+	}, time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	w := server.post("key-1", body)
+	assert.Equal(t, http.StatusTooEarly, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, 0, server.calls)
+}
+
+func TestIdempotencyMiddleware_ScopedByTenant(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	first := newIdempotencyTestServer(store, uuid.New(), false)
+	second := newIdempotencyTestServer(store, uuid.New(), false)
+
+	require.Equal(t, http.StatusCreated, first.post("key-1", `{"amount":1000}`).Code)
+
+	w := second.post("key-1", `{"amount":2000}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, second.calls)
+}
+
+func TestIdempotencyMiddleware_ScopedByUser(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	tenantID := uuid.New()
+	first := newUserIdempotencyTestServer(store, tenantID, uuid.New(), false)
+	second := newUserIdempotencyTestServer(store, tenantID, uuid.New(), false)
+
+	require.Equal(t, http.StatusCreated, first.post("key-1", `{"amount":1000}`).Code)
+
+	// The same key and body from another user of the tenant is a new request
+	w := second.post("key-1", `{"amount":1000}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, second.calls)
+
+	// Each user still gets their own response replayed
+	w = first.post("key-1", `{"amount":1000}`)
+	assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, first.calls)
+}
+
+func TestIdempotencyMiddleware_ServerErrorsAreNotStored(t *testing.T) {
+	server := newIdempotencyTestServer(NewMemoryIdempotencyStore(), uuid.New(), false)
+	server.status = http.StatusInternalServerError
+
+	require.Equal(t, http.StatusInternalServerError, server.post("key-1", `{"amount":1000}`).Code)
+
+	server.status = http.StatusCreated
+	w := server.post("key-1", `{"amount":1000}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 2, server.calls)
+}
+
+func TestIdempotencyMiddleware_MissingKey(t *testing.T) {
+	optional := newIdempotencyTestServer(NewMemoryIdempotencyStore(), uuid.New(), false)
+	require.Equal(t, http.StatusCreated, optional.post("", `{}`).Code)
+	require.Equal(t, http.StatusCreated, optional.post("", `{}`).Code)
+	assert.Equal(t, 2, optional.calls)
+
+	required := newIdempotencyTestServer(NewMemoryIdempotencyStore(), uuid.New(), true)
+	w := required.post("", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "IDEMPOTENCY_KEY_REQUIRED")
+	assert.Equal(t, 0, required.calls)
+
+	w = required.post(strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestIdempotencyMiddleware_StoreUnavailable(t *testing.T) {
+	server := newIdempotencyTestServer(failingIdempotencyStore{}, uuid.New(), false)
+
+	w := server.post("key-1", `{"amount":1000}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, 0, server.calls)
+}
+
+func TestMemoryIdempotencyStore_LockExpires(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := NewMemoryIdempotencyStore().(*memoryIdempotencyStore)
+	store.now = func() time.Time { return now }
+
+	record := &IdempotencyRecord{Status: IdempotencyInProgress, Fingerprint: "abc"}
+	_, reserved, err := store.Reserve(ctx, "key", record, time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	existing, reserved, err := store.Reserve(ctx, "key", record, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, IdempotencyInProgress, existing.Status)
+
+	store.now = func() time.Time { return now.Add(2 * time.Minute) }
+	_, reserved, err = store.Reserve(ctx, "key", record, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+}
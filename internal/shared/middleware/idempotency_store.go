@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/cache"
+)
+
+// IdempotencyStatus is the processing state of an idempotency key
+type IdempotencyStatus string
+
+const (
+	IdempotencyInProgress IdempotencyStatus = "in_progress"
+	IdempotencyCompleted  IdempotencyStatus = "completed"
+)
+
+// IdempotencyRecord is the state stored for an idempotency key
+type IdempotencyRecord struct {
+	Status      IdempotencyStatus `json:"status"`
+	Fingerprint string            `json:"fingerprint"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	Header      http.Header       `json:"header,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// IdempotencyStore persists idempotency keys and their responses
+type IdempotencyStore interface {
+	// Reserve stores record under key if the key is unused. When the key is
+	// already taken the existing record is returned with reserved false.
+	Reserve(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (existing *IdempotencyRecord, reserved bool, err error)
+	// Complete replaces the reservation with the final response
+	Complete(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error
+	// Release removes a reservation so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// redisIdempotencyStore stores idempotency records in Redis so retries are
+// recognised by every service replica
+type redisIdempotencyStore struct {
+	client *redis.Client
+}
+
+// NewRedisIdempotencyStore creates a Redis backed idempotency store
+func NewRedisIdempotencyStore(redisCache *cache.RedisCache) IdempotencyStore {
+	return &redisIdempotencyStore{client: redisCache.Client}
+}
+
+// Reserve atomically claims the key with SET NX
+func (s *redisIdempotencyStore) Reserve(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	reserved, err := s.client.SetNX(ctx, key, data, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if reserved {
+		return nil, true, nil
+	}
+
+	raw, err := s.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		// The reservation expired between SET NX and GET; let the caller retry
+		return nil, false, fmt.Errorf("idempotency key expired during reservation: %s", key)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load idempotency record: %w", err)
+	}
+
+	var existing IdempotencyRecord
+	if err := json.Unmarshal(raw, &existing); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+	}
+	return &existing, false, nil
+}
+
+// Complete stores the final response for replay
+func (s *redisIdempotencyStore) Complete(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+	if err := s.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store idempotency record: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the reservation
+func (s *redisIdempotencyStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// memoryIdempotencyStore keeps idempotency records in process memory.
+// It is meant for tests and single instance development setups.
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]memoryIdempotencyEntry
+	now     func() time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	record    IdempotencyRecord
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyStore creates an in-memory idempotency store
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{
+		records: make(map[string]memoryIdempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+// Reserve claims the key if it is unused or expired
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if entry, exists := s.records[key]; exists && now.Before(entry.expiresAt) {
+		existing := entry.record
+		return &existing, false, nil
+	}
+
+	s.records[key] = memoryIdempotencyEntry{record: *record, expiresAt: now.Add(ttl)}
+	return nil, true, nil
+}
+
+// Complete stores the final response for replay
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = memoryIdempotencyEntry{record: *record, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+// Release deletes the reservation
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
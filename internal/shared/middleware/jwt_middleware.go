@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"context"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -10,6 +9,8 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/VincentArjuna/RexiErp/internal/authentication/service"
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
 )
 
 // JWTMiddleware provides JWT token validation middleware
@@ -29,95 +30,88 @@ func NewJWTMiddleware(authService service.AuthService, logger *logrus.Logger) *J
 // RequireAuth creates a gin middleware that requires valid JWT authentication
 func (m *JWTMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Extract token from Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			m.logger.Debug("Missing Authorization header")
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header required",
-				"code":  "MISSING_AUTH_HEADER",
-			})
-			c.Abort()
-			return
-		}
-
-		// Parse Bearer token
-		tokenParts := strings.SplitN(authHeader, " ", 2)
-		if len(tokenParts) != 2 || strings.ToLower(tokenParts[0]) != "bearer" {
-			m.logger.Debug("Invalid Authorization header format")
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid authorization header format. Expected 'Bearer <token>'",
-				"code":  "INVALID_AUTH_FORMAT",
-			})
-			c.Abort()
-			return
-		}
-
-		token := tokenParts[1]
-		if token == "" {
-			m.logger.Debug("Empty token provided")
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Token cannot be empty",
-				"code":  "EMPTY_TOKEN",
-			})
-			c.Abort()
-			return
-		}
-
-		// Validate token
-		result, err := m.authService.ValidateToken(context.Background(), token)
-		if err != nil {
-			m.logger.WithFields(logrus.Fields{
-				"error": err,
-			}).Debug("Token validation failed")
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid token",
-				"code":  "INVALID_TOKEN",
-			})
-			c.Abort()
-			return
+		if m.authenticate(c) {
+			c.Next()
 		}
+	}
+}
 
-		if !result.IsValid {
-			m.logger.Debug("Token is invalid or expired")
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Token is invalid or expired",
-				"code":  "TOKEN_EXPIRED",
-			})
-			c.Abort()
-			return
-		}
+// authenticate validates the bearer token and puts its claims in the
+// context. It aborts the request and returns false when the token is missing
+// or invalid; it never calls c.Next, so guards can check the claims before
+// the rest of the chain runs.
+func (m *JWTMiddleware) authenticate(c *gin.Context) bool {
+	// Extract token from Authorization header
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		m.logger.Debug("Missing Authorization header")
+		apperror.Abort(c, apperror.New(apperror.CodeMissingAuthHeader, "Authorization header required"))
+		return false
+	}
 
-		// Add user information to context
-		c.Set("user_id", result.UserID)
-		c.Set("tenant_id", result.TenantID)
-		c.Set("user_role", result.Role)
-		c.Set("session_id", result.SessionID)
+	// Parse Bearer token
+	tokenParts := strings.SplitN(authHeader, " ", 2)
+	if len(tokenParts) != 2 || strings.ToLower(tokenParts[0]) != "bearer" {
+		m.logger.Debug("Invalid Authorization header format")
+		apperror.Abort(c, apperror.New(apperror.CodeInvalidAuthFormat, "Invalid authorization header format. Expected 'Bearer <token>'"))
+		return false
+	}
 
-		// Add logging context
-		c.Set("logger", m.logger.WithFields(logrus.Fields{
-			"user_id":    result.UserID,
-			"tenant_id":  result.TenantID,
-			"user_role":  result.Role,
-			"session_id": result.SessionID,
-		}))
+	token := tokenParts[1]
+	if token == "" {
+		m.logger.Debug("Empty token provided")
+		apperror.Abort(c, apperror.New(apperror.CodeInvalidAuthFormat, "Token cannot be empty"))
+		return false
+	}
 
+	// Validate token
+	result, err := m.authService.ValidateToken(context.Background(), token)
+	if err != nil {
 		m.logger.WithFields(logrus.Fields{
-			"user_id":   result.UserID,
-			"tenant_id": result.TenantID,
-			"role":      result.Role,
-		}).Debug("JWT validation successful")
+			"error": err,
+		}).Debug("Token validation failed")
+		apperror.Abort(c, apperror.New(apperror.CodeInvalidToken, "Invalid token"))
+		return false
+	}
 
-		c.Next()
+	if !result.IsValid {
+		m.logger.Debug("Token is invalid or expired")
+		apperror.Abort(c, apperror.New(apperror.CodeTokenExpired, "Token is invalid or expired"))
+		return false
 	}
+
+	// Add user information to context
+	c.Set("user_id", result.UserID)
+	c.Set("tenant_id", result.TenantID)
+	c.Set("user_role", result.Role)
+	c.Set("session_id", result.SessionID)
+
+	// Make the caller available to request-scoped logging and the audit trail
+	ctx := logger.WithUserID(c.Request.Context(), result.UserID.String())
+	c.Request = c.Request.WithContext(logger.WithTenantID(ctx, result.TenantID.String()))
+
+	// Add logging context
+	c.Set("logger", m.logger.WithFields(logrus.Fields{
+		"user_id":    result.UserID,
+		"tenant_id":  result.TenantID,
+		"user_role":  result.Role,
+		"session_id": result.SessionID,
+	}))
+
+	m.logger.WithFields(logrus.Fields{
+		"user_id":   result.UserID,
+		"tenant_id": result.TenantID,
+		"role":      result.Role,
+	}).Debug("JWT validation successful")
+
+	return true
 }
 
 // RequireRole creates a gin middleware that requires specific user roles
 func (m *JWTMiddleware) RequireRole(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// First ensure user is authenticated
-		m.RequireAuth()(c)
-		if c.IsAborted() {
+		if !m.authenticate(c) {
 			return
 		}
 
@@ -125,22 +119,14 @@ func (m *JWTMiddleware) RequireRole(allowedRoles ...string) gin.HandlerFunc {
 		userRole, exists := c.Get("user_role")
 		if !exists {
 			m.logger.Debug("User role not found in context")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "User role not found",
-				"code":  "ROLE_NOT_FOUND",
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeForbidden, "User role not found"))
 			return
 		}
 
 		roleStr, ok := userRole.(string)
 		if !ok {
 			m.logger.Debug("Invalid user role type in context")
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Internal server error",
-				"code":  "INVALID_ROLE_TYPE",
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeInternal, "Internal server error"))
 			return
 		}
 
@@ -158,11 +144,7 @@ func (m *JWTMiddleware) RequireRole(allowedRoles ...string) gin.HandlerFunc {
 				"user_role":     roleStr,
 				"allowed_roles": allowedRoles,
 			}).Debug("User role not allowed")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Insufficient permissions",
-				"code":  "INSUFFICIENT_PERMISSIONS",
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeInsufficientPermissions, "Insufficient permissions"))
 			return
 		}
 
@@ -178,8 +160,7 @@ func (m *JWTMiddleware) RequireRole(allowedRoles ...string) gin.HandlerFunc {
 func (m *JWTMiddleware) RequireTenant() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// First ensure user is authenticated
-		m.RequireAuth()(c)
-		if c.IsAborted() {
+		if !m.authenticate(c) {
 			return
 		}
 
@@ -187,22 +168,14 @@ func (m *JWTMiddleware) RequireTenant() gin.HandlerFunc {
 		tenantID, exists := c.Get("tenant_id")
 		if !exists {
 			m.logger.Debug("Tenant ID not found in context")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Tenant context not found",
-				"code":  "TENANT_NOT_FOUND",
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeForbidden, "Tenant context not found"))
 			return
 		}
 
 		_, ok := tenantID.(uuid.UUID)
 		if !ok {
 			m.logger.Debug("Invalid tenant ID type in context")
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Internal server error",
-				"code":  "INVALID_TENANT_TYPE",
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeInternal, "Internal server error"))
 			return
 		}
 
@@ -257,6 +230,10 @@ func (m *JWTMiddleware) OptionalAuth() gin.HandlerFunc {
 		c.Set("user_role", result.Role)
 		c.Set("session_id", result.SessionID)
 
+		// Make the caller available to request-scoped logging and the audit trail
+		ctx := logger.WithUserID(c.Request.Context(), result.UserID.String())
+		c.Request = c.Request.WithContext(logger.WithTenantID(ctx, result.TenantID.String()))
+
 		// Add logging context
 		c.Set("logger", m.logger.WithFields(logrus.Fields{
 			"user_id":    result.UserID,
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
+)
+
+// PlanLimitMiddleware provides subscription plan enforcement middleware
+type PlanLimitMiddleware struct {
+	enforcer *subscription.Enforcer
+	logger   *logrus.Logger
+}
+
+// NewPlanLimitMiddleware creates a new plan limit middleware instance
+func NewPlanLimitMiddleware(enforcer *subscription.Enforcer, logger *logrus.Logger) *PlanLimitMiddleware {
+	return &PlanLimitMiddleware{
+		enforcer: enforcer,
+		logger:   logger,
+	}
+}
+
+// MeterAPICalls creates a gin middleware that counts API calls against the
+// tenant's daily quota. It must run after RequireAuth. Metering failures are
+// logged and the request is allowed through.
+func (m *PlanLimitMiddleware) MeterAPICalls() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID, exists := c.Get("tenant_id")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		id, ok := tenantID.(uuid.UUID)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if err := m.enforcer.Consume(c.Request.Context(), id, subscription.ResourceAPICalls, 1); err != nil {
+			if limitErr, ok := subscription.AsLimitExceeded(err); ok {
+				c.Header("Retry-After", strconv.Itoa(secondsUntilTomorrow(time.Now().In(m.enforcer.Location()))))
+				apperror.Abort(c, limitErr)
+				return
+			}
+
+			m.logger.WithFields(logrus.Fields{
+				"tenant_id": id,
+				"error":     err,
+			}).Warn("Failed to meter API call")
+		}
+
+		c.Next()
+	}
+}
+
+// MeterMonthlyInvoices creates a gin middleware for routes that issue an
+// invoice. It counts the invoice against the tenant's monthly quota before
+// the handler runs and gives it back when the handler fails. It must run
+// after RequireAuth.
+func (m *PlanLimitMiddleware) MeterMonthlyInvoices() gin.HandlerFunc {
+	return m.meter(subscription.ResourceMonthlyInvoices, func(*gin.Context) (int64, bool) {
+		return 1, true
+	})
+}
+
+// MeterStorage creates a gin middleware for upload routes. It counts the
+// request body against the tenant's storage quota before the handler runs
+// and gives it back when the handler fails. Uploads must declare their
+// Content-Length. Handlers that delete stored files return their size with
+// Enforcer.Release. It must run after RequireAuth.
+func (m *PlanLimitMiddleware) MeterStorage() gin.HandlerFunc {
+	return m.meter(subscription.ResourceStorageBytes, func(c *gin.Context) (int64, bool) {
+		if c.Request.ContentLength < 0 {
+			apperror.Abort(c, apperror.New(apperror.CodeBadRequest, "Content-Length header required"))
+			return 0, false
+		}
+		return c.Request.ContentLength, true
+	})
+}
+
+// meter consumes the amount of a request before the handler runs and
+// releases it when the handler responds with an error. Unlike API calls,
+// these limits are enforced even when metering fails.
+func (m *PlanLimitMiddleware) meter(resource subscription.Resource, amount func(c *gin.Context) (int64, bool)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID, exists := c.Get("tenant_id")
+		id, ok := tenantID.(uuid.UUID)
+		if !exists || !ok {
+			apperror.Abort(c, apperror.New(apperror.CodeForbidden, "Tenant context not found"))
+			return
+		}
+
+		n, ok := amount(c)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		if err := m.enforcer.Consume(ctx, id, resource, n); err != nil {
+			if limitErr, ok := subscription.AsLimitExceeded(err); ok {
+				apperror.Abort(c, limitErr)
+				return
+			}
+			m.logger.WithFields(logrus.Fields{
+				"tenant_id": id,
+				"resource":  resource,
+				"error":     err,
+			}).Error("Failed to meter usage")
+			apperror.Abort(c, apperror.New(apperror.CodeInternal, "Internal server error"))
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() < http.StatusBadRequest && len(c.Errors) == 0 {
+			return
+		}
+		if err := m.enforcer.Release(context.WithoutCancel(ctx), id, resource, n); err != nil {
+			m.logger.WithFields(logrus.Fields{
+				"tenant_id": id,
+				"resource":  resource,
+				"error":     err,
+			}).Error("Failed to release usage of a failed request")
+		}
+	}
+}
+
+func secondsUntilTomorrow(now time.Time) int {
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	seconds := int(tomorrow.Sub(now).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
+)
+
+// memoryUsageStore keeps one counter per tenant and resource, ignoring
+// periods
+type memoryUsageStore struct {
+	mu       sync.Mutex
+	counters map[string]int64
+}
+
+func newMemoryUsageStore() *memoryUsageStore {
+	return &memoryUsageStore{counters: make(map[string]int64)}
+}
+
+func (s *memoryUsageStore) Increment(_ context.Context, tenantID uuid.UUID, resource subscription.Resource, delta int64, _ time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := tenantID.String() + ":" + string(resource)
+	s.counters[key] += delta
+	return s.counters[key], nil
+}
+
+func (s *memoryUsageStore) Get(_ context.Context, tenantID uuid.UUID, resource subscription.Resource, _ time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counters[tenantID.String()+":"+string(resource)], nil
+}
+
+func (s *memoryUsageStore) GetDaily(ctx context.Context, tenantID uuid.UUID, resource subscription.Resource, day time.Time) (int64, error) {
+	return s.Get(ctx, tenantID, resource, day)
+}
+
+func (s *memoryUsageStore) Tenants(context.Context, time.Time) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+// newPlanLimitRouter serves POST /test for a tenant on the basic plan. The
+// handler fails validation when the request asks for it.
+func newPlanLimitRouter(store subscription.UsageStore, tenantID uuid.UUID, meter func(*PlanLimitMiddleware) gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	plans := subscription.PlanResolverFunc(func(context.Context, uuid.UUID) (*subscription.TenantPlan, error) {
+		return &subscription.TenantPlan{PlanCode: subscription.PlanBasic}, nil
+	})
+	enforcer := subscription.NewEnforcer(subscription.DefaultCatalogue(), plans, store, time.UTC, logger)
+
+	router := gin.New()
+	router.Use(apperror.Middleware(logger))
+	router.Use(func(c *gin.Context) {
+		c.Set("tenant_id", tenantID)
+		c.Next()
+	})
+	router.Use(meter(NewPlanLimitMiddleware(enforcer, logger)))
+	router.POST("/test", func(c *gin.Context) {
+		if c.Query("fail") != "" {
+			_ = c.Error(apperror.New(apperror.CodeValidationFailed, "Invalid invoice"))
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"success": true})
+	})
+	return router
+}
+
+func postPlanLimited(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPlanLimitMiddleware_MeterMonthlyInvoices(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	store := newMemoryUsageStore()
+	router := newPlanLimitRouter(store, tenantID, (*PlanLimitMiddleware).MeterMonthlyInvoices)
+
+	// The basic plan allows 500 invoices a month
+	_, _ = store.Increment(ctx, tenantID, subscription.ResourceMonthlyInvoices, 499, time.Now())
+
+	// Failed requests do not use up the quota
+	w := postPlanLimited(router, "/test?fail=1", "{}")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	used, _ := store.Get(ctx, tenantID, subscription.ResourceMonthlyInvoices, time.Now())
+	assert.Equal(t, int64(499), used)
+
+	w = postPlanLimited(router, "/test", "{}")
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = postPlanLimited(router, "/test", "{}")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), subscription.ErrorCodePlanLimitExceeded)
+
+	used, _ = store.Get(ctx, tenantID, subscription.ResourceMonthlyInvoices, time.Now())
+	assert.Equal(t, int64(500), used)
+}
+
+func TestPlanLimitMiddleware_MeterStorage(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	store := newMemoryUsageStore()
+	router := newPlanLimitRouter(store, tenantID, (*PlanLimitMiddleware).MeterStorage)
+
+	// The basic plan allows 1 GiB of storage
+	_, _ = store.Increment(ctx, tenantID, subscription.ResourceStorageBytes, 1<<30-10, time.Now())
+
+	w := postPlanLimited(router, "/test", "0123456789")
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = postPlanLimited(router, "/test", "x")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), subscription.ErrorCodePlanLimitExceeded)
+
+	used, _ := store.Get(ctx, tenantID, subscription.ResourceStorageBytes, time.Now())
+	assert.Equal(t, int64(1<<30), used)
+
+	// Uploads of unknown size are refused rather than left unmetered
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader("x"))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
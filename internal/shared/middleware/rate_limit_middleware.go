@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
+)
+
+// RateLimitKey selects the identity a rate limit rule is applied to
+type RateLimitKey string
+
+const (
+	RateLimitByIP     RateLimitKey = "ip"
+	RateLimitByUser   RateLimitKey = "user"
+	RateLimitByTenant RateLimitKey = "tenant"
+	RateLimitByAPIKey RateLimitKey = "api_key"
+)
+
+// RateLimitRule declares a rate limit for a route group
+type RateLimitRule struct {
+	// Name identifies the rule in Redis keys and logs, e.g. "auth-login"
+	Name string
+	// Limit is the number of requests allowed per window
+	Limit int64
+	// Window is the length of the sliding window
+	Window time.Duration
+	// KeyBy selects the identity the limit applies to. Requests without that
+	// identity (e.g. no tenant before authentication) fall back to the client IP.
+	KeyBy RateLimitKey
+	// PlanLimits overrides Limit by subscription plan code for tenant keyed rules
+	PlanLimits map[string]int64
+}
+
+// RateLimitMiddleware provides distributed rate limiting middleware
+type RateLimitMiddleware struct {
+	limiter      RateLimiter
+	planResolver subscription.PlanResolver
+	logger       *logrus.Logger
+}
+
+// NewRateLimitMiddleware creates a new rate limit middleware instance. The
+// plan resolver is optional and only needed for rules with PlanLimits.
+func NewRateLimitMiddleware(limiter RateLimiter, planResolver subscription.PlanResolver, logger *logrus.Logger) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		limiter:      limiter,
+		planResolver: planResolver,
+		logger:       logger,
+	}
+}
+
+// Limit creates a gin middleware enforcing the given rules. All rules are
+// evaluated and the most restrictive one determines the response headers.
+// Limiter failures are logged and the request is allowed through.
+func (m *RateLimitMiddleware) Limit(rules ...RateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tightest *RateLimitResult
+		var tightestRule RateLimitRule
+
+		for _, rule := range rules {
+			key, limit := m.resolve(c, rule)
+
+			result, err := m.limiter.Allow(c.Request.Context(), key, limit, rule.Window)
+			if err != nil {
+				m.logger.WithFields(logrus.Fields{
+					"rule":  rule.Name,
+					"error": err,
+				}).Warn("Rate limiter unavailable, allowing request")
+				continue
+			}
+
+			if tightest == nil || !result.Allowed || (tightest.Allowed && result.Remaining < tightest.Remaining) {
+				tightest = result
+				tightestRule = rule
+			}
+			if !result.Allowed {
+				break
+			}
+		}
+
+		if tightest == nil {
+			c.Next()
+			return
+		}
+
+		setRateLimitHeaders(c, tightestRule, tightest)
+
+		if !tightest.Allowed {
+			retryAfter := int(math.Ceil(tightest.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+			m.logger.WithFields(logrus.Fields{
+				"rule":        tightestRule.Name,
+				"path":        c.FullPath(),
+				"client_ip":   c.ClientIP(),
+				"retry_after": retryAfter,
+			}).Info("Rate limit exceeded")
+
+			apperror.Abort(c, apperror.New(apperror.CodeRateLimitExceeded, "too many requests").
+				WithDetail("rule", tightestRule.Name).
+				WithDetail("limit", tightest.Limit).
+				WithDetail("retry_after", retryAfter))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// resolve returns the limiter key and the effective limit of a rule
+func (m *RateLimitMiddleware) resolve(c *gin.Context, rule RateLimitRule) (string, int64) {
+	identity := ""
+	keyBy := rule.KeyBy
+
+	switch rule.KeyBy {
+	case RateLimitByUser:
+		if userID, ok := c.Get("user_id"); ok {
+			identity = fmt.Sprint(userID)
+		}
+	case RateLimitByTenant:
+		if tenantID, ok := c.Get("tenant_id"); ok {
+			identity = fmt.Sprint(tenantID)
+		}
+	case RateLimitByAPIKey:
+		identity = c.GetString("api_key_hash")
+	}
+
+	if identity == "" {
+		keyBy = RateLimitByIP
+		identity = c.ClientIP()
+	}
+
+	limit := rule.Limit
+	if keyBy == RateLimitByTenant && len(rule.PlanLimits) > 0 && m.planResolver != nil {
+		if tenantID, ok := c.Get("tenant_id"); ok {
+			if id, ok := tenantID.(uuid.UUID); ok {
+				if plan, err := m.planResolver.ResolvePlan(c.Request.Context(), id); err == nil {
+					if planLimit, exists := rule.PlanLimits[plan.PlanCode]; exists {
+						limit = planLimit
+					}
+				} else {
+					m.logger.WithFields(logrus.Fields{
+						"tenant_id": id,
+						"error":     err,
+					}).Debug("Failed to resolve tenant plan for rate limit")
+				}
+			}
+		}
+	}
+
+	return fmt.Sprintf("%s:%s:%s", rule.Name, keyBy, identity), limit
+}
+
+// setRateLimitHeaders writes the IETF RateLimit header fields
+func setRateLimitHeaders(c *gin.Context, rule RateLimitRule, result *RateLimitResult) {
+	c.Header("RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+	c.Header("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+	c.Header("RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds()))))
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit, int(rule.Window.Seconds())))
+}
@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
+)
+
+// failingRateLimiter simulates an unavailable Redis
+type failingRateLimiter struct{}
+
+func (failingRateLimiter) Allow(ctx context.Context, key string, limit int64, window time.Duration) (*RateLimitResult, error) {
+	return nil, errors.New("connection refused")
+}
+
+func newTestLimiter(now time.Time) *memoryRateLimiter {
+	limiter := NewMemoryRateLimiter().(*memoryRateLimiter)
+	limiter.now = func() time.Time { return now }
+	return limiter
+}
+
+func newRateLimitRouter(limiter RateLimiter, resolver subscription.PlanResolver, identity gin.HandlerFunc, rules ...RateLimitRule) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	router := gin.New()
+	if identity != nil {
+		router.Use(identity)
+	}
+	router.Use(NewRateLimitMiddleware(limiter, resolver, logger).Limit(rules...))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+	return router
+}
+
+func doRequest(router *gin.Engine, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitMiddleware_SetsHeaders(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 15, 0, time.UTC)
+	router := newRateLimitRouter(newTestLimiter(now), nil, nil, RateLimitRule{
+		Name: "test", Limit: 5, Window: time.Minute, KeyBy: RateLimitByIP,
+	})
+
+	w := doRequest(router, "10.0.0.1:1234")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "5", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "4", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "45", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "5;w=60", w.Header().Get("RateLimit-Policy"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+}
+
+func TestRateLimitMiddleware_RejectsOverLimit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 15, 0, time.UTC)
+	router := newRateLimitRouter(newTestLimiter(now), nil, nil, RateLimitRule{
+		Name: "test", Limit: 2, Window: time.Minute, KeyBy: RateLimitByIP,
+	})
+
+	require.Equal(t, http.StatusOK, doRequest(router, "10.0.0.1:1234").Code)
+	require.Equal(t, http.StatusOK, doRequest(router, "10.0.0.1:1234").Code)
+
+	w := doRequest(router, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "45", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "RATE_LIMIT_EXCEEDED", body["code"])
+
+	// Other clients keep their own quota
+	assert.Equal(t, http.StatusOK, doRequest(router, "10.0.0.2:1234").Code)
+}
+
+func TestRateLimitMiddleware_KeysByUser(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	authenticated := true
+	identity := func(c *gin.Context) {
+		if authenticated {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	}
+	router := newRateLimitRouter(newTestLimiter(now), nil, identity, RateLimitRule{
+		Name: "test", Limit: 1, Window: time.Minute, KeyBy: RateLimitByUser,
+	})
+
+	// The same user is limited across client addresses
+	require.Equal(t, http.StatusOK, doRequest(router, "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(router, "10.0.0.2:1234").Code)
+
+	// Anonymous requests fall back to the client IP
+	authenticated = false
+	assert.Equal(t, http.StatusOK, doRequest(router, "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(router, "10.0.0.1:1234").Code)
+}
+
+func TestRateLimitMiddleware_PlanLimits(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	tenantID := uuid.New()
+	identity := func(c *gin.Context) {
+		c.Set("tenant_id", tenantID)
+		c.Next()
+	}
+	resolver := subscription.PlanResolverFunc(func(ctx context.Context, id uuid.UUID) (*subscription.TenantPlan, error) {
+		return &subscription.TenantPlan{PlanCode: "professional"}, nil
+	})
+	router := newRateLimitRouter(newTestLimiter(now), resolver, identity, RateLimitRule{
+		Name:       "test",
+		Limit:      1,
+		Window:     time.Minute,
+		KeyBy:      RateLimitByTenant,
+		PlanLimits: map[string]int64{"basic": 1, "professional": 3},
+	})
+
+	for i := 0; i < 3; i++ {
+		w := doRequest(router, "10.0.0.1:1234")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(router, "10.0.0.1:1234").Code)
+}
+
+func TestRateLimitMiddleware_TightestRuleWins(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	router := newRateLimitRouter(newTestLimiter(now), nil, nil,
+		RateLimitRule{Name: "loose", Limit: 100, Window: time.Minute, KeyBy: RateLimitByIP},
+		RateLimitRule{Name: "strict", Limit: 2, Window: time.Minute, KeyBy: RateLimitByIP},
+	)
+
+	w := doRequest(router, "10.0.0.1:1234")
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+}
+
+func TestRateLimitMiddleware_FailsOpen(t *testing.T) {
+	router := newRateLimitRouter(failingRateLimiter{}, nil, nil, RateLimitRule{
+		Name: "test", Limit: 1, Window: time.Minute, KeyBy: RateLimitByIP,
+	})
+
+	w := doRequest(router, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestMemoryRateLimiter_SlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(start)
+
+	for i := 0; i < 10; i++ {
+		result, err := limiter.Allow(ctx, "key", 10, time.Minute)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+	}
+
+	// Halfway into the next window half of the previous requests still count
+	limiter.now = func() time.Time { return start.Add(90 * time.Second) }
+	for i := 0; i < 5; i++ {
+		result, err := limiter.Allow(ctx, "key", 10, time.Minute)
+		require.NoError(t, err)
+		require.True(t, result.Allowed, "request %d", i)
+	}
+
+	result, err := limiter.Allow(ctx, "key", 10, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, int64(0), result.Remaining)
+	assert.Greater(t, result.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, result.RetryAfter, 30*time.Second)
+
+	// Two windows later the quota is fully restored
+	limiter.now = func() time.Time { return start.Add(3 * time.Minute) }
+	result, err = limiter.Allow(ctx, "key", 10, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(9), result.Remaining)
+}
+
+func TestSlidingWindowResult_RetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 30, 0, time.UTC)
+
+	// Current window exhausted: wait for the window to roll over
+	result := slidingWindowResult(now, time.Minute, 10, false, 10, 0)
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+	assert.Equal(t, 30*time.Second, result.ResetAfter)
+
+	// 10 previous requests weighted 0.9 plus 2 current ones exceed the limit.
+	// The weight has to drop to 0.8, which happens 12s into the window.
+	now = time.Date(2026, 1, 1, 10, 0, 6, 0, time.UTC)
+	result = slidingWindowResult(now, time.Minute, 10, false, 2, 10)
+	assert.Equal(t, int64(0), result.Remaining)
+	assert.Equal(t, 6*time.Second, result.RetryAfter)
+}
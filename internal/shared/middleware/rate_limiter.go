@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/cache"
+)
+
+// RateLimitResult describes the outcome of a rate limit check
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimiter decides whether a request identified by key is allowed
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit int64, window time.Duration) (*RateLimitResult, error)
+}
+
+// slidingWindowScript implements the sliding window counter atomically.
+// Only allowed requests are counted, so clients that keep retrying while
+// limited do not extend their own penalty.
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local weight = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local estimated = math.floor(previous * weight) + current
+if estimated >= limit then
+	return {0, current, previous}
+end
+current = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return {1, current, previous}
+`)
+
+// redisRateLimiter implements a sliding window counter shared by all
+// service replicas through Redis
+type redisRateLimiter struct {
+	client *redis.Client
+	now    func() time.Time
+}
+
+// NewRedisRateLimiter creates a Redis backed sliding window rate limiter
+func NewRedisRateLimiter(redisCache *cache.RedisCache) RateLimiter {
+	return &redisRateLimiter{
+		client: redisCache.Client,
+		now:    time.Now,
+	}
+}
+
+// Allow checks and counts a request against the sliding window
+func (l *redisRateLimiter) Allow(ctx context.Context, key string, limit int64, window time.Duration) (*RateLimitResult, error) {
+	now := l.now()
+	windowStart := now.Truncate(window)
+	weight := previousWindowWeight(now, windowStart, window)
+
+	currentKey := fmt.Sprintf("ratelimit:%s:%d", key, windowStart.UnixMilli())
+	previousKey := fmt.Sprintf("ratelimit:%s:%d", key, windowStart.Add(-window).UnixMilli())
+
+	values, err := slidingWindowScript.Run(ctx, l.client,
+		[]string{currentKey, previousKey},
+		strconv.FormatFloat(weight, 'f', 6, 64),
+		limit,
+		(2 * window).Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate rate limit: %w", err)
+	}
+
+	return slidingWindowResult(now, window, limit, values[0] == 1, values[1], values[2]), nil
+}
+
+// memoryRateLimiter implements the sliding window counter in process memory.
+// It is meant for tests and single instance development setups.
+type memoryRateLimiter struct {
+	mu       sync.Mutex
+	counters map[string]int64
+	now      func() time.Time
+}
+
+// NewMemoryRateLimiter creates an in-memory sliding window rate limiter
+func NewMemoryRateLimiter() RateLimiter {
+	return &memoryRateLimiter{
+		counters: make(map[string]int64),
+		now:      time.Now,
+	}
+}
+
+// Allow checks and counts a request against the sliding window
+func (l *memoryRateLimiter) Allow(ctx context.Context, key string, limit int64, window time.Duration) (*RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	windowStart := now.Truncate(window)
+	currentKey := fmt.Sprintf("%s:%d", key, windowStart.UnixMilli())
+	previousKey := fmt.Sprintf("%s:%d", key, windowStart.Add(-window).UnixMilli())
+
+	current := l.counters[currentKey]
+	previous := l.counters[previousKey]
+	estimated := int64(math.Floor(float64(previous)*previousWindowWeight(now, windowStart, window))) + current
+
+	allowed := estimated < limit
+	if allowed {
+		current++
+		l.counters[currentKey] = current
+	}
+
+	return slidingWindowResult(now, window, limit, allowed, current, previous), nil
+}
+
+// previousWindowWeight returns the share of the previous window that still
+// overlaps the sliding window ending now
+func previousWindowWeight(now, windowStart time.Time, window time.Duration) float64 {
+	elapsed := now.Sub(windowStart)
+	return 1 - float64(elapsed)/float64(window)
+}
+
+// slidingWindowResult derives the remaining quota and timing headers from the
+// window counters after the request has been evaluated
+func slidingWindowResult(now time.Time, window time.Duration, limit int64, allowed bool, current, previous int64) *RateLimitResult {
+	windowStart := now.Truncate(window)
+	elapsed := now.Sub(windowStart)
+	weight := previousWindowWeight(now, windowStart, window)
+	estimated := int64(math.Floor(float64(previous)*weight)) + current
+
+	result := &RateLimitResult{
+		Allowed:    allowed,
+		Limit:      limit,
+		Remaining:  limit - estimated,
+		ResetAfter: window - elapsed,
+	}
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+
+	if allowed {
+		return result
+	}
+
+	// The estimate drops below the limit once enough of the previous window
+	// has slid out: previous * (1 - (elapsed+d)/window) + current < limit
+	switch {
+	case current >= limit:
+		result.RetryAfter = window - elapsed
+	case previous > 0:
+		required := 1 - float64(limit-current)/float64(previous)
+		wait := (time.Duration(required*float64(window)) - elapsed).Round(time.Millisecond)
+		if wait < time.Second {
+			wait = time.Second
+		}
+		result.RetryAfter = wait
+	default:
+		result.RetryAfter = time.Second
+	}
+
+	return result
+}
@@ -1,11 +1,13 @@
 package middleware
 
 import (
-	"net/http"
+	"fmt"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
 )
 
 // Permission represents a specific permission that can be granted to a role
@@ -53,49 +55,25 @@ var RolePermissions = map[string][]Permission{
 	},
 }
 
-// RBACMiddleware provides role-based access control middleware
+// RBACMiddleware provides role-based access control middleware. Its guards
+// read the claims JWTMiddleware.RequireAuth put in the context, so they must
+// be registered after it.
 type RBACMiddleware struct {
-	jwtMiddleware *JWTMiddleware
-	logger        *logrus.Logger
+	logger *logrus.Logger
 }
 
 // NewRBACMiddleware creates a new RBAC middleware instance
-func NewRBACMiddleware(jwtMiddleware *JWTMiddleware, logger *logrus.Logger) *RBACMiddleware {
+func NewRBACMiddleware(logger *logrus.Logger) *RBACMiddleware {
 	return &RBACMiddleware{
-		jwtMiddleware: jwtMiddleware,
-		logger:        logger,
+		logger: logger,
 	}
 }
 
 // RequirePermission creates a gin middleware that requires specific permissions
 func (m *RBACMiddleware) RequirePermission(resource, action string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// First ensure user is authenticated
-		m.jwtMiddleware.RequireAuth()(c)
-		if c.IsAborted() {
-			return
-		}
-
-		// Get user role from context
-		userRole, exists := c.Get("user_role")
-		if !exists {
-			m.logger.Debug("User role not found in context")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "User role not found",
-				"code":  "ROLE_NOT_FOUND",
-			})
-			c.Abort()
-			return
-		}
-
-		roleStr, ok := userRole.(string)
+		roleStr, ok := m.role(c)
 		if !ok {
-			m.logger.Debug("Invalid user role type in context")
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Internal server error",
-				"code":  "INVALID_ROLE_TYPE",
-			})
-			c.Abort()
 			return
 		}
 
@@ -106,15 +84,9 @@ func (m *RBACMiddleware) RequirePermission(resource, action string) gin.HandlerF
 				"resource":  resource,
 				"action":    action,
 			}).Debug("User does not have required permission")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Insufficient permissions",
-				"code":  "INSUFFICIENT_PERMISSIONS",
-				"details": gin.H{
-					"required_permission": resource + ":" + action,
-					"user_role":          roleStr,
-				},
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeInsufficientPermissions, "insufficient permissions").
+				WithDetail("required_permission", resource+":"+action).
+				WithDetail("user_role", roleStr))
 			return
 		}
 
@@ -135,32 +107,8 @@ func (m *RBACMiddleware) RequirePermission(resource, action string) gin.HandlerF
 // RequireRole creates a gin middleware that requires specific roles
 func (m *RBACMiddleware) RequireRole(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// First ensure user is authenticated
-		m.jwtMiddleware.RequireAuth()(c)
-		if c.IsAborted() {
-			return
-		}
-
-		// Get user role from context
-		userRole, exists := c.Get("user_role")
-		if !exists {
-			m.logger.Debug("User role not found in context")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "User role not found",
-				"code":  "ROLE_NOT_FOUND",
-			})
-			c.Abort()
-			return
-		}
-
-		roleStr, ok := userRole.(string)
+		roleStr, ok := m.role(c)
 		if !ok {
-			m.logger.Debug("Invalid user role type in context")
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Internal server error",
-				"code":  "INVALID_ROLE_TYPE",
-			})
-			c.Abort()
 			return
 		}
 
@@ -178,15 +126,9 @@ func (m *RBACMiddleware) RequireRole(allowedRoles ...string) gin.HandlerFunc {
 				"user_role":     roleStr,
 				"allowed_roles": allowedRoles,
 			}).Debug("User role not allowed")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Insufficient permissions",
-				"code":  "INSUFFICIENT_PERMISSIONS",
-				"details": gin.H{
-					"allowed_roles": allowedRoles,
-					"user_role":     roleStr,
-				},
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeInsufficientPermissions, "insufficient permissions").
+				WithDetail("allowed_roles", allowedRoles).
+				WithDetail("user_role", roleStr))
 			return
 		}
 
@@ -199,23 +141,19 @@ func (m *RBACMiddleware) RequireRole(allowedRoles ...string) gin.HandlerFunc {
 // TenantIsolation creates a middleware that ensures users can only access their own tenant's data
 func (m *RBACMiddleware) TenantIsolation() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// First ensure user is authenticated
-		m.jwtMiddleware.RequireAuth()(c)
-		if c.IsAborted() {
+		roleStr, ok := m.role(c)
+		if !ok {
 			return
 		}
 
 		// Get tenant ID from context (set by JWT middleware)
-		userTenantID, exists := c.Get("tenant_id")
+		tenantID, exists := c.Get("tenant_id")
 		if !exists {
 			m.logger.Debug("Tenant ID not found in context")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Tenant context not found",
-				"code":  "TENANT_NOT_FOUND",
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeForbidden, "Tenant context not found"))
 			return
 		}
+		userTenantID := fmt.Sprint(tenantID)
 
 		// Check for tenant ID in request (for APIs that allow cross-tenant access)
 		// This is typically used by super_admins
@@ -227,33 +165,15 @@ func (m *RBACMiddleware) TenantIsolation() gin.HandlerFunc {
 			requestTenantID = c.GetHeader("X-Tenant-ID")
 		}
 
-		// Get user role to check if they can access other tenants
-		userRole, exists := c.Get("user_role")
-		if !exists {
-			m.logger.Debug("User role not found in context")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "User role not found",
-				"code":  "ROLE_NOT_FOUND",
-			})
-			c.Abort()
-			return
-		}
-
-		roleStr, _ := userRole.(string)
-
 		// If request specifies a different tenant, check if user is allowed
-		if requestTenantID != "" && requestTenantID != userTenantID.(string) {
+		if requestTenantID != "" && requestTenantID != userTenantID {
 			if roleStr != "super_admin" {
 				m.logger.WithFields(logrus.Fields{
 					"user_role":        roleStr,
 					"user_tenant_id":   userTenantID,
 					"request_tenant_id": requestTenantID,
 				}).Debug("User attempting to access different tenant")
-				c.JSON(http.StatusForbidden, gin.H{
-					"error": "Cross-tenant access not allowed",
-					"code":  "CROSS_TENANT_ACCESS_DENIED",
-				})
-				c.Abort()
+				apperror.Abort(c, apperror.New(apperror.CodeCrossTenantAccess, "Cross-tenant access not allowed"))
 				return
 			}
 		}
@@ -266,7 +186,7 @@ func (m *RBACMiddleware) TenantIsolation() gin.HandlerFunc {
 
 		// Add tenant context for downstream services
 		c.Set("effective_tenant_id", effectiveTenantID)
-		c.Header("X-Tenant-ID", effectiveTenantID.(string))
+		c.Header("X-Tenant-ID", effectiveTenantID)
 
 		m.logger.WithFields(logrus.Fields{
 			"user_role":           roleStr,
@@ -279,6 +199,26 @@ func (m *RBACMiddleware) TenantIsolation() gin.HandlerFunc {
 	}
 }
 
+// role returns the role of the authenticated caller, aborting the request
+// when RequireAuth has not run before the guard
+func (m *RBACMiddleware) role(c *gin.Context) (string, bool) {
+	userRole, exists := c.Get("user_role")
+	if !exists {
+		m.logger.Debug("User role not found in context")
+		apperror.Abort(c, apperror.New(apperror.CodeUnauthorized, "Authentication required"))
+		return "", false
+	}
+
+	roleStr, ok := userRole.(string)
+	if !ok {
+		m.logger.Debug("Invalid user role type in context")
+		apperror.Abort(c, apperror.New(apperror.CodeInternal, "Internal server error"))
+		return "", false
+	}
+
+	return roleStr, true
+}
+
 // hasPermission checks if a role has the required permission
 func (m *RBACMiddleware) hasPermission(role, resource, action string) bool {
 	permissions, exists := RolePermissions[role]
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+)
+
+// errRequestFailed rolls back the transaction of a failed request
+var errRequestFailed = errors.New("request failed")
+
+// TenantRunner runs work in a transaction of a tenant, such as a
+// database.TenantScope or database.TenantRouter
+type TenantRunner interface {
+	Run(ctx context.Context, tenantID uuid.UUID, fn func(tx *gorm.DB) error) error
+}
+
+// TenantTransactionMiddleware runs each request in a transaction of the
+// caller's tenant, so PostgreSQL row-level security limits every query of
+// the request to that tenant
+type TenantTransactionMiddleware struct {
+	scope  TenantRunner
+	logger *logrus.Logger
+}
+
+// NewTenantTransactionMiddleware creates a new tenant transaction middleware
+func NewTenantTransactionMiddleware(scope TenantRunner, logger *logrus.Logger) *TenantTransactionMiddleware {
+	return &TenantTransactionMiddleware{
+		scope:  scope,
+		logger: logger,
+	}
+}
+
+// Handle runs the rest of the chain in a tenant transaction. It must run
+// after RequireAuth. The transaction commits when the response is below
+// 400 and the handlers attached no errors; the response is held back until
+// then, so a failed commit is reported instead of a lost success.
+func (m *TenantTransactionMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID, ok := c.Get("tenant_id")
+		id, isUUID := tenantID.(uuid.UUID)
+		if !ok || !isUUID || id == uuid.Nil {
+			apperror.Abort(c, apperror.New(apperror.CodeForbidden, "Tenant context not found"))
+			return
+		}
+
+		original := c.Writer
+		buffered := &bufferedResponseWriter{ResponseWriter: original, status: original.Status(), size: -1}
+		c.Writer = buffered
+		// A panicking handler leaves the response to the recovery middleware
+		defer func() { c.Writer = original }()
+
+		err := m.scope.Run(c.Request.Context(), id, func(tx *gorm.DB) error {
+			c.Request = c.Request.WithContext(tx.Statement.Context)
+			c.Next()
+			if buffered.status >= http.StatusBadRequest || len(c.Errors) > 0 {
+				return errRequestFailed
+			}
+			return nil
+		})
+		c.Writer = original
+
+		if err != nil && !errors.Is(err, errRequestFailed) {
+			m.logger.WithFields(logrus.Fields{
+				"tenant_id": id,
+				"path":      c.FullPath(),
+				"error":     err,
+			}).Error("Tenant transaction failed")
+			apperror.Abort(c, apperror.New(apperror.CodeInternal, "Internal server error"))
+			return
+		}
+		buffered.flush()
+	}
+}
+
+// bufferedResponseWriter holds the response back until flush
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	status int
+	size   int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	if code > 0 && !w.Written() {
+		w.status = code
+	}
+}
+
+func (w *bufferedResponseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+	}
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.body.Write(data)
+	w.size += n
+	return n, err
+}
+
+func (w *bufferedResponseWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.body.WriteString(s)
+	w.size += n
+	return n, err
+}
+
+func (w *bufferedResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedResponseWriter) Size() int {
+	return w.size
+}
+
+func (w *bufferedResponseWriter) Written() bool {
+	return w.size != -1
+}
+
+// Flush is a no-op; streaming responses cannot be held back
+func (w *bufferedResponseWriter) Flush() {}
+
+// flush writes the held back response. A response nobody wrote is left for
+// later middleware, such as the error renderer.
+func (w *bufferedResponseWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if !w.Written() {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	w.ResponseWriter.Write(w.body.Bytes())
+}
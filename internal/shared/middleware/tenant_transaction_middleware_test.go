@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+type tenantNote struct {
+	ID       uint      `gorm:"primaryKey"`
+	TenantID uuid.UUID `gorm:"type:uuid;not null"`
+	Text     string
+}
+
+func newTenantTransactionRouter(t *testing.T, tenantID interface{}) (*gin.Engine, *gorm.DB) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, db.AutoMigrate(&tenantNote{}))
+
+	tenantTransaction := NewTenantTransactionMiddleware(database.NewTenantScope(db, ""), logger)
+
+	router := gin.New()
+	router.Use(apperror.Middleware(logger))
+	router.Use(func(c *gin.Context) {
+		if tenantID != nil {
+			c.Set("tenant_id", tenantID)
+		}
+		c.Next()
+	})
+	router.Use(tenantTransaction.Handle())
+
+	create := func(c *gin.Context) bool {
+		id, _ := database.TenantFromContext(c.Request.Context())
+		note := &tenantNote{TenantID: id, Text: c.Query("text")}
+		if err := database.Conn(c.Request.Context(), db).Create(note).Error; err != nil {
+			c.Error(err)
+			return false
+		}
+		return true
+	}
+	router.POST("/notes", func(c *gin.Context) {
+		if create(c) {
+			c.JSON(http.StatusCreated, gin.H{"text": c.Query("text")})
+		}
+	})
+	router.POST("/notes/invalid", func(c *gin.Context) {
+		if create(c) {
+			apperror.Respond(c, apperror.New(apperror.CodeValidationFailed, "Invalid note"))
+		}
+	})
+	router.POST("/notes/error", func(c *gin.Context) {
+		if create(c) {
+			c.Error(errors.New("downstream failed"))
+		}
+	})
+	router.DELETE("/notes", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router, db
+}
+
+func noteTexts(t *testing.T, db *gorm.DB) []string {
+	var texts []string
+	require.NoError(t, db.Model(&tenantNote{}).Order("id").Pluck("text", &texts).Error)
+	return texts
+}
+
+func TestTenantTransactionMiddleware(t *testing.T) {
+	tenantID := uuid.New()
+	router, db := newTenantTransactionRouter(t, tenantID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notes?text=kept", nil))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"text":"kept"}`, w.Body.String())
+
+	// Failed requests roll back what they wrote
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notes/invalid?text=invalid", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notes/error?text=error", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), string(apperror.CodeInternal))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/notes", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	assert.Equal(t, []string{"kept"}, noteTexts(t, db))
+
+	var stored tenantNote
+	require.NoError(t, db.First(&stored).Error)
+	assert.Equal(t, tenantID, stored.TenantID)
+}
+
+func TestTenantTransactionMiddleware_RequiresTenant(t *testing.T) {
+	for name, tenantID := range map[string]interface{}{
+		"missing": nil,
+		"nil":     uuid.Nil,
+		"string":  uuid.New().String(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			router, db := newTenantTransactionRouter(t, tenantID)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notes?text=lost", nil))
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Empty(t, noteTexts(t, db))
+		})
+	}
+}
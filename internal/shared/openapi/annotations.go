@@ -0,0 +1,328 @@
+package openapi
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+)
+
+// annotation is the swag style comment block of a handler, e.g.
+//
+//	// @Summary Authenticate user
+//	// @Tags authentication
+//	// @Param request body LoginRequest true "Login request"
+//	// @Success 200 {object} SuccessResponse{data=AuthResponse}
+//	// @Security BearerAuth
+//	// @Router /auth/login [post]
+type annotation struct {
+	pkg          string
+	handler      string
+	Summary      string
+	Description  string
+	Tags         []string
+	Accept       []string
+	Params       []paramAnnotation
+	Responses    []responseAnnotation
+	Security     []string
+	RouterPath   string
+	RouterMethod string
+}
+
+type paramAnnotation struct {
+	Name        string
+	In          string
+	Type        string
+	Required    bool
+	Description string
+}
+
+type responseAnnotation struct {
+	Status      string
+	Kind        string
+	Type        string
+	Description string
+}
+
+// sourceInfo is what the generator reads from the handler sources
+type sourceInfo struct {
+	// annotations by handler key, e.g. handler.(*AuthHandler).Login
+	annotations map[string]*annotation
+	// aliases maps alias types to their target, e.g. handler.ErrorResponse
+	// to apperror.Response
+	aliases map[string]string
+	// docs holds the doc comment of every type by qualified name
+	docs map[string]string
+}
+
+// parseSources reads the handler annotations and type docs of the Go
+// packages in dirs
+func parseSources(dirs []string) (*sourceInfo, error) {
+	info := &sourceInfo{
+		annotations: make(map[string]*annotation),
+		aliases:     make(map[string]string),
+		docs:        make(map[string]string),
+	}
+
+	fset := token.NewFileSet()
+	for _, dir := range dirs {
+		pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+			return !strings.HasSuffix(fi.Name(), "_test.go")
+		}, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", dir, err)
+		}
+
+		for pkgName, pkg := range pkgs {
+			for _, file := range pkg.Files {
+				for _, decl := range file.Decls {
+					switch d := decl.(type) {
+					case *ast.FuncDecl:
+						if d.Doc == nil {
+							continue
+						}
+						a, err := parseAnnotation(pkgName, d.Doc)
+						if err != nil {
+							return nil, fmt.Errorf("%s: %w", fset.Position(d.Pos()), err)
+						}
+						if a != nil {
+							a.handler = handlerKey(pkgName, d)
+							info.annotations[a.handler] = a
+						}
+					case *ast.GenDecl:
+						info.collectTypes(pkgName, d)
+					}
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+func (s *sourceInfo) collectTypes(pkgName string, d *ast.GenDecl) {
+	if d.Tok != token.TYPE {
+		return
+	}
+	for _, spec := range d.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		name := pkgName + "." + ts.Name.Name
+
+		doc := ts.Doc
+		if doc == nil && len(d.Specs) == 1 {
+			doc = d.Doc
+		}
+		if doc != nil {
+			s.docs[name] = strings.TrimSpace(doc.Text())
+		}
+
+		if ts.Assign.IsValid() {
+			switch target := ts.Type.(type) {
+			case *ast.Ident:
+				s.aliases[name] = pkgName + "." + target.Name
+			case *ast.SelectorExpr:
+				if pkg, ok := target.X.(*ast.Ident); ok {
+					s.aliases[name] = pkg.Name + "." + target.Sel.Name
+				}
+			}
+		}
+	}
+}
+
+// handlerKey returns the name gin reports for a handler, without the
+// package path and the -fm suffix of method values
+func handlerKey(pkgName string, d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return pkgName + "." + d.Name.Name
+	}
+	switch recv := d.Recv.List[0].Type.(type) {
+	case *ast.StarExpr:
+		if ident, ok := recv.X.(*ast.Ident); ok {
+			return fmt.Sprintf("%s.(*%s).%s", pkgName, ident.Name, d.Name.Name)
+		}
+	case *ast.Ident:
+		return fmt.Sprintf("%s.%s.%s", pkgName, recv.Name, d.Name.Name)
+	}
+	return pkgName + "." + d.Name.Name
+}
+
+// parseAnnotation parses a doc comment, returning nil when it has no @Router
+func parseAnnotation(pkgName string, doc *ast.CommentGroup) (*annotation, error) {
+	a := &annotation{pkg: pkgName}
+	for _, comment := range doc.List {
+		line := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+		if !strings.HasPrefix(line, "@") {
+			continue
+		}
+		keyword, rest, _ := strings.Cut(line, " ")
+		rest = strings.TrimSpace(rest)
+
+		switch strings.ToLower(keyword) {
+		case "@summary":
+			a.Summary = rest
+		case "@description":
+			a.Description = rest
+		case "@tags":
+			for _, tag := range strings.Split(rest, ",") {
+				a.Tags = append(a.Tags, strings.TrimSpace(tag))
+			}
+		case "@accept":
+			a.Accept = append(a.Accept, strings.Fields(rest)...)
+		case "@param":
+			fields := splitFields(rest)
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("invalid @Param %q", rest)
+			}
+			param := paramAnnotation{
+				Name:     fields[0],
+				In:       fields[1],
+				Type:     fields[2],
+				Required: fields[3] == "true",
+			}
+			if len(fields) > 4 {
+				param.Description = fields[4]
+			}
+			a.Params = append(a.Params, param)
+		case "@success", "@failure":
+			fields := splitFields(rest)
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("invalid %s %q", keyword, rest)
+			}
+			response := responseAnnotation{
+				Status: fields[0],
+				Kind:   strings.Trim(fields[1], "{}"),
+				Type:   fields[2],
+			}
+			if len(fields) > 3 {
+				response.Description = fields[3]
+			}
+			a.Responses = append(a.Responses, response)
+		case "@security":
+			a.Security = append(a.Security, strings.Fields(rest)[0])
+		case "@router":
+			path, method, ok := strings.Cut(rest, " ")
+			if !ok {
+				return nil, fmt.Errorf("invalid @Router %q", rest)
+			}
+			a.RouterPath = path
+			a.RouterMethod = strings.ToUpper(strings.Trim(strings.TrimSpace(method), "[]"))
+		}
+	}
+
+	if a.RouterPath == "" {
+		return nil, nil
+	}
+	return a, nil
+}
+
+// splitFields splits on whitespace, keeping "quoted text" and {braced}
+// type expressions together
+func splitFields(s string) []string {
+	var fields []string
+	var current strings.Builder
+	depth := 0
+	quoted := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			fields = append(fields, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case r == '"' && depth == 0:
+			if quoted {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+			quoted = !quoted
+		case quoted:
+			current.WriteRune(r)
+		case r == '{':
+			depth++
+			current.WriteRune(r)
+		case r == '}':
+			depth--
+			current.WriteRune(r)
+		case (r == ' ' || r == '\t') && depth == 0:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return fields
+}
+
+// typeExpr is a parsed annotation type, e.g. SuccessResponse{data=[]UserDTO}
+type typeExpr struct {
+	Array     bool
+	Name      string
+	Overrides []typeOverride
+}
+
+type typeOverride struct {
+	Field string
+	Type  *typeExpr
+}
+
+// parseTypeExpr parses the swag type syntax: an optional [] prefix, a type
+// name and optional {field=type,...} overrides of object fields
+func parseTypeExpr(s string) (*typeExpr, error) {
+	expr, rest, err := parseTypeExprPrefix(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("unexpected %q in type %q", rest, s)
+	}
+	return expr, nil
+}
+
+func parseTypeExprPrefix(s string) (*typeExpr, string, error) {
+	expr := &typeExpr{}
+	if strings.HasPrefix(s, "[]") {
+		expr.Array = true
+		s = s[2:]
+	}
+
+	end := strings.IndexAny(s, "{},")
+	if end < 0 {
+		end = len(s)
+	}
+	expr.Name = s[:end]
+	if expr.Name == "" {
+		return nil, "", fmt.Errorf("missing type name")
+	}
+	s = s[end:]
+
+	if !strings.HasPrefix(s, "{") {
+		return expr, s, nil
+	}
+	s = s[1:]
+	for {
+		field, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, "", fmt.Errorf("invalid override %q", s)
+		}
+		fieldType, rest, err := parseTypeExprPrefix(rest)
+		if err != nil {
+			return nil, "", err
+		}
+		expr.Overrides = append(expr.Overrides, typeOverride{Field: strings.TrimSpace(field), Type: fieldType})
+
+		switch {
+		case strings.HasPrefix(rest, ","):
+			s = rest[1:]
+		case strings.HasPrefix(rest, "}"):
+			return expr, rest[1:], nil
+		default:
+			return nil, "", fmt.Errorf("unterminated override list")
+		}
+	}
+}
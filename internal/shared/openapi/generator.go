@@ -0,0 +1,458 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// GeneratedHeader starts every generated document
+const GeneratedHeader = "# Code generated by cmd/openapi-gen. DO NOT EDIT.\n"
+
+// GeneratorConfig describes the service a document is generated for
+type GeneratorConfig struct {
+	Title       string
+	Description string
+	Version     string
+	// Servers are the server URLs; the path of the first one is stripped
+	// from the gin routes, e.g. http://localhost:8080/api/v1
+	Servers []string
+	// SourceDirs are the packages holding the annotated handlers and DTOs
+	SourceDirs []string
+	// Models are zero values of every type the annotations reference
+	// outside of other models, e.g. handler.LoginRequest{}
+	Models []interface{}
+	// SecuritySchemes are the authentication schemes by the name that
+	// @Security annotations use
+	SecuritySchemes map[string]SecurityScheme
+}
+
+// SecurityScheme is an OpenAPI security scheme
+type SecurityScheme struct {
+	Type         string `yaml:"type"`
+	Description  string `yaml:"description,omitempty"`
+	Scheme       string `yaml:"scheme,omitempty"`
+	BearerFormat string `yaml:"bearerFormat,omitempty"`
+	Name         string `yaml:"name,omitempty"`
+	In           string `yaml:"in,omitempty"`
+}
+
+// Generate builds an OpenAPI 3.1 document from the gin route table and the
+// handler annotations. Every route must have an annotated handler whose
+// @Router matches it, so a route added or moved without updating its
+// documentation fails generation.
+func Generate(routes gin.RoutesInfo, config GeneratorConfig) ([]byte, error) {
+	sources, err := parseSources(config.SourceDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		config:  config,
+		sources: sources,
+		schemas: newSchemaBuilder(sources.docs),
+		models:  make(map[string]reflect.Type),
+	}
+	for _, model := range config.Models {
+		t := reflect.TypeOf(model)
+		g.models[qualifiedName(t)] = t
+	}
+
+	// Aliased types take the alias name however the first route reaches
+	// them, so adding a route does not rename a shared schema
+	aliases := make([]string, 0, len(sources.aliases))
+	for alias := range sources.aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		if t, ok := g.models[sources.aliases[alias]]; ok {
+			g.schemas.preferName(t, alias[strings.LastIndex(alias, ".")+1:])
+		}
+	}
+
+	doc := &documentOut{
+		OpenAPI: "3.1.0",
+		Info: infoOut{
+			Title:       config.Title,
+			Description: config.Description,
+			Version:     config.Version,
+		},
+		Paths: make(map[string]map[string]*operationOut),
+	}
+	for _, server := range config.Servers {
+		doc.Servers = append(doc.Servers, serverOut{URL: server})
+	}
+	basePath := ""
+	if len(config.Servers) > 0 {
+		basePath = serverBasePath(config.Servers[0])
+	}
+
+	var errs []string
+	tags := make(map[string]bool)
+	operationIDs := make(map[string]string)
+
+	sorted := append(gin.RoutesInfo{}, routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	for _, route := range sorted {
+		path := openAPIPath(strings.TrimPrefix(route.Path, basePath))
+		key := routeHandlerKey(route.Handler)
+
+		a, ok := sources.annotations[key]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s %s: handler %s has no @Router annotation", route.Method, route.Path, key))
+			continue
+		}
+		if a.RouterMethod != route.Method || a.RouterPath != path {
+			errs = append(errs, fmt.Sprintf("%s %s: handler %s is annotated as @Router %s [%s]",
+				route.Method, route.Path, key, a.RouterPath, strings.ToLower(a.RouterMethod)))
+			continue
+		}
+
+		op, err := g.operation(a, path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s %s: %v", route.Method, route.Path, err))
+			continue
+		}
+		if other, taken := operationIDs[op.OperationID]; taken {
+			errs = append(errs, fmt.Sprintf("%s %s: operationId %s is already used by %s", route.Method, route.Path, op.OperationID, other))
+			continue
+		}
+		operationIDs[op.OperationID] = route.Method + " " + route.Path
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*operationOut)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+		for _, tag := range op.Tags {
+			tags[tag] = true
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("OpenAPI generation failed:\n  %s", strings.Join(errs, "\n  "))
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, tagOut{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	doc.Components.Schemas = g.schemas.components
+	doc.Components.SecuritySchemes = config.SecuritySchemes
+
+	var buf bytes.Buffer
+	buf.WriteString(GeneratedHeader)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type generator struct {
+	config  GeneratorConfig
+	sources *sourceInfo
+	schemas *schemaBuilder
+	models  map[string]reflect.Type
+}
+
+func (g *generator) operation(a *annotation, path string) (*operationOut, error) {
+	op := &operationOut{
+		Tags:        a.Tags,
+		Summary:     a.Summary,
+		Description: a.Description,
+		OperationID: operationID(a.handler),
+		Responses:   make(map[string]*responseOut),
+	}
+
+	declared := make(map[string]bool)
+	for _, param := range a.Params {
+		if param.In == "body" {
+			schema, err := g.typeSchema(a.pkg, param.Type, false)
+			if err != nil {
+				return nil, err
+			}
+			contentType := "application/json"
+			if len(a.Accept) > 0 && a.Accept[0] != "json" {
+				contentType = a.Accept[0]
+			}
+			op.RequestBody = &requestBodyOut{
+				Description: param.Description,
+				Required:    param.Required,
+				Content:     map[string]mediaTypeOut{contentType: {Schema: schema}},
+			}
+			continue
+		}
+
+		schema, ok := scalarSchema(param.Type)
+		if !ok {
+			return nil, fmt.Errorf("unsupported %s parameter type %q", param.In, param.Type)
+		}
+		op.Parameters = append(op.Parameters, parameterOut{
+			Name:        param.Name,
+			In:          param.In,
+			Description: param.Description,
+			Required:    param.Required || param.In == "path",
+			Schema:      schema,
+		})
+		declared[param.In+":"+param.Name] = true
+	}
+
+	// Every path template parameter must be declared
+	for _, segment := range splitPath(path) {
+		if name, ok := paramName(segment); ok && !declared["path:"+name] {
+			op.Parameters = append(op.Parameters, parameterOut{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &schemaOut{Type: "string"},
+			})
+		}
+	}
+
+	for _, response := range a.Responses {
+		out := &responseOut{Description: response.Description}
+		if out.Description == "" {
+			status, _ := strconv.Atoi(response.Status)
+			out.Description = http.StatusText(status)
+		}
+		if response.Type != "" {
+			schema, err := g.typeSchema(a.pkg, response.Type, response.Kind == "array")
+			if err != nil {
+				return nil, err
+			}
+			out.Content = map[string]mediaTypeOut{"application/json": {Schema: schema}}
+		}
+		op.Responses[response.Status] = out
+	}
+	if len(op.Responses) == 0 {
+		return nil, fmt.Errorf("no @Success or @Failure responses")
+	}
+
+	for _, name := range a.Security {
+		if _, ok := g.config.SecuritySchemes[name]; !ok {
+			return nil, fmt.Errorf("unknown security scheme %s", name)
+		}
+		op.Security = append(op.Security, map[string][]string{name: {}})
+	}
+	return op, nil
+}
+
+// typeSchema builds the schema of an annotation type expression. Names
+// without a package are resolved in the package of the handler.
+func (g *generator) typeSchema(pkg, expr string, array bool) (*schemaOut, error) {
+	parsed, err := parseTypeExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := g.exprSchema(pkg, parsed)
+	if err != nil {
+		return nil, err
+	}
+	if array {
+		return &schemaOut{Type: "array", Items: schema}, nil
+	}
+	return schema, nil
+}
+
+func (g *generator) exprSchema(pkg string, expr *typeExpr) (*schemaOut, error) {
+	var schema *schemaOut
+	if scalar, ok := scalarSchema(expr.Name); ok {
+		schema = scalar
+	} else {
+		t, err := g.resolve(pkg, expr.Name)
+		if err != nil {
+			return nil, err
+		}
+		schema = g.schemas.schemaFor(t)
+	}
+
+	if len(expr.Overrides) > 0 {
+		overrides := &schemaOut{Type: "object", Properties: make(map[string]*schemaOut)}
+		for _, override := range expr.Overrides {
+			fieldSchema, err := g.exprSchema(pkg, override.Type)
+			if err != nil {
+				return nil, err
+			}
+			overrides.Properties[override.Field] = fieldSchema
+			overrides.Required = append(overrides.Required, override.Field)
+		}
+		if schema.Ref == "" && len(schema.Properties) == 0 {
+			// A plain object{...} is described by its overrides alone
+			schema = overrides
+		} else {
+			schema = &schemaOut{AllOf: []*schemaOut{schema, overrides}}
+		}
+	}
+
+	if expr.Array {
+		return &schemaOut{Type: "array", Items: schema}, nil
+	}
+	return schema, nil
+}
+
+// resolve finds the Go type of an annotation type name
+func (g *generator) resolve(pkg, name string) (reflect.Type, error) {
+	qualified := name
+	if !strings.Contains(name, ".") {
+		qualified = pkg + "." + name
+	}
+	alias := ""
+	for depth := 0; depth < 8; depth++ {
+		target, ok := g.sources.aliases[qualified]
+		if !ok {
+			break
+		}
+		if alias == "" {
+			alias = qualified[strings.LastIndex(qualified, ".")+1:]
+		}
+		qualified = target
+	}
+
+	t, ok := g.models[qualified]
+	if !ok {
+		t, ok = g.schemas.byName(qualified)
+	}
+	if !ok {
+		return nil, fmt.Errorf("type %s is not registered as a model", qualified)
+	}
+	// Aliased types are published under the alias name, e.g. ErrorResponse
+	// rather than apperror's Response
+	if alias != "" {
+		g.schemas.preferName(t, alias)
+	}
+	return t, nil
+}
+
+// scalarSchema maps the primitive annotation types
+func scalarSchema(name string) (*schemaOut, bool) {
+	switch name {
+	case "string":
+		return &schemaOut{Type: "string"}, true
+	case "int", "integer":
+		return &schemaOut{Type: "integer"}, true
+	case "number", "float", "float64":
+		return &schemaOut{Type: "number"}, true
+	case "bool", "boolean":
+		return &schemaOut{Type: "boolean"}, true
+	case "object":
+		return &schemaOut{Type: "object"}, true
+	case "file":
+		return &schemaOut{Type: "string", Format: "binary"}, true
+	}
+	return nil, false
+}
+
+// routeHandlerKey trims the package path and method value suffix from a
+// gin handler name, e.g. github.com/x/handler.(*AuthHandler).Login-fm
+func routeHandlerKey(name string) string {
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// openAPIPath converts gin path parameters to OpenAPI templates
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	if joined := strings.Join(segments, "/"); joined != "" {
+		return joined
+	}
+	return "/"
+}
+
+// operationID derives a stable operation ID from the handler name
+func operationID(handler string) string {
+	name := handler[strings.LastIndex(handler, ".")+1:]
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// Generated document layout. Field order is the order of the YAML output.
+
+type documentOut struct {
+	OpenAPI    string                              `yaml:"openapi"`
+	Info       infoOut                             `yaml:"info"`
+	Servers    []serverOut                         `yaml:"servers,omitempty"`
+	Tags       []tagOut                            `yaml:"tags,omitempty"`
+	Paths      map[string]map[string]*operationOut `yaml:"paths"`
+	Components componentsOut                       `yaml:"components"`
+}
+
+type infoOut struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description,omitempty"`
+	Version     string `yaml:"version"`
+}
+
+type serverOut struct {
+	URL string `yaml:"url"`
+}
+
+type tagOut struct {
+	Name string `yaml:"name"`
+}
+
+type operationOut struct {
+	Tags        []string                `yaml:"tags,omitempty"`
+	Summary     string                  `yaml:"summary,omitempty"`
+	Description string                  `yaml:"description,omitempty"`
+	OperationID string                  `yaml:"operationId"`
+	Parameters  []parameterOut          `yaml:"parameters,omitempty"`
+	RequestBody *requestBodyOut         `yaml:"requestBody,omitempty"`
+	Responses   map[string]*responseOut `yaml:"responses"`
+	Security    []map[string][]string   `yaml:"security,omitempty"`
+}
+
+type parameterOut struct {
+	Name        string     `yaml:"name"`
+	In          string     `yaml:"in"`
+	Description string     `yaml:"description,omitempty"`
+	Required    bool       `yaml:"required"`
+	Schema      *schemaOut `yaml:"schema"`
+}
+
+type requestBodyOut struct {
+	Description string                  `yaml:"description,omitempty"`
+	Required    bool                    `yaml:"required"`
+	Content     map[string]mediaTypeOut `yaml:"content"`
+}
+
+type responseOut struct {
+	Description string                  `yaml:"description"`
+	Content     map[string]mediaTypeOut `yaml:"content,omitempty"`
+}
+
+type mediaTypeOut struct {
+	Schema *schemaOut `yaml:"schema"`
+}
+
+type componentsOut struct {
+	Schemas         map[string]*schemaOut     `yaml:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `yaml:"securitySchemes,omitempty"`
+}
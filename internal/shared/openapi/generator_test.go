@@ -0,0 +1,208 @@
+package openapi
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testHandlerSource = `package openapi
+
+// CreateWidget creates a widget
+// @Summary Create widget
+// @Tags widgets
+// @Accept json
+// @Param request body widgetRequest true "Widget"
+// @Success 201 {object} object{success=bool,data=widget}
+// @Failure 400 {object} errorAlias
+// @Security BearerAuth
+// @Router /widgets [post]
+func (h *widgetHandler) CreateWidget(c *gin.Context) {}
+
+// GetWidget returns a widget
+// @Summary Get widget
+// @Tags widgets
+// @Param id path string true "Widget ID"
+// @Param expand query bool false "Expand parts"
+// @Success 200 {array} widget
+// @Router /widgets/{id} [get]
+func (h *widgetHandler) GetWidget(c *gin.Context) {}
+
+// widget is a thing the tests generate schemas for
+type widget struct{}
+
+type errorAlias = widgetError
+`
+
+type widgetHandler struct{}
+
+func (h *widgetHandler) CreateWidget(c *gin.Context) {}
+func (h *widgetHandler) GetWidget(c *gin.Context)    {}
+func (h *widgetHandler) Undocumented(c *gin.Context) {}
+
+type widgetPart struct {
+	SKU string `json:"sku" binding:"required,len=8"`
+}
+
+type widget struct {
+	ID        uuid.UUID    `json:"id" example:"6f1c9a52-6a0e-4d8b-9a53-2f1d6f3c8e10"`
+	Name      string       `json:"name"`
+	Count     int          `json:"count" example:"3"`
+	Parts     []widgetPart `json:"parts,omitempty"`
+	Parent    *widget      `json:"parent,omitempty"`
+	Note      *string      `json:"note,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	internal  string
+}
+
+type widgetRequest struct {
+	Name  string   `json:"name" binding:"required,min=2,max=50"`
+	Kind  string   `json:"kind" binding:"omitempty,oneof=small large"`
+	Email string   `json:"email" binding:"required,email"`
+	Phone string   `json:"phone" binding:"omitempty,e164"`
+	Count int      `json:"count" binding:"gte=1,lte=10"`
+	Tags  []string `json:"tags" binding:"max=3"`
+	Debug bool     `json:"-"`
+}
+
+type widgetError struct {
+	Code string `json:"code"`
+}
+
+func generateTestSpec(t *testing.T, register func(r *gin.RouterGroup)) ([]byte, error) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "handler.go"), []byte(testHandlerSource), 0644))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	register(router.Group("/api/v1"))
+
+	return Generate(router.Routes(), GeneratorConfig{
+		Title:      "Widgets",
+		Version:    "1.0.0",
+		Servers:    []string{"http://localhost:8080/api/v1"},
+		SourceDirs: []string{dir},
+		Models:     []interface{}{widget{}, widgetRequest{}, widgetError{}},
+		SecuritySchemes: map[string]SecurityScheme{
+			"BearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		},
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	h := &widgetHandler{}
+	spec, err := generateTestSpec(t, func(r *gin.RouterGroup) {
+		r.POST("/widgets", h.CreateWidget)
+		r.GET("/widgets/:id", h.GetWidget)
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(spec), GeneratedHeader))
+
+	// The output is a valid document for the request validator
+	_, err = Parse(spec)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(spec, &doc))
+	assert.Equal(t, "3.1.0", doc["openapi"])
+
+	paths := doc["paths"].(map[string]interface{})
+	create := paths["/widgets"].(map[string]interface{})["post"].(map[string]interface{})
+	assert.Equal(t, "createWidget", create["operationId"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"BearerAuth": []interface{}{}}}, create["security"])
+
+	get := paths["/widgets/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+	params := get["parameters"].([]interface{})
+	require.Len(t, params, 2)
+	assert.Equal(t, map[string]interface{}{
+		"name": "id", "in": "path", "description": "Widget ID", "required": true,
+		"schema": map[string]interface{}{"type": "string"},
+	}, params[0])
+
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	// Aliases are published under the alias name
+	assert.Contains(t, schemas, "errorAlias")
+	assert.NotContains(t, schemas, "widgetError")
+
+	w := schemas["widget"].(map[string]interface{})
+	assert.Equal(t, "widget is a thing the tests generate schemas for", w["description"])
+	assert.Equal(t, []interface{}{"count", "created_at", "id", "name"}, w["required"])
+	props := w["properties"].(map[string]interface{})
+	assert.NotContains(t, props, "internal")
+	assert.Equal(t, map[string]interface{}{
+		"type": "string", "format": "uuid", "example": "6f1c9a52-6a0e-4d8b-9a53-2f1d6f3c8e10",
+	}, props["id"])
+	assert.Equal(t, map[string]interface{}{"type": "integer", "example": 3}, props["count"])
+	assert.Equal(t, map[string]interface{}{"type": []interface{}{"string", "null"}}, props["note"])
+	assert.Equal(t, map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"$ref": "#/components/schemas/widget"},
+			map[string]interface{}{"type": "null"},
+		},
+	}, props["parent"])
+
+	part := schemas["widgetPart"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string", "minLength": 8, "maxLength": 8},
+		part["properties"].(map[string]interface{})["sku"])
+
+	req := schemas["widgetRequest"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"email", "name"}, req["required"])
+	reqProps := req["properties"].(map[string]interface{})
+	assert.NotContains(t, reqProps, "Debug")
+	assert.Equal(t, map[string]interface{}{"type": "string", "minLength": 2, "maxLength": 50}, reqProps["name"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "enum": []interface{}{"small", "large"}}, reqProps["kind"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "format": "email"}, reqProps["email"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "pattern": e164Pattern}, reqProps["phone"])
+	assert.Equal(t, map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 10}, reqProps["count"])
+	assert.Equal(t, 3, reqProps["tags"].(map[string]interface{})["maxItems"])
+}
+
+func TestGenerate_RouteWithoutAnnotation(t *testing.T) {
+	h := &widgetHandler{}
+	_, err := generateTestSpec(t, func(r *gin.RouterGroup) {
+		r.DELETE("/widgets/:id", h.Undocumented)
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "(*widgetHandler).Undocumented has no @Router annotation")
+}
+
+func TestGenerate_RouterMismatch(t *testing.T) {
+	h := &widgetHandler{}
+	_, err := generateTestSpec(t, func(r *gin.RouterGroup) {
+		r.PUT("/widgets/:id", h.GetWidget)
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is annotated as @Router /widgets/{id} [get]")
+}
+
+func TestParseTypeExpr(t *testing.T) {
+	expr, err := parseTypeExpr("SuccessResponse{data=[]UserDTO,meta=object{total=int}}")
+	require.NoError(t, err)
+	assert.Equal(t, &typeExpr{
+		Name: "SuccessResponse",
+		Overrides: []typeOverride{
+			{Field: "data", Type: &typeExpr{Array: true, Name: "UserDTO"}},
+			{Field: "meta", Type: &typeExpr{Name: "object", Overrides: []typeOverride{
+				{Field: "total", Type: &typeExpr{Name: "int"}},
+			}}},
+		},
+	}, expr)
+
+	_, err = parseTypeExpr("SuccessResponse{data=UserDTO")
+	assert.Error(t, err)
+}
+
+func TestSplitFields(t *testing.T) {
+	assert.Equal(t,
+		[]string{"200", "{object}", "object{success=bool, data=[]City}", "Cities of a province"},
+		splitFields(`200 {object} object{success=bool, data=[]City} "Cities of a province"`))
+}
@@ -0,0 +1,439 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+)
+
+// Options configures the contract validation
+type Options struct {
+	// ValidateResponses checks every response against the spec and replaces
+	// responses that drift from it with a RESPONSE_CONTRACT_VIOLATION error.
+	// It buffers whole responses and is meant for tests and CI only.
+	ValidateResponses bool
+}
+
+// Validator validates HTTP traffic against an OpenAPI document. Paths and
+// methods the document does not describe are passed through untouched.
+type Validator struct {
+	doc     *Document
+	router  *router
+	options Options
+	logger  *logrus.Logger
+}
+
+// NewValidator creates a validator for a parsed document
+func NewValidator(doc *Document, options Options, logger *logrus.Logger) *Validator {
+	return &Validator{
+		doc:     doc,
+		router:  newRouter(doc),
+		options: options,
+		logger:  logger,
+	}
+}
+
+// NewValidatorFromFile loads the spec at path and creates a validator for it
+func NewValidatorFromFile(path string, options Options, logger *logrus.Logger) (*Validator, error) {
+	doc, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewValidator(doc, options, logger), nil
+}
+
+// Middleware validates path, query and header parameters and the request
+// body of documented operations, rejecting invalid requests with the
+// standard error envelope
+func (v *Validator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op, ok := v.find(c.Request)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if err := v.validateRequest(c.Request, op); err != nil {
+			apperror.Abort(c, err)
+			return
+		}
+
+		if !v.options.ValidateResponses {
+			c.Next()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if err := v.validateResponse(op.operation, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes()); err != nil {
+			v.logger.WithFields(logrus.Fields{
+				"method":  c.Request.Method,
+				"path":    c.Request.URL.Path,
+				"route":   op.template,
+				"status":  writer.Status(),
+				"details": err.Details,
+			}).Error("Response does not match the OpenAPI contract")
+
+			writer.Header().Del("Content-Length")
+			apperror.Respond(c, err)
+			return
+		}
+		writer.flush()
+	}
+}
+
+// ValidateRequest validates a request against the spec. It returns nil for
+// requests the spec does not describe.
+func (v *Validator) ValidateRequest(r *http.Request) *apperror.Error {
+	op, ok := v.find(r)
+	if !ok {
+		return nil
+	}
+	return v.validateRequest(r, op)
+}
+
+// matchedOperation is a documented operation matched to a request
+type matchedOperation struct {
+	template   string
+	operation  *Operation
+	parameters []*Parameter
+	pathValues map[string]string
+}
+
+func (v *Validator) find(r *http.Request) (*matchedOperation, bool) {
+	route, pathValues, ok := v.router.match(r.URL.Path)
+	if !ok {
+		return nil, false
+	}
+	op, ok := route.item.Operations()[r.Method]
+	if !ok {
+		return nil, false
+	}
+
+	// Operation parameters override path item parameters of the same name
+	byKey := make(map[string]*Parameter)
+	var order []string
+	for _, p := range append(append([]*Parameter{}, route.item.Parameters...), op.Parameters...) {
+		parameter, err := v.doc.parameter(p)
+		if err != nil {
+			continue
+		}
+		key := parameter.In + ":" + parameter.Name
+		if _, seen := byKey[key]; !seen {
+			order = append(order, key)
+		}
+		byKey[key] = parameter
+	}
+	parameters := make([]*Parameter, 0, len(order))
+	for _, key := range order {
+		parameters = append(parameters, byKey[key])
+	}
+
+	return &matchedOperation{
+		template:   route.template,
+		operation:  op,
+		parameters: parameters,
+		pathValues: pathValues,
+	}, true
+}
+
+func (v *Validator) validateRequest(r *http.Request, op *matchedOperation) *apperror.Error {
+	var fields []apperror.FieldError
+	query := r.URL.Query()
+
+	for _, parameter := range op.parameters {
+		var values []string
+		switch parameter.In {
+		case "path":
+			if value, ok := op.pathValues[parameter.Name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = query[parameter.Name]
+		case "header":
+			values = r.Header.Values(parameter.Name)
+		default:
+			continue
+		}
+
+		if len(values) == 0 {
+			if parameter.Required || parameter.In == "path" {
+				fields = append(fields, fieldError(parameter.Name, "required", nil))
+			}
+			continue
+		}
+
+		value, fieldErr := v.coerce(parameter, values)
+		if fieldErr != nil {
+			fields = append(fields, *fieldErr)
+			continue
+		}
+		v.doc.validateValue(parameter.Schema, value, parameter.Name, &fields)
+	}
+
+	if len(fields) > 0 {
+		return apperror.Validation(fields...)
+	}
+	return v.validateBody(r, op.operation)
+}
+
+func (v *Validator) validateBody(r *http.Request, op *Operation) *apperror.Error {
+	requestBody, err := v.doc.requestBody(op.RequestBody)
+	if err != nil || requestBody == nil {
+		return nil
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			return apperror.Wrap(err, apperror.CodeBadRequest, "failed to read request body")
+		}
+		// Restore the body for the handler
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if requestBody.Required {
+			return apperror.Validation(fieldError("body", "required", nil))
+		}
+		return nil
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	media, ok := mediaTypeFor(requestBody.Content, contentType)
+	if !ok {
+		return apperror.Newf(apperror.CodeUnsupportedMediaType, "content type %q is not accepted", contentType)
+	}
+	if media == nil || media.Schema == nil || !isJSON(contentType) {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return apperror.Wrap(err, apperror.CodeBadRequest, "malformed JSON body")
+	}
+
+	var fields []apperror.FieldError
+	v.doc.validateValue(media.Schema, value, "", &fields)
+	if len(fields) > 0 {
+		for i := range fields {
+			if fields[i].Field == "" {
+				fields[i].Field = "body"
+			}
+		}
+		return apperror.Validation(fields...)
+	}
+	return nil
+}
+
+// validateResponse checks a response status and body against the operation
+func (v *Validator) validateResponse(op *Operation, status int, contentType string, body []byte) *apperror.Error {
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		response, ok = op.Responses[fmt.Sprintf("%dXX", status/100)]
+	}
+	if !ok {
+		response, ok = op.Responses["default"]
+	}
+	if !ok {
+		return violation(status, fmt.Sprintf("status %d is not documented", status))
+	}
+
+	response, err := v.doc.response(response)
+	if err != nil {
+		return violation(status, err.Error())
+	}
+	if len(response.Content) == 0 || len(body) == 0 {
+		return nil
+	}
+
+	media, ok := mediaTypeFor(response.Content, contentType)
+	if !ok {
+		return violation(status, fmt.Sprintf("content type %q is not documented", contentType))
+	}
+	if media == nil || media.Schema == nil || !isJSON(contentType) {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return violation(status, "body is not valid JSON")
+	}
+
+	var fields []apperror.FieldError
+	v.doc.validateValue(media.Schema, value, "", &fields)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	violations := make([]string, 0, len(fields))
+	for _, field := range fields {
+		name := field.Field
+		if name == "" {
+			name = "body"
+		}
+		violations = append(violations, name+": "+apperror.FieldMessage(field, apperror.English))
+	}
+	return violation(status, violations...)
+}
+
+func violation(status int, violations ...string) *apperror.Error {
+	return apperror.New(apperror.CodeContractViolation, "response does not match the OpenAPI contract").
+		WithDetail("status", status).
+		WithDetail("violations", violations)
+}
+
+// mediaTypeFor finds the content entry of a Content-Type header, trying
+// the exact type, the type/* wildcard and */* in that order
+func mediaTypeFor(content map[string]*MediaType, contentType string) (*MediaType, bool) {
+	if len(content) == 0 {
+		return nil, true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	if media, ok := content[mediaType]; ok {
+		return media, true
+	}
+	major, _, _ := strings.Cut(mediaType, "/")
+	if media, ok := content[major+"/*"]; ok {
+		return media, true
+	}
+	media, ok := content["*/*"]
+	return media, ok
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// coerce converts raw parameter strings to the JSON type of the schema
+func (v *Validator) coerce(parameter *Parameter, values []string) (interface{}, *apperror.FieldError) {
+	schema, err := v.doc.schema(parameter.Schema)
+	if err != nil || schema == nil {
+		return values[0], nil
+	}
+
+	if schema.Type.has("array") {
+		// form style: ?status=a&status=b or ?status=a,b
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		items, _ := v.doc.schema(schema.Items)
+		result := make([]interface{}, 0, len(values))
+		for _, raw := range values {
+			item, ok := coerceScalar(items, raw)
+			if !ok {
+				fe := typeError(parameter.Name, items.Type)
+				return nil, &fe
+			}
+			result = append(result, item)
+		}
+		return result, nil
+	}
+
+	value, ok := coerceScalar(schema, values[0])
+	if !ok {
+		fe := typeError(parameter.Name, schema.Type)
+		return nil, &fe
+	}
+	return value, nil
+}
+
+func coerceScalar(schema *Schema, raw string) (interface{}, bool) {
+	if schema == nil {
+		return raw, true
+	}
+	switch {
+	case schema.Type.has("integer"), schema.Type.has("number"):
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, false
+		}
+		return number, true
+	case schema.Type.has("boolean"):
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, false
+		}
+		return b, true
+	}
+	return raw, true
+}
+
+// bufferedWriter holds the response back until it has been validated
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	body    bytes.Buffer
+	written bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// flush writes the buffered response to the client
+func (w *bufferedWriter) flush() {
+	if !w.written && w.status == 0 {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.Status())
+	w.ResponseWriter.WriteHeaderNow()
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+	}
+}
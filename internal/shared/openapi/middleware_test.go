@@ -0,0 +1,356 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+)
+
+const testSpec = `
+openapi: 3.0.3
+servers:
+  - url: http://localhost:8080/api/v1
+paths:
+  /tenants:
+    get:
+      parameters:
+        - name: page
+          in: query
+          schema:
+            type: integer
+            minimum: 1
+        - name: status
+          in: query
+          schema:
+            type: string
+            enum: [active, suspended]
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [success, data]
+                properties:
+                  success:
+                    type: boolean
+                  data:
+                    type: array
+                    items:
+                      $ref: '#/components/schemas/Tenant'
+        default:
+          $ref: '#/components/responses/Error'
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/TenantCreate'
+      responses:
+        '201':
+          description: created
+  /tenants/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+          format: uuid
+    get:
+      responses:
+        '200':
+          description: ok
+  /tenants/current:
+    get:
+      responses:
+        '200':
+          description: ok
+components:
+  schemas:
+    Tenant:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: string
+          format: uuid
+        name:
+          type: string
+    TenantCreate:
+      type: object
+      required: [name, admin]
+      additionalProperties: false
+      properties:
+        name:
+          type: string
+          minLength: 2
+        admin:
+          type: object
+          required: [email]
+          properties:
+            email:
+              type: string
+              format: email
+  responses:
+    Error:
+      description: error
+      content:
+        application/json:
+          schema:
+            type: object
+            required: [error, code]
+            properties:
+              error:
+                type: string
+              code:
+                type: string
+`
+
+func newTestValidator(t *testing.T, options Options) *Validator {
+	doc, err := Parse([]byte(testSpec))
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	return NewValidator(doc, options, logger)
+}
+
+func newTestRouter(v *Validator, register func(r *gin.RouterGroup)) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(v.Middleware())
+	register(router.Group("/api/v1"))
+	return router
+}
+
+func perform(router *gin.Engine, method, path, body string) (*httptest.ResponseRecorder, apperror.Response) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response apperror.Response
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func fieldRules(response apperror.Response) map[string]string {
+	rules := make(map[string]string)
+	for _, field := range response.Fields {
+		rules[field.Field] = field.Rule
+	}
+	return rules
+}
+
+func TestParse_RejectsUnresolvedReference(t *testing.T) {
+	_, err := Parse([]byte(`
+openapi: 3.0.3
+paths:
+  /things:
+    get:
+      responses:
+        '200':
+          $ref: '#/components/responses/Missing'
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "#/components/responses/Missing")
+}
+
+func TestPublishedSpec(t *testing.T) {
+	v, err := NewValidatorFromFile("../../../deployments/docker-compose/nginx/docs/openapi.yaml", Options{}, logrus.New())
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"not-an-email"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	appErr := v.ValidateRequest(req)
+	require.NotNil(t, appErr)
+	assert.Equal(t, apperror.CodeValidationFailed, appErr.Code)
+	require.Len(t, appErr.Fields, 2)
+	assert.Equal(t, "password", appErr.Fields[0].Field)
+	assert.Equal(t, "required", appErr.Fields[0].Rule)
+	assert.Equal(t, "email", appErr.Fields[1].Field)
+	assert.Equal(t, "email", appErr.Fields[1].Rule)
+}
+
+func TestMiddleware_QueryParameters(t *testing.T) {
+	v := newTestValidator(t, Options{})
+	router := newTestRouter(v, func(r *gin.RouterGroup) {
+		r.GET("/tenants", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"success": true, "data": []interface{}{}})
+		})
+	})
+
+	w, _ := perform(router, http.MethodGet, "/api/v1/tenants?page=2&status=active", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, response := perform(router, http.MethodGet, "/api/v1/tenants?page=0&status=deleted", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, apperror.CodeValidationFailed, response.Code)
+	assert.Equal(t, map[string]string{"page": "minimum", "status": "one_of"}, fieldRules(response))
+
+	w, response = perform(router, http.MethodGet, "/api/v1/tenants?page=first", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{"page": "type"}, fieldRules(response))
+}
+
+func TestMiddleware_PathParameters(t *testing.T) {
+	v := newTestValidator(t, Options{})
+	router := newTestRouter(v, func(r *gin.RouterGroup) {
+		r.GET("/tenants/:id", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+	})
+
+	w, _ := perform(router, http.MethodGet, "/api/v1/tenants/6f1c9a52-6a0e-4d8b-9a53-2f1d6f3c8e10", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Literal paths take precedence over templated ones
+	w, _ = perform(router, http.MethodGet, "/api/v1/tenants/current", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, response := perform(router, http.MethodGet, "/api/v1/tenants/42", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{"id": "uuid"}, fieldRules(response))
+}
+
+func TestMiddleware_RequestBody(t *testing.T) {
+	v := newTestValidator(t, Options{})
+	var received string
+	router := newTestRouter(v, func(r *gin.RouterGroup) {
+		r.POST("/tenants", func(c *gin.Context) {
+			var body map[string]interface{}
+			_ = c.ShouldBindJSON(&body)
+			received, _ = body["name"].(string)
+			c.Status(http.StatusCreated)
+		})
+	})
+
+	t.Run("valid body reaches the handler", func(t *testing.T) {
+		w, _ := perform(router, http.MethodPost, "/api/v1/tenants", `{"name":"PT Maju","admin":{"email":"admin@maju.co.id"}}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "PT Maju", received)
+	})
+
+	t.Run("nested and unknown fields", func(t *testing.T) {
+		w, response := perform(router, http.MethodPost, "/api/v1/tenants", `{"name":"P","admin":{"email":"nope"},"plan":"gold"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, map[string]string{
+			"name":        "min_length",
+			"admin.email": "email",
+			"plan":        "unknown_field",
+		}, fieldRules(response))
+	})
+
+	t.Run("missing body", func(t *testing.T) {
+		w, response := perform(router, http.MethodPost, "/api/v1/tenants", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, map[string]string{"body": "required"}, fieldRules(response))
+	})
+
+	t.Run("malformed JSON", func(t *testing.T) {
+		w, response := perform(router, http.MethodPost, "/api/v1/tenants", `{"name":`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, apperror.CodeBadRequest, response.Code)
+	})
+
+	t.Run("wrong content type", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tenants", strings.NewReader("name=PT+Maju"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+}
+
+func TestMiddleware_UndocumentedRoutesPassThrough(t *testing.T) {
+	v := newTestValidator(t, Options{ValidateResponses: true})
+	router := newTestRouter(v, func(r *gin.RouterGroup) {
+		r.POST("/internal/jobs", func(c *gin.Context) {
+			c.JSON(http.StatusAccepted, gin.H{"anything": "goes"})
+		})
+	})
+
+	w, _ := perform(router, http.MethodPost, "/api/v1/internal/jobs", `{"x":1}`)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestMiddleware_ResponseValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     interface{}
+		expected int
+	}{
+		{
+			name:     "documented response",
+			status:   http.StatusOK,
+			body:     gin.H{"success": true, "data": []gin.H{{"id": "6f1c9a52-6a0e-4d8b-9a53-2f1d6f3c8e10", "name": "PT Maju"}}},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "drifted item schema",
+			status:   http.StatusOK,
+			body:     gin.H{"success": true, "data": []gin.H{{"id": 42}}},
+			expected: http.StatusInternalServerError,
+		},
+		{
+			name:     "error envelope matches default response",
+			status:   http.StatusForbidden,
+			body:     gin.H{"error": "Forbidden", "code": "FORBIDDEN"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "error envelope in the wrong shape",
+			status:   http.StatusForbidden,
+			body:     gin.H{"error": gin.H{"code": "FORBIDDEN"}},
+			expected: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestValidator(t, Options{ValidateResponses: true})
+			router := newTestRouter(v, func(r *gin.RouterGroup) {
+				r.GET("/tenants", func(c *gin.Context) {
+					c.JSON(tt.status, tt.body)
+				})
+			})
+
+			w, response := perform(router, http.MethodGet, "/api/v1/tenants", "")
+			assert.Equal(t, tt.expected, w.Code)
+			if tt.expected == http.StatusInternalServerError {
+				assert.Equal(t, apperror.CodeContractViolation, response.Code)
+				assert.NotEmpty(t, response.Details["violations"])
+			}
+		})
+	}
+}
+
+func TestMiddleware_UndocumentedStatus(t *testing.T) {
+	v := newTestValidator(t, Options{ValidateResponses: true})
+	router := newTestRouter(v, func(r *gin.RouterGroup) {
+		r.GET("/tenants/current", func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+	})
+
+	w, response := perform(router, http.MethodGet, "/api/v1/tenants/current", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, apperror.CodeContractViolation, response.Code)
+	assert.Equal(t, []interface{}{"status 204 is not documented"}, response.Details["violations"])
+}
@@ -0,0 +1,111 @@
+package openapi
+
+import (
+	"sort"
+	"strings"
+)
+
+// route is a compiled path template of the spec
+type route struct {
+	template string
+	segments []string
+	item     *PathItem
+}
+
+// router finds the spec path matching a request path. Literal segments
+// win over templated ones, so /tenants/current matches before /tenants/{id}.
+type router struct {
+	basePaths []string
+	routes    []route
+}
+
+func newRouter(doc *Document) *router {
+	r := &router{basePaths: doc.BasePaths()}
+	for template, item := range doc.Paths {
+		if item == nil {
+			continue
+		}
+		r.routes = append(r.routes, route{
+			template: template,
+			segments: splitPath(template),
+			item:     item,
+		})
+	}
+
+	sort.Slice(r.routes, func(i, j int) bool {
+		a, b := r.routes[i], r.routes[j]
+		if params(a.segments) != params(b.segments) {
+			return params(a.segments) < params(b.segments)
+		}
+		return a.template < b.template
+	})
+	return r
+}
+
+// match returns the path item and path parameters of a request path.
+// The spec paths are relative to the server URLs, so the server base paths
+// are tried first, then the path as is.
+func (r *router) match(path string) (*route, map[string]string, bool) {
+	candidates := make([]string, 0, len(r.basePaths)+1)
+	for _, base := range r.basePaths {
+		if path == base || strings.HasPrefix(path, base+"/") {
+			candidates = append(candidates, strings.TrimPrefix(path, base))
+		}
+	}
+	candidates = append(candidates, path)
+
+	for _, candidate := range candidates {
+		segments := splitPath(candidate)
+		for i := range r.routes {
+			if values, ok := matchSegments(r.routes[i].segments, segments); ok {
+				return &r.routes[i], values, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+func matchSegments(template, path []string) (map[string]string, bool) {
+	if len(template) != len(path) {
+		return nil, false
+	}
+	values := make(map[string]string)
+	for i, segment := range template {
+		if name, ok := paramName(segment); ok {
+			if path[i] == "" {
+				return nil, false
+			}
+			values[name] = path[i]
+			continue
+		}
+		if segment != path[i] {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func paramName(segment string) (string, bool) {
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+func params(segments []string) int {
+	count := 0
+	for _, segment := range segments {
+		if _, ok := paramName(segment); ok {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,312 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+)
+
+// Schema is the subset of JSON Schema used by OpenAPI that the validator
+// enforces. Annotations such as example and description are ignored.
+type Schema struct {
+	Ref                  string               `yaml:"$ref"`
+	Type                 schemaType           `yaml:"type"`
+	Format               string               `yaml:"format"`
+	Nullable             bool                 `yaml:"nullable"`
+	Enum                 []interface{}        `yaml:"enum"`
+	Properties           map[string]*Schema   `yaml:"properties"`
+	Required             []string             `yaml:"required"`
+	AdditionalProperties additionalProperties `yaml:"additionalProperties"`
+	Items                *Schema              `yaml:"items"`
+	MinItems             *int                 `yaml:"minItems"`
+	MaxItems             *int                 `yaml:"maxItems"`
+	MinLength            *int                 `yaml:"minLength"`
+	MaxLength            *int                 `yaml:"maxLength"`
+	Minimum              *float64             `yaml:"minimum"`
+	Maximum              *float64             `yaml:"maximum"`
+	Pattern              string               `yaml:"pattern"`
+	AllOf                []*Schema            `yaml:"allOf"`
+	AnyOf                []*Schema            `yaml:"anyOf"`
+	OneOf                []*Schema            `yaml:"oneOf"`
+}
+
+// schemaType accepts both the 3.0 form (type: string) and the 3.1 form
+// (type: [string, "null"])
+type schemaType []string
+
+func (t *schemaType) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = schemaType{node.Value}
+		return nil
+	}
+	var types []string
+	if err := node.Decode(&types); err != nil {
+		return err
+	}
+	*t = types
+	return nil
+}
+
+func (t schemaType) has(name string) bool {
+	for _, typ := range t {
+		if typ == name {
+			return true
+		}
+	}
+	return false
+}
+
+// additionalProperties is either a boolean or a schema
+type additionalProperties struct {
+	Forbidden bool
+	Schema    *Schema
+}
+
+func (a *additionalProperties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var allowed bool
+		if err := node.Decode(&allowed); err != nil {
+			return err
+		}
+		a.Forbidden = !allowed
+		return nil
+	}
+	a.Schema = &Schema{}
+	return node.Decode(a.Schema)
+}
+
+var emailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// patterns caches compiled schema patterns
+var patterns sync.Map
+
+// validateValue checks a decoded JSON value against a schema and records a
+// field error for every violation. field is the JSON path of the value,
+// e.g. admin.email or items[2].sku.
+func (d *Document) validateValue(s *Schema, value interface{}, field string, errs *[]apperror.FieldError) {
+	s, err := d.schema(s)
+	if err != nil || s == nil {
+		return
+	}
+
+	for _, sub := range s.AllOf {
+		d.validateValue(sub, value, field, errs)
+	}
+	if len(s.AnyOf) > 0 && d.matching(s.AnyOf, value, field) == 0 {
+		*errs = append(*errs, apperror.Field(field, "invalid", "does not match any allowed schema"))
+	}
+	if len(s.OneOf) > 0 && d.matching(s.OneOf, value, field) != 1 {
+		*errs = append(*errs, apperror.Field(field, "invalid", "must match exactly one allowed schema"))
+	}
+
+	if value == nil {
+		if s.Nullable || s.Type.has("null") || len(s.Type) == 0 {
+			return
+		}
+		*errs = append(*errs, typeError(field, s.Type))
+		return
+	}
+
+	if len(s.Type) > 0 && !matchesType(s.Type, value) {
+		*errs = append(*errs, typeError(field, s.Type))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		allowed := make([]string, 0, len(s.Enum))
+		for _, option := range s.Enum {
+			allowed = append(allowed, fmt.Sprint(option))
+		}
+		*errs = append(*errs, fieldError(field, "one_of", map[string]interface{}{"allowed": strings.Join(allowed, ", ")}))
+	}
+
+	switch v := value.(type) {
+	case string:
+		d.validateString(s, v, field, errs)
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			*errs = append(*errs, fieldError(field, "minimum", map[string]interface{}{"min": *s.Minimum}))
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			*errs = append(*errs, fieldError(field, "maximum", map[string]interface{}{"max": *s.Maximum}))
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			*errs = append(*errs, fieldError(field, "min_items", map[string]interface{}{"min": *s.MinItems}))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			*errs = append(*errs, fieldError(field, "max_items", map[string]interface{}{"max": *s.MaxItems}))
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				d.validateValue(s.Items, item, fmt.Sprintf("%s[%d]", field, i), errs)
+			}
+		}
+	case map[string]interface{}:
+		d.validateObject(s, v, field, errs)
+	}
+}
+
+func (d *Document) validateString(s *Schema, v, field string, errs *[]apperror.FieldError) {
+	length := len([]rune(v))
+	if s.MinLength != nil && length < *s.MinLength {
+		*errs = append(*errs, fieldError(field, "min_length", map[string]interface{}{"min": *s.MinLength}))
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		*errs = append(*errs, fieldError(field, "max_length", map[string]interface{}{"max": *s.MaxLength}))
+	}
+	if s.Pattern != "" {
+		if re, err := compilePattern(s.Pattern); err == nil && !re.MatchString(v) {
+			*errs = append(*errs, fieldError(field, "pattern", nil))
+		}
+	}
+
+	switch s.Format {
+	case "email":
+		if !emailPattern.MatchString(v) {
+			*errs = append(*errs, fieldError(field, "email", nil))
+		}
+	case "uuid":
+		if _, err := uuid.Parse(v); err != nil {
+			*errs = append(*errs, fieldError(field, "uuid", nil))
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			*errs = append(*errs, fieldError(field, "date_time", nil))
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			*errs = append(*errs, fieldError(field, "date", nil))
+		}
+	}
+}
+
+func (d *Document) validateObject(s *Schema, v map[string]interface{}, field string, errs *[]apperror.FieldError) {
+	for _, name := range s.Required {
+		if _, ok := v[name]; !ok {
+			*errs = append(*errs, fieldError(joinField(field, name), "required", nil))
+		}
+	}
+
+	// Sorted for a stable error order
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if property, ok := s.Properties[name]; ok {
+			d.validateValue(property, v[name], joinField(field, name), errs)
+			continue
+		}
+		switch {
+		case s.AdditionalProperties.Schema != nil:
+			d.validateValue(s.AdditionalProperties.Schema, v[name], joinField(field, name), errs)
+		case s.AdditionalProperties.Forbidden:
+			*errs = append(*errs, fieldError(joinField(field, name), "unknown_field", nil))
+		}
+	}
+}
+
+// matching counts the schemas a value satisfies
+func (d *Document) matching(schemas []*Schema, value interface{}, field string) int {
+	count := 0
+	for _, s := range schemas {
+		var errs []apperror.FieldError
+		d.validateValue(s, value, field, &errs)
+		if len(errs) == 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func matchesType(types schemaType, value interface{}) bool {
+	for _, typ := range types {
+		switch v := value.(type) {
+		case string:
+			if typ == "string" {
+				return true
+			}
+		case bool:
+			if typ == "boolean" {
+				return true
+			}
+		case float64:
+			if typ == "number" || (typ == "integer" && v == math.Trunc(v)) {
+				return true
+			}
+		case []interface{}:
+			if typ == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if typ == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, option := range enum {
+		if normalize(option) == normalize(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize converts YAML integers to float64 so enum values compare
+// equal to decoded JSON numbers
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	}
+	return value
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := patterns.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+func typeError(field string, types schemaType) apperror.FieldError {
+	return fieldError(field, "type", map[string]interface{}{"type": strings.Join(types, " or ")})
+}
+
+func fieldError(field, rule string, params map[string]interface{}) apperror.FieldError {
+	fe := apperror.Field(field, rule, rule)
+	fe.Params = params
+	return fe
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
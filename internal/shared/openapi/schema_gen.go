@@ -0,0 +1,342 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// schemaOut is a generated JSON Schema (OpenAPI 3.1 dialect)
+type schemaOut struct {
+	Ref                  string                `yaml:"$ref,omitempty"`
+	Type                 interface{}           `yaml:"type,omitempty"`
+	Format               string                `yaml:"format,omitempty"`
+	Description          string                `yaml:"description,omitempty"`
+	Enum                 []string              `yaml:"enum,omitempty"`
+	Pattern              string                `yaml:"pattern,omitempty"`
+	MinLength            *int                  `yaml:"minLength,omitempty"`
+	MaxLength            *int                  `yaml:"maxLength,omitempty"`
+	Minimum              *float64              `yaml:"minimum,omitempty"`
+	Maximum              *float64              `yaml:"maximum,omitempty"`
+	MinItems             *int                  `yaml:"minItems,omitempty"`
+	MaxItems             *int                  `yaml:"maxItems,omitempty"`
+	Items                *schemaOut            `yaml:"items,omitempty"`
+	Properties           map[string]*schemaOut `yaml:"properties,omitempty"`
+	Required             []string              `yaml:"required,omitempty"`
+	AdditionalProperties interface{}           `yaml:"additionalProperties,omitempty"`
+	AllOf                []*schemaOut          `yaml:"allOf,omitempty"`
+	AnyOf                []*schemaOut          `yaml:"anyOf,omitempty"`
+	Example              interface{}           `yaml:"example,omitempty"`
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+	// e164Pattern matches the e164 binding rule
+	e164Pattern = `^\+[1-9]\d{1,14}$`
+)
+
+// schemaBuilder turns Go types into component schemas following their json
+// and binding tags
+type schemaBuilder struct {
+	docs       map[string]string
+	components map[string]*schemaOut
+	// names maps named struct types to their component name
+	names map[reflect.Type]string
+	// types maps qualified names such as region.Province to their type
+	types map[string]reflect.Type
+}
+
+func newSchemaBuilder(docs map[string]string) *schemaBuilder {
+	return &schemaBuilder{
+		docs:       docs,
+		components: make(map[string]*schemaOut),
+		names:      make(map[reflect.Type]string),
+		types:      make(map[string]reflect.Type),
+	}
+}
+
+// qualifiedName returns the package name and type name, e.g. handler.UserDTO
+func qualifiedName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg == "" {
+		return t.Name()
+	}
+	return pkg + "." + t.Name()
+}
+
+// byName finds a type that was already reached through another schema
+func (b *schemaBuilder) byName(qualified string) (reflect.Type, bool) {
+	t, ok := b.types[qualified]
+	return t, ok
+}
+
+// schemaFor returns the schema of a type; named structs become components
+// referenced with $ref
+func (b *schemaBuilder) schemaFor(t reflect.Type) *schemaOut {
+	switch {
+	case t == timeType:
+		return &schemaOut{Type: "string", Format: "date-time"}
+	case t == uuidType:
+		return &schemaOut{Type: "string", Format: "uuid"}
+	case t == rawType:
+		// raw JSON accepts any value
+		return &schemaOut{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem := b.schemaFor(t.Elem())
+		if elem.Ref != "" {
+			return &schemaOut{AnyOf: []*schemaOut{elem, {Type: "null"}}}
+		}
+		if typ, ok := elem.Type.(string); ok {
+			elem.Type = []string{typ, "null"}
+		}
+		return elem
+	case reflect.String:
+		return &schemaOut{Type: "string"}
+	case reflect.Bool:
+		return &schemaOut{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schemaOut{Type: "integer"}
+	case reflect.Int64:
+		if t == reflect.TypeOf(time.Duration(0)) {
+			return &schemaOut{Type: "integer", Description: "Duration in nanoseconds"}
+		}
+		return &schemaOut{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &schemaOut{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &schemaOut{Type: "string", Format: "byte"}
+		}
+		return &schemaOut{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &schemaOut{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return &schemaOut{Ref: "#/components/schemas/" + b.component(t)}
+	}
+	// interface{} and anything else accepts any value
+	return &schemaOut{}
+}
+
+// preferName sets the component name of a type that has not been named yet
+func (b *schemaBuilder) preferName(t reflect.Type, name string) {
+	if _, named := b.names[t]; named {
+		return
+	}
+	if _, taken := b.components[name]; taken {
+		return
+	}
+	b.names[t] = name
+}
+
+// component registers a named struct as a component schema and returns its
+// name. The bare type name is used unless another package already took it.
+func (b *schemaBuilder) component(t reflect.Type) string {
+	qualified := qualifiedName(t)
+	name, named := b.names[t]
+	if named {
+		if _, built := b.components[name]; built {
+			return name
+		}
+	} else {
+		name = t.Name()
+		if _, taken := b.components[name]; taken {
+			name = strings.ReplaceAll(qualified, ".", "_")
+		}
+		b.names[t] = name
+	}
+	b.types[qualified] = t
+
+	// Reserve the name first so recursive types terminate
+	b.components[name] = &schemaOut{}
+	schema := b.structSchema(t)
+	schema.Description = b.docs[qualified]
+	b.components[name] = schema
+	return name
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *schemaOut {
+	schema := &schemaOut{Type: "object", Properties: make(map[string]*schemaOut)}
+	b.addFields(schema, t)
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func (b *schemaBuilder) addFields(schema *schemaOut, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := b.schemaFor(field.Type)
+		binding := field.Tag.Get("binding")
+		b.applyBinding(property, field.Type, binding)
+		if example, ok := field.Tag.Lookup("example"); ok {
+			property.Example = typedExample(field.Type, example)
+		}
+		schema.Properties[name] = property
+
+		if isRequired(binding, options) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// isRequired follows the binding tag when there is one and treats fields
+// without omitempty as always present otherwise
+func isRequired(binding, jsonOptions string) bool {
+	if binding != "" {
+		for _, rule := range strings.Split(binding, ",") {
+			if rule == "required" {
+				return true
+			}
+		}
+		return false
+	}
+	return !strings.Contains(jsonOptions, "omitempty")
+}
+
+// applyBinding translates validator rules into schema keywords
+func (b *schemaBuilder) applyBinding(s *schemaOut, t reflect.Type, binding string) {
+	if binding == "" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// References cannot carry keywords of their own
+	if s.Ref != "" || len(s.AnyOf) > 0 {
+		return
+	}
+
+	for _, rule := range strings.Split(binding, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "email":
+			s.Format = "email"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "url", "uri":
+			s.Format = "uri"
+		case "fqdn", "hostname":
+			s.Format = "hostname"
+		case "e164":
+			s.Pattern = e164Pattern
+		case "numeric":
+			if t.Kind() == reflect.String {
+				s.Pattern = "^[0-9]+$"
+			}
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "len":
+			if n, err := strconv.Atoi(param); err == nil {
+				setBound(s, t, &n, &n)
+			}
+		case "min", "gte":
+			if n, err := strconv.Atoi(param); err == nil {
+				setBound(s, t, &n, nil)
+			}
+		case "max", "lte":
+			if n, err := strconv.Atoi(param); err == nil {
+				setBound(s, t, nil, &n)
+			}
+		}
+	}
+}
+
+// setBound applies a min/max rule as a length, value or item count
+// depending on the field kind, as the validator does
+func setBound(s *schemaOut, t reflect.Type, min, max *int) {
+	switch t.Kind() {
+	case reflect.String:
+		if min != nil {
+			s.MinLength = min
+		}
+		if max != nil {
+			s.MaxLength = max
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if min != nil {
+			s.MinItems = min
+		}
+		if max != nil {
+			s.MaxItems = max
+		}
+	default:
+		if min != nil {
+			v := float64(*min)
+			s.Minimum = &v
+		}
+		if max != nil {
+			v := float64(*max)
+			s.Maximum = &v
+		}
+	}
+}
+
+// typedExample converts an example tag to the field's JSON type
+func typedExample(t reflect.Type, example string) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if v, err := strconv.ParseBool(example); err == nil {
+			return v
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v, err := strconv.ParseInt(example, 10, 64); err == nil {
+			return v
+		}
+	case reflect.Float32, reflect.Float64:
+		if v, err := strconv.ParseFloat(example, 64); err == nil {
+			return v
+		}
+	case reflect.Slice, reflect.Map, reflect.Interface:
+		var v interface{}
+		if err := json.Unmarshal([]byte(example), &v); err == nil {
+			return v
+		}
+	}
+	return example
+}
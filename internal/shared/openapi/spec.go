@@ -0,0 +1,304 @@
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is the subset of an OpenAPI 3.0/3.1 document used to validate
+// requests and responses
+type Document struct {
+	OpenAPI    string               `yaml:"openapi"`
+	Servers    []Server             `yaml:"servers"`
+	Paths      map[string]*PathItem `yaml:"paths"`
+	Components Components           `yaml:"components"`
+}
+
+// Server is an entry of the servers list
+type Server struct {
+	URL string `yaml:"url"`
+}
+
+// PathItem holds the operations of a path template
+type PathItem struct {
+	Ref        string       `yaml:"$ref"`
+	Parameters []*Parameter `yaml:"parameters"`
+	Get        *Operation   `yaml:"get"`
+	Put        *Operation   `yaml:"put"`
+	Post       *Operation   `yaml:"post"`
+	Delete     *Operation   `yaml:"delete"`
+	Options    *Operation   `yaml:"options"`
+	Head       *Operation   `yaml:"head"`
+	Patch      *Operation   `yaml:"patch"`
+}
+
+// Operation is a single API operation
+type Operation struct {
+	OperationID string               `yaml:"operationId"`
+	Parameters  []*Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody         `yaml:"requestBody"`
+	Responses   map[string]*Response `yaml:"responses"`
+}
+
+// Parameter is a path, query or header parameter
+type Parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *Schema `yaml:"schema"`
+}
+
+// RequestBody describes the accepted request payloads
+type RequestBody struct {
+	Ref      string                `yaml:"$ref"`
+	Required bool                  `yaml:"required"`
+	Content  map[string]*MediaType `yaml:"content"`
+}
+
+// Response describes a response of an operation
+type Response struct {
+	Ref     string                `yaml:"$ref"`
+	Content map[string]*MediaType `yaml:"content"`
+}
+
+// MediaType holds the schema of a content type
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+// Components holds the reusable objects referenced with $ref
+type Components struct {
+	Schemas       map[string]*Schema      `yaml:"schemas"`
+	Parameters    map[string]*Parameter   `yaml:"parameters"`
+	RequestBodies map[string]*RequestBody `yaml:"requestBodies"`
+	Responses     map[string]*Response    `yaml:"responses"`
+}
+
+// LoadFile reads and parses an OpenAPI document in YAML or JSON
+func LoadFile(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI spec: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses an OpenAPI document and checks that all its references resolve
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", doc.OpenAPI)
+	}
+	if err := doc.checkRefs(); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// BasePaths returns the path prefixes of the declared servers, e.g.
+// /api/v1 for http://localhost:8080/api/v1
+func (d *Document) BasePaths() []string {
+	var paths []string
+	for _, server := range d.Servers {
+		if base := serverBasePath(server.URL); base != "" {
+			paths = append(paths, base)
+		}
+	}
+	return paths
+}
+
+// serverBasePath returns the path of a server URL without a trailing slash
+func serverBasePath(serverURL string) string {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// Operations returns the operations of a path item by HTTP method
+func (p *PathItem) Operations() map[string]*Operation {
+	operations := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{
+		"GET": p.Get, "PUT": p.Put, "POST": p.Post, "DELETE": p.Delete,
+		"OPTIONS": p.Options, "HEAD": p.Head, "PATCH": p.Patch,
+	} {
+		if op != nil {
+			operations[method] = op
+		}
+	}
+	return operations
+}
+
+// schema resolves a schema reference
+func (d *Document) schema(s *Schema) (*Schema, error) {
+	for depth := 0; s != nil && s.Ref != ""; depth++ {
+		if depth > 32 {
+			return nil, fmt.Errorf("reference loop at %s", s.Ref)
+		}
+		name, err := componentName(s.Ref, "schemas")
+		if err != nil {
+			return nil, err
+		}
+		target, ok := d.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", s.Ref)
+		}
+		s = target
+	}
+	return s, nil
+}
+
+// parameter resolves a parameter reference
+func (d *Document) parameter(p *Parameter) (*Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := componentName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	target, ok := d.Components.Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %s", p.Ref)
+	}
+	return target, nil
+}
+
+// requestBody resolves a request body reference
+func (d *Document) requestBody(b *RequestBody) (*RequestBody, error) {
+	if b == nil || b.Ref == "" {
+		return b, nil
+	}
+	name, err := componentName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	target, ok := d.Components.RequestBodies[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %s", b.Ref)
+	}
+	return target, nil
+}
+
+// response resolves a response reference
+func (d *Document) response(r *Response) (*Response, error) {
+	if r == nil || r.Ref == "" {
+		return r, nil
+	}
+	name, err := componentName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	target, ok := d.Components.Responses[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %s", r.Ref)
+	}
+	return target, nil
+}
+
+// componentName extracts the component name of a local reference such as
+// #/components/schemas/User
+func componentName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %s", ref)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+// checkRefs resolves every reference once so that a broken spec fails at
+// startup instead of on the first request
+func (d *Document) checkRefs() error {
+	seen := make(map[*Schema]bool)
+	var walk func(s *Schema) error
+	walk = func(s *Schema) error {
+		if s == nil || seen[s] {
+			return nil
+		}
+		seen[s] = true
+		resolved, err := d.schema(s)
+		if err != nil {
+			return err
+		}
+		children := []*Schema{resolved.Items, resolved.AdditionalProperties.Schema}
+		for _, property := range resolved.Properties {
+			children = append(children, property)
+		}
+		children = append(children, resolved.AllOf...)
+		children = append(children, resolved.AnyOf...)
+		children = append(children, resolved.OneOf...)
+		for _, child := range children {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	walkContent := func(content map[string]*MediaType) error {
+		for _, media := range content {
+			if media != nil {
+				if err := walk(media.Schema); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for path, item := range d.Paths {
+		if item == nil {
+			continue
+		}
+		if item.Ref != "" {
+			return fmt.Errorf("path %s: path item references are not supported", path)
+		}
+		parameters := item.Parameters
+		for method, op := range item.Operations() {
+			parameters = append(parameters, op.Parameters...)
+			body, err := d.requestBody(op.RequestBody)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			if body != nil {
+				if err := walkContent(body.Content); err != nil {
+					return fmt.Errorf("%s %s: %w", method, path, err)
+				}
+			}
+			for status, r := range op.Responses {
+				response, err := d.response(r)
+				if err != nil {
+					return fmt.Errorf("%s %s %s: %w", method, path, status, err)
+				}
+				if response != nil {
+					if err := walkContent(response.Content); err != nil {
+						return fmt.Errorf("%s %s %s: %w", method, path, status, err)
+					}
+				}
+			}
+		}
+		for _, p := range parameters {
+			parameter, err := d.parameter(p)
+			if err != nil {
+				return fmt.Errorf("path %s: %w", path, err)
+			}
+			if err := walk(parameter.Schema); err != nil {
+				return fmt.Errorf("path %s: %w", path, err)
+			}
+		}
+	}
+	for name, schema := range d.Components.Schemas {
+		if err := walk(schema); err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+	return nil
+}
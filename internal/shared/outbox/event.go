@@ -0,0 +1,134 @@
+// Package outbox delivers domain events exactly once in effect. Events are
+// written to outbox_events in the transaction of the change they describe,
+// so they exist if and only if the change committed, and a relay publishes
+// them to the message bus afterwards. A crash between publishing and
+// marking an event delivered publishes it again; consumers deduplicate by
+// event ID.
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+)
+
+// ErrNoTransaction is returned when events are enqueued outside of a
+// transaction, where they could outlive a failed change
+var ErrNoTransaction = errors.New("outbox events must be enqueued in a transaction")
+
+// Status is the delivery state of an event
+type Status string
+
+// Event statuses
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	// StatusParked events failed too often and wait for an operator
+	StatusParked Status = "parked"
+)
+
+// Event is a domain event in the outbox
+type Event struct {
+	ID            uuid.UUID         `gorm:"type:uuid;primaryKey" json:"id"`
+	TenantID      *uuid.UUID        `gorm:"type:uuid" json:"tenant_id,omitempty"`
+	Topic         string            `gorm:"size:100;not null" json:"topic"`
+	Key           string            `gorm:"column:event_key;size:255;not null;default:''" json:"key,omitempty"`
+	AggregateType string            `gorm:"size:100;not null;default:''" json:"aggregate_type,omitempty"`
+	AggregateID   string            `gorm:"size:100;not null;default:''" json:"aggregate_id,omitempty"`
+	Payload       json.RawMessage   `gorm:"type:jsonb;not null" json:"payload"`
+	Headers       map[string]string `gorm:"type:jsonb;serializer:json;not null" json:"headers,omitempty"`
+	Status        Status            `gorm:"size:20;not null;default:pending" json:"status"`
+	Attempts      int               `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time         `gorm:"not null" json:"next_attempt_at"`
+	LastError     string            `gorm:"type:text;not null;default:''" json:"last_error,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	DeliveredAt   *time.Time        `json:"delivered_at,omitempty"`
+}
+
+// TableName returns the table name for the Event model
+func (Event) TableName() string {
+	return "outbox_events"
+}
+
+// Message is an event to enqueue
+type Message struct {
+	// Topic names the event, e.g. "invoice.posted"
+	Topic string
+	// Key orders related events for consumers, e.g. the invoice ID
+	Key           string
+	AggregateType string
+	AggregateID   string
+	// TenantID defaults to the tenant of the transaction
+	TenantID uuid.UUID
+	// Payload is marshalled to JSON
+	Payload interface{}
+	Headers map[string]string
+}
+
+// Enqueue writes messages to the outbox in tx, the transaction of the change
+// they describe, e.g. within TransactionManager.WithTransaction. The
+// correlation ID of the transaction's context is passed on in the headers.
+func Enqueue(tx *gorm.DB, messages ...Message) error {
+	if _, ok := tx.Statement.ConnPool.(gorm.TxCommitter); !ok {
+		return ErrNoTransaction
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ctx := tx.Statement.Context
+	now := time.Now()
+	events := make([]*Event, 0, len(messages))
+	for _, message := range messages {
+		if message.Topic == "" {
+			return fmt.Errorf("outbox event topic is required")
+		}
+		payload, err := json.Marshal(message.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s payload: %w", message.Topic, err)
+		}
+
+		headers := make(map[string]string, len(message.Headers)+1)
+		for key, value := range message.Headers {
+			headers[key] = value
+		}
+		if correlationID := logger.GetCorrelationID(ctx); correlationID != "" {
+			if _, ok := headers["correlation_id"]; !ok {
+				headers["correlation_id"] = correlationID
+			}
+		}
+
+		event := &Event{
+			ID:            uuid.New(),
+			Topic:         message.Topic,
+			Key:           message.Key,
+			AggregateType: message.AggregateType,
+			AggregateID:   message.AggregateID,
+			Payload:       payload,
+			Headers:       headers,
+			Status:        StatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+		tenantID := message.TenantID
+		if tenantID == uuid.Nil {
+			tenantID, _ = database.TenantFromContext(ctx)
+		}
+		if tenantID != uuid.Nil {
+			event.TenantID = &tenantID
+		}
+		events = append(events, event)
+	}
+
+	if err := tx.Create(events).Error; err != nil {
+		return fmt.Errorf("failed to enqueue outbox events: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package outbox
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics tracks the delivery of outbox events
+type Metrics struct {
+	depth     *prometheus.GaugeVec
+	lag       prometheus.Gauge
+	published *prometheus.CounterVec
+	failures  *prometheus.CounterVec
+	parked    *prometheus.CounterVec
+}
+
+// NewMetrics creates the outbox metrics of a service
+func NewMetrics(serviceName string) *Metrics {
+	labels := prometheus.Labels{"service": serviceName}
+	return &Metrics{
+		depth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "outbox_events",
+				Help:        "Outbox events by status; pending events await delivery",
+				ConstLabels: labels,
+			},
+			[]string{"status"},
+		),
+		lag: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name:        "outbox_lag_seconds",
+				Help:        "Age of the oldest pending outbox event",
+				ConstLabels: labels,
+			},
+		),
+		published: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "outbox_events_published_total",
+				Help:        "Outbox events published to the message bus",
+				ConstLabels: labels,
+			},
+			[]string{"topic"},
+		),
+		failures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "outbox_publish_failures_total",
+				Help:        "Failed attempts to publish outbox events",
+				ConstLabels: labels,
+			},
+			[]string{"topic"},
+		),
+		parked: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "outbox_events_parked_total",
+				Help:        "Outbox events parked after too many failed attempts",
+				ConstLabels: labels,
+			},
+			[]string{"topic"},
+		),
+	}
+}
+
+// Register registers the metrics with registerer
+func (m *Metrics) Register(registerer prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{m.depth, m.lag, m.published, m.failures, m.parked} {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Metrics) observe(stats *Stats) {
+	if m == nil {
+		return
+	}
+	m.depth.WithLabelValues(string(StatusPending)).Set(float64(stats.Pending))
+	m.depth.WithLabelValues(string(StatusParked)).Set(float64(stats.Parked))
+	m.lag.Set(stats.Lag.Seconds())
+}
+
+func (m *Metrics) recordPublished(topic string) {
+	if m != nil {
+		m.published.WithLabelValues(topic).Inc()
+	}
+}
+
+func (m *Metrics) recordFailure(topic string, parked bool) {
+	if m == nil {
+		return
+	}
+	m.failures.WithLabelValues(topic).Inc()
+	if parked {
+		m.parked.WithLabelValues(topic).Inc()
+	}
+}
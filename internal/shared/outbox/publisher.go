@@ -0,0 +1,71 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Publisher delivers events to the message bus. Publish returns once the
+// bus accepted the event.
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// PublisherFunc adapts a function to Publisher
+type PublisherFunc func(ctx context.Context, event *Event) error
+
+// Publish calls f
+func (f PublisherFunc) Publish(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// RedisStreamPublisher appends events to a Redis stream per topic, which
+// consumer groups read with XREADGROUP
+type RedisStreamPublisher struct {
+	client redis.Cmdable
+	prefix string
+	maxLen int64
+}
+
+// NewRedisStreamPublisher creates a publisher writing to the stream prefix
+// plus topic, trimmed to about maxLen entries; 0 keeps every entry
+func NewRedisStreamPublisher(client redis.Cmdable, prefix string, maxLen int64) *RedisStreamPublisher {
+	return &RedisStreamPublisher{client: client, prefix: prefix, maxLen: maxLen}
+}
+
+// Publish appends the event to the stream of its topic
+func (p *RedisStreamPublisher) Publish(ctx context.Context, event *Event) error {
+	headers, err := json.Marshal(event.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal headers: %w", err)
+	}
+	tenantID := ""
+	if event.TenantID != nil {
+		tenantID = event.TenantID.String()
+	}
+
+	args := &redis.XAddArgs{
+		Stream: p.prefix + event.Topic,
+		MaxLen: p.maxLen,
+		Approx: p.maxLen > 0,
+		Values: map[string]interface{}{
+			"id":             event.ID.String(),
+			"topic":          event.Topic,
+			"key":            event.Key,
+			"tenant_id":      tenantID,
+			"aggregate_type": event.AggregateType,
+			"aggregate_id":   event.AggregateID,
+			"payload":        string(event.Payload),
+			"headers":        string(headers),
+			"created_at":     event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		},
+	}
+	if err := p.client.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", args.Stream, err)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+)
+
+// Stats describes the backlog of the outbox
+type Stats struct {
+	Pending int64 `json:"pending"`
+	Parked  int64 `json:"parked"`
+	// Lag is the age of the oldest pending event
+	Lag time.Duration `json:"lag"`
+}
+
+// Relay publishes the events of the outbox. Relays on several replicas
+// share the work: each claims due events with FOR UPDATE SKIP LOCKED and
+// holds them until they are published and marked.
+type Relay struct {
+	db        *gorm.DB
+	publisher Publisher
+	config    config.OutboxConfig
+	metrics   *Metrics
+	logger    *logrus.Logger
+	now       func() time.Time
+}
+
+// NewRelay creates a relay. db must bypass row-level security, since the
+// relay serves every tenant.
+func NewRelay(db *gorm.DB, publisher Publisher, cfg config.OutboxConfig, logger *logrus.Logger) *Relay {
+	return &Relay{
+		db:        db,
+		publisher: publisher,
+		config:    cfg,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// UseMetrics records deliveries and the backlog in metrics
+func (r *Relay) UseMetrics(metrics *Metrics) {
+	r.metrics = metrics
+}
+
+// Run relays due events every poll interval until ctx is done
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain relays batches until the due events run out, then updates the
+// backlog metrics
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		claimed, err := r.RelayBatch(ctx)
+		if err != nil {
+			r.logger.WithError(err).Error("Outbox relay failed")
+			break
+		}
+		if claimed < r.config.BatchSize {
+			break
+		}
+	}
+
+	stats, err := r.Stats(ctx)
+	if err != nil {
+		r.logger.WithError(err).Warn("Failed to read outbox backlog")
+		return
+	}
+	r.metrics.observe(stats)
+}
+
+// RelayBatch claims up to a batch of due events, publishes them and marks
+// each delivered, due for retry, or parked. It returns how many events it
+// claimed.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	claimed := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var events []Event
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", StatusPending, r.now()).
+			Order("next_attempt_at, created_at").
+			Limit(r.config.BatchSize).
+			Find(&events).Error; err != nil {
+			return fmt.Errorf("failed to claim outbox events: %w", err)
+		}
+		claimed = len(events)
+
+		for i := range events {
+			if err := r.deliver(ctx, tx, &events[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+// deliver publishes one claimed event and records the outcome
+func (r *Relay) deliver(ctx context.Context, tx *gorm.DB, event *Event) error {
+	now := r.now()
+	updates := map[string]interface{}{"attempts": event.Attempts + 1}
+
+	publishErr := r.publisher.Publish(ctx, event)
+	if publishErr == nil {
+		updates["status"] = StatusDelivered
+		updates["delivered_at"] = now
+		updates["last_error"] = ""
+		r.metrics.recordPublished(event.Topic)
+	} else {
+		attempts := event.Attempts + 1
+		parked := attempts >= r.config.MaxAttempts
+		updates["last_error"] = publishErr.Error()
+		if parked {
+			updates["status"] = StatusParked
+		} else {
+			updates["next_attempt_at"] = now.Add(r.retryDelay(attempts))
+		}
+		r.metrics.recordFailure(event.Topic, parked)
+
+		entry := r.logger.WithFields(logrus.Fields{
+			"event_id": event.ID,
+			"topic":    event.Topic,
+			"attempts": attempts,
+			"error":    publishErr,
+		})
+		if parked {
+			entry.Error("Outbox event parked after too many failed attempts")
+		} else {
+			entry.Warn("Failed to publish outbox event, will retry")
+		}
+	}
+
+	if err := tx.Model(&Event{}).Where("id = ?", event.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to mark outbox event %s: %w", event.ID, err)
+	}
+	return nil
+}
+
+// retryDelay doubles the retry delay with every failed attempt
+func (r *Relay) retryDelay(attempts int) time.Duration {
+	delay := r.config.RetryDelay
+	for i := 1; i < attempts && delay < r.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if r.config.MaxRetryDelay > 0 && delay > r.config.MaxRetryDelay {
+		delay = r.config.MaxRetryDelay
+	}
+	return delay
+}
+
+// Stats returns the backlog of the outbox
+func (r *Relay) Stats(ctx context.Context) (*Stats, error) {
+	db := r.db.WithContext(ctx)
+
+	var counts []struct {
+		Status Status
+		Count  int64
+	}
+	if err := db.Model(&Event{}).Select("status, COUNT(*) AS count").
+		Where("status IN ?", []Status{StatusPending, StatusParked}).
+		Group("status").Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count outbox events: %w", err)
+	}
+
+	stats := &Stats{}
+	for _, count := range counts {
+		switch count.Status {
+		case StatusPending:
+			stats.Pending = count.Count
+		case StatusParked:
+			stats.Parked = count.Count
+		}
+	}
+	if stats.Pending == 0 {
+		return stats, nil
+	}
+
+	var oldest Event
+	if err := db.Select("created_at").Where("status = ?", StatusPending).Order("created_at").Take(&oldest).Error; err != nil {
+		return nil, fmt.Errorf("failed to find oldest outbox event: %w", err)
+	}
+	if lag := r.now().Sub(oldest.CreatedAt); lag > 0 {
+		stats.Lag = lag
+	}
+	return stats, nil
+}
+
+// Requeue returns a parked event to delivery, e.g. after fixing its
+// consumer
+func (r *Relay) Requeue(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&Event{}).
+		Where("id = ? AND status = ?", id, StatusParked).
+		Updates(map[string]interface{}{
+			"status":          StatusPending,
+			"attempts":        0,
+			"next_attempt_at": r.now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to requeue outbox event %s: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("outbox event %s is not parked", id)
+	}
+	return nil
+}
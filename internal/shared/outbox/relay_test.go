@@ -0,0 +1,216 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+)
+
+func newOutboxDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, db.AutoMigrate(&Event{}))
+	return db
+}
+
+func newTestRelay(db *gorm.DB, publisher Publisher) *Relay {
+	log := logrus.New()
+	log.SetLevel(logrus.PanicLevel)
+	return NewRelay(db, publisher, config.OutboxConfig{
+		PollInterval:  time.Second,
+		BatchSize:     10,
+		MaxAttempts:   3,
+		RetryDelay:    time.Second,
+		MaxRetryDelay: 3 * time.Second,
+	}, log)
+}
+
+func enqueue(t *testing.T, db *gorm.DB, messages ...Message) {
+	ctx := logger.WithCorrelationID(context.Background(), "req-1")
+	require.NoError(t, database.NewTransactionManager(db).WithTransaction(ctx, func(tx *gorm.DB) error {
+		return Enqueue(tx, messages...)
+	}))
+}
+
+func TestEnqueue(t *testing.T) {
+	db := newOutboxDB(t)
+
+	enqueue(t, db, Message{Topic: "invoice.posted", Key: "INV-1", AggregateType: "invoice", AggregateID: "1", Payload: map[string]int{"total": 100}})
+
+	var event Event
+	require.NoError(t, db.First(&event).Error)
+	assert.Equal(t, "invoice.posted", event.Topic)
+	assert.Equal(t, "INV-1", event.Key)
+	assert.Equal(t, StatusPending, event.Status)
+	assert.JSONEq(t, `{"total":100}`, string(event.Payload))
+	assert.Equal(t, "req-1", event.Headers["correlation_id"])
+	assert.Nil(t, event.TenantID)
+
+	t.Run("Rollback", func(t *testing.T) {
+		err := database.NewTransactionManager(db).WithTransaction(context.Background(), func(tx *gorm.DB) error {
+			require.NoError(t, Enqueue(tx, Message{Topic: "invoice.voided"}))
+			return errors.New("change failed")
+		})
+		require.Error(t, err)
+
+		var count int64
+		require.NoError(t, db.Model(&Event{}).Where("topic = ?", "invoice.voided").Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		assert.ErrorIs(t, Enqueue(db, Message{Topic: "invoice.posted"}), ErrNoTransaction)
+
+		err := database.NewTransactionManager(db).WithTransaction(context.Background(), func(tx *gorm.DB) error {
+			return Enqueue(tx, Message{})
+		})
+		assert.ErrorContains(t, err, "topic is required")
+	})
+}
+
+func TestRelay_Delivers(t *testing.T) {
+	db := newOutboxDB(t)
+	enqueue(t, db, Message{Topic: "invoice.posted"}, Message{Topic: "stock.moved"})
+
+	var published []string
+	relay := newTestRelay(db, PublisherFunc(func(ctx context.Context, event *Event) error {
+		published = append(published, event.Topic)
+		return nil
+	}))
+	metrics := NewMetrics("test")
+	relay.UseMetrics(metrics)
+
+	claimed, err := relay.RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, claimed)
+	assert.ElementsMatch(t, []string{"invoice.posted", "stock.moved"}, published)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.published.WithLabelValues("invoice.posted")))
+
+	var events []Event
+	require.NoError(t, db.Find(&events).Error)
+	for _, event := range events {
+		assert.Equal(t, StatusDelivered, event.Status)
+		assert.Equal(t, 1, event.Attempts)
+		assert.NotNil(t, event.DeliveredAt)
+	}
+
+	// Delivered events are not published again
+	claimed, err = relay.RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, claimed)
+	assert.Len(t, published, 2)
+}
+
+func TestRelay_RetriesAndParks(t *testing.T) {
+	db := newOutboxDB(t)
+	enqueue(t, db, Message{Topic: "invoice.posted"})
+
+	failing := true
+	relay := newTestRelay(db, PublisherFunc(func(ctx context.Context, event *Event) error {
+		if failing {
+			return errors.New("bus unavailable")
+		}
+		return nil
+	}))
+	metrics := NewMetrics("test")
+	relay.UseMetrics(metrics)
+	now := time.Now()
+	relay.now = func() time.Time { return now }
+
+	_, err := relay.RelayBatch(context.Background())
+	require.NoError(t, err)
+
+	var event Event
+	require.NoError(t, db.First(&event).Error)
+	assert.Equal(t, StatusPending, event.Status)
+	assert.Equal(t, 1, event.Attempts)
+	assert.Equal(t, "bus unavailable", event.LastError)
+	assert.WithinDuration(t, now.Add(time.Second), event.NextAttemptAt, time.Millisecond)
+
+	// The event waits for its next attempt
+	claimed, err := relay.RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, claimed)
+
+	for i := 0; i < 2; i++ {
+		now = now.Add(time.Minute)
+		_, err = relay.RelayBatch(context.Background())
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.First(&event).Error)
+	assert.Equal(t, StatusParked, event.Status)
+	assert.Equal(t, 3, event.Attempts)
+	assert.Equal(t, float64(3), testutil.ToFloat64(metrics.failures.WithLabelValues("invoice.posted")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.parked.WithLabelValues("invoice.posted")))
+
+	// Parked events stay put until requeued
+	now = now.Add(time.Hour)
+	claimed, err = relay.RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, claimed)
+
+	failing = false
+	require.NoError(t, relay.Requeue(context.Background(), event.ID))
+	assert.Error(t, relay.Requeue(context.Background(), event.ID))
+
+	claimed, err = relay.RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	require.NoError(t, db.First(&event).Error)
+	assert.Equal(t, StatusDelivered, event.Status)
+}
+
+func TestRelay_RetryDelay(t *testing.T) {
+	relay := newTestRelay(nil, nil)
+
+	assert.Equal(t, time.Second, relay.retryDelay(1))
+	assert.Equal(t, 2*time.Second, relay.retryDelay(2))
+	assert.Equal(t, 3*time.Second, relay.retryDelay(3))
+	assert.Equal(t, 3*time.Second, relay.retryDelay(30))
+}
+
+func TestRelay_Stats(t *testing.T) {
+	db := newOutboxDB(t)
+	relay := newTestRelay(db, PublisherFunc(func(ctx context.Context, event *Event) error {
+		return nil
+	}))
+
+	stats, err := relay.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{}, stats)
+
+	enqueue(t, db, Message{Topic: "invoice.posted"}, Message{Topic: "stock.moved"})
+	require.NoError(t, db.Model(&Event{}).Where("topic = ?", "stock.moved").Update("status", StatusParked).Error)
+	now := time.Now().Add(time.Minute)
+	relay.now = func() time.Time { return now }
+
+	stats, err = relay.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Pending)
+	assert.Equal(t, int64(1), stats.Parked)
+	assert.InDelta(t, time.Minute.Seconds(), stats.Lag.Seconds(), 1)
+
+	metrics := NewMetrics("test")
+	require.NoError(t, metrics.Register(prometheus.NewRegistry()))
+	metrics.observe(stats)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.depth.WithLabelValues(string(StatusPending))))
+	assert.InDelta(t, 60, testutil.ToFloat64(metrics.lag), 1)
+}
@@ -0,0 +1,249 @@
+package region
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BundledDatasetVersion is the version of the bundled region dataset
+const BundledDatasetVersion = "kemendagri-2024"
+
+// bundledDataset holds the region dataset shipped with the binary. The file
+// follows the Kemendagri "kode,nama" layout. It currently covers Kota Adm.
+// Jakarta Pusat only; the full release can replace it without code changes
+// or be imported with cmd/region-import -file.
+//
+//go:embed data/wilayah.csv
+var bundledDataset embed.FS
+
+// Record represents a single region entry of the dataset
+type Record struct {
+	Code       string   `json:"code"`
+	Name       string   `json:"name"`
+	ParentCode string   `json:"parent_code,omitempty"`
+	Level      Level    `json:"level"`
+	CityType   CityType `json:"city_type,omitempty"`
+}
+
+// Dataset represents a parsed Kemendagri region dataset
+type Dataset struct {
+	Provinces []Record
+	Cities    []Record
+	Districts []Record
+	Villages  []Record
+
+	// Checksum is the SHA-256 of the raw dataset file
+	Checksum string
+}
+
+// LoadBundledDataset parses the region dataset embedded in the binary
+func LoadBundledDataset() (*Dataset, error) {
+	data, err := bundledDataset.ReadFile("data/wilayah.csv")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundled region dataset: %w", err)
+	}
+	return ParseCSV(data)
+}
+
+// LoadDatasetFile parses a region dataset file. The format is chosen by the
+// file extension: .csv or .json.
+func LoadDatasetFile(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read region dataset: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ParseCSV(data)
+	case ".json":
+		return ParseJSON(data)
+	}
+	return nil, fmt.Errorf("unsupported region dataset format: %s", path)
+}
+
+// ParseCSV parses a dataset in the Kemendagri "kode,nama" CSV layout. Codes
+// may be dotted (31.71.01.1001) or plain (3171011001). A header row is optional.
+func ParseCSV(data []byte) (*Dataset, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []rawEntry
+	line := 0
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse region dataset: %w", err)
+		}
+		line++
+
+		if len(row) < 2 {
+			return nil, fmt.Errorf("line %d: expected code and name columns", line)
+		}
+		if line == 1 && !isDigits(NormalizeCode(row[0])) {
+			// Header row
+			continue
+		}
+
+		entries = append(entries, rawEntry{Code: row[0], Name: row[1]})
+	}
+
+	return buildDataset(entries, checksum(data))
+}
+
+// ParseJSON parses a dataset given as a JSON array of {"code", "name"} objects
+func ParseJSON(data []byte) (*Dataset, error) {
+	var entries []rawEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse region dataset: %w", err)
+	}
+
+	return buildDataset(entries, checksum(data))
+}
+
+// rawEntry is a dataset row before normalisation
+type rawEntry struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+func buildDataset(entries []rawEntry, sum string) (*Dataset, error) {
+	dataset := &Dataset{Checksum: sum}
+	seen := make(map[string]bool, len(entries))
+
+	for _, entry := range entries {
+		code := NormalizeCode(entry.Code)
+		name := strings.TrimSpace(entry.Name)
+		if code == "" || name == "" {
+			return nil, fmt.Errorf("region code and name are required")
+		}
+		if !isDigits(code) {
+			return nil, fmt.Errorf("invalid region code: %s", entry.Code)
+		}
+		if seen[code] {
+			return nil, fmt.Errorf("duplicate region code: %s", code)
+		}
+		seen[code] = true
+
+		level, parentCode, err := levelOf(code)
+		if err != nil {
+			return nil, err
+		}
+
+		record := Record{
+			Code:       code,
+			Name:       name,
+			ParentCode: parentCode,
+			Level:      level,
+		}
+
+		switch level {
+		case LevelProvince:
+			dataset.Provinces = append(dataset.Provinces, record)
+		case LevelCity:
+			record.CityType, record.Name = splitCityName(name)
+			dataset.Cities = append(dataset.Cities, record)
+		case LevelDistrict:
+			dataset.Districts = append(dataset.Districts, record)
+		case LevelVillage:
+			dataset.Villages = append(dataset.Villages, record)
+		}
+	}
+
+	// Every region must belong to a parent in the same dataset
+	for _, records := range [][]Record{dataset.Cities, dataset.Districts, dataset.Villages} {
+		for _, record := range records {
+			if !seen[record.ParentCode] {
+				return nil, fmt.Errorf("region %s references unknown parent %s", record.Code, record.ParentCode)
+			}
+		}
+	}
+
+	for _, records := range [][]Record{dataset.Provinces, dataset.Cities, dataset.Districts, dataset.Villages} {
+		sort.Slice(records, func(i, j int) bool {
+			return records[i].Code < records[j].Code
+		})
+	}
+
+	return dataset, nil
+}
+
+// NormalizeCode converts a dotted Kemendagri code to the plain form stored in
+// the database, e.g. 31.71.01.1001 to 3171011001
+func NormalizeCode(code string) string {
+	code = strings.TrimSpace(code)
+	code = strings.ReplaceAll(code, ".", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// levelOf derives the hierarchy level and parent code from a plain code
+func levelOf(code string) (Level, string, error) {
+	switch len(code) {
+	case 2:
+		return LevelProvince, "", nil
+	case 4:
+		return LevelCity, code[:2], nil
+	case 6:
+		return LevelDistrict, code[:4], nil
+	case 10:
+		return LevelVillage, code[:6], nil
+	}
+	return "", "", fmt.Errorf("invalid region code length: %s", code)
+}
+
+// cityPrefixes maps the name prefixes used by Kemendagri to the city type.
+// Longer prefixes come first so "Kota Adm." wins over "Kota".
+var cityPrefixes = []struct {
+	prefix   string
+	cityType CityType
+}{
+	{"kota administrasi ", CityTypeKota},
+	{"kota adm. ", CityTypeKota},
+	{"kota ", CityTypeKota},
+	{"kabupaten administrasi ", CityTypeKabupaten},
+	{"kab. adm. ", CityTypeKabupaten},
+	{"kabupaten ", CityTypeKabupaten},
+	{"kab. ", CityTypeKabupaten},
+}
+
+// splitCityName derives the city type from the name prefix and strips it
+func splitCityName(name string) (CityType, string) {
+	lower := strings.ToLower(name)
+	for _, p := range cityPrefixes {
+		if strings.HasPrefix(lower, p.prefix) {
+			return p.cityType, strings.TrimSpace(name[len(p.prefix):])
+		}
+	}
+	return CityTypeKabupaten, name
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
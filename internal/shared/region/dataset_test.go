@@ -0,0 +1,98 @@
+package region
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	data := []byte(`kode,nama
+31,DKI Jakarta
+31.71,Kota Adm. Jakarta Pusat
+32.01,Kab. Bogor
+32,Jawa Barat
+31.71.01,Gambir
+31.71.01.1001,Gambir
+`)
+
+	dataset, err := ParseCSV(data)
+	require.NoError(t, err)
+
+	require.Len(t, dataset.Provinces, 2)
+	assert.Equal(t, "31", dataset.Provinces[0].Code)
+	assert.Equal(t, "32", dataset.Provinces[1].Code)
+
+	require.Len(t, dataset.Cities, 2)
+	assert.Equal(t, "3171", dataset.Cities[0].Code)
+	assert.Equal(t, "Jakarta Pusat", dataset.Cities[0].Name)
+	assert.Equal(t, CityTypeKota, dataset.Cities[0].CityType)
+	assert.Equal(t, "31", dataset.Cities[0].ParentCode)
+	assert.Equal(t, "Bogor", dataset.Cities[1].Name)
+	assert.Equal(t, CityTypeKabupaten, dataset.Cities[1].CityType)
+
+	require.Len(t, dataset.Districts, 1)
+	assert.Equal(t, "317101", dataset.Districts[0].Code)
+
+	require.Len(t, dataset.Villages, 1)
+	assert.Equal(t, "3171011001", dataset.Villages[0].Code)
+	assert.Equal(t, "317101", dataset.Villages[0].ParentCode)
+
+	assert.Len(t, dataset.Checksum, 64)
+
+	// The checksum identifies the exact file contents
+	again, err := ParseCSV(data)
+	require.NoError(t, err)
+	assert.Equal(t, dataset.Checksum, again.Checksum)
+}
+
+func TestParseCSV_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "Duplicate code", data: "31,DKI Jakarta\n31,DKI Jakarta\n"},
+		{name: "Unknown parent", data: "31,DKI Jakarta\n32.01,Kab. Bogor\n"},
+		{name: "Invalid code length", data: "31,DKI Jakarta\n317,Unknown\n"},
+		{name: "Non numeric code", data: "31,DKI Jakarta\n31.AB,Unknown\n"},
+		{name: "Missing name", data: "31,\n"},
+		{name: "Missing column", data: "31\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCSV([]byte(tt.data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	dataset, err := ParseJSON([]byte(`[
+		{"code": "31", "name": "DKI Jakarta"},
+		{"code": "3171", "name": "Kota Adm. Jakarta Pusat"}
+	]`))
+	require.NoError(t, err)
+	assert.Len(t, dataset.Provinces, 1)
+	assert.Len(t, dataset.Cities, 1)
+
+	_, err = ParseJSON([]byte(`{"code": "31"}`))
+	assert.Error(t, err)
+}
+
+func TestLoadBundledDataset(t *testing.T) {
+	dataset, err := LoadBundledDataset()
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, dataset.Provinces)
+	assert.NotEmpty(t, dataset.Cities)
+	assert.NotEmpty(t, dataset.Districts)
+	assert.NotEmpty(t, dataset.Villages)
+}
+
+func TestNormalizeCode(t *testing.T) {
+	assert.Equal(t, "3171011001", NormalizeCode("31.71.01.1001"))
+	assert.Equal(t, "3171", NormalizeCode(" 31.71 "))
+	assert.Equal(t, "317101", NormalizeCode("317101"))
+}
@@ -0,0 +1,159 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+)
+
+// Reader defines the region read operations served over HTTP
+type Reader interface {
+	ListProvinces(ctx context.Context) ([]Province, error)
+	ListCities(ctx context.Context, provinceID uuid.UUID) ([]City, error)
+	ListDistricts(ctx context.Context, cityID uuid.UUID) ([]District, error)
+	ListVillages(ctx context.Context, districtID uuid.UUID) ([]Village, error)
+	LookupCode(ctx context.Context, code string) (*Hierarchy, error)
+}
+
+// Handler serves the region master data for cascading address dropdowns
+type Handler struct {
+	reader Reader
+	logger *logrus.Logger
+}
+
+// NewHandler creates a new region handler
+func NewHandler(reader Reader, logger *logrus.Logger) *Handler {
+	return &Handler{
+		reader: reader,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the region routes on the given router group
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	regions := router.Group("/regions")
+	{
+		regions.GET("/provinces", h.ListProvinces)
+		regions.GET("/provinces/:id/cities", h.ListCities)
+		regions.GET("/cities/:id/districts", h.ListDistricts)
+		regions.GET("/districts/:id/villages", h.ListVillages)
+		regions.GET("/lookup/:code", h.LookupCode)
+	}
+}
+
+// ListProvinces returns all provinces
+// @Summary List provinces
+// @Tags regions
+// @Produce json
+// @Success 200 {object} object{success=bool,data=[]Province}
+// @Router /regions/provinces [get]
+func (h *Handler) ListProvinces(c *gin.Context) {
+	provinces, err := h.reader.ListProvinces(c.Request.Context())
+	h.respond(c, provinces, err)
+}
+
+// ListCities returns the cities of a province
+// @Summary List cities of a province
+// @Tags regions
+// @Produce json
+// @Param id path string true "Province ID"
+// @Success 200 {object} object{success=bool,data=[]City}
+// @Router /regions/provinces/{id}/cities [get]
+func (h *Handler) ListCities(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+	cities, err := h.reader.ListCities(c.Request.Context(), id)
+	h.respond(c, cities, err)
+}
+
+// ListDistricts returns the districts of a city
+// @Summary List districts of a city
+// @Tags regions
+// @Produce json
+// @Param id path string true "City ID"
+// @Success 200 {object} object{success=bool,data=[]District}
+// @Router /regions/cities/{id}/districts [get]
+func (h *Handler) ListDistricts(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+	districts, err := h.reader.ListDistricts(c.Request.Context(), id)
+	h.respond(c, districts, err)
+}
+
+// ListVillages returns the villages of a district
+// @Summary List villages of a district
+// @Tags regions
+// @Produce json
+// @Param id path string true "District ID"
+// @Success 200 {object} object{success=bool,data=[]Village}
+// @Router /regions/districts/{id}/villages [get]
+func (h *Handler) ListVillages(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+	villages, err := h.reader.ListVillages(c.Request.Context(), id)
+	h.respond(c, villages, err)
+}
+
+// LookupCode resolves a Kemendagri region code to its hierarchy
+// @Summary Look up a region code
+// @Tags regions
+// @Produce json
+// @Param code path string true "Kemendagri code, e.g. 3171011001 or 31.71.01.1001"
+// @Success 200 {object} object{success=bool,data=Hierarchy}
+// @Failure 400 {object} apperror.Response
+// @Failure 404 {object} apperror.Response
+// @Router /regions/lookup/{code} [get]
+func (h *Handler) LookupCode(c *gin.Context) {
+	code := NormalizeCode(c.Param("code"))
+	if _, _, err := levelOf(code); err != nil || !isDigits(code) {
+		apperror.Respond(c, apperror.Validation(apperror.Field("code", "invalid", "invalid region code")))
+		return
+	}
+
+	hierarchy, err := h.reader.LookupCode(c.Request.Context(), code)
+	h.respond(c, hierarchy, err)
+}
+
+func (h *Handler) parseID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apperror.Respond(c, apperror.Validation(apperror.Field("id", "uuid", "invalid region ID")))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) respond(c *gin.Context, data interface{}, err error) {
+	if err != nil {
+		if errors.Is(err, ErrRegionNotFound) {
+			apperror.Respond(c, apperror.Wrap(err, apperror.CodeRegionNotFound, "region not found"))
+			return
+		}
+
+		h.logger.WithFields(logrus.Fields{
+			"path":  c.FullPath(),
+			"error": err,
+		}).Error("Failed to load regions")
+		apperror.Respond(c, err)
+		return
+	}
+
+	// Region data changes only on dataset imports
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
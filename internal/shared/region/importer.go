@@ -0,0 +1,251 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/cache"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+const (
+	// IndonesiaCountryCode is the ISO 3166-1 alpha-2 code of Indonesia
+	IndonesiaCountryCode = "ID"
+
+	importBatchSize = 1000
+)
+
+// ImportOptions controls a dataset import
+type ImportOptions struct {
+	// Version identifies the dataset release, e.g. kemendagri-2024
+	Version string
+	// Source describes where the dataset came from
+	Source string
+	// Force re-imports a version that was already imported
+	Force bool
+}
+
+// ImportResult describes the outcome of a dataset import
+type ImportResult struct {
+	Version   string        `json:"version"`
+	Skipped   bool          `json:"skipped"`
+	Provinces int           `json:"provinces"`
+	Cities    int           `json:"cities"`
+	Districts int           `json:"districts"`
+	Villages  int           `json:"villages"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Importer loads a region dataset into the master data tables. Regions are
+// upserted by code, so existing IDs referenced by tenants, customers and
+// suppliers are preserved and re-running an import is safe.
+type Importer struct {
+	db     *database.Database
+	cache  *cache.RedisCache
+	logger *logrus.Logger
+}
+
+// NewImporter creates a new region dataset importer. The cache is optional;
+// when set, cached lookups are invalidated after a successful import.
+func NewImporter(db *database.Database, cache *cache.RedisCache, logger *logrus.Logger) *Importer {
+	return &Importer{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// Import upserts the dataset and records its version
+func (i *Importer) Import(ctx context.Context, dataset *Dataset, opts ImportOptions) (*ImportResult, error) {
+	if opts.Version == "" {
+		return nil, fmt.Errorf("dataset version is required")
+	}
+
+	start := time.Now()
+	result := &ImportResult{Version: opts.Version}
+
+	var existing DatasetVersion
+	err := i.db.DB.WithContext(ctx).Where("version = ?", opts.Version).First(&existing).Error
+	switch {
+	case err == nil && !opts.Force:
+		if existing.Checksum != dataset.Checksum {
+			return nil, fmt.Errorf("region dataset version %s was already imported with a different checksum", opts.Version)
+		}
+		i.logger.WithField("version", opts.Version).Info("Region dataset version already imported, skipping")
+		result.Skipped = true
+		return result, nil
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("failed to check region dataset version: %w", err)
+	}
+
+	err = i.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		countryID, err := i.upsertCountry(tx)
+		if err != nil {
+			return err
+		}
+
+		provinceIDs, err := i.importProvinces(tx, dataset.Provinces, countryID)
+		if err != nil {
+			return err
+		}
+		cityIDs, err := i.importCities(tx, dataset.Cities, provinceIDs)
+		if err != nil {
+			return err
+		}
+		districtIDs, err := i.importDistricts(tx, dataset.Districts, cityIDs)
+		if err != nil {
+			return err
+		}
+		if err := i.importVillages(tx, dataset.Villages, districtIDs); err != nil {
+			return err
+		}
+
+		version := &DatasetVersion{
+			Version:    opts.Version,
+			Checksum:   dataset.Checksum,
+			Source:     opts.Source,
+			Provinces:  len(dataset.Provinces),
+			Cities:     len(dataset.Cities),
+			Districts:  len(dataset.Districts),
+			Villages:   len(dataset.Villages),
+			ImportedAt: time.Now(),
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(version).Error
+	})
+	if err != nil {
+		i.logger.WithFields(logrus.Fields{
+			"version": opts.Version,
+			"error":   err,
+		}).Error("Failed to import region dataset")
+		return nil, fmt.Errorf("failed to import region dataset: %w", err)
+	}
+
+	if i.cache != nil {
+		if _, err := i.cache.Increment(ctx, cacheGenerationKey); err != nil {
+			i.logger.WithError(err).Warn("Failed to invalidate region cache")
+		}
+	}
+
+	result.Provinces = len(dataset.Provinces)
+	result.Cities = len(dataset.Cities)
+	result.Districts = len(dataset.Districts)
+	result.Villages = len(dataset.Villages)
+	result.Duration = time.Since(start)
+
+	i.logger.WithFields(logrus.Fields{
+		"version":   result.Version,
+		"provinces": result.Provinces,
+		"cities":    result.Cities,
+		"districts": result.Districts,
+		"villages":  result.Villages,
+		"duration":  result.Duration,
+	}).Info("Region dataset imported")
+
+	return result, nil
+}
+
+func (i *Importer) upsertCountry(tx *gorm.DB) (uuid.UUID, error) {
+	country := &Country{ID: uuid.New(), Code: IndonesiaCountryCode, Name: "Indonesia"}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "updated_at"}),
+	}).Create(country).Error; err != nil {
+		return uuid.Nil, fmt.Errorf("failed to upsert country: %w", err)
+	}
+
+	if err := tx.Where("code = ?", IndonesiaCountryCode).First(country).Error; err != nil {
+		return uuid.Nil, fmt.Errorf("failed to load country: %w", err)
+	}
+	return country.ID, nil
+}
+
+func (i *Importer) importProvinces(tx *gorm.DB, records []Record, countryID uuid.UUID) (map[string]uuid.UUID, error) {
+	rows := make([]*Province, 0, len(records))
+	for _, record := range records {
+		rows = append(rows, &Province{ID: uuid.New(), CountryID: &countryID, Code: record.Code, Name: record.Name})
+	}
+
+	if err := upsertByCode(tx, rows, "country_id", "name"); err != nil {
+		return nil, fmt.Errorf("failed to import provinces: %w", err)
+	}
+	return loadCodeIDs(tx, &Province{})
+}
+
+func (i *Importer) importCities(tx *gorm.DB, records []Record, provinceIDs map[string]uuid.UUID) (map[string]uuid.UUID, error) {
+	rows := make([]*City, 0, len(records))
+	for _, record := range records {
+		rows = append(rows, &City{
+			ID:         uuid.New(),
+			ProvinceID: provinceIDs[record.ParentCode],
+			Code:       record.Code,
+			Name:       record.Name,
+			Type:       record.CityType,
+		})
+	}
+
+	if err := upsertByCode(tx, rows, "province_id", "name", "type"); err != nil {
+		return nil, fmt.Errorf("failed to import cities: %w", err)
+	}
+	return loadCodeIDs(tx, &City{})
+}
+
+func (i *Importer) importDistricts(tx *gorm.DB, records []Record, cityIDs map[string]uuid.UUID) (map[string]uuid.UUID, error) {
+	rows := make([]*District, 0, len(records))
+	for _, record := range records {
+		rows = append(rows, &District{ID: uuid.New(), CityID: cityIDs[record.ParentCode], Code: record.Code, Name: record.Name})
+	}
+
+	if err := upsertByCode(tx, rows, "city_id", "name"); err != nil {
+		return nil, fmt.Errorf("failed to import districts: %w", err)
+	}
+	return loadCodeIDs(tx, &District{})
+}
+
+func (i *Importer) importVillages(tx *gorm.DB, records []Record, districtIDs map[string]uuid.UUID) error {
+	rows := make([]*Village, 0, len(records))
+	for _, record := range records {
+		rows = append(rows, &Village{ID: uuid.New(), DistrictID: districtIDs[record.ParentCode], Code: record.Code, Name: record.Name})
+	}
+
+	if err := upsertByCode(tx, rows, "district_id", "name"); err != nil {
+		return fmt.Errorf("failed to import villages: %w", err)
+	}
+	return nil
+}
+
+// upsertByCode inserts rows in batches, updating the given columns of rows
+// whose code already exists. IDs of existing rows are left untouched.
+func upsertByCode[T any](tx *gorm.DB, rows []*T, columns ...string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns(append(columns, "updated_at")),
+	}).CreateInBatches(rows, importBatchSize).Error
+}
+
+// loadCodeIDs returns the code to ID mapping of a region table
+func loadCodeIDs(tx *gorm.DB, model interface{}) (map[string]uuid.UUID, error) {
+	var rows []struct {
+		ID   uuid.UUID
+		Code string
+	}
+	if err := tx.Model(model).Select("id", "code").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load region codes: %w", err)
+	}
+
+	ids := make(map[string]uuid.UUID, len(rows))
+	for _, row := range rows {
+		ids[row.Code] = row.ID
+	}
+	return ids, nil
+}
@@ -0,0 +1,130 @@
+// Package region provides the Indonesian administrative region master data
+// (provinsi, kabupaten/kota, kecamatan and kelurahan/desa) based on the
+// Kemendagri region codes: importing, cached lookups and address validation.
+package region
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CityType distinguishes a kota from a kabupaten
+type CityType string
+
+const (
+	CityTypeKota      CityType = "kota"
+	CityTypeKabupaten CityType = "kabupaten"
+)
+
+// Level identifies a level of the administrative hierarchy
+type Level string
+
+const (
+	LevelProvince Level = "province"
+	LevelCity     Level = "city"
+	LevelDistrict Level = "district"
+	LevelVillage  Level = "village"
+)
+
+// Country represents a country
+type Country struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Code      string    `gorm:"type:varchar(2);uniqueIndex;not null" json:"code"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for Country
+func (Country) TableName() string {
+	return "countries"
+}
+
+// Province represents a provinsi, e.g. 31 DKI Jakarta
+type Province struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	CountryID *uuid.UUID `gorm:"type:uuid" json:"country_id,omitempty"`
+	Code      string     `gorm:"type:varchar(2);uniqueIndex;not null" json:"code"`
+	Name      string     `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Province
+func (Province) TableName() string {
+	return "provinces"
+}
+
+// City represents a kabupaten or kota, e.g. 3171 Jakarta Pusat
+type City struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ProvinceID uuid.UUID `gorm:"type:uuid" json:"province_id"`
+	Code       string    `gorm:"type:varchar(4);uniqueIndex;not null" json:"code"`
+	Name       string    `gorm:"type:varchar(100);not null" json:"name"`
+	Type       CityType  `gorm:"type:varchar(20);not null" json:"type"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for City
+func (City) TableName() string {
+	return "cities"
+}
+
+// District represents a kecamatan, e.g. 317101 Gambir
+type District struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	CityID    uuid.UUID `gorm:"type:uuid" json:"city_id"`
+	Code      string    `gorm:"type:varchar(6);uniqueIndex;not null" json:"code"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for District
+func (District) TableName() string {
+	return "districts"
+}
+
+// Village represents a kelurahan or desa, e.g. 3171011001 Gambir
+type Village struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	DistrictID uuid.UUID `gorm:"type:uuid" json:"district_id"`
+	Code       string    `gorm:"type:varchar(10);uniqueIndex;not null" json:"code"`
+	Name       string    `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for Village
+func (Village) TableName() string {
+	return "villages"
+}
+
+// DatasetVersion records an imported version of the region dataset
+type DatasetVersion struct {
+	Version    string    `gorm:"type:varchar(50);primaryKey" json:"version"`
+	Checksum   string    `gorm:"type:varchar(64);not null" json:"checksum"`
+	Source     string    `gorm:"type:varchar(255)" json:"source"`
+	Provinces  int       `gorm:"not null;default:0" json:"provinces"`
+	Cities     int       `gorm:"not null;default:0" json:"cities"`
+	Districts  int       `gorm:"not null;default:0" json:"districts"`
+	Villages   int       `gorm:"not null;default:0" json:"villages"`
+	ImportedAt time.Time `gorm:"not null" json:"imported_at"`
+}
+
+// TableName returns the table name for DatasetVersion
+func (DatasetVersion) TableName() string {
+	return "region_dataset_versions"
+}
+
+// Hierarchy represents a region code resolved to all of its levels
+type Hierarchy struct {
+	Level    Level     `json:"level"`
+	Code     string    `json:"code"`
+	Province *Province `json:"province,omitempty"`
+	City     *City     `json:"city,omitempty"`
+	District *District `json:"district,omitempty"`
+	Village  *Village  `json:"village,omitempty"`
+}
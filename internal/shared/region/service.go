@@ -0,0 +1,211 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/cache"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+const (
+	// cacheGenerationKey is bumped by the importer so that all cached region
+	// lookups are invalidated at once
+	cacheGenerationKey = "region:generation"
+	cacheTTL           = 24 * time.Hour
+)
+
+// ErrRegionNotFound is returned when a region does not exist
+var ErrRegionNotFound = errors.New("region not found")
+
+// Lookup defines read access to single regions by ID
+type Lookup interface {
+	GetProvince(ctx context.Context, id uuid.UUID) (*Province, error)
+	GetCity(ctx context.Context, id uuid.UUID) (*City, error)
+	GetDistrict(ctx context.Context, id uuid.UUID) (*District, error)
+	GetVillage(ctx context.Context, id uuid.UUID) (*Village, error)
+}
+
+// Service provides cached read access to the region master data for
+// cascading dropdowns and code lookups
+type Service struct {
+	db     *database.Database
+	cache  *cache.RedisCache
+	logger *logrus.Logger
+}
+
+// NewService creates a new region service. The cache is optional.
+func NewService(db *database.Database, cache *cache.RedisCache, logger *logrus.Logger) *Service {
+	return &Service{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// ListProvinces returns all provinces ordered by code
+func (s *Service) ListProvinces(ctx context.Context) ([]Province, error) {
+	var provinces []Province
+	err := s.cached(ctx, "provinces", &provinces, func() error {
+		return s.db.DB.WithContext(ctx).Order("code").Find(&provinces).Error
+	})
+	return provinces, err
+}
+
+// ListCities returns the cities of a province ordered by code
+func (s *Service) ListCities(ctx context.Context, provinceID uuid.UUID) ([]City, error) {
+	var cities []City
+	err := s.cached(ctx, "cities:"+provinceID.String(), &cities, func() error {
+		return s.db.DB.WithContext(ctx).Where("province_id = ?", provinceID).Order("code").Find(&cities).Error
+	})
+	return cities, err
+}
+
+// ListDistricts returns the districts of a city ordered by code
+func (s *Service) ListDistricts(ctx context.Context, cityID uuid.UUID) ([]District, error) {
+	var districts []District
+	err := s.cached(ctx, "districts:"+cityID.String(), &districts, func() error {
+		return s.db.DB.WithContext(ctx).Where("city_id = ?", cityID).Order("code").Find(&districts).Error
+	})
+	return districts, err
+}
+
+// ListVillages returns the villages of a district ordered by code
+func (s *Service) ListVillages(ctx context.Context, districtID uuid.UUID) ([]Village, error) {
+	var villages []Village
+	err := s.cached(ctx, "villages:"+districtID.String(), &villages, func() error {
+		return s.db.DB.WithContext(ctx).Where("district_id = ?", districtID).Order("code").Find(&villages).Error
+	})
+	return villages, err
+}
+
+// GetProvince returns a province by ID
+func (s *Service) GetProvince(ctx context.Context, id uuid.UUID) (*Province, error) {
+	var province Province
+	if err := s.getByID(ctx, "province", id, &province); err != nil {
+		return nil, err
+	}
+	return &province, nil
+}
+
+// GetCity returns a city by ID
+func (s *Service) GetCity(ctx context.Context, id uuid.UUID) (*City, error) {
+	var city City
+	if err := s.getByID(ctx, "city", id, &city); err != nil {
+		return nil, err
+	}
+	return &city, nil
+}
+
+// GetDistrict returns a district by ID
+func (s *Service) GetDistrict(ctx context.Context, id uuid.UUID) (*District, error) {
+	var district District
+	if err := s.getByID(ctx, "district", id, &district); err != nil {
+		return nil, err
+	}
+	return &district, nil
+}
+
+// GetVillage returns a village by ID
+func (s *Service) GetVillage(ctx context.Context, id uuid.UUID) (*Village, error) {
+	var village Village
+	if err := s.getByID(ctx, "village", id, &village); err != nil {
+		return nil, err
+	}
+	return &village, nil
+}
+
+// LookupCode resolves a Kemendagri code of any level to the full hierarchy,
+// e.g. 31.71.01.1001 to Gambir, Gambir, Jakarta Pusat, DKI Jakarta
+func (s *Service) LookupCode(ctx context.Context, code string) (*Hierarchy, error) {
+	code = NormalizeCode(code)
+	level, _, err := levelOf(code)
+	if err != nil || !isDigits(code) {
+		return nil, fmt.Errorf("invalid region code: %s", code)
+	}
+
+	var hierarchy Hierarchy
+	err = s.cached(ctx, "code:"+code, &hierarchy, func() error {
+		hierarchy = Hierarchy{Level: level, Code: code}
+		tx := s.db.DB.WithContext(ctx)
+
+		if len(code) >= 10 {
+			hierarchy.Village = &Village{}
+			if err := tx.Where("code = ?", code[:10]).First(hierarchy.Village).Error; err != nil {
+				return err
+			}
+		}
+		if len(code) >= 6 {
+			hierarchy.District = &District{}
+			if err := tx.Where("code = ?", code[:6]).First(hierarchy.District).Error; err != nil {
+				return err
+			}
+		}
+		if len(code) >= 4 {
+			hierarchy.City = &City{}
+			if err := tx.Where("code = ?", code[:4]).First(hierarchy.City).Error; err != nil {
+				return err
+			}
+		}
+		hierarchy.Province = &Province{}
+		return tx.Where("code = ?", code[:2]).First(hierarchy.Province).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &hierarchy, nil
+}
+
+func (s *Service) getByID(ctx context.Context, kind string, id uuid.UUID, dest interface{}) error {
+	return s.cached(ctx, kind+":"+id.String(), dest, func() error {
+		return s.db.DB.WithContext(ctx).Where("id = ?", id).First(dest).Error
+	})
+}
+
+// cached serves dest from the cache or loads it and stores it in the cache.
+// Cache failures are logged and fall back to the database.
+func (s *Service) cached(ctx context.Context, key string, dest interface{}, load func() error) error {
+	cacheKey := ""
+	if s.cache != nil {
+		var generation int64
+		if err := s.cache.Get(ctx, cacheGenerationKey, &generation); err != nil && !isCacheMiss(err) {
+			s.logger.WithError(err).Warn("Failed to read region cache generation")
+		}
+		cacheKey = fmt.Sprintf("region:%d:%s", generation, key)
+
+		if err := s.cache.Get(ctx, cacheKey, dest); err == nil {
+			return nil
+		}
+	}
+
+	if err := load(); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRegionNotFound
+		}
+		s.logger.WithFields(logrus.Fields{
+			"key":   key,
+			"error": err,
+		}).Error("Failed to load region data")
+		return fmt.Errorf("failed to load region data: %w", err)
+	}
+
+	if cacheKey != "" {
+		if err := s.cache.Set(ctx, cacheKey, dest, cacheTTL); err != nil {
+			s.logger.WithError(err).Warn("Failed to cache region data")
+		}
+	}
+
+	return nil
+}
+
+func isCacheMiss(err error) bool {
+	return strings.Contains(err.Error(), "key not found")
+}
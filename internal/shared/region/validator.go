@@ -0,0 +1,196 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidAddress is wrapped by every address validation error
+var ErrInvalidAddress = errors.New("invalid address")
+
+// Address holds the region references of an address
+type Address struct {
+	CountryID  *uuid.UUID
+	ProvinceID *uuid.UUID
+	CityID     *uuid.UUID
+	DistrictID *uuid.UUID
+	VillageID  *uuid.UUID
+}
+
+// IsEmpty reports whether no region is set
+func (a Address) IsEmpty() bool {
+	return a.CountryID == nil && a.ProvinceID == nil && a.CityID == nil &&
+		a.DistrictID == nil && a.VillageID == nil
+}
+
+// Addressable is implemented by models that reference the region master
+// data, such as tenants, customers and suppliers
+type Addressable interface {
+	RegionAddress() Address
+}
+
+// AddressValidator checks that the regions of an address exist and belong
+// to each other, e.g. that the village lies in the chosen district and city
+type AddressValidator struct {
+	lookup Lookup
+}
+
+// NewAddressValidator creates a new address validator
+func NewAddressValidator(lookup Lookup) *AddressValidator {
+	return &AddressValidator{lookup: lookup}
+}
+
+// ValidateAddress validates the region hierarchy of an address
+func (v *AddressValidator) ValidateAddress(ctx context.Context, address Address) error {
+	if address.IsEmpty() {
+		return nil
+	}
+
+	// A region can only be set together with its parent regions
+	if address.VillageID != nil && address.DistrictID == nil {
+		return invalidAddress("district is required when village is set")
+	}
+	if address.DistrictID != nil && address.CityID == nil {
+		return invalidAddress("city is required when district is set")
+	}
+	if address.CityID != nil && address.ProvinceID == nil {
+		return invalidAddress("province is required when city is set")
+	}
+
+	if address.VillageID != nil {
+		village, err := v.lookup.GetVillage(ctx, *address.VillageID)
+		if err != nil {
+			return lookupError("village", err)
+		}
+		if village.DistrictID != *address.DistrictID {
+			return invalidAddress("village %s does not belong to the selected district", village.Name)
+		}
+	}
+
+	if address.DistrictID != nil {
+		district, err := v.lookup.GetDistrict(ctx, *address.DistrictID)
+		if err != nil {
+			return lookupError("district", err)
+		}
+		if district.CityID != *address.CityID {
+			return invalidAddress("district %s does not belong to the selected city", district.Name)
+		}
+	}
+
+	if address.CityID != nil {
+		city, err := v.lookup.GetCity(ctx, *address.CityID)
+		if err != nil {
+			return lookupError("city", err)
+		}
+		if city.ProvinceID != *address.ProvinceID {
+			return invalidAddress("city %s does not belong to the selected province", city.Name)
+		}
+	}
+
+	if address.ProvinceID != nil {
+		province, err := v.lookup.GetProvince(ctx, *address.ProvinceID)
+		if err != nil {
+			return lookupError("province", err)
+		}
+		if address.CountryID != nil && province.CountryID != nil && *province.CountryID != *address.CountryID {
+			return invalidAddress("province %s does not belong to the selected country", province.Name)
+		}
+	}
+
+	return nil
+}
+
+// RegisterCallbacks validates the address of every Addressable model on
+// create and update, so customer, supplier and tenant writes are checked
+// regardless of which service performs them. Column map updates are
+// validated against the regions of the model they are applied to, so the
+// model must hold the stored address when only part of it is updated.
+func (v *AddressValidator) RegisterCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("region:validate_address_create", v.validateCallback); err != nil {
+		return fmt.Errorf("failed to register address validation callback: %w", err)
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("region:validate_address_update", v.validateCallback); err != nil {
+		return fmt.Errorf("failed to register address validation callback: %w", err)
+	}
+	return nil
+}
+
+func (v *AddressValidator) validateCallback(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Dest == nil {
+		return
+	}
+
+	addressable, ok := db.Statement.Dest.(Addressable)
+	if !ok {
+		// db.Model(&x).Updates(map...) carries the model separately
+		updates, isMap := db.Statement.Dest.(map[string]interface{})
+		if !isMap {
+			return
+		}
+		merged, err := applyUpdates(db.Statement, updates)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		if merged == nil {
+			return
+		}
+		addressable = merged
+	}
+
+	if err := v.ValidateAddress(db.Statement.Context, addressable.RegionAddress()); err != nil {
+		db.AddError(err)
+	}
+}
+
+// applyUpdates applies a column map to a copy of the statement model and
+// returns the copy, or nil when the model is not Addressable
+func applyUpdates(stmt *gorm.Statement, updates map[string]interface{}) (Addressable, error) {
+	if stmt.Model == nil || stmt.Schema == nil {
+		return nil, nil
+	}
+
+	model := reflect.Indirect(reflect.ValueOf(stmt.Model))
+	if model.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	merged := reflect.New(model.Type())
+	addressable, ok := merged.Interface().(Addressable)
+	if !ok {
+		return nil, nil
+	}
+	merged.Elem().Set(model)
+
+	for column, value := range updates {
+		field := stmt.Schema.LookUpField(column)
+		if field == nil {
+			continue
+		}
+		// SQL expressions are evaluated by the database, not here
+		if _, ok := value.(clause.Expression); ok {
+			continue
+		}
+		if err := field.Set(stmt.Context, merged.Elem(), value); err != nil {
+			return nil, fmt.Errorf("failed to apply update of %s: %w", column, err)
+		}
+	}
+
+	return addressable, nil
+}
+
+func invalidAddress(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidAddress, fmt.Sprintf(format, args...))
+}
+
+func lookupError(kind string, err error) error {
+	if errors.Is(err, ErrRegionNotFound) {
+		return invalidAddress("%s not found", kind)
+	}
+	return fmt.Errorf("failed to validate %s: %w", kind, err)
+}
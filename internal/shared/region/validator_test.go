@@ -0,0 +1,186 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// memoryLookup is an in-memory Lookup for tests
+type memoryLookup struct {
+	provinces map[uuid.UUID]*Province
+	cities    map[uuid.UUID]*City
+	districts map[uuid.UUID]*District
+	villages  map[uuid.UUID]*Village
+}
+
+func (l *memoryLookup) GetProvince(ctx context.Context, id uuid.UUID) (*Province, error) {
+	if province, ok := l.provinces[id]; ok {
+		return province, nil
+	}
+	return nil, ErrRegionNotFound
+}
+
+func (l *memoryLookup) GetCity(ctx context.Context, id uuid.UUID) (*City, error) {
+	if city, ok := l.cities[id]; ok {
+		return city, nil
+	}
+	return nil, ErrRegionNotFound
+}
+
+func (l *memoryLookup) GetDistrict(ctx context.Context, id uuid.UUID) (*District, error) {
+	if district, ok := l.districts[id]; ok {
+		return district, nil
+	}
+	return nil, ErrRegionNotFound
+}
+
+func (l *memoryLookup) GetVillage(ctx context.Context, id uuid.UUID) (*Village, error) {
+	if village, ok := l.villages[id]; ok {
+		return village, nil
+	}
+	return nil, ErrRegionNotFound
+}
+
+func TestAddressValidator_ValidateAddress(t *testing.T) {
+	countryID := uuid.New()
+	jakarta := &Province{ID: uuid.New(), CountryID: &countryID, Code: "31", Name: "DKI Jakarta"}
+	jawaBarat := &Province{ID: uuid.New(), CountryID: &countryID, Code: "32", Name: "Jawa Barat"}
+	jakartaPusat := &City{ID: uuid.New(), ProvinceID: jakarta.ID, Code: "3171", Name: "Jakarta Pusat"}
+	bogor := &City{ID: uuid.New(), ProvinceID: jawaBarat.ID, Code: "3201", Name: "Bogor"}
+	gambir := &District{ID: uuid.New(), CityID: jakartaPusat.ID, Code: "317101", Name: "Gambir"}
+	menteng := &District{ID: uuid.New(), CityID: jakartaPusat.ID, Code: "317106", Name: "Menteng"}
+	cideng := &Village{ID: uuid.New(), DistrictID: gambir.ID, Code: "3171011002", Name: "Cideng"}
+
+	lookup := &memoryLookup{
+		provinces: map[uuid.UUID]*Province{jakarta.ID: jakarta, jawaBarat.ID: jawaBarat},
+		cities:    map[uuid.UUID]*City{jakartaPusat.ID: jakartaPusat, bogor.ID: bogor},
+		districts: map[uuid.UUID]*District{gambir.ID: gambir, menteng.ID: menteng},
+		villages:  map[uuid.UUID]*Village{cideng.ID: cideng},
+	}
+	validator := NewAddressValidator(lookup)
+
+	unknown := uuid.New()
+	tests := []struct {
+		name    string
+		address Address
+		wantErr bool
+	}{
+		{
+			name:    "Empty address",
+			address: Address{},
+		},
+		{
+			name:    "Complete valid address",
+			address: Address{CountryID: &countryID, ProvinceID: &jakarta.ID, CityID: &jakartaPusat.ID, DistrictID: &gambir.ID, VillageID: &cideng.ID},
+		},
+		{
+			name:    "Province and city only",
+			address: Address{ProvinceID: &jawaBarat.ID, CityID: &bogor.ID},
+		},
+		{
+			name:    "Village outside the district",
+			address: Address{ProvinceID: &jakarta.ID, CityID: &jakartaPusat.ID, DistrictID: &menteng.ID, VillageID: &cideng.ID},
+			wantErr: true,
+		},
+		{
+			name:    "District outside the city",
+			address: Address{ProvinceID: &jawaBarat.ID, CityID: &bogor.ID, DistrictID: &gambir.ID},
+			wantErr: true,
+		},
+		{
+			name:    "City outside the province",
+			address: Address{ProvinceID: &jawaBarat.ID, CityID: &jakartaPusat.ID},
+			wantErr: true,
+		},
+		{
+			name:    "Village without district",
+			address: Address{ProvinceID: &jakarta.ID, CityID: &jakartaPusat.ID, VillageID: &cideng.ID},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown village",
+			address: Address{ProvinceID: &jakarta.ID, CityID: &jakartaPusat.ID, DistrictID: &gambir.ID, VillageID: &unknown},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateAddress(context.Background(), tt.address)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, ErrInvalidAddress))
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// addressedCustomer is a minimal Addressable model for the callback tests
+type addressedCustomer struct {
+	ID         uuid.UUID `gorm:"primaryKey"`
+	Name       string
+	ProvinceID *uuid.UUID
+	CityID     *uuid.UUID
+}
+
+func (c *addressedCustomer) RegionAddress() Address {
+	return Address{ProvinceID: c.ProvinceID, CityID: c.CityID}
+}
+
+func TestAddressValidator_RegisterCallbacks(t *testing.T) {
+	jakarta := &Province{ID: uuid.New(), Code: "31", Name: "DKI Jakarta"}
+	jawaBarat := &Province{ID: uuid.New(), Code: "32", Name: "Jawa Barat"}
+	jakartaPusat := &City{ID: uuid.New(), ProvinceID: jakarta.ID, Code: "3171", Name: "Jakarta Pusat"}
+	jakartaSelatan := &City{ID: uuid.New(), ProvinceID: jakarta.ID, Code: "3174", Name: "Jakarta Selatan"}
+	bogor := &City{ID: uuid.New(), ProvinceID: jawaBarat.ID, Code: "3201", Name: "Bogor"}
+	lookup := &memoryLookup{
+		provinces: map[uuid.UUID]*Province{jakarta.ID: jakarta, jawaBarat.ID: jawaBarat},
+		cities:    map[uuid.UUID]*City{jakartaPusat.ID: jakartaPusat, jakartaSelatan.ID: jakartaSelatan, bogor.ID: bogor},
+	}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&addressedCustomer{}))
+	require.NoError(t, NewAddressValidator(lookup).RegisterCallbacks(db))
+
+	customer := &addressedCustomer{ID: uuid.New(), Name: "Toko Sinar", ProvinceID: &jakarta.ID, CityID: &jakartaPusat.ID}
+	require.NoError(t, db.Create(customer).Error)
+
+	invalid := &addressedCustomer{ID: uuid.New(), Name: "Toko Bulan", ProvinceID: &jakarta.ID, CityID: &bogor.ID}
+	assert.ErrorIs(t, db.Create(invalid).Error, ErrInvalidAddress)
+
+	t.Run("Map update is merged with the model address", func(t *testing.T) {
+		err := db.Model(customer).Updates(map[string]interface{}{"city_id": bogor.ID}).Error
+		assert.ErrorIs(t, err, ErrInvalidAddress)
+
+		err = db.Model(customer).Update("CityID", &bogor.ID).Error
+		assert.ErrorIs(t, err, ErrInvalidAddress)
+
+		require.NoError(t, db.Model(customer).Updates(map[string]interface{}{"city_id": jakartaSelatan.ID}).Error)
+	})
+
+	t.Run("Map update moving the whole address", func(t *testing.T) {
+		err := db.Model(customer).Updates(map[string]interface{}{"province_id": jawaBarat.ID, "city_id": bogor.ID}).Error
+		require.NoError(t, err)
+	})
+
+	t.Run("Map update without regions", func(t *testing.T) {
+		require.NoError(t, db.Model(customer).Updates(map[string]interface{}{"name": "Toko Sinar Jaya"}).Error)
+		require.NoError(t, db.Model(&addressedCustomer{}).Where("id = ?", customer.ID).Update("name", gorm.Expr("UPPER(name)")).Error)
+	})
+
+	var stored addressedCustomer
+	require.NoError(t, db.First(&stored, "id = ?", customer.ID).Error)
+	assert.Equal(t, bogor.ID, *stored.CityID)
+	assert.Equal(t, "TOKO SINAR JAYA", stored.Name)
+}
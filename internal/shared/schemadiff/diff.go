@@ -0,0 +1,418 @@
+// Package schemadiff compares the GORM models with the tables of a live
+// database and drafts a migration that reconciles them.
+package schemadiff
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Kind classifies a difference between a model and its table
+type Kind string
+
+const (
+	// KindMissingTable is a model without a table
+	KindMissingTable Kind = "missing_table"
+	// KindMissingColumn is a model field without a column
+	KindMissingColumn Kind = "missing_column"
+	// KindExtraColumn is a column no model field maps to
+	KindExtraColumn Kind = "extra_column"
+	// KindTypeMismatch is a column whose type differs from the field's
+	KindTypeMismatch Kind = "type_mismatch"
+	// KindNullMismatch is a column whose nullability differs from the field's
+	KindNullMismatch Kind = "null_mismatch"
+	// KindMissingIndex is a model index without an index on the same columns
+	KindMissingIndex Kind = "missing_index"
+	// KindExtraIndex is an index no model declares
+	KindExtraIndex Kind = "extra_index"
+)
+
+// kindOrder orders the differences of a table in reports and migrations
+var kindOrder = map[Kind]int{
+	KindMissingTable:  0,
+	KindMissingColumn: 1,
+	KindExtraColumn:   2,
+	KindTypeMismatch:  3,
+	KindNullMismatch:  4,
+	KindMissingIndex:  5,
+	KindExtraIndex:    6,
+}
+
+// Difference is one way a table differs from its model. Expected and
+// Actual hold the type, nullability or index columns being compared.
+type Difference struct {
+	Kind     Kind   `json:"kind"`
+	Table    string `json:"table"`
+	Column   string `json:"column,omitempty"`
+	Index    string `json:"index,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+
+	// definition is the SQL the migration uses to create the missing column,
+	// table or index
+	definition string
+	// revert is the SQL that restores the actual column type
+	revert string
+}
+
+// String describes the difference for humans
+func (d Difference) String() string {
+	switch d.Kind {
+	case KindMissingTable:
+		return fmt.Sprintf("%s: table is missing", d.Table)
+	case KindMissingColumn:
+		return fmt.Sprintf("%s: column %s is missing (expected %s)", d.Table, d.Column, d.Expected)
+	case KindExtraColumn:
+		return fmt.Sprintf("%s: column %s (%s) is not in the model", d.Table, d.Column, d.Actual)
+	case KindTypeMismatch:
+		return fmt.Sprintf("%s: column %s is %s, expected %s", d.Table, d.Column, d.Actual, d.Expected)
+	case KindNullMismatch:
+		return fmt.Sprintf("%s: column %s is %s, expected %s", d.Table, d.Column, d.Actual, d.Expected)
+	case KindMissingIndex:
+		return fmt.Sprintf("%s: index %s on %s is missing", d.Table, d.Index, d.Expected)
+	case KindExtraIndex:
+		return fmt.Sprintf("%s: index %s on %s is not in the model", d.Table, d.Index, d.Actual)
+	}
+	return fmt.Sprintf("%s: %s", d.Table, d.Kind)
+}
+
+// Report lists the differences found by Compare, ordered by table
+type Report struct {
+	Dialect     string       `json:"dialect"`
+	Tables      int          `json:"tables"`
+	Differences []Difference `json:"differences"`
+}
+
+// HasDrift reports whether there are differences other than the allowed
+// kinds
+func (r *Report) HasDrift(allowed ...Kind) bool {
+	for _, d := range r.Differences {
+		if !containsKind(allowed, d.Kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare introspects the tables of the models and reports how they differ
+// from the models. Only the tables of the given models are compared; tables
+// managed by SQL migrations alone are not reported. Indexes are compared by
+// their columns and uniqueness, since SQL migrations name them differently.
+func Compare(ctx context.Context, db *gorm.DB, models ...interface{}) (*Report, error) {
+	db = db.WithContext(ctx)
+	report := &Report{Dialect: db.Dialector.Name()}
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("failed to parse model %T: %w", model, err)
+		}
+		differences, err := compareTable(db, stmt.Schema, model)
+		if err != nil {
+			return nil, err
+		}
+		report.Tables++
+		report.Differences = append(report.Differences, differences...)
+	}
+
+	sort.SliceStable(report.Differences, func(i, j int) bool {
+		a, b := report.Differences[i], report.Differences[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		return kindOrder[a.Kind] < kindOrder[b.Kind]
+	})
+	return report, nil
+}
+
+func compareTable(db *gorm.DB, s *schema.Schema, model interface{}) ([]Difference, error) {
+	migrator := db.Migrator()
+	fields := migratedFields(s)
+
+	if !migrator.HasTable(s.Table) {
+		return []Difference{{
+			Kind:       KindMissingTable,
+			Table:      s.Table,
+			definition: createTableSQL(db, s, fields),
+		}}, nil
+	}
+
+	columnTypes, err := migrator.ColumnTypes(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", s.Table, err)
+	}
+	columns := make(map[string]gorm.ColumnType, len(columnTypes))
+	for _, columnType := range columnTypes {
+		columns[columnType.Name()] = columnType
+	}
+
+	var differences []Difference
+	for _, field := range fields {
+		expectedType := normalizeType(db.Dialector.DataTypeOf(field), 0, false)
+		column, ok := columns[field.DBName]
+		if !ok {
+			differences = append(differences, Difference{
+				Kind:       KindMissingColumn,
+				Table:      s.Table,
+				Column:     field.DBName,
+				Expected:   expectedType,
+				definition: migrator.FullDataTypeOf(field).SQL,
+			})
+			continue
+		}
+
+		length, hasLength := column.Length()
+		actualType := normalizeType(column.DatabaseTypeName(), length, hasLength)
+		if actualType != expectedType {
+			differences = append(differences, Difference{
+				Kind:       KindTypeMismatch,
+				Table:      s.Table,
+				Column:     field.DBName,
+				Expected:   expectedType,
+				Actual:     actualType,
+				definition: db.Dialector.DataTypeOf(field),
+				revert:     actualType,
+			})
+		}
+
+		// Primary keys are never null, whatever the column reports
+		if nullable, ok := column.Nullable(); ok && !field.PrimaryKey && nullable == field.NotNull {
+			differences = append(differences, Difference{
+				Kind:     KindNullMismatch,
+				Table:    s.Table,
+				Column:   field.DBName,
+				Expected: nullability(!field.NotNull),
+				Actual:   nullability(nullable),
+			})
+		}
+	}
+
+	modelColumns := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		modelColumns[field.DBName] = true
+	}
+	for _, columnType := range columnTypes {
+		if !modelColumns[columnType.Name()] {
+			length, hasLength := columnType.Length()
+			differences = append(differences, Difference{
+				Kind:   KindExtraColumn,
+				Table:  s.Table,
+				Column: columnType.Name(),
+				Actual: normalizeType(columnType.DatabaseTypeName(), length, hasLength),
+			})
+		}
+	}
+
+	indexDifferences, err := compareIndexes(db, s, model)
+	if err != nil {
+		return nil, err
+	}
+	return append(differences, indexDifferences...), nil
+}
+
+// index is an index reduced to what is compared
+type index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// modelIndex reduces an index declared by a model
+func modelIndex(parsed *schema.Index) index {
+	i := index{Name: parsed.Name, Unique: parsed.Class == "UNIQUE"}
+	for _, option := range parsed.Fields {
+		if option.Field != nil {
+			i.Columns = append(i.Columns, option.DBName)
+		} else {
+			i.Columns = append(i.Columns, option.Expression)
+		}
+	}
+	return i
+}
+
+func (i index) key() string {
+	return fmt.Sprintf("%s|%t", strings.Join(i.Columns, ","), i.Unique)
+}
+
+func (i index) describe() string {
+	description := "(" + strings.Join(i.Columns, ", ") + ")"
+	if i.Unique {
+		description += " unique"
+	}
+	return description
+}
+
+func compareIndexes(db *gorm.DB, s *schema.Schema, model interface{}) ([]Difference, error) {
+	actual, err := tableIndexes(db, s.Table, model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexes of %s: %w", s.Table, err)
+	}
+	actualKeys := make(map[string]bool, len(actual))
+	for _, i := range actual {
+		actualKeys[i.key()] = true
+	}
+
+	var differences []Difference
+	expectedKeys := make(map[string]bool)
+	for _, parsed := range s.ParseIndexes() {
+		expected := modelIndex(parsed)
+		expectedKeys[expected.key()] = true
+
+		if !actualKeys[expected.key()] {
+			differences = append(differences, Difference{
+				Kind:       KindMissingIndex,
+				Table:      s.Table,
+				Index:      expected.Name,
+				Expected:   expected.describe(),
+				definition: createIndexSQL(s.Table, expected, parsed.Where),
+			})
+		}
+	}
+
+	for _, i := range actual {
+		if !expectedKeys[i.key()] {
+			differences = append(differences, Difference{
+				Kind:   KindExtraIndex,
+				Table:  s.Table,
+				Index:  i.Name,
+				Actual: i.describe(),
+			})
+		}
+	}
+	return differences, nil
+}
+
+// tableIndexes returns the indexes of a table other than the primary key
+// and constraint indexes
+func tableIndexes(db *gorm.DB, table string, model interface{}) ([]index, error) {
+	var indexes []index
+	if db.Dialector.Name() == "postgres" {
+		// Unlike the migrator this includes partitioned tables
+		var rows []struct {
+			IndexName  string
+			IsUnique   bool
+			ColumnName string
+		}
+		if err := db.Raw(`SELECT ci.relname AS index_name, i.indisunique AS is_unique, a.attname AS column_name
+			FROM pg_index i
+			JOIN pg_class ct ON ct.oid = i.indrelid
+			JOIN pg_class ci ON ci.oid = i.indexrelid
+			JOIN pg_namespace n ON n.oid = ct.relnamespace
+			JOIN pg_attribute a ON a.attrelid = ct.oid AND a.attnum = ANY(i.indkey)
+			LEFT JOIN pg_constraint con ON con.conindid = i.indexrelid
+			WHERE ct.relname = ? AND n.nspname = current_schema() AND NOT i.indisprimary AND con.oid IS NULL
+			ORDER BY ci.relname, array_position(i.indkey::int2[], a.attnum)`, table).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if len(indexes) == 0 || indexes[len(indexes)-1].Name != row.IndexName {
+				indexes = append(indexes, index{Name: row.IndexName, Unique: row.IsUnique})
+			}
+			last := &indexes[len(indexes)-1]
+			last.Columns = append(last.Columns, row.ColumnName)
+		}
+		return indexes, nil
+	}
+
+	found, err := db.Migrator().GetIndexes(model)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range found {
+		if primaryKey, _ := i.PrimaryKey(); primaryKey {
+			continue
+		}
+		unique, _ := i.Unique()
+		indexes = append(indexes, index{Name: i.Name(), Columns: i.Columns(), Unique: unique})
+	}
+	sort.Slice(indexes, func(a, b int) bool { return indexes[a].Name < indexes[b].Name })
+	return indexes, nil
+}
+
+// migratedFields returns the fields stored in columns of the model's table
+func migratedFields(s *schema.Schema) []*schema.Field {
+	var fields []*schema.Field
+	for _, field := range s.Fields {
+		if field.DBName != "" && !field.IgnoreMigration {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func nullability(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+var (
+	typeArguments = regexp.MustCompile(`\s*\(([^)]*)\)`)
+
+	// typeAliases maps the names PostgreSQL reports and the names GORM
+	// declares to one spelling
+	typeAliases = map[string]string{
+		"character varying":           "varchar",
+		"character":                   "bpchar",
+		"char":                        "bpchar",
+		"int8":                        "bigint",
+		"bigserial":                   "bigint",
+		"int4":                        "integer",
+		"int":                         "integer",
+		"serial":                      "integer",
+		"int2":                        "smallint",
+		"smallserial":                 "smallint",
+		"bool":                        "boolean",
+		"timestamp with time zone":    "timestamptz",
+		"timestamp without time zone": "timestamp",
+		"float8":                      "double precision",
+		"float4":                      "real",
+		"decimal":                     "numeric",
+	}
+)
+
+// normalizeType spells a column type the same way whether it comes from a
+// model or the database. Only the length of character types is compared.
+func normalizeType(dataType string, length int64, hasLength bool) string {
+	dataType = strings.ToLower(strings.TrimSpace(dataType))
+	arguments := ""
+	if matches := typeArguments.FindStringSubmatch(dataType); matches != nil {
+		arguments = matches[1]
+	}
+	base := strings.Join(strings.Fields(typeArguments.ReplaceAllString(dataType, "")), " ")
+	if alias, ok := typeAliases[base]; ok {
+		base = alias
+	}
+
+	if base != "varchar" && base != "bpchar" {
+		return base
+	}
+	if arguments == "" && hasLength && length > 0 {
+		arguments = strconv.FormatInt(length, 10)
+	}
+	if arguments == "" {
+		return base
+	}
+	return base + "(" + arguments + ")"
+}
+
+func containsKind(kinds []Kind, kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
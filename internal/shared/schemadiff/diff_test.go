@@ -0,0 +1,159 @@
+package schemadiff
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type testCustomer struct {
+	ID        string    `gorm:"type:text;primaryKey"`
+	TenantID  string    `gorm:"type:text;not null;index:idx_test_customers_tenant_email,unique"`
+	Email     string    `gorm:"type:text;not null;index:idx_test_customers_tenant_email,unique"`
+	Segment   string    `gorm:"type:text;index"`
+	Points    int64     `gorm:"not null;default:0"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+func (testCustomer) TableName() string { return "test_customers" }
+
+type testSupplier struct {
+	ID   string `gorm:"type:text;primaryKey"`
+	Name string `gorm:"type:text;not null"`
+}
+
+func (testSupplier) TableName() string { return "test_suppliers" }
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func differenceKinds(report *Report) map[string]Kind {
+	kinds := make(map[string]Kind, len(report.Differences))
+	for _, d := range report.Differences {
+		kinds[d.Table+"."+d.Column+d.Index] = d.Kind
+	}
+	return kinds
+}
+
+func TestCompare_NoDrift(t *testing.T) {
+	db := newTestDB(t)
+	require.NoError(t, db.AutoMigrate(&testCustomer{}, &testSupplier{}))
+
+	report, err := Compare(context.Background(), db, &testCustomer{}, &testSupplier{})
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", report.Dialect)
+	assert.Equal(t, 2, report.Tables)
+	assert.Empty(t, report.Differences)
+	assert.False(t, report.HasDrift())
+
+	up, down := report.Migration()
+	assert.Empty(t, up)
+	assert.Empty(t, down)
+}
+
+func TestCompare_Drift(t *testing.T) {
+	db := newTestDB(t)
+	// The table as an older SQL migration created it
+	require.NoError(t, db.Exec(`CREATE TABLE test_customers (
+		id text PRIMARY KEY,
+		tenant_id text NOT NULL,
+		email integer,
+		points integer NOT NULL DEFAULT 0,
+		created_at datetime NOT NULL,
+		legacy_code text
+	)`).Error)
+	require.NoError(t, db.Exec("CREATE INDEX idx_customers_legacy_code ON test_customers (legacy_code)").Error)
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX uq_customers_tenant_email ON test_customers (tenant_id, email)").Error)
+
+	report, err := Compare(context.Background(), db, &testCustomer{}, &testSupplier{})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]Kind{
+		"test_customers.segment":                    KindMissingColumn,
+		"test_customers.legacy_code":                KindExtraColumn,
+		"test_customers.email":                      KindNullMismatch,
+		"test_customers.idx_test_customers_segment": KindMissingIndex,
+		"test_customers.idx_customers_legacy_code":  KindExtraIndex,
+		"test_suppliers.":                           KindMissingTable,
+	}, withoutTypeMismatch(report, t))
+
+	// Indexes match on columns, whatever their name
+	for _, d := range report.Differences {
+		assert.NotEqual(t, "uq_customers_tenant_email", d.Index)
+	}
+
+	assert.True(t, report.HasDrift())
+	assert.True(t, report.HasDrift(KindExtraIndex))
+
+	up, down := report.Migration()
+	assert.Contains(t, up, `ALTER TABLE "test_customers" ADD COLUMN "segment" text;`)
+	assert.Contains(t, up, `ALTER TABLE "test_customers" ALTER COLUMN "email" TYPE text USING "email"::text;`)
+	assert.Contains(t, up, `ALTER TABLE "test_customers" ALTER COLUMN "email" SET NOT NULL;`)
+	assert.Contains(t, up, `CREATE INDEX IF NOT EXISTS "idx_test_customers_segment" ON "test_customers" ("segment");`)
+	assert.Contains(t, up, `-- ALTER TABLE "test_customers" DROP COLUMN "legacy_code";`)
+	assert.Contains(t, up, `-- DROP INDEX "idx_customers_legacy_code";`)
+	assert.Contains(t, up, `CREATE TABLE IF NOT EXISTS "test_suppliers" (`)
+	assert.Contains(t, up, `PRIMARY KEY ("id")`)
+
+	assert.Contains(t, down, `DROP TABLE IF EXISTS "test_suppliers";`)
+	assert.Contains(t, down, `ALTER TABLE "test_customers" DROP COLUMN IF EXISTS "segment";`)
+	assert.Contains(t, down, `ALTER TABLE "test_customers" ALTER COLUMN "email" TYPE integer USING "email"::integer;`)
+	assert.Contains(t, down, `ALTER TABLE "test_customers" ALTER COLUMN "email" DROP NOT NULL;`)
+	assert.NotContains(t, down, "legacy_code")
+}
+
+// withoutTypeMismatch checks the type mismatch of email and returns the
+// other differences by column or index
+func withoutTypeMismatch(report *Report, t *testing.T) map[string]Kind {
+	var others Report
+	for _, d := range report.Differences {
+		if d.Kind == KindTypeMismatch {
+			assert.Equal(t, "email", d.Column)
+			assert.Equal(t, "text", d.Expected)
+			assert.Equal(t, "integer", d.Actual)
+			continue
+		}
+		others.Differences = append(others.Differences, d)
+	}
+	return differenceKinds(&others)
+}
+
+func TestNormalizeType(t *testing.T) {
+	tests := []struct {
+		dataType  string
+		length    int64
+		hasLength bool
+		expected  string
+	}{
+		{"character varying", 255, true, "varchar(255)"},
+		{"varchar(255)", 0, false, "varchar(255)"},
+		{"VARCHAR", 0, false, "varchar"},
+		{"int8", 64, true, "bigint"},
+		{"bigserial", 0, false, "bigint"},
+		{"int4", 32, true, "integer"},
+		{"bool", 8, true, "boolean"},
+		{"timestamp with time zone", 0, false, "timestamptz"},
+		{"timestamptz", 64, true, "timestamptz"},
+		{"decimal(15,2)", 0, false, "numeric"},
+		{"uuid", 128, true, "uuid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dataType, func(t *testing.T) {
+			assert.Equal(t, tt.expected, normalizeType(tt.dataType, tt.length, tt.hasLength))
+		})
+	}
+}
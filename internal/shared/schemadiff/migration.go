@@ -0,0 +1,102 @@
+package schemadiff
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Migration drafts an up and down migration that brings the tables in line
+// with the models. Dropping extra columns and indexes loses data or breaks
+// queries the models do not know about, so those statements are commented
+// out for a reviewer to enable.
+func (r *Report) Migration() (string, string) {
+	var up, down []string
+	for _, d := range r.Differences {
+		table := quoteIdentifier(d.Table)
+		switch d.Kind {
+		case KindMissingTable:
+			up = append(up, d.definition)
+			down = append(down, fmt.Sprintf("DROP TABLE IF EXISTS %s;", table))
+		case KindMissingColumn:
+			up = append(up, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, quoteIdentifier(d.Column), d.definition))
+			down = append(down, fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;", table, quoteIdentifier(d.Column)))
+		case KindExtraColumn:
+			up = append(up, fmt.Sprintf("-- ALTER TABLE %s DROP COLUMN %s;", table, quoteIdentifier(d.Column)))
+		case KindTypeMismatch:
+			column := quoteIdentifier(d.Column)
+			up = append(up, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;", table, column, d.definition, column, d.definition))
+			down = append(down, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;", table, column, d.revert, column, d.revert))
+		case KindNullMismatch:
+			column := quoteIdentifier(d.Column)
+			set, drop := "SET NOT NULL", "DROP NOT NULL"
+			if d.Expected == "NULL" {
+				set, drop = drop, set
+			}
+			up = append(up, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;", table, column, set))
+			down = append(down, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;", table, column, drop))
+		case KindMissingIndex:
+			up = append(up, d.definition)
+			down = append(down, fmt.Sprintf("DROP INDEX IF EXISTS %s;", quoteIdentifier(d.Index)))
+		case KindExtraIndex:
+			up = append(up, fmt.Sprintf("-- DROP INDEX %s;", quoteIdentifier(d.Index)))
+		}
+	}
+
+	// Changes are undone in reverse order
+	for i, j := 0, len(down)-1; i < j; i, j = i+1, j-1 {
+		down[i], down[j] = down[j], down[i]
+	}
+	return joinStatements(up), joinStatements(down)
+}
+
+func joinStatements(statements []string) string {
+	if len(statements) == 0 {
+		return ""
+	}
+	return strings.Join(statements, "\n") + "\n"
+}
+
+// createTableSQL creates the table of a model with its indexes
+func createTableSQL(db *gorm.DB, s *schema.Schema, fields []*schema.Field) string {
+	migrator := db.Migrator()
+	var definitions, primaryKeys []string
+	for _, field := range fields {
+		definitions = append(definitions, fmt.Sprintf("    %s %s", quoteIdentifier(field.DBName), migrator.FullDataTypeOf(field).SQL))
+		if field.PrimaryKey {
+			primaryKeys = append(primaryKeys, quoteIdentifier(field.DBName))
+		}
+	}
+	if len(primaryKeys) > 0 {
+		definitions = append(definitions, fmt.Sprintf("    PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
+	}
+
+	statements := []string{fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n);", quoteIdentifier(s.Table), strings.Join(definitions, ",\n"))}
+	for _, parsed := range s.ParseIndexes() {
+		statements = append(statements, createIndexSQL(s.Table, modelIndex(parsed), parsed.Where))
+	}
+	return strings.Join(statements, "\n")
+}
+
+func createIndexSQL(table string, i index, where string) string {
+	columns := make([]string, len(i.Columns))
+	for n, column := range i.Columns {
+		if strings.ContainsAny(column, "( ") {
+			columns[n] = column
+		} else {
+			columns[n] = quoteIdentifier(column)
+		}
+	}
+
+	unique := ""
+	if i.Unique {
+		unique = "UNIQUE "
+	}
+	sql := fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)", unique, quoteIdentifier(i.Name), quoteIdentifier(table), strings.Join(columns, ", "))
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	return sql + ";"
+}
@@ -0,0 +1,358 @@
+// Package service bootstraps the HTTP services: it loads the shared
+// configuration and wires logging, metrics, tracing, health routes and the
+// common middleware, then runs the server with a graceful shutdown that
+// fails readiness while requests drain and closes resources in order.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/health"
+	"github.com/VincentArjuna/RexiErp/internal/shared/httpclient"
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+	"github.com/VincentArjuna/RexiErp/internal/shared/metrics"
+	"github.com/VincentArjuna/RexiErp/internal/shared/middleware"
+	"github.com/VincentArjuna/RexiErp/internal/shared/openapi"
+	"github.com/VincentArjuna/RexiErp/internal/shared/tracing"
+)
+
+// Options configures a Service
+type Options struct {
+	// Name identifies the service in logs, metrics and traces, e.g.
+	// "inventory-service"
+	Name string
+	// Port is used when neither PORT nor APP_PORT is set
+	Port int
+	// Config replaces the shared configuration, for services that extend it
+	Config *config.Config
+	// Middleware runs on every request after the common middleware,
+	// security headers and CORS
+	Middleware []gin.HandlerFunc
+	// PreMiddleware runs on every request before the common middleware,
+	// e.g. for the gateway to drop headers only it may set
+	PreMiddleware []gin.HandlerFunc
+}
+
+// closer releases a resource on shutdown
+type closer struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Service is a bootstrapped HTTP service. Domain routes go on API, or on
+// Router for routes outside /api/v1. Middleware added to Router after New
+// does not apply to API, since gin copies a group's middleware when the
+// group is created.
+type Service struct {
+	Name    string
+	Config  *config.Config
+	Logger  *logrus.Logger
+	Metrics *metrics.PrometheusMetrics
+	Health  *health.HealthChecker
+	// CORS answers preflights for every route, matched or not
+	CORS   *middleware.CORSMiddleware
+	Router *gin.Engine
+	API    *gin.RouterGroup
+
+	log     *logger.Logger
+	addr    string
+	handler http.Handler
+
+	mu      sync.Mutex
+	closers []closer
+
+	workers     sync.WaitGroup
+	workerCtx   context.Context
+	stopWorkers context.CancelFunc
+}
+
+// New creates a service. Metrics and tracing are only set up when enabled
+// in the configuration.
+func New(opts Options) (*Service, error) {
+	cfg := opts.Config
+	if cfg == nil {
+		var err error
+		if cfg, err = config.LoadConfig(); err != nil {
+			return nil, fmt.Errorf("failed to load configuration: %w", err)
+		}
+	}
+
+	log := logger.NewLogger(&logger.Config{
+		Level:       cfg.Log.Level,
+		Format:      cfg.Log.Format,
+		ServiceName: opts.Name,
+		Version:     cfg.App.Version,
+		Environment: cfg.App.Environment,
+	})
+
+	if cfg.IsProduction() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	s := &Service{
+		Name:        opts.Name,
+		Config:      cfg,
+		Logger:      log.Logger,
+		Health:      health.NewChecker(cfg, log.Logger),
+		CORS:        middleware.NewCORSMiddleware(cfg.CORS, log.Logger),
+		Router:      gin.New(),
+		log:         log,
+		addr:        ":" + listenPort(opts.Port, cfg.App.Port),
+		workerCtx:   workerCtx,
+		stopWorkers: stopWorkers,
+	}
+	s.handler = s.Router
+
+	if cfg.Tracing.Enabled {
+		s.initTracing()
+	}
+
+	// Probes and scrapes are registered before the middleware so they are
+	// neither logged nor counted
+	s.Health.RegisterRoutes(s.Router)
+	if cfg.Monitoring.Enabled {
+		s.Metrics = metrics.NewPrometheusMetrics(opts.Name)
+		if err := s.Metrics.Register(); err != nil {
+			return nil, fmt.Errorf("failed to register metrics: %w", err)
+		}
+		s.Router.GET(cfg.Monitoring.Path, metrics.MetricsHandler())
+	}
+
+	httpMiddleware := logger.NewHTTPMiddleware(log)
+	s.Router.Use(opts.PreMiddleware...)
+	s.Router.Use(httpMiddleware.CorrelationID())
+	s.Router.Use(httpMiddleware.RequestLogging())
+	s.Router.Use(recovery(log))
+	s.Router.Use(auditContext())
+	if s.Metrics != nil {
+		s.Router.Use(metrics.NewMetricsMiddleware(s.Metrics, log).HTTPMiddleware())
+	}
+	s.Router.Use(apperror.Middleware(log.Logger))
+	s.Router.Use(middleware.SecurityHeaders(cfg.Security))
+	s.Router.Use(s.CORS.Handler())
+	s.Router.Use(opts.Middleware...)
+
+	s.API = s.Router.Group("/api/v1")
+
+	// Validate requests, and in tests responses, against the published spec
+	if cfg.OpenAPI.SpecPath != "" {
+		contract, err := openapi.NewValidatorFromFile(cfg.OpenAPI.SpecPath, openapi.Options{
+			ValidateResponses: cfg.OpenAPI.ValidateResponses,
+		}, log.Logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load OpenAPI spec: %w", err)
+		}
+		s.API.Use(contract.Middleware())
+	}
+
+	return s, nil
+}
+
+func (s *Service) initTracing() {
+	tracingConfig := tracing.DefaultConfig(s.Name)
+	tracingConfig.AgentHost = s.Config.Tracing.AgentHost
+	tracingConfig.AgentPort = s.Config.Tracing.AgentPort
+	tracingConfig.SamplerType = "probabilistic"
+	tracingConfig.SamplerParam = s.Config.Tracing.SampleRate
+
+	_, tracerCloser, err := tracing.InitTracer(tracingConfig)
+	if err != nil {
+		s.Logger.WithError(err).Warn("Failed to initialize tracing, continuing without it")
+		return
+	}
+	s.handler = tracing.TraceMiddleware(s.Name)(s.Router)
+	s.OnShutdown("tracer", func(context.Context) error {
+		return tracerCloser.Close()
+	})
+}
+
+// OnShutdown registers a resource to release once the server has drained.
+// Closers run in reverse registration order, so a resource is closed
+// before the ones it was built on, e.g. a repository before its database.
+func (s *Service) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closers = append(s.closers, closer{name: name, fn: fn})
+}
+
+// Go runs a background worker. Its context is cancelled once the server has
+// drained, and shutdown waits for it before running the closers.
+func (s *Service) Go(name string, fn func(ctx context.Context)) {
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		fn(s.workerCtx)
+		s.Logger.WithField("worker", name).Debug("Background worker stopped")
+	}()
+}
+
+// HTTPClient returns a client for calls to another service, recording the
+// outcomes in this service's metrics
+func (s *Service) HTTPClient(target string) *httpclient.Client {
+	var recorder httpclient.CallRecorder
+	if s.Metrics != nil {
+		recorder = metrics.NewExternalServiceMiddleware(s.Metrics, s.log)
+	}
+	return httpclient.NewClient(httpclient.DefaultConfig(target), recorder, s.Logger)
+}
+
+// Run serves until SIGINT or SIGTERM and then shuts down gracefully
+func (s *Service) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves on listener until ctx is done and then shuts down gracefully
+func (s *Service) Serve(ctx context.Context, listener net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+
+	s.Logger.WithFields(logrus.Fields{
+		"service": s.Name,
+		"addr":    listener.Addr().String(),
+		"env":     s.Config.App.Environment,
+		"version": s.Config.App.Version,
+	}).Info("Service started")
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-serveErr:
+		// The listener failed; there is nothing left to drain
+		s.Logger.WithError(err).Error("Server stopped unexpectedly")
+	}
+
+	if shutdownErr := s.shutdown(srv, err == nil); err == nil {
+		err = shutdownErr
+	}
+	return err
+}
+
+// shutdown fails readiness, waits for load balancers to notice, drains the
+// server, stops background workers and finally runs the closers
+func (s *Service) shutdown(srv *http.Server, drain bool) error {
+	s.Health.SetDraining()
+	if drain && s.Config.Shutdown.DrainDelay > 0 {
+		s.Logger.WithField("drain_delay", s.Config.Shutdown.DrainDelay.String()).Info("Draining service")
+		time.Sleep(s.Config.Shutdown.DrainDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.Shutdown.Timeout)
+	defer cancel()
+
+	var errs []error
+	s.Logger.Info("Shutting down server")
+	if err := srv.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("server shutdown: %w", err))
+	}
+
+	s.stopWorkers()
+	workersDone := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		errs = append(errs, errors.New("background workers did not stop in time"))
+	}
+
+	s.mu.Lock()
+	closers := s.closers
+	s.mu.Unlock()
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].fn(ctx); err != nil {
+			s.Logger.WithError(err).WithField("resource", closers[i].name).Error("Failed to close resource")
+			errs = append(errs, fmt.Errorf("close %s: %w", closers[i].name, err))
+		}
+	}
+
+	s.Logger.WithField("service", s.Name).Info("Service stopped")
+	return errors.Join(errs...)
+}
+
+// recovery renders panics as the internal error envelope and logs them with
+// the request context
+func recovery(log *logger.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		ctx := c.Request.Context()
+		log.WithRequestContext(logger.GetCorrelationID(ctx), logger.GetTenantID(ctx), logger.GetUserID(ctx)).
+			WithFields(logrus.Fields{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+				"panic":  recovered,
+				"stack":  string(debug.Stack()),
+			}).Error("HTTP request panic recovered")
+
+		apperror.Abort(c, apperror.New(apperror.CodeInternal, fmt.Sprintf("panic: %v", recovered)))
+	})
+}
+
+// auditContext records the client of mutating requests for the audit trail.
+// The actor and tenant are added by the JWT middleware and the correlation
+// and trace IDs are read from the request context when a change is written.
+func auditContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		ctx := database.WithAuditContext(c.Request.Context(), database.AuditContext{
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// listenPort prefers PORT, then APP_PORT, then the service's own default
+func listenPort(defaultPort, configPort int) string {
+	for _, key := range []string{"PORT", "APP_PORT"} {
+		if port := os.Getenv(key); port != "" {
+			return port
+		}
+	}
+	if defaultPort > 0 {
+		return strconv.Itoa(defaultPort)
+	}
+	return strconv.Itoa(configPort)
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+	"github.com/VincentArjuna/RexiErp/internal/shared/config"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		App: config.AppConfig{Version: "1.0.0", Environment: "test"},
+		Log: config.LogConfig{Level: "error", Format: "json"},
+		Shutdown: config.ShutdownConfig{
+			DrainDelay: 200 * time.Millisecond,
+			Timeout:    5 * time.Second,
+		},
+	}
+}
+
+func newTestService(t *testing.T, cfg *config.Config) *Service {
+	gin.SetMode(gin.TestMode)
+	s, err := New(Options{Name: "test-service", Config: cfg})
+	require.NoError(t, err)
+	return s
+}
+
+func serve(t *testing.T, s *Service) (string, context.CancelFunc, <-chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, listener) }()
+	t.Cleanup(cancel)
+	return "http://" + listener.Addr().String(), cancel, done
+}
+
+func status(t *testing.T, url string) int {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestService_Middleware(t *testing.T) {
+	s := newTestService(t, testConfig())
+	s.API.GET("/panic", func(c *gin.Context) { panic("boom") })
+	s.API.GET("/missing", func(c *gin.Context) {
+		_ = c.Error(apperror.New(apperror.CodeNotFound, "widget not found"))
+	})
+
+	t.Run("recovers panics as the error envelope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/panic", nil)
+		req.Header.Set("X-Correlation-ID", "corr-123")
+		s.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "corr-123", w.Header().Get("X-Correlation-ID"))
+
+		var response apperror.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, apperror.CodeInternal, response.Code)
+		assert.Equal(t, "corr-123", response.CorrelationID)
+		assert.NotContains(t, w.Body.String(), "boom")
+	})
+
+	t.Run("renders handler errors and generates a correlation ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/missing", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NotEmpty(t, w.Header().Get("X-Correlation-ID"))
+	})
+}
+
+func TestService_PreMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dropTenant := func(c *gin.Context) {
+		c.Request.Header.Del("X-Tenant-ID")
+		c.Next()
+	}
+	s, err := New(Options{Name: "test-service", Config: testConfig(), PreMiddleware: []gin.HandlerFunc{dropTenant}})
+	require.NoError(t, err)
+	s.API.GET("/widgets", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/widgets", nil)
+	req.Header.Set("X-Tenant-ID", "spoofed")
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-Tenant-ID"), "the correlation middleware must not see the dropped header")
+}
+
+func TestService_AuditContext(t *testing.T) {
+	s := newTestService(t, testConfig())
+
+	var captured database.AuditContext
+	s.API.Any("/widgets", func(c *gin.Context) {
+		captured = database.AuditContextFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/widgets", nil)
+	req.Header.Set("X-Correlation-ID", "corr-123")
+	req.Header.Set("User-Agent", "rexi-test")
+	req.RemoteAddr = "10.0.0.1:4321"
+	s.Router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "10.0.0.1", captured.IPAddress)
+	assert.Equal(t, "rexi-test", captured.UserAgent)
+	assert.Equal(t, "corr-123", captured.CorrelationID)
+
+	captured = database.AuditContext{}
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/widgets", nil)
+	req.Header.Set("User-Agent", "rexi-test")
+	s.Router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Empty(t, captured.UserAgent, "reads are not audited")
+}
+
+func TestService_HealthRoutes(t *testing.T) {
+	s := newTestService(t, testConfig())
+
+	for _, path := range []string{"/health", "/health/live", "/health/ready"} {
+		w := httptest.NewRecorder()
+		s.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+
+	s.Health.AddCheck("redis", func(context.Context) error { return errors.New("connection refused") })
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "connection refused")
+}
+
+func TestService_Metrics(t *testing.T) {
+	cfg := testConfig()
+	cfg.Monitoring = config.MonitoringConfig{Enabled: true, Path: "/metrics"}
+	s := newTestService(t, cfg)
+	t.Cleanup(s.Metrics.Unregister)
+
+	s.API.GET("/widgets", func(c *gin.Context) { c.Status(http.StatusOK) })
+	s.Router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/widgets", nil))
+
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "http_requests_total")
+	assert.NotNil(t, s.HTTPClient("inventory-service"))
+}
+
+func TestService_GracefulShutdown(t *testing.T) {
+	s := newTestService(t, testConfig())
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	s.OnShutdown("database", func(context.Context) error { record("close database"); return nil })
+	s.OnShutdown("cache", func(context.Context) error { record("close cache"); return errors.New("already closed") })
+	s.Go("rollup", func(ctx context.Context) {
+		<-ctx.Done()
+		record("stop worker")
+	})
+
+	release := make(chan struct{})
+	s.API.GET("/slow", func(c *gin.Context) {
+		<-release
+		record("finish request")
+		c.Status(http.StatusOK)
+	})
+
+	url, cancel, done := serve(t, s)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url + "/health/ready")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, time.Second, 5*time.Millisecond)
+
+	slow := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url + "/api/v1/slow")
+		if err != nil {
+			slow <- 0
+			return
+		}
+		resp.Body.Close()
+		slow <- resp.StatusCode
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+
+	// While draining, readiness fails but the instance stays alive
+	require.Eventually(t, func() bool { return s.Health.IsDraining() }, time.Second, time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, status(t, url+"/health/ready"))
+	assert.Equal(t, http.StatusOK, status(t, url+"/health/live"))
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-slow, "in-flight requests complete")
+
+	err := <-done
+	assert.ErrorContains(t, err, "close cache: already closed")
+	assert.Equal(t, []string{"finish request", "stop worker", "close cache", "close database"}, events)
+}
+
+func TestListenPort(t *testing.T) {
+	t.Setenv("PORT", "")
+	t.Setenv("APP_PORT", "")
+	assert.Equal(t, "8002", listenPort(8002, 8000))
+	assert.Equal(t, "8000", listenPort(0, 8000))
+
+	t.Setenv("APP_PORT", "9001")
+	assert.Equal(t, "9001", listenPort(8002, 8000))
+
+	t.Setenv("PORT", "9002")
+	assert.Equal(t, "9002", listenPort(8002, 8000))
+}
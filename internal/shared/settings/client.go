@@ -0,0 +1,74 @@
+package settings
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Client reads settings for the tenant and user of the request context,
+// e.g. flags.Enabled(ctx, "ppn_12_percent"). Failures are logged and fall
+// back to the setting's default, so callers never handle errors.
+type Client struct {
+	service *Service
+	logger  *logrus.Logger
+}
+
+// NewClient creates a settings client
+func NewClient(service *Service, logger *logrus.Logger) *Client {
+	return &Client{service: service, logger: logger}
+}
+
+// Enabled reports whether a feature flag is on
+func (c *Client) Enabled(ctx context.Context, key string) bool {
+	v, _ := c.get(ctx, key, TypeBool).(bool)
+	return v
+}
+
+// Int returns an integer setting
+func (c *Client) Int(ctx context.Context, key string) int64 {
+	v, _ := c.get(ctx, key, TypeInt).(int64)
+	return v
+}
+
+// String returns a string setting
+func (c *Client) String(ctx context.Context, key string) string {
+	v, _ := c.get(ctx, key, TypeString).(string)
+	return v
+}
+
+// Duration returns a duration setting
+func (c *Client) Duration(ctx context.Context, key string) time.Duration {
+	v, _ := c.get(ctx, key, TypeDuration).(time.Duration)
+	return v
+}
+
+// Strings returns a list of strings setting
+func (c *Client) Strings(ctx context.Context, key string) []string {
+	v, _ := c.get(ctx, key, TypeStringList).([]string)
+	return v
+}
+
+func (c *Client) get(ctx context.Context, key string, typ Type) interface{} {
+	def, err := c.service.Definition(key)
+	if err != nil {
+		c.logger.WithError(err).Warn("Reading undefined setting")
+		return nil
+	}
+	if def.Type != typ {
+		c.logger.WithFields(logrus.Fields{
+			"key":  key,
+			"type": def.Type,
+			"read": typ,
+		}).Warn("Reading setting as the wrong type")
+		return nil
+	}
+
+	value, err := c.service.Resolve(ctx, key, SubjectFromContext(ctx))
+	if err != nil {
+		c.logger.WithError(err).WithField("key", key).Error("Failed to resolve setting, using default")
+		return def.Default
+	}
+	return value.Value
+}
@@ -0,0 +1,261 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/apperror"
+)
+
+// SetSettingRequest overrides a setting at a scope
+type SetSettingRequest struct {
+	Scope Scope `json:"scope" binding:"required,oneof=system plan tenant user"`
+	// ScopeID is the plan code or tenant or user ID; tenant admins may leave
+	// it empty for their own tenant
+	ScopeID string          `json:"scope_id,omitempty"`
+	Value   json.RawMessage `json:"value" binding:"required"`
+	// Rollout enables a flag for this percentage of tenants
+	Rollout *int `json:"rollout" binding:"omitempty,min=0,max=100"`
+}
+
+// Handler serves the settings administration API. Super admins manage
+// every scope; tenant admins manage the overrides of their own tenant for
+// tenant editable settings, without rollouts.
+type Handler struct {
+	service *Service
+	logger  *logrus.Logger
+}
+
+// NewHandler creates a new settings handler
+func NewHandler(service *Service, logger *logrus.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the settings routes. The effective settings are
+// served behind authenticated, the administration behind authenticated
+// followed by the admin guard.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authenticated, admin gin.HandlerFunc) {
+	router.GET("/settings/effective", authenticated, h.GetEffectiveSettings)
+
+	settings := router.Group("/settings")
+	settings.Use(authenticated, admin)
+	{
+		settings.GET("/definitions", h.ListDefinitions)
+		settings.GET("", h.ListSettings)
+		settings.PUT("/:key", h.SetSetting)
+		settings.DELETE("/:key", h.DeleteSetting)
+	}
+}
+
+// GetEffectiveSettings returns every setting as it applies to the caller
+// @Summary Get the caller's effective settings and feature flags
+// @Tags settings
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{success=bool,data=[]Value}
+// @Failure 401 {object} apperror.Response
+// @Router /settings/effective [get]
+func (h *Handler) GetEffectiveSettings(c *gin.Context) {
+	ctx := c.Request.Context()
+	values, err := h.service.ResolveAll(ctx, SubjectFromContext(ctx))
+	h.respond(c, values, err)
+}
+
+// ListDefinitions returns every setting with its type and default
+// @Summary List setting definitions
+// @Tags settings
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{success=bool,data=[]Definition}
+// @Failure 403 {object} apperror.Response
+// @Router /settings/definitions [get]
+func (h *Handler) ListDefinitions(c *gin.Context) {
+	h.respond(c, h.service.Definitions(), nil)
+}
+
+// ListSettings returns the setting overrides
+// @Summary List setting overrides
+// @Tags settings
+// @Produce json
+// @Security BearerAuth
+// @Param key query string false "Setting key"
+// @Param scope query string false "Scope: system, plan, tenant or user"
+// @Param scope_id query string false "Plan code or tenant or user ID"
+// @Success 200 {object} object{success=bool,data=[]Setting}
+// @Failure 403 {object} apperror.Response
+// @Router /settings [get]
+func (h *Handler) ListSettings(c *gin.Context) {
+	filter := Filter{
+		Key:     c.Query("key"),
+		Scope:   Scope(c.Query("scope")),
+		ScopeID: c.Query("scope_id"),
+	}
+	if !isSuperAdmin(c) {
+		scopeID, ok := h.tenantScope(c, ScopeTenant, filter.ScopeID)
+		if !ok {
+			return
+		}
+		if filter.Scope != "" && filter.Scope != ScopeTenant {
+			apperror.Respond(c, apperror.New(apperror.CodeInsufficientPermissions, "tenant admins only see tenant settings"))
+			return
+		}
+		filter.Scope = ScopeTenant
+		filter.ScopeID = scopeID
+	}
+
+	overrides, err := h.service.List(c.Request.Context(), filter)
+	h.respond(c, overrides, err)
+}
+
+// SetSetting creates or replaces a setting override
+// @Summary Override a setting
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "Setting key"
+// @Param request body SetSettingRequest true "Override"
+// @Success 200 {object} object{success=bool,data=Setting}
+// @Failure 400 {object} apperror.Response
+// @Failure 403 {object} apperror.Response
+// @Failure 404 {object} apperror.Response
+// @Router /settings/{key} [put]
+func (h *Handler) SetSetting(c *gin.Context) {
+	var req SetSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	scopeID, ok := h.tenantScope(c, req.Scope, req.ScopeID)
+	if !ok || !h.tenantEditable(c, c.Param("key")) {
+		return
+	}
+	if req.Rollout != nil && !isSuperAdmin(c) {
+		apperror.Respond(c, apperror.New(apperror.CodeInsufficientPermissions, "only super admins roll out flags"))
+		return
+	}
+
+	change := Change{
+		Scope:   req.Scope,
+		ScopeID: scopeID,
+		Value:   req.Value,
+		Rollout: req.Rollout,
+	}
+	if userID, ok := c.Get("user_id"); ok {
+		if id, ok := userID.(uuid.UUID); ok {
+			change.UpdatedBy = &id
+		}
+	}
+
+	setting, err := h.service.Set(c.Request.Context(), c.Param("key"), change)
+	h.respond(c, setting, err)
+}
+
+// DeleteSetting removes a setting override
+// @Summary Remove a setting override
+// @Tags settings
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "Setting key"
+// @Param scope query string true "Scope: system, plan, tenant or user"
+// @Param scope_id query string false "Plan code or tenant or user ID"
+// @Success 200 {object} object{success=bool,message=string}
+// @Failure 403 {object} apperror.Response
+// @Failure 404 {object} apperror.Response
+// @Router /settings/{key} [delete]
+func (h *Handler) DeleteSetting(c *gin.Context) {
+	scope := Scope(c.Query("scope"))
+	scopeID, ok := h.tenantScope(c, scope, c.Query("scope_id"))
+	if !ok || !h.tenantEditable(c, c.Param("key")) {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), c.Param("key"), scope, scopeID); err != nil {
+		h.respond(c, nil, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Setting override removed",
+	})
+}
+
+// tenantScope restricts tenant admins to the tenant scope of their own
+// tenant and fills in its ID
+func (h *Handler) tenantScope(c *gin.Context, scope Scope, scopeID string) (string, bool) {
+	if isSuperAdmin(c) {
+		return scopeID, true
+	}
+	if scope != ScopeTenant {
+		apperror.Respond(c, apperror.New(apperror.CodeInsufficientPermissions, "only super admins manage system, plan and user settings"))
+		return "", false
+	}
+
+	tenantID, _ := c.Get("tenant_id")
+	current, _ := tenantID.(uuid.UUID)
+	if scopeID == "" {
+		return current.String(), true
+	}
+	if id, err := uuid.Parse(scopeID); err != nil || id != current {
+		apperror.Respond(c, apperror.New(apperror.CodeCrossTenantAccess, "cannot manage another tenant's settings"))
+		return "", false
+	}
+	return scopeID, true
+}
+
+// tenantEditable restricts tenant admins to the settings that are tenant
+// editable
+func (h *Handler) tenantEditable(c *gin.Context, key string) bool {
+	if isSuperAdmin(c) {
+		return true
+	}
+	def, err := h.service.Definition(key)
+	if err != nil {
+		h.respond(c, nil, err)
+		return false
+	}
+	if !def.TenantEditable {
+		apperror.Respond(c, apperror.Newf(apperror.CodeInsufficientPermissions, "only super admins manage %s", key))
+		return false
+	}
+	return true
+}
+
+func (h *Handler) respond(c *gin.Context, data interface{}, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownSetting), errors.Is(err, ErrSettingNotFound):
+			apperror.Respond(c, apperror.Wrap(err, apperror.CodeSettingNotFound, "setting not found"))
+		case errors.Is(err, ErrInvalidScope):
+			apperror.Respond(c, apperror.Validation(apperror.Field("scope_id", "setting_scope", err.Error())))
+		case errors.Is(err, ErrInvalidValue):
+			apperror.Respond(c, apperror.Validation(apperror.Field("value", "setting_value", err.Error())))
+		default:
+			h.logger.WithFields(logrus.Fields{
+				"path":  c.FullPath(),
+				"error": err,
+			}).Error("Failed to process settings request")
+			apperror.Respond(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func isSuperAdmin(c *gin.Context) bool {
+	return c.GetString("user_role") == "super_admin"
+}
@@ -0,0 +1,110 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+)
+
+// handlerRequest returns a function that calls the settings API as a user
+// of the tenant with the given role
+func handlerRequest(s *Service, tenantID uuid.UUID) func(role, method, path, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	return func(role, method, path, body string) *httptest.ResponseRecorder {
+		identify := func(c *gin.Context) {
+			c.Set("tenant_id", tenantID)
+			c.Set("user_role", role)
+			c.Request = c.Request.WithContext(logger.WithTenantID(c.Request.Context(), tenantID.String()))
+		}
+		router := gin.New()
+		NewHandler(s, log).RegisterRoutes(router.Group("/api/v1"), identify, identify)
+
+		req := httptest.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+}
+
+func TestHandler_ScopesTenantAdmins(t *testing.T) {
+	s := newTestService(t, nil)
+	tenantID := uuid.New()
+	request := handlerRequest(s, tenantID)
+
+	w := request("tenant_admin", http.MethodPut, "/settings/ppn_12_percent", `{"scope":"tenant","value":true}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	overrides, err := s.List(context.Background(), Filter{Key: "ppn_12_percent"})
+	require.NoError(t, err)
+	require.Len(t, overrides, 1)
+	assert.Equal(t, tenantID.String(), overrides[0].ScopeID)
+
+	assert.Equal(t, http.StatusForbidden, request("tenant_admin", http.MethodPut, "/settings/ppn_12_percent", `{"scope":"system","value":true}`).Code)
+	assert.Equal(t, http.StatusForbidden, request("tenant_admin", http.MethodPut, "/settings/ppn_12_percent", `{"scope":"tenant","scope_id":"`+uuid.NewString()+`","value":true}`).Code)
+	assert.Equal(t, http.StatusForbidden, request("tenant_admin", http.MethodGet, "/settings?scope=system", "").Code)
+	assert.Equal(t, http.StatusBadRequest, request("super_admin", http.MethodPut, "/settings/ppn_12_percent", `{"scope":"system","value":"yes"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request("super_admin", http.MethodPut, "/settings/ppn_12_percent", `{"scope":"system","value":true,"rollout":150}`).Code)
+	assert.Equal(t, http.StatusNotFound, request("super_admin", http.MethodPut, "/settings/unknown", `{"scope":"system","value":true}`).Code)
+	assert.Equal(t, http.StatusOK, request("super_admin", http.MethodPut, "/settings/auth.password_reset_limit", `{"scope":"system","value":5}`).Code)
+
+	w = request("user", http.MethodGet, "/settings/effective", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data []struct {
+			Key    string          `json:"key"`
+			Value  json.RawMessage `json:"value"`
+			Source string          `json:"source"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	effective := make(map[string]string)
+	for _, value := range response.Data {
+		effective[value.Key] = string(value.Value) + "@" + value.Source
+	}
+	assert.Equal(t, "true@tenant", effective["ppn_12_percent"])
+	assert.Equal(t, "5@system", effective["auth.password_reset_limit"])
+	assert.Equal(t, `"30m0s"@default`, effective["session.idle_timeout"])
+
+	assert.Equal(t, http.StatusOK, request("tenant_admin", http.MethodDelete, "/settings/ppn_12_percent?scope=tenant", "").Code)
+	assert.Equal(t, http.StatusNotFound, request("tenant_admin", http.MethodDelete, "/settings/ppn_12_percent?scope=tenant", "").Code)
+}
+
+func TestHandler_TenantAdminsChangeOnlyTenantEditableSettings(t *testing.T) {
+	s := newTestService(t, nil)
+	tenantID := uuid.New()
+	request := handlerRequest(s, tenantID)
+
+	// The password reset limit guards every tenant's users, so only super
+	// admins change it, within its bounds
+	assert.Equal(t, http.StatusForbidden, request("tenant_admin", http.MethodPut, "/settings/auth.password_reset_limit", `{"scope":"tenant","value":1000000}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request("super_admin", http.MethodPut, "/settings/auth.password_reset_limit", `{"scope":"tenant","scope_id":"`+tenantID.String()+`","value":1000000}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request("super_admin", http.MethodPut, "/settings/auth.password_reset_limit", `{"scope":"system","value":0}`).Code)
+	require.Equal(t, http.StatusOK, request("super_admin", http.MethodPut, "/settings/auth.password_reset_limit", `{"scope":"tenant","scope_id":"`+tenantID.String()+`","value":10}`).Code)
+	assert.Equal(t, http.StatusForbidden, request("tenant_admin", http.MethodDelete, "/settings/auth.password_reset_limit?scope=tenant", "").Code)
+
+	// Tenant admins cannot roll out a flag, not even for their own tenant
+	assert.Equal(t, http.StatusForbidden, request("tenant_admin", http.MethodPut, "/settings/ppn_12_percent", `{"scope":"tenant","value":true,"rollout":50}`).Code)
+	assert.Equal(t, http.StatusNotFound, request("tenant_admin", http.MethodPut, "/settings/unknown", `{"scope":"tenant","value":true}`).Code)
+
+	overrides, err := s.List(context.Background(), Filter{Scope: ScopeTenant, ScopeID: tenantID.String()})
+	require.NoError(t, err)
+	require.Len(t, overrides, 1)
+	assert.Equal(t, "auth.password_reset_limit", overrides[0].Key)
+	assert.JSONEq(t, "10", string(overrides[0].Value))
+}
@@ -0,0 +1,49 @@
+package settings
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Setting overrides the value of a setting at a scope. ScopeID is empty for
+// system overrides, the plan code for plan overrides and the tenant or user
+// ID otherwise.
+type Setting struct {
+	ID      uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Key     string          `gorm:"type:varchar(100);not null;uniqueIndex:idx_settings_key_scope,priority:1" json:"key"`
+	Scope   Scope           `gorm:"type:varchar(20);not null;uniqueIndex:idx_settings_key_scope,priority:2" json:"scope"`
+	ScopeID string          `gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_settings_key_scope,priority:3" json:"scope_id,omitempty"`
+	Value   json.RawMessage `gorm:"type:jsonb;not null" json:"value"`
+	// Rollout enables a flag for this percentage of tenants, or of users
+	// outside a tenant; nil enables it for everyone in scope
+	Rollout   *int       `gorm:"type:smallint" json:"rollout,omitempty"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Setting
+func (Setting) TableName() string {
+	return "settings"
+}
+
+// Value is the effective value of a setting for a subject
+type Value struct {
+	Key   string      `json:"key"`
+	Type  Type        `json:"type"`
+	Value interface{} `json:"value"`
+	// Source is the scope of the override that applied, or "default"
+	Source  string `json:"source"`
+	Rollout *int   `json:"rollout,omitempty"`
+}
+
+// MarshalJSON renders durations the way they are stored, e.g. "15m0s"
+func (v Value) MarshalJSON() ([]byte, error) {
+	type plain Value
+	if duration, ok := v.Value.(time.Duration); ok {
+		v.Value = duration.String()
+	}
+	return json.Marshal(plain(v))
+}
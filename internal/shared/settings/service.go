@@ -0,0 +1,458 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/cache"
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
+)
+
+const (
+	// invalidationChannel carries the keys of changed settings to every
+	// instance
+	invalidationChannel = "settings:invalidate"
+	cachePrefix         = "settings:overrides:"
+	cacheTTL            = time.Hour
+	// localTTL bounds staleness if an invalidation message is lost
+	localTTL = time.Minute
+)
+
+// Subject is who a setting is resolved for
+type Subject struct {
+	TenantID uuid.UUID
+	UserID   uuid.UUID
+}
+
+// SubjectFromContext returns the tenant and user of the request context
+func SubjectFromContext(ctx context.Context) Subject {
+	var subject Subject
+	if id, err := uuid.Parse(logger.GetTenantID(ctx)); err == nil {
+		subject.TenantID = id
+	}
+	if id, err := uuid.Parse(logger.GetUserID(ctx)); err == nil {
+		subject.UserID = id
+	}
+	return subject
+}
+
+// Change is a new override of a setting
+type Change struct {
+	Scope   Scope
+	ScopeID string
+	Value   json.RawMessage
+	Rollout *int
+	// UpdatedBy is the user making the change
+	UpdatedBy *uuid.UUID
+}
+
+// Filter selects overrides; empty fields match everything
+type Filter struct {
+	Key     string
+	Scope   Scope
+	ScopeID string
+}
+
+// Service resolves settings through the system, plan, tenant and user
+// overrides and manages the overrides
+type Service struct {
+	db     *gorm.DB
+	cache  *cache.RedisCache
+	plans  subscription.PlanResolver
+	logger *logrus.Logger
+	now    func() time.Time
+
+	mu          sync.RWMutex
+	definitions map[string]Definition
+	local       map[string]localEntry
+}
+
+type localEntry struct {
+	overrides []Setting
+	expiresAt time.Time
+}
+
+// NewService creates a new settings service. The cache and plan resolver
+// are optional; without a plan resolver plan overrides do not apply.
+func NewService(db *database.Database, cache *cache.RedisCache, plans subscription.PlanResolver, logger *logrus.Logger) *Service {
+	return &Service{
+		db:          db.DB,
+		cache:       cache,
+		plans:       plans,
+		logger:      logger,
+		now:         time.Now,
+		definitions: make(map[string]Definition),
+		local:       make(map[string]localEntry),
+	}
+}
+
+// Define registers setting definitions
+func (s *Service) Define(definitions ...Definition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, def := range definitions {
+		if err := def.validate(); err != nil {
+			return err
+		}
+		if _, exists := s.definitions[def.Key]; exists {
+			return fmt.Errorf("setting %s is already defined", def.Key)
+		}
+		s.definitions[def.Key] = def
+	}
+	return nil
+}
+
+// Definition returns the definition of a setting
+func (s *Service) Definition(key string) (Definition, error) {
+	s.mu.RLock()
+	def, ok := s.definitions[key]
+	s.mu.RUnlock()
+	if !ok {
+		return Definition{}, fmt.Errorf("%w: %s", ErrUnknownSetting, key)
+	}
+	return def, nil
+}
+
+// Definitions returns every definition ordered by key
+func (s *Service) Definitions() []Definition {
+	s.mu.RLock()
+	definitions := make([]Definition, 0, len(s.definitions))
+	for _, def := range s.definitions {
+		definitions = append(definitions, def)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Key < definitions[j].Key })
+	return definitions
+}
+
+// Resolve returns the value of a setting for a subject. The most specific
+// override wins: user, tenant, plan, then system. A flag override with a
+// rollout is enabled only for the subjects that fall into its percentage.
+func (s *Service) Resolve(ctx context.Context, key string, subject Subject) (*Value, error) {
+	def, err := s.Definition(key)
+	if err != nil {
+		return nil, err
+	}
+
+	value := &Value{Key: key, Type: def.Type, Value: def.Default, Source: SourceDefault}
+
+	overrides, err := s.overrides(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(overrides) == 0 {
+		return value, nil
+	}
+
+	scopeIDs := s.scopeIDs(ctx, subject)
+	for _, scope := range precedence {
+		id, ok := scopeIDs[scope]
+		if !ok {
+			continue
+		}
+		for _, override := range overrides {
+			if override.Scope != scope || override.ScopeID != id {
+				continue
+			}
+			decoded, err := def.Decode(override.Value)
+			if err != nil {
+				return nil, err
+			}
+			if enabled, isFlag := decoded.(bool); isFlag && enabled && override.Rollout != nil {
+				decoded = inRollout(key, subject, *override.Rollout)
+			}
+			value.Value = decoded
+			value.Source = string(scope)
+			value.Rollout = override.Rollout
+			return value, nil
+		}
+	}
+	return value, nil
+}
+
+// ResolveAll returns the value of every setting for a subject
+func (s *Service) ResolveAll(ctx context.Context, subject Subject) ([]*Value, error) {
+	definitions := s.Definitions()
+	values := make([]*Value, 0, len(definitions))
+	for _, def := range definitions {
+		value, err := s.Resolve(ctx, def.Key, subject)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// List returns the overrides matching the filter
+func (s *Service) List(ctx context.Context, filter Filter) ([]Setting, error) {
+	query := s.db.WithContext(ctx).Model(&Setting{})
+	if filter.Key != "" {
+		query = query.Where("key = ?", filter.Key)
+	}
+	if filter.Scope != "" {
+		query = query.Where("scope = ?", filter.Scope)
+	}
+	if filter.ScopeID != "" {
+		query = query.Where("scope_id = ?", filter.ScopeID)
+	}
+
+	var overrides []Setting
+	if err := query.Order("key, scope, scope_id").Find(&overrides).Error; err != nil {
+		return nil, fmt.Errorf("failed to list settings: %w", err)
+	}
+	return overrides, nil
+}
+
+// Set creates or replaces the override of a setting at a scope
+func (s *Service) Set(ctx context.Context, key string, change Change) (*Setting, error) {
+	def, err := s.Definition(key)
+	if err != nil {
+		return nil, err
+	}
+	scopeID, err := normalizeScopeID(change.Scope, change.ScopeID)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := def.Decode(change.Value)
+	if err != nil {
+		return nil, err
+	}
+	value, err := def.Encode(decoded)
+	if err != nil {
+		return nil, err
+	}
+	if change.Rollout != nil {
+		if def.Type != TypeBool {
+			return nil, fmt.Errorf("%w: only flags can be rolled out", ErrInvalidValue)
+		}
+		if *change.Rollout < 0 || *change.Rollout > 100 {
+			return nil, fmt.Errorf("%w: rollout must be between 0 and 100", ErrInvalidValue)
+		}
+	}
+
+	var setting Setting
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("key = ? AND scope = ? AND scope_id = ?", key, change.Scope, scopeID).First(&setting).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		setting.Value = value
+		setting.Rollout = change.Rollout
+		setting.UpdatedBy = change.UpdatedBy
+		if setting.ID != uuid.Nil {
+			return tx.Save(&setting).Error
+		}
+
+		setting.ID = uuid.New()
+		setting.Key = key
+		setting.Scope = change.Scope
+		setting.ScopeID = scopeID
+		return tx.Create(&setting).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save setting %s: %w", key, err)
+	}
+
+	s.invalidate(ctx, key)
+	s.logger.WithFields(logrus.Fields{
+		"key":      key,
+		"scope":    change.Scope,
+		"scope_id": scopeID,
+		"rollout":  change.Rollout,
+	}).Info("Setting changed")
+	return &setting, nil
+}
+
+// Delete removes the override of a setting at a scope
+func (s *Service) Delete(ctx context.Context, key string, scope Scope, scopeID string) error {
+	if _, err := s.Definition(key); err != nil {
+		return err
+	}
+	scopeID, err := normalizeScopeID(scope, scopeID)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).
+		Where("key = ? AND scope = ? AND scope_id = ?", key, scope, scopeID).
+		Delete(&Setting{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete setting %s: %w", key, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSettingNotFound
+	}
+
+	s.invalidate(ctx, key)
+	s.logger.WithFields(logrus.Fields{
+		"key":      key,
+		"scope":    scope,
+		"scope_id": scopeID,
+	}).Info("Setting override removed")
+	return nil
+}
+
+// Listen drops locally cached overrides when any instance changes them,
+// until ctx is done
+func (s *Service) Listen(ctx context.Context) {
+	if s.cache == nil {
+		return
+	}
+
+	pubsub := s.cache.Subscribe(ctx, invalidationChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			s.forget(msg.Payload)
+		}
+	}
+}
+
+// overrides returns every override of a key from the local cache, Redis or
+// the database
+func (s *Service) overrides(ctx context.Context, key string) ([]Setting, error) {
+	s.mu.RLock()
+	entry, ok := s.local[key]
+	s.mu.RUnlock()
+	if ok && s.now().Before(entry.expiresAt) {
+		return entry.overrides, nil
+	}
+
+	var overrides []Setting
+	cached := false
+	if s.cache != nil {
+		if err := s.cache.Get(ctx, cachePrefix+key, &overrides); err == nil {
+			cached = true
+		} else if !isCacheMiss(err) {
+			s.logger.WithError(err).Warn("Failed to read cached settings")
+		}
+	}
+
+	if !cached {
+		if err := s.db.WithContext(ctx).Where("key = ?", key).Find(&overrides).Error; err != nil {
+			return nil, fmt.Errorf("failed to load setting %s: %w", key, err)
+		}
+		if s.cache != nil {
+			if err := s.cache.Set(ctx, cachePrefix+key, overrides, cacheTTL); err != nil {
+				s.logger.WithError(err).Warn("Failed to cache settings")
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.local[key] = localEntry{overrides: overrides, expiresAt: s.now().Add(localTTL)}
+	s.mu.Unlock()
+	return overrides, nil
+}
+
+// invalidate drops the cached overrides of key here, in Redis and, through
+// pub/sub, on every other instance
+func (s *Service) invalidate(ctx context.Context, key string) {
+	s.forget(key)
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Delete(ctx, cachePrefix+key); err != nil {
+		s.logger.WithError(err).Warn("Failed to invalidate cached settings")
+	}
+	if err := s.cache.Publish(ctx, invalidationChannel, key); err != nil {
+		s.logger.WithError(err).Warn("Failed to publish settings invalidation")
+	}
+}
+
+func (s *Service) forget(key string) {
+	s.mu.Lock()
+	delete(s.local, key)
+	s.mu.Unlock()
+}
+
+// scopeIDs returns the scope IDs that apply to a subject
+func (s *Service) scopeIDs(ctx context.Context, subject Subject) map[Scope]string {
+	ids := map[Scope]string{ScopeSystem: ""}
+	if subject.UserID != uuid.Nil {
+		ids[ScopeUser] = subject.UserID.String()
+	}
+	if subject.TenantID != uuid.Nil {
+		ids[ScopeTenant] = subject.TenantID.String()
+		if s.plans != nil {
+			plan, err := s.plans.ResolvePlan(ctx, subject.TenantID)
+			if err != nil {
+				s.logger.WithError(err).WithField("tenant_id", subject.TenantID).Warn("Failed to resolve plan for settings")
+			} else {
+				ids[ScopePlan] = strings.ToLower(plan.PlanCode)
+			}
+		}
+	}
+	return ids
+}
+
+// inRollout places the subject in a stable bucket per flag, so that raising
+// the percentage only adds subjects. Tenants are bucketed as a whole.
+func inRollout(key string, subject Subject, percentage int) bool {
+	if percentage >= 100 {
+		return true
+	}
+	unit := subject.TenantID
+	if unit == uuid.Nil {
+		unit = subject.UserID
+	}
+	if unit == uuid.Nil || percentage <= 0 {
+		return false
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + unit.String()))
+	return int(h.Sum32()%100) < percentage
+}
+
+func normalizeScopeID(scope Scope, scopeID string) (string, error) {
+	scopeID = strings.TrimSpace(scopeID)
+	switch scope {
+	case ScopeSystem:
+		if scopeID != "" {
+			return "", fmt.Errorf("%w: system settings have no scope ID", ErrInvalidScope)
+		}
+		return "", nil
+	case ScopePlan:
+		if scopeID == "" {
+			return "", fmt.Errorf("%w: plan settings need a plan code", ErrInvalidScope)
+		}
+		return strings.ToLower(scopeID), nil
+	case ScopeTenant, ScopeUser:
+		id, err := uuid.Parse(scopeID)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s settings need a %s ID", ErrInvalidScope, scope, scope)
+		}
+		return id.String(), nil
+	}
+	return "", fmt.Errorf("%w: unknown scope %q", ErrInvalidScope, scope)
+}
+
+func isCacheMiss(err error) bool {
+	return strings.Contains(err.Error(), "key not found")
+}
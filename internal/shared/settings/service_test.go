@@ -0,0 +1,196 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+	"github.com/VincentArjuna/RexiErp/internal/shared/logger"
+	"github.com/VincentArjuna/RexiErp/internal/shared/subscription"
+)
+
+type fixedPlans map[uuid.UUID]string
+
+func (p fixedPlans) ResolvePlan(_ context.Context, tenantID uuid.UUID) (*subscription.TenantPlan, error) {
+	return &subscription.TenantPlan{PlanCode: p[tenantID]}, nil
+}
+
+func newTestService(t *testing.T, plans subscription.PlanResolver) *Service {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.Exec(`CREATE TABLE settings (
+		id TEXT PRIMARY KEY,
+		key TEXT NOT NULL,
+		scope TEXT NOT NULL,
+		scope_id TEXT NOT NULL DEFAULT '',
+		value TEXT NOT NULL,
+		rollout INTEGER,
+		updated_by TEXT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		UNIQUE (key, scope, scope_id)
+	)`).Error)
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	service := NewService(&database.Database{DB: db}, nil, plans, log)
+	require.NoError(t, service.Define(
+		Bool("ppn_12_percent", false, "Charge PPN at 12%").EditableByTenants(),
+		Int("auth.password_reset_limit", 3, "Password resets per hour").Between(1, 20),
+		Duration("session.idle_timeout", 30*time.Minute, "Idle session timeout"),
+		StringList("cors.origins", []string{"https://rexi-erp.com"}, "Allowed origins"),
+	))
+	return service
+}
+
+func set(t *testing.T, s *Service, key string, scope Scope, scopeID string, value interface{}) {
+	raw, err := json.Marshal(value)
+	require.NoError(t, err)
+	_, err = s.Set(context.Background(), key, Change{Scope: scope, ScopeID: scopeID, Value: raw})
+	require.NoError(t, err)
+}
+
+func TestService_ResolvesMostSpecificScope(t *testing.T) {
+	tenantID := uuid.New()
+	userID := uuid.New()
+	s := newTestService(t, fixedPlans{tenantID: "professional"})
+	ctx := context.Background()
+	subject := Subject{TenantID: tenantID, UserID: userID}
+
+	value, err := s.Resolve(ctx, "auth.password_reset_limit", subject)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), value.Value)
+	assert.Equal(t, SourceDefault, value.Source)
+
+	set(t, s, "auth.password_reset_limit", ScopeSystem, "", 5)
+	set(t, s, "auth.password_reset_limit", ScopePlan, "Professional", 10)
+	value, err = s.Resolve(ctx, "auth.password_reset_limit", subject)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), value.Value)
+	assert.Equal(t, "plan", value.Source)
+
+	set(t, s, "auth.password_reset_limit", ScopeTenant, tenantID.String(), 20)
+	set(t, s, "auth.password_reset_limit", ScopeUser, userID.String(), 1)
+	value, err = s.Resolve(ctx, "auth.password_reset_limit", subject)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value.Value)
+
+	value, err = s.Resolve(ctx, "auth.password_reset_limit", Subject{TenantID: tenantID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), value.Value)
+
+	value, err = s.Resolve(ctx, "auth.password_reset_limit", Subject{TenantID: uuid.New()})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), value.Value)
+	assert.Equal(t, "system", value.Source)
+
+	require.NoError(t, s.Delete(ctx, "auth.password_reset_limit", ScopeUser, userID.String()))
+	value, err = s.Resolve(ctx, "auth.password_reset_limit", subject)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), value.Value)
+
+	assert.ErrorIs(t, s.Delete(ctx, "auth.password_reset_limit", ScopeUser, userID.String()), ErrSettingNotFound)
+}
+
+func TestService_RolloutIsStableAndProportional(t *testing.T) {
+	s := newTestService(t, nil)
+	ctx := context.Background()
+
+	rollout := 30
+	_, err := s.Set(ctx, "ppn_12_percent", Change{Scope: ScopeSystem, Value: json.RawMessage("true"), Rollout: &rollout})
+	require.NoError(t, err)
+
+	enabled := 0
+	tenants := make([]uuid.UUID, 1000)
+	for i := range tenants {
+		tenants[i] = uuid.New()
+		value, err := s.Resolve(ctx, "ppn_12_percent", Subject{TenantID: tenants[i]})
+		require.NoError(t, err)
+		if value.Value.(bool) {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 300, enabled, 60)
+
+	// Raising the rollout keeps every tenant that already had the flag
+	before := make(map[uuid.UUID]bool)
+	for _, id := range tenants {
+		before[id] = inRollout("ppn_12_percent", Subject{TenantID: id}, 30)
+	}
+	for _, id := range tenants {
+		if before[id] {
+			assert.True(t, inRollout("ppn_12_percent", Subject{TenantID: id}, 60))
+		}
+	}
+
+	assert.False(t, inRollout("ppn_12_percent", Subject{}, 99))
+	assert.True(t, inRollout("ppn_12_percent", Subject{}, 100))
+}
+
+func TestService_ValidatesChanges(t *testing.T) {
+	s := newTestService(t, nil)
+	ctx := context.Background()
+	rollout := 50
+
+	tests := []struct {
+		name   string
+		key    string
+		change Change
+		err    error
+	}{
+		{"unknown key", "nope", Change{Scope: ScopeSystem, Value: json.RawMessage("1")}, ErrUnknownSetting},
+		{"wrong type", "auth.password_reset_limit", Change{Scope: ScopeSystem, Value: json.RawMessage(`"many"`)}, ErrInvalidValue},
+		{"fractional int", "auth.password_reset_limit", Change{Scope: ScopeSystem, Value: json.RawMessage("2.5")}, ErrInvalidValue},
+		{"bad duration", "session.idle_timeout", Change{Scope: ScopeSystem, Value: json.RawMessage(`"soon"`)}, ErrInvalidValue},
+		{"mixed list", "cors.origins", Change{Scope: ScopeSystem, Value: json.RawMessage(`["a", 1]`)}, ErrInvalidValue},
+		{"rollout of a non flag", "auth.password_reset_limit", Change{Scope: ScopeSystem, Value: json.RawMessage("1"), Rollout: &rollout}, ErrInvalidValue},
+		{"system with scope ID", "ppn_12_percent", Change{Scope: ScopeSystem, ScopeID: "x", Value: json.RawMessage("true")}, ErrInvalidScope},
+		{"tenant without ID", "ppn_12_percent", Change{Scope: ScopeTenant, Value: json.RawMessage("true")}, ErrInvalidScope},
+		{"unknown scope", "ppn_12_percent", Change{Scope: "region", Value: json.RawMessage("true")}, ErrInvalidScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Set(ctx, tt.key, tt.change)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestClient_ReadsTypedValuesForTheRequest(t *testing.T) {
+	tenantID := uuid.New()
+	s := newTestService(t, nil)
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	flags := NewClient(s, log)
+
+	ctx := logger.WithTenantID(context.Background(), tenantID.String())
+	assert.False(t, flags.Enabled(ctx, "ppn_12_percent"))
+
+	set(t, s, "ppn_12_percent", ScopeTenant, tenantID.String(), true)
+	set(t, s, "session.idle_timeout", ScopeSystem, "", "45m")
+	assert.True(t, flags.Enabled(ctx, "ppn_12_percent"))
+	assert.False(t, flags.Enabled(context.Background(), "ppn_12_percent"))
+	assert.Equal(t, 45*time.Minute, flags.Duration(ctx, "session.idle_timeout"))
+	assert.Equal(t, []string{"https://rexi-erp.com"}, flags.Strings(ctx, "cors.origins"))
+	assert.Equal(t, int64(3), flags.Int(ctx, "auth.password_reset_limit"))
+
+	// Undefined keys and type mismatches read as the zero value
+	assert.False(t, flags.Enabled(ctx, "auth.password_reset_limit"))
+	assert.Empty(t, flags.String(ctx, "missing"))
+}
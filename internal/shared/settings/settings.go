@@ -0,0 +1,212 @@
+// Package settings provides typed settings and feature flags that can be
+// overridden per plan, tenant and user, with percentage rollouts and a
+// Redis cached read path invalidated over pub/sub.
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Type is the value type of a setting
+type Type string
+
+const (
+	TypeBool       Type = "bool"
+	TypeInt        Type = "int"
+	TypeString     Type = "string"
+	TypeDuration   Type = "duration"
+	TypeStringList Type = "string_list"
+)
+
+// Scope is the level a setting is overridden at
+type Scope string
+
+const (
+	ScopeSystem Scope = "system"
+	ScopePlan   Scope = "plan"
+	ScopeTenant Scope = "tenant"
+	ScopeUser   Scope = "user"
+)
+
+// SourceDefault is reported as the source of a value that is not overridden
+const SourceDefault = "default"
+
+// precedence lists the scopes from the most to the least specific
+var precedence = []Scope{ScopeUser, ScopeTenant, ScopePlan, ScopeSystem}
+
+var (
+	// ErrUnknownSetting is returned for a key without a definition
+	ErrUnknownSetting = errors.New("unknown setting")
+	// ErrInvalidValue is returned for a value that does not match the
+	// setting's type
+	ErrInvalidValue = errors.New("invalid setting value")
+	// ErrInvalidScope is returned for an unknown scope or a scope ID that
+	// does not fit the scope
+	ErrInvalidScope = errors.New("invalid setting scope")
+	// ErrSettingNotFound is returned when an override does not exist
+	ErrSettingNotFound = errors.New("setting not found")
+)
+
+// Definition declares a setting, its type and its default value. Feature
+// flags are bool settings. Only super admins change a setting unless it is
+// tenant editable.
+type Definition struct {
+	Key         string      `json:"key"`
+	Type        Type        `json:"type"`
+	Default     interface{} `json:"default"`
+	Description string      `json:"description"`
+	// TenantEditable lets tenant admins override the setting for their own
+	// tenant
+	TenantEditable bool `json:"tenant_editable"`
+	// Min and Max bound the values of an int setting
+	Min *int64 `json:"min,omitempty"`
+	Max *int64 `json:"max,omitempty"`
+}
+
+// Bool declares a feature flag
+func Bool(key string, def bool, description string) Definition {
+	return Definition{Key: key, Type: TypeBool, Default: def, Description: description}
+}
+
+// Int declares an integer setting
+func Int(key string, def int64, description string) Definition {
+	return Definition{Key: key, Type: TypeInt, Default: def, Description: description}
+}
+
+// String declares a string setting
+func String(key string, def string, description string) Definition {
+	return Definition{Key: key, Type: TypeString, Default: def, Description: description}
+}
+
+// Duration declares a duration setting, stored as e.g. "15m"
+func Duration(key string, def time.Duration, description string) Definition {
+	return Definition{Key: key, Type: TypeDuration, Default: def, Description: description}
+}
+
+// StringList declares a list of strings setting
+func StringList(key string, def []string, description string) Definition {
+	return Definition{Key: key, Type: TypeStringList, Default: def, Description: description}
+}
+
+// EditableByTenants lets tenant admins override the setting for their own
+// tenant
+func (d Definition) EditableByTenants() Definition {
+	d.TenantEditable = true
+	return d
+}
+
+// Between bounds the values of an int setting, both ends included
+func (d Definition) Between(min, max int64) Definition {
+	d.Min, d.Max = &min, &max
+	return d
+}
+
+// MarshalJSON renders a duration default the way it is stored
+func (d Definition) MarshalJSON() ([]byte, error) {
+	type plain Definition
+	if duration, ok := d.Default.(time.Duration); ok {
+		d.Default = duration.String()
+	}
+	return json.Marshal(plain(d))
+}
+
+// Decode parses a stored JSON value into the setting's Go type: bool,
+// int64, string, time.Duration or []string
+func (d Definition) Decode(raw json.RawMessage) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w: %s is not valid JSON", ErrInvalidValue, d.Key)
+	}
+
+	switch d.Type {
+	case TypeBool:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case TypeInt:
+		if n, ok := value.(json.Number); ok {
+			if v, err := n.Int64(); err == nil {
+				return d.bounded(v)
+			}
+			if f, err := n.Float64(); err == nil && f == math.Trunc(f) {
+				return d.bounded(int64(f))
+			}
+		}
+	case TypeString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case TypeDuration:
+		if s, ok := value.(string); ok {
+			if v, err := time.ParseDuration(s); err == nil {
+				return v, nil
+			}
+		}
+	case TypeStringList:
+		if items, ok := value.([]interface{}); ok {
+			list := make([]string, 0, len(items))
+			for _, item := range items {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("%w: %s must be a list of strings", ErrInvalidValue, d.Key)
+				}
+				list = append(list, s)
+			}
+			return list, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s must be a %s", ErrInvalidValue, d.Key, d.Type)
+}
+
+// bounded checks an int value against Min and Max
+func (d Definition) bounded(v int64) (interface{}, error) {
+	if d.Min != nil && v < *d.Min {
+		return nil, fmt.Errorf("%w: %s must be at least %d", ErrInvalidValue, d.Key, *d.Min)
+	}
+	if d.Max != nil && v > *d.Max {
+		return nil, fmt.Errorf("%w: %s must be at most %d", ErrInvalidValue, d.Key, *d.Max)
+	}
+	return v, nil
+}
+
+// Encode returns the JSON form of a value of the setting's Go type
+func (d Definition) Encode(value interface{}) (json.RawMessage, error) {
+	if duration, ok := value.(time.Duration); ok {
+		value = duration.String()
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", d.Key, err)
+	}
+	if _, err := d.Decode(raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// validate checks that the definition is usable
+func (d Definition) validate() error {
+	if d.Key == "" {
+		return errors.New("setting key is required")
+	}
+	switch d.Type {
+	case TypeBool, TypeInt, TypeString, TypeDuration, TypeStringList:
+	default:
+		return fmt.Errorf("setting %s has unknown type %q", d.Key, d.Type)
+	}
+	if (d.Min != nil || d.Max != nil) && d.Type != TypeInt {
+		return fmt.Errorf("setting %s is not an int and cannot be bounded", d.Key)
+	}
+	if _, err := d.Encode(d.Default); err != nil {
+		return fmt.Errorf("setting %s has an invalid default: %w", d.Key, err)
+	}
+	return nil
+}
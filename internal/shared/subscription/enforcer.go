@@ -0,0 +1,259 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// TenantPlan describes the plan a tenant is subscribed to
+type TenantPlan struct {
+	PlanCode string
+	// MaxUsers raises the plan user limit for tenants on a custom deal
+	MaxUsers int
+}
+
+// PlanResolver resolves the plan of a tenant
+type PlanResolver interface {
+	ResolvePlan(ctx context.Context, tenantID uuid.UUID) (*TenantPlan, error)
+}
+
+// PlanResolverFunc adapts a function to the PlanResolver interface
+type PlanResolverFunc func(ctx context.Context, tenantID uuid.UUID) (*TenantPlan, error)
+
+// ResolvePlan calls f(ctx, tenantID)
+func (f PlanResolverFunc) ResolvePlan(ctx context.Context, tenantID uuid.UUID) (*TenantPlan, error) {
+	return f(ctx, tenantID)
+}
+
+// Enforcer checks operations against tenant plan limits and meters usage
+type Enforcer struct {
+	catalogue *Catalogue
+	resolver  PlanResolver
+	store     UsageStore
+	location  *time.Location
+	logger    *logrus.Logger
+	now       func() time.Time
+}
+
+// NewEnforcer creates a new plan limit enforcer. Usage periods follow the
+// given location so that daily and monthly limits reset at local midnight.
+func NewEnforcer(catalogue *Catalogue, resolver PlanResolver, store UsageStore, location *time.Location, logger *logrus.Logger) *Enforcer {
+	if location == nil {
+		location = time.UTC
+	}
+	return &Enforcer{
+		catalogue: catalogue,
+		resolver:  resolver,
+		store:     store,
+		location:  location,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Catalogue returns the plan catalogue used by the enforcer
+func (e *Enforcer) Catalogue() *Catalogue {
+	return e.catalogue
+}
+
+// Location returns the location usage periods are computed in
+func (e *Enforcer) Location() *time.Location {
+	return e.location
+}
+
+// Limits returns the plan and effective limits of a tenant
+func (e *Enforcer) Limits(ctx context.Context, tenantID uuid.UUID) (*Plan, Limits, error) {
+	tenantPlan, err := e.resolver.ResolvePlan(ctx, tenantID)
+	if err != nil {
+		return nil, Limits{}, fmt.Errorf("failed to resolve tenant plan: %w", err)
+	}
+
+	plan := e.catalogue.Resolve(tenantPlan.PlanCode)
+	limits := plan.Limits
+	if limits.MaxUsers != Unlimited && int64(tenantPlan.MaxUsers) > limits.MaxUsers {
+		limits.MaxUsers = int64(tenantPlan.MaxUsers)
+	}
+
+	return plan, limits, nil
+}
+
+// CheckCount verifies that adding requested items to a resource counted by the
+// caller (e.g. users or warehouses) stays within the plan limit
+func (e *Enforcer) CheckCount(ctx context.Context, tenantID uuid.UUID, resource Resource, current, requested int64) error {
+	plan, limits, err := e.Limits(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	return e.checkCount(tenantID, plan, limits.Get(resource), resource, current, requested)
+}
+
+// CheckPlanCount is CheckCount for a tenant that is not stored yet, such as
+// one being provisioned, so its plan cannot be resolved
+func (e *Enforcer) CheckPlanCount(tenantID uuid.UUID, planCode string, resource Resource, current, requested int64) error {
+	plan := e.catalogue.Resolve(planCode)
+	return e.checkCount(tenantID, plan, plan.Limits.Get(resource), resource, current, requested)
+}
+
+func (e *Enforcer) checkCount(tenantID uuid.UUID, plan *Plan, limit int64, resource Resource, current, requested int64) error {
+	if limit == Unlimited || current+requested <= limit {
+		return nil
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"tenant_id": tenantID,
+		"plan":      plan.Code,
+		"resource":  resource,
+		"limit":     limit,
+		"current":   current,
+		"requested": requested,
+	}).Info("Plan limit exceeded")
+
+	return &LimitExceededError{
+		TenantID:  tenantID,
+		Plan:      plan.Code,
+		Resource:  resource,
+		Limit:     limit,
+		Current:   current,
+		Requested: requested,
+	}
+}
+
+// Consume meters amount units of a metered resource. When the plan limit
+// would be exceeded nothing is consumed and a LimitExceededError is returned.
+func (e *Enforcer) Consume(ctx context.Context, tenantID uuid.UUID, resource Resource, amount int64) error {
+	if !resource.Metered() {
+		return fmt.Errorf("resource %s is not metered", resource)
+	}
+
+	plan, limits, err := e.Limits(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	at := e.now().In(e.location)
+	total, err := e.store.Increment(ctx, tenantID, resource, amount, at)
+	if err != nil {
+		return err
+	}
+
+	limit := limits.Get(resource)
+	if limit == Unlimited || total <= limit {
+		return nil
+	}
+
+	// Roll back the increment so rejected operations are not metered
+	if _, err := e.store.Increment(ctx, tenantID, resource, -amount, at); err != nil {
+		e.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"resource":  resource,
+			"error":     err,
+		}).Error("Failed to roll back usage counter")
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"tenant_id": tenantID,
+		"plan":      plan.Code,
+		"resource":  resource,
+		"limit":     limit,
+		"current":   total - amount,
+		"requested": amount,
+	}).Info("Plan limit exceeded")
+
+	return &LimitExceededError{
+		TenantID:  tenantID,
+		Plan:      plan.Code,
+		Resource:  resource,
+		Limit:     limit,
+		Current:   total - amount,
+		Requested: amount,
+	}
+}
+
+// Release returns amount units of a metered resource, e.g. when a stored
+// file is deleted
+func (e *Enforcer) Release(ctx context.Context, tenantID uuid.UUID, resource Resource, amount int64) error {
+	if !resource.Metered() {
+		return fmt.Errorf("resource %s is not metered", resource)
+	}
+
+	_, err := e.store.Increment(ctx, tenantID, resource, -amount, e.now().In(e.location))
+	return err
+}
+
+// UsageItem represents the usage of a single resource against its limit
+type UsageItem struct {
+	Resource    Resource `json:"resource"`
+	Period      Period   `json:"period"`
+	Used        int64    `json:"used"`
+	Limit       int64    `json:"limit"`
+	Remaining   int64    `json:"remaining"`
+	PercentUsed float64  `json:"percent_used"`
+}
+
+// UsageReport represents a tenant's usage against its plan limits
+type UsageReport struct {
+	TenantID    uuid.UUID   `json:"tenant_id"`
+	Plan        string      `json:"plan"`
+	PlanName    string      `json:"plan_name"`
+	GeneratedAt time.Time   `json:"generated_at"`
+	Items       []UsageItem `json:"items"`
+}
+
+// Report builds the usage report of a tenant. Counts supplies the usage of
+// resources that are not metered in Redis, such as users and warehouses.
+func (e *Enforcer) Report(ctx context.Context, tenantID uuid.UUID, counts map[Resource]int64) (*UsageReport, error) {
+	plan, limits, err := e.Limits(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	at := e.now().In(e.location)
+	report := &UsageReport{
+		TenantID:    tenantID,
+		Plan:        plan.Code,
+		PlanName:    plan.Name,
+		GeneratedAt: at,
+		Items:       make([]UsageItem, 0, len(Resources)),
+	}
+
+	for _, resource := range Resources {
+		used := counts[resource]
+		if resource.Metered() {
+			used, err = e.store.Get(ctx, tenantID, resource, at)
+			if err != nil {
+				return nil, err
+			}
+		}
+		report.Items = append(report.Items, newUsageItem(resource, used, limits.Get(resource)))
+	}
+
+	return report, nil
+}
+
+func newUsageItem(resource Resource, used, limit int64) UsageItem {
+	item := UsageItem{
+		Resource:  resource,
+		Period:    resource.Period(),
+		Used:      used,
+		Limit:     limit,
+		Remaining: Unlimited,
+	}
+
+	if limit == Unlimited {
+		return item
+	}
+
+	item.Remaining = limit - used
+	if item.Remaining < 0 {
+		item.Remaining = 0
+	}
+	if limit > 0 {
+		item.PercentUsed = float64(used) / float64(limit) * 100
+	}
+
+	return item
+}
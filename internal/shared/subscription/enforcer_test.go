@@ -0,0 +1,288 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryUsageStore is an in-memory UsageStore for tests
+type memoryUsageStore struct {
+	counters map[string]int64
+	tenants  map[string]map[uuid.UUID]bool
+}
+
+func newMemoryUsageStore() *memoryUsageStore {
+	return &memoryUsageStore{
+		counters: make(map[string]int64),
+		tenants:  make(map[string]map[uuid.UUID]bool),
+	}
+}
+
+func (s *memoryUsageStore) Increment(ctx context.Context, tenantID uuid.UUID, resource Resource, delta int64, at time.Time) (int64, error) {
+	periodKey := PeriodKey(tenantID, resource, at)
+	dailyKey := DailyKey(tenantID, resource, at)
+	s.counters[periodKey] += delta
+	if dailyKey != periodKey {
+		s.counters[dailyKey] += delta
+	}
+
+	day := at.Format("2006-01-02")
+	if s.tenants[day] == nil {
+		s.tenants[day] = make(map[uuid.UUID]bool)
+	}
+	s.tenants[day][tenantID] = true
+
+	return s.counters[periodKey], nil
+}
+
+func (s *memoryUsageStore) Get(ctx context.Context, tenantID uuid.UUID, resource Resource, at time.Time) (int64, error) {
+	return s.counters[PeriodKey(tenantID, resource, at)], nil
+}
+
+func (s *memoryUsageStore) GetDaily(ctx context.Context, tenantID uuid.UUID, resource Resource, day time.Time) (int64, error) {
+	return s.counters[DailyKey(tenantID, resource, day)], nil
+}
+
+func (s *memoryUsageStore) Tenants(ctx context.Context, day time.Time) ([]uuid.UUID, error) {
+	var tenantIDs []uuid.UUID
+	for tenantID := range s.tenants[day.Format("2006-01-02")] {
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	return tenantIDs, nil
+}
+
+func newTestEnforcer(plan *TenantPlan, store UsageStore, now time.Time) *Enforcer {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	resolver := PlanResolverFunc(func(ctx context.Context, tenantID uuid.UUID) (*TenantPlan, error) {
+		if plan == nil {
+			return nil, fmt.Errorf("tenant not found")
+		}
+		return plan, nil
+	})
+
+	enforcer := NewEnforcer(DefaultCatalogue(), resolver, store, time.UTC, logger)
+	enforcer.now = func() time.Time { return now }
+	return enforcer
+}
+
+func TestDefaultCatalogue(t *testing.T) {
+	catalogue := DefaultCatalogue()
+	require.NotNil(t, catalogue)
+
+	assert.True(t, catalogue.IsValid(PlanBasic))
+	assert.True(t, catalogue.IsValid(PlanProfessional))
+	assert.True(t, catalogue.IsValid(PlanEnterprise))
+	assert.False(t, catalogue.IsValid("premium"))
+
+	// Unknown plans fall back to the default plan
+	assert.Equal(t, PlanBasic, catalogue.Resolve("premium").Code)
+	assert.Len(t, catalogue.Plans(), 3)
+
+	_, err := NewCatalogue("missing", &Plan{Code: PlanBasic})
+	assert.Error(t, err)
+	_, err = NewCatalogue(PlanBasic, &Plan{Code: PlanBasic}, &Plan{Code: PlanBasic})
+	assert.Error(t, err)
+}
+
+func TestEnforcer_Limits(t *testing.T) {
+	store := newMemoryUsageStore()
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	t.Run("Tenant max users raises the plan limit", func(t *testing.T) {
+		enforcer := newTestEnforcer(&TenantPlan{PlanCode: PlanBasic, MaxUsers: 25}, store, time.Now())
+		plan, limits, err := enforcer.Limits(ctx, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, PlanBasic, plan.Code)
+		assert.Equal(t, int64(25), limits.MaxUsers)
+	})
+
+	t.Run("Tenant max users cannot lower the plan limit", func(t *testing.T) {
+		enforcer := newTestEnforcer(&TenantPlan{PlanCode: PlanProfessional, MaxUsers: 10}, store, time.Now())
+		_, limits, err := enforcer.Limits(ctx, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(50), limits.MaxUsers)
+	})
+
+	t.Run("Resolver errors are returned", func(t *testing.T) {
+		enforcer := newTestEnforcer(nil, store, time.Now())
+		_, _, err := enforcer.Limits(ctx, tenantID)
+		assert.Error(t, err)
+	})
+}
+
+func TestEnforcer_CheckCount(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	enforcer := newTestEnforcer(&TenantPlan{PlanCode: PlanBasic}, newMemoryUsageStore(), time.Now())
+
+	assert.NoError(t, enforcer.CheckCount(ctx, tenantID, ResourceUsers, 9, 1))
+
+	err := enforcer.CheckCount(ctx, tenantID, ResourceUsers, 10, 1)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrPlanLimitExceeded))
+
+	limitErr, ok := AsLimitExceeded(err)
+	require.True(t, ok)
+	assert.Equal(t, ErrorCodePlanLimitExceeded, limitErr.Code())
+	assert.Equal(t, http.StatusForbidden, limitErr.StatusCode())
+	assert.Equal(t, ResourceUsers, limitErr.Resource)
+	assert.Equal(t, int64(10), limitErr.Limit)
+	assert.Equal(t, int64(10), limitErr.Current)
+
+	// Enterprise plans have unlimited warehouses
+	enterprise := newTestEnforcer(&TenantPlan{PlanCode: PlanEnterprise}, newMemoryUsageStore(), time.Now())
+	assert.NoError(t, enterprise.CheckCount(ctx, tenantID, ResourceWarehouses, 1000, 1))
+}
+
+func TestEnforcer_CheckPlanCount(t *testing.T) {
+	tenantID := uuid.New()
+	// The tenant is not stored yet, so the resolver is never asked
+	enforcer := newTestEnforcer(nil, newMemoryUsageStore(), time.Now())
+
+	assert.NoError(t, enforcer.CheckPlanCount(tenantID, PlanBasic, ResourceWarehouses, 0, 1))
+	assert.NoError(t, enforcer.CheckPlanCount(tenantID, PlanProfessional, ResourceWarehouses, 0, 5))
+
+	err := enforcer.CheckPlanCount(tenantID, PlanBasic, ResourceWarehouses, 0, 2)
+	limitErr, ok := AsLimitExceeded(err)
+	require.True(t, ok)
+	assert.Equal(t, PlanBasic, limitErr.Plan)
+	assert.Equal(t, int64(1), limitErr.Limit)
+}
+
+func TestEnforcer_Consume(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	now := time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)
+	store := newMemoryUsageStore()
+	enforcer := newTestEnforcer(&TenantPlan{PlanCode: PlanBasic}, store, now)
+
+	require.NoError(t, enforcer.Consume(ctx, tenantID, ResourceMonthlyInvoices, 499))
+	require.NoError(t, enforcer.Consume(ctx, tenantID, ResourceMonthlyInvoices, 1))
+
+	err := enforcer.Consume(ctx, tenantID, ResourceMonthlyInvoices, 1)
+	require.Error(t, err)
+	limitErr, ok := AsLimitExceeded(err)
+	require.True(t, ok)
+	assert.Equal(t, int64(500), limitErr.Current)
+
+	// Rejected consumption is rolled back
+	used, err := store.Get(ctx, tenantID, ResourceMonthlyInvoices, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), used)
+
+	// Monthly counters reset in the next month
+	enforcer.now = func() time.Time { return now.AddDate(0, 0, 1) }
+	assert.NoError(t, enforcer.Consume(ctx, tenantID, ResourceMonthlyInvoices, 1))
+
+	// Only metered resources can be consumed
+	assert.Error(t, enforcer.Consume(ctx, tenantID, ResourceUsers, 1))
+}
+
+func TestEnforcer_APICallsReturnTooManyRequests(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	store := newMemoryUsageStore()
+	enforcer := newTestEnforcer(&TenantPlan{PlanCode: PlanBasic}, store, now)
+
+	_, err := store.Increment(ctx, tenantID, ResourceAPICalls, 10000, now)
+	require.NoError(t, err)
+
+	err = enforcer.Consume(ctx, tenantID, ResourceAPICalls, 1)
+	limitErr, ok := AsLimitExceeded(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusTooManyRequests, limitErr.StatusCode())
+}
+
+func TestEnforcer_Release(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	now := time.Now()
+	store := newMemoryUsageStore()
+	enforcer := newTestEnforcer(&TenantPlan{PlanCode: PlanBasic}, store, now)
+
+	require.NoError(t, enforcer.Consume(ctx, tenantID, ResourceStorageBytes, 2048))
+	require.NoError(t, enforcer.Release(ctx, tenantID, ResourceStorageBytes, 1024))
+
+	used, err := store.Get(ctx, tenantID, ResourceStorageBytes, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1024), used)
+}
+
+func TestEnforcer_Report(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	now := time.Now()
+	store := newMemoryUsageStore()
+	enforcer := newTestEnforcer(&TenantPlan{PlanCode: PlanBasic}, store, now)
+
+	require.NoError(t, enforcer.Consume(ctx, tenantID, ResourceMonthlyInvoices, 250))
+
+	report, err := enforcer.Report(ctx, tenantID, map[Resource]int64{
+		ResourceUsers:      12,
+		ResourceWarehouses: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PlanBasic, report.Plan)
+	require.Len(t, report.Items, len(Resources))
+
+	items := make(map[Resource]UsageItem)
+	for _, item := range report.Items {
+		items[item.Resource] = item
+	}
+
+	assert.Equal(t, int64(250), items[ResourceMonthlyInvoices].Used)
+	assert.Equal(t, int64(250), items[ResourceMonthlyInvoices].Remaining)
+	assert.InDelta(t, 50.0, items[ResourceMonthlyInvoices].PercentUsed, 0.001)
+	assert.Equal(t, PeriodMonthly, items[ResourceMonthlyInvoices].Period)
+
+	// Usage above the limit never reports negative remaining
+	assert.Equal(t, int64(0), items[ResourceUsers].Remaining)
+	assert.InDelta(t, 120.0, items[ResourceUsers].PercentUsed, 0.001)
+}
+
+func TestCachedPlanResolver(t *testing.T) {
+	calls := 0
+	resolver := NewCachedPlanResolver(PlanResolverFunc(func(ctx context.Context, tenantID uuid.UUID) (*TenantPlan, error) {
+		calls++
+		return &TenantPlan{PlanCode: PlanBasic}, nil
+	}), time.Minute).(*cachedPlanResolver)
+
+	now := time.Now()
+	resolver.now = func() time.Time { return now }
+	tenantID := uuid.New()
+
+	for i := 0; i < 3; i++ {
+		_, err := resolver.ResolvePlan(context.Background(), tenantID)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, calls)
+
+	// Entries expire after the TTL
+	resolver.now = func() time.Time { return now.Add(2 * time.Minute) }
+	_, err := resolver.ResolvePlan(context.Background(), tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestPeriodKey(t *testing.T) {
+	tenantID := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	at := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "usage:123e4567-e89b-12d3-a456-426614174000:monthly_invoices:2025-03", PeriodKey(tenantID, ResourceMonthlyInvoices, at))
+	assert.Equal(t, "usage:123e4567-e89b-12d3-a456-426614174000:api_calls:2025-03-07", PeriodKey(tenantID, ResourceAPICalls, at))
+	assert.Equal(t, "usage:123e4567-e89b-12d3-a456-426614174000:storage_bytes:total", PeriodKey(tenantID, ResourceStorageBytes, at))
+	assert.Equal(t, "usage:123e4567-e89b-12d3-a456-426614174000:storage_bytes:2025-03-07", DailyKey(tenantID, ResourceStorageBytes, at))
+}
@@ -0,0 +1,71 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// ErrorCodePlanLimitExceeded is the stable error code returned to clients
+// when an operation would exceed the tenant's plan limits
+const ErrorCodePlanLimitExceeded = "PLAN_LIMIT_EXCEEDED"
+
+// ErrPlanLimitExceeded is the sentinel wrapped by every LimitExceededError
+var ErrPlanLimitExceeded = errors.New("plan limit exceeded")
+
+// LimitExceededError describes which plan limit an operation would exceed
+type LimitExceededError struct {
+	TenantID  uuid.UUID `json:"tenant_id"`
+	Plan      string    `json:"plan"`
+	Resource  Resource  `json:"resource"`
+	Limit     int64     `json:"limit"`
+	Current   int64     `json:"current"`
+	Requested int64     `json:"requested"`
+}
+
+// Error implements the error interface
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("plan limit exceeded: %s plan allows %d %s (current %d, requested %d)",
+		e.Plan, e.Limit, e.Resource, e.Current, e.Requested)
+}
+
+// Unwrap allows errors.Is(err, ErrPlanLimitExceeded)
+func (e *LimitExceededError) Unwrap() error {
+	return ErrPlanLimitExceeded
+}
+
+// Code returns the stable client error code
+func (e *LimitExceededError) Code() string {
+	return ErrorCodePlanLimitExceeded
+}
+
+// StatusCode returns the HTTP status for the error. Exhausted API call quotas
+// are retryable once the period resets; other limits require a plan upgrade.
+func (e *LimitExceededError) StatusCode() int {
+	if e.Resource == ResourceAPICalls {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusForbidden
+}
+
+// Details returns the error details exposed in API responses
+func (e *LimitExceededError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"plan":      e.Plan,
+		"resource":  e.Resource,
+		"limit":     e.Limit,
+		"current":   e.Current,
+		"requested": e.Requested,
+	}
+}
+
+// AsLimitExceeded extracts a LimitExceededError from an error chain
+func AsLimitExceeded(err error) (*LimitExceededError, bool) {
+	var limitErr *LimitExceededError
+	if errors.As(err, &limitErr) {
+		return limitErr, true
+	}
+	return nil, false
+}
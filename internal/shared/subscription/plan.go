@@ -0,0 +1,205 @@
+// Package subscription provides the subscription plan catalogue, plan limit
+// enforcement and per-tenant usage metering for RexiERP services.
+package subscription
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Unlimited marks a plan limit that is not enforced
+const Unlimited int64 = -1
+
+// Resource identifies a metered or counted resource limited by a plan
+type Resource string
+
+const (
+	ResourceUsers           Resource = "users"
+	ResourceWarehouses      Resource = "warehouses"
+	ResourceMonthlyInvoices Resource = "monthly_invoices"
+	ResourceStorageBytes    Resource = "storage_bytes"
+	ResourceAPICalls        Resource = "api_calls"
+)
+
+// Period defines how often a metered resource counter is reset
+type Period string
+
+const (
+	// PeriodNone counters are never reset (e.g. storage)
+	PeriodNone    Period = "none"
+	PeriodDaily   Period = "daily"
+	PeriodMonthly Period = "monthly"
+)
+
+// Resources lists every resource limited by a plan in display order
+var Resources = []Resource{
+	ResourceUsers,
+	ResourceWarehouses,
+	ResourceMonthlyInvoices,
+	ResourceStorageBytes,
+	ResourceAPICalls,
+}
+
+// Metered reports whether the resource usage is counted in Redis. Users and
+// warehouses are counted from their own tables instead.
+func (r Resource) Metered() bool {
+	switch r {
+	case ResourceMonthlyInvoices, ResourceStorageBytes, ResourceAPICalls:
+		return true
+	}
+	return false
+}
+
+// Period returns the reset period of the resource counter
+func (r Resource) Period() Period {
+	switch r {
+	case ResourceMonthlyInvoices:
+		return PeriodMonthly
+	case ResourceAPICalls:
+		return PeriodDaily
+	}
+	return PeriodNone
+}
+
+// Limits defines the resource limits of a plan. Unlimited disables a limit.
+type Limits struct {
+	MaxUsers           int64 `json:"max_users"`
+	MaxWarehouses      int64 `json:"max_warehouses"`
+	MaxMonthlyInvoices int64 `json:"max_monthly_invoices"`
+	MaxStorageBytes    int64 `json:"max_storage_bytes"`
+	MaxDailyAPICalls   int64 `json:"max_daily_api_calls"`
+}
+
+// Get returns the limit configured for the resource
+func (l Limits) Get(resource Resource) int64 {
+	switch resource {
+	case ResourceUsers:
+		return l.MaxUsers
+	case ResourceWarehouses:
+		return l.MaxWarehouses
+	case ResourceMonthlyInvoices:
+		return l.MaxMonthlyInvoices
+	case ResourceStorageBytes:
+		return l.MaxStorageBytes
+	case ResourceAPICalls:
+		return l.MaxDailyAPICalls
+	}
+	return Unlimited
+}
+
+// Plan represents a subscription plan
+type Plan struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Limits Limits `json:"limits"`
+}
+
+const (
+	PlanBasic        = "basic"
+	PlanProfessional = "professional"
+	PlanEnterprise   = "enterprise"
+)
+
+const (
+	gibibyte = int64(1024 * 1024 * 1024)
+)
+
+// Catalogue holds the available subscription plans keyed by plan code
+type Catalogue struct {
+	plans       map[string]*Plan
+	defaultPlan string
+}
+
+// NewCatalogue creates a catalogue from the given plans. The default plan is
+// used for tenants whose plan code is unknown.
+func NewCatalogue(defaultPlan string, plans ...*Plan) (*Catalogue, error) {
+	c := &Catalogue{
+		plans:       make(map[string]*Plan, len(plans)),
+		defaultPlan: defaultPlan,
+	}
+	for _, plan := range plans {
+		if plan.Code == "" {
+			return nil, fmt.Errorf("plan code is required")
+		}
+		if _, exists := c.plans[plan.Code]; exists {
+			return nil, fmt.Errorf("duplicate plan code: %s", plan.Code)
+		}
+		c.plans[plan.Code] = plan
+	}
+	if _, exists := c.plans[defaultPlan]; !exists {
+		return nil, fmt.Errorf("default plan %s is not in the catalogue", defaultPlan)
+	}
+	return c, nil
+}
+
+// DefaultCatalogue returns the standard RexiERP plans
+func DefaultCatalogue() *Catalogue {
+	catalogue, _ := NewCatalogue(PlanBasic,
+		&Plan{
+			Code: PlanBasic,
+			Name: "Basic",
+			Limits: Limits{
+				MaxUsers:           10,
+				MaxWarehouses:      1,
+				MaxMonthlyInvoices: 500,
+				MaxStorageBytes:    1 * gibibyte,
+				MaxDailyAPICalls:   10000,
+			},
+		},
+		&Plan{
+			Code: PlanProfessional,
+			Name: "Professional",
+			Limits: Limits{
+				MaxUsers:           50,
+				MaxWarehouses:      5,
+				MaxMonthlyInvoices: 5000,
+				MaxStorageBytes:    10 * gibibyte,
+				MaxDailyAPICalls:   100000,
+			},
+		},
+		&Plan{
+			Code: PlanEnterprise,
+			Name: "Enterprise",
+			Limits: Limits{
+				MaxUsers:           500,
+				MaxWarehouses:      Unlimited,
+				MaxMonthlyInvoices: Unlimited,
+				MaxStorageBytes:    100 * gibibyte,
+				MaxDailyAPICalls:   1000000,
+			},
+		},
+	)
+	return catalogue
+}
+
+// Get returns the plan with the given code
+func (c *Catalogue) Get(code string) (*Plan, bool) {
+	plan, exists := c.plans[code]
+	return plan, exists
+}
+
+// Resolve returns the plan with the given code, falling back to the default plan
+func (c *Catalogue) Resolve(code string) *Plan {
+	if plan, exists := c.plans[code]; exists {
+		return plan
+	}
+	return c.plans[c.defaultPlan]
+}
+
+// IsValid reports whether the plan code exists in the catalogue
+func (c *Catalogue) IsValid(code string) bool {
+	_, exists := c.plans[code]
+	return exists
+}
+
+// Plans returns all plans sorted by code
+func (c *Catalogue) Plans() []*Plan {
+	plans := make([]*Plan, 0, len(c.plans))
+	for _, plan := range c.plans {
+		plans = append(plans, plan)
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].Code < plans[j].Code
+	})
+	return plans
+}
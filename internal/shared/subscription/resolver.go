@@ -0,0 +1,56 @@
+package subscription
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// cachedPlanResolver caches resolved tenant plans in memory. Plans change
+// rarely, while API call metering resolves the plan on every request.
+type cachedPlanResolver struct {
+	resolver PlanResolver
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.RWMutex
+	entries map[uuid.UUID]cachedPlan
+}
+
+type cachedPlan struct {
+	plan      *TenantPlan
+	expiresAt time.Time
+}
+
+// NewCachedPlanResolver wraps a PlanResolver with an in-memory cache
+func NewCachedPlanResolver(resolver PlanResolver, ttl time.Duration) PlanResolver {
+	return &cachedPlanResolver{
+		resolver: resolver,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[uuid.UUID]cachedPlan),
+	}
+}
+
+// ResolvePlan returns the cached plan or resolves and caches it
+func (r *cachedPlanResolver) ResolvePlan(ctx context.Context, tenantID uuid.UUID) (*TenantPlan, error) {
+	r.mu.RLock()
+	entry, exists := r.entries[tenantID]
+	r.mu.RUnlock()
+	if exists && r.now().Before(entry.expiresAt) {
+		return entry.plan, nil
+	}
+
+	plan, err := r.resolver.ResolvePlan(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.entries[tenantID] = cachedPlan{plan: plan, expiresAt: r.now().Add(r.ttl)}
+	r.mu.Unlock()
+
+	return plan, nil
+}
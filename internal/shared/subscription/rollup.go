@@ -0,0 +1,138 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+// DailyUsage represents a tenant's rolled up usage of a resource for one day.
+// For storage the quantity is the total stored at rollup time; for all other
+// resources it is the amount consumed during the day.
+type DailyUsage struct {
+	TenantID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"tenant_id"`
+	UsageDate time.Time `gorm:"type:date;primaryKey" json:"usage_date"`
+	Resource  string    `gorm:"type:varchar(50);primaryKey" json:"resource"`
+	Quantity  int64     `gorm:"not null;default:0" json:"quantity"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for DailyUsage
+func (DailyUsage) TableName() string {
+	return "tenant_usage_daily"
+}
+
+// UsageRollup copies the Redis usage counters into tenant_usage_daily
+type UsageRollup struct {
+	db       *database.Database
+	store    UsageStore
+	location *time.Location
+	logger   *logrus.Logger
+	now      func() time.Time
+}
+
+// NewUsageRollup creates a new usage rollup job
+func NewUsageRollup(db *database.Database, store UsageStore, location *time.Location, logger *logrus.Logger) *UsageRollup {
+	if location == nil {
+		location = time.UTC
+	}
+	return &UsageRollup{
+		db:       db,
+		store:    store,
+		location: location,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// RollupDay upserts the usage of every tenant active on the given day. It is
+// idempotent and can be run repeatedly for the same day.
+func (r *UsageRollup) RollupDay(ctx context.Context, day time.Time) (int, error) {
+	day = day.In(r.location)
+	usageDate := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	tenantIDs, err := r.store.Tenants(ctx, day)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := make([]*DailyUsage, 0, len(tenantIDs)*len(Resources))
+	for _, tenantID := range tenantIDs {
+		for _, resource := range Resources {
+			if !resource.Metered() {
+				continue
+			}
+
+			var quantity int64
+			if resource.Period() == PeriodNone {
+				quantity, err = r.store.Get(ctx, tenantID, resource, day)
+			} else {
+				quantity, err = r.store.GetDaily(ctx, tenantID, resource, day)
+			}
+			if err != nil {
+				return 0, fmt.Errorf("failed to read usage of tenant %s: %w", tenantID, err)
+			}
+
+			rows = append(rows, &DailyUsage{
+				TenantID:  tenantID,
+				UsageDate: usageDate,
+				Resource:  string(resource),
+				Quantity:  quantity,
+			})
+		}
+	}
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	if err := r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "usage_date"}, {Name: "resource"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+		}).
+		CreateInBatches(rows, 500).Error; err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"usage_date": usageDate.Format("2006-01-02"),
+			"error":      err,
+		}).Error("Failed to roll up tenant usage")
+		return 0, fmt.Errorf("failed to roll up tenant usage: %w", err)
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"usage_date": usageDate.Format("2006-01-02"),
+		"tenants":    len(tenantIDs),
+		"rows":       len(rows),
+	}).Info("Tenant usage rolled up")
+
+	return len(tenantIDs), nil
+}
+
+// Run rolls up yesterday's and today's usage on every tick until ctx is done.
+// Rolling up yesterday again finalises counters updated around midnight.
+func (r *UsageRollup) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := r.now()
+		for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+			if _, err := r.RollupDay(ctx, day); err != nil {
+				r.logger.WithError(err).Error("Usage rollup failed")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/cache"
+)
+
+const (
+	usageKeyPrefix = "usage"
+
+	// Daily counters are kept long enough for a missed rollup to be retried
+	dailyCounterTTL   = 8 * 24 * time.Hour
+	monthlyCounterTTL = 40 * 24 * time.Hour
+)
+
+// UsageStore defines the contract for per-tenant usage counters
+type UsageStore interface {
+	// Increment adds delta to the resource counter of the period containing at
+	// and returns the new period total. Delta may be negative.
+	Increment(ctx context.Context, tenantID uuid.UUID, resource Resource, delta int64, at time.Time) (int64, error)
+
+	// Get returns the resource counter of the period containing at
+	Get(ctx context.Context, tenantID uuid.UUID, resource Resource, at time.Time) (int64, error)
+
+	// GetDaily returns the net change recorded for the resource on the given day
+	GetDaily(ctx context.Context, tenantID uuid.UUID, resource Resource, day time.Time) (int64, error)
+
+	// Tenants returns the tenants with usage recorded on the given day
+	Tenants(ctx context.Context, day time.Time) ([]uuid.UUID, error)
+}
+
+// redisUsageStore implements UsageStore on Redis
+type redisUsageStore struct {
+	client *redis.Client
+	logger *logrus.Logger
+}
+
+// NewRedisUsageStore creates a Redis backed UsageStore
+func NewRedisUsageStore(redisCache *cache.RedisCache, logger *logrus.Logger) UsageStore {
+	return &redisUsageStore{
+		client: redisCache.Client,
+		logger: logger,
+	}
+}
+
+// Increment adds delta to the period and daily counters of the resource
+func (s *redisUsageStore) Increment(ctx context.Context, tenantID uuid.UUID, resource Resource, delta int64, at time.Time) (int64, error) {
+	periodKey := PeriodKey(tenantID, resource, at)
+	dailyKey := DailyKey(tenantID, resource, at)
+	tenantsKey := tenantsKey(at)
+
+	pipe := s.client.TxPipeline()
+	periodCmd := pipe.IncrBy(ctx, periodKey, delta)
+	switch resource.Period() {
+	case PeriodDaily:
+		pipe.Expire(ctx, periodKey, dailyCounterTTL)
+	case PeriodMonthly:
+		pipe.Expire(ctx, periodKey, monthlyCounterTTL)
+	}
+	if dailyKey != periodKey {
+		pipe.IncrBy(ctx, dailyKey, delta)
+		pipe.Expire(ctx, dailyKey, dailyCounterTTL)
+	}
+	pipe.SAdd(ctx, tenantsKey, tenantID.String())
+	pipe.Expire(ctx, tenantsKey, dailyCounterTTL)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"tenant_id": tenantID,
+			"resource":  resource,
+			"error":     err,
+		}).Error("Failed to increment usage counter")
+		return 0, fmt.Errorf("failed to increment usage counter: %w", err)
+	}
+
+	return periodCmd.Val(), nil
+}
+
+// Get returns the resource counter of the period containing at
+func (s *redisUsageStore) Get(ctx context.Context, tenantID uuid.UUID, resource Resource, at time.Time) (int64, error) {
+	return s.getCounter(ctx, PeriodKey(tenantID, resource, at))
+}
+
+// GetDaily returns the net change recorded for the resource on the given day
+func (s *redisUsageStore) GetDaily(ctx context.Context, tenantID uuid.UUID, resource Resource, day time.Time) (int64, error) {
+	return s.getCounter(ctx, DailyKey(tenantID, resource, day))
+}
+
+// Tenants returns the tenants with usage recorded on the given day
+func (s *redisUsageStore) Tenants(ctx context.Context, day time.Time) ([]uuid.UUID, error) {
+	members, err := s.client.SMembers(ctx, tenantsKey(day)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage tenants: %w", err)
+	}
+
+	tenantIDs := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		tenantID, err := uuid.Parse(member)
+		if err != nil {
+			s.logger.WithField("member", member).Warn("Skipping invalid tenant ID in usage set")
+			continue
+		}
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+
+	return tenantIDs, nil
+}
+
+func (s *redisUsageStore) getCounter(ctx context.Context, key string) (int64, error) {
+	value, err := s.client.Get(ctx, key).Int64()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get usage counter: %w", err)
+	}
+	return value, nil
+}
+
+// PeriodKey returns the Redis key holding the resource counter of the period
+// containing at, e.g. usage:{tenant}:monthly_invoices:2025-01
+func PeriodKey(tenantID uuid.UUID, resource Resource, at time.Time) string {
+	switch resource.Period() {
+	case PeriodDaily:
+		return fmt.Sprintf("%s:%s:%s:%s", usageKeyPrefix, tenantID, resource, at.Format("2006-01-02"))
+	case PeriodMonthly:
+		return fmt.Sprintf("%s:%s:%s:%s", usageKeyPrefix, tenantID, resource, at.Format("2006-01"))
+	}
+	return fmt.Sprintf("%s:%s:%s:total", usageKeyPrefix, tenantID, resource)
+}
+
+// DailyKey returns the Redis key holding the net change of the resource on the
+// day containing at. It is the source of the daily database rollup.
+func DailyKey(tenantID uuid.UUID, resource Resource, at time.Time) string {
+	return fmt.Sprintf("%s:%s:%s:%s", usageKeyPrefix, tenantID, resource, at.Format("2006-01-02"))
+}
+
+func tenantsKey(at time.Time) string {
+	return fmt.Sprintf("%s:tenants:%s", usageKeyPrefix, at.Format("2006-01-02"))
+}
@@ -0,0 +1,276 @@
+package tenantdata
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+// FormatVersion is the version of the archives written by the exporter.
+// The importer reads archives up to this version.
+const FormatVersion = 1
+
+const (
+	manifestName = "manifest.json"
+	tablesDir    = "tables/"
+)
+
+var (
+	// ErrTenantNotFound is returned when the tenant to export or import
+	// into does not exist
+	ErrTenantNotFound = errors.New("tenant not found")
+	// ErrRedactedArchive is returned when importing a redacted archive
+	ErrRedactedArchive = errors.New("redacted archives cannot be imported")
+	// ErrInvalidArchive is returned for archives that are malformed, of an
+	// unsupported version or do not match their manifest
+	ErrInvalidArchive = errors.New("invalid tenant archive")
+	// ErrIntegrity is returned when archived rows reference rows that are
+	// neither in the archive nor in the target database
+	ErrIntegrity = errors.New("referential integrity violation")
+)
+
+// Manifest describes a tenant archive. Tables are listed in the order they
+// are stored and must be imported in.
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	TenantID      uuid.UUID `json:"tenant_id"`
+	TenantName    string    `json:"tenant_name"`
+	ExportedAt    time.Time `json:"exported_at"`
+	// Redacted archives have credential columns cleared
+	Redacted bool            `json:"redacted"`
+	Tables   []TableManifest `json:"tables"`
+}
+
+// TableManifest describes the NDJSON file of a table
+type TableManifest struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+	SHA256  string   `json:"sha256"`
+}
+
+// ExportOptions configures an export
+type ExportOptions struct {
+	// Redact clears credential columns such as password hashes, for
+	// archives handed to the tenant
+	Redact bool
+}
+
+// Exporter writes all data of a tenant to a gzip compressed tar archive
+// holding manifest.json and one NDJSON file per tenant-owned table
+type Exporter struct {
+	db     *database.Database
+	logger *logrus.Logger
+}
+
+// NewExporter creates a tenant exporter
+func NewExporter(db *database.Database, logger *logrus.Logger) *Exporter {
+	return &Exporter{db: db, logger: logger}
+}
+
+// Export writes the tenant's archive to w. All tables are read in one
+// read-only transaction, so the archive is a consistent snapshot.
+func (e *Exporter) Export(ctx context.Context, tenantID uuid.UUID, w io.Writer, opts ExportOptions) (*Manifest, error) {
+	// Tables are spooled to disk so the manifest can lead the archive
+	spool, err := os.CreateTemp("", "tenant-export-*.ndjson")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export spool: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		TenantID:      tenantID,
+		ExportedAt:    time.Now().UTC(),
+		Redacted:      opts.Redact,
+	}
+	var offsets []int64
+
+	var txOptions *sql.TxOptions
+	if e.db.DB.Dialector.Name() == "postgres" {
+		txOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	}
+	err = e.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tenants []struct {
+			Name string
+		}
+		if err := tx.Raw("SELECT name FROM "+tenantsTable+" WHERE id = ?", tenantID.String()).Scan(&tenants).Error; err != nil {
+			return fmt.Errorf("failed to read tenant: %w", err)
+		}
+		if len(tenants) == 0 {
+			return ErrTenantNotFound
+		}
+		manifest.TenantName = tenants[0].Name
+
+		s, err := loadSchema(ctx, tx)
+		if err != nil {
+			return err
+		}
+		tables, err := s.ownedTables()
+		if err != nil {
+			return err
+		}
+
+		var offset int64
+		for _, t := range tables {
+			counter := &countingWriter{w: spool}
+			tableManifest, err := exportTable(tx, t, tenantID, counter, opts)
+			if err != nil {
+				return err
+			}
+			manifest.Tables = append(manifest.Tables, tableManifest)
+			offsets = append(offsets, offset)
+			offset += counter.n
+		}
+		return nil
+	}, txOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeArchive(w, manifest, spool, offsets); err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"tenant_id": tenantID,
+		"tables":    len(manifest.Tables),
+		"redacted":  opts.Redact,
+	}).Info("Tenant exported")
+	return manifest, nil
+}
+
+func exportTable(tx *gorm.DB, t *table, tenantID uuid.UUID, w *countingWriter, opts ExportOptions) (TableManifest, error) {
+	tableManifest := TableManifest{Name: t.Name, Columns: t.Columns}
+
+	columns := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		columns[i] = quoteIdentifier(column)
+	}
+	where, args := t.selection(tenantID.String())
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(columns, ", "), quoteIdentifier(t.Name), where)
+	if t.hasColumn(idColumn) {
+		query += " ORDER BY " + idColumn
+	}
+
+	rows, err := tx.Raw(query, args...).Rows()
+	if err != nil {
+		return tableManifest, fmt.Errorf("failed to read %s: %w", t.Name, err)
+	}
+	defer rows.Close()
+
+	hash := sha256.New()
+	encoder := json.NewEncoder(io.MultiWriter(w, hash))
+	values := make([]interface{}, len(t.Columns))
+	pointers := make([]interface{}, len(t.Columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return tableManifest, fmt.Errorf("failed to read %s: %w", t.Name, err)
+		}
+		record := make(map[string]interface{}, len(t.Columns))
+		for i, column := range t.Columns {
+			if opts.Redact && database.IsSensitiveColumn(column) {
+				record[column] = nil
+				continue
+			}
+			record[column] = exportValue(values[i])
+		}
+		if err := encoder.Encode(record); err != nil {
+			return tableManifest, fmt.Errorf("failed to write %s: %w", t.Name, err)
+		}
+		tableManifest.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		return tableManifest, fmt.Errorf("failed to read %s: %w", t.Name, err)
+	}
+
+	tableManifest.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return tableManifest, nil
+}
+
+// exportValue converts driver values to their JSON form; uuid may come as
+// bytes depending on the driver
+func exportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case [16]byte:
+		return uuid.UUID(v).String()
+	}
+	return value
+}
+
+func writeArchive(w io.Writer, manifest *Manifest, spool *os.File, offsets []int64) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	modTime := manifest.ExportedAt
+
+	if err := archive.WriteHeader(&tar.Header{Name: manifestName, Mode: 0o600, Size: int64(len(data)), ModTime: modTime}); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if _, err := archive.Write(data); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	end, err := spool.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to read export spool: %w", err)
+	}
+	for i, tableManifest := range manifest.Tables {
+		size := end - offsets[i]
+		if i+1 < len(offsets) {
+			size = offsets[i+1] - offsets[i]
+		}
+		header := &tar.Header{Name: tablesDir + tableManifest.Name + ".ndjson", Mode: 0o600, Size: size, ModTime: modTime}
+		if err := archive.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		if _, err := io.Copy(archive, io.NewSectionReader(spool, offsets[i], size)); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,385 @@
+package tenantdata
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+// defaultImportBatchSize is the number of rows inserted per statement
+const defaultImportBatchSize = 500
+
+// ImportResult reports the rows imported per table
+type ImportResult struct {
+	TenantID uuid.UUID        `json:"tenant_id"`
+	Tables   map[string]int64 `json:"tables"`
+}
+
+// Importer re-creates an exported tenant's data under another tenant. Every
+// row gets a new ID and references are remapped, so an archive can be
+// imported next to the original tenant.
+type Importer struct {
+	db        *database.Database
+	logger    *logrus.Logger
+	batchSize int
+}
+
+// NewImporter creates a tenant importer
+func NewImporter(db *database.Database, logger *logrus.Logger) *Importer {
+	return &Importer{db: db, logger: logger, batchSize: defaultImportBatchSize}
+}
+
+// Import reads an archive written by the Exporter and inserts its rows
+// under tenantID, which must exist. The import runs in one transaction and
+// is rolled back if the archive does not match its manifest or a row
+// references a row that is neither in the archive nor in the database.
+func (im *Importer) Import(ctx context.Context, r io.Reader, tenantID uuid.UUID) (*ImportResult, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	archive := tar.NewReader(gz)
+
+	manifest, err := readManifest(archive)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Redacted {
+		return nil, ErrRedactedArchive
+	}
+
+	result := &ImportResult{TenantID: tenantID, Tables: make(map[string]int64)}
+	err = im.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Raw("SELECT count(*) FROM "+tenantsTable+" WHERE id = ?", tenantID.String()).Scan(&count).Error; err != nil {
+			return fmt.Errorf("failed to read tenant: %w", err)
+		}
+		if count == 0 {
+			return ErrTenantNotFound
+		}
+
+		s, err := loadSchema(ctx, tx)
+		if err != nil {
+			return err
+		}
+		state := &importState{
+			oldTenant: manifest.TenantID.String(),
+			newTenant: tenantID.String(),
+			archived:  make(map[string]bool),
+			ids:       make(map[string]string),
+			existing:  make(map[foreignKey]map[string]bool),
+		}
+		for _, tableManifest := range manifest.Tables {
+			t, ok := s[tableManifest.Name]
+			if !ok {
+				return fmt.Errorf("%w: table %s does not exist in this database", ErrInvalidArchive, tableManifest.Name)
+			}
+			for _, column := range tableManifest.Columns {
+				if !t.hasColumn(column) {
+					return fmt.Errorf("%w: column %s.%s does not exist in this database", ErrInvalidArchive, t.Name, column)
+				}
+			}
+			state.archived[t.Name] = true
+		}
+
+		for _, tableManifest := range manifest.Tables {
+			header, err := archive.Next()
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+			}
+			if header.Name != tablesDir+tableManifest.Name+".ndjson" {
+				return fmt.Errorf("%w: expected table %s, found %s", ErrInvalidArchive, tableManifest.Name, header.Name)
+			}
+			rows, err := im.importTable(ctx, tx, s[tableManifest.Name], tableManifest, archive, state)
+			if err != nil {
+				return err
+			}
+			result.Tables[tableManifest.Name] = rows
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	im.logger.WithFields(logrus.Fields{
+		"source_tenant_id": manifest.TenantID,
+		"tenant_id":        tenantID,
+		"tables":           len(result.Tables),
+	}).Info("Tenant imported")
+	return result, nil
+}
+
+func readManifest(archive *tar.Reader) (*Manifest, error) {
+	header, err := archive.Next()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if header.Name != manifestName {
+		return nil, fmt.Errorf("%w: archive does not start with %s", ErrInvalidArchive, manifestName)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(archive).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("%w: unsupported format version %d", ErrInvalidArchive, manifest.FormatVersion)
+	}
+	return &manifest, nil
+}
+
+// importState carries the ID mapping across the tables of an import
+type importState struct {
+	oldTenant string
+	newTenant string
+	archived  map[string]bool
+	// ids maps archived row IDs to their new IDs
+	ids map[string]string
+	// existing caches the referenced rows found outside the archive
+	existing map[foreignKey]map[string]bool
+}
+
+func (im *Importer) importTable(ctx context.Context, tx *gorm.DB, t *table, tableManifest TableManifest, r io.Reader, state *importState) (int64, error) {
+	hash := sha256.New()
+	source := io.TeeReader(r, hash)
+	decoder := json.NewDecoder(source)
+	decoder.UseNumber()
+
+	bulk := database.NewBulkOperation(tx.Table(t.Name), im.logger)
+	insert := func(rows []map[string]interface{}) error {
+		if err := im.checkExternalReferences(tx, t, rows, state); err != nil {
+			return err
+		}
+		if err := bulk.BulkInsert(ctx, rows, im.batchSize); err != nil {
+			return fmt.Errorf("failed to insert into %s: %w", t.Name, err)
+		}
+		return nil
+	}
+
+	// Trees are buffered so parents can be inserted before their children
+	selfReference := t.selfReference()
+	var count int64
+	var batch []map[string]interface{}
+	for {
+		var row map[string]interface{}
+		if err := decoder.Decode(&row); err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, t.Name, err)
+		}
+		count++
+
+		state.assignID(t, row)
+		if selfReference == "" {
+			if err := state.remap(t, row); err != nil {
+				return 0, err
+			}
+		}
+		batch = append(batch, row)
+
+		if selfReference == "" && len(batch) >= im.batchSize {
+			if err := insert(batch); err != nil {
+				return 0, err
+			}
+			batch = nil
+		}
+	}
+
+	if selfReference != "" {
+		for _, row := range batch {
+			if err := state.remap(t, row); err != nil {
+				return 0, err
+			}
+		}
+		ordered, err := parentsFirst(batch, selfReference)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s: %v", ErrIntegrity, t.Name, err)
+		}
+		batch = ordered
+	}
+	for len(batch) > 0 {
+		size := im.batchSize
+		if size > len(batch) {
+			size = len(batch)
+		}
+		if err := insert(batch[:size]); err != nil {
+			return 0, err
+		}
+		batch = batch[size:]
+	}
+
+	if _, err := io.Copy(io.Discard, source); err != nil {
+		return 0, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, t.Name, err)
+	}
+	if count != tableManifest.Rows {
+		return 0, fmt.Errorf("%w: %s has %d rows, manifest has %d", ErrInvalidArchive, t.Name, count, tableManifest.Rows)
+	}
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != tableManifest.SHA256 {
+		return 0, fmt.Errorf("%w: %s does not match its checksum", ErrInvalidArchive, t.Name)
+	}
+	return count, nil
+}
+
+// assignID gives the row a new ID and records the mapping
+func (s *importState) assignID(t *table, row map[string]interface{}) {
+	if !t.hasColumn(idColumn) {
+		return
+	}
+	old, ok := row[idColumn].(string)
+	if !ok {
+		return
+	}
+	if _, err := uuid.Parse(old); err != nil {
+		return
+	}
+	id := uuid.NewString()
+	s.ids[old] = id
+	row[idColumn] = id
+}
+
+// remap moves the row to the new tenant, points references to archived
+// rows at their new IDs and converts values for insertion
+func (s *importState) remap(t *table, row map[string]interface{}) error {
+	for column, value := range row {
+		row[column] = importValue(value)
+		if value == nil || column == idColumn {
+			continue
+		}
+
+		fk, isReference := t.ForeignKeys[column]
+		if column == tenantIDColumn || (isReference && fk.Table == tenantsTable) {
+			if !sameID(value, s.oldTenant) {
+				return fmt.Errorf("%w: %s.%s belongs to tenant %v, not the exported tenant", ErrIntegrity, t.Name, column, value)
+			}
+			row[column] = s.newTenant
+			continue
+		}
+		if !isReference || !s.archived[fk.Table] || fk.Column != idColumn {
+			continue
+		}
+
+		old, _ := value.(string)
+		id, ok := s.ids[old]
+		if !ok {
+			return fmt.Errorf("%w: %s.%s references %s %v, which is not in the archive", ErrIntegrity, t.Name, column, fk.Table, value)
+		}
+		row[column] = id
+	}
+	return nil
+}
+
+// checkExternalReferences verifies that references to tables outside the
+// archive, such as regions, exist in the target database
+func (im *Importer) checkExternalReferences(tx *gorm.DB, t *table, rows []map[string]interface{}, state *importState) error {
+	missing := make(map[foreignKey]map[string]bool)
+	for column, fk := range t.ForeignKeys {
+		if state.archived[fk.Table] || fk.Table == tenantsTable || column == tenantIDColumn {
+			continue
+		}
+		if state.existing[fk] == nil {
+			state.existing[fk] = make(map[string]bool)
+		}
+		for _, row := range rows {
+			if value := row[column]; value != nil {
+				key := fmt.Sprint(value)
+				if !state.existing[fk][key] {
+					if missing[fk] == nil {
+						missing[fk] = make(map[string]bool)
+					}
+					missing[fk][key] = true
+				}
+			}
+		}
+	}
+
+	for fk, values := range missing {
+		keys := make([]string, 0, len(values))
+		for value := range values {
+			keys = append(keys, value)
+		}
+		sort.Strings(keys)
+
+		var found []string
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE %s IN ?", quoteIdentifier(fk.Column), quoteIdentifier(fk.Table), quoteIdentifier(fk.Column))
+		if err := tx.Raw(query, keys).Scan(&found).Error; err != nil {
+			return fmt.Errorf("failed to check references to %s: %w", fk.Table, err)
+		}
+		for _, value := range found {
+			state.existing[fk][value] = true
+		}
+		for _, value := range keys {
+			if !state.existing[fk][value] {
+				return fmt.Errorf("%w: %s references %s %s, which does not exist in this database", ErrIntegrity, t.Name, fk.Table, value)
+			}
+		}
+	}
+	return nil
+}
+
+// parentsFirst orders the rows of a tree so that every row follows the row
+// its column references
+func parentsFirst(rows []map[string]interface{}, column string) ([]map[string]interface{}, error) {
+	inserted := make(map[string]bool, len(rows))
+	ordered := make([]map[string]interface{}, 0, len(rows))
+	for len(rows) > 0 {
+		var pending []map[string]interface{}
+		for _, row := range rows {
+			parent, _ := row[column].(string)
+			if parent != "" && !inserted[parent] {
+				pending = append(pending, row)
+				continue
+			}
+			id, _ := row[idColumn].(string)
+			inserted[id] = true
+			ordered = append(ordered, row)
+		}
+		if len(pending) == len(rows) {
+			return nil, errors.New("rows reference each other in a cycle")
+		}
+		rows = pending
+	}
+	return ordered, nil
+}
+
+// importValue converts decoded JSON for insertion: integers stay exact,
+// other numbers keep their text for numeric columns, and nested JSON is
+// stored as text
+func importValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		return v.String()
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return value
+		}
+		return string(data)
+	}
+	return value
+}
+
+func sameID(value interface{}, id string) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	parsed, err := uuid.Parse(s)
+	return err == nil && parsed.String() == id
+}
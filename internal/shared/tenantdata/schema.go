@@ -0,0 +1,294 @@
+package tenantdata
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	tenantsTable   = "tenants"
+	tenantIDColumn = "tenant_id"
+	idColumn       = "id"
+)
+
+// excludedTables are not moved with a tenant: the tenant row itself, which
+// the target environment creates, sessions and credentials that are only
+// valid where they were issued, and the hash chained trails
+var excludedTables = map[string]bool{
+	tenantsTable:            true,
+	"user_sessions":         true,
+	"password_reset_tokens": true,
+	"audit_logs":            true,
+	"activity_logs":         true,
+}
+
+// foreignKey is the table and column a single column foreign key references
+type foreignKey struct {
+	Table  string
+	Column string
+}
+
+// table describes a table and, for tenant-owned tables, how its rows belong
+// to the tenant
+type table struct {
+	Name        string
+	Columns     []string
+	ForeignKeys map[string]foreignKey
+
+	// ownerColumn is tenant_id, or for child tables such as order items
+	// the column referencing the owned parent
+	ownerColumn string
+	parent      *table
+}
+
+func (t *table) hasColumn(name string) bool {
+	for _, column := range t.Columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// selfReference returns the column referencing the table itself, such as
+// parent_id in a tree
+func (t *table) selfReference() string {
+	for _, column := range t.Columns {
+		if fk, ok := t.ForeignKeys[column]; ok && fk.Table == t.Name && fk.Column == idColumn {
+			return column
+		}
+	}
+	return ""
+}
+
+// selection returns the condition matching the tenant's rows
+func (t *table) selection(tenantID string) (string, []interface{}) {
+	if t.parent == nil {
+		return quoteIdentifier(t.ownerColumn) + " = ?", []interface{}{tenantID}
+	}
+	where, args := t.parent.selection(tenantID)
+	return fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE %s)",
+		quoteIdentifier(t.ownerColumn), idColumn, quoteIdentifier(t.parent.Name), where), args
+}
+
+type schema map[string]*table
+
+// loadSchema reads the tables, columns and single column foreign keys of
+// the current schema
+func loadSchema(ctx context.Context, db *gorm.DB) (schema, error) {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return loadPostgresSchema(ctx, db)
+	case "sqlite":
+		return loadSQLiteSchema(ctx, db)
+	}
+	return nil, fmt.Errorf("tenant export is not supported on %s", db.Dialector.Name())
+}
+
+func loadPostgresSchema(ctx context.Context, db *gorm.DB) (schema, error) {
+	var columns []struct {
+		TableName  string
+		ColumnName string
+	}
+	// Partitions are read through their parent
+	if err := db.WithContext(ctx).Raw(`SELECT c.relname AS table_name, a.attname AS column_name
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid
+		WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+			AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum`).Scan(&columns).Error; err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	s := make(schema)
+	for _, column := range columns {
+		s.table(column.TableName).Columns = append(s.table(column.TableName).Columns, column.ColumnName)
+	}
+
+	var keys []struct {
+		TableName     string
+		ColumnName    string
+		ForeignTable  string
+		ForeignColumn string
+	}
+	if err := db.WithContext(ctx).Raw(`SELECT c.relname AS table_name, a.attname AS column_name,
+			fc.relname AS foreign_table, fa.attname AS foreign_column
+		FROM pg_constraint k
+		JOIN pg_class c ON c.oid = k.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_class fc ON fc.oid = k.confrelid
+		JOIN pg_attribute a ON a.attrelid = k.conrelid AND a.attnum = k.conkey[1]
+		JOIN pg_attribute fa ON fa.attrelid = k.confrelid AND fa.attnum = k.confkey[1]
+		WHERE k.contype = 'f' AND n.nspname = current_schema() AND NOT c.relispartition
+			AND array_length(k.conkey, 1) = 1`).Scan(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to read foreign keys: %w", err)
+	}
+	for _, key := range keys {
+		s.table(key.TableName).ForeignKeys[key.ColumnName] = foreignKey{Table: key.ForeignTable, Column: key.ForeignColumn}
+	}
+	return s, nil
+}
+
+func loadSQLiteSchema(ctx context.Context, db *gorm.DB) (schema, error) {
+	var names []string
+	if err := db.WithContext(ctx).Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'").
+		Scan(&names).Error; err != nil {
+		return nil, fmt.Errorf("failed to read tables: %w", err)
+	}
+
+	s := make(schema)
+	for _, name := range names {
+		t := s.table(name)
+
+		var columns []struct {
+			Name string
+		}
+		if err := db.WithContext(ctx).Raw("PRAGMA table_info(" + quoteIdentifier(name) + ")").Scan(&columns).Error; err != nil {
+			return nil, fmt.Errorf("failed to read columns of %s: %w", name, err)
+		}
+		for _, column := range columns {
+			t.Columns = append(t.Columns, column.Name)
+		}
+
+		var keys []struct {
+			ID    int
+			Table string
+			From  string
+			To    *string
+		}
+		if err := db.WithContext(ctx).Raw("PRAGMA foreign_key_list(" + quoteIdentifier(name) + ")").Scan(&keys).Error; err != nil {
+			return nil, fmt.Errorf("failed to read foreign keys of %s: %w", name, err)
+		}
+		columnsPerKey := make(map[int]int)
+		for _, key := range keys {
+			columnsPerKey[key.ID]++
+		}
+		for _, key := range keys {
+			if columnsPerKey[key.ID] != 1 {
+				continue
+			}
+			// A reference without columns targets the primary key
+			to := idColumn
+			if key.To != nil && *key.To != "" {
+				to = *key.To
+			}
+			t.ForeignKeys[key.From] = foreignKey{Table: key.Table, Column: to}
+		}
+	}
+	return s, nil
+}
+
+func (s schema) table(name string) *table {
+	t, ok := s[name]
+	if !ok {
+		t = &table{Name: name, ForeignKeys: make(map[string]foreignKey)}
+		s[name] = t
+	}
+	return t
+}
+
+func (s schema) names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ownedTables returns the tenant-owned tables, parents before the tables
+// referencing them. Tables with a tenant_id column are owned directly;
+// tables without one are owned through a reference to an owned table.
+func (s schema) ownedTables() ([]*table, error) {
+	owned := make(map[string]*table)
+	for _, name := range s.names() {
+		if t := s[name]; !excludedTables[name] && t.hasColumn(tenantIDColumn) {
+			t.ownerColumn = tenantIDColumn
+			owned[name] = t
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, name := range s.names() {
+			t := s[name]
+			if owned[name] != nil || excludedTables[name] {
+				continue
+			}
+			for _, column := range t.Columns {
+				fk, ok := t.ForeignKeys[column]
+				if !ok || fk.Column != idColumn || fk.Table == name || owned[fk.Table] == nil {
+					continue
+				}
+				t.ownerColumn = column
+				t.parent = owned[fk.Table]
+				owned[name] = t
+				changed = true
+				break
+			}
+		}
+	}
+
+	return sortByDependencies(owned)
+}
+
+// sortByDependencies orders tables so that every table comes after the
+// tables it references, breaking ties by name
+func sortByDependencies(tables map[string]*table) ([]*table, error) {
+	dependents := make(map[string][]string)
+	pending := make(map[string]int)
+	for name, t := range tables {
+		pending[name] = 0
+		seen := make(map[string]bool)
+		for _, fk := range t.ForeignKeys {
+			if fk.Table == name || tables[fk.Table] == nil || seen[fk.Table] {
+				continue
+			}
+			seen[fk.Table] = true
+			pending[name]++
+			dependents[fk.Table] = append(dependents[fk.Table], name)
+		}
+	}
+
+	var ready []string
+	for name, count := range pending {
+		if count == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	var ordered []*table
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, tables[name])
+		for _, dependent := range dependents[name] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(ordered) != len(tables) {
+		var cyclic []string
+		for name, count := range pending {
+			if count > 0 {
+				cyclic = append(cyclic, name)
+			}
+		}
+		sort.Strings(cyclic)
+		return nil, fmt.Errorf("tables %s reference each other in a cycle", strings.Join(cyclic, ", "))
+	}
+	return ordered, nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,279 @@
+package tenantdata
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/VincentArjuna/RexiErp/internal/shared/database"
+)
+
+var testSchema = []string{
+	`CREATE TABLE tenants (id TEXT PRIMARY KEY, name TEXT NOT NULL)`,
+	`CREATE TABLE cities (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`,
+	`CREATE TABLE users (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL REFERENCES tenants(id),
+		email TEXT NOT NULL,
+		password_hash TEXT NOT NULL
+	)`,
+	`CREATE TABLE user_sessions (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL REFERENCES tenants(id),
+		user_id TEXT NOT NULL REFERENCES users(id)
+	)`,
+	`CREATE TABLE product_categories (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL REFERENCES tenants(id),
+		parent_id TEXT REFERENCES product_categories(id),
+		name TEXT NOT NULL
+	)`,
+	`CREATE TABLE products (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL REFERENCES tenants(id),
+		category_id TEXT REFERENCES product_categories(id),
+		name TEXT NOT NULL,
+		price NUMERIC NOT NULL,
+		attributes TEXT
+	)`,
+	`CREATE TABLE sales_orders (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL REFERENCES tenants(id),
+		created_by TEXT NOT NULL REFERENCES users(id),
+		city_id INTEGER REFERENCES cities(id),
+		number TEXT NOT NULL
+	)`,
+	`CREATE TABLE sales_order_items (
+		id TEXT PRIMARY KEY,
+		order_id TEXT NOT NULL REFERENCES sales_orders(id),
+		product_id TEXT NOT NULL REFERENCES products(id),
+		quantity INTEGER NOT NULL
+	)`,
+}
+
+type fixture struct {
+	db     *database.Database
+	logger *logrus.Logger
+	tenant uuid.UUID
+	other  uuid.UUID
+	user   string
+	order  string
+}
+
+func newFixture(t *testing.T) *fixture {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=1"), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	for _, statement := range testSchema {
+		require.NoError(t, db.Exec(statement).Error)
+	}
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	f := &fixture{db: &database.Database{DB: db}, logger: log, tenant: uuid.New(), other: uuid.New()}
+
+	root, child := uuid.NewString(), uuid.NewString()
+	product := uuid.NewString()
+	f.user, f.order = uuid.NewString(), uuid.NewString()
+	for _, statement := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO tenants VALUES (?, 'Toko Maju'), (?, 'Toko Baru')", []interface{}{f.tenant.String(), f.other.String()}},
+		{"INSERT INTO cities VALUES (3171, 'Jakarta Selatan')", nil},
+		{"INSERT INTO users VALUES (?, ?, 'owner@tokomaju.co.id', 'bcrypt-hash')", []interface{}{f.user, f.tenant.String()}},
+		{"INSERT INTO user_sessions VALUES (?, ?, ?)", []interface{}{uuid.NewString(), f.tenant.String(), f.user}},
+		// The child is inserted first so the tree is not stored parents first
+		{"INSERT INTO product_categories VALUES (?, ?, NULL, 'Minuman')", []interface{}{root, f.tenant.String()}},
+		{"INSERT INTO product_categories VALUES (?, ?, ?, 'Kopi')", []interface{}{child, f.tenant.String(), root}},
+		{"INSERT INTO products VALUES (?, ?, ?, 'Kopi Gayo 250g', 85000.5, '{\"origin\":\"Aceh\"}')", []interface{}{product, f.tenant.String(), child}},
+		{"INSERT INTO sales_orders VALUES (?, ?, ?, 3171, 'SO-0001')", []interface{}{f.order, f.tenant.String(), f.user}},
+		{"INSERT INTO sales_order_items VALUES (?, ?, ?, 2)", []interface{}{uuid.NewString(), f.order, product}},
+		{"INSERT INTO sales_order_items VALUES (?, ?, ?, 5)", []interface{}{uuid.NewString(), f.order, product}},
+		// Data of another tenant is not exported
+		{"INSERT INTO users VALUES (?, ?, 'owner@tokobaru.co.id', 'other-hash')", []interface{}{uuid.NewString(), f.other.String()}},
+	} {
+		require.NoError(t, db.Exec(statement.query, statement.args...).Error)
+	}
+	return f
+}
+
+func (f *fixture) export(t *testing.T, opts ExportOptions) (*Manifest, []byte) {
+	var buf bytes.Buffer
+	manifest, err := NewExporter(f.db, f.logger).Export(context.Background(), f.tenant, &buf, opts)
+	require.NoError(t, err)
+	return manifest, buf.Bytes()
+}
+
+func (f *fixture) importer() *Importer {
+	im := NewImporter(f.db, f.logger)
+	im.batchSize = 1
+	return im
+}
+
+func (f *fixture) count(t *testing.T, query string, args ...interface{}) int64 {
+	var n int64
+	require.NoError(t, f.db.DB.Raw(query, args...).Scan(&n).Error)
+	return n
+}
+
+func TestExport_WritesOwnedTablesInDependencyOrder(t *testing.T) {
+	f := newFixture(t)
+
+	manifest, _ := f.export(t, ExportOptions{})
+
+	var names []string
+	rows := make(map[string]int64)
+	for _, table := range manifest.Tables {
+		names = append(names, table.Name)
+		rows[table.Name] = table.Rows
+	}
+	assert.Equal(t, []string{"product_categories", "products", "users", "sales_orders", "sales_order_items"}, names)
+	assert.Equal(t, int64(1), rows["users"])
+	assert.Equal(t, int64(2), rows["product_categories"])
+	assert.Equal(t, int64(2), rows["sales_order_items"])
+	assert.Equal(t, "Toko Maju", manifest.TenantName)
+	assert.Equal(t, FormatVersion, manifest.FormatVersion)
+}
+
+func TestImport_RecreatesDataUnderNewIDs(t *testing.T) {
+	f := newFixture(t)
+	_, archive := f.export(t, ExportOptions{})
+
+	result, err := f.importer().Import(context.Background(), bytes.NewReader(archive), f.other)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Tables["sales_order_items"])
+
+	assert.Equal(t, int64(2), f.count(t, "SELECT count(*) FROM users WHERE tenant_id = ?", f.other.String()))
+	assert.Equal(t, int64(0), f.count(t, "SELECT count(*) FROM user_sessions WHERE tenant_id = ?", f.other.String()))
+
+	var order struct {
+		ID        string
+		CreatedBy string
+		CityID    int64
+	}
+	require.NoError(t, f.db.DB.Raw("SELECT id, created_by, city_id FROM sales_orders WHERE tenant_id = ?", f.other.String()).Scan(&order).Error)
+	assert.NotEqual(t, f.order, order.ID)
+	assert.NotEqual(t, f.user, order.CreatedBy)
+	assert.Equal(t, int64(3171), order.CityID)
+	assert.Equal(t, int64(1), f.count(t, "SELECT count(*) FROM users WHERE id = ? AND tenant_id = ? AND password_hash = 'bcrypt-hash'", order.CreatedBy, f.other.String()))
+	assert.Equal(t, int64(7), f.count(t, "SELECT sum(quantity) FROM sales_order_items WHERE order_id = ?", order.ID))
+
+	var product struct {
+		Price      float64
+		Attributes string
+		Category   string
+	}
+	require.NoError(t, f.db.DB.Raw(`SELECT p.price, p.attributes, parent.name AS category
+		FROM products p
+		JOIN product_categories c ON c.id = p.category_id
+		JOIN product_categories parent ON parent.id = c.parent_id
+		WHERE p.tenant_id = ?`, f.other.String()).Scan(&product).Error)
+	assert.Equal(t, 85000.5, product.Price)
+	assert.Equal(t, `{"origin":"Aceh"}`, product.Attributes)
+	assert.Equal(t, "Minuman", product.Category)
+}
+
+func TestImport_RejectsDanglingReferences(t *testing.T) {
+	f := newFixture(t)
+	// An order of the tenant created by a user of another tenant
+	outsider := uuid.NewString()
+	require.NoError(t, f.db.DB.Exec("INSERT INTO users VALUES (?, ?, 'auditor@tokobaru.co.id', 'hash')", outsider, f.other.String()).Error)
+	require.NoError(t, f.db.DB.Exec("UPDATE sales_orders SET created_by = ?", outsider).Error)
+	_, archive := f.export(t, ExportOptions{})
+
+	_, err := f.importer().Import(context.Background(), bytes.NewReader(archive), f.other)
+	assert.ErrorIs(t, err, ErrIntegrity)
+	assert.Equal(t, int64(0), f.count(t, "SELECT count(*) FROM product_categories WHERE tenant_id = ?", f.other.String()))
+}
+
+func TestExport_RedactsCredentials(t *testing.T) {
+	f := newFixture(t)
+	manifest, archive := f.export(t, ExportOptions{Redact: true})
+	assert.True(t, manifest.Redacted)
+
+	users := readEntry(t, archive, tablesDir+"users.ndjson")
+	assert.Contains(t, users, `"password_hash":null`)
+	assert.Contains(t, users, `"email":"owner@tokomaju.co.id"`)
+
+	_, err := f.importer().Import(context.Background(), bytes.NewReader(archive), f.other)
+	assert.ErrorIs(t, err, ErrRedactedArchive)
+}
+
+func TestImport_RejectsTamperedArchive(t *testing.T) {
+	f := newFixture(t)
+	_, archive := f.export(t, ExportOptions{})
+
+	tampered := rewriteEntry(t, archive, tablesDir+"sales_order_items.ndjson", func(data string) string {
+		return strings.Replace(data, `"quantity":5`, `"quantity":50`, 1)
+	})
+	_, err := f.importer().Import(context.Background(), bytes.NewReader(tampered), f.other)
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+	assert.Equal(t, int64(1), f.count(t, "SELECT count(*) FROM sales_orders"))
+}
+
+func TestExportImport_UnknownTenant(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := NewExporter(f.db, f.logger).Export(context.Background(), uuid.New(), io.Discard, ExportOptions{})
+	assert.ErrorIs(t, err, ErrTenantNotFound)
+
+	_, archive := f.export(t, ExportOptions{})
+	_, err = f.importer().Import(context.Background(), bytes.NewReader(archive), uuid.New())
+	assert.ErrorIs(t, err, ErrTenantNotFound)
+}
+
+func readEntry(t *testing.T, archive []byte, name string) string {
+	var found string
+	rewriteEntry(t, archive, name, func(data string) string {
+		found = data
+		return data
+	})
+	return found
+}
+
+// rewriteEntry copies the archive, passing the named entry through edit
+func rewriteEntry(t *testing.T, archive []byte, name string, edit func(string) string) []byte {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+	reader := tar.NewReader(gz)
+
+	var out bytes.Buffer
+	gzOut := gzip.NewWriter(&out)
+	writer := tar.NewWriter(gzOut)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		if header.Name == name {
+			data = []byte(edit(string(data)))
+			header.Size = int64(len(data))
+		}
+		require.NoError(t, writer.WriteHeader(header))
+		_, err = writer.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, gzOut.Close())
+	return out.Bytes()
+}
@@ -0,0 +1,13 @@
+-- Rollback: Tenant lifecycle and numbering sequences
+-- Description: Removes the numbering sequences and the lifecycle columns of tenants
+
+DROP TABLE IF EXISTS numbering_sequences;
+
+DROP INDEX IF EXISTS idx_tenants_is_active;
+DROP INDEX IF EXISTS idx_tenants_status;
+
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_status_check;
+ALTER TABLE tenants DROP COLUMN IF EXISTS closed_at;
+ALTER TABLE tenants DROP COLUMN IF EXISTS suspended_at;
+ALTER TABLE tenants DROP COLUMN IF EXISTS status_reason;
+ALTER TABLE tenants DROP COLUMN IF EXISTS status;
@@ -0,0 +1,45 @@
+-- Migration: Tenant lifecycle and numbering sequences
+-- Created: Authentication Service
+-- Description: Adds lifecycle status to tenants and the per-tenant document numbering sequences
+
+-- Tenant lifecycle columns
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS status_reason TEXT;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_status_check;
+ALTER TABLE tenants ADD CONSTRAINT tenants_status_check
+    CHECK (status IN ('active', 'suspended', 'closed'));
+
+-- Keep is_active consistent with the lifecycle status for existing rows
+UPDATE tenants SET status = 'suspended' WHERE is_active = false AND status = 'active';
+
+CREATE INDEX IF NOT EXISTS idx_tenants_status ON tenants(status);
+CREATE INDEX IF NOT EXISTS idx_tenants_is_active ON tenants(is_active);
+
+-- Document numbering sequences (e.g. INV/2025/000001)
+CREATE TABLE IF NOT EXISTS numbering_sequences (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    document_type VARCHAR(50) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    next_number BIGINT NOT NULL DEFAULT 1,
+    padding INTEGER NOT NULL DEFAULT 6,
+    reset_period VARCHAR(20) NOT NULL DEFAULT 'yearly', -- 'never', 'yearly' or 'monthly'
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant_id, document_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_numbering_sequences_tenant_id ON numbering_sequences(tenant_id);
+
+DROP TRIGGER IF EXISTS update_numbering_sequences_updated_at ON numbering_sequences;
+CREATE TRIGGER update_numbering_sequences_updated_at
+    BEFORE UPDATE ON numbering_sequences
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON COLUMN tenants.status IS 'Lifecycle status: active, suspended or closed';
+COMMENT ON COLUMN tenants.status_reason IS 'Reason given for the last suspend or close operation';
+COMMENT ON TABLE numbering_sequences IS 'Per-tenant document numbering sequences seeded at tenant provisioning';
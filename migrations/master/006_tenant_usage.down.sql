@@ -0,0 +1,7 @@
+-- Rollback: Tenant usage rollup
+-- Description: Removes the usage rollup and the plan code check. Tenants
+-- moved from 'premium' to 'professional' keep their new plan.
+
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_subscription_plan_check;
+
+DROP TABLE IF EXISTS tenant_usage_daily;
@@ -0,0 +1,32 @@
+-- Migration: Tenant usage rollup
+-- Created: Authentication Service
+-- Description: Daily rollup of the per-tenant usage counters metered in Redis
+
+CREATE TABLE IF NOT EXISTS tenant_usage_daily (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    usage_date DATE NOT NULL,
+    resource VARCHAR(50) NOT NULL, -- 'monthly_invoices', 'storage_bytes' or 'api_calls'
+    quantity BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, usage_date, resource)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_usage_daily_usage_date ON tenant_usage_daily(usage_date);
+
+DROP TRIGGER IF EXISTS update_tenant_usage_daily_updated_at ON tenant_usage_daily;
+CREATE TRIGGER update_tenant_usage_daily_updated_at
+    BEFORE UPDATE ON tenant_usage_daily
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Plan codes are defined by the subscription plan catalogue; the seed data
+-- used 'premium' before the catalogue existed
+UPDATE tenants SET subscription_plan = 'professional' WHERE subscription_plan = 'premium';
+
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_subscription_plan_check;
+ALTER TABLE tenants ADD CONSTRAINT tenants_subscription_plan_check
+    CHECK (subscription_plan IN ('basic', 'professional', 'enterprise'));
+
+COMMENT ON TABLE tenant_usage_daily IS 'Daily per-tenant usage rolled up from the Redis usage counters';
+COMMENT ON COLUMN tenant_usage_daily.quantity IS 'Amount consumed during the day; total stored bytes for storage_bytes';
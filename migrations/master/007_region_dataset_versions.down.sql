@@ -0,0 +1,9 @@
+-- Rollback: Region dataset versions
+-- Description: Removes the dataset version history and the child lookup indexes
+
+DROP INDEX IF EXISTS idx_villages_district_id;
+DROP INDEX IF EXISTS idx_districts_city_id;
+DROP INDEX IF EXISTS idx_cities_province_id;
+DROP INDEX IF EXISTS idx_provinces_country_id;
+
+DROP TABLE IF EXISTS region_dataset_versions;
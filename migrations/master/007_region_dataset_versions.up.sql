@@ -0,0 +1,22 @@
+-- Migration: Region dataset versions
+-- Created: Shared Region Master Data
+-- Description: Tracks imported versions of the Kemendagri region code dataset
+
+CREATE TABLE IF NOT EXISTS region_dataset_versions (
+    version VARCHAR(50) PRIMARY KEY,
+    checksum VARCHAR(64) NOT NULL,
+    source VARCHAR(255),
+    provinces INTEGER NOT NULL DEFAULT 0,
+    cities INTEGER NOT NULL DEFAULT 0,
+    districts INTEGER NOT NULL DEFAULT 0,
+    villages INTEGER NOT NULL DEFAULT 0,
+    imported_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Cascading dropdowns list the children of a region
+CREATE INDEX IF NOT EXISTS idx_provinces_country_id ON provinces(country_id);
+CREATE INDEX IF NOT EXISTS idx_cities_province_id ON cities(province_id);
+CREATE INDEX IF NOT EXISTS idx_districts_city_id ON districts(city_id);
+CREATE INDEX IF NOT EXISTS idx_villages_district_id ON villages(district_id);
+
+COMMENT ON TABLE region_dataset_versions IS 'Imported versions of the Kemendagri region dataset with their SHA-256 checksum';
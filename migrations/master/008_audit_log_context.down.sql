@@ -0,0 +1,15 @@
+-- Rollback: Audit log context and append-only audit trail
+-- Description: Removes the append-only triggers and the context columns of
+-- audit_logs. The revoked privileges are not granted back to PUBLIC.
+
+DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs;
+DROP TRIGGER IF EXISTS audit_logs_no_update_delete ON audit_logs;
+DROP FUNCTION IF EXISTS audit_logs_append_only();
+
+DROP INDEX IF EXISTS idx_audit_logs_correlation_id;
+DROP INDEX IF EXISTS idx_audit_logs_user_id;
+DROP INDEX IF EXISTS idx_audit_logs_table_record;
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS trace_id;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS correlation_id;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS changes;
@@ -0,0 +1,36 @@
+-- Migration: Audit log context and append-only audit trail
+-- Created: Shared Database
+-- Description: Records the diff, correlation ID and trace ID of every audited change and rejects any change to existing audit entries
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS changes JSONB;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(100);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS trace_id VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_table_record ON audit_logs(table_name, record_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_correlation_id ON audit_logs(correlation_id);
+
+-- Audit entries are written by the application in the same transaction as
+-- the change; once written they can never be modified or removed
+CREATE OR REPLACE FUNCTION audit_logs_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only: % is not allowed', TG_OP
+        USING ERRCODE = 'insufficient_privilege';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_no_update_delete ON audit_logs;
+CREATE TRIGGER audit_logs_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+
+DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs;
+CREATE TRIGGER audit_logs_no_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();
+
+REVOKE UPDATE, DELETE, TRUNCATE ON audit_logs FROM PUBLIC;
+
+COMMENT ON TABLE audit_logs IS 'Append-only trail of every create, update and delete with the actor, request and trace context';
+COMMENT ON COLUMN audit_logs.changes IS 'Changed columns of an UPDATE as {"column": {"old": ..., "new": ...}}';
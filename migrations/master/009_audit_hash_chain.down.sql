@@ -0,0 +1,16 @@
+-- Rollback: Tamper-evident hash chain of the audit and activity trails
+-- Description: Removes the chain columns of audit_logs and activity_logs
+
+DROP TRIGGER IF EXISTS activity_logs_no_update ON activity_logs;
+DROP FUNCTION IF EXISTS activity_logs_no_update();
+
+DROP INDEX IF EXISTS idx_activity_logs_chain;
+DROP INDEX IF EXISTS idx_audit_logs_chain;
+
+ALTER TABLE activity_logs DROP COLUMN IF EXISTS row_hash;
+ALTER TABLE activity_logs DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE activity_logs DROP COLUMN IF EXISTS chain_seq;
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS row_hash;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS chain_seq;
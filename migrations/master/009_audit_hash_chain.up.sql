@@ -0,0 +1,42 @@
+-- Migration: Tamper-evident hash chain of the audit and activity trails
+-- Created: Shared Database
+-- Description: Chains every audit_logs and activity_logs row per tenant to the previous one with a SHA-256 hash of its content
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS chain_seq BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS row_hash VARCHAR(64);
+
+ALTER TABLE activity_logs ADD COLUMN IF NOT EXISTS chain_seq BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE activity_logs ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64);
+ALTER TABLE activity_logs ADD COLUMN IF NOT EXISTS row_hash VARCHAR(64);
+
+-- Rows written before this migration keep chain_seq 0 and are not part of
+-- any chain. Platform rows without a tenant form a chain of their own.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_chain
+    ON audit_logs(COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::uuid), chain_seq)
+    WHERE chain_seq > 0;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_activity_logs_chain
+    ON activity_logs(tenant_id, chain_seq)
+    WHERE chain_seq > 0;
+
+-- Activity entries may only be removed by the retention job, which deletes
+-- the oldest rows of a chain; they can never be changed
+CREATE OR REPLACE FUNCTION activity_logs_no_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'activity_logs rows cannot be modified'
+        USING ERRCODE = 'insufficient_privilege';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS activity_logs_no_update ON activity_logs;
+CREATE TRIGGER activity_logs_no_update
+    BEFORE UPDATE ON activity_logs
+    FOR EACH ROW EXECUTE FUNCTION activity_logs_no_update();
+
+COMMENT ON COLUMN audit_logs.chain_seq IS 'Position of the row in the hash chain of its tenant, 0 for rows written before chaining';
+COMMENT ON COLUMN audit_logs.prev_hash IS 'row_hash of the previous row in the chain';
+COMMENT ON COLUMN audit_logs.row_hash IS 'SHA-256 of prev_hash, chain_seq and the canonical JSON of the row';
+COMMENT ON COLUMN activity_logs.chain_seq IS 'Position of the row in the hash chain of its tenant, 0 for rows written before chaining';
+COMMENT ON COLUMN activity_logs.prev_hash IS 'row_hash of the previous row in the chain';
+COMMENT ON COLUMN activity_logs.row_hash IS 'SHA-256 of prev_hash, chain_seq and the canonical JSON of the row';
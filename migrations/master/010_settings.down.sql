@@ -0,0 +1,4 @@
+-- Rollback: Settings and feature flags
+-- Description: Removes all setting and feature flag overrides
+
+DROP TABLE IF EXISTS settings;
@@ -0,0 +1,21 @@
+-- Migration: Settings and feature flags
+-- Created: Shared Settings
+-- Description: Overrides of typed settings and feature flags per system, plan, tenant and user
+
+CREATE TABLE IF NOT EXISTS settings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    key VARCHAR(100) NOT NULL,
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('system', 'plan', 'tenant', 'user')),
+    scope_id VARCHAR(100) NOT NULL DEFAULT '',
+    value JSONB NOT NULL,
+    rollout SMALLINT CHECK (rollout BETWEEN 0 AND 100),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_settings_key_scope ON settings(key, scope, scope_id);
+
+COMMENT ON TABLE settings IS 'Setting and feature flag overrides; the most specific of user, tenant, plan and system applies';
+COMMENT ON COLUMN settings.scope_id IS 'Empty for system, the plan code for plan, the tenant or user ID otherwise';
+COMMENT ON COLUMN settings.rollout IS 'Percentage of tenants a flag is enabled for, NULL for all';
@@ -0,0 +1,26 @@
+-- Rollback: Row-level security
+-- Description: Removes the tenant isolation policies. The rexi_app role is
+-- kept because roles are shared by all databases of the cluster.
+
+DO $$
+DECLARE
+    target regclass;
+BEGIN
+    FOR target IN
+        SELECT (quote_ident(schemaname) || '.' || quote_ident(tablename))::regclass
+        FROM pg_policies
+        WHERE schemaname = 'public' AND policyname = 'tenant_isolation'
+    LOOP
+        EXECUTE format('DROP POLICY tenant_isolation ON %s', target);
+        EXECUTE format('ALTER TABLE %s DISABLE ROW LEVEL SECURITY', target);
+    END LOOP;
+END
+$$;
+
+DROP FUNCTION IF EXISTS enable_tenant_isolation(regclass);
+
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE SELECT, INSERT, UPDATE, DELETE ON TABLES FROM rexi_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE USAGE, SELECT ON SEQUENCES FROM rexi_app;
+REVOKE SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public FROM rexi_app;
+REVOKE USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public FROM rexi_app;
+REVOKE USAGE ON SCHEMA public FROM rexi_app;
@@ -0,0 +1,74 @@
+-- Migration: Row-level security
+-- Created: Shared Database
+-- Description: Tenant isolation enforced by PostgreSQL for every table with a tenant_id column
+--
+-- Requests run in transactions that switch to the rexi_app role with
+-- SET LOCAL ROLE and set app.tenant_id with set_config(..., true), so
+-- neither outlives the transaction on a pooled connection. rexi_app is not
+-- a superuser and does not own the tables, so the policies apply to it even
+-- when a query forgets its tenant_id condition. Without app.tenant_id it
+-- sees no rows at all.
+--
+-- Tables created by later migrations opt in with
+--     SELECT enable_tenant_isolation('new_table');
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'rexi_app') THEN
+        CREATE ROLE rexi_app NOLOGIN NOSUPERUSER NOBYPASSRLS;
+    END IF;
+END
+$$;
+
+-- The login role switches to rexi_app inside tenant transactions
+GRANT rexi_app TO CURRENT_USER;
+
+GRANT USAGE ON SCHEMA public TO rexi_app;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO rexi_app;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO rexi_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO rexi_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO rexi_app;
+
+-- enable_tenant_isolation restricts rexi_app to the rows of the tenant in
+-- app.tenant_id, for reads and writes alike
+CREATE OR REPLACE FUNCTION enable_tenant_isolation(target regclass) RETURNS void AS $$
+BEGIN
+    EXECUTE format('ALTER TABLE %s ENABLE ROW LEVEL SECURITY', target);
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %s', target);
+    EXECUTE format(
+        'CREATE POLICY tenant_isolation ON %s TO rexi_app
+            USING (tenant_id = NULLIF(current_setting(''app.tenant_id'', true), '''')::uuid)
+            WITH CHECK (tenant_id = NULLIF(current_setting(''app.tenant_id'', true), '''')::uuid)',
+        target);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Every table and partition with a tenant_id column
+DO $$
+DECLARE
+    target regclass;
+BEGIN
+    FOR target IN
+        SELECT c.oid::regclass
+        FROM pg_class c
+        JOIN pg_namespace n ON n.oid = c.relnamespace
+        JOIN pg_attribute a ON a.attrelid = c.oid AND a.attname = 'tenant_id' AND NOT a.attisdropped
+        WHERE n.nspname = 'public' AND c.relkind IN ('r', 'p')
+    LOOP
+        PERFORM enable_tenant_isolation(target);
+    END LOOP;
+END
+$$;
+
+-- A tenant sees its own tenant record only
+DO $$
+BEGIN
+    IF to_regclass('tenants') IS NOT NULL THEN
+        ALTER TABLE tenants ENABLE ROW LEVEL SECURITY;
+        DROP POLICY IF EXISTS tenant_isolation ON tenants;
+        CREATE POLICY tenant_isolation ON tenants TO rexi_app
+            USING (id = NULLIF(current_setting('app.tenant_id', true), '')::uuid)
+            WITH CHECK (id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+    END IF;
+END
+$$;
@@ -0,0 +1,31 @@
+-- Migration: Transactional outbox
+-- Created: Shared Outbox
+-- Description: Domain events written in the transaction of the change they
+-- describe and relayed to the message bus afterwards
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID REFERENCES tenants(id) ON DELETE CASCADE,
+    topic VARCHAR(100) NOT NULL,
+    event_key VARCHAR(255) NOT NULL DEFAULT '',
+    aggregate_type VARCHAR(100) NOT NULL DEFAULT '',
+    aggregate_id VARCHAR(100) NOT NULL DEFAULT '',
+    payload JSONB NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'parked')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+-- The relay claims due pending events oldest first
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(next_attempt_at, created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_outbox_events_parked ON outbox_events(created_at) WHERE status = 'parked';
+
+-- Tenant transactions may only write events of their own tenant
+SELECT enable_tenant_isolation('outbox_events');
+
+COMMENT ON TABLE outbox_events IS 'Domain events awaiting delivery to the message bus; consumers deduplicate by id';
+COMMENT ON COLUMN outbox_events.status IS 'pending until published, delivered after, parked after too many failed attempts';
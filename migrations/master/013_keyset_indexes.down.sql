@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_activity_logs_tenant_action_id;
+DROP INDEX IF EXISTS idx_activity_logs_tenant_created_id;
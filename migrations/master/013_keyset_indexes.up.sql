@@ -0,0 +1,9 @@
+-- Migration: Keyset pagination indexes
+-- Created: Shared Pagination
+-- Description: Indexes matching the keyset order (sort column, id) of large
+-- lists, so each page is an index range scan however deep it is
+
+CREATE INDEX IF NOT EXISTS idx_activity_logs_tenant_created_id
+    ON activity_logs (tenant_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_activity_logs_tenant_action_id
+    ON activity_logs (tenant_id, action, id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_inventory_movements_tenant_created_id;
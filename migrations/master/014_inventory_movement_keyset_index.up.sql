@@ -0,0 +1,8 @@
+-- Migration: Keyset pagination index of inventory movements
+-- Created: Shared Pagination
+-- Description: Index matching the default keyset order (created_at, id) of
+-- the inventory movement list, so each page is an index range scan however
+-- deep it is
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_tenant_created_id
+    ON inventory_movements (tenant_id, created_at DESC, id DESC);
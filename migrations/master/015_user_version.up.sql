@@ -0,0 +1,8 @@
+-- Migration: User profile version
+-- Created: Authentication Service
+-- Description: Version of optimistic locking, served as the ETag of the
+-- profile and checked against If-Match on update
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN users.version IS 'Incremented by every update; updates based on an older version are rejected';
@@ -0,0 +1,28 @@
+-- Rollback: Tenant tables
+-- Description: Removes the tables of a dedicated tenant schema or database
+
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS numbering_sequences;
+DROP TABLE IF EXISTS journal_entry_lines;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS invoice_items;
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS purchase_order_items;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TABLE IF EXISTS sales_order_items;
+DROP TABLE IF EXISTS sales_orders;
+DROP TABLE IF EXISTS inventory_stocks;
+DROP TABLE IF EXISTS inventory_movements;
+DROP TABLE IF EXISTS warehouses;
+DROP TABLE IF EXISTS suppliers;
+DROP TABLE IF EXISTS customers;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS product_categories;
+DROP TABLE IF EXISTS chart_of_accounts;
+
+DROP FUNCTION IF EXISTS audit_logs_append_only();
+DROP FUNCTION IF EXISTS update_updated_at_column();
+
+DROP TYPE IF EXISTS invoice_status;
+DROP TYPE IF EXISTS payment_method;
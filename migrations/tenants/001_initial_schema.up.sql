@@ -0,0 +1,388 @@
+-- Migration: Tenant tables
+-- Created: Shared Database
+-- Description: Builds the tables of a dedicated tenant schema or database, mirroring the tenant tables of the master migrations (001_initial_schema, 005_tenant_lifecycle, 008_audit_log_context, 009_audit_hash_chain and 014_inventory_movement_keyset_index)
+
+-- The tenant cannot see the master tables, so tenant_id, users and the
+-- region references carry no foreign key here; tenant_id is kept so the
+-- same models and queries serve shared and dedicated tenants.
+-- gen_random_uuid() is built in, unlike uuid_generate_v4() which lives in
+-- the public schema of the master.
+
+CREATE TYPE payment_method AS ENUM ('tunai', 'transfer', 'kartu_kredit', 'cek', 'giro', 'ewallet', 'lainnya');
+CREATE TYPE invoice_status AS ENUM ('draft', 'sent', 'paid', 'overdue', 'cancelled');
+
+-- Chart of Accounts (SAK Indonesia compliant)
+CREATE TABLE chart_of_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    code VARCHAR(20) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    description TEXT,
+    account_type VARCHAR(50) NOT NULL, -- 'asset', 'liability', 'equity', 'revenue', 'expense'
+    parent_id UUID REFERENCES chart_of_accounts(id),
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant_id, code)
+);
+
+-- Products/Categories
+CREATE TABLE product_categories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    description TEXT,
+    parent_id UUID REFERENCES product_categories(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant_id, name)
+);
+
+CREATE TABLE products (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    sku VARCHAR(100) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    description TEXT,
+    category_id UUID REFERENCES product_categories(id),
+    unit VARCHAR(50) NOT NULL,
+    purchase_price DECIMAL(19,4) DEFAULT 0,
+    selling_price DECIMAL(19,4) DEFAULT 0,
+    tax_rate DECIMAL(5,4) DEFAULT 0.11, -- 11% PPN
+    min_stock INTEGER DEFAULT 0,
+    max_stock INTEGER,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant_id, sku)
+);
+
+-- Customers
+CREATE TABLE customers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    email VARCHAR(255),
+    phone VARCHAR(50),
+    address TEXT,
+    country_id UUID,
+    province_id UUID,
+    city_id UUID,
+    district_id UUID,
+    village_id UUID,
+    postal_code VARCHAR(10),
+    tax_number VARCHAR(50),
+    credit_limit DECIMAL(19,2) DEFAULT 0,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant_id, code)
+);
+
+-- Suppliers
+CREATE TABLE suppliers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    email VARCHAR(255),
+    phone VARCHAR(50),
+    address TEXT,
+    country_id UUID,
+    province_id UUID,
+    city_id UUID,
+    district_id UUID,
+    village_id UUID,
+    postal_code VARCHAR(10),
+    tax_number VARCHAR(50),
+    payment_terms INTEGER DEFAULT 30, -- days
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant_id, code)
+);
+
+-- Inventory
+CREATE TABLE warehouses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    address TEXT,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant_id, code)
+);
+
+CREATE TABLE inventory_movements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    product_id UUID REFERENCES products(id),
+    warehouse_id UUID REFERENCES warehouses(id),
+    movement_type VARCHAR(20) NOT NULL, -- 'in', 'out', 'adjustment'
+    quantity INTEGER NOT NULL,
+    unit_cost DECIMAL(19,4),
+    reference_type VARCHAR(50), -- 'purchase', 'sale', 'adjustment'
+    reference_id UUID,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by UUID
+);
+
+CREATE TABLE inventory_stocks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    product_id UUID REFERENCES products(id),
+    warehouse_id UUID REFERENCES warehouses(id),
+    quantity INTEGER DEFAULT 0,
+    last_updated TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant_id, product_id, warehouse_id)
+);
+
+-- Sales and Purchases
+CREATE TABLE sales_orders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    order_number VARCHAR(50) NOT NULL,
+    customer_id UUID REFERENCES customers(id),
+    order_date DATE NOT NULL,
+    delivery_date DATE,
+    subtotal DECIMAL(19,2) NOT NULL,
+    tax_amount DECIMAL(19,2) DEFAULT 0,
+    discount_amount DECIMAL(19,2) DEFAULT 0,
+    total_amount DECIMAL(19,2) NOT NULL,
+    status VARCHAR(20) DEFAULT 'draft', -- 'draft', 'confirmed', 'delivered', 'invoiced', 'cancelled'
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by UUID,
+    UNIQUE(tenant_id, order_number)
+);
+
+CREATE TABLE sales_order_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sales_order_id UUID REFERENCES sales_orders(id) ON DELETE CASCADE,
+    product_id UUID REFERENCES products(id),
+    quantity DECIMAL(19,4) NOT NULL,
+    unit_price DECIMAL(19,4) NOT NULL,
+    discount_percentage DECIMAL(5,2) DEFAULT 0,
+    tax_percentage DECIMAL(5,4) DEFAULT 0.11,
+    line_total DECIMAL(19,2) NOT NULL
+);
+
+CREATE TABLE purchase_orders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    order_number VARCHAR(50) NOT NULL,
+    supplier_id UUID REFERENCES suppliers(id),
+    order_date DATE NOT NULL,
+    expected_date DATE,
+    subtotal DECIMAL(19,2) NOT NULL,
+    tax_amount DECIMAL(19,2) DEFAULT 0,
+    discount_amount DECIMAL(19,2) DEFAULT 0,
+    total_amount DECIMAL(19,2) NOT NULL,
+    status VARCHAR(20) DEFAULT 'draft', -- 'draft', 'confirmed', 'received', 'paid', 'cancelled'
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by UUID,
+    UNIQUE(tenant_id, order_number)
+);
+
+CREATE TABLE purchase_order_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    purchase_order_id UUID REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    product_id UUID REFERENCES products(id),
+    quantity DECIMAL(19,4) NOT NULL,
+    unit_price DECIMAL(19,4) NOT NULL,
+    discount_percentage DECIMAL(5,2) DEFAULT 0,
+    tax_percentage DECIMAL(5,4) DEFAULT 0.11,
+    line_total DECIMAL(19,2) NOT NULL
+);
+
+-- Invoices
+CREATE TABLE invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    invoice_number VARCHAR(50) NOT NULL,
+    invoice_type VARCHAR(20) NOT NULL, -- 'sales', 'purchase'
+    reference_id UUID, -- sales_order_id or purchase_order_id
+    customer_id UUID REFERENCES customers(id),
+    supplier_id UUID REFERENCES suppliers(id),
+    invoice_date DATE NOT NULL,
+    due_date DATE,
+    subtotal DECIMAL(19,2) NOT NULL,
+    tax_amount DECIMAL(19,2) DEFAULT 0,
+    discount_amount DECIMAL(19,2) DEFAULT 0,
+    total_amount DECIMAL(19,2) NOT NULL,
+    paid_amount DECIMAL(19,2) DEFAULT 0,
+    status invoice_status DEFAULT 'draft',
+    tax_number VARCHAR(50), -- For e-faktur integration
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by UUID,
+    UNIQUE(tenant_id, invoice_number)
+);
+
+CREATE TABLE invoice_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    invoice_id UUID REFERENCES invoices(id) ON DELETE CASCADE,
+    product_id UUID REFERENCES products(id),
+    description TEXT,
+    quantity DECIMAL(19,4) NOT NULL,
+    unit_price DECIMAL(19,4) NOT NULL,
+    discount_percentage DECIMAL(5,2) DEFAULT 0,
+    tax_percentage DECIMAL(5,4) DEFAULT 0.11,
+    line_total DECIMAL(19,2) NOT NULL
+);
+
+-- Payments
+CREATE TABLE payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    payment_number VARCHAR(50) NOT NULL,
+    payment_type VARCHAR(20) NOT NULL, -- 'receivable', 'payable'
+    invoice_id UUID REFERENCES invoices(id),
+    customer_id UUID REFERENCES customers(id),
+    supplier_id UUID REFERENCES suppliers(id),
+    payment_date DATE NOT NULL,
+    amount DECIMAL(19,2) NOT NULL,
+    payment_method payment_method NOT NULL,
+    bank_name VARCHAR(100),
+    account_number VARCHAR(50),
+    reference_number VARCHAR(100),
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by UUID,
+    UNIQUE(tenant_id, payment_number)
+);
+
+-- Accounting (Journal Entries)
+CREATE TABLE journal_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    entry_number VARCHAR(50) NOT NULL,
+    entry_date DATE NOT NULL,
+    description TEXT,
+    reference_type VARCHAR(50), -- 'invoice', 'payment', 'adjustment'
+    reference_id UUID,
+    total_debit DECIMAL(19,2) NOT NULL,
+    total_credit DECIMAL(19,2) NOT NULL,
+    is_posted BOOLEAN DEFAULT false,
+    posted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by UUID,
+    UNIQUE(tenant_id, entry_number)
+);
+
+CREATE TABLE journal_entry_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    journal_entry_id UUID REFERENCES journal_entries(id) ON DELETE CASCADE,
+    account_id UUID REFERENCES chart_of_accounts(id),
+    description TEXT,
+    debit_amount DECIMAL(19,2) DEFAULT 0,
+    credit_amount DECIMAL(19,2) DEFAULT 0
+);
+
+-- Document numbering sequences (e.g. INV/2025/000001)
+CREATE TABLE numbering_sequences (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    document_type VARCHAR(50) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    next_number BIGINT NOT NULL DEFAULT 1,
+    padding INTEGER NOT NULL DEFAULT 6,
+    reset_period VARCHAR(20) NOT NULL DEFAULT 'yearly', -- 'never', 'yearly' or 'monthly'
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant_id, document_type)
+);
+
+-- Audit Trail
+CREATE TABLE audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID,
+    user_id UUID,
+    table_name VARCHAR(100) NOT NULL,
+    record_id UUID,
+    action VARCHAR(20) NOT NULL, -- 'INSERT', 'UPDATE', 'DELETE'
+    old_values JSONB,
+    new_values JSONB,
+    changes JSONB,
+    correlation_id VARCHAR(100),
+    trace_id VARCHAR(64),
+    chain_seq BIGINT NOT NULL DEFAULT 0,
+    prev_hash VARCHAR(64),
+    row_hash VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    ip_address INET,
+    user_agent TEXT
+);
+
+-- Indexes for performance
+CREATE INDEX idx_products_tenant_sku ON products(tenant_id, sku);
+CREATE INDEX idx_products_tenant_active ON products(tenant_id, is_active);
+CREATE INDEX idx_customers_tenant_code ON customers(tenant_id, code);
+CREATE INDEX idx_suppliers_tenant_code ON suppliers(tenant_id, code);
+CREATE INDEX idx_invoices_tenant_number ON invoices(tenant_id, invoice_number);
+CREATE INDEX idx_invoices_tenant_type ON invoices(tenant_id, invoice_type);
+CREATE INDEX idx_sales_orders_tenant_number ON sales_orders(tenant_id, order_number);
+CREATE INDEX idx_purchase_orders_tenant_number ON purchase_orders(tenant_id, order_number);
+CREATE INDEX idx_payments_tenant_number ON payments(tenant_id, payment_number);
+CREATE INDEX idx_journal_entries_tenant_number ON journal_entries(tenant_id, entry_number);
+CREATE INDEX idx_inventory_stocks_tenant_product_warehouse ON inventory_stocks(tenant_id, product_id, warehouse_id);
+CREATE INDEX idx_inventory_movements_tenant_created_id ON inventory_movements(tenant_id, created_at DESC, id DESC);
+CREATE INDEX idx_numbering_sequences_tenant_id ON numbering_sequences(tenant_id);
+CREATE INDEX idx_audit_logs_tenant_table ON audit_logs(tenant_id, table_name);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
+CREATE INDEX idx_audit_logs_table_record ON audit_logs(table_name, record_id);
+CREATE INDEX idx_audit_logs_user_id ON audit_logs(user_id);
+CREATE INDEX idx_audit_logs_correlation_id ON audit_logs(correlation_id);
+CREATE UNIQUE INDEX idx_audit_logs_chain
+    ON audit_logs(COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'::uuid), chain_seq)
+    WHERE chain_seq > 0;
+
+-- Triggers for updated_at timestamps
+CREATE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER update_chart_of_accounts_updated_at BEFORE UPDATE ON chart_of_accounts FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_product_categories_updated_at BEFORE UPDATE ON product_categories FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_products_updated_at BEFORE UPDATE ON products FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_customers_updated_at BEFORE UPDATE ON customers FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_suppliers_updated_at BEFORE UPDATE ON suppliers FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_warehouses_updated_at BEFORE UPDATE ON warehouses FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_sales_orders_updated_at BEFORE UPDATE ON sales_orders FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_purchase_orders_updated_at BEFORE UPDATE ON purchase_orders FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_invoices_updated_at BEFORE UPDATE ON invoices FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_numbering_sequences_updated_at BEFORE UPDATE ON numbering_sequences FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Audit entries can never be modified or removed once written
+CREATE FUNCTION audit_logs_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only: % is not allowed', TG_OP
+        USING ERRCODE = 'insufficient_privilege';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+
+CREATE TRIGGER audit_logs_no_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();
+
+REVOKE UPDATE, DELETE, TRUNCATE ON audit_logs FROM PUBLIC;
+
+COMMENT ON TABLE numbering_sequences IS 'Per-tenant document numbering sequences seeded at tenant provisioning';
+COMMENT ON TABLE audit_logs IS 'Append-only trail of every create, update and delete with the actor, request and trace context';